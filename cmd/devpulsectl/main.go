@@ -0,0 +1,462 @@
+// Command devpulsectl runs operations tasks straight against the database and Redis, so they need
+// neither hand-written SQL nor a running server.
+//
+//	devpulsectl create-admin -username u -email e   create an active admin account
+//	devpulsectl reset-password -user u              set a new password and sign the user out
+//	devpulsectl grant-role -user u -role r          move a user to another role
+//	devpulsectl flush-cache -user u                 drop every cache entry of a user
+//	devpulsectl reindex [-batch n]                  rebuild the search index
+//	devpulsectl replay-outbox [-kind k] [-id id]    requeue outbox events that were given up
+//	devpulsectl migrate up|down [-steps n]|status   apply, roll back or list schema migrations
+//
+// Users are named by username or email. Passwords are read from the first line of stdin, so they
+// stay out of the shell history and the process list. Changes to users are recorded in the audit
+// log with the nil UUID as the actor.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/config"
+	"github.com/mnuddindev/devpulse/internal/db"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// cliAgent is the user agent security events recorded by devpulsectl carry.
+const cliAgent = "devpulsectl"
+
+// env holds the connections a command works with.
+type env struct {
+	cfg   *config.Config
+	db    *gorm.DB
+	redis *storage.RedisClient
+}
+
+// command is a devpulsectl subcommand. Commands that leave Redis alone run without it.
+type command struct {
+	usage string
+	redis bool
+	run   func(ctx context.Context, e *env, args []string) error
+}
+
+var commands = map[string]command{
+	"create-admin":   {usage: "create-admin -username u -email e", redis: true, run: createAdmin},
+	"reset-password": {usage: "reset-password -user u", redis: true, run: resetPassword},
+	"grant-role":     {usage: "grant-role -user u -role r", redis: true, run: grantRole},
+	"flush-cache":    {usage: "flush-cache -user u", redis: true, run: flushCache},
+	"reindex":        {usage: "reindex [-batch n]", run: reindex},
+	"replay-outbox":  {usage: "replay-outbox [-kind k] [-id id]", run: replayOutbox},
+	"migrate":        {usage: "migrate up|down [-steps n]|status", run: migrate},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, cmd, os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "usage:")
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  devpulsectl "+commands[name].usage)
+	}
+	os.Exit(2)
+}
+
+// run connects to what cmd needs and runs it.
+func run(ctx context.Context, cmd command, args []string) error {
+	cfg := config.LoadConfig()
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC", cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort)
+	gdb, err := db.Open(dsn)
+	if err != nil {
+		return err
+	}
+	e := &env{cfg: cfg, db: gdb}
+
+	if cmd.redis {
+		log, err := logger.NewLogger(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to initialize logger: %v", err)
+		}
+		defer log.Close()
+		e.redis, err = storage.NewRedis(ctx, storage.Config{
+			Mode:             cfg.RedisMode,
+			Addrs:            storage.ParseAddrs(cfg.RedisAddr),
+			MasterName:       cfg.RedisMasterName,
+			Password:         cfg.RedisPassword,
+			SentinelPassword: cfg.RedisSentinelPassword,
+			Logger:           log,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize Redis: %v", err)
+		}
+		defer e.redis.Close(log)
+	}
+	return cmd.run(ctx, e, args)
+}
+
+// parseFlags parses args into flags and fails when one of the required flags is empty.
+func parseFlags(flags *flag.FlagSet, args []string, required ...string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	for _, name := range required {
+		if flags.Lookup(name).Value.String() == "" {
+			return fmt.Errorf("-%s is required", name)
+		}
+	}
+	return nil
+}
+
+// findUser looks a user up by username or email.
+func findUser(ctx context.Context, e *env, name string) (*models.User, error) {
+	u, err := models.GetUserBy(ctx, e.redis, e.db, "username = ? OR email = ?", []interface{}{name, name})
+	if err != nil {
+		return nil, fmt.Errorf("user %q: %v", name, err)
+	}
+	return u, nil
+}
+
+// readPassword reads a password from the first line of stdin, prompting when stdin is a terminal,
+// and checks it against the rules passwords set through the API follow.
+func readPassword() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "New password: ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no password on stdin")
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if utils.ContainsInvalidChars(password) {
+		return "", errors.New("password contains invalid characters")
+	}
+	if !utils.IsStrongPassword(password) {
+		return "", errors.New("password needs 8 characters with upper and lower case letters, a digit and one of !@#$%^&*")
+	}
+	return password, nil
+}
+
+// audit records a change made from the command line, reporting a failure without undoing it.
+func audit(ctx context.Context, e *env, action string, userID uuid.UUID, before, after map[string]interface{}) {
+	if err := models.RecordAudit(ctx, e.db, uuid.Nil, action, models.AuditTargetUser, userID, before, after, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to record audit entry: %v\n", err)
+	}
+}
+
+// revokeSessions signs a user out everywhere, recording it like the API does.
+func revokeSessions(ctx context.Context, e *env, userID uuid.UUID) error {
+	if err := models.RevokeSessions(ctx, e.redis, e.db, userID); err != nil {
+		return err
+	}
+	if err := models.RecordSecurityEvent(ctx, e.db, userID, models.EventSessionsRevoked, "", cliAgent); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to record session revocation: %v\n", err)
+	}
+	return nil
+}
+
+// createAdmin creates an active, verified account holding the admin role.
+func createAdmin(ctx context.Context, e *env, args []string) error {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	username := flags.String("username", "", "username of the new admin")
+	email := flags.String("email", "", "email of the new admin")
+	if err := parseFlags(flags, args, "username", "email"); err != nil {
+		return err
+	}
+	// The rules registration applies
+	account := struct {
+		Username string `json:"username" validate:"required,min=3,max=50,alphanum"`
+		Email    string `json:"email" validate:"required,email,max=100"`
+	}{*username, *email}
+	if res := utils.NewValidator().Validate(account); res != nil {
+		for _, v := range res.Errors {
+			fmt.Fprintln(os.Stderr, v.Msg)
+		}
+		return errors.New("invalid account details")
+	}
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+	role, err := models.GetRoleBy(ctx, e.redis, e.db, "name = ?", []interface{}{models.RoleAdmin})
+	if err != nil {
+		return fmt.Errorf("admin role: %v", err)
+	}
+
+	var u *models.User
+	err = e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		u, err = models.NewUser(ctx, e.redis, tx, *username, *email, string(hashed), "",
+			models.WithRoleID(role.ID), models.WithIsActive(true), models.WithPasswordChangedAt(time.Now()))
+		if err != nil {
+			return err
+		}
+		return models.EnqueueOutbox(ctx, tx, models.OutboxUserUpdated, models.UserChangedEvent{UserID: u.ID})
+	})
+	if err != nil {
+		return err
+	}
+	cache.ForgetMissing(ctx, e.redis, cache.MissingUsers)
+	audit(ctx, e, models.AuditUserRoleAssign, u.ID, nil, map[string]interface{}{"role_id": role.ID, "role": role.Name})
+
+	fmt.Printf("Created admin %s (%s)\n", u.Username, u.ID)
+	return nil
+}
+
+// resetPassword sets a user's password, drops pending reset links and signs them out everywhere.
+func resetPassword(ctx context.Context, e *env, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	name := flags.String("user", "", "username or email")
+	if err := parseFlags(flags, args, "user"); err != nil {
+		return err
+	}
+	u, err := findUser(ctx, e, *name)
+	if err != nil {
+		return err
+	}
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	if utils.IsPasswordReused(u.PreviousPasswords, password) {
+		return errors.New("new password cannot match previous passwords")
+	}
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	_, err = models.UpdateUser(ctx, e.redis, e.db, u.ID,
+		models.WithPassword(string(hashed)),
+		models.WithPasswordless(false),
+		models.WithPreviousPasswords(utils.UpdatePreviousPasswords(u.PreviousPasswords, u.Password)),
+		models.WithPasswordChangedAt(time.Now()),
+	)
+	if err != nil {
+		return err
+	}
+	if token, err := e.redis.Get(ctx, "reset_token_user:"+u.ID.String()).Result(); err == nil {
+		e.redis.Del(ctx, "reset_token:"+token, "reset_token_user:"+u.ID.String())
+	}
+	audit(ctx, e, models.AuditPasswordReset, u.ID, nil, map[string]interface{}{"password_changed_at": time.Now().UTC()})
+	if err := models.RecordSecurityEvent(ctx, e.db, u.ID, models.EventPasswordReset, "", cliAgent); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to record password reset: %v\n", err)
+	}
+	if err := revokeSessions(ctx, e, u.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Password of %s reset and sessions revoked\n", u.Username)
+	return nil
+}
+
+// grantRole moves a user to a role, signing them out when LOGOUT_ON_ROLE_CHANGE asks for it.
+func grantRole(ctx context.Context, e *env, args []string) error {
+	flags := flag.NewFlagSet("grant-role", flag.ExitOnError)
+	name := flags.String("user", "", "username or email")
+	roleName := flags.String("role", "", "name of the role")
+	if err := parseFlags(flags, args, "user", "role"); err != nil {
+		return err
+	}
+	u, err := findUser(ctx, e, *name)
+	if err != nil {
+		return err
+	}
+	role, err := models.GetRoleBy(ctx, e.redis, e.db, "name = ?", []interface{}{*roleName})
+	if err != nil {
+		return fmt.Errorf("role %q: %v", *roleName, err)
+	}
+
+	prev, next, err := models.AssignRole(ctx, e.redis, e.db, u.ID, role.ID)
+	if err != nil {
+		return err
+	}
+	if prev.ID == next.ID {
+		fmt.Printf("%s already holds %s\n", u.Username, next.Name)
+		return nil
+	}
+	audit(ctx, e, models.AuditUserRoleAssign, u.ID,
+		map[string]interface{}{"role_id": prev.ID, "role": prev.Name},
+		map[string]interface{}{"role_id": next.ID, "role": next.Name})
+	if e.cfg.LogoutOnRoleChange == "true" {
+		if err := revokeSessions(ctx, e, u.ID); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%s moved from %s to %s\n", u.Username, prev.Name, next.Name)
+	return nil
+}
+
+// flushCache drops the cached user record, public profile, feed, suggestions, notifications and
+// everything else tagged with the user.
+func flushCache(ctx context.Context, e *env, args []string) error {
+	flags := flag.NewFlagSet("flush-cache", flag.ExitOnError)
+	name := flags.String("user", "", "username or email")
+	if err := parseFlags(flags, args, "user"); err != nil {
+		return err
+	}
+	u, err := findUser(ctx, e, *name)
+	if err != nil {
+		return err
+	}
+
+	if err := cache.InvalidateUser(ctx, e.redis, u.ID); err != nil {
+		return err
+	}
+	if err := cache.Invalidate(ctx, e.redis, cache.NotificationsTag(u.ID)); err != nil {
+		return err
+	}
+	if err := cache.Delete(ctx, e.redis, cache.PublicUserKey(u.Username), cache.FeedKey(u.ID), cache.SuggestionsKey(u.ID)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Cache of %s flushed\n", u.Username)
+	return nil
+}
+
+// reindex rebuilds the search index, printing a line per batch.
+func reindex(ctx context.Context, e *env, args []string) error {
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	batch := flags.Int("batch", 500, "documents indexed per batch")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *batch < 1 {
+		return errors.New("batch must be positive")
+	}
+	if pending, err := db.PendingMigrations(ctx, e.db); err != nil {
+		return err
+	} else if len(pending) > 0 {
+		return fmt.Errorf("%d pending migrations, run devpulsectl migrate up first", len(pending))
+	}
+
+	started := time.Now()
+	err := models.ReindexSearch(ctx, e.db, *batch, func(p models.ReindexProgress) {
+		percent := 100.0
+		if p.Total > 0 {
+			percent = float64(p.Done) * 100 / float64(p.Total)
+		}
+		fmt.Printf("%-5s %d/%d (%.1f%%) %s\n", p.Kind, p.Done, p.Total, percent, time.Since(started).Round(time.Second))
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Search index rebuilt in %s\n", time.Since(started).Round(time.Second))
+	return nil
+}
+
+// replayOutbox requeues outbox events that ran out of attempts; the server's outbox worker then
+// handles them again.
+func replayOutbox(ctx context.Context, e *env, args []string) error {
+	flags := flag.NewFlagSet("replay-outbox", flag.ExitOnError)
+	kind := flags.String("kind", "", "only requeue events of this kind, such as user.registered")
+	rawID := flags.String("id", "", "only requeue the event with this id")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	var id uuid.UUID
+	if *rawID != "" {
+		var err error
+		if id, err = uuid.Parse(*rawID); err != nil {
+			return fmt.Errorf("invalid event id %q", *rawID)
+		}
+	}
+
+	n, err := models.RequeueOutbox(ctx, e.db, *kind, id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Requeued %d outbox events\n", n)
+	return nil
+}
+
+// migrate applies pending migrations, rolls back applied ones or lists them all. Production servers
+// refuse to start on pending migrations, so a release runs migrate up before it rolls out.
+func migrate(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate needs up, down or status")
+	}
+	action, args := args[0], args[1:]
+
+	log, err := logger.NewLogger(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize logger: %v", err)
+	}
+	defer log.Close()
+
+	switch action {
+	case "up":
+		if err := parseFlags(flag.NewFlagSet("migrate up", flag.ExitOnError), args); err != nil {
+			return err
+		}
+		if err := db.Migrate(ctx, e.db, log); err != nil {
+			return err
+		}
+		fmt.Println("Schema is up to date")
+		return nil
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		steps := flags.Int("steps", 1, "how many applied migrations to roll back, newest first")
+		if err := parseFlags(flags, args); err != nil {
+			return err
+		}
+		if *steps < 1 {
+			return errors.New("steps must be positive")
+		}
+		return db.Rollback(ctx, e.db, log, *steps)
+	case "status":
+		states, err := db.MigrationStates(ctx, e.db)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			reversible := ""
+			if !s.Reversible {
+				reversible = " (irreversible)"
+			}
+			fmt.Printf("%-45s %s%s\n", s.ID, applied, reversible)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate action %q, want up, down or status", action)
+	}
+}
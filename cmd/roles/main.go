@@ -0,0 +1,123 @@
+// Command roles compares the roles and permissions in the database with the policy declared in
+// code, and applies it.
+//
+//	roles diff    print the drift and pending migrations; exits 1 when there is any
+//	roles apply   run pending migrations and apply the policy, as the server does at startup
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mnuddindev/devpulse/internal/config"
+	"github.com/mnuddindev/devpulse/internal/db"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+)
+
+func main() {
+	if len(os.Args) != 2 || (os.Args[1] != "diff" && os.Args[1] != "apply") {
+		fmt.Fprintln(os.Stderr, "usage: roles diff|apply")
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC", cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort)
+
+	if os.Args[1] == "apply" {
+		if err := apply(ctx, cfg, dsn); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("Role policy applied")
+		return
+	}
+
+	drifted, err := diff(ctx, dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if drifted {
+		os.Exit(1)
+	}
+}
+
+// diff prints how the database differs from the declared policy and reports whether it does.
+func diff(ctx context.Context, dsn string) (bool, error) {
+	gdb, err := db.Open(dsn)
+	if err != nil {
+		return false, err
+	}
+	pending, err := db.PendingMigrations(ctx, gdb)
+	if err != nil {
+		return false, err
+	}
+	d, err := models.DiffRolePolicy(ctx, gdb)
+	if err != nil {
+		return false, err
+	}
+
+	for _, id := range pending {
+		fmt.Printf("pending migration %s\n", id)
+	}
+	for _, name := range d.MissingPermissions {
+		fmt.Printf("+ permission %s\n", name)
+	}
+	for _, r := range d.Roles {
+		if r.Missing {
+			fmt.Printf("+ role %s\n", r.Role)
+		} else {
+			fmt.Printf("~ role %s\n", r.Role)
+		}
+		for _, name := range r.Added {
+			fmt.Printf("    + %s\n", name)
+		}
+		for _, name := range r.Removed {
+			fmt.Printf("    - %s\n", name)
+		}
+	}
+	if len(d.UndeclaredRoles) > 0 {
+		fmt.Printf("undeclared roles, left as they are: %s\n", strings.Join(d.UndeclaredRoles, ", "))
+	}
+	if len(d.UndeclaredPermissions) > 0 {
+		fmt.Printf("undeclared permissions, left as they are: %s\n", strings.Join(d.UndeclaredPermissions, ", "))
+	}
+
+	drifted := d.Drifted() || len(pending) > 0
+	if !drifted {
+		fmt.Println("Database matches the declared role policy")
+	}
+	return drifted, nil
+}
+
+// apply migrates the database and applies the policy, dropping cached role data.
+func apply(ctx context.Context, cfg *config.Config, dsn string) error {
+	log, err := logger.NewLogger(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize logger: %v", err)
+	}
+	defer log.Close()
+
+	rclient, err := storage.NewRedis(ctx, storage.Config{
+		Mode:             cfg.RedisMode,
+		Addrs:            storage.ParseAddrs(cfg.RedisAddr),
+		MasterName:       cfg.RedisMasterName,
+		Password:         cfg.RedisPassword,
+		SentinelPassword: cfg.RedisSentinelPassword,
+		Logger:           log,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize Redis: %v", err)
+	}
+	defer rclient.Close(log)
+
+	if _, err := db.NewDB(ctx, dsn, rclient, log, true); err != nil {
+		return err
+	}
+	return db.CloseDB()
+}
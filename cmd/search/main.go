@@ -0,0 +1,69 @@
+// Command search maintains the search index, which the server otherwise keeps up to date one
+// change at a time.
+//
+//	search reindex [-batch n]   rebuild the whole index, reporting progress as it goes
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/mnuddindev/devpulse/internal/config"
+	"github.com/mnuddindev/devpulse/internal/db"
+	"github.com/mnuddindev/devpulse/internal/models"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "reindex" {
+		fmt.Fprintln(os.Stderr, "usage: search reindex [-batch n]")
+		os.Exit(2)
+	}
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	batch := flags.Int("batch", 500, "documents indexed per batch")
+	flags.Parse(os.Args[2:])
+	if *batch < 1 {
+		fmt.Fprintln(os.Stderr, "batch must be positive")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	cfg := config.LoadConfig()
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC", cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort)
+
+	if err := reindex(ctx, dsn, *batch); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// reindex rebuilds the search index, printing a line per batch.
+func reindex(ctx context.Context, dsn string, batch int) error {
+	gdb, err := db.Open(dsn)
+	if err != nil {
+		return err
+	}
+	if pending, err := db.PendingMigrations(ctx, gdb); err != nil {
+		return err
+	} else if len(pending) > 0 {
+		return fmt.Errorf("%d pending migrations, run devpulsectl migrate up first", len(pending))
+	}
+
+	started := time.Now()
+	err = models.ReindexSearch(ctx, gdb, batch, func(p models.ReindexProgress) {
+		percent := 100.0
+		if p.Total > 0 {
+			percent = float64(p.Done) * 100 / float64(p.Total)
+		}
+		fmt.Printf("%-5s %d/%d (%.1f%%) %s\n", p.Kind, p.Done, p.Total, percent, time.Since(started).Round(time.Second))
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Search index rebuilt in %s\n", time.Since(started).Round(time.Second))
+	return nil
+}
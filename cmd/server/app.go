@@ -11,7 +11,9 @@ import (
 	routes "github.com/mnuddindev/devpulse/internal/api"
 	"github.com/mnuddindev/devpulse/internal/config"
 	"github.com/mnuddindev/devpulse/internal/db"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
 	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/metrics"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 )
@@ -30,11 +32,33 @@ func main() {
 		}
 	}()
 
-	rclient, err := storage.NewRedis(ctx, cfg.RedisAddr, "")
+	ttlPolicy, err := storage.ParseTTLPolicy(cfg.CacheTTLs, cfg.TTLJitter)
+	if err != nil {
+		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid cache TTL configuration")
+		panic(err)
+	}
+
+	writeMode, err := storage.ParseWriteMode(cfg.CacheWrite)
+	if err != nil {
+		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid cache write mode")
+		panic(err)
+	}
+
+	rclient, err := storage.NewRedis(ctx, storage.Config{
+		Mode:             cfg.RedisMode,
+		Addrs:            storage.ParseAddrs(cfg.RedisAddr),
+		MasterName:       cfg.RedisMasterName,
+		Password:         cfg.RedisPassword,
+		SentinelPassword: cfg.RedisSentinelPassword,
+		Logger:           log,
+		TTLPolicy:        ttlPolicy,
+	})
 	if err != nil {
 		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to initialize Redis")
 		panic(err)
 	}
+	rclient.AddHook(metrics.RedisHook{})
+	rclient.SetWriteMode(writeMode)
 	defer func() {
 		if err != nil {
 			rclient.Close(log)
@@ -42,7 +66,10 @@ func main() {
 	}()
 
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC", cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort)
-	DB, err := db.NewDB(ctx, dsn, rclient, log)
+	// Production refuses to start on pending migrations unless told otherwise, so they are applied
+	// deliberately, before the release rolls out
+	migrate := cfg.MigrateOnStart == "true" || (cfg.MigrateOnStart == "" && cfg.Status != "production")
+	DB, err := db.NewDB(ctx, dsn, rclient, log, migrate)
 	if err != nil {
 		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to initialize PostgreSQL database")
 		panic("DB init failed")
@@ -53,7 +80,14 @@ func main() {
 		}
 	}()
 
-	app := fiber.New()
+	proxies := routes.TrustedProxies(cfg)
+	app := fiber.New(fiber.Config{
+		EnableTrustedProxyCheck: len(proxies) > 0,
+		TrustedProxies:          proxies,
+		ErrorHandler:            apierror.Handler(log),
+		// Image uploads may be up to 8MB, above Fiber's 4MB default
+		BodyLimit: 10 << 20,
+	})
 
 	routes.NewRoutes(ctx, app, cfg, DB, log, rclient)
 
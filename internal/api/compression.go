@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+	"github.com/mnuddindev/devpulse/internal/config"
+)
+
+// compressionLevels maps COMPRESSION to the levels of the compress middleware.
+var compressionLevels = map[string]compress.Level{
+	"off":     compress.LevelDisabled,
+	"speed":   compress.LevelBestSpeed,
+	"default": compress.LevelDefault,
+	"best":    compress.LevelBestCompression,
+}
+
+// Compress compresses responses with brotli, gzip or deflate, whichever the client accepts first
+// in that order, at the level named by COMPRESSION: off, speed, default or best, the default.
+// Streamed bodies are compressed as they are written, and bodies that are already compressed,
+// such as export archives and images, are sent as they are.
+func Compress(cfg *config.Config) fiber.Handler {
+	level, ok := compressionLevels[cfg.Compression]
+	if !ok {
+		level = compress.LevelBestCompression
+	}
+	return compress.New(
+		compress.Config{
+			Level: level,
+			// Compression would buffer server-sent events.
+			Next: func(c *fiber.Ctx) bool { return c.Path() == "/ws/notifications" },
+		},
+	)
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/pkg/metrics"
+)
+
+// legacyRoutesDeprecated is when the routes kept for older clients were deprecated in favour of
+// their successors.
+var legacyRoutesDeprecated = time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+// deprecated marks a route that older clients still use, serving it as before. Responses carry a
+// Deprecation header (RFC 9745) and a Link to the successor route, whose :params are filled in
+// from the request, and hits are counted so the route can be removed once they stop.
+func deprecated(since time.Time, successor string) fiber.Handler {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	return func(c *fiber.Ctx) error {
+		metrics.DeprecatedRequest(c.Method(), c.Route().Path)
+		c.Set("Deprecation", deprecation)
+		c.Set(fiber.HeaderLink, "<"+successorPath(c, successor)+`>; rel="successor-version"`)
+		return c.Next()
+	}
+}
+
+// successorPath fills the :params of a route path in from the request.
+func successorPath(c *fiber.Ctx, path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if name, ok := strings.CutPrefix(s, ":"); ok {
+			segments[i] = c.Params(name)
+		}
+	}
+	return strings.Join(segments, "/")
+}
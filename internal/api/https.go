@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/internal/config"
+)
+
+// defaultHSTSMaxAge is one year, the minimum accepted for browser preload lists.
+const defaultHSTSMaxAge = 31536000
+
+// TrustedProxies parses the comma separated TRUSTED_PROXIES list.
+func TrustedProxies(cfg *config.Config) []string {
+	var proxies []string
+	for _, p := range strings.Split(cfg.TrustedProxies, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// ForceHTTPS redirects plain HTTP requests to HTTPS and sets Strict-Transport-Security.
+// The scheme comes from c.Protocol, which only honours X-Forwarded-Proto from trusted proxies.
+// It is a no-op unless FORCE_HTTPS is "true".
+func ForceHTTPS(cfg *config.Config) fiber.Handler {
+	if cfg.ForceHTTPS != "true" {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+
+	maxAge := defaultHSTSMaxAge
+	if v, err := strconv.Atoi(cfg.HSTSMaxAge); err == nil && v >= 0 {
+		maxAge = v
+	}
+	hsts := "max-age=" + strconv.Itoa(maxAge)
+	if cfg.HSTSIncludeSubdomains == "true" {
+		hsts += "; includeSubDomains"
+	}
+
+	return func(c *fiber.Ctx) error {
+		if c.Protocol() != "https" {
+			// RequestURI is the path and query even for an absolute-form request target.
+			return c.Redirect("https://"+c.Hostname()+string(c.Request().URI().RequestURI()), fiber.StatusPermanentRedirect)
+		}
+		c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		return c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/internal/config"
+)
+
+func TestForceHTTPS(t *testing.T) {
+	cases := []struct {
+		name     string
+		cfg      config.Config
+		proxies  []string
+		header   map[string]string
+		status   int
+		location string
+		hsts     string
+	}{
+		{name: "disabled", cfg: config.Config{}, status: fiber.StatusOK},
+		{name: "plain HTTP is redirected", cfg: config.Config{ForceHTTPS: "true"}, status: fiber.StatusPermanentRedirect, location: "https://example.com/posts?page=2"},
+		{
+			name:    "HTTPS behind a trusted proxy",
+			cfg:     config.Config{ForceHTTPS: "true"},
+			proxies: []string{"0.0.0.0"},
+			header:  map[string]string{fiber.HeaderXForwardedProto: "https"},
+			status:  fiber.StatusOK,
+			hsts:    "max-age=31536000",
+		},
+		{
+			name:     "forwarded scheme from an untrusted client",
+			cfg:      config.Config{ForceHTTPS: "true"},
+			proxies:  []string{"10.0.0.1"},
+			header:   map[string]string{fiber.HeaderXForwardedProto: "https"},
+			status:   fiber.StatusPermanentRedirect,
+			location: "https://example.com/posts?page=2",
+		},
+		{
+			name:    "configured max age and subdomains",
+			cfg:     config.Config{ForceHTTPS: "true", HSTSMaxAge: "600", HSTSIncludeSubdomains: "true"},
+			proxies: []string{"0.0.0.0"},
+			header:  map[string]string{fiber.HeaderXForwardedProto: "https"},
+			status:  fiber.StatusOK,
+			hsts:    "max-age=600; includeSubDomains",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{EnableTrustedProxyCheck: len(tc.proxies) > 0, TrustedProxies: tc.proxies})
+			app.Use(ForceHTTPS(&tc.cfg))
+			app.Get("/posts", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/posts?page=2", nil)
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != tc.status {
+				t.Fatalf("status %d, want %d", res.StatusCode, tc.status)
+			}
+			if got := res.Header.Get(fiber.HeaderLocation); got != tc.location {
+				t.Fatalf("Location %q, want %q", got, tc.location)
+			}
+			if got := res.Header.Get(fiber.HeaderStrictTransportSecurity); got != tc.hsts {
+				t.Fatalf("Strict-Transport-Security %q, want %q", got, tc.hsts)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package routes
+
+import (
+	v1 "github.com/mnuddindev/devpulse/internal/api/v1"
+	"github.com/mnuddindev/devpulse/internal/auth"
+	"github.com/mnuddindev/devpulse/internal/models"
+)
+
+// accessPolicy declares what every route requires. Enforce runs on the whole app and Verify makes
+// sure each route is declared here, public ones included, so a new route cannot be reached before
+// it is.
+func accessPolicy(opt auth.Options) *auth.Policy {
+	perms := func(names ...string) auth.Rule { return auth.Rule{Any: names} }
+	public := auth.Rule{Public: true}
+	member := auth.Rule{Authenticated: true}
+	webhook := auth.Rule{Any: []string{"manage_webhooks"}, Own: []string{"create_webhook"}, Owner: v1.WebhookOwner}
+	series := auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.SeriesOwner}
+	listing := auth.Rule{Any: []string{"moderate_listing"}, Own: []string{"create_listing"}, Owner: v1.ListingOwner}
+	account := perms("manage_account")
+
+	return auth.NewPolicy(opt).
+		// Probes, metrics and the public site; routes answering signed in users differently
+		// authenticate them optionally
+		Declare("GET /healthz", public).
+		Declare("GET /readyz", public).
+		Declare("GET /metrics", public).
+		Declare("GET /feed.xml", public).
+		Declare("GET /sitemap.xml", public).
+		Declare("GET /oembed", public).
+		Declare("GET /search", public).
+		Declare("GET /flags", public).
+		Declare("GET /uploads/files/*", public).
+
+		// Signing up and in
+		Declare("GET /auth/csrf", public).
+		Declare("POST /register", public).
+		Declare("POST /activate", public).
+		Declare("POST /login", public).
+		Declare("POST /logout", public).
+		Declare("POST /refresh-token", public).
+		Declare("POST /auth/password/forgot", public).
+		Declare("POST /auth/password/reset", public).
+		Declare("POST /forgot-password", public).
+		Declare("POST /reset-password", public).
+		Declare("GET /auth/registration", public).
+		Declare("GET /auth/oauth/:provider", public).
+		Declare("GET /auth/oauth/:provider/callback", public).
+		Declare("POST /auth/oauth/2fa", public).
+
+		// Account
+		Declare("GET /users/me", account).
+		Declare("POST /users/me/2fa/enable", account).
+		Declare("POST /users/me/2fa/verify", account).
+		Declare("POST /users/me/2fa/disable", account).
+		Declare("GET /users/me/export", account).
+		Declare("GET /users/me/export/:id", account).
+		Declare("GET /users/me/export/:id/download", account).
+		Declare("GET /users/me/api-keys", account).
+		Declare("POST /users/me/api-keys", account).
+		Declare("DELETE /users/me/api-keys/:id", account).
+		Declare("GET /users/me/notification-preferences", account).
+		Declare("PUT /users/me/notification-preferences", account).
+		Declare("GET /users/me/push-subscriptions", account).
+		Declare("POST /users/me/push-subscriptions", account).
+		Declare("DELETE /users/me/push-subscriptions/:id", account).
+		Declare("GET /users/me/security/activity", account).
+		Declare("GET /users/me/streak", account).
+		Declare("GET /users/me/activity", account).
+		Declare("GET /users/me/identities", account).
+		Declare("PUT /users/me/identities/password", account).
+		Declare("DELETE /users/me/identities/password", account).
+		Declare("POST /users/me/identities/:provider", account).
+		Declare("DELETE /users/me/identities/:provider", account).
+		Declare("GET /me/security", account).
+		Declare("DELETE /me/sessions/providers/:provider", account).
+		Declare("GET /me/mentions", account).
+		Declare("GET /me/posts", account).
+		Declare("GET /me/posts/scheduled", account).
+		Declare("GET /me/co-author-invitations", account).
+		Declare("GET /me/tags", account).
+		Declare("GET /me/organizations", account).
+		Declare("GET /me/series", account).
+		Declare("GET /me/listings", account).
+		Declare("POST /user/profile", account).
+		Declare("PUT /user/update/profile/me", perms("edit_own_profile")).
+		Declare("PUT /user/update/notification/me", account).
+		Declare("PUT /user/update/customization/me", perms("edit_own_profile")).
+		Declare("PUT /user/update/account/me", account).
+		Declare("DELETE /user/account/delete/me", perms("delete_own_profile")).
+
+		// Notifications
+		Declare("GET /notifications", account).
+		Declare("GET /notifications/unread-count", account).
+		Declare("POST /notifications/read-all", account).
+		Declare("POST /notifications/archive", account).
+		Declare("GET /notifications/archived", account).
+		Declare("PATCH /notifications/:id/read", account).
+		Declare("GET /me/notifications/summary", account).
+		Declare("GET /ws/notifications", account).
+		Declare("GET /user/notifications/me", account).
+		Declare("POST /user/notification/me/:notificationId", account).
+
+		// Users
+		Declare("GET /users/active", public).
+		Declare("GET /users/:username", public).
+		Declare("GET /users/:username/stats", public).
+		Declare("GET /users/:username/feed.xml", public).
+		Declare("GET /users/:username/followers", public).
+		Declare("GET /users/:username/following", public).
+		Declare("GET /users/:username/badges", public).
+		Declare("GET /users/suggestions", perms("follow_user")).
+		Declare("GET /users/mention-suggest", perms("create_comment", "create_post")).
+		Declare("PUT /users/:username/follow", perms("follow_user")).
+		Declare("POST /user/:username/follow", perms("follow_user")).
+		Declare("POST /user/:username/unfollow", perms("unfollow_user")).
+		Declare("GET /users/me/blocks", perms("block_user")).
+		Declare("POST /users/:username/block", perms("block_user")).
+		Declare("DELETE /users/:username/block", perms("block_user")).
+		Declare("POST /users/:username/mute", perms("block_user")).
+		Declare("DELETE /users/:username/mute", perms("block_user")).
+
+		// Reading
+		Declare("GET /feed", perms("read_post")).
+		Declare("GET /users/me/reading-list", perms("bookmark_post")).
+		Declare("POST /users/me/reading-list/:id/archive", perms("bookmark_post")).
+		Declare("POST /users/me/reading-list/:id/unarchive", perms("bookmark_post")).
+		Declare("POST /posts/:id/bookmark", perms("bookmark_post")).
+		Declare("DELETE /posts/:id/bookmark", perms("bookmark_post")).
+
+		// Posts and comments
+		Declare("GET /posts", public).
+		Declare("GET /posts/:slug", public).
+		Declare("GET /posts/:slug/meta", public).
+		Declare("GET /posts/:id/comments", public).
+		Declare("POST /posts", perms("create_post")).
+		Declare("PUT /posts/:id", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostEditor}).
+		Declare("POST /posts/:id/publish", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostOwner}).
+		Declare("POST /posts/:id/unpublish", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostOwner}).
+		Declare("POST /posts/:id/preview-link", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostOwner}).
+		Declare("DELETE /posts/:id", auth.Rule{Any: []string{"delete_any_post"}, Own: []string{"delete_own_post"}, Owner: v1.PostOwner}).
+		Declare("PATCH /posts/:id/autosave", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostEditor}).
+		Declare("DELETE /posts/:id/autosave", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostEditor}).
+		Declare("GET /posts/:id/revisions", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostEditor}).
+		Declare("GET /posts/:id/revisions/:revision_id", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostEditor}).
+		Declare("POST /posts/:id/revisions/:revision_id/restore", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostEditor}).
+		Declare("GET /posts/:id/history", auth.Rule{Any: []string{"moderate_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostOwner}).
+		// Co-authors edit the post with its author; only the author invites them
+		Declare("GET /posts/:id/co-authors", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostEditor}).
+		Declare("POST /posts/:id/co-authors", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostOwner}).
+		Declare("POST /posts/:id/co-authors/accept", perms("edit_own_post")).
+		Declare("POST /posts/:id/co-authors/decline", account).
+		Declare("DELETE /posts/:id/co-authors/:username", auth.Rule{Any: []string{"edit_any_post"}, Own: []string{"edit_own_post"}, Owner: v1.PostEditor}).
+		Declare("POST /posts/:id/comments", perms("create_comment")).
+		Declare("PUT /comments/:id", auth.Rule{Any: []string{"edit_any_comment"}, Own: []string{"edit_own_comment"}, Owner: v1.CommentOwner}).
+		Declare("DELETE /comments/:id", auth.Rule{Any: []string{"delete_any_comment"}, Own: []string{"delete_own_comment"}, Owner: v1.CommentOwner}).
+		Declare("GET /comments/:id/history", auth.Rule{Any: []string{"moderate_comment"}, Own: []string{"edit_own_comment"}, Owner: v1.CommentOwner}).
+
+		// Series; adding a post also requires it to be by the series author, which the handlers check
+		Declare("GET /series/:slug", public).
+		Declare("POST /series", perms("create_post")).
+		Declare("PUT /series/:id", series).
+		Declare("DELETE /series/:id", series).
+		Declare("POST /series/:id/posts", series).
+		Declare("PUT /series/:id/posts", series).
+		Declare("DELETE /series/:id/posts/:post_id", series).
+
+		// Listings
+		Declare("GET /listings", public).
+		Declare("GET /listings/:id", public).
+		Declare("POST /listings", perms("create_listing")).
+		Declare("PUT /listings/:id", listing).
+		Declare("DELETE /listings/:id", listing).
+		Declare("POST /listings/:id/renew", listing).
+
+		// Tags
+		Declare("GET /tags", public).
+		Declare("GET /tags/:slug", public).
+		Declare("GET /tags/:slug/posts", public).
+		Declare("GET /tags/:slug/feed.xml", public).
+		Declare("POST /tags", perms("create_tag")).
+		Declare("PUT /tags/:slug", perms("edit_tag", "moderate_tag")).
+		Declare("POST /tags/:slug/follow", perms("follow_tag")).
+		Declare("DELETE /tags/:slug/follow", perms("unfollow_tag")).
+
+		// Organizations; what a member may do inside one is decided by their organization role,
+		// which the handlers check
+		Declare("GET /orgs/:slug", public).
+		Declare("GET /orgs/:slug/posts", public).
+		Declare("GET /orgs/:slug/members", public).
+		Declare("POST /orgs", perms("create_organization")).
+		Declare("PUT /orgs/:slug", member).
+		Declare("POST /orgs/:slug/members", member).
+		Declare("PUT /orgs/:slug/members/:username", member).
+		Declare("DELETE /orgs/:slug/members/:username", member).
+		Declare("POST /orgs/:slug/invitation/accept", member).
+		Declare("POST /orgs/:slug/invitation/decline", member).
+
+		// Uploads
+		Declare("POST /uploads", perms("upload_media")).
+		Declare("GET /uploads/:id", perms("upload_media")).
+		Declare("GET /uploads/:id/url", perms("upload_media")).
+
+		// Invitations; holders of manage_invitations are not limited and can revoke anyone's
+		Declare("GET /invitations", perms("create_invitation")).
+		Declare("POST /invitations", perms("create_invitation", "manage_invitations")).
+		Declare("DELETE /invitations/:id", perms("create_invitation", "manage_invitations")).
+
+		// Webhooks; admins holding manage_webhooks can manage everyone's
+		Declare("GET /webhooks", perms("create_webhook")).
+		Declare("POST /webhooks", perms("create_webhook")).
+		Declare("PUT /webhooks/:id", webhook).
+		Declare("DELETE /webhooks/:id", webhook).
+		Declare("POST /webhooks/:id/secret", webhook).
+		Declare("GET /webhooks/:id/deliveries", webhook).
+
+		// Administration
+		Declare("GET /admin/roles", perms("manage_roles")).
+		Declare("POST /admin/roles", perms("create_roles")).
+		Declare("GET /admin/roles/templates", perms("manage_roles")).
+		Declare("POST /admin/roles/:role_id/clone", perms("create_roles")).
+		Declare("GET /admin/permissions", perms("manage_roles")).
+		Declare("GET /admin/roles/:role_id/users", perms("manage_roles")).
+		Declare("PUT /admin/roles/:role_id", perms("edit_roles")).
+		Declare("POST /admin/roles/:role_id/permissions/preview", perms("edit_roles")).
+		Declare("POST /admin/roles/:role_id/permissions", perms("edit_roles")).
+		Declare("DELETE /admin/roles/:role_id/permissions", perms("edit_roles")).
+		Declare("GET /admin/stats", perms("manage_analytics")).
+		Declare("GET /admin/users", perms("moderate_user")).
+		Declare("GET /admin/users/:id", perms("moderate_user", "edit_any_user")).
+		Declare("GET /admin/users/:id/moderation", perms("moderate_user")).
+		Declare("POST /admin/users/:id/suspend", perms("moderate_user")).
+		Declare("POST /admin/users/:id/ban", perms("ban_user")).
+		Declare("POST /admin/users/:id/reinstate", perms("ban_user")).
+		Declare("POST /admin/users/:id/logout", perms("moderate_user")).
+		Declare("POST /admin/users/:id/recount", perms("moderate_user")).
+		Declare("PUT /admin/users/:id/role", perms("assign_roles")).
+		Declare("POST /admin/users/:id/impersonate", perms(auth.ImpersonatePermission)).
+		Declare("GET /admin/invitations", perms("manage_invitations")).
+		Declare("GET /admin/blocklist", perms("manage_content_filter")).
+		Declare("POST /admin/blocklist", perms("manage_content_filter")).
+		Declare("DELETE /admin/blocklist/:id", perms("manage_content_filter")).
+		Declare("GET /admin/rate-limits", perms("manage_site_settings")).
+		Declare("PUT /admin/rate-limits/:policy", perms("manage_site_settings")).
+		Declare("DELETE /admin/rate-limits/overrides/:id", perms("manage_site_settings")).
+		Declare("GET /admin/feature-flags", perms("manage_site_settings")).
+		Declare("POST /admin/feature-flags", perms("manage_site_settings")).
+		Declare("PATCH /admin/feature-flags/:key", perms("manage_site_settings")).
+		Declare("DELETE /admin/feature-flags/:key", perms("manage_site_settings")).
+		Declare("GET /admin/audit-logs", perms("view_audit_logs")).
+		Declare("GET /admin/audit-logs/export", perms("view_audit_logs")).
+		Declare("GET /admin/email-templates", perms("manage_site_settings")).
+		Declare("GET /admin/email-templates/:name/preview", perms("manage_site_settings")).
+		Declare("POST /admin/email-templates/:name/test", perms("manage_site_settings"))
+}
+
+// unimpersonableRoutes lists the routes an impersonation token cannot reach: those changing how a
+// user signs in (the profile update included, as it changes the email and username unverified),
+// minting credentials or invitations, exporting their data or deleting the account, and impersonation
+// itself.
+func unimpersonableRoutes() *auth.Routes {
+	return auth.NewRoutes(
+		"POST /users/me/2fa/enable",
+		"POST /users/me/2fa/verify",
+		"POST /users/me/2fa/disable",
+		"POST /users/me/api-keys",
+		"POST /invitations",
+		"DELETE /users/me/api-keys/:id",
+		"PUT /users/me/identities/password",
+		"DELETE /users/me/identities/password",
+		"POST /users/me/identities/:provider",
+		"DELETE /users/me/identities/:provider",
+		"GET /users/me/export",
+		"GET /users/me/export/:id/download",
+		"PUT /user/update/profile/me",
+		"PUT /user/update/account/me",
+		"DELETE /user/account/delete/me",
+		"DELETE /me/sessions/providers/:provider",
+		"POST /admin/users/:id/impersonate",
+	)
+}
+
+// tokenScopes declares the routes personal access tokens can reach and the scope each needs. Any
+// other route, managing the keys themselves included, only accepts a session.
+func tokenScopes() *auth.TokenScopes {
+	return auth.NewTokenScopes().
+		// read:profile
+		Declare("POST /user/profile", models.ScopeReadProfile).
+		Declare("GET /users/me", models.ScopeReadProfile).
+		Declare("GET /me/posts", models.ScopeReadProfile).
+		Declare("GET /me/co-author-invitations", models.ScopeReadProfile).
+		Declare("GET /me/tags", models.ScopeReadProfile).
+		Declare("GET /me/organizations", models.ScopeReadProfile).
+		Declare("GET /me/series", models.ScopeReadProfile).
+		Declare("GET /me/listings", models.ScopeReadProfile).
+
+		// write:posts
+		Declare("POST /posts", models.ScopeWritePosts).
+		Declare("PUT /posts/:id", models.ScopeWritePosts).
+		Declare("POST /posts/:id/publish", models.ScopeWritePosts).
+		Declare("POST /posts/:id/unpublish", models.ScopeWritePosts).
+		Declare("POST /posts/:id/preview-link", models.ScopeWritePosts).
+		Declare("DELETE /posts/:id", models.ScopeWritePosts).
+		Declare("PATCH /posts/:id/autosave", models.ScopeWritePosts).
+		Declare("DELETE /posts/:id/autosave", models.ScopeWritePosts).
+		Declare("GET /posts/:id/revisions", models.ScopeWritePosts).
+		Declare("GET /posts/:id/revisions/:revision_id", models.ScopeWritePosts).
+		Declare("POST /posts/:id/revisions/:revision_id/restore", models.ScopeWritePosts).
+		Declare("GET /posts/:id/history", models.ScopeWritePosts).
+		Declare("GET /posts/:id/co-authors", models.ScopeWritePosts).
+		Declare("POST /posts/:id/co-authors", models.ScopeWritePosts).
+		Declare("POST /posts/:id/co-authors/accept", models.ScopeWritePosts).
+		Declare("POST /posts/:id/co-authors/decline", models.ScopeWritePosts).
+		Declare("DELETE /posts/:id/co-authors/:username", models.ScopeWritePosts).
+		Declare("POST /series", models.ScopeWritePosts).
+		Declare("PUT /series/:id", models.ScopeWritePosts).
+		Declare("DELETE /series/:id", models.ScopeWritePosts).
+		Declare("POST /series/:id/posts", models.ScopeWritePosts).
+		Declare("PUT /series/:id/posts", models.ScopeWritePosts).
+		Declare("DELETE /series/:id/posts/:post_id", models.ScopeWritePosts).
+		Declare("POST /listings", models.ScopeWritePosts).
+		Declare("PUT /listings/:id", models.ScopeWritePosts).
+		Declare("DELETE /listings/:id", models.ScopeWritePosts).
+		Declare("POST /listings/:id/renew", models.ScopeWritePosts).
+		Declare("POST /uploads", models.ScopeWritePosts)
+}
@@ -2,92 +2,532 @@ package routes
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/google/uuid"
 	v1 "github.com/mnuddindev/devpulse/internal/api/v1"
 	"github.com/mnuddindev/devpulse/internal/auth"
 	"github.com/mnuddindev/devpulse/internal/config"
+	internalgrpc "github.com/mnuddindev/devpulse/internal/grpc"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/repository"
+	"github.com/mnuddindev/devpulse/internal/services"
+	"github.com/mnuddindev/devpulse/pkg/contentfilter"
+	"github.com/mnuddindev/devpulse/pkg/geoip"
 	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/mailer"
+	"github.com/mnuddindev/devpulse/pkg/metrics"
+	"github.com/mnuddindev/devpulse/pkg/middleware/ratelimit"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/uploads"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"github.com/mnuddindev/devpulse/pkg/webpush"
 	"gorm.io/gorm"
 )
 
 func NewRoutes(ctx context.Context, app *fiber.App, cfg *config.Config, db *gorm.DB, log *logger.Logger, rclient *storage.RedisClient) {
+	// Probes and metrics come before the middleware so load balancers and scrapers reach them
+	// over plain HTTP, without rate limits and without filling the request log.
+	app.Get("/healthz", v1.Healthz)
+	app.Get("/readyz", v1.Readyz)
+	app.Get("/metrics", metrics.Handler(cfg.MetricsToken))
+
 	app.Use(
 		logger.SetupLogger(log),
+		metrics.Middleware(),
 		recover.New(),
+		ForceHTTPS(cfg),
 		cors.New(
 			cors.Config{
 				AllowOrigins:     "http://localhost:3000",
 				AllowCredentials: true,
-				AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-			},
-		),
-		compress.New(
-			compress.Config{
-				Level: compress.LevelBestCompression,
-			},
-		),
-		limiter.New(
-			limiter.Config{
-				Expiration: 1 * time.Minute,
-				Max:        10,
-				KeyGenerator: func(c *fiber.Ctx) string {
-					return c.IP()
-				},
+				AllowHeaders:     "Origin, Content-Type, Accept, Authorization, " + auth.CSRFHeader + ", " + auth.TokenTransportHeader + ", " + fiber.HeaderXRequestID,
+				ExposeHeaders:    fiber.HeaderXRequestID,
 			},
 		),
+		Compress(cfg),
 	)
-	app.Use(log.Middleware())
 
 	v1.DB = db
 	v1.Redis = rclient
 	v1.Logger = log
+	v1.Limiter = ratelimit.New(rclient, log)
+	v1.Limiter.UseOverrides(v1.RateLimitOverrides, v1.RateLimitRole, v1.RateLimitRefresh)
+	app.Use(v1.Limiter.Handle(v1.GlobalRateLimit), v1.Limiter.Handle(v1.GuestReadRateLimit))
+	intervals, err := utils.ParseDurations(cfg.ProfileFieldIntervals)
+	if err != nil {
+		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid profile field intervals")
+		panic(err)
+	}
+	for field, interval := range intervals {
+		v1.ProfileFieldIntervals[field] = interval
+	}
+	v1.SessionPolicy = models.SessionPolicy{
+		OnPasswordChange: cfg.LogoutOnPasswordChange != "false",
+		OnRoleChange:     cfg.LogoutOnRoleChange == "true",
+	}
+	retention := models.DefaultNotificationRetention
+	if cfg.NotificationRetentionCount != "" {
+		retention.Keep, err = strconv.Atoi(cfg.NotificationRetentionCount)
+		if err != nil || retention.Keep < 0 {
+			log.Error(ctx).WithMeta(utils.Map{"count": cfg.NotificationRetentionCount}).Logs("Invalid notification retention count")
+			panic("invalid notification retention count " + cfg.NotificationRetentionCount)
+		}
+	}
+	if cfg.NotificationRetentionAge != "" {
+		retention.MaxAge, err = time.ParseDuration(cfg.NotificationRetentionAge)
+		if err != nil || retention.MaxAge < 0 {
+			log.Error(ctx).WithMeta(utils.Map{"age": cfg.NotificationRetentionAge}).Logs("Invalid notification retention age")
+			panic("invalid notification retention age " + cfg.NotificationRetentionAge)
+		}
+	}
+	// Privacy mode defaults to on outside development, where verbose errors help debugging.
+	v1.PrivacyMode = cfg.PrivacyMode == "true" || (cfg.PrivacyMode == "" && cfg.Status != "development")
+	if cfg.RegistrationMode != "" {
+		if !utils.Contains(models.RegistrationModes, cfg.RegistrationMode) {
+			log.Error(ctx).WithMeta(utils.Map{"mode": cfg.RegistrationMode}).Logs("Invalid registration mode")
+			panic("invalid registration mode " + cfg.RegistrationMode)
+		}
+		v1.RegistrationMode = cfg.RegistrationMode
+	}
+	filterRules, err := contentfilter.ParseRules(cfg.ContentFilterRules)
+	if err == nil {
+		v1.ContentFilter, err = contentfilter.New(filterRules)
+	}
+	if err != nil {
+		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid content filter rules")
+		panic(err)
+	}
+	if cfg.GeoIPDatabase != "" {
+		v1.GeoIP, err = geoip.Open(cfg.GeoIPDatabase)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid GeoIP database")
+			panic(err)
+		}
+	}
+	if cfg.TwoFactorKey != "" {
+		key, err := utils.ParseEncryptionKey(cfg.TwoFactorKey)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid two-factor encryption key")
+			panic(err)
+		}
+		v1.TwoFactorKey = key
+	}
+	if cfg.WebhookKey != "" {
+		key, err := utils.ParseEncryptionKey(cfg.WebhookKey)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid webhook encryption key")
+			panic(err)
+		}
+		v1.WebhookKey = key
+	}
+	if cfg.VAPIDPrivateKey != "" {
+		v1.Push, err = webpush.NewSender(cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid Web Push configuration")
+			panic(err)
+		}
+	}
+	var uploadKey []byte
+	if cfg.UploadSigningKey != "" {
+		uploadKey, err = utils.ParseEncryptionKey(cfg.UploadSigningKey)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid upload signing key")
+			panic(err)
+		}
+	}
+	uploadURL := cfg.UploadPublicURL
+	if uploadURL == "" && (cfg.UploadDriver == "" || cfg.UploadDriver == "local") {
+		uploadURL = v1.EmailCfg.AppURL + "/uploads/files"
+	}
+	v1.Uploads, err = uploads.NewDriver(uploads.Config{
+		Driver:      cfg.UploadDriver,
+		PublicURL:   uploadURL,
+		Dir:         cfg.UploadDir,
+		SigningKey:  uploadKey,
+		S3Endpoint:  cfg.S3Endpoint,
+		S3Region:    cfg.S3Region,
+		S3Bucket:    cfg.S3Bucket,
+		S3AccessKey: cfg.S3AccessKey,
+		S3SecretKey: cfg.S3SecretKey,
+	})
+	if err != nil {
+		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to initialize upload storage")
+		panic(err)
+	}
+	// Without SMTP settings mail goes to a local catcher such as MailHog
+	smtpHost, smtpPort := cfg.SMTPHost, 1025
+	if smtpHost == "" {
+		smtpHost = "0.0.0.0"
+	}
+	if cfg.SMTPPort != "" {
+		smtpPort, err = strconv.Atoi(cfg.SMTPPort)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid SMTP port")
+			panic(err)
+		}
+	}
+	v1.EmailCfg.Provider, err = mailer.NewProvider(mailer.Config{
+		Provider:       cfg.MailProvider,
+		SMTPHost:       smtpHost,
+		SMTPPort:       smtpPort,
+		SMTPUsername:   cfg.SMTPUsername,
+		SMTPPassword:   cfg.SMTPPassword,
+		SESRegion:      cfg.SESRegion,
+		SESAccessKey:   cfg.SESAccessKey,
+		SESSecretKey:   cfg.SESSecretKey,
+		SendGridAPIKey: cfg.SendGridAPIKey,
+	})
+	if err != nil {
+		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to initialize the mail provider")
+		panic(err)
+	}
+	if cfg.MailFrom != "" {
+		v1.EmailCfg.FromEmail = cfg.MailFrom
+	}
+	// Without a configured key cursors are sealed with a per-process key and expire on restart.
+	var cursorKey []byte
+	if cfg.CursorKey != "" {
+		cursorKey, err = utils.ParseEncryptionKey(cfg.CursorKey)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid cursor encryption key")
+			panic(err)
+		}
+	}
+	v1.Paginator, err = utils.NewPaginator(cursorKey)
+	if err != nil {
+		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to initialize cursor pagination")
+		panic(err)
+	}
+	// Without a configured key preview links are signed with a per-process key and expire on restart.
+	var previewKey []byte
+	if cfg.PreviewKey != "" {
+		previewKey, err = utils.ParseEncryptionKey(cfg.PreviewKey)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid post preview key")
+			panic(err)
+		}
+	}
+	v1.PostPolicy, err = models.NewPostPolicy(previewKey)
+	if err != nil {
+		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to initialize the post policy")
+		panic(err)
+	}
+	v1.Services = services.New(services.Deps{
+		Repos:            repository.New(db, rclient),
+		Rclient:          rclient,
+		Logger:           log,
+		RegistrationMode: v1.RegistrationMode,
+		PrivacyMode:      v1.PrivacyMode,
+	})
+	v1.OAuthProviders = auth.NewOAuthProviders(map[string]auth.OAuthCredentials{
+		"google":   {ClientID: cfg.GoogleClientID, ClientSecret: cfg.GoogleClientSecret},
+		"github":   {ClientID: cfg.GitHubClientID, ClientSecret: cfg.GitHubClientSecret},
+		"facebook": {ClientID: cfg.FacebookClientID, ClientSecret: cfg.FacebookClientSecret},
+	}, cfg.OAuthCallbackURL)
+	models.SetMentionEmailer(v1.SendMentionEmail)
 
 	opt := auth.Options{
-		DB:      db,
-		Rclient: rclient,
-		Logger:  log,
+		DB:             db,
+		Rclient:        rclient,
+		Logger:         log,
+		Scopes:         tokenScopes(),
+		Unimpersonable: unimpersonableRoutes(),
 	}
+	policy := accessPolicy(opt)
+	app.Use(auth.CSRFMiddleware(opt), policy.Enforce())
 
+	app.Get("/auth/csrf", v1.GetCSRFToken)
 	app.Post("/register", v1.Register)
 	app.Post("/activate", v1.ActivateUser)
-	app.Post("/login", v1.Login)
+	app.Post("/login", v1.Limiter.Handle(v1.LoginRateLimit), v1.Login)
 	app.Post("/logout", v1.Logout)
-	app.Post("/refresh-token", v1.Refresh)
-	app.Post("/forgot-password", v1.ForgotPassword)
-	app.Post("/reset-password", v1.ResetPassword)
+	app.Post("/refresh-token", v1.Limiter.Handle(v1.RefreshRateLimit), v1.Refresh)
+	app.Post("/auth/password/forgot", v1.ForgotPassword)
+	app.Post("/auth/password/reset", v1.ResetPassword)
+	app.Post("/forgot-password", deprecated(legacyRoutesDeprecated, "/auth/password/forgot"), v1.ForgotPassword)
+	app.Post("/reset-password", deprecated(legacyRoutesDeprecated, "/auth/password/reset"), v1.ResetPassword)
+	app.Get("/auth/registration", v1.GetRegistrationMode)
+	app.Get("/auth/oauth/:provider", v1.OAuthLogin)
+	app.Get("/auth/oauth/:provider/callback", v1.OAuthCallback)
+	app.Post("/auth/oauth/2fa", v1.Limiter.Handle(v1.LoginRateLimit), v1.OAuthTwoFactor)
 
 	users := app.Group("/users")
-	users.Get("/:username", v1.GetUserByUsername)
-	users.Get("/:username/stats", v1.GetUserStats)
-	users.Get("/:username/followers", v1.GetUserFollowers)
-	users.Get("/:username/following", v1.GetUserFollowing)
+	users.Post("/me/2fa/enable", v1.Limiter.Handle(v1.TwoFactorRateLimit), v1.EnableTwoFactor)
+	users.Post("/me/2fa/verify", v1.Limiter.Handle(v1.TwoFactorRateLimit), v1.VerifyTwoFactor)
+	users.Post("/me/2fa/disable", v1.Limiter.Handle(v1.TwoFactorRateLimit), v1.DisableTwoFactor)
+	users.Get("/me", v1.ConditionalGet, v1.GetProfile)
+	users.Get("/me/reading-list", v1.GetReadingList)
+	users.Post("/me/reading-list/:id/archive", v1.ArchiveBookmark)
+	users.Post("/me/reading-list/:id/unarchive", v1.UnarchiveBookmark)
+	users.Get("/me/export", v1.RequestDataExport)
+	users.Get("/me/export/:id", v1.GetDataExport)
+	users.Get("/me/export/:id/download", v1.DownloadDataExport)
+	users.Get("/me/api-keys", v1.ListAPIKeys)
+	users.Post("/me/api-keys", v1.CreateAPIKey)
+	users.Delete("/me/api-keys/:id", v1.RevokeAPIKey)
+	users.Get("/me/notification-preferences", v1.GetNotificationMatrix)
+	users.Put("/me/notification-preferences", v1.Limiter.Handle(v1.ProfileUpdateRateLimit), v1.UpdateNotificationMatrix)
+	users.Get("/me/push-subscriptions", v1.ListPushSubscriptions)
+	users.Post("/me/push-subscriptions", v1.CreatePushSubscription)
+	users.Delete("/me/push-subscriptions/:id", v1.DeletePushSubscription)
+	users.Get("/me/blocks", v1.ListBlocks)
+	users.Get("/me/security/activity", v1.GetSecurityActivity)
+	users.Get("/me/streak", v1.GetMyStreak)
+	users.Get("/me/activity", v1.GetMyActivity)
+	users.Get("/me/identities", v1.ListIdentities)
+	users.Put("/me/identities/password", v1.Limiter.Handle(v1.TwoFactorRateLimit), v1.SetPassword)
+	users.Delete("/me/identities/password", v1.Limiter.Handle(v1.TwoFactorRateLimit), v1.RemovePassword)
+	users.Post("/me/identities/:provider", v1.LinkIdentity)
+	users.Delete("/me/identities/:provider", v1.UnlinkIdentity)
+	users.Get("/active", v1.GetActiveUsers)
+	users.Get("/suggestions", v1.GetFollowSuggestions)
+	users.Get("/mention-suggest", v1.SuggestMentions)
+	users.Get("/:username", v1.ConditionalGet, v1.GuestCache, auth.OptionalAuth(opt), v1.GetUserByUsername)
+	users.Get("/:username/stats", v1.ConditionalGet, v1.GuestCache, v1.GetUserStats)
+	users.Get("/:username/feed.xml", v1.GetUserRSSFeed)
+	users.Get("/:username/followers", v1.ConditionalGet, v1.GuestCache, v1.GetUserFollowers)
+	users.Get("/:username/following", v1.ConditionalGet, v1.GuestCache, v1.GetUserFollowing)
+	users.Put("/:username/follow", v1.Limiter.Handle(v1.FollowRateLimit), v1.SetFollowState)
+	users.Post("/:username/block", v1.BlockUser)
+	users.Delete("/:username/block", v1.UnblockUser)
+	users.Post("/:username/mute", v1.MuteUser)
+	users.Delete("/:username/mute", v1.UnmuteUser)
 
 	// User Badges
-	users.Get("/:username/badges", v1.GetUserBadges)
+	users.Get("/:username/badges", v1.ConditionalGet, v1.GuestCache, v1.GetUserBadges)
 
 	// Private routes
-	user := app.Group("/user", auth.RefreshTokenMiddleware(opt))
-	user.Post("/profile", auth.CheckPerm(opt, "create_comment"), v1.GetProfile)
-	user.Put("/update/profile/me", auth.CheckPerm(opt, "create_comment"), v1.UpdateUserProfile)
-	user.Put("/update/notification/me", auth.CheckPerm(opt, "create_comment"), v1.UpdateUserNotificationPrefrences)
-	user.Put("/update/customization/me", auth.CheckPerm(opt, "create_comment"), v1.UpdateUserCustomization)
-	user.Put("/update/account/me", auth.CheckPerm(opt, "create_comment"), v1.UpdateUserAccount)
-	user.Delete("/account/delete/me", auth.CheckPerm(opt, "create_comment"), v1.DeleteUserAccount)
+	user := app.Group("/user")
+	user.Post("/profile", deprecated(legacyRoutesDeprecated, "/users/me"), v1.GetProfile)
+	user.Put("/update/profile/me", v1.Limiter.Handle(v1.ProfileUpdateRateLimit), v1.UpdateUserProfile)
+	user.Put("/update/notification/me", v1.Limiter.Handle(v1.ProfileUpdateRateLimit), v1.UpdateUserNotificationPrefrences)
+	user.Put("/update/customization/me", v1.Limiter.Handle(v1.ProfileUpdateRateLimit), v1.UpdateUserCustomization)
+	user.Put("/update/account/me", v1.Limiter.Handle(v1.ChangePasswordRateLimit), v1.UpdateUserAccount)
+	user.Delete("/account/delete/me", v1.Limiter.Handle(v1.DeleteAccountRateLimit), v1.DeleteUserAccount)
 
 	// follow
-	user.Post("/:username/follow", auth.CheckPerm(opt, "create_comment"), v1.FollowUser)
-	user.Post("/:username/unfollow", auth.CheckPerm(opt, "create_comment"), v1.UnfollowUser)
+	user.Post("/:username/follow", deprecated(legacyRoutesDeprecated, "/users/:username/follow"), v1.Limiter.Handle(v1.FollowRateLimit), v1.FollowUser)
+	user.Post("/:username/unfollow", deprecated(legacyRoutesDeprecated, "/users/:username/follow"), v1.Limiter.Handle(v1.FollowRateLimit), v1.UnfollowUser)
 
 	// user notifications
-	user.Get("/notifications/me", auth.CheckPerm(opt, "create_comment"), v1.GetUserNotifications)
-	user.Post("/notification/me/:notificationId", auth.CheckPerm(opt, "create_comment"), v1.GetUserNotificationID)
+	user.Get("/notifications/me", deprecated(legacyRoutesDeprecated, "/notifications"), v1.GetUserNotifications)
+	user.Post("/notification/me/:notificationId", v1.GetUserNotificationID)
+
+	// Current user
+	me := app.Group("/me")
+	me.Get("/security", v1.GetSecuritySummary)
+	me.Get("/notifications/summary", v1.GetNotificationSummary)
+	me.Delete("/sessions/providers/:provider", v1.RevokeProviderSessions)
+	me.Get("/mentions", v1.GetMyMentions)
+
+	me.Get("/posts", v1.GetMyPosts)
+	me.Get("/posts/scheduled", v1.GetMyScheduledPosts)
+	me.Get("/co-author-invitations", v1.GetMyCoAuthorInvitations)
+	me.Get("/tags", v1.GetMyTags)
+	me.Get("/organizations", v1.GetMyOrganizations)
+	me.Get("/series", v1.GetMySeries)
+	me.Get("/listings", v1.GetMyListings)
+
+	// Notifications
+	notifications := app.Group("/notifications")
+	notifications.Get("/", v1.ConditionalGet, v1.GetUserNotifications)
+	notifications.Get("/unread-count", v1.ConditionalGet, v1.GetUnreadNotificationCount)
+	notifications.Post("/read-all", v1.MarkAllNotificationsRead)
+	notifications.Post("/archive", v1.ArchiveNotifications)
+	notifications.Get("/archived", v1.GetArchivedNotifications)
+	notifications.Patch("/:id/read", v1.MarkNotificationRead)
+
+	// Live notifications
+	app.Get("/ws/notifications", v1.StreamNotifications)
+
+	// Home feed
+	app.Get("/feed", v1.ConditionalGet, v1.GetFeed)
+
+	// Feature flags of the current user
+	app.Get("/flags", auth.OptionalAuth(opt), v1.GetFlags)
+
+	// Embeds
+	app.Get("/oembed", v1.OEmbed)
+
+	// Search
+	app.Get("/search", v1.ConditionalGet, v1.GuestCache, auth.OptionalAuth(opt), v1.Search)
+
+	// Syndication
+	app.Get("/feed.xml", v1.GetSiteFeed)
+	app.Get("/sitemap.xml", v1.GetSitemap)
+
+	// Posts
+	posts := app.Group("/posts")
+	posts.Get("/", v1.ConditionalGet, v1.GuestCache, auth.OptionalAuth(opt), v1.ListPosts)
+	posts.Get("/:slug", v1.ConditionalGet, v1.GuestCache, auth.OptionalAuth(opt), v1.GetPost)
+	posts.Get("/:slug/meta", v1.ConditionalGet, v1.GuestCache, auth.OptionalAuth(opt), v1.GetPostMeta)
+	posts.Post("/", v1.CreatePost)
+	posts.Put("/:id", v1.UpdatePost)
+	posts.Post("/:id/publish", v1.PublishPost)
+	posts.Post("/:id/unpublish", v1.UnpublishPost)
+	posts.Post("/:id/preview-link", v1.CreatePreviewLink)
+	posts.Delete("/:id", v1.DeletePost)
+	posts.Patch("/:id/autosave", v1.AutosavePost)
+	posts.Delete("/:id/autosave", v1.DiscardAutosave)
+	posts.Get("/:id/revisions", v1.ListPostRevisions)
+	posts.Get("/:id/revisions/:revision_id", v1.GetPostRevision)
+	posts.Post("/:id/revisions/:revision_id/restore", v1.RestorePostRevision)
+	posts.Get("/:id/history", v1.GetPostHistory)
+	posts.Get("/:id/co-authors", v1.ListCoAuthors)
+	posts.Post("/:id/co-authors", v1.InviteCoAuthor)
+	posts.Post("/:id/co-authors/accept", v1.AcceptCoAuthorInvitation)
+	posts.Post("/:id/co-authors/decline", v1.DeclineCoAuthorInvitation)
+	posts.Delete("/:id/co-authors/:username", v1.RemoveCoAuthor)
+
+	posts.Post("/:id/bookmark", v1.BookmarkPost)
+	posts.Delete("/:id/bookmark", v1.UnbookmarkPost)
+
+	// Comments
+	posts.Get("/:id/comments", v1.ConditionalGet, v1.GuestCache, auth.OptionalAuth(opt), v1.GetPostComments)
+	posts.Post("/:id/comments", v1.CreateComment)
+	comments := app.Group("/comments")
+	comments.Put("/:id", v1.UpdateComment)
+	comments.Delete("/:id", v1.DeleteComment)
+	comments.Get("/:id/history", v1.GetCommentHistory)
+
+	// Series
+	series := app.Group("/series")
+	series.Get("/:slug", v1.ConditionalGet, v1.GuestCache, v1.GetSeries)
+	series.Post("/", v1.CreateSeries)
+	series.Put("/:id", v1.UpdateSeries)
+	series.Delete("/:id", v1.DeleteSeries)
+	series.Post("/:id/posts", v1.AddSeriesPost)
+	series.Put("/:id/posts", v1.ReorderSeriesPosts)
+	series.Delete("/:id/posts/:post_id", v1.RemoveSeriesPost)
+
+	// Listings
+	listings := app.Group("/listings")
+	listings.Get("/", v1.ConditionalGet, v1.GuestCache, v1.ListListings)
+	listings.Get("/:id", v1.ConditionalGet, v1.GuestCache, auth.OptionalAuth(opt), v1.GetListing)
+	listings.Post("/", v1.CreateListing)
+	listings.Put("/:id", v1.UpdateListing)
+	listings.Delete("/:id", v1.DeleteListing)
+	listings.Post("/:id/renew", v1.RenewListing)
+
+	// Tags
+	tags := app.Group("/tags")
+	tags.Get("/", v1.ConditionalGet, v1.GuestCache, v1.ListTags)
+	tags.Get("/:slug", v1.ConditionalGet, v1.GuestCache, v1.GetTag)
+	tags.Get("/:slug/posts", v1.ConditionalGet, v1.GuestCache, auth.OptionalAuth(opt), v1.GetTagFeed)
+	tags.Get("/:slug/feed.xml", v1.GetTagRSSFeed)
+	tags.Post("/", v1.CreateTag)
+	tags.Put("/:slug", v1.UpdateTag)
+	tags.Post("/:slug/follow", v1.Limiter.Handle(v1.FollowRateLimit), v1.FollowTag)
+	tags.Delete("/:slug/follow", v1.Limiter.Handle(v1.FollowRateLimit), v1.UnfollowTag)
+
+	// Organizations
+	orgs := app.Group("/orgs")
+	orgs.Get("/:slug", v1.ConditionalGet, v1.GuestCache, v1.GetOrganization)
+	orgs.Get("/:slug/posts", v1.ConditionalGet, v1.GuestCache, auth.OptionalAuth(opt), v1.GetOrganizationPosts)
+	orgs.Get("/:slug/members", v1.ConditionalGet, v1.GuestCache, v1.GetOrganizationMembers)
+	orgs.Post("/", v1.CreateOrganization)
+	orgs.Put("/:slug", v1.UpdateOrganization)
+	orgs.Post("/:slug/members", v1.InviteOrganizationMember)
+	orgs.Put("/:slug/members/:username", v1.UpdateOrganizationMember)
+	orgs.Delete("/:slug/members/:username", v1.RemoveOrganizationMember)
+	orgs.Post("/:slug/invitation/accept", v1.AcceptOrganizationInvite)
+	orgs.Post("/:slug/invitation/decline", v1.DeclineOrganizationInvite)
+
+	// Uploads
+	uploadRoutes := app.Group("/uploads")
+	uploadRoutes.Get("/files/*", v1.ServeUpload)
+	uploadRoutes.Post("/", v1.Limiter.Handle(v1.UploadRateLimit), v1.CreateUpload)
+	uploadRoutes.Get("/:id", v1.GetUpload)
+	uploadRoutes.Get("/:id/url", v1.GetUploadURL)
+
+	// Invitations
+	invitations := app.Group("/invitations")
+	invitations.Get("/", v1.ListInvitations)
+	invitations.Post("/", v1.CreateInvitation)
+	invitations.Delete("/:id", v1.RevokeInvitation)
+
+	// Webhooks
+	webhooks := app.Group("/webhooks")
+	webhooks.Get("/", v1.ListWebhooks)
+	webhooks.Post("/", v1.CreateWebhook)
+	webhooks.Put("/:id", v1.UpdateWebhook)
+	webhooks.Delete("/:id", v1.DeleteWebhook)
+	webhooks.Post("/:id/secret", v1.RotateWebhookSecret)
+	webhooks.Get("/:id/deliveries", v1.GetWebhookDeliveries)
+
+	// Admin routes
+	admin := app.Group("/admin")
+	admin.Get("/roles", v1.ListRoles)
+	admin.Post("/roles", v1.CreateRole)
+	admin.Get("/roles/templates", v1.ListRoleTemplates)
+	admin.Post("/roles/:role_id/clone", v1.CloneRole)
+	admin.Get("/permissions", v1.ListPermissions)
+	admin.Get("/roles/:role_id/users", v1.ListRoleUsers)
+	admin.Put("/roles/:role_id", v1.UpdateRole)
+	admin.Post("/roles/:role_id/permissions/preview", v1.PreviewRolePermissions)
+	admin.Post("/roles/:role_id/permissions", v1.UpdateRolePermissions)
+	admin.Delete("/roles/:role_id/permissions", v1.UpdateRolePermissions)
+
+	admin.Get("/stats", v1.GetAdminStats)
+
+	admin.Get("/users", v1.ListUsers)
+	admin.Get("/users/:id", v1.GetAdminUser)
+	admin.Get("/users/:id/moderation", v1.GetUserModeration)
+	admin.Post("/users/:id/suspend", v1.SuspendUser)
+	admin.Post("/users/:id/ban", v1.BanUser)
+	admin.Post("/users/:id/reinstate", v1.ReinstateUser)
+	admin.Post("/users/:id/logout", v1.ForceLogoutUser)
+	admin.Post("/users/:id/recount", v1.RecountUserStats)
+	admin.Put("/users/:id/role", v1.AssignUserRole)
+	admin.Post("/users/:id/impersonate", v1.ImpersonateUser)
+
+	admin.Get("/invitations", v1.ListAllInvitations)
+
+	admin.Get("/blocklist", v1.ListBlockedTerms)
+	admin.Post("/blocklist", v1.AddBlockedTerm)
+	admin.Delete("/blocklist/:id", v1.DeleteBlockedTerm)
+
+	admin.Get("/rate-limits", v1.ListRateLimits)
+	admin.Put("/rate-limits/:policy", v1.SetRateLimit)
+	admin.Delete("/rate-limits/overrides/:id", v1.DeleteRateLimit)
+
+	admin.Get("/feature-flags", v1.ListFeatureFlags)
+	admin.Post("/feature-flags", v1.CreateFeatureFlag)
+	admin.Patch("/feature-flags/:key", v1.UpdateFeatureFlag)
+	admin.Delete("/feature-flags/:key", v1.DeleteFeatureFlag)
+
+	admin.Get("/audit-logs", v1.ListAuditLogs)
+	admin.Get("/audit-logs/export", v1.ExportAuditLogs)
+
+	admin.Get("/email-templates", v1.ListEmailTemplates)
+	admin.Get("/email-templates/:name/preview", v1.PreviewEmailTemplate)
+	admin.Post("/email-templates/:name/test", v1.TestSendEmailTemplate)
+
+	if err := policy.Verify(app.GetRoutes(true)); err != nil {
+		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Invalid access policy")
+		panic(err)
+	}
+
+	go publishScheduledPosts(ctx, db, rclient, log)
+	go sendDigests(ctx, db, rclient, log)
+	go processDataExports(ctx, db, rclient, log)
+	go processOutbox(ctx, db, log)
+	go reconcileCounters(ctx, db, rclient, log)
+	go cleanupNotifications(ctx, db, rclient, retention, log)
+	go generateSitemap(ctx, log)
+	go aggregatePlatformStats(ctx, db, log)
+	go rollupActivity(ctx, db, rclient, log)
+	if len(v1.WebhookKey) > 0 {
+		go deliverWebhooks(ctx, db, log)
+	}
+	if cfg.GRPCAddr != "" {
+		serveGRPC(ctx, cfg, log)
+	}
 
 	go func() {
 		<-ctx.Done()
@@ -95,3 +535,293 @@ func NewRoutes(ctx context.Context, app *fiber.App, cfg *config.Config, db *gorm
 		log.Close()
 	}()
 }
+
+// publishScheduledPosts publishes scheduled posts once their time has come until ctx is cancelled.
+func publishScheduledPosts(ctx context.Context, db *gorm.DB, rclient *storage.RedisClient, log *logger.Logger) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := models.PublishDuePosts(ctx, rclient, db)
+			if err != nil {
+				log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to publish scheduled posts")
+			}
+			if published > 0 {
+				log.Info(ctx).WithMeta(utils.Map{"published": strconv.Itoa(published)}).Logs("Published scheduled posts")
+			}
+		}
+	}
+}
+
+// sendDigests mails due notification digests every quarter hour until ctx is cancelled.
+func sendDigests(ctx context.Context, db *gorm.DB, rclient *storage.RedisClient, log *logger.Logger) {
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := models.SendDigests(ctx, rclient, db, v1.SendDigestEmail)
+			if err != nil {
+				log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to send digests")
+			}
+			if sent > 0 {
+				log.Info(ctx).WithMeta(utils.Map{"sent": strconv.Itoa(sent)}).Logs("Sent notification digests")
+			}
+		}
+	}
+}
+
+// processDataExports builds queued data exports one at a time until ctx is cancelled, dropping
+// expired archives about once an hour.
+func processDataExports(ctx context.Context, db *gorm.DB, rclient *storage.RedisClient, log *logger.Logger) {
+	lastPurge := time.Time{}
+	for ctx.Err() == nil {
+		if time.Since(lastPurge) >= time.Hour {
+			purged, err := models.PurgeDataExports(ctx, db, v1.Uploads)
+			if err != nil {
+				log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to purge data exports")
+			}
+			if purged > 0 {
+				log.Info(ctx).WithMeta(utils.Map{"purged": strconv.FormatInt(purged, 10)}).Logs("Purged expired data exports")
+			}
+			lastPurge = time.Now()
+		}
+
+		id, err := models.NextDataExport(ctx, rclient, db, 30*time.Second)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to read data export queue")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(30 * time.Second):
+			}
+			continue
+		}
+		if id == uuid.Nil {
+			continue
+		}
+		processed, err := models.ProcessDataExport(ctx, db, v1.Uploads, id, v1.SendDataExportEmail)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error(), "export_id": id.String()}).Logs("Failed to process data export")
+			continue
+		}
+		if !processed {
+			continue
+		}
+		log.Info(ctx).WithMeta(utils.Map{"export_id": id.String()}).Logs("Processed data export")
+	}
+}
+
+// processOutbox handles due outbox events every few seconds, or as soon as a handler commits new
+// ones, until ctx is cancelled, dropping old processed events about once an hour.
+func processOutbox(ctx context.Context, db *gorm.DB, log *logger.Logger) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	lastPurge := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-v1.OutboxReady:
+		}
+
+		if time.Since(lastPurge) >= time.Hour {
+			purged, err := models.PurgeOutbox(ctx, db)
+			if err != nil {
+				log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to purge outbox events")
+			}
+			if purged > 0 {
+				log.Info(ctx).WithMeta(utils.Map{"purged": strconv.FormatInt(purged, 10)}).Logs("Purged processed outbox events")
+			}
+			lastPurge = time.Now()
+		}
+
+		processed, err := models.ProcessOutbox(ctx, db, 50, v1.HandleOutboxEvent)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to process outbox events")
+		}
+		if processed > 0 {
+			log.Info(ctx).WithMeta(utils.Map{"processed": strconv.Itoa(processed)}).Logs("Processed outbox events")
+		}
+	}
+}
+
+// reconcileCounters corrects drifted user and post counters every night at 03:00 UTC until ctx is
+// cancelled.
+func reconcileCounters(ctx context.Context, db *gorm.DB, rclient *storage.RedisClient, log *logger.Logger) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), 3, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		users, posts, err := models.ReconcileCounters(ctx, rclient, db)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to reconcile counters")
+			continue
+		}
+		if users > 0 || posts > 0 {
+			log.Info(ctx).WithMeta(utils.Map{"users": strconv.Itoa(users), "posts": strconv.Itoa(posts)}).Logs("Corrected drifted counters")
+		}
+	}
+}
+
+// cleanupNotifications applies the notification retention policy every night at 04:00 UTC until
+// ctx is cancelled.
+func cleanupNotifications(ctx context.Context, db *gorm.DB, rclient *storage.RedisClient, retention models.NotificationRetention, log *logger.Logger) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), 4, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		archived, deleted, err := models.ApplyNotificationRetention(ctx, rclient, db, retention)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to apply notification retention")
+			continue
+		}
+		if archived > 0 || deleted > 0 {
+			log.Info(ctx).WithMeta(utils.Map{"archived": strconv.FormatInt(archived, 10), "deleted": strconv.FormatInt(deleted, 10)}).Logs("Applied notification retention")
+		}
+	}
+}
+
+// aggregatePlatformStats computes the platform statistics right away and then every hour until ctx
+// is cancelled.
+func aggregatePlatformStats(ctx context.Context, db *gorm.DB, log *logger.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		stats, err := models.AggregatePlatformStats(ctx, db, time.Now())
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to aggregate platform stats")
+		} else {
+			log.Info(ctx).WithMeta(utils.Map{"day": stats.Day.Format("2006-01-02"), "active_users": strconv.FormatInt(stats.ActiveUsers, 10)}).Logs("Aggregated platform stats")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rollupActivity writes the activity counted in Redis today and yesterday into the database right
+// away and then every hour until ctx is cancelled.
+func rollupActivity(ctx context.Context, db *gorm.DB, rclient *storage.RedisClient, log *logger.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		rows, err := models.RollupActivity(ctx, rclient, db, time.Now())
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to roll up activity")
+		} else if rows > 0 {
+			log.Info(ctx).WithMeta(utils.Map{"rows": strconv.Itoa(rows)}).Logs("Rolled up activity")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// generateSitemap rebuilds the sitemap right away and then every hour until ctx is cancelled.
+func generateSitemap(ctx context.Context, log *logger.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		listed, err := v1.BuildSitemap(ctx)
+		if err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to build sitemap")
+		} else {
+			log.Info(ctx).WithMeta(utils.Map{"urls": strconv.Itoa(listed)}).Logs("Built sitemap")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverWebhooks sends due webhook deliveries every few seconds until ctx is cancelled, dropping
+// old entries of the delivery log about once an hour.
+func deliverWebhooks(ctx context.Context, db *gorm.DB, log *logger.Logger) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	lastPurge := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if time.Since(lastPurge) >= time.Hour {
+				purged, err := models.PurgeWebhookDeliveries(ctx, db)
+				if err != nil {
+					log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to purge webhook deliveries")
+				}
+				if purged > 0 {
+					log.Info(ctx).WithMeta(utils.Map{"purged": strconv.FormatInt(purged, 10)}).Logs("Purged old webhook deliveries")
+				}
+				lastPurge = time.Now()
+			}
+
+			delivered, err := models.DeliverDueWebhooks(ctx, db, 50, v1.SendWebhook)
+			if err != nil {
+				log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to deliver webhooks")
+			}
+			if delivered > 0 {
+				log.Info(ctx).WithMeta(utils.Map{"delivered": strconv.Itoa(delivered)}).Logs("Delivered webhooks")
+			}
+		}
+	}
+}
+
+// serveGRPC starts the internal gRPC API on the services the handlers use, until ctx is cancelled.
+func serveGRPC(ctx context.Context, cfg *config.Config, log *logger.Logger) {
+	srv, err := internalgrpc.NewServer(internalgrpc.Options{
+		Services:     v1.Services,
+		Logger:       log,
+		CertFile:     cfg.GRPCCertFile,
+		KeyFile:      cfg.GRPCKeyFile,
+		ClientCAFile: cfg.GRPCClientCAFile,
+	})
+	if err != nil {
+		log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("Failed to initialize gRPC server")
+		panic(err)
+	}
+	if cfg.GRPCClientCAFile == "" {
+		log.Warn(ctx).Logs("gRPC server running without client certificates")
+	}
+	go func() {
+		log.Info(ctx).WithMeta(utils.Map{"addr": cfg.GRPCAddr}).Logs("Starting gRPC server")
+		if err := internalgrpc.Serve(ctx, srv, cfg.GRPCAddr); err != nil {
+			log.Error(ctx).WithMeta(utils.Map{"error": err.Error()}).Logs("gRPC server failed")
+		}
+	}()
+}
@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+)
+
+// recordActivity counts an activity of the user towards their streak and heatmap. Failing to
+// count it is logged and does not fail the request it came with.
+func recordActivity(c *fiber.Ctx, userID uuid.UUID, kind string) {
+	if err := models.RecordActivity(c.Context(), Redis, DB, userID, kind, time.Now()); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "kind", kind).Logs("Failed to record activity")
+	}
+}
+
+// GetMyStreak returns the authenticated user's current and longest streak of active days
+func GetMyStreak(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetMyStreak attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetMyStreak")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	streak, err := models.GetStreak(c.Context(), DB, userID, time.Now())
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch streak")
+		return apierror.From(err, "Failed to fetch streak")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":    "Streak retrieved successfully",
+		"status":     fiber.StatusOK,
+		"streak":     streak,
+		"milestones": models.StreakMilestones,
+	})
+}
+
+// GetMyActivity returns the authenticated user's activity heatmap of ?year, the current year by
+// default
+func GetMyActivity(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetMyActivity attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetMyActivity")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	now := time.Now().UTC()
+	year := c.QueryInt("year", now.Year())
+	if year < 2000 || year > now.Year() {
+		return apierror.BadRequest("Invalid year")
+	}
+
+	heatmap, err := models.GetActivityHeatmap(c.Context(), Redis, DB, userID, year, now)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID, "year", year).Logs("Failed to fetch activity")
+		return apierror.From(err, "Failed to fetch activity")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Activity retrieved successfully",
+		"status":   fiber.StatusOK,
+		"activity": heatmap,
+	})
+}
@@ -0,0 +1,413 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/auth"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// restrictedAccount answers a sign-in attempt on a banned or suspended account
+func restrictedAccount(c *fiber.Ctx, user *models.User) error {
+	details := fiber.Map{"reason": user.StatusReason}
+	if user.Status == models.UserStatusSuspended && user.SuspendedUntil != nil {
+		details["suspended_until"] = user.SuspendedUntil
+	}
+	return apierror.Forbidden("Account is " + user.Status).WithDetails(details)
+}
+
+// moderationTarget reads the acting moderator and the user named by the :id param. On failure the
+// actor ID is uuid.Nil and the error answers the request.
+func moderationTarget(c *fiber.Ctx, action string) (uuid.UUID, uuid.UUID, error) {
+	actorIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || actorIDRaw == "" {
+		Logger.Warn(c.Context()).Logs(action + " attempted without user_id in context")
+		return uuid.Nil, uuid.Nil, apierror.Unauthorized("Unauthorized")
+	}
+	actorID, err := uuid.Parse(actorIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", actorIDRaw).Logs("Invalid user_id format in " + action)
+		return uuid.Nil, uuid.Nil, apierror.BadRequest("Invalid user ID")
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("target_id", c.Params("id")).Logs("Invalid target user ID in " + action)
+		return uuid.Nil, uuid.Nil, apierror.BadRequest("Invalid user ID")
+	}
+	return actorID, userID, nil
+}
+
+// ListUsers lists accounts for moderators, filtered by ?q=, ?status= and ?role_id=
+func ListUsers(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := models.UserFilter{Query: c.Query("q")}
+	switch status := strings.ToLower(strings.TrimSpace(c.Query("status"))); status {
+	case "":
+	case models.UserStatusActive, models.UserStatusSuspended, models.UserStatusBanned:
+		filter.Status = status
+	default:
+		return apierror.BadRequest("Status must be active, suspended or banned")
+	}
+	if raw := c.Query("role_id"); raw != "" {
+		roleID, err := uuid.Parse(raw)
+		if err != nil {
+			return apierror.BadRequest("Invalid role ID")
+		}
+		filter.RoleID = &roleID
+	}
+
+	users, total, err := models.ListUsers(c.Context(), DB, filter, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to list users")
+		return postError(c, err, "Failed to fetch users")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Users retrieved successfully",
+		"status":  fiber.StatusOK,
+		"users":   users,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetAdminUser returns the profile of any user with the account details staff may see, picked
+// with ?fields= as on the profile endpoints
+func GetAdminUser(c *fiber.Ctx) error {
+	actorID, userID, err := moderationTarget(c, "GetAdminUser")
+	if actorID == uuid.Nil {
+		return err
+	}
+	fields, err := profileFields(c)
+	if err != nil {
+		return err
+	}
+
+	users, err := models.GetUsersByID(c.Context(), Redis, DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "target_id", userID).Logs("Failed to fetch user")
+		return postError(c, err, "Failed to fetch user")
+	}
+	user, ok := users[userID]
+	if !ok {
+		return apierror.NotFound("User not found")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User retrieved successfully",
+		"status":  fiber.StatusOK,
+		"user":    BuildProfileResponse(requestViewer(c), user, fields...),
+	})
+}
+
+// SuspendUser suspends an account until a given time, or indefinitely, and signs it out
+func SuspendUser(c *fiber.Ctx) error {
+	type SuspendUserRequest struct {
+		Reason string     `json:"reason" validate:"required,min=3,max=255"`
+		Until  *time.Time `json:"until"`
+	}
+
+	var req SuspendUserRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return apierror.BadRequest("Suspension end must be in the future")
+	}
+
+	return setUserStatus(c, models.UserStatusSuspended, req.Reason, req.Until)
+}
+
+// BanUser bans an account for good and signs it out
+func BanUser(c *fiber.Ctx) error {
+	type BanUserRequest struct {
+		Reason string `json:"reason" validate:"required,min=3,max=255"`
+	}
+
+	var req BanUserRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	return setUserStatus(c, models.UserStatusBanned, req.Reason, nil)
+}
+
+// ReinstateUser lifts a suspension or ban
+func ReinstateUser(c *fiber.Ctx) error {
+	return setUserStatus(c, models.UserStatusActive, "", nil)
+}
+
+func setUserStatus(c *fiber.Ctx, status, reason string, until *time.Time) error {
+	actorID, userID, err := moderationTarget(c, "SetUserStatus")
+	if actorID == uuid.Nil {
+		return err
+	}
+
+	user, err := models.SetUserStatus(c.Context(), Redis, DB, userID, actorID, status, reason, until, c.IP())
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "actor_id", actorID, "target_id", userID).Logs("Failed to update user status")
+		return postError(c, err, "Failed to update user status")
+	}
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "target_id", userID, "status", status).Logs("User status updated")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":         "User status updated successfully",
+		"status":          fiber.StatusOK,
+		"user_id":         user.ID,
+		"account_status":  user.Status,
+		"status_reason":   user.StatusReason,
+		"suspended_until": user.SuspendedUntil,
+	})
+}
+
+// ForceLogoutUser signs a user out of every session
+func ForceLogoutUser(c *fiber.Ctx) error {
+	actorID, userID, err := moderationTarget(c, "ForceLogoutUser")
+	if actorID == uuid.Nil {
+		return err
+	}
+
+	type ForceLogoutRequest struct {
+		Reason string `json:"reason" validate:"omitempty,max=255"`
+	}
+	var req ForceLogoutRequest
+	if len(c.Body()) > 0 {
+		if err := utils.StrictBodyParser(c, &req); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+			return apierror.BadRequest("Invalid request body")
+		}
+		if err := Validator.Validate(req); err != nil {
+			return apierror.Validation(err)
+		}
+	}
+
+	if err := models.ForceLogout(c.Context(), Redis, DB, userID, actorID, req.Reason, c.IP()); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "actor_id", actorID, "target_id", userID).Logs("Failed to force logout")
+		return postError(c, err, "Failed to sign user out")
+	}
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "target_id", userID).Logs("User signed out by moderator")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User signed out of all sessions",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// RecountUserStats recomputes a user's post, comment and bookmark counters from the rows they count
+func RecountUserStats(c *fiber.Ctx) error {
+	actorID, userID, err := moderationTarget(c, "RecountUserStats")
+	if actorID == uuid.Nil {
+		return err
+	}
+
+	user, err := models.RecountUserStats(c.Context(), Redis, DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "target_id", userID).Logs("Failed to recount user stats")
+		return postError(c, err, "Failed to recount user stats")
+	}
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "target_id", userID).Logs("User stats recounted")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User stats recounted",
+		"status":  fiber.StatusOK,
+		"stats":   user.Stats,
+	})
+}
+
+// GetUserModeration returns the moderation trail of a user
+func GetUserModeration(c *fiber.Ctx) error {
+	actorID, userID, err := moderationTarget(c, "GetUserModeration")
+	if actorID == uuid.Nil {
+		return err
+	}
+
+	actions, err := models.GetModerationActions(c.Context(), DB, userID, 50)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "target_id", userID).Logs("Failed to fetch moderation actions")
+		return postError(c, err, "Failed to fetch moderation actions")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Moderation actions retrieved successfully",
+		"status":  fiber.StatusOK,
+		"actions": actions,
+	})
+}
+
+// AssignUserRole moves a user to another role
+func AssignUserRole(c *fiber.Ctx) error {
+	type AssignRoleRequest struct {
+		RoleID string `json:"role_id" validate:"required,uuid"`
+	}
+
+	actorID, userID, err := moderationTarget(c, "AssignUserRole")
+	if actorID == uuid.Nil {
+		return err
+	}
+
+	var req AssignRoleRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	roleID := uuid.MustParse(req.RoleID)
+
+	prev, next, err := models.AssignRole(c.Context(), Redis, DB, userID, roleID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "actor_id", actorID, "target_id", userID, "role_id", roleID).Logs("Failed to assign role")
+		return postError(c, err, "Failed to assign role")
+	}
+	recordAudit(c, actorID, models.AuditUserRoleAssign, models.AuditTargetUser, userID,
+		map[string]interface{}{"role_id": prev.ID, "role": prev.Name},
+		map[string]interface{}{"role_id": next.ID, "role": next.Name})
+
+	if SessionPolicy.OnRoleChange && prev.ID != next.ID {
+		if err := models.RevokeSessions(c.Context(), Redis, DB, userID); err != nil {
+			Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to revoke sessions after role change")
+		} else if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventSessionsRevoked, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record session revocation")
+		}
+	}
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "target_id", userID, "role", next.Name).Logs("Role assigned")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Role assigned successfully",
+		"status":  fiber.StatusOK,
+		"user_id": userID,
+		"role":    fiber.Map{"id": next.ID, "name": next.Name},
+	})
+}
+
+// ImpersonateUser issues a short-lived bearer token letting support staff act as a user to debug
+// what they report. Staff cannot impersonate themselves, restricted accounts or users holding
+// permissions they lack, and everything done with the token is audited.
+func ImpersonateUser(c *fiber.Ctx) error {
+	type ImpersonateRequest struct {
+		Reason string `json:"reason" validate:"required,min=3,max=255"`
+	}
+
+	actorID, userID, err := moderationTarget(c, "ImpersonateUser")
+	if actorID == uuid.Nil {
+		return err
+	}
+	if _, ok := c.Locals("impersonator_id").(uuid.UUID); ok {
+		return apierror.Forbidden("Cannot impersonate while impersonating")
+	}
+	if actorID == userID {
+		return apierror.BadRequest("You cannot impersonate yourself")
+	}
+
+	var req ImpersonateRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{userID}, models.PreloadMinimal)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "target_id", userID).Logs("Impersonation target not found")
+		return postError(c, err, "Failed to fetch user")
+	}
+	if user.IsRestricted() {
+		return apierror.Forbidden("Cannot impersonate an account that is " + user.Status)
+	}
+
+	actorRoleID, _ := c.Locals("role_id").(uuid.UUID)
+	actorPerms, err := models.GetPermissionSnapshot(c.Context(), Redis, DB, actorRoleID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "role_id", actorRoleID).Logs("Failed to get permissions")
+		return postError(c, err, "Failed to impersonate user")
+	}
+	userPerms, err := models.GetPermissionSnapshot(c.Context(), Redis, DB, user.RoleID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "role_id", user.RoleID).Logs("Failed to get permissions")
+		return postError(c, err, "Failed to impersonate user")
+	}
+	for _, perm := range userPerms.Permissions {
+		if !actorPerms.Has(perm) {
+			Logger.Warn(c.Context()).WithFields("actor_id", actorID, "target_id", userID, "permission", perm).Logs("Impersonation would escalate privileges")
+			return apierror.Forbidden("Cannot impersonate a user holding permissions you lack")
+		}
+	}
+
+	token, expiresAt, err := auth.GenerateImpersonationToken(user.ID.String(), user.RoleID.String(), user.TokenVersion, actorID.String())
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "target_id", userID).Logs("Failed to generate impersonation token")
+		return apierror.Internal("Failed to impersonate user")
+	}
+	recordAudit(c, actorID, models.AuditUserImpersonate, models.AuditTargetUser, userID, nil,
+		map[string]interface{}{"reason": req.Reason, "expires_at": expiresAt})
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "target_id", userID).Logs("Impersonation started")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":      "Impersonation token issued",
+		"status":       fiber.StatusOK,
+		"token_type":   "Bearer",
+		"access_token": token,
+		"expires_at":   expiresAt,
+		"expires_in":   int(auth.ImpersonationTTL.Seconds()),
+		"user":         user.Summary(),
+	})
+}
+
+// GetAdminStats returns the platform statistics of the last ?days= days (30 by default), oldest
+// first, with the latest day's figures on their own
+func GetAdminStats(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 30)
+	if days < 1 || days > models.MaxPlatformStatsDays {
+		return apierror.BadRequest(fmt.Sprintf("Days must be between 1 and %d", models.MaxPlatformStatsDays))
+	}
+
+	daily, err := models.GetPlatformStats(c.Context(), DB, days)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch platform stats")
+		return apierror.Internal("Failed to fetch platform stats")
+	}
+	var latest *models.PlatformStats
+	if len(daily) > 0 {
+		latest = &daily[len(daily)-1]
+	}
+
+	c.Set("Cache-Control", "no-store, private")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Platform stats retrieved successfully",
+		"status":  fiber.StatusOK,
+		"latest":  latest,
+		"daily":   daily,
+		"days":    days,
+	})
+}
@@ -0,0 +1,142 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// apiKeyResponse is the representation of an API key shown to its owner; the token is only ever
+// returned when the key is created
+func apiKeyResponse(key *models.APIKey) fiber.Map {
+	return fiber.Map{
+		"id":           key.ID,
+		"name":         key.Name,
+		"prefix":       key.Prefix,
+		"scopes":       key.ScopeList(),
+		"last_used_at": key.LastUsedAt,
+		"last_used_ip": key.LastUsedIP,
+		"expires_at":   key.ExpiresAt,
+		"created_at":   key.CreatedAt,
+	}
+}
+
+// ListAPIKeys returns the authenticated user's active API keys
+func ListAPIKeys(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("ListAPIKeys attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in ListAPIKeys")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	keys, err := models.GetUserAPIKeys(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch API keys")
+		return apierror.From(err, "Failed to fetch API keys")
+	}
+	items := make([]fiber.Map, 0, len(keys))
+	for i := range keys {
+		items = append(items, apiKeyResponse(&keys[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "API keys retrieved successfully",
+		"status":   fiber.StatusOK,
+		"api_keys": items,
+		"scopes":   models.APIKeyScopes,
+	})
+}
+
+// CreateAPIKey issues a personal access token for the authenticated user. Clients send it as
+// "Authorization: Bearer devpulse_pat_..."; it is returned once and only its hash is stored.
+func CreateAPIKey(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("CreateAPIKey attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in CreateAPIKey")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	type CreateAPIKeyRequest struct {
+		Name          string   `json:"name" validate:"required,min=1,max=100"`
+		Scopes        []string `json:"scopes" validate:"required,min=1,max=10"`
+		ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+	}
+	var req CreateAPIKeyRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		at := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &at
+	}
+	key, token, err := models.CreateAPIKey(c.Context(), DB, userID, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to create API key")
+		return apierror.From(err, "Failed to create API key")
+	}
+	if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventAPIKeyCreated, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record API key creation")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "api_key_id", key.ID).Logs("API key created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "API key created; store the token now, it will not be shown again",
+		"status":  fiber.StatusCreated,
+		"api_key": apiKeyResponse(key),
+		"token":   token,
+	})
+}
+
+// RevokeAPIKey revokes one of the authenticated user's API keys
+func RevokeAPIKey(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("RevokeAPIKey attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in RevokeAPIKey")
+		return apierror.BadRequest("Invalid user ID")
+	}
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("api_key_id", c.Params("id")).Logs("Invalid API key ID")
+		return apierror.BadRequest("Invalid API key ID")
+	}
+
+	if err := models.RevokeAPIKey(c.Context(), DB, userID, keyID); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "api_key_id", keyID).Logs("Failed to revoke API key")
+		return apierror.From(err, "Failed to revoke API key")
+	}
+	if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventAPIKeyRevoked, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record API key revocation")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "api_key_id", keyID).Logs("API key revoked")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "API key revoked successfully",
+		"status":  fiber.StatusOK,
+	})
+}
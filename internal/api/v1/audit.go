@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+)
+
+// auditActor returns the authenticated user, or uuid.Nil outside an authenticated route
+func auditActor(c *fiber.Ctx) uuid.UUID {
+	userIDRaw, _ := c.Locals("user_id").(string)
+	actorID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		return uuid.Nil
+	}
+	return actorID
+}
+
+// recordAudit appends an audit entry for a privileged action that already succeeded, so a failure
+// is logged instead of failing the request
+func recordAudit(c *fiber.Ctx, actorID uuid.UUID, action, targetType string, targetID uuid.UUID, before, after map[string]interface{}) {
+	if err := models.RecordAudit(c.Context(), DB, actorID, action, targetType, targetID, before, after, c.IP()); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "action", action, "actor_id", actorID, "target_id", targetID).Logs("Failed to record audit entry")
+	}
+}
+
+// roleAuditState is the part of a role recorded in the audit log
+func roleAuditState(role *models.Role) map[string]interface{} {
+	if role == nil {
+		return nil
+	}
+	perms := make([]string, len(role.Permissions))
+	for i, perm := range role.Permissions {
+		perms[i] = perm.Name
+	}
+	return map[string]interface{}{
+		"name":        role.Name,
+		"permissions": perms,
+	}
+}
+
+// auditFilter reads the audit log filters from the query string. On failure the
+// filter is nil and the error answers the request.
+func auditFilter(c *fiber.Ctx) (*models.AuditFilter, error) {
+	filter := &models.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+	}
+	for param, dst := range map[string]**uuid.UUID{"actor_id": &filter.ActorID, "target_id": &filter.TargetID} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, apierror.BadRequest("Invalid " + param)
+		}
+		*dst = &id
+	}
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, apierror.BadRequest("The " + param + " parameter must be an RFC 3339 time")
+		}
+		*dst = &t
+	}
+	return filter, nil
+}
+
+// ListAuditLogs returns a page of the audit log, filtered by ?actor_id=, ?target_id=, ?action=,
+// ?target_type=, ?from= and ?to=
+func ListAuditLogs(c *fiber.Ctx) error {
+	filter, err := auditFilter(c)
+	if filter == nil {
+		return err
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	logs, total, err := models.ListAuditLogs(c.Context(), DB, *filter, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch audit logs")
+		return postError(c, err, "Failed to fetch audit logs")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Audit logs retrieved successfully",
+		"status":  fiber.StatusOK,
+		"logs":    logs,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// auditExportTimeout bounds how long an audit log export may take to stream.
+const auditExportTimeout = 5 * time.Minute
+
+// ExportAuditLogs downloads the filtered audit log as ?format=csv or json. Entries are streamed a
+// batch at a time as they are read; the first batch is read up front so a failing query still
+// answers with an error rather than an empty file.
+func ExportAuditLogs(c *fiber.Ctx) error {
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "json" {
+		return apierror.BadRequest("Format must be csv or json")
+	}
+
+	filter, err := auditFilter(c)
+	if filter == nil {
+		return err
+	}
+
+	next := models.ExportAuditLogs(DB, *filter)
+	logs, err := next(c.Context())
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to export audit logs")
+		return postError(c, err, "Failed to export audit logs")
+	}
+
+	write := writeAuditCSV
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	if format == "json" {
+		write = writeAuditJSON
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+
+	actorID := auditActor(c)
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "format", format).Logs("Audit logs export started")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="audit-logs-`+time.Now().UTC().Format("20060102")+`.`+format+`"`)
+	c.Status(fiber.StatusOK)
+	// The stream outlives the request context, so it gets its own.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), auditExportTimeout)
+		defer cancel()
+
+		count, err := write(ctx, w, logs, next)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			Logger.Error(ctx).WithFields("error", err, "actor_id", actorID, "count", count).Logs("Audit logs export interrupted")
+			return
+		}
+		Logger.Info(ctx).WithFields("actor_id", actorID, "format", format, "count", count).Logs("Audit logs exported")
+	})
+	return nil
+}
+
+// writeAuditCSV writes the audit entries yielded by next, starting with first, as CSV and returns
+// how many it wrote.
+func writeAuditCSV(ctx context.Context, w *bufio.Writer, first []models.AuditLog, next func(context.Context) ([]models.AuditLog, error)) (int, error) {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "created_at", "actor_id", "action", "target_type", "target_id", "before", "after", "ip"})
+	count := 0
+	for logs := first; len(logs) > 0; {
+		for _, entry := range logs {
+			cw.Write([]string{
+				entry.ID.String(),
+				entry.CreatedAt.UTC().Format(time.RFC3339),
+				entry.ActorID.String(),
+				entry.Action,
+				entry.TargetType,
+				entry.TargetID.String(),
+				string(entry.Before),
+				string(entry.After),
+				entry.IP,
+			})
+		}
+		count += len(logs)
+		if cw.Flush(); cw.Error() != nil {
+			return count, cw.Error()
+		}
+		var err error
+		if logs, err = next(ctx); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// writeAuditJSON writes the audit entries yielded by next, starting with first, as a JSON array
+// and returns how many it wrote.
+func writeAuditJSON(ctx context.Context, w *bufio.Writer, first []models.AuditLog, next func(context.Context) ([]models.AuditLog, error)) (int, error) {
+	w.WriteByte('[')
+	count := 0
+	for logs := first; len(logs) > 0; {
+		for i := range logs {
+			if count > 0 {
+				w.WriteByte(',')
+			}
+			data, err := json.Marshal(&logs[i])
+			if err != nil {
+				return count, err
+			}
+			if _, err := w.Write(data); err != nil {
+				return count, err
+			}
+			count++
+		}
+		var err error
+		if logs, err = next(ctx); err != nil {
+			return count, err
+		}
+	}
+	return count, w.WriteByte(']')
+}
@@ -0,0 +1,353 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	v1 "github.com/mnuddindev/devpulse/internal/api/v1"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+)
+
+// registration is a valid registration request body.
+func registration(username string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":             "Test Person " + username,
+		"username":         username,
+		"email":            username + "@example.com",
+		"password":         testutil.Password,
+		"confirm_password": testutil.Password,
+	}
+}
+
+// pendingActivation returns the activation token and code of the last registration, storing the
+// code the way the outbox worker does. Mailing it fails without a mail server, which the test
+// does not need.
+func pendingActivation(t *testing.T, e *testutil.Env) models.UserRegisteredEvent {
+	t.Helper()
+	var event models.OutboxEvent
+	if err := e.DB.Where("kind = ?", models.OutboxUserRegistered).Order("created_at DESC").First(&event).Error; err != nil {
+		t.Fatalf("finding registration event: %v", err)
+	}
+	var registered models.UserRegisteredEvent
+	if err := json.Unmarshal(event.Payload, &registered); err != nil {
+		t.Fatal(err)
+	}
+	v1.HandleOutboxEvent(context.Background(), &event)
+	return registered
+}
+
+type tokensBody struct {
+	Tokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	} `json:"tokens"`
+}
+
+// login signs user in with testutil.Password and returns the session's tokens.
+func login(t *testing.T, e *testutil.Env, email string) tokensBody {
+	t.Helper()
+	res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/login", Body: map[string]string{"email": email, "password": testutil.Password}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("POST /login: status %d: %s", res.Status, res.Body)
+	}
+	var body tokensBody
+	res.JSON(t, &body)
+	if body.Tokens.AccessToken == "" || body.Tokens.RefreshToken == "" {
+		t.Fatalf("POST /login: no tokens in %s", res.Body)
+	}
+	return body
+}
+
+func TestRegister(t *testing.T) {
+	testutil.Run(t, []testutil.Case{
+		{
+			Name:    "valid registration",
+			Request: testutil.Request{Method: http.MethodPost, Path: "/register", Body: registration("newcomer")},
+			Status:  http.StatusCreated,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var user models.User
+				if err := e.DB.Where("username = ?", "newcomer").First(&user).Error; err != nil {
+					t.Fatalf("registered user not stored: %v", err)
+				}
+				if user.IsActive {
+					t.Fatal("registered user is active before activation")
+				}
+			},
+		},
+		{
+			Name: "mismatched confirmation",
+			Request: testutil.Request{Method: http.MethodPost, Path: "/register", Body: func() map[string]interface{} {
+				body := registration("newcomer")
+				body["confirm_password"] = "something-else"
+				return body
+			}()},
+			Status: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:    "unknown field",
+			Request: testutil.Request{Method: http.MethodPost, Path: "/register", Body: map[string]interface{}{"username": "newcomer", "role": "admin"}},
+			Status:  http.StatusBadRequest,
+		},
+		{
+			// Outside development the API does not reveal which emails have accounts
+			Name: "taken email",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				body := registration("newcomer")
+				body["email"] = e.CreateUser(t, models.RoleMember).Email
+				req.Body = body
+			},
+			Request: testutil.Request{Method: http.MethodPost, Path: "/register"},
+			Status:  http.StatusCreated,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body map[string]interface{}
+				res.JSON(t, &body)
+				if _, ok := body["user"]; ok {
+					t.Fatalf("registration with a taken email answered with a user: %s", res.Body)
+				}
+				var n int64
+				if err := e.DB.Model(&models.User{}).Where("username = ?", "newcomer").Count(&n).Error; err != nil {
+					t.Fatal(err)
+				}
+				if n != 0 {
+					t.Fatal("registration with a taken email created a user")
+				}
+			},
+		},
+	})
+}
+
+func TestActivate(t *testing.T) {
+	register := func(t *testing.T, e *testutil.Env) models.UserRegisteredEvent {
+		t.Helper()
+		if res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/register", Body: registration("newcomer")}); res.Status != http.StatusCreated {
+			t.Fatalf("POST /register: status %d: %s", res.Status, res.Body)
+		}
+		return pendingActivation(t, e)
+	}
+
+	testutil.Run(t, []testutil.Case{
+		{
+			Name: "valid code",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				pending := register(t, e)
+				req.Path = "/activate?token=" + pending.Token
+				req.Body = map[string]string{"otp": pending.OTP}
+			},
+			Request: testutil.Request{Method: http.MethodPost},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var user models.User
+				if err := e.DB.Where("username = ?", "newcomer").First(&user).Error; err != nil {
+					t.Fatal(err)
+				}
+				if !user.IsActive {
+					t.Fatal("user not active after activation")
+				}
+				login(t, e, user.Email)
+			},
+		},
+		{
+			Name: "wrong code",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				pending := register(t, e)
+				req.Path = "/activate?token=" + pending.Token
+				req.Body = map[string]string{"otp": "000000x"}
+			},
+			Request: testutil.Request{Method: http.MethodPost},
+			Status:  http.StatusBadRequest,
+		},
+		{
+			Name:    "unknown token",
+			Request: testutil.Request{Method: http.MethodPost, Path: "/activate?token=missing", Body: map[string]string{"otp": "123456"}},
+			Status:  http.StatusBadRequest,
+		},
+		{
+			Name:    "missing code",
+			Request: testutil.Request{Method: http.MethodPost, Path: "/activate?token=missing", Body: map[string]string{}},
+			Status:  http.StatusUnprocessableEntity,
+		},
+	})
+}
+
+func TestLogin(t *testing.T) {
+	var user *models.User
+	createUser := func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+		user = e.CreateUser(t, models.RoleMember)
+		req.Body = map[string]string{"email": user.Email, "password": testutil.Password}
+	}
+
+	testutil.Run(t, []testutil.Case{
+		{
+			Name:    "valid credentials",
+			Setup:   createUser,
+			Request: testutil.Request{Method: http.MethodPost, Path: "/login"},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body tokensBody
+				res.JSON(t, &body)
+				me := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/users/me", Token: body.Tokens.AccessToken})
+				if me.Status != http.StatusOK {
+					t.Fatalf("GET /users/me with the new token: status %d: %s", me.Status, me.Body)
+				}
+			},
+		},
+		{
+			Name: "wrong password",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				createUser(t, e, req)
+				req.Body = map[string]string{"email": user.Email, "password": "wrong-password"}
+			},
+			Request: testutil.Request{Method: http.MethodPost, Path: "/login"},
+			Status:  http.StatusUnauthorized,
+		},
+		{
+			Name:    "unknown email",
+			Request: testutil.Request{Method: http.MethodPost, Path: "/login", Body: map[string]string{"email": "nobody@example.com", "password": testutil.Password}},
+			Status:  http.StatusUnauthorized,
+		},
+		{
+			Name: "unverified account",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				user = e.CreateUser(t, models.RoleMember, models.WithEmailVerified(false))
+				req.Body = map[string]string{"email": user.Email, "password": testutil.Password}
+			},
+			Request: testutil.Request{Method: http.MethodPost, Path: "/login"},
+			Status:  http.StatusForbidden,
+		},
+		{
+			Name:    "invalid email",
+			Request: testutil.Request{Method: http.MethodPost, Path: "/login", Body: map[string]string{"email": "not-an-email", "password": testutil.Password}},
+			Status:  http.StatusUnprocessableEntity,
+		},
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	var session tokensBody
+	signIn := func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+		session = login(t, e, e.CreateUser(t, models.RoleMember).Email)
+		req.Body = map[string]string{"refresh_token": session.Tokens.RefreshToken}
+	}
+
+	testutil.Run(t, []testutil.Case{
+		{
+			Name:    "valid refresh token",
+			Setup:   signIn,
+			Request: testutil.Request{Method: http.MethodPost, Path: "/refresh-token"},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body tokensBody
+				res.JSON(t, &body)
+				if body.Tokens.RefreshToken == "" || body.Tokens.RefreshToken == session.Tokens.RefreshToken {
+					t.Fatalf("refresh token not rotated: %s", res.Body)
+				}
+			},
+		},
+		{
+			Name: "reused refresh token",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				signIn(t, e, req)
+				if res := e.Do(t, *req); res.Status != http.StatusOK {
+					t.Fatalf("first refresh: status %d: %s", res.Status, res.Body)
+				}
+			},
+			Request: testutil.Request{Method: http.MethodPost, Path: "/refresh-token"},
+			Status:  http.StatusUnauthorized,
+		},
+		{
+			Name:    "unknown refresh token",
+			Request: testutil.Request{Method: http.MethodPost, Path: "/refresh-token", Body: map[string]string{"refresh_token": "unknown"}},
+			Status:  http.StatusUnauthorized,
+		},
+		{
+			Name:    "missing refresh token",
+			Request: testutil.Request{Method: http.MethodPost, Path: "/refresh-token", Body: map[string]string{}},
+			Status:  http.StatusUnauthorized,
+		},
+	})
+}
+
+func TestLogout(t *testing.T) {
+	var session tokensBody
+	testutil.Run(t, []testutil.Case{
+		{
+			Name: "revokes the session",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				session = login(t, e, e.CreateUser(t, models.RoleMember).Email)
+				req.Token = session.Tokens.AccessToken
+				req.Body = map[string]string{"refresh_token": session.Tokens.RefreshToken}
+			},
+			Request: testutil.Request{Method: http.MethodPost, Path: "/logout"},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				if res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/users/me", Token: session.Tokens.AccessToken}); res.Status != http.StatusUnauthorized {
+					t.Fatalf("GET /users/me after logout: status %d, want %d", res.Status, http.StatusUnauthorized)
+				}
+				refresh := testutil.Request{Method: http.MethodPost, Path: "/refresh-token", Body: map[string]string{"refresh_token": session.Tokens.RefreshToken}}
+				if res := e.Do(t, refresh); res.Status != http.StatusUnauthorized {
+					t.Fatalf("refresh after logout: status %d, want %d", res.Status, http.StatusUnauthorized)
+				}
+			},
+		},
+		{
+			Name:    "without a session",
+			Request: testutil.Request{Method: http.MethodPost, Path: "/logout", Body: map[string]string{}},
+			Status:  http.StatusOK,
+		},
+	})
+}
+
+// TestPrivacyModeUniformResponses checks that, in privacy mode, requests about an existing account
+// are answered exactly like the same requests about an account that does not exist.
+func TestPrivacyModeUniformResponses(t *testing.T) {
+	e := testutil.Setup(t)
+	if !v1.PrivacyMode {
+		t.Fatal("privacy mode is off outside development")
+	}
+	existing := e.CreateUser(t, models.RoleMember)
+	unverified := e.CreateUser(t, models.RoleMember, models.WithEmailVerified(false))
+
+	cases := []struct {
+		name    string
+		path    string
+		exists  interface{}
+		missing interface{}
+	}{
+		{
+			name:    "login with a wrong password",
+			path:    "/login",
+			exists:  map[string]string{"email": existing.Email, "password": "wrong-password"},
+			missing: map[string]string{"email": "nobody@example.com", "password": "wrong-password"},
+		},
+		{
+			name:    "login to an unverified account",
+			path:    "/login",
+			exists:  map[string]string{"email": unverified.Email, "password": "wrong-password"},
+			missing: map[string]string{"email": "nobody@example.com", "password": "wrong-password"},
+		},
+		{
+			name:    "forgot password",
+			path:    "/auth/password/forgot",
+			exists:  map[string]string{"email": existing.Email},
+			missing: map[string]string{"email": "nobody@example.com"},
+		},
+		{
+			name:    "registration",
+			path:    "/register",
+			exists:  registration(existing.Username),
+			missing: registration("newcomer"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			exists := e.Do(t, testutil.Request{Method: http.MethodPost, Path: tc.path, Body: tc.exists})
+			missing := e.Do(t, testutil.Request{Method: http.MethodPost, Path: tc.path, Body: tc.missing})
+			if exists.Status != missing.Status || string(exists.Body) != string(missing.Body) {
+				t.Fatalf("existing account: %d %s; missing account: %d %s", exists.Status, exists.Body, missing.Status, missing.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// BlockUser blocks the user named by :username: they can no longer follow the authenticated
+// user, comment on their posts or mention them, and their content is hidden from them
+func BlockUser(c *fiber.Ctx) error {
+	return setBlockState(c, models.BlockKindBlock, true)
+}
+
+// UnblockUser lifts a block
+func UnblockUser(c *fiber.Ctx) error {
+	return setBlockState(c, models.BlockKindBlock, false)
+}
+
+// MuteUser hides the posts of the user named by :username from the authenticated user's feed,
+// and their comments and mentions from their notifications
+func MuteUser(c *fiber.Ctx) error {
+	return setBlockState(c, models.BlockKindMute, true)
+}
+
+// UnmuteUser lifts a mute
+func UnmuteUser(c *fiber.Ctx) error {
+	return setBlockState(c, models.BlockKindMute, false)
+}
+
+func setBlockState(c *fiber.Ctx, kind string, on bool) error {
+	username := c.Params("username")
+	if username == "" {
+		return apierror.BadRequest("Username is required")
+	}
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs(kind + " attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in " + kind)
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	blocker := &models.User{ID: userID}
+	_, changed, err := blocker.SetBlockState(c.Context(), Redis, DB, username, kind, on)
+	if err != nil {
+		var appErr *utils.CustomError
+		if utils.As(err, &appErr) && appErr.Code < fiber.StatusInternalServerError {
+			Logger.Warn(c.Context()).WithFields("error", err, "target_username", username).Logs("Block state change rejected")
+			return apierror.New(appErr.Code, appErr.Message)
+		}
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID, "target_username", username, "kind", kind).Logs("Failed to set block state")
+		return apierror.Internal("Failed to update " + kind)
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "target_username", username, "kind", kind, "on", on, "changed", changed).Logs("Block state set")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Block state updated",
+		"status":  fiber.StatusOK,
+		"kind":    kind,
+		"active":  on,
+		"changed": changed,
+	})
+}
+
+// ListBlocks returns the users the authenticated user blocked or muted, narrowed by
+// ?kind=block or ?kind=mute
+func ListBlocks(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("ListBlocks attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in ListBlocks")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	kind := c.Query("kind")
+	if kind != "" && kind != models.BlockKindBlock && kind != models.BlockKindMute {
+		return apierror.BadRequest("Kind must be block or mute")
+	}
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	blocks, total, err := models.ListBlocks(c.Context(), DB, userID, kind, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to list blocks")
+		return apierror.Internal("Failed to fetch blocked users")
+	}
+
+	c.Set("Cache-Control", "no-store, private")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Blocked users retrieved successfully",
+		"status":  fiber.StatusOK,
+		"users":   blocks,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
@@ -0,0 +1,167 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+)
+
+// bookmarkResponse is a reading list entry: the bookmarked post and the state of the bookmark
+func bookmarkResponse(bookmark *models.Bookmark) fiber.Map {
+	res := fiber.Map{
+		"id":            bookmark.ID,
+		"post_id":       bookmark.PostID,
+		"archived":      bookmark.ArchivedAt != nil,
+		"archived_at":   bookmark.ArchivedAt,
+		"bookmarked_at": bookmark.CreatedAt,
+	}
+	if bookmark.Post.ID != uuid.Nil {
+		res["post"] = postResponse(&bookmark.Post)
+	}
+	return res
+}
+
+// bookmarkTarget reads the authenticated user and the post named by the :id param. On failure the
+// user ID is uuid.Nil and the error answers the request.
+func bookmarkTarget(c *fiber.Ctx, action string) (uuid.UUID, uuid.UUID, error) {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs(action + " attempted without user_id in context")
+		return uuid.Nil, uuid.Nil, apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in " + action)
+		return uuid.Nil, uuid.Nil, apierror.BadRequest("Invalid user ID")
+	}
+
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("post_id", c.Params("id")).Logs("Invalid post ID")
+		return uuid.Nil, uuid.Nil, apierror.BadRequest("Invalid post ID")
+	}
+	return userID, postID, nil
+}
+
+// BookmarkPost adds a published post to the authenticated user's reading list
+func BookmarkPost(c *fiber.Ctx) error {
+	userID, postID, err := bookmarkTarget(c, "BookmarkPost")
+	if userID == uuid.Nil {
+		return err
+	}
+
+	bookmark, created, err := models.AddBookmark(c.Context(), Redis, DB, userID, postID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "post_id", postID).Logs("Failed to bookmark post")
+		return postError(c, err, "Failed to bookmark post")
+	}
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+		Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", postID).Logs("Post bookmarked successfully")
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"message":  "Post bookmarked successfully",
+		"status":   status,
+		"bookmark": bookmarkResponse(bookmark),
+	})
+}
+
+// UnbookmarkPost removes a post from the authenticated user's reading list
+func UnbookmarkPost(c *fiber.Ctx) error {
+	userID, postID, err := bookmarkTarget(c, "UnbookmarkPost")
+	if userID == uuid.Nil {
+		return err
+	}
+
+	if err := models.RemoveBookmark(c.Context(), Redis, DB, userID, postID); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "post_id", postID).Logs("Failed to remove bookmark")
+		return postError(c, err, "Failed to remove bookmark")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", postID).Logs("Bookmark removed successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Bookmark removed successfully",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// ArchiveBookmark moves a bookmark out of the active reading list
+func ArchiveBookmark(c *fiber.Ctx) error {
+	return setBookmarkArchived(c, true)
+}
+
+// UnarchiveBookmark moves an archived bookmark back into the active reading list
+func UnarchiveBookmark(c *fiber.Ctx) error {
+	return setBookmarkArchived(c, false)
+}
+
+func setBookmarkArchived(c *fiber.Ctx, archived bool) error {
+	userID, postID, err := bookmarkTarget(c, "SetBookmarkArchived")
+	if userID == uuid.Nil {
+		return err
+	}
+
+	bookmark, err := models.SetBookmarkArchived(c.Context(), DB, userID, postID, archived)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "post_id", postID).Logs("Failed to update bookmark")
+		return postError(c, err, "Failed to update bookmark")
+	}
+
+	message := "Bookmark unarchived successfully"
+	if archived {
+		message = "Bookmark archived successfully"
+	}
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", postID).Logs(message)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  message,
+		"status":   fiber.StatusOK,
+		"bookmark": bookmarkResponse(bookmark),
+	})
+}
+
+// GetReadingList lists the authenticated user's bookmarked posts; ?archived=true lists the archive
+func GetReadingList(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetReadingList attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetReadingList")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+	archived := c.QueryBool("archived", false)
+
+	bookmarks, total, err := models.GetReadingList(c.Context(), DB, userID, archived, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch reading list")
+		return postError(c, err, "Failed to fetch reading list")
+	}
+
+	entries := make([]fiber.Map, len(bookmarks))
+	for i := range bookmarks {
+		entries[i] = bookmarkResponse(&bookmarks[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":   "Reading list retrieved successfully",
+		"status":    fiber.StatusOK,
+		"bookmarks": entries,
+		"page":      page,
+		"limit":     limit,
+		"total":     total,
+	})
+}
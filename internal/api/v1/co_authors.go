@@ -0,0 +1,222 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// coAuthorResponse is the public representation of a post co-author
+func coAuthorResponse(a *models.PostAuthor) fiber.Map {
+	return fiber.Map{
+		"user": fiber.Map{
+			"id":         a.User.ID,
+			"username":   a.User.Username,
+			"name":       a.User.Profile.Name,
+			"avatar_url": a.User.Profile.AvatarURL,
+		},
+		"status":      a.Status,
+		"invited_at":  a.CreatedAt,
+		"accepted_at": a.AcceptedAt,
+	}
+}
+
+// ListCoAuthors lists the co-authors of a post, including the invitations not answered yet
+func ListCoAuthors(c *fiber.Ctx) error {
+	post, userID, err := editablePost(c)
+	if post == nil {
+		return err
+	}
+
+	coAuthors, err := models.GetCoAuthors(c.Context(), DB, post.ID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID, "post_id", post.ID).Logs("Failed to fetch co-authors")
+		return postError(c, err, "Failed to fetch co-authors")
+	}
+	res := make([]fiber.Map, len(coAuthors))
+	for i := range coAuthors {
+		res[i] = coAuthorResponse(&coAuthors[i])
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":    "Co-authors retrieved successfully",
+		"status":     fiber.StatusOK,
+		"co_authors": res,
+	})
+}
+
+// InviteCoAuthor invites a user to co-author a post; they become one of its authors once they
+// accept
+func InviteCoAuthor(c *fiber.Ctx) error {
+	type InviteCoAuthorRequest struct {
+		Username string `json:"username" validate:"required,min=3,max=50"`
+	}
+
+	post, userID, err := managedPost(c, "edit_any_post")
+	if post == nil {
+		return err
+	}
+	var req InviteCoAuthorRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	coAuthor, err := models.InviteCoAuthor(c.Context(), Redis, DB, post, req.Username)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "post_id", post.ID, "username", req.Username).Logs("Failed to invite co-author")
+		return postError(c, err, "Failed to invite co-author")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", post.ID, "co_author_id", coAuthor.UserID).Logs("Co-author invited")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":   "Co-author invited successfully",
+		"status":    fiber.StatusCreated,
+		"co_author": coAuthorResponse(coAuthor),
+	})
+}
+
+// RemoveCoAuthor takes :username off the co-authors of a post or withdraws their invitation. The
+// author and moderators may remove anyone; a co-author may only step down themselves
+func RemoveCoAuthor(c *fiber.Ctx) error {
+	post, userID, err := editablePost(c)
+	if post == nil {
+		return err
+	}
+
+	coAuthors, err := models.GetCoAuthors(c.Context(), DB, post.ID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to fetch co-authors")
+		return postError(c, err, "Failed to fetch co-authors")
+	}
+	target := uuid.Nil
+	for _, a := range coAuthors {
+		if a.User.Username == c.Params("username") {
+			target = a.UserID
+		}
+	}
+	if target == uuid.Nil {
+		return apierror.NotFound("Co-author not found")
+	}
+	if target != userID && post.AuthorID != userID {
+		allowed, err := hasPermission(c, userID, "edit_any_post")
+		if err != nil {
+			Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch permissions in RemoveCoAuthor")
+			return postError(c, err, "Failed to fetch user")
+		}
+		if !allowed {
+			Logger.Warn(c.Context()).WithFields("user_id", userID, "post_id", post.ID).Logs("Co-author tried to remove another co-author")
+			return apierror.Forbidden("Only the author can remove other co-authors")
+		}
+	}
+
+	if err := models.RemoveCoAuthor(c.Context(), Redis, DB, post, target); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "post_id", post.ID, "co_author_id", target).Logs("Failed to remove co-author")
+		return postError(c, err, "Failed to remove co-author")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", post.ID, "co_author_id", target).Logs("Co-author removed")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Co-author removed successfully",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// AcceptCoAuthorInvitation makes the user one of the authors of a post they were invited to
+func AcceptCoAuthorInvitation(c *fiber.Ctx) error {
+	return answerCoAuthorInvitation(c, true)
+}
+
+// DeclineCoAuthorInvitation turns down an invitation to co-author a post
+func DeclineCoAuthorInvitation(c *fiber.Ctx) error {
+	return answerCoAuthorInvitation(c, false)
+}
+
+func answerCoAuthorInvitation(c *fiber.Ctx, accept bool) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("Co-author invitation answered without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in co-author invitation")
+		return apierror.BadRequest("Invalid user ID")
+	}
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("post_id", c.Params("id")).Logs("Invalid post ID")
+		return apierror.BadRequest("Invalid post ID")
+	}
+
+	coAuthor, err := models.AnswerCoAuthorInvitation(c.Context(), Redis, DB, postID, userID, accept)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "post_id", postID, "accept", accept).Logs("Failed to answer co-author invitation")
+		return postError(c, err, "Failed to answer invitation")
+	}
+
+	if !accept {
+		Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", postID).Logs("Co-author invitation declined")
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": "Invitation declined",
+			"status":  fiber.StatusOK,
+		})
+	}
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", postID).Logs("Co-author invitation accepted")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Invitation accepted",
+		"status":      fiber.StatusOK,
+		"post_id":     coAuthor.PostID,
+		"accepted_at": coAuthor.AcceptedAt,
+	})
+}
+
+// GetMyCoAuthorInvitations lists the invitations to co-author a post the user has not answered
+func GetMyCoAuthorInvitations(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetMyCoAuthorInvitations attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetMyCoAuthorInvitations")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	invitations, err := models.GetCoAuthorInvitations(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch co-author invitations")
+		return postError(c, err, "Failed to fetch invitations")
+	}
+	res := make([]fiber.Map, 0, len(invitations))
+	for _, inv := range invitations {
+		if inv.Post == nil {
+			continue
+		}
+		res = append(res, fiber.Map{
+			"post": fiber.Map{
+				"id":    inv.Post.ID,
+				"title": inv.Post.Title,
+				"slug":  inv.Post.Slug,
+				"author": fiber.Map{
+					"id":         inv.Post.Author.ID,
+					"username":   inv.Post.Author.Username,
+					"name":       inv.Post.Author.Profile.Name,
+					"avatar_url": inv.Post.Author.Profile.AvatarURL,
+				},
+			},
+			"invited_at": inv.CreatedAt,
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Invitations retrieved successfully",
+		"status":      fiber.StatusOK,
+		"invitations": res,
+	})
+}
@@ -0,0 +1,326 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/markdown"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// commentResponse is the public representation of a comment and its replies. Deleted comments
+// that still have replies are rendered as placeholders.
+func commentResponse(c *fiber.Ctx, comment *models.Comment) fiber.Map {
+	replies := make([]fiber.Map, len(comment.Replies))
+	for i := range comment.Replies {
+		replies[i] = commentResponse(c, &comment.Replies[i])
+	}
+
+	res := fiber.Map{
+		"id":                comment.ID,
+		"post_id":           comment.PostID,
+		"parent_comment_id": comment.ParentCommentID,
+		"depth":             comment.Depth,
+		"content":           comment.Content,
+		"edited":            comment.Edited,
+		"edited_at":         comment.EditedAt,
+		"pinned":            comment.Pinned,
+		"deleted":           comment.DeletedAt.Valid,
+		"created_at":        comment.CreatedAt,
+		"updated_at":        comment.UpdatedAt,
+		"replies":           replies,
+	}
+	if comment.DeletedAt.Valid {
+		res["content"] = "[deleted]"
+		return res
+	}
+	res["content_html"] = renderContent(c, cache.CommentHTMLKey(comment.ID), comment.Content, markdown.FormatMarkdown)
+	res["author_id"] = comment.AuthorID
+	if comment.Author.ID != uuid.Nil {
+		res["author"] = fiber.Map{
+			"id":         comment.Author.ID,
+			"username":   comment.Author.Username,
+			"name":       comment.Author.Profile.Name,
+			"avatar_url": comment.Author.Profile.AvatarURL,
+		}
+	}
+	return res
+}
+
+// hasPermission reports whether a user's role grants perm, reading the role's cached permission
+// snapshot
+func hasPermission(c *fiber.Ctx, userID uuid.UUID, perm string) (bool, error) {
+	roleID, ok := c.Locals("role_id").(uuid.UUID)
+	if !ok {
+		user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{userID})
+		if err != nil {
+			return false, err
+		}
+		roleID = user.RoleID
+	}
+	snapshot, err := models.GetPermissionSnapshot(c.Context(), Redis, DB, roleID)
+	if err != nil {
+		return false, err
+	}
+	return snapshot.Has(perm), nil
+}
+
+// SendMentionEmail emails a user who opted into mention emails; models call it once a mention is committed
+func SendMentionEmail(ctx context.Context, email, username, locale, message, postSlug string) {
+	utils.SendMentionEmail(ctx, EmailCfg, email, locale, username, message, EmailCfg.AppURL+"/posts/"+postSlug, Logger)
+}
+
+// CommentOwner resolves the author of the comment named by the :id path parameter for
+// owner-or-permission access rules
+func CommentOwner(c *fiber.Ctx) (uuid.UUID, error) {
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, apierror.BadRequest("Invalid comment ID")
+	}
+	author, err := models.GetCommentAuthor(c.Context(), DB, commentID)
+	if err != nil {
+		return uuid.Nil, postError(c, err, "Failed to fetch comment")
+	}
+	return author, nil
+}
+
+// GetPostComments returns the comment threads of a published post
+func GetPostComments(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("post_id", c.Params("id")).Logs("Invalid post ID")
+		return apierror.BadRequest("Invalid post ID")
+	}
+
+	post, err := models.GetPostsBy(c.Context(), Redis, DB, "id = ?", []interface{}{postID})
+	if err != nil || !post.Published {
+		if err == nil {
+			err = utils.NewError(utils.ErrNotFound.Code, "Post not found")
+		}
+		Logger.Warn(c.Context()).WithFields("error", err, "post_id", postID).Logs("Failed to fetch post for comments")
+		return postError(c, err, "Failed to fetch comments")
+	}
+	if err := canViewPost(c, post); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "post_id", postID).Logs("Comments of a post hidden from the requester")
+		return postError(c, err, "Failed to fetch comments")
+	}
+
+	threads, err := models.GetPostComments(c.Context(), DB, postID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", postID).Logs("Failed to fetch comments")
+		return postError(c, err, "Failed to fetch comments")
+	}
+
+	comments := make([]fiber.Map, len(threads))
+	for i := range threads {
+		comments[i] = commentResponse(c, &threads[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Comments retrieved successfully",
+		"status":   fiber.StatusOK,
+		"comments": comments,
+	})
+}
+
+// CreateComment adds a comment or reply to a published post
+func CreateComment(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("CreateComment attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in CreateComment")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("post_id", c.Params("id")).Logs("Invalid post ID")
+		return apierror.BadRequest("Invalid post ID")
+	}
+
+	type CreateCommentRequest struct {
+		Content         string     `json:"content" validate:"required,min=2,max=1000"`
+		ParentCommentID *uuid.UUID `json:"parent_comment_id" validate:"omitempty"`
+	}
+
+	var req CreateCommentRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	post, err := models.GetPostsBy(c.Context(), Redis, DB, "id = ?", []interface{}{postID})
+	if err == nil && post.Published {
+		err = canViewPost(c, post)
+	}
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "post_id", postID).Logs("Comment on a post hidden from the user")
+		return postError(c, err, "Failed to create comment")
+	}
+
+	comment, err := models.CreateComment(c.Context(), Redis, DB, postID, userID, req.ParentCommentID, req.Content)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "post_id", postID).Logs("Failed to create comment")
+		return postError(c, err, "Failed to create comment")
+	}
+
+	recordActivity(c, userID, models.ActivityComment)
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", postID, "comment_id", comment.ID).Logs("Comment created successfully")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Comment created successfully",
+		"status":  fiber.StatusCreated,
+		"comment": commentResponse(c, comment),
+	})
+}
+
+// UpdateComment edits a comment; authors are limited to CommentEditWindow, moderators are not
+func UpdateComment(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("UpdateComment attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in UpdateComment")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("comment_id", c.Params("id")).Logs("Invalid comment ID")
+		return apierror.BadRequest("Invalid comment ID")
+	}
+
+	type UpdateCommentRequest struct {
+		Content string `json:"content" validate:"required,min=2,max=1000"`
+	}
+
+	var req UpdateCommentRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	moderator, err := hasPermission(c, userID, "edit_any_comment")
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user in UpdateComment")
+		return postError(c, err, "Failed to update comment")
+	}
+
+	comment, err := models.UpdateComment(c.Context(), Redis, DB, commentID, userID, req.Content, CommentEditWindow, moderator)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "comment_id", commentID).Logs("Failed to update comment")
+		return postError(c, err, "Failed to update comment")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "comment_id", commentID).Logs("Comment updated successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Comment updated successfully",
+		"status":  fiber.StatusOK,
+		"comment": commentResponse(c, comment),
+	})
+}
+
+// GetCommentHistory lists the versions of a comment, newest first, each with a unified diff from
+// the version before it. Only the author and moderators may read it
+func GetCommentHistory(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetCommentHistory attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetCommentHistory")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("comment_id", c.Params("id")).Logs("Invalid comment ID")
+		return apierror.BadRequest("Invalid comment ID")
+	}
+
+	comment, err := models.GetComment(c.Context(), DB, commentID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "comment_id", commentID).Logs("Failed to fetch comment")
+		return postError(c, err, "Failed to fetch history")
+	}
+	if comment.AuthorID != userID {
+		moderator, err := hasPermission(c, userID, "moderate_comment")
+		if err != nil {
+			Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user in GetCommentHistory")
+			return postError(c, err, "Failed to fetch history")
+		}
+		if !moderator {
+			Logger.Warn(c.Context()).WithFields("user_id", userID, "comment_id", commentID).Logs("User is not allowed to read comment history")
+			return apierror.Forbidden("You can only read the history of your own comments")
+		}
+	}
+
+	history, err := models.GetCommentHistory(c.Context(), DB, comment)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "comment_id", commentID).Logs("Failed to fetch comment history")
+		return postError(c, err, "Failed to fetch history")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "History retrieved successfully",
+		"status":  fiber.StatusOK,
+		"history": history,
+	})
+}
+
+// DeleteComment soft deletes a comment, keeping its replies in the thread
+func DeleteComment(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("DeleteComment attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in DeleteComment")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("comment_id", c.Params("id")).Logs("Invalid comment ID")
+		return apierror.BadRequest("Invalid comment ID")
+	}
+
+	moderator, err := hasPermission(c, userID, "delete_any_comment")
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user in DeleteComment")
+		return postError(c, err, "Failed to delete comment")
+	}
+
+	if err := models.DeleteComment(c.Context(), Redis, DB, commentID, userID, moderator); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "comment_id", commentID).Logs("Failed to delete comment")
+		return postError(c, err, "Failed to delete comment")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "comment_id", commentID).Logs("Comment deleted successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Comment deleted successfully",
+		"status":  fiber.StatusOK,
+	})
+}
@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConditionalGet tags successful reads with a strong ETag, a hash of the body, and answers 304
+// Not Modified with no body when the client's If-None-Match names it, so clients that poll only
+// download what changed. An ETag set by the handler is kept. It goes before GuestCache so answers
+// served from that cache are tagged too.
+func ConditionalGet(c *fiber.Ctx) error {
+	if err := c.Next(); err != nil {
+		return err
+	}
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead || c.Response().StatusCode() != fiber.StatusOK {
+		return nil
+	}
+
+	if len(c.Response().Header.Peek(fiber.HeaderETag)) == 0 {
+		sum := sha1.Sum(c.Response().Body())
+		c.Set(fiber.HeaderETag, `"`+hex.EncodeToString(sum[:])+`"`)
+	}
+	// Fresh alone would also answer a bare If-Modified-Since, which these responses cannot judge
+	// without a Last-Modified.
+	if c.Get(fiber.HeaderIfNoneMatch) != "" && c.Fresh() {
+		c.Response().ResetBody()
+		c.Response().Header.Del(fiber.HeaderContentType)
+		c.Status(fiber.StatusNotModified)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// contentField is a request field checked by the content filter: its name in the request, the
+// filter field whose rules apply and its text
+type contentField struct {
+	Name  string
+	Field string
+	Text  string
+}
+
+// filterContent checks fields against the content filter and answers the fields it rejects as
+// validation errors. Without the blocklists only the rules needing none are enforced, so an
+// outage does not stop every sign-up and edit.
+func filterContent(c *fiber.Ctx, fields ...contentField) error {
+	list, err := models.GetContentBlocklist(c.Context(), Redis, DB)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to load content blocklist")
+		list = nil
+	}
+
+	res := &utils.ErrorResponse{}
+	for _, f := range fields {
+		if err := ContentFilter.Check(f.Field, f.Text, list); err != nil {
+			res.Errors = append(res.Errors, utils.CError{Field: f.Name, Msg: err.Error()})
+		}
+	}
+	if len(res.Errors) > 0 {
+		Logger.Warn(c.Context()).WithFields("errors", res.Errors, "path", c.Path()).Logs("Content filter rejected request")
+		return apierror.Validation(res)
+	}
+	return nil
+}
+
+// ListBlockedTerms lists the content filter blocklists, filtered by ?kind=, along with the rules
+// each field is checked against
+func ListBlockedTerms(c *fiber.Ctx) error {
+	kind := c.Query("kind")
+	if kind != "" && kind != models.BlockedTermKind && kind != models.BlockedDomainKind {
+		return apierror.BadRequest("Kind must be term or domain")
+	}
+
+	entries, err := models.ListBlockedTerms(c.Context(), DB, kind)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch blocklist")
+		return apierror.From(err, "Failed to fetch blocklist")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Blocklist retrieved successfully",
+		"status":  fiber.StatusOK,
+		"entries": entries,
+		"rules":   ContentFilter.Config(),
+	})
+}
+
+// AddBlockedTerm blocks a term or a domain in profile fields and post titles
+func AddBlockedTerm(c *fiber.Ctx) error {
+	type AddBlockedTermRequest struct {
+		Kind      string `json:"kind" validate:"required,oneof=term domain"`
+		Value     string `json:"value" validate:"required,min=2,max=100"`
+		Substring bool   `json:"substring"`
+	}
+
+	actorID := auditActor(c)
+	if actorID == uuid.Nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+	var req AddBlockedTermRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	entry, err := models.AddBlockedTerm(c.Context(), Redis, DB, actorID, req.Kind, req.Value, req.Substring)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "actor_id", actorID).Logs("Failed to add blocklist entry")
+		return apierror.From(err, "Failed to add blocklist entry")
+	}
+	recordAudit(c, actorID, models.AuditBlocklistAdd, models.AuditTargetBlockedTerm, entry.ID, nil,
+		map[string]interface{}{"kind": entry.Kind, "value": entry.Value, "substring": entry.Substring})
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "entry_id", entry.ID, "kind", entry.Kind).Logs("Blocklist entry added")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Blocklist entry added successfully",
+		"status":  fiber.StatusCreated,
+		"entry":   entry,
+	})
+}
+
+// DeleteBlockedTerm removes an entry from the content filter blocklists
+func DeleteBlockedTerm(c *fiber.Ctx) error {
+	actorID := auditActor(c)
+	if actorID == uuid.Nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid blocklist entry ID")
+	}
+
+	entry, err := models.DeleteBlockedTerm(c.Context(), Redis, DB, id)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "entry_id", id).Logs("Failed to delete blocklist entry")
+		return apierror.From(err, "Failed to delete blocklist entry")
+	}
+	recordAudit(c, actorID, models.AuditBlocklistRemove, models.AuditTargetBlockedTerm, entry.ID,
+		map[string]interface{}{"kind": entry.Kind, "value": entry.Value, "substring": entry.Substring}, nil)
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "entry_id", entry.ID).Logs("Blocklist entry removed")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Blocklist entry removed successfully",
+		"status":  fiber.StatusOK,
+	})
+}
@@ -0,0 +1,130 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/mnuddindev/devpulse/internal/api/v1"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/services"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// stubAuth is an AuthService whose Authenticate answers with user and err; its other methods are
+// not implemented.
+type stubAuth struct {
+	services.AuthService
+	user  *models.User
+	err   error
+	calls int
+}
+
+func (s *stubAuth) Authenticate(ctx context.Context, email, password string, client services.Client) (*models.User, error) {
+	s.calls++
+	return s.user, s.err
+}
+
+// withAuthService serves handler on a bare Fiber app with auth as the authentication service, so
+// the handler runs without a database or Redis.
+func withAuthService(t *testing.T, auth services.AuthService, handler fiber.Handler) *fiber.App {
+	t.Helper()
+	log, err := logger.NewLogger(context.Background(), logger.WithOutputDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(log.Close)
+
+	previousLogger, previousServices := v1.Logger, v1.Services
+	v1.Logger, v1.Services = log, &services.Services{Auth: auth}
+	t.Cleanup(func() { v1.Logger, v1.Services = previousLogger, previousServices })
+
+	app := fiber.New(fiber.Config{ErrorHandler: apierror.Handler(log)})
+	app.Post("/", handler)
+	return app
+}
+
+// TestLoginController checks that Login maps what the authentication service answers to the
+// response, with the service stubbed out.
+func TestLoginController(t *testing.T) {
+	invalid := utils.NewError(utils.ErrUnauthorized.Code, "Invalid email or password")
+	cases := []struct {
+		name    string
+		body    string
+		user    *models.User
+		err     error
+		status  int
+		message string
+		calls   int
+	}{
+		{
+			name:    "refused login",
+			body:    `{"email":"someone@example.com","password":"wrong-password"}`,
+			err:     &services.LoginError{Reason: services.LoginInvalidPassword, Err: invalid},
+			status:  http.StatusUnauthorized,
+			message: "Invalid email or password",
+			calls:   1,
+		},
+		{
+			name:    "restricted account",
+			body:    `{"email":"someone@example.com","password":"right-password"}`,
+			user:    &models.User{ID: uuid.New(), Status: models.UserStatusBanned},
+			err:     &services.LoginError{Reason: services.LoginRestricted, Err: utils.NewError(utils.ErrForbidden.Code, "Account is banned")},
+			status:  http.StatusForbidden,
+			message: "Account is " + models.UserStatusBanned,
+			calls:   1,
+		},
+		{
+			name:    "service failure",
+			body:    `{"email":"someone@example.com","password":"right-password"}`,
+			err:     errors.New("database unavailable"),
+			status:  http.StatusInternalServerError,
+			message: "Failed to process login",
+			calls:   1,
+		},
+		{
+			name:   "invalid form is not passed on",
+			body:   `{"email":"not-an-email","password":"right-password"}`,
+			status: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			auth := &stubAuth{user: tc.user, err: tc.err}
+			app := withAuthService(t, auth, v1.Login)
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tc.status {
+				t.Fatalf("status %d, want %d", res.StatusCode, tc.status)
+			}
+			if tc.message != "" {
+				var body struct {
+					Message string `json:"error"`
+				}
+				if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+					t.Fatal(err)
+				}
+				if body.Message != tc.message {
+					t.Fatalf("error %q, want %q", body.Message, tc.message)
+				}
+			}
+			if auth.calls != tc.calls {
+				t.Fatalf("service called %d times, want %d", auth.calls, tc.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/i18n"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// SendDigestEmail mails a user their periodic digest; the digest job calls it for each due user
+func SendDigestEmail(ctx context.Context, digest *models.Digest) error {
+	content := utils.DigestContent{
+		Username:      digest.Username,
+		Period:        digest.Frequency,
+		Unread:        digest.Unread,
+		Notifications: make([]utils.DigestItem, 0, len(digest.Notifications)),
+		Posts:         make([]utils.DigestItem, 0, len(digest.Posts)),
+		Link:          EmailCfg.AppURL + "/notifications",
+	}
+	for _, n := range digest.Notifications {
+		content.Notifications = append(content.Notifications, utils.DigestItem{
+			Title: n.Message,
+			Meta:  n.CreatedAt.Format("Jan 2, 15:04"),
+		})
+	}
+	for _, post := range digest.Posts {
+		item := utils.DigestItem{
+			Title: post.Title,
+			Link:  EmailCfg.AppURL + "/posts/" + post.Slug,
+		}
+		if post.Author.Username != "" {
+			item.Meta = i18n.T(digest.Locale, "by %s", post.Author.Username)
+		}
+		content.Posts = append(content.Posts, item)
+	}
+	return utils.SendDigestEmail(ctx, EmailCfg, digest.Email, digest.Locale, content, Logger)
+}
@@ -0,0 +1,114 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/i18n"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// emailTemplate looks up the mail template named in the path
+func emailTemplate(c *fiber.Ctx) (*utils.MailTemplate, error) {
+	tmpl, ok := utils.LookupMailTemplate(c.Params("name"))
+	if !ok {
+		return nil, apierror.NotFound("Email template not found")
+	}
+	return tmpl, nil
+}
+
+// emailLocale reads the locale an email is rendered in from ?locale=, falling back to fallback
+func emailLocale(c *fiber.Ctx, fallback string) (string, error) {
+	locale := c.Query("locale", fallback)
+	if !i18n.Supports(locale) {
+		return "", apierror.BadRequest("Unsupported locale").WithDetails(fiber.Map{"supported": i18n.Supported()})
+	}
+	return locale, nil
+}
+
+// ListEmailTemplates lists the mail templates with their untranslated subjects, along with the
+// locales they can be rendered in
+func ListEmailTemplates(c *fiber.Ctx) error {
+	names := utils.MailTemplateNames()
+	templates := make([]fiber.Map, 0, len(names))
+	for _, name := range names {
+		tmpl, _ := utils.LookupMailTemplate(name)
+		templates = append(templates, fiber.Map{"name": tmpl.Name, "subject": tmpl.Subject})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":   "Email templates retrieved successfully",
+		"status":    fiber.StatusOK,
+		"templates": templates,
+		"locales":   i18n.Supported(),
+		"provider":  EmailCfg.Provider.Name(),
+	})
+}
+
+// PreviewEmailTemplate renders a mail template with sample data in ?locale=. With ?format=html the
+// HTML body is answered as a page, so it can be opened in a browser.
+func PreviewEmailTemplate(c *fiber.Ctx) error {
+	tmpl, err := emailTemplate(c)
+	if err != nil {
+		return err
+	}
+	locale, err := emailLocale(c, i18n.Default)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := tmpl.Preview(EmailCfg, locale)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "template", tmpl.Name).Logs("Failed to render email preview")
+		return apierror.From(err, "Failed to render email preview")
+	}
+	if c.Query("format") == "html" {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.Status(fiber.StatusOK).SendString(rendered.HTML)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Email preview rendered successfully",
+		"status":  fiber.StatusOK,
+		"name":    tmpl.Name,
+		"locale":  locale,
+		"email":   rendered,
+	})
+}
+
+// TestSendEmailTemplate sends a mail template with sample data to the admin asking, in ?locale= or
+// their own locale, to check delivery through the configured provider. Test emails only go to the
+// admin's own address so the endpoint cannot be used to mail anyone else.
+func TestSendEmailTemplate(c *fiber.Ctx) error {
+	actorID := auditActor(c)
+	if actorID == uuid.Nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+	tmpl, err := emailTemplate(c)
+	if err != nil {
+		return err
+	}
+	user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{actorID})
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", actorID).Logs("Failed to fetch user")
+		return apierror.From(err, "Failed to fetch user")
+	}
+	locale, err := emailLocale(c, user.Settings.Locale)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.SendTemplatedEmail(c.Context(), EmailCfg, user.Email, locale, tmpl, tmpl.Sample(EmailCfg), Logger); err != nil {
+		return apierror.From(err, "Failed to send test email")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Test email sent successfully",
+		"status":   fiber.StatusOK,
+		"name":     tmpl.Name,
+		"locale":   locale,
+		"to":       user.Email,
+		"provider": EmailCfg.Provider.Name(),
+	})
+}
@@ -0,0 +1,127 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	v1 "github.com/mnuddindev/devpulse/internal/api/v1"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+	"github.com/mnuddindev/devpulse/pkg/mailer"
+)
+
+// outbox is a mail provider keeping what it is given.
+type outbox struct {
+	sent []mailer.Message
+}
+
+func (o *outbox) Name() string { return "outbox" }
+
+func (o *outbox) Send(ctx context.Context, msg mailer.Message) error {
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+// withOutbox sends the rest of the test's email to an outbox.
+func withOutbox(t *testing.T) *outbox {
+	o := &outbox{}
+	previous := v1.EmailCfg.Provider
+	v1.EmailCfg.Provider = o
+	t.Cleanup(func() { v1.EmailCfg.Provider = previous })
+	return o
+}
+
+func TestEmailTemplates(t *testing.T) {
+	var member *models.User
+	testutil.Run(t, []testutil.Case{
+		{
+			Name:    "list",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodGet, Path: "/admin/email-templates"},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body struct {
+					Templates []struct {
+						Name string `json:"name"`
+					} `json:"templates"`
+				}
+				res.JSON(t, &body)
+				names := make([]string, len(body.Templates))
+				for i, tmpl := range body.Templates {
+					names[i] = tmpl.Name
+				}
+				if !strings.Contains(strings.Join(names, ","), "badge_earned") {
+					t.Fatalf("templates %v lack badge_earned", names)
+				}
+			},
+		},
+		{
+			Name:    "preview",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodGet, Path: "/admin/email-templates/password_reset/preview?locale=bn"},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body struct {
+					Locale string `json:"locale"`
+					Email  struct {
+						Subject string `json:"subject"`
+						Text    string `json:"text"`
+						HTML    string `json:"html"`
+					} `json:"email"`
+				}
+				res.JSON(t, &body)
+				if body.Locale != "bn" || body.Email.Subject == "" || body.Email.Text == "" || !strings.Contains(body.Email.HTML, `lang="bn"`) {
+					t.Fatalf("got %+v", body)
+				}
+			},
+		},
+		{
+			Name:    "preview as a page",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodGet, Path: "/admin/email-templates/digest/preview?format=html"},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				if !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") || !strings.Contains(string(res.Body), "<!DOCTYPE html>") {
+					t.Fatalf("got %s: %.60s", res.Header.Get("Content-Type"), res.Body)
+				}
+			},
+		},
+		{
+			Name:    "unsupported locale",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodGet, Path: "/admin/email-templates/digest/preview?locale=xx"},
+			Status:  http.StatusBadRequest,
+		},
+		{
+			Name:    "unknown template",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodGet, Path: "/admin/email-templates/nothing/preview"},
+			Status:  http.StatusNotFound,
+		},
+		{
+			Name:    "members cannot preview",
+			Setup:   asMember(&member),
+			Request: testutil.Request{Method: http.MethodGet, Path: "/admin/email-templates/digest/preview"},
+			Status:  http.StatusForbidden,
+		},
+	})
+}
+
+func TestSendTestEmail(t *testing.T) {
+	e := testutil.Setup(t)
+	sent := withOutbox(t)
+	admin := e.CreateUser(t, models.RoleAdmin)
+
+	res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/admin/email-templates/badge_earned/test", Token: e.Token(t, admin)})
+	if res.Status != http.StatusOK {
+		t.Fatalf("status %d: %s", res.Status, res.Body)
+	}
+	if len(sent.sent) != 1 || sent.sent[0].To != admin.Email {
+		t.Fatalf("sent %+v, want one email to %s", sent.sent, admin.Email)
+	}
+	if msg := sent.sent[0]; msg.Text == "" || !strings.Contains(msg.HTML, "First Post") {
+		t.Fatalf("test email lacks the sample data: %+v", msg)
+	}
+}
@@ -0,0 +1,184 @@
+package v1
+
+import (
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+)
+
+// providerName names the site in embeds and link previews.
+const providerName = "DevPulse"
+
+// embedCacheAge is how long consumers may cache an embed or the meta of a post, in seconds.
+const embedCacheAge = 3600
+
+// postURL is the link to a post on the site.
+func postURL(slug string) string {
+	return EmailCfg.AppURL + "/posts/" + slug
+}
+
+// postSlugFromURL extracts the slug of a post link on the site, as in
+// https://devpulse.example/posts/some-slug. ok is false for any other URL.
+func postSlugFromURL(raw string) (slug string, ok bool) {
+	link, err := url.Parse(raw)
+	if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+		return "", false
+	}
+	site, err := url.Parse(EmailCfg.AppURL)
+	if err != nil || !strings.EqualFold(strings.TrimPrefix(link.Hostname(), "www."), strings.TrimPrefix(site.Hostname(), "www.")) {
+		return "", false
+	}
+	rest, found := strings.CutPrefix(strings.TrimSuffix(link.Path, "/"), strings.TrimSuffix(site.Path, "/")+"/posts/")
+	if !found || rest == "" || strings.Contains(rest, "/") {
+		return "", false
+	}
+	return strings.ToLower(rest), true
+}
+
+// authorName is the name a post's author is credited with in embeds.
+func authorName(post *models.Posts) string {
+	if post.Author.Profile.Name != "" {
+		return post.Author.Profile.Name
+	}
+	return post.Author.Username
+}
+
+// OEmbed answers oEmbed requests (https://oembed.com) for post links so other sites and chat apps
+// can unfurl them. Posts are embedded as links; their excerpt and cover, which the link type has
+// no fields for, come as description and cover_image_url.
+func OEmbed(c *fiber.Ctx) error {
+	raw := strings.TrimSpace(c.Query("url"))
+	if raw == "" {
+		return apierror.BadRequest("URL is required")
+	}
+	if format := c.Query("format", "json"); format != "json" {
+		return apierror.New(fiber.StatusNotImplemented, "Only the json format is supported")
+	}
+	slug, ok := postSlugFromURL(raw)
+	if !ok {
+		return apierror.NotFound("Nothing to embed at this URL")
+	}
+
+	post, err := publishedPost(c, slug)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "url", raw).Logs("Failed to fetch embedded post")
+		return postError(c, err, "Failed to fetch post")
+	}
+
+	res := fiber.Map{
+		"version":       "1.0",
+		"type":          "link",
+		"provider_name": providerName,
+		"provider_url":  EmailCfg.AppURL,
+		"title":         post.Title,
+		"author_name":   authorName(post),
+		"author_url":    profileURL(post.Author.Username),
+		"url":           postURL(post.Slug),
+		"description":   post.Excerpt,
+		"published_at":  post.PublishedAt,
+		"cache_age":     embedCacheAge,
+	}
+	if post.FeaturedImageURL != "" {
+		res["cover_image_url"] = post.FeaturedImageURL
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// metaTag is an HTML meta tag of a page, keyed by property for Open Graph and by name otherwise.
+type metaTag struct {
+	Property string `json:"property,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Content  string `json:"content"`
+}
+
+// GetPostMeta returns the Open Graph and Twitter card meta of a published post, as tags and as
+// ready-to-use HTML with the canonical and oEmbed discovery links, for pages rendering the post
+func GetPostMeta(c *fiber.Ctx) error {
+	slug := strings.ToLower(strings.TrimSpace(c.Params("slug")))
+	if slug == "" {
+		return apierror.BadRequest("Slug is required")
+	}
+
+	post, err := publishedPost(c, slug)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "slug", slug).Logs("Failed to fetch post")
+		return postError(c, err, "Failed to fetch post")
+	}
+
+	link := postURL(post.Slug)
+	canonical := firstNonEmpty(post.CanonicalURL, link)
+	title := firstNonEmpty(post.OGTitle, post.MetaTitle, post.Title)
+	description := firstNonEmpty(post.OGDescription, post.MetaDescription, post.Excerpt)
+	image := firstNonEmpty(post.OGImageURL, post.FeaturedImageURL)
+
+	tags := []metaTag{
+		{Name: "description", Content: firstNonEmpty(post.MetaDescription, post.Excerpt)},
+		{Property: "og:type", Content: "article"},
+		{Property: "og:site_name", Content: providerName},
+		{Property: "og:url", Content: link},
+		{Property: "og:title", Content: title},
+		{Property: "og:description", Content: description},
+		{Property: "article:author", Content: authorName(post)},
+	}
+	if post.PublishedAt != nil {
+		tags = append(tags, metaTag{Property: "article:published_time", Content: post.PublishedAt.UTC().Format("2006-01-02T15:04:05Z")})
+	}
+	for _, tag := range post.Tags {
+		tags = append(tags, metaTag{Property: "article:tag", Content: tag.Name})
+	}
+	card := "summary"
+	if image != "" {
+		card = "summary_large_image"
+		tags = append(tags, metaTag{Property: "og:image", Content: image})
+	}
+	tags = append(tags,
+		metaTag{Name: "twitter:card", Content: card},
+		metaTag{Name: "twitter:title", Content: firstNonEmpty(post.TwitterTitle, title)},
+		metaTag{Name: "twitter:description", Content: firstNonEmpty(post.TwitterDescription, description)},
+	)
+	if twitterImage := firstNonEmpty(post.TwitterImageURL, image); twitterImage != "" {
+		tags = append(tags, metaTag{Name: "twitter:image", Content: twitterImage})
+	}
+
+	var b strings.Builder
+	b.WriteString(`<title>` + html.EscapeString(firstNonEmpty(post.MetaTitle, post.Title)) + `</title>` + "\n")
+	for _, tag := range tags {
+		if tag.Content == "" {
+			continue
+		}
+		if tag.Property != "" {
+			b.WriteString(`<meta property="` + html.EscapeString(tag.Property) + `" content="` + html.EscapeString(tag.Content) + `">` + "\n")
+		} else {
+			b.WriteString(`<meta name="` + html.EscapeString(tag.Name) + `" content="` + html.EscapeString(tag.Content) + `">` + "\n")
+		}
+	}
+	b.WriteString(`<link rel="canonical" href="` + html.EscapeString(canonical) + `">` + "\n")
+	oembed := c.BaseURL() + "/oembed?url=" + url.QueryEscape(link)
+	b.WriteString(`<link rel="alternate" type="application/json+oembed" href="` + html.EscapeString(oembed) + `" title="` + html.EscapeString(post.Title) + `">` + "\n")
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":       "Post meta retrieved successfully",
+		"status":        fiber.StatusOK,
+		"title":         firstNonEmpty(post.MetaTitle, post.Title),
+		"canonical_url": canonical,
+		"oembed_url":    oembed,
+		"tags":          tags,
+		"html":          b.String(),
+	})
+}
+
+// firstNonEmpty returns the first of values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// dataExportResponse is the client view of an export
+func dataExportResponse(export *models.DataExport) fiber.Map {
+	res := fiber.Map{
+		"id":           export.ID,
+		"status":       export.Status,
+		"created_at":   export.CreatedAt,
+		"completed_at": export.CompletedAt,
+		"expires_at":   export.ExpiresAt,
+	}
+	if export.Status == models.ExportReady {
+		res["size"] = export.Size
+		res["download_url"] = "/users/me/export/" + export.ID.String() + "/download"
+	}
+	if export.Status == models.ExportFailed {
+		res["error"] = export.Error
+	}
+	return res
+}
+
+// RequestDataExport queues an archive of everything stored about the authenticated user. The
+// archive is built in the background and the user is emailed once it can be downloaded; asking
+// again while one is in progress, or within a day of the last one, returns that export instead.
+func RequestDataExport(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("RequestDataExport attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in RequestDataExport")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	export, queued, err := models.RequestDataExport(c.Context(), Redis, DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to request data export")
+		return apierror.Internal("Failed to request data export")
+	}
+
+	status := fiber.StatusOK
+	message := "Data export retrieved successfully"
+	if export.Status == models.ExportPending || export.Status == models.ExportProcessing {
+		status = fiber.StatusAccepted
+		message = "Data export is being prepared; you will be emailed when it is ready"
+	}
+	Logger.Info(c.Context()).WithFields("user_id", userID, "export_id", export.ID, "queued", queued).Logs("Data export requested")
+	return c.Status(status).JSON(fiber.Map{
+		"message": message,
+		"status":  status,
+		"export":  dataExportResponse(export),
+	})
+}
+
+// GetDataExport returns the state of one of the authenticated user's exports
+func GetDataExport(c *fiber.Ctx) error {
+	userID, exportID, err := dataExportTarget(c, "GetDataExport")
+	if err != nil {
+		return err
+	}
+
+	export, err := models.GetDataExport(c.Context(), DB, userID, exportID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "export_id", exportID).Logs("Failed to fetch data export")
+		return apierror.From(err, "Failed to fetch data export")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Data export retrieved successfully",
+		"status":  fiber.StatusOK,
+		"export":  dataExportResponse(export),
+	})
+}
+
+// DownloadDataExport streams a ready export of the authenticated user from upload storage as a ZIP
+// archive
+func DownloadDataExport(c *fiber.Ctx) error {
+	userID, exportID, err := dataExportTarget(c, "DownloadDataExport")
+	if err != nil {
+		return err
+	}
+
+	export, archive, err := models.OpenDataExportArchive(c.Context(), DB, Uploads, userID, exportID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "export_id", exportID).Logs("Failed to fetch data export archive")
+		return apierror.From(err, "Failed to fetch data export")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "export_id", exportID, "size", export.Size).Logs("Data export downloaded")
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="devpulse-export-`+export.CreatedAt.UTC().Format("20060102")+`.zip"`)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).SendStream(archive, int(export.Size))
+}
+
+// dataExportTarget reads the authenticated user and the export named in the path. On failure the
+// IDs are nil and the error answers the request.
+func dataExportTarget(c *fiber.Ctx, handler string) (uuid.UUID, uuid.UUID, error) {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs(handler + " attempted without user_id in context")
+		return uuid.Nil, uuid.Nil, apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in " + handler)
+		return uuid.Nil, uuid.Nil, apierror.BadRequest("Invalid user ID")
+	}
+	exportID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("export_id", c.Params("id")).Logs("Invalid export ID in " + handler)
+		return uuid.Nil, uuid.Nil, apierror.BadRequest("Invalid export ID")
+	}
+	return userID, exportID, nil
+}
+
+// SendDataExportEmail tells a user their export is ready; the export worker calls it once the
+// archive is stored
+func SendDataExportEmail(ctx context.Context, export *models.DataExport, email, username, locale string) error {
+	link := EmailCfg.AppURL + "/settings/export?id=" + export.ID.String()
+	return utils.SendDataExportEmail(ctx, EmailCfg, email, locale, username, link, *export.ExpiresAt, Logger)
+}
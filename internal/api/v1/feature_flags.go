@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// featureFlagRequest holds the settings of a flag; fields left out are not changed.
+type featureFlagRequest struct {
+	Description *string      `json:"description" validate:"omitempty,max=255"`
+	Enabled     *bool        `json:"enabled"`
+	Rollout     *int         `json:"rollout" validate:"omitempty,min=0,max=100"`
+	Users       *[]uuid.UUID `json:"users" validate:"omitempty,max=1000"`
+	Roles       *[]uuid.UUID `json:"roles" validate:"omitempty,max=50"`
+}
+
+func (r featureFlagRequest) update() models.FeatureFlagUpdate {
+	return models.FeatureFlagUpdate{
+		Description: r.Description,
+		Enabled:     r.Enabled,
+		Rollout:     r.Rollout,
+		Users:       r.Users,
+		Roles:       r.Roles,
+	}
+}
+
+// featureFlagAudit is the part of a flag recorded in the audit log.
+func featureFlagAudit(f *models.FeatureFlag) map[string]interface{} {
+	return map[string]interface{}{"key": f.Key, "enabled": f.Enabled, "rollout": f.Rollout, "users": f.Users, "roles": f.Roles}
+}
+
+// ListFeatureFlags lists the feature flags
+func ListFeatureFlags(c *fiber.Ctx) error {
+	flags, err := Services.Flags.List(c.Context())
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch feature flags")
+		return apierror.From(err, "Failed to fetch feature flags")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Feature flags retrieved successfully",
+		"status":  fiber.StatusOK,
+		"flags":   flags,
+	})
+}
+
+// CreateFeatureFlag creates a feature flag, disabled unless the request enables it
+func CreateFeatureFlag(c *fiber.Ctx) error {
+	type CreateFeatureFlagRequest struct {
+		Key string `json:"key" validate:"required,min=2,max=50"`
+		featureFlagRequest
+	}
+
+	actorID := auditActor(c)
+	if actorID == uuid.Nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+	var req CreateFeatureFlagRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	flag, err := Services.Flags.Create(c.Context(), actorID, req.Key, req.update())
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "actor_id", actorID, "flag", req.Key).Logs("Failed to create feature flag")
+		return apierror.From(err, "Failed to create feature flag")
+	}
+	recordAudit(c, actorID, models.AuditFeatureFlagCreate, models.AuditTargetFeatureFlag, flag.ID, nil, featureFlagAudit(flag))
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "flag", flag.Key).Logs("Feature flag created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Feature flag created successfully",
+		"status":  fiber.StatusCreated,
+		"flag":    flag,
+	})
+}
+
+// UpdateFeatureFlag changes the :key feature flag; it takes effect as soon as the cached flags are
+// dropped
+func UpdateFeatureFlag(c *fiber.Ctx) error {
+	actorID := auditActor(c)
+	if actorID == uuid.Nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+	key := c.Params("key")
+	var req featureFlagRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	before, flag, err := Services.Flags.Update(c.Context(), actorID, key, req.update())
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "actor_id", actorID, "flag", key).Logs("Failed to update feature flag")
+		return apierror.From(err, "Failed to update feature flag")
+	}
+	recordAudit(c, actorID, models.AuditFeatureFlagUpdate, models.AuditTargetFeatureFlag, flag.ID, featureFlagAudit(before), featureFlagAudit(flag))
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "flag", key, "enabled", flag.Enabled, "rollout", flag.Rollout).Logs("Feature flag updated")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Feature flag updated successfully",
+		"status":  fiber.StatusOK,
+		"flag":    flag,
+	})
+}
+
+// DeleteFeatureFlag deletes the :key feature flag, turning its feature off for everyone
+func DeleteFeatureFlag(c *fiber.Ctx) error {
+	actorID := auditActor(c)
+	if actorID == uuid.Nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+	key := c.Params("key")
+
+	flag, err := Services.Flags.Delete(c.Context(), key)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "actor_id", actorID, "flag", key).Logs("Failed to delete feature flag")
+		return apierror.From(err, "Failed to delete feature flag")
+	}
+	recordAudit(c, actorID, models.AuditFeatureFlagDelete, models.AuditTargetFeatureFlag, flag.ID, featureFlagAudit(flag), nil)
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "flag", key).Logs("Feature flag deleted")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Feature flag deleted successfully",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// GetFlags returns which feature flags are on for the current user, or for guests when signed out,
+// so clients can show the features they gate
+func GetFlags(c *fiber.Ctx) error {
+	flags, err := Services.Flags.Evaluate(c.Context())
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to evaluate feature flags")
+		return apierror.From(err, "Failed to fetch feature flags")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Feature flags retrieved successfully",
+		"status":  fiber.StatusOK,
+		"flags":   flags,
+	})
+}
+
+// RequireFlag hides a route behind the key feature flag: while it is off for the user, the route
+// answers as if it did not exist. It runs after authentication so targeting applies.
+func RequireFlag(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !Services.Flags.Enabled(c.Context(), key) {
+			return apierror.NotFound("Not found")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+)
+
+// GetFeed returns the authenticated user's home feed: posts of followed users and tags ranked by
+// recency and engagement. Pages are chained with the returned next_cursor.
+func GetFeed(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetFeed attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetFeed")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	p, err := Paginator.Parse(c, 20, 50)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Invalid cursor in GetFeed")
+		return apierror.From(err, "Invalid cursor")
+	}
+
+	page, err := models.GetFeed(c.Context(), Redis, DB, userID, p)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch feed")
+		return postError(c, err, "Failed to fetch feed")
+	}
+	next, err := Paginator.Seal(page.Next)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to seal feed cursor")
+		return apierror.Internal("Failed to fetch feed")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Feed retrieved successfully",
+		"status":      fiber.StatusOK,
+		"posts":       postsResponse(page.Items),
+		"limit":       p.Limit,
+		"next_cursor": next,
+		"total":       page.Total,
+	})
+}
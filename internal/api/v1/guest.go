@@ -0,0 +1,57 @@
+package v1
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+)
+
+// guestResponse is a response cached for anonymous readers.
+type guestResponse struct {
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// GuestCache serves the public read routes to anonymous readers from a short-lived cache shared
+// by every instance, and lets browsers and CDNs keep the answer as long. Readers with
+// credentials always reach the handler, since answers may depend on who is asking. Only 200
+// answers without cookies are cached, and handlers opt out by marking theirs private or no-store.
+func GuestCache(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet || !guestRead(c) {
+		return c.Next()
+	}
+
+	ttl := Redis.TTL("guest_response")
+	maxAge := "public, max-age=" + strconv.Itoa(int(ttl.Seconds()))
+	key := cache.GuestResponseKey(c.OriginalURL())
+	if cached, ok, err := cache.Get[guestResponse](c.Context(), Redis, key); err == nil && ok {
+		c.Set(fiber.HeaderContentType, cached.ContentType)
+		c.Set(fiber.HeaderCacheControl, maxAge)
+		c.Set(fiber.HeaderVary, "Authorization, Cookie")
+		c.Set("X-Cache", "HIT")
+		return c.Status(fiber.StatusOK).Send(cached.Body)
+	}
+
+	if err := c.Next(); err != nil {
+		return err
+	}
+	res := c.Response()
+	control := string(res.Header.Peek(fiber.HeaderCacheControl))
+	if res.StatusCode() != fiber.StatusOK || len(res.Header.Peek(fiber.HeaderSetCookie)) > 0 ||
+		strings.Contains(control, "private") || strings.Contains(control, "no-store") {
+		return nil
+	}
+
+	if control == "" {
+		c.Set(fiber.HeaderCacheControl, maxAge)
+	}
+	c.Set(fiber.HeaderVary, "Authorization, Cookie")
+	c.Set("X-Cache", "MISS")
+	cached := guestResponse{ContentType: string(res.Header.ContentType()), Body: append([]byte(nil), res.Body()...)}
+	if err := cache.Set(c.Context(), Redis, key, cached, ttl); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "path", c.Path()).Logs("Failed to cache guest response")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadinessTimeout bounds each dependency check of Readyz so a hung database or Redis fails the
+// probe instead of stalling it.
+const ReadinessTimeout = 2 * time.Second
+
+// Healthz reports that the process is up and serving requests. It checks no dependencies, so an
+// outage of Postgres or Redis does not get healthy instances restarted.
+func Healthz(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "ok",
+	})
+}
+
+// Readyz reports whether the instance can serve traffic: it answers 503 unless Postgres responds
+// within ReadinessTimeout. The API degrades rather than fails without Redis, so an unreachable
+// Redis leaves the instance ready and flags it as degraded. Failures are logged rather than
+// returned, since their messages may name internal hosts.
+func Readyz(c *fiber.Ctx) error {
+	status, state := fiber.StatusOK, "ok"
+	checks := fiber.Map{}
+	if err := checkDependency(c.Context(), pingPostgres); err != nil {
+		Logger.Warn(c.Context()).WithFields("dependency", "postgres", "error", err).Logs("Readiness check failed")
+		status, state = fiber.StatusServiceUnavailable, "unavailable"
+		checks["postgres"] = "unavailable"
+	} else {
+		checks["postgres"] = "ok"
+	}
+
+	degraded := false
+	if err := checkDependency(c.Context(), pingRedis); err != nil {
+		Logger.Warn(c.Context()).WithFields("dependency", "redis", "error", err).Logs("Readiness check failed, serving degraded")
+		degraded = true
+		checks["redis"] = "unavailable"
+		if status == fiber.StatusOK {
+			state = "degraded"
+		}
+	} else {
+		checks["redis"] = "ok"
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(fiber.Map{
+		"status":   state,
+		"degraded": degraded,
+		"checks":   checks,
+	})
+}
+
+// checkDependency runs a check under ReadinessTimeout.
+func checkDependency(ctx context.Context, check func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, ReadinessTimeout)
+	defer cancel()
+	return check(ctx)
+}
+
+func pingPostgres(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func pingRedis(ctx context.Context) error {
+	return Redis.Ping(ctx).Err()
+}
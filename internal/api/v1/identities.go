@@ -0,0 +1,246 @@
+package v1
+
+import (
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// ListIdentities returns the ways the authenticated user can sign in: their password, if they
+// have one, and the social login accounts linked to them
+func ListIdentities(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("ListIdentities attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in ListIdentities")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{userID}, models.PreloadMinimal)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user in ListIdentities")
+		return apierror.From(err, "Failed to fetch identities")
+	}
+	identities, err := models.ListIdentities(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch identities")
+		return apierror.From(err, "Failed to fetch identities")
+	}
+
+	providers := make([]string, 0, len(OAuthProviders))
+	for name := range OAuthProviders {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":    "Identities retrieved successfully",
+		"status":     fiber.StatusOK,
+		"password":   !user.Passwordless,
+		"identities": identities,
+		"providers":  providers,
+	})
+}
+
+// LinkIdentity starts linking a social login account to the authenticated user. The client sends
+// the browser to the returned URL; the provider's callback then links the account.
+func LinkIdentity(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("LinkIdentity attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in LinkIdentity")
+		return apierror.BadRequest("Invalid user ID")
+	}
+	name := c.Params("provider")
+	provider, ok := OAuthProviders[name]
+	if !ok {
+		Logger.Warn(c.Context()).WithFields("provider", name).Logs("Unsupported OAuth provider")
+		return apierror.NotFound("Unsupported login provider")
+	}
+
+	consentURL, err := beginOAuth(c, provider, userID.String())
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID, "provider", name).Logs("Failed to store OAuth state")
+		return apierror.Internal("Failed to start linking")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "provider", name).Logs("Identity link started")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":           "Continue at the provider to link your account",
+		"status":            fiber.StatusOK,
+		"authorization_url": consentURL,
+	})
+}
+
+// completeIdentityLink answers the OAuth callback of a flow started by LinkIdentity, linking the
+// provider's account to the user who started it
+func completeIdentityLink(c *fiber.Ctx, userIDRaw string, profile *models.OAuthProfile) error {
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in OAuth link state")
+		return apierror.BadRequest("Invalid or expired login state")
+	}
+
+	identity, err := models.LinkIdentity(c.Context(), Redis, DB, userID, profile, c.IP())
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "provider", profile.Provider).Logs("Failed to link identity")
+		return apierror.From(err, "Failed to link account")
+	}
+	if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventLoginAdded, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record login method added")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "provider", profile.Provider).Logs("Identity linked")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Account linked successfully",
+		"status":   fiber.StatusCreated,
+		"identity": identity,
+	})
+}
+
+// UnlinkIdentity removes a social login account from the authenticated user, unless it is the
+// last way they can sign in
+func UnlinkIdentity(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("UnlinkIdentity attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in UnlinkIdentity")
+		return apierror.BadRequest("Invalid user ID")
+	}
+	provider := c.Params("provider")
+
+	if err := models.UnlinkIdentity(c.Context(), Redis, DB, userID, provider, c.IP()); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "provider", provider).Logs("Failed to unlink identity")
+		return apierror.From(err, "Failed to unlink account")
+	}
+	if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventLoginRemoved, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record login method removed")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "provider", provider).Logs("Identity unlinked")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Account unlinked successfully",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// SetPassword lets a user who signed up with a social login add a password to sign in with
+func SetPassword(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("SetPassword attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in SetPassword")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	type SetPasswordRequest struct {
+		NewPassword     string `json:"new_password" validate:"required,min=8,max=100"`
+		ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
+	}
+	var req SetPasswordRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	if utils.ContainsInvalidChars(req.NewPassword) {
+		return apierror.BadRequest("password contains invalid characters")
+	}
+	if !utils.IsStrongPassword(req.NewPassword) {
+		return apierror.BadRequest("new password does not meet strength requirements")
+	}
+
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to hash password")
+		return apierror.Internal("Failed to process password")
+	}
+	if err := models.SetPassword(c.Context(), Redis, DB, userID, hash, c.IP()); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to set password")
+		return apierror.From(err, "Failed to set password")
+	}
+	if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventLoginAdded, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record login method added")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("Password set")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Password set successfully",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// RemovePassword stops the authenticated user from signing in with their password, leaving their
+// linked social logins as the way in. The current password confirms the change.
+func RemovePassword(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("RemovePassword attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in RemovePassword")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	type RemovePasswordRequest struct {
+		Password string `json:"password" validate:"required,min=6,max=100"`
+	}
+	var req RemovePasswordRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	user, err := models.GetTwoFactorUser(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user in RemovePassword")
+		return apierror.Internal("Failed to remove password")
+	}
+	if err := utils.ComparePasswords(user.Password, req.Password); err != nil {
+		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Invalid password in RemovePassword")
+		return apierror.Unauthorized("Invalid password")
+	}
+
+	if err := models.RemovePassword(c.Context(), Redis, DB, userID, c.IP()); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to remove password")
+		return apierror.From(err, "Failed to remove password")
+	}
+	if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventLoginRemoved, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record login method removed")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("Password removed")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Password removed successfully",
+		"status":  fiber.StatusOK,
+	})
+}
@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// GetRegistrationMode tells sign-up forms whether registration is open, needs an invitation code
+// or is closed
+func GetRegistrationMode(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":             "Registration mode retrieved successfully",
+		"status":              fiber.StatusOK,
+		"mode":                RegistrationMode,
+		"invitation_required": RegistrationMode == models.RegistrationInviteOnly,
+	})
+}
+
+// invitationUser reads the authenticated user of an invitation request. On failure the ID is
+// uuid.Nil and the error answers the request.
+func invitationUser(c *fiber.Ctx, action string) (uuid.UUID, error) {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs(action + " attempted without user_id in context")
+		return uuid.Nil, apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in " + action)
+		return uuid.Nil, apierror.BadRequest("Invalid user ID")
+	}
+	return userID, nil
+}
+
+// listInvitations answers a page of invitations, those of createdBy or all of them
+func listInvitations(c *fiber.Ctx, createdBy *uuid.UUID) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	invitations, total, err := models.ListInvitations(c.Context(), DB, createdBy, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch invitations")
+		return apierror.From(err, "Failed to fetch invitations")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Invitations retrieved successfully",
+		"status":      fiber.StatusOK,
+		"invitations": invitations,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// ListInvitations lists the invitations the authenticated user created
+func ListInvitations(c *fiber.Ctx) error {
+	userID, err := invitationUser(c, "ListInvitations")
+	if userID == uuid.Nil {
+		return err
+	}
+	return listInvitations(c, &userID)
+}
+
+// ListAllInvitations lists the invitations of every user for admins
+func ListAllInvitations(c *fiber.Ctx) error {
+	return listInvitations(c, nil)
+}
+
+// CreateInvitation issues an invitation code. Members are held to a few short-lived invitations of
+// a few uses each; holders of manage_invitations are not limited.
+func CreateInvitation(c *fiber.Ctx) error {
+	userID, err := invitationUser(c, "CreateInvitation")
+	if userID == uuid.Nil {
+		return err
+	}
+
+	type CreateInvitationRequest struct {
+		MaxUses       int `json:"max_uses" validate:"omitempty,min=1,max=1000"`
+		ExpiresInDays int `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+	}
+	var req CreateInvitationRequest
+	if len(c.Body()) > 0 {
+		if err := utils.StrictBodyParser(c, &req); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+			return apierror.BadRequest("Invalid request body")
+		}
+		if err := Validator.Validate(req); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+			return apierror.Validation(err)
+		}
+	}
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+
+	staff, err := hasPermission(c, userID, "manage_invitations")
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to check permissions")
+		return apierror.Internal("Failed to create invitation")
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		at := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &at
+	}
+	invitation, err := models.CreateInvitation(c.Context(), DB, userID, req.MaxUses, expiresAt, !staff)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to create invitation")
+		return apierror.From(err, "Failed to create invitation")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "invitation_id", invitation.ID).Logs("Invitation created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":    "Invitation created successfully",
+		"status":     fiber.StatusCreated,
+		"invitation": invitation,
+	})
+}
+
+// RevokeInvitation revokes an invitation of the authenticated user, or anyone's for holders of
+// manage_invitations
+func RevokeInvitation(c *fiber.Ctx) error {
+	userID, err := invitationUser(c, "RevokeInvitation")
+	if userID == uuid.Nil {
+		return err
+	}
+	invitationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("invitation_id", c.Params("id")).Logs("Invalid invitation ID")
+		return apierror.BadRequest("Invalid invitation ID")
+	}
+
+	staff, err := hasPermission(c, userID, "manage_invitations")
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to check permissions")
+		return apierror.Internal("Failed to revoke invitation")
+	}
+	if err := models.RevokeInvitation(c.Context(), DB, userID, invitationID, staff); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "invitation_id", invitationID).Logs("Failed to revoke invitation")
+		return apierror.From(err, "Failed to revoke invitation")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "invitation_id", invitationID).Logs("Invitation revoked")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Invitation revoked successfully",
+		"status":  fiber.StatusOK,
+	})
+}
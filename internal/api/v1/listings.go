@@ -0,0 +1,366 @@
+package v1
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/contentfilter"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// listingResponse is the public representation of a listing
+func listingResponse(listing *models.Listing) fiber.Map {
+	res := fiber.Map{
+		"id":          listing.ID,
+		"category":    listing.Category,
+		"title":       listing.Title,
+		"body":        listing.Body,
+		"tags":        listing.Tags,
+		"location":    listing.Location,
+		"contact_url": listing.ContactURL,
+		"author_id":   listing.AuthorID,
+		"expires_at":  listing.ExpiresAt,
+		"expired":     listing.Expired(),
+		"created_at":  listing.CreatedAt,
+		"updated_at":  listing.UpdatedAt,
+	}
+	if listing.Author.ID != uuid.Nil {
+		res["author"] = fiber.Map{
+			"id":         listing.Author.ID,
+			"username":   listing.Author.Username,
+			"name":       listing.Author.Profile.Name,
+			"avatar_url": listing.Author.Profile.AvatarURL,
+		}
+	}
+	return res
+}
+
+// managedListing loads the listing named by the :id param for the authenticated user, who must be
+// its author or hold moderate_listing. On failure the listing is nil and the error answers the
+// request.
+func managedListing(c *fiber.Ctx) (*models.Listing, uuid.UUID, error) {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("Listing management attempted without user_id in context")
+		return nil, uuid.Nil, apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in listing management")
+		return nil, uuid.Nil, apierror.BadRequest("Invalid user ID")
+	}
+
+	listingID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("listing_id", c.Params("id")).Logs("Invalid listing ID")
+		return nil, userID, apierror.BadRequest("Invalid listing ID")
+	}
+	listing, err := models.GetListing(c.Context(), DB, listingID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "listing_id", listingID).Logs("Failed to fetch listing")
+		return nil, userID, apierror.From(err, "Failed to fetch listing")
+	}
+	if listing.AuthorID == userID {
+		return listing, userID, nil
+	}
+
+	allowed, err := hasPermission(c, userID, "moderate_listing")
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch permissions in listing management")
+		return nil, userID, apierror.From(err, "Failed to fetch user")
+	}
+	if allowed {
+		return listing, userID, nil
+	}
+
+	Logger.Warn(c.Context()).WithFields("user_id", userID, "listing_id", listingID).Logs("User is not allowed to manage listing")
+	return nil, userID, apierror.Forbidden("You can only manage your own listings")
+}
+
+// ListingOwner resolves the author of the listing named by the :id path parameter for
+// owner-or-permission access rules
+func ListingOwner(c *fiber.Ctx) (uuid.UUID, error) {
+	listingID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, apierror.BadRequest("Invalid listing ID")
+	}
+	listing, err := models.GetListing(c.Context(), DB, listingID)
+	if err != nil {
+		return uuid.Nil, apierror.From(err, "Failed to fetch listing")
+	}
+	return listing.AuthorID, nil
+}
+
+// CreateListing puts a listing on the board for the authenticated user. It stays up for
+// expires_in_days, 30 at most, and the user may only have a few listings up at once
+func CreateListing(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("CreateListing attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in CreateListing")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	type CreateListingRequest struct {
+		Category      string   `json:"category" validate:"required,oneof=events jobs collabs"`
+		Title         string   `json:"title" validate:"required,min=5,max=128"`
+		Body          string   `json:"body" validate:"required,max=2000"`
+		Tags          []string `json:"tags" validate:"max=4,dive,min=2,max=30,alphanum"`
+		Location      string   `json:"location" validate:"omitempty,max=100"`
+		ContactURL    string   `json:"contact_url" validate:"omitempty,url,max=500"`
+		ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=30"`
+	}
+
+	var req CreateListingRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	if err := filterContent(c, contentField{Name: "title", Field: contentfilter.FieldPostTitle, Text: req.Title}); err != nil {
+		return err
+	}
+
+	listing := &models.Listing{
+		AuthorID:   userID,
+		Category:   req.Category,
+		Title:      req.Title,
+		Body:       req.Body,
+		Tags:       req.Tags,
+		Location:   req.Location,
+		ContactURL: req.ContactURL,
+	}
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	if err := models.CreateListing(c.Context(), DB, listing, ttl); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to create listing")
+		return apierror.From(err, "Failed to create listing")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "listing_id", listing.ID, "category", listing.Category).Logs("Listing created successfully")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Listing created successfully",
+		"status":  fiber.StatusCreated,
+		"listing": listingResponse(listing),
+	})
+}
+
+// ListListings browses the board's current listings, newest first, filtered by ?category and ?tag
+func ListListings(c *fiber.Ctx) error {
+	category := strings.ToLower(strings.TrimSpace(c.Query("category")))
+	if category != "" && !models.IsListingCategory(category) {
+		return apierror.BadRequest("Invalid listing category")
+	}
+	tag := c.Query("tag")
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	listings, total, err := models.GetListings(c.Context(), DB, category, tag, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "category", category, "tag", tag).Logs("Failed to fetch listings")
+		return apierror.From(err, "Failed to fetch listings")
+	}
+
+	res := make([]fiber.Map, len(listings))
+	for i := range listings {
+		res[i] = listingResponse(&listings[i])
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":    "Listings retrieved successfully",
+		"status":     fiber.StatusOK,
+		"listings":   res,
+		"categories": models.ListingCategories,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+	})
+}
+
+// GetListing returns a listing by ID. Once expired, only its author can still see it
+func GetListing(c *fiber.Ctx) error {
+	listingID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("listing_id", c.Params("id")).Logs("Invalid listing ID")
+		return apierror.BadRequest("Invalid listing ID")
+	}
+
+	listing, err := models.GetListing(c.Context(), DB, listingID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "listing_id", listingID).Logs("Failed to fetch listing")
+		return apierror.From(err, "Failed to fetch listing")
+	}
+	if listing.Expired() {
+		viewer := viewerID(c)
+		if viewer == nil || *viewer != listing.AuthorID {
+			return apierror.NotFound("Listing not found")
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Listing retrieved successfully",
+		"status":  fiber.StatusOK,
+		"listing": listingResponse(listing),
+	})
+}
+
+// GetMyListings lists the authenticated user's listings, expired ones included
+func GetMyListings(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetMyListings attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetMyListings")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	listings, total, err := models.GetAuthorListings(c.Context(), DB, userID, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user listings")
+		return apierror.From(err, "Failed to fetch listings")
+	}
+
+	res := make([]fiber.Map, len(listings))
+	for i := range listings {
+		res[i] = listingResponse(&listings[i])
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Listings retrieved successfully",
+		"status":   fiber.StatusOK,
+		"listings": res,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+	})
+}
+
+// UpdateListing edits a listing; its expiry only moves when it is renewed
+func UpdateListing(c *fiber.Ctx) error {
+	listing, userID, err := managedListing(c)
+	if listing == nil {
+		return err
+	}
+
+	type UpdateListingRequest struct {
+		Category   *string   `json:"category" validate:"omitempty,oneof=events jobs collabs"`
+		Title      *string   `json:"title" validate:"omitempty,min=5,max=128"`
+		Body       *string   `json:"body" validate:"omitempty,max=2000"`
+		Tags       *[]string `json:"tags" validate:"omitempty,max=4,dive,min=2,max=30,alphanum"`
+		Location   *string   `json:"location" validate:"omitempty,max=100"`
+		ContactURL *string   `json:"contact_url" validate:"omitempty,url,max=500"`
+	}
+
+	var req UpdateListingRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	opts := []models.ListingOption{}
+	if req.Category != nil {
+		opts = append(opts, models.WithListingCategory(*req.Category))
+	}
+	if req.Title != nil {
+		if err := filterContent(c, contentField{Name: "title", Field: contentfilter.FieldPostTitle, Text: *req.Title}); err != nil {
+			return err
+		}
+		opts = append(opts, models.WithListingTitle(*req.Title))
+	}
+	if req.Body != nil {
+		opts = append(opts, models.WithListingBody(*req.Body))
+	}
+	if req.Tags != nil {
+		opts = append(opts, models.WithListingTags(*req.Tags))
+	}
+	if req.Location != nil {
+		opts = append(opts, models.WithListingLocation(*req.Location))
+	}
+	if req.ContactURL != nil {
+		opts = append(opts, models.WithListingContactURL(*req.ContactURL))
+	}
+
+	updated, err := models.UpdateListing(c.Context(), DB, listing.ID, opts...)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "listing_id", listing.ID).Logs("Failed to update listing")
+		return apierror.From(err, "Failed to update listing")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "listing_id", listing.ID).Logs("Listing updated successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Listing updated successfully",
+		"status":  fiber.StatusOK,
+		"listing": listingResponse(updated),
+	})
+}
+
+// RenewListing puts a listing back on the board for another 30 days from now
+func RenewListing(c *fiber.Ctx) error {
+	listing, userID, err := managedListing(c)
+	if listing == nil {
+		return err
+	}
+
+	renewed, err := models.RenewListing(c.Context(), DB, listing.ID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "listing_id", listing.ID).Logs("Failed to renew listing")
+		return apierror.From(err, "Failed to renew listing")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "listing_id", listing.ID, "expires_at", renewed.ExpiresAt).Logs("Listing renewed successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Listing renewed successfully",
+		"status":  fiber.StatusOK,
+		"listing": listingResponse(renewed),
+	})
+}
+
+// DeleteListing takes a listing off the board
+func DeleteListing(c *fiber.Ctx) error {
+	listing, userID, err := managedListing(c)
+	if listing == nil {
+		return err
+	}
+
+	if err := models.DeleteListing(c.Context(), DB, listing.ID); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "listing_id", listing.ID).Logs("Failed to delete listing")
+		return apierror.From(err, "Failed to delete listing")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "listing_id", listing.ID).Logs("Listing deleted successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Listing deleted successfully",
+		"status":  fiber.StatusOK,
+	})
+}
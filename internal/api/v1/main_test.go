@@ -0,0 +1,21 @@
+package v1_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+)
+
+func TestMain(m *testing.M) { os.Exit(testutil.Main(m)) }
+
+// mustUUID parses an ID the API returned.
+func mustUUID(tb testing.TB, raw string) uuid.UUID {
+	tb.Helper()
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		tb.Fatalf("parsing ID %q: %v", raw, err)
+	}
+	return id
+}
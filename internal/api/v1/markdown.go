@@ -0,0 +1,26 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/markdown"
+)
+
+// Markdown renders the content of posts and comments to sanitized HTML.
+var Markdown = markdown.New()
+
+// renderContent returns the sanitized HTML of content written in format, cached under key until
+// the content is edited. A failed render is logged and yields no HTML.
+func renderContent(c *fiber.Ctx, key, source, format string) string {
+	rendered, err := cache.Fetch(c.Context(), Redis, key, Redis.TTL("rendered"), func(ctx context.Context) (string, []string, error) {
+		html, err := Markdown.RenderFormat(source, format)
+		return html, nil, err
+	})
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "key", key).Logs("Failed to render content")
+		return ""
+	}
+	return rendered
+}
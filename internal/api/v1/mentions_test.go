@@ -0,0 +1,192 @@
+package v1_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+)
+
+// postContent pads text to the minimum length of a post.
+func postContent(text string) string {
+	return text + " " + strings.Repeat("Some words about Go. ", 6)
+}
+
+// publishPost publishes a post with content as the user token belongs to and returns its ID.
+func publishPost(t *testing.T, e *testutil.Env, token, content string) string {
+	t.Helper()
+	res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/posts", Token: token, Body: map[string]interface{}{
+		"title":   "Notes on mentions",
+		"content": postContent(content),
+		"publish": true,
+	}})
+	if res.Status != http.StatusCreated {
+		t.Fatalf("creating post: status %d: %s", res.Status, res.Body)
+	}
+	var body struct {
+		Post struct {
+			ID string `json:"id"`
+		} `json:"post"`
+	}
+	res.JSON(t, &body)
+	return body.Post.ID
+}
+
+// editPost replaces the content of a post.
+func editPost(t *testing.T, e *testutil.Env, token, id, content string) {
+	t.Helper()
+	res := e.Do(t, testutil.Request{Method: http.MethodPut, Path: "/posts/" + id, Token: token, Body: map[string]string{"content": postContent(content)}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("editing post: status %d: %s", res.Status, res.Body)
+	}
+}
+
+// comment comments content on a post and returns the comment's ID.
+func comment(t *testing.T, e *testutil.Env, token, postID, content string) string {
+	t.Helper()
+	res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/posts/" + postID + "/comments", Token: token, Body: map[string]string{"content": content}})
+	if res.Status != http.StatusCreated {
+		t.Fatalf("commenting: status %d: %s", res.Status, res.Body)
+	}
+	var body struct {
+		Comment struct {
+			ID string `json:"id"`
+		} `json:"comment"`
+	}
+	res.JSON(t, &body)
+	return body.Comment.ID
+}
+
+// editComment replaces the content of a comment.
+func editComment(t *testing.T, e *testutil.Env, token, id, content string) {
+	t.Helper()
+	res := e.Do(t, testutil.Request{Method: http.MethodPut, Path: "/comments/" + id, Token: token, Body: map[string]string{"content": content}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("editing comment: status %d: %s", res.Status, res.Body)
+	}
+}
+
+// myMentions lists the mentions of user through GET /me/mentions.
+func myMentions(t *testing.T, e *testutil.Env, user *models.User, query string) []models.Mention {
+	t.Helper()
+	res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/me/mentions" + query, Token: e.Token(t, user)})
+	if res.Status != http.StatusOK {
+		t.Fatalf("GET /me/mentions%s: status %d: %s", query, res.Status, res.Body)
+	}
+	var body struct {
+		Mentions []models.Mention `json:"mentions"`
+	}
+	res.JSON(t, &body)
+	return body.Mentions
+}
+
+// mentionNotifications counts the mention notifications user received.
+func mentionNotifications(t *testing.T, e *testutil.Env, user *models.User) int64 {
+	t.Helper()
+	var n int64
+	if err := e.DB.Model(&models.Notification{}).Where("user_id = ? AND type = ?", user.ID, models.NotificationMention).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestGetMyMentions(t *testing.T) {
+	e := testutil.Setup(t)
+	author := e.CreateUser(t, models.RoleMember)
+	mentioned := e.CreateUser(t, models.RoleMember)
+	token := e.Token(t, author)
+
+	postID := publishPost(t, e, token, "Thanks to @"+mentioned.Username+" for the review.")
+	mentions := myMentions(t, e, mentioned, "")
+	if len(mentions) != 1 || mentions[0].SourceType != models.MentionSourcePost || mentions[0].SourceID.String() != postID || mentions[0].AuthorID != author.ID {
+		t.Fatalf("after the post: got %+v, want the post's mention", mentions)
+	}
+
+	commentID := comment(t, e, token, postID, "@"+mentioned.Username+" what do you think?")
+	if mentions := myMentions(t, e, mentioned, ""); len(mentions) != 2 {
+		t.Fatalf("after the comment: got %d mentions, want 2", len(mentions))
+	}
+	if mentions := myMentions(t, e, mentioned, "?type=comment"); len(mentions) != 1 || mentions[0].SourceID.String() != commentID {
+		t.Fatalf("comments only: got %+v, want the comment's mention", mentions)
+	}
+
+	editPost(t, e, token, postID, "Thanks to everyone for the review.")
+	if mentions := myMentions(t, e, mentioned, ""); len(mentions) != 1 || mentions[0].SourceID.String() != commentID {
+		t.Fatalf("after editing the post: got %+v, want only the comment's mention", mentions)
+	}
+	editComment(t, e, token, commentID, "What does everyone think?")
+	if mentions := myMentions(t, e, mentioned, ""); len(mentions) != 0 {
+		t.Fatalf("after editing the comment: got %+v, want none", mentions)
+	}
+
+	if other := myMentions(t, e, author, ""); len(other) != 0 {
+		t.Fatalf("the author has %d mentions, want none", len(other))
+	}
+	res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/me/mentions?type=tag", Token: e.Token(t, mentioned)})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("unknown type: status %d, want 400", res.Status)
+	}
+}
+
+func TestMentionsReconciledOnEdit(t *testing.T) {
+	sources := []struct {
+		kind   string
+		create func(t *testing.T, e *testutil.Env, token, content string) string
+		edit   func(t *testing.T, e *testutil.Env, token, id, content string)
+	}{
+		{kind: models.MentionSourcePost, create: publishPost, edit: editPost},
+		{
+			kind: models.MentionSourceComment,
+			create: func(t *testing.T, e *testutil.Env, token, content string) string {
+				return comment(t, e, token, publishPost(t, e, token, "A post to comment on."), content)
+			},
+			edit: editComment,
+		},
+	}
+	for _, source := range sources {
+		t.Run(source.kind, func(t *testing.T) {
+			e := testutil.Setup(t)
+			author := e.CreateUser(t, models.RoleMember)
+			removed := e.CreateUser(t, models.RoleMember)
+			kept := e.CreateUser(t, models.RoleMember)
+			added := e.CreateUser(t, models.RoleMember)
+			token := e.Token(t, author)
+
+			id := source.create(t, e, token, "Ping @"+removed.Username+" and @"+kept.Username)
+			before := myMentions(t, e, kept, "")
+			source.edit(t, e, token, id, "Ping @"+kept.Username+" and @"+added.Username)
+
+			cases := []struct {
+				name          string
+				user          *models.User
+				mentions      int
+				notifications int64
+			}{
+				{name: "removed", user: removed, mentions: 0, notifications: 1},
+				{name: "unchanged", user: kept, mentions: 1, notifications: 1},
+				{name: "added", user: added, mentions: 1, notifications: 1},
+			}
+			for _, tc := range cases {
+				mentions := myMentions(t, e, tc.user, "")
+				if len(mentions) != tc.mentions {
+					t.Errorf("%s mention: %d records, want %d", tc.name, len(mentions), tc.mentions)
+				}
+				for _, m := range mentions {
+					if m.SourceType != source.kind || m.SourceID != uuid.MustParse(id) {
+						t.Errorf("%s mention: from %s %s, want %s %s", tc.name, m.SourceType, m.SourceID, source.kind, id)
+					}
+				}
+				if n := mentionNotifications(t, e, tc.user); n != tc.notifications {
+					t.Errorf("%s mention: %d notifications, want %d", tc.name, n, tc.notifications)
+				}
+			}
+			// The unchanged mention keeps its record rather than being recreated.
+			if after := myMentions(t, e, kept, ""); len(before) != 1 || len(after) != 1 || after[0].ID != before[0].ID {
+				t.Fatalf("unchanged mention recreated: before %+v, after %+v", before, after)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// notificationPreferencesResponse is the matrix view of a user's notification preferences
+func notificationPreferencesResponse(np *models.NotificationPreferences) fiber.Map {
+	return fiber.Map{
+		"matrix":           np.Matrix(),
+		"digest_frequency": np.DigestFrequency,
+		"last_digest_at":   np.LastDigestAt,
+		"updated_at":       np.UpdatedAt,
+	}
+}
+
+// GetNotificationMatrix returns the authenticated user's notification preferences as a channel by
+// event matrix, with the channels and events it spans
+func GetNotificationMatrix(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetNotificationMatrix attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetNotificationMatrix")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	np, err := models.GetNotificationPreferencesByUser(c.Context(), Redis, DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch notification preferences")
+		return apierror.From(err, "Failed to fetch notification preferences")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Notification preferences retrieved successfully",
+		"status":      fiber.StatusOK,
+		"preferences": notificationPreferencesResponse(np),
+		"channels":    models.NotificationChannels,
+		"events":      models.NotificationEvents,
+	})
+}
+
+// UpdateNotificationMatrix changes cells of the authenticated user's notification matrix. Only the
+// cells in the request change, so clients can toggle one channel of one event at a time.
+func UpdateNotificationMatrix(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("UpdateNotificationMatrix attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in UpdateNotificationMatrix")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	type UpdateNotificationMatrixRequest struct {
+		Matrix          models.NotificationMatrix `json:"matrix"`
+		DigestFrequency string                    `json:"digest_frequency" validate:"omitempty,oneof=daily weekly"`
+	}
+	var req UpdateNotificationMatrixRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	if len(req.Matrix) == 0 && req.DigestFrequency == "" {
+		return apierror.BadRequest("Nothing to update")
+	}
+
+	np, err := models.UpdateNotificationPreferences(c.Context(), Redis, DB, userID, req.Matrix, req.DigestFrequency)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to update notification preferences")
+		return apierror.From(err, "Failed to update notification preferences")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("Notification preferences updated")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Notification preferences updated successfully",
+		"status":      fiber.StatusOK,
+		"preferences": notificationPreferencesResponse(np),
+	})
+}
@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+)
+
+const (
+	// streamHeartbeat keeps idle proxies from dropping the stream and detects gone clients.
+	streamHeartbeat = 25 * time.Second
+	// streamLifetime matches the access token lifetime, so a client reconnects through the auth
+	// middleware before its credentials would have expired.
+	streamLifetime = 15 * time.Minute
+	// streamRetry tells EventSource clients how long to wait before reconnecting, in milliseconds.
+	streamRetry = 3000
+	// streamBacklog caps how many missed notifications a reconnecting client catches up on.
+	streamBacklog = 50
+)
+
+// writeNotificationEvent writes a notification as a server-sent event whose ID lets the client
+// resume after it
+func writeNotificationEvent(w *bufio.Writer, id uuid.UUID, data []byte) error {
+	if _, err := fmt.Fprintf(w, "id: %s\nevent: notification\ndata: %s\n\n", id, data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// StreamNotifications pushes the authenticated user's new notifications as server-sent events.
+// Clients resume with the Last-Event-ID header and receive what they missed first.
+func StreamNotifications(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("StreamNotifications attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in StreamNotifications")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	var backlog []models.Notification
+	if lastID := c.Get("Last-Event-ID"); lastID != "" {
+		if afterID, err := uuid.Parse(lastID); err == nil {
+			backlog, err = models.GetNotificationsAfter(c.Context(), DB, userID, afterID, streamBacklog)
+			if err != nil {
+				Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to load missed notifications")
+			}
+		}
+	}
+
+	// The stream outlives the request context, so it gets its own.
+	ctx, cancel := context.WithTimeout(context.Background(), streamLifetime)
+	sub := Redis.Subscribe(ctx, models.NotificationChannel(userID))
+	if _, err := sub.Receive(ctx); err != nil {
+		cancel()
+		sub.Close()
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to subscribe to notifications")
+		return apierror.Unavailable("Notification stream unavailable")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("Notification stream opened")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer sub.Close()
+
+		fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+		if err := w.Flush(); err != nil {
+			return
+		}
+		for _, n := range backlog {
+			data, _ := json.Marshal(n)
+			if err := writeNotificationEvent(w, n.ID, data); err != nil {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeat.C:
+				if _, err := w.WriteString(": heartbeat\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var n models.Notification
+				if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+					continue
+				}
+				if err := writeNotificationEvent(w, n.ID, []byte(msg.Payload)); err != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
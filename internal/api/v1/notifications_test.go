@@ -0,0 +1,275 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// notify creates a notification of kind for user.
+func notify(t *testing.T, e *testutil.Env, user *models.User, kind, message string) *models.Notification {
+	t.Helper()
+	n, err := models.NewNotification(context.Background(), e.Redis, e.DB, user.ID, kind, message)
+	if err != nil {
+		t.Fatalf("creating notification: %v", err)
+	}
+	return n
+}
+
+func TestNotificationOwnership(t *testing.T) {
+	e := testutil.Setup(t)
+	owner := e.CreateUser(t, models.RoleMember)
+	stranger := e.CreateUser(t, models.RoleMember)
+	n := notify(t, e, owner, "mention", "You were mentioned")
+	ownerToken, strangerToken := e.Token(t, owner), e.Token(t, stranger)
+
+	read := testutil.Request{Method: http.MethodPost, Path: "/user/notification/me/" + n.ID.String()}
+	markRead := testutil.Request{Method: http.MethodPatch, Path: "/notifications/" + n.ID.String() + "/read", Body: map[string]bool{"read": true}}
+	for _, req := range []testutil.Request{read, markRead} {
+		req.Token = strangerToken
+		if res := e.Do(t, req); res.Status != http.StatusNotFound {
+			t.Fatalf("%s %s as another user: status %d, want 404: %s", req.Method, req.Path, res.Status, res.Body)
+		}
+	}
+	var appErr *utils.CustomError
+	if err := models.DeleteNotification(context.Background(), e.Redis, e.DB, stranger.ID, n.ID); !utils.As(err, &appErr) || appErr.Code != http.StatusNotFound {
+		t.Fatalf("deleting as another user: got %v, want not found", err)
+	}
+
+	var stored models.Notification
+	if err := e.DB.Where("id = ?", n.ID).First(&stored).Error; err != nil {
+		t.Fatalf("notification gone after another user's delete: %v", err)
+	}
+	if stored.IsRead {
+		t.Fatal("another user marked the notification read")
+	}
+
+	// The owner can still do all of it
+	for _, req := range []testutil.Request{read, markRead} {
+		req.Token = ownerToken
+		if res := e.Do(t, req); res.Status != http.StatusOK {
+			t.Fatalf("%s %s as the owner: status %d: %s", req.Method, req.Path, res.Status, res.Body)
+		}
+	}
+	if err := models.DeleteNotification(context.Background(), e.Redis, e.DB, owner.ID, n.ID); err != nil {
+		t.Fatalf("deleting as the owner: %v", err)
+	}
+}
+
+func TestGetNotificationByIDCacheMiss(t *testing.T) {
+	e := testutil.Setup(t)
+	ctx := context.Background()
+	owner := e.CreateUser(t, models.RoleMember)
+	stranger := e.CreateUser(t, models.RoleMember)
+	n := notify(t, e, owner, "mention", "You were mentioned")
+	key := "notification:" + n.ID.String()
+	path := "/user/notification/me/" + n.ID.String()
+
+	// Nothing is cached, so the notification is looked up in the database
+	e.Redis.Del(ctx, key)
+	if res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: path, Token: e.Token(t, stranger)}); res.Status != http.StatusNotFound {
+		t.Fatalf("another user on a cache miss: status %d, want 404: %s", res.Status, res.Body)
+	}
+	if exists := e.Redis.Exists(ctx, key).Val(); exists != 0 {
+		t.Fatal("another user's lookup cached the notification")
+	}
+
+	// The owner's lookup caches it, and the cached copy is not handed to anyone else either
+	if res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: path, Token: e.Token(t, owner)}); res.Status != http.StatusOK {
+		t.Fatalf("owner: status %d: %s", res.Status, res.Body)
+	}
+	if exists := e.Redis.Exists(ctx, key).Val(); exists != 1 {
+		t.Fatal("the owner's lookup did not cache the notification")
+	}
+	if res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: path, Token: e.Token(t, stranger)}); res.Status != http.StatusNotFound {
+		t.Fatalf("another user on a cache hit: status %d, want 404: %s", res.Status, res.Body)
+	}
+}
+
+func TestGetNotificationSummary(t *testing.T) {
+	e := testutil.Setup(t)
+	user := e.CreateUser(t, models.RoleMember)
+	other := e.CreateUser(t, models.RoleMember)
+	token := e.Token(t, user)
+
+	for _, kind := range []string{"like", "like", "like", "mention", "follow"} {
+		notify(t, e, user, kind, "Something happened")
+	}
+	read := notify(t, e, user, "mention", "Already seen")
+	if _, err := models.UpdateNotification(context.Background(), e.Redis, e.DB, user.ID, read.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	notify(t, e, other, "like", "Not yours")
+
+	summary := func(t *testing.T) models.NotificationSummary {
+		t.Helper()
+		res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/me/notifications/summary", Token: token})
+		if res.Status != http.StatusOK {
+			t.Fatalf("status %d: %s", res.Status, res.Body)
+		}
+		var body struct {
+			Summary models.NotificationSummary `json:"summary"`
+		}
+		res.JSON(t, &body)
+		return body.Summary
+	}
+	check := func(t *testing.T, got models.NotificationSummary, total int64, byType map[string]int64) {
+		t.Helper()
+		if got.Total != total || len(got.ByType) != len(byType) {
+			t.Fatalf("summary %+v, want %d in %v", got, total, byType)
+		}
+		for kind, count := range byType {
+			if got.ByType[kind] != count {
+				t.Fatalf("summary %+v, want %d in %v", got, total, byType)
+			}
+		}
+	}
+
+	check(t, summary(t), 5, map[string]int64{"like": 3, "mention": 1, "follow": 1})
+
+	// The cached summary is dropped when the counts change
+	notify(t, e, user, "mention", "Again")
+	check(t, summary(t), 6, map[string]int64{"like": 3, "mention": 2, "follow": 1})
+	if res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/notifications/read-all", Token: token}); res.Status != http.StatusOK {
+		t.Fatalf("marking all read: status %d: %s", res.Status, res.Body)
+	}
+	check(t, summary(t), 0, map[string]int64{})
+}
+
+// notifiedAgo creates a notification for user as if it was sent ago.
+func notifiedAgo(t *testing.T, e *testutil.Env, user *models.User, ago time.Duration) *models.Notification {
+	t.Helper()
+	n := notify(t, e, user, "like", "Someone liked your post")
+	n.CreatedAt = time.Now().Add(-ago)
+	if err := e.DB.Model(&models.Notification{}).Where("id = ?", n.ID).Update("created_at", n.CreatedAt).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// notificationIDs returns the IDs of the notifications, or archived notifications, of user.
+func notificationIDs(t *testing.T, e *testutil.Env, model interface{}, user *models.User) map[uuid.UUID]bool {
+	t.Helper()
+	var ids []uuid.UUID
+	if err := e.DB.Model(model).Where("user_id = ?", user.ID).Pluck("id", &ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	set := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func TestNotificationRetention(t *testing.T) {
+	e := testutil.Setup(t)
+	ctx := context.Background()
+	user := e.CreateUser(t, models.RoleMember)
+	other := e.CreateUser(t, models.RoleMember)
+
+	expired := notifiedAgo(t, e, user, 40*24*time.Hour)
+	oldest := notifiedAgo(t, e, user, 4*time.Hour)
+	kept := []*models.Notification{
+		notifiedAgo(t, e, user, 3*time.Hour),
+		notifiedAgo(t, e, user, 2*time.Hour),
+		notifiedAgo(t, e, user, time.Hour),
+	}
+	othersOld := notifiedAgo(t, e, other, 5*time.Hour)
+	expiredArchive := models.NotificationArchive{ID: uuid.New(), UserID: user.ID, Type: "like", Message: "Long ago", CreatedAt: time.Now().Add(-60 * 24 * time.Hour), ArchivedAt: time.Now()}
+	if err := e.DB.Create(&expiredArchive).Error; err != nil {
+		t.Fatal(err)
+	}
+	e.Redis.Set(ctx, "notifications:summary:"+user.ID.String(), "{}", time.Hour)
+
+	archived, deleted, err := models.ApplyNotificationRetention(ctx, e.Redis, e.DB, models.NotificationRetention{Keep: 3, MaxAge: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived != 1 || deleted != 2 {
+		t.Fatalf("archived %d and deleted %d, want 1 and 2", archived, deleted)
+	}
+
+	notifications := notificationIDs(t, e, &models.Notification{}, user)
+	if len(notifications) != len(kept) {
+		t.Fatalf("user keeps %d notifications, want %d", len(notifications), len(kept))
+	}
+	for _, n := range kept {
+		if !notifications[n.ID] {
+			t.Fatalf("latest notification %s not kept", n.ID)
+		}
+	}
+	archive := notificationIDs(t, e, &models.NotificationArchive{}, user)
+	if len(archive) != 1 || !archive[oldest.ID] {
+		t.Fatalf("archive %v, want only the notification beyond the latest kept", archive)
+	}
+	if archive[expired.ID] || archive[expiredArchive.ID] {
+		t.Fatal("expired notifications were kept in the archive")
+	}
+	if others := notificationIDs(t, e, &models.Notification{}, other); len(others) != 1 || !others[othersOld.ID] {
+		t.Fatalf("another user's notifications changed: %v", others)
+	}
+	if e.Redis.Exists(ctx, "notifications:summary:"+user.ID.String()).Val() != 0 {
+		t.Fatal("the user's cached summary outlived the cleanup")
+	}
+}
+
+func TestArchiveNotifications(t *testing.T) {
+	e := testutil.Setup(t)
+	user := e.CreateUser(t, models.RoleMember)
+	other := e.CreateUser(t, models.RoleMember)
+	token := e.Token(t, user)
+
+	read := notifiedAgo(t, e, user, 3*time.Hour)
+	if _, err := models.UpdateNotification(context.Background(), e.Redis, e.DB, user.ID, read.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	unread := notifiedAgo(t, e, user, 2*time.Hour)
+	recent := notifiedAgo(t, e, user, time.Minute)
+	others := notifiedAgo(t, e, other, time.Hour)
+
+	archive := func(t *testing.T, body interface{}, want int64) {
+		t.Helper()
+		res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/notifications/archive", Token: token, Body: body})
+		if res.Status != http.StatusOK {
+			t.Fatalf("status %d: %s", res.Status, res.Body)
+		}
+		var got struct {
+			Archived int64 `json:"archived"`
+		}
+		res.JSON(t, &got)
+		if got.Archived != want {
+			t.Fatalf("archived %d, want %d", got.Archived, want)
+		}
+	}
+
+	// Without a filter only read notifications are archived
+	archive(t, map[string]interface{}{}, 1)
+	// Unread ones only with "unread", and only the user's own
+	archive(t, map[string]interface{}{"ids": []uuid.UUID{unread.ID, others.ID}}, 0)
+	archive(t, map[string]interface{}{"ids": []uuid.UUID{unread.ID, others.ID}, "unread": true}, 1)
+	archive(t, map[string]interface{}{"before": time.Now().Add(-time.Hour), "unread": true}, 0)
+
+	res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/notifications/archived", Token: token})
+	if res.Status != http.StatusOK {
+		t.Fatalf("listing the archive: status %d: %s", res.Status, res.Body)
+	}
+	var body struct {
+		Notifications []models.NotificationArchive `json:"notifications"`
+	}
+	res.JSON(t, &body)
+	if len(body.Notifications) != 2 || body.Notifications[0].ID != unread.ID || body.Notifications[1].ID != read.ID {
+		t.Fatalf("archive %+v, want the unread then the read notification", body.Notifications)
+	}
+	if left := notificationIDs(t, e, &models.Notification{}, user); len(left) != 1 || !left[recent.ID] {
+		t.Fatalf("notifications left %v, want only the recent one", left)
+	}
+	if left := notificationIDs(t, e, &models.Notification{}, other); !left[others.ID] {
+		t.Fatal("another user's notification was archived")
+	}
+}
@@ -0,0 +1,240 @@
+package v1
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/internal/auth"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/metrics"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oauthStateTTL     = 10 * time.Minute
+	oauthTwoFactorTTL = 5 * time.Minute
+)
+
+type oauthState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	// LinkUserID is set when a signed in user links the account rather than signing in with it
+	LinkUserID string `json:"link_user_id,omitempty"`
+}
+
+// oauthTwoFactor is a social login waiting for the second factor of a user with two-factor enabled.
+type oauthTwoFactor struct {
+	UserID   string `json:"user_id"`
+	Provider string `json:"provider"`
+	Created  bool   `json:"created"`
+}
+
+// beginOAuth stores the state of a new authorization flow with provider, binds it to the browser
+// with a cookie, and returns the consent URL to send the browser to.
+func beginOAuth(c *fiber.Ctx, provider *auth.OAuthProvider, linkUserID string) (string, error) {
+	state := oauth2.GenerateVerifier()
+	verifier := oauth2.GenerateVerifier()
+	stateJSON, _ := json.Marshal(oauthState{Provider: provider.Name, Verifier: verifier, LinkUserID: linkUserID})
+	if err := Redis.Set(c.Context(), "oauth_state:"+state, stateJSON, oauthStateTTL).Err(); err != nil {
+		return "", err
+	}
+
+	// The state cookie binds the callback to the browser that started the flow.
+	c.Cookie(&fiber.Cookie{
+		Name:     "oauth_state",
+		Value:    state,
+		Expires:  time.Now().Add(oauthStateTTL),
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+	return provider.AuthCodeURL(state, verifier), nil
+}
+
+// OAuthLogin redirects to the consent page of a social login provider
+func OAuthLogin(c *fiber.Ctx) error {
+	name := c.Params("provider")
+	provider, ok := OAuthProviders[name]
+	if !ok {
+		Logger.Warn(c.Context()).WithFields("provider", name).Logs("Unsupported OAuth provider")
+		return apierror.NotFound("Unsupported login provider")
+	}
+
+	consentURL, err := beginOAuth(c, provider, "")
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "provider", name).Logs("Failed to store OAuth state")
+		return apierror.Internal("Failed to start login")
+	}
+
+	Logger.Info(c.Context()).WithFields("provider", name).Logs("Redirecting to OAuth provider")
+	return c.Redirect(consentURL, fiber.StatusFound)
+}
+
+// OAuthCallback completes a social login, linking or creating the account and starting a session
+func OAuthCallback(c *fiber.Ctx) error {
+	name := c.Params("provider")
+	provider, ok := OAuthProviders[name]
+	if !ok {
+		Logger.Warn(c.Context()).WithFields("provider", name).Logs("Unsupported OAuth provider")
+		return apierror.NotFound("Unsupported login provider")
+	}
+
+	if reason := c.Query("error"); reason != "" {
+		Logger.Warn(c.Context()).WithFields("provider", name, "reason", reason).Logs("OAuth login denied by provider")
+		return apierror.BadRequest("Login was cancelled or denied")
+	}
+
+	state := c.Query("state")
+	cookieState := c.Cookies("oauth_state")
+	c.ClearCookie("oauth_state")
+	if state == "" || state != cookieState {
+		Logger.Warn(c.Context()).WithFields("provider", name).Logs("OAuth state mismatch")
+		return apierror.BadRequest("Invalid or expired login state")
+	}
+
+	raw, err := Redis.GetDel(c.Context(), "oauth_state:"+state).Result()
+	var stored oauthState
+	if err != nil || json.Unmarshal([]byte(raw), &stored) != nil || stored.Provider != name {
+		Logger.Warn(c.Context()).WithFields("provider", name).Logs("OAuth state not found or expired")
+		return apierror.BadRequest("Invalid or expired login state")
+	}
+
+	profile, err := provider.Exchange(c.Context(), c.Query("code"), stored.Verifier)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "provider", name).Logs("OAuth code exchange failed")
+		return apierror.New(fiber.StatusBadGateway, "Failed to sign in with "+name)
+	}
+	if stored.LinkUserID != "" {
+		return completeIdentityLink(c, stored.LinkUserID, profile)
+	}
+
+	// Social logins carry no invitation code, so they only create accounts while registration is open
+	user, created, err := models.ResolveOAuthUser(c.Context(), Redis, DB, profile, RegistrationMode == models.RegistrationOpen)
+	if err != nil {
+		var appErr *utils.CustomError
+		if utils.As(err, &appErr) && appErr.Code < fiber.StatusInternalServerError {
+			Logger.Warn(c.Context()).WithFields("error", err, "provider", name).Logs("OAuth login rejected")
+			return apierror.New(appErr.Code, appErr.Message)
+		}
+		Logger.Error(c.Context()).WithFields("error", err, "provider", name).Logs("Failed to resolve OAuth user")
+		return apierror.Internal("Failed to process login")
+	}
+
+	if !user.IsActive {
+		Logger.Warn(c.Context()).WithFields("user_id", user.ID, "provider", name).Logs("OAuth login attempt on inactive account")
+		return apierror.Forbidden("Account not activated. Check your email.")
+	}
+
+	if user.IsRestricted() {
+		Logger.Warn(c.Context()).WithFields("user_id", user.ID, "provider", name, "status", user.Status).Logs("OAuth login attempt on restricted account")
+		return restrictedAccount(c, user)
+	}
+
+	// The provider vouches for the first factor only; a user with two-factor enabled still owes the
+	// second, which the callback cannot carry, so the login waits behind a ticket for it.
+	if user.TwoFactorEnabled {
+		ticket := oauth2.GenerateVerifier()
+		pendingJSON, _ := json.Marshal(oauthTwoFactor{UserID: user.ID.String(), Provider: name, Created: created})
+		if err := Redis.Set(c.Context(), "oauth_2fa:"+ticket, pendingJSON, oauthTwoFactorTTL).Err(); err != nil {
+			Logger.Error(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to store pending two-factor login")
+			return apierror.Internal("Failed to process login")
+		}
+		Logger.Info(c.Context()).WithFields("user_id", user.ID, "provider", name).Logs("OAuth login awaiting two-factor code")
+		return apierror.Unauthorized("Two-factor code required").WithDetails(fiber.Map{
+			"two_factor_required": true,
+			"two_factor_ticket":   ticket,
+		})
+	}
+
+	return oauthSignedIn(c, user, name, created)
+}
+
+// OAuthTwoFactor completes a social login held back by OAuthCallback for the user's second factor,
+// given the ticket it answered with and a TOTP or backup code
+func OAuthTwoFactor(c *fiber.Ctx) error {
+	type TwoFactorRequest struct {
+		Ticket string `json:"ticket" validate:"required"`
+		Code   string `json:"code" validate:"required,max=20"`
+	}
+	var req TwoFactorRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	key := "oauth_2fa:" + req.Ticket
+	raw, err := Redis.Get(c.Context(), key).Result()
+	var pending oauthTwoFactor
+	if err != nil || json.Unmarshal([]byte(raw), &pending) != nil {
+		Logger.Warn(c.Context()).Logs("Pending two-factor login not found or expired")
+		return apierror.Unauthorized("Invalid or expired login ticket")
+	}
+
+	user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{pending.UserID})
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", pending.UserID).Logs("Pending two-factor login for a missing user")
+		return apierror.Unauthorized("Invalid or expired login ticket")
+	}
+	if user.IsRestricted() {
+		Logger.Warn(c.Context()).WithFields("user_id", user.ID, "provider", pending.Provider, "status", user.Status).Logs("OAuth login attempt on restricted account")
+		return restrictedAccount(c, user)
+	}
+
+	if rateLimited(c, TwoFactorRateLimit, user.ID.String()) {
+		return apierror.RateLimited("Too many two-factor attempts, try again later")
+	}
+	valid, err := verifySecondFactor(c, user, req.Code)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to check two-factor code")
+		return apierror.Internal("Failed to process login")
+	}
+	if !valid {
+		Logger.Warn(c.Context()).WithFields("user_id", user.ID, "provider", pending.Provider).Logs("Invalid two-factor code provided")
+		metrics.LoginFailed("invalid_two_factor")
+		recordLogin(c, user.ID, pending.Provider, true, "invalid_two_factor")
+		if err := models.RecordSecurityEvent(c.Context(), DB, user.ID, models.EventLoginFailed, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to record failed login")
+		}
+		return apierror.Unauthorized("Invalid two-factor code").WithDetails(fiber.Map{"two_factor_required": true})
+	}
+
+	// Each ticket signs in once, even when two requests carry valid codes at the same time.
+	if n, err := Redis.Del(c.Context(), key).Result(); err != nil || n == 0 {
+		Logger.Warn(c.Context()).WithFields("user_id", user.ID).Logs("Pending two-factor login already used")
+		return apierror.Unauthorized("Invalid or expired login ticket")
+	}
+	return oauthSignedIn(c, user, pending.Provider, pending.Created)
+}
+
+// oauthSignedIn starts the session of a user who signed in via provider and answers with it.
+func oauthSignedIn(c *fiber.Ctx, user *models.User, provider string, created bool) error {
+	tokens, err := startSession(c, user, provider)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to start session")
+		return apierror.Internal("Failed to process login")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", user.ID, "provider", provider, "created", created).Logs("User logged in with OAuth")
+	res := fiber.Map{
+		"message": "Login successful",
+		"status":  fiber.StatusOK,
+		"created": created,
+		"user": fiber.Map{
+			"id":       user.ID,
+			"username": user.Username,
+			"email":    user.Email,
+			"name":     user.Profile.Name,
+			"avatar":   user.Profile.AvatarURL,
+		},
+	}
+	if tokens != nil {
+		res["tokens"] = tokens
+	}
+	return c.Status(fiber.StatusOK).JSON(res)
+}
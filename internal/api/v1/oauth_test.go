@@ -0,0 +1,144 @@
+package v1_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	v1 "github.com/mnuddindev/devpulse/internal/api/v1"
+	"github.com/mnuddindev/devpulse/internal/auth"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// googleStub answers Google's token and userinfo endpoints with the account of email, passing
+// every other request on.
+type googleStub struct {
+	next  http.RoundTripper
+	email string
+}
+
+func (g googleStub) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body interface{}
+	switch req.URL.Host {
+	case "oauth2.googleapis.com":
+		body = map[string]interface{}{"access_token": "google-access-token", "token_type": "Bearer", "expires_in": 3600}
+	case "openidconnect.googleapis.com":
+		body = map[string]interface{}{"sub": "google-" + g.email, "email": g.email, "email_verified": true, "name": "Google User"}
+	default:
+		return g.next.RoundTrip(req)
+	}
+	raw, _ := json.Marshal(body)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(string(raw))),
+		Request:    req,
+	}, nil
+}
+
+// withGoogle configures Google sign in against a stub signing in as email.
+func withGoogle(t *testing.T, email string) {
+	t.Helper()
+	previousProviders, previousTransport := v1.OAuthProviders, http.DefaultTransport
+	v1.OAuthProviders = auth.NewOAuthProviders(map[string]auth.OAuthCredentials{
+		"google": {ClientID: "client-id", ClientSecret: "client-secret"},
+	}, "http://localhost")
+	http.DefaultTransport = googleStub{next: previousTransport, email: email}
+	t.Cleanup(func() { v1.OAuthProviders, http.DefaultTransport = previousProviders, previousTransport })
+}
+
+// signInWithGoogle runs the Google sign in flow from the consent redirect to the callback.
+func signInWithGoogle(t *testing.T, e *testutil.Env) *testutil.Response {
+	t.Helper()
+	res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/auth/oauth/google"})
+	if res.Status != http.StatusFound {
+		t.Fatalf("starting the login: status %d: %s", res.Status, res.Body)
+	}
+	consent, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := consent.Query().Get("state")
+	return e.Do(t, testutil.Request{
+		Method: http.MethodGet,
+		Path:   "/auth/oauth/google/callback?code=provider-code&state=" + url.QueryEscape(state),
+		Header: map[string]string{"Cookie": "oauth_state=" + state},
+	})
+}
+
+func TestOAuthLoginTwoFactor(t *testing.T) {
+	e := testutil.Setup(t)
+	const backupCode = "abcde-fghij"
+	hashed, err := utils.HashPassword(backupCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, _ := json.Marshal([]string{hashed})
+	user := e.CreateUser(t, models.RoleMember, models.WithEmailVerified(true), func(u *models.User) {
+		u.TwoFactorEnabled = true
+		u.TwoFactorBackupCodes = string(codes)
+	})
+	withGoogle(t, user.Email)
+
+	res := signInWithGoogle(t, e)
+	if res.Status != http.StatusUnauthorized {
+		t.Fatalf("callback: status %d, want 401: %s", res.Status, res.Body)
+	}
+	if cookies := res.Header.Values("Set-Cookie"); strings.Contains(strings.Join(cookies, ";"), "access_token=") {
+		t.Fatalf("callback set session cookies: %v", cookies)
+	}
+	var challenge struct {
+		Details struct {
+			Required bool   `json:"two_factor_required"`
+			Ticket   string `json:"two_factor_ticket"`
+		} `json:"details"`
+		Tokens interface{} `json:"tokens"`
+	}
+	res.JSON(t, &challenge)
+	if !challenge.Details.Required || challenge.Details.Ticket == "" || challenge.Tokens != nil {
+		t.Fatalf("callback answered %s, want a two-factor challenge without tokens", res.Body)
+	}
+
+	complete := func(code string) *testutil.Response {
+		return e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/auth/oauth/2fa", Body: map[string]string{
+			"ticket": challenge.Details.Ticket,
+			"code":   code,
+		}})
+	}
+	if res := complete("wrong-code1"); res.Status != http.StatusUnauthorized {
+		t.Fatalf("wrong code: status %d, want 401", res.Status)
+	}
+	res = complete(backupCode)
+	if res.Status != http.StatusOK {
+		t.Fatalf("backup code: status %d: %s", res.Status, res.Body)
+	}
+	var session tokensBody
+	res.JSON(t, &session)
+	if session.Tokens.AccessToken == "" || session.Tokens.RefreshToken == "" {
+		t.Fatalf("no tokens in %s", res.Body)
+	}
+	if res := complete(backupCode); res.Status != http.StatusUnauthorized {
+		t.Fatalf("reused ticket: status %d, want 401", res.Status)
+	}
+}
+
+func TestOAuthLoginWithoutTwoFactor(t *testing.T) {
+	e := testutil.Setup(t)
+	user := e.CreateUser(t, models.RoleMember, models.WithEmailVerified(true))
+	withGoogle(t, user.Email)
+
+	res := signInWithGoogle(t, e)
+	if res.Status != http.StatusOK {
+		t.Fatalf("callback: status %d: %s", res.Status, res.Body)
+	}
+	var session tokensBody
+	res.JSON(t, &session)
+	if session.Tokens.AccessToken == "" {
+		t.Fatalf("no tokens in %s", res.Body)
+	}
+}
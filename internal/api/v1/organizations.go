@@ -0,0 +1,525 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// organizationResponse is the public representation of an organization
+func organizationResponse(org *models.Organization) fiber.Map {
+	return fiber.Map{
+		"id":            org.ID,
+		"name":          org.Name,
+		"slug":          org.Slug,
+		"summary":       org.Summary,
+		"avatar_url":    org.AvatarURL,
+		"website_url":   org.WebsiteURL,
+		"location":      org.Location,
+		"brand_color":   org.BrandColor,
+		"members_count": org.MembersCount,
+		"created_at":    org.CreatedAt,
+	}
+}
+
+// membershipResponse is the representation of a membership shown to the organization and the member
+func membershipResponse(m *models.OrganizationMember) fiber.Map {
+	return fiber.Map{
+		"organization_id": m.OrganizationID,
+		"user_id":         m.UserID,
+		"role":            m.Role,
+		"status":          m.Status,
+		"invited_by_id":   m.InvitedByID,
+		"created_at":      m.CreatedAt,
+		"joined_at":       m.JoinedAt,
+	}
+}
+
+// organizationBySlug loads the organization named by the :slug param. On failure the organization
+// is nil and the error answers the request.
+func organizationBySlug(c *fiber.Ctx) (*models.Organization, error) {
+	slug := strings.ToLower(strings.TrimSpace(c.Params("slug")))
+	org, err := models.GetOrganization(c.Context(), Redis, DB, slug)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "slug", slug).Logs("Failed to fetch organization")
+		return nil, apierror.From(err, "Failed to fetch organization")
+	}
+	return org, nil
+}
+
+// managedOrganization loads the organization named by the :slug param for the authenticated user,
+// whose active membership must grant at least role. On failure the organization is nil and the
+// error answers the request.
+func managedOrganization(c *fiber.Ctx, role string) (*models.Organization, uuid.UUID, error) {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("Organization management attempted without user_id in context")
+		return nil, uuid.Nil, apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in organization management")
+		return nil, uuid.Nil, apierror.BadRequest("Invalid user ID")
+	}
+
+	org, err := organizationBySlug(c)
+	if org == nil {
+		return nil, userID, err
+	}
+
+	member, err := organizationMember(c, org.ID, userID)
+	if err != nil {
+		return nil, userID, err
+	}
+	if !member.Can(role) {
+		Logger.Warn(c.Context()).WithFields("user_id", userID, "org_id", org.ID, "required_role", role).Logs("User is not allowed to manage organization")
+		return nil, userID, apierror.Forbidden("Your organization role does not allow this")
+	}
+	return org, userID, nil
+}
+
+// organizationMember loads a user's membership of an organization, nil when they have none
+func organizationMember(c *fiber.Ctx, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	member, err := models.GetOrganizationMember(c.Context(), Redis, DB, orgID, userID)
+	if err != nil {
+		var appErr *utils.CustomError
+		if utils.As(err, &appErr) && appErr.Code == fiber.StatusNotFound {
+			return nil, nil
+		}
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID, "org_id", orgID).Logs("Failed to fetch membership")
+		return nil, apierror.From(err, "Failed to fetch membership")
+	}
+	return member, nil
+}
+
+// bylineOrganization resolves the organization a post is published under. The author must be an
+// active member of it.
+func bylineOrganization(c *fiber.Ctx, authorID uuid.UUID, slug string) (*uuid.UUID, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	org, err := models.GetOrganization(c.Context(), Redis, DB, slug)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "slug", slug).Logs("Unknown post organization")
+		return nil, apierror.From(err, "Failed to fetch organization")
+	}
+	member, err := organizationMember(c, org.ID, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.Can(models.OrgRoleMember) {
+		Logger.Warn(c.Context()).WithFields("user_id", authorID, "org_id", org.ID).Logs("Author is not a member of the post organization")
+		return nil, apierror.Forbidden("Only members can publish under an organization")
+	}
+	return &org.ID, nil
+}
+
+// CreateOrganization creates an organization with the authenticated user as its admin
+func CreateOrganization(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("CreateOrganization attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in CreateOrganization")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	type CreateOrganizationRequest struct {
+		Name       string `json:"name" validate:"required,min=2,max=100"`
+		Slug       string `json:"slug" validate:"omitempty,max=60,slug"`
+		Summary    string `json:"summary" validate:"omitempty,max=250"`
+		AvatarURL  string `json:"avatar_url" validate:"omitempty,url,max=500"`
+		WebsiteURL string `json:"website_url" validate:"omitempty,url,max=500"`
+		Location   string `json:"location" validate:"omitempty,max=100"`
+		BrandColor string `json:"brand_color" validate:"omitempty,hexcolor,max=7"`
+	}
+
+	var req CreateOrganizationRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	if req.Slug == "" {
+		req.Slug = utils.Slugify(req.Name, 60)
+	}
+
+	org := &models.Organization{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Summary:     strings.TrimSpace(req.Summary),
+		AvatarURL:   req.AvatarURL,
+		WebsiteURL:  req.WebsiteURL,
+		Location:    strings.TrimSpace(req.Location),
+		BrandColor:  req.BrandColor,
+		CreatedByID: userID,
+	}
+	if err := models.CreateOrganization(c.Context(), Redis, DB, org); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to create organization")
+		return apierror.From(err, "Failed to create organization")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "org_id", org.ID).Logs("Organization created successfully")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":      "Organization created successfully",
+		"status":       fiber.StatusCreated,
+		"organization": organizationResponse(org),
+	})
+}
+
+// GetOrganization returns the profile of an organization
+func GetOrganization(c *fiber.Ctx) error {
+	org, err := organizationBySlug(c)
+	if org == nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":      "Organization retrieved successfully",
+		"status":       fiber.StatusOK,
+		"organization": organizationResponse(org),
+	})
+}
+
+// GetOrganizationPosts lists the published posts under an organization's byline, newest first
+func GetOrganizationPosts(c *fiber.Ctx) error {
+	org, err := organizationBySlug(c)
+	if org == nil {
+		return err
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	posts, total, err := models.GetOrganizationPosts(c.Context(), DB, org.ID, viewerID(c), page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "org_id", org.ID).Logs("Failed to fetch organization posts")
+		return postError(c, err, "Failed to fetch posts")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":      "Posts retrieved successfully",
+		"status":       fiber.StatusOK,
+		"organization": organizationResponse(org),
+		"posts":        postsResponse(posts),
+		"page":         page,
+		"limit":        limit,
+		"total":        total,
+	})
+}
+
+// GetOrganizationMembers returns a page of the active members of an organization, ordered by
+// username. Pages are chained with the returned next_cursor; ?total=true adds the member count.
+func GetOrganizationMembers(c *fiber.Ctx) error {
+	org, err := organizationBySlug(c)
+	if org == nil {
+		return err
+	}
+	p, err := Paginator.Parse(c, 20, 100)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("org_id", org.ID).Logs("Invalid cursor in GetOrganizationMembers")
+		return apierror.From(err, "Invalid cursor")
+	}
+
+	page, err := models.GetOrganizationMembersPage(c.Context(), Redis, DB, org.ID, p)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "org_id", org.ID).Logs("Failed to fetch organization members")
+		return apierror.From(err, "Failed to fetch members")
+	}
+	next, err := Paginator.Seal(page.Next)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to seal members cursor")
+		return apierror.Internal("Failed to fetch members")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Members retrieved successfully",
+		"status":      fiber.StatusOK,
+		"members":     page.Items,
+		"limit":       p.Limit,
+		"next_cursor": next,
+		"total":       page.Total,
+	})
+}
+
+// UpdateOrganization edits the profile of an organization; editors and admins may do so
+func UpdateOrganization(c *fiber.Ctx) error {
+	org, userID, err := managedOrganization(c, models.OrgRoleEditor)
+	if org == nil {
+		return err
+	}
+
+	type UpdateOrganizationRequest struct {
+		Name       *string `json:"name" validate:"omitempty,min=2,max=100"`
+		Summary    *string `json:"summary" validate:"omitempty,max=250"`
+		AvatarURL  *string `json:"avatar_url" validate:"omitempty,url,max=500"`
+		WebsiteURL *string `json:"website_url" validate:"omitempty,url,max=500"`
+		Location   *string `json:"location" validate:"omitempty,max=100"`
+		BrandColor *string `json:"brand_color" validate:"omitempty,hexcolor,max=7"`
+	}
+
+	var req UpdateOrganizationRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	var opts []models.OrganizationOption
+	if req.Name != nil {
+		opts = append(opts, models.WithOrgName(*req.Name))
+	}
+	if req.Summary != nil {
+		opts = append(opts, models.WithOrgSummary(*req.Summary))
+	}
+	if req.AvatarURL != nil {
+		opts = append(opts, models.WithOrgAvatarURL(*req.AvatarURL))
+	}
+	if req.WebsiteURL != nil {
+		opts = append(opts, models.WithOrgWebsiteURL(*req.WebsiteURL))
+	}
+	if req.Location != nil {
+		opts = append(opts, models.WithOrgLocation(*req.Location))
+	}
+	if req.BrandColor != nil {
+		opts = append(opts, models.WithOrgBrandColor(*req.BrandColor))
+	}
+
+	updated, err := models.UpdateOrganization(c.Context(), Redis, DB, org.ID, opts...)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "org_id", org.ID).Logs("Failed to update organization")
+		return apierror.From(err, "Failed to update organization")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "org_id", org.ID).Logs("Organization updated successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":      "Organization updated successfully",
+		"status":       fiber.StatusOK,
+		"organization": organizationResponse(updated),
+	})
+}
+
+// InviteOrganizationMember invites a user to an organization; only admins may invite
+func InviteOrganizationMember(c *fiber.Ctx) error {
+	org, userID, err := managedOrganization(c, models.OrgRoleAdmin)
+	if org == nil {
+		return err
+	}
+
+	type InviteMemberRequest struct {
+		Username string `json:"username" validate:"required,min=3,max=255"`
+		Role     string `json:"role" validate:"required,oneof=admin editor member"`
+	}
+
+	var req InviteMemberRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	invitee, err := models.GetUserBy(c.Context(), Redis, DB, "username = ?", []interface{}{strings.TrimSpace(req.Username)}, models.PreloadMinimal)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "username", req.Username).Logs("Invitee not found")
+		return apierror.NotFound("User not found")
+	}
+
+	member, err := models.InviteOrganizationMember(c.Context(), Redis, DB, org, userID, invitee.ID, req.Role)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "org_id", org.ID, "invitee_id", invitee.ID).Logs("Failed to invite member")
+		return apierror.From(err, "Failed to invite member")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "org_id", org.ID, "invitee_id", invitee.ID, "role", req.Role).Logs("Organization member invited")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":    "Invitation sent successfully",
+		"status":     fiber.StatusCreated,
+		"membership": membershipResponse(member),
+	})
+}
+
+// UpdateOrganizationMember changes the role of a member; only admins may do so
+func UpdateOrganizationMember(c *fiber.Ctx) error {
+	org, userID, err := managedOrganization(c, models.OrgRoleAdmin)
+	if org == nil {
+		return err
+	}
+
+	type UpdateMemberRequest struct {
+		Role string `json:"role" validate:"required,oneof=admin editor member"`
+	}
+
+	var req UpdateMemberRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	target, err := models.GetUserBy(c.Context(), Redis, DB, "username = ?", []interface{}{c.Params("username")}, models.PreloadMinimal)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "username", c.Params("username")).Logs("Member not found")
+		return apierror.NotFound("User not found")
+	}
+
+	member, err := models.SetOrganizationMemberRole(c.Context(), Redis, DB, org.ID, target.ID, req.Role)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "org_id", org.ID, "member_id", target.ID).Logs("Failed to update member role")
+		return apierror.From(err, "Failed to update member")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "org_id", org.ID, "member_id", target.ID, "role", req.Role).Logs("Organization member role updated")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":    "Member updated successfully",
+		"status":     fiber.StatusOK,
+		"membership": membershipResponse(member),
+	})
+}
+
+// RemoveOrganizationMember removes a member or withdraws an invitation. Admins may remove anyone;
+// every member may remove themselves to leave.
+func RemoveOrganizationMember(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("RemoveOrganizationMember attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	target, err := models.GetUserBy(c.Context(), Redis, DB, "username = ?", []interface{}{c.Params("username")}, models.PreloadMinimal)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "username", c.Params("username")).Logs("Member not found")
+		return apierror.NotFound("User not found")
+	}
+
+	var org *models.Organization
+	if target.ID.String() == userIDRaw {
+		org, err = organizationBySlug(c)
+	} else {
+		org, _, err = managedOrganization(c, models.OrgRoleAdmin)
+	}
+	if org == nil {
+		return err
+	}
+
+	if err := models.RemoveOrganizationMember(c.Context(), Redis, DB, org.ID, target.ID); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "org_id", org.ID, "member_id", target.ID).Logs("Failed to remove member")
+		return apierror.From(err, "Failed to remove member")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userIDRaw, "org_id", org.ID, "member_id", target.ID).Logs("Organization member removed")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Member removed successfully",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// AcceptOrganizationInvite makes the authenticated user a member of an organization they were
+// invited to
+func AcceptOrganizationInvite(c *fiber.Ctx) error {
+	return respondToOrganizationInvite(c, true)
+}
+
+// DeclineOrganizationInvite drops the authenticated user's invitation to an organization
+func DeclineOrganizationInvite(c *fiber.Ctx) error {
+	return respondToOrganizationInvite(c, false)
+}
+
+func respondToOrganizationInvite(c *fiber.Ctx, accept bool) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("Organization invitation answered without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in organization invitation")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	org, err := organizationBySlug(c)
+	if org == nil {
+		return err
+	}
+
+	if !accept {
+		member, err := models.GetOrganizationMember(c.Context(), Redis, DB, org.ID, userID)
+		if err == nil && member.Status != models.OrgMemberInvited {
+			err = utils.NewError(utils.ErrNotFound.Code, "Invitation not found")
+		}
+		if err == nil {
+			err = models.RemoveOrganizationMember(c.Context(), Redis, DB, org.ID, userID)
+		}
+		if err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "org_id", org.ID).Logs("Failed to decline invitation")
+			return apierror.From(err, "Failed to decline invitation")
+		}
+
+		Logger.Info(c.Context()).WithFields("user_id", userID, "org_id", org.ID).Logs("Organization invitation declined")
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": "Invitation declined",
+			"status":  fiber.StatusOK,
+		})
+	}
+
+	member, err := models.AcceptOrganizationInvite(c.Context(), Redis, DB, org.ID, userID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "org_id", org.ID).Logs("Failed to accept invitation")
+		return apierror.From(err, "Failed to accept invitation")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "org_id", org.ID).Logs("Organization invitation accepted")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":    "You joined " + org.Name,
+		"status":     fiber.StatusOK,
+		"membership": membershipResponse(member),
+	})
+}
+
+// GetMyOrganizations lists the organizations the authenticated user belongs to
+func GetMyOrganizations(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetMyOrganizations attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetMyOrganizations")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	orgs, err := models.GetUserOrganizations(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user organizations")
+		return apierror.From(err, "Failed to fetch organizations")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":       "Organizations retrieved successfully",
+		"status":        fiber.StatusOK,
+		"organizations": orgs,
+	})
+}
@@ -0,0 +1,143 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/geoip"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// activationTTL is how long a new account can be activated with the code mailed to it.
+const activationTTL = 24 * time.Hour
+
+// OutboxReady wakes the outbox worker after a handler committed events, so they are handled
+// right away rather than on the worker's next tick.
+var OutboxReady = make(chan struct{}, 1)
+
+// wakeOutbox signals OutboxReady without blocking; one pending signal is enough.
+func wakeOutbox() {
+	select {
+	case OutboxReady <- struct{}{}:
+	default:
+	}
+}
+
+// HandleOutboxEvent carries out an outbox event; the outbox worker calls it for every due event.
+// Every step is idempotent, as the outbox requires.
+func HandleOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	switch event.Kind {
+	case models.OutboxUserRegistered:
+		var e models.UserRegisteredEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Kind, err)
+		}
+		return startActivation(ctx, &e)
+	case models.OutboxPostPublished, models.OutboxPostUpdated, models.OutboxPostDeleted:
+		var e models.PostChangedEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Kind, err)
+		}
+		return models.IndexPosts(ctx, DB, []uuid.UUID{e.PostID})
+	case models.OutboxPostFanout:
+		var e models.PostFanoutEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Kind, err)
+		}
+		return models.FanoutPost(ctx, Redis, DB, e.PostID, e.After)
+	case models.OutboxUserUpdated:
+		var e models.UserChangedEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Kind, err)
+		}
+		if err := models.IndexUsers(ctx, DB, []uuid.UUID{e.UserID}); err != nil {
+			return err
+		}
+		return models.IndexMentionUsers(ctx, Redis, DB, []uuid.UUID{e.UserID})
+	case models.OutboxNotificationPush:
+		var e models.PushNotificationEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Kind, err)
+		}
+		return pushNotification(ctx, &e)
+	case models.OutboxLoginAnomaly:
+		var e models.LoginAnomalyEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Kind, err)
+		}
+		return alertLogin(ctx, &e)
+	case models.OutboxStreakMilestone:
+		var e models.StreakMilestoneEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Kind, err)
+		}
+		image := fmt.Sprintf("%s/badges/streak-%d.svg", EmailCfg.AppURL, e.Days)
+		return models.AwardStreakBadge(ctx, Redis, DB, e.UserID, e.Days, image)
+	case models.OutboxUploadScan:
+		var e models.UploadScanEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Kind, err)
+		}
+		if Uploads == nil {
+			return fmt.Errorf("uploads are not configured")
+		}
+		return models.ScanQuarantinedUpload(ctx, DB, Uploads, utils.Scanner, &e)
+	}
+	return fmt.Errorf("unknown outbox event %q", event.Kind)
+}
+
+// startActivation stores the activation code of a new account and mails it.
+func startActivation(ctx context.Context, e *models.UserRegisteredEvent) error {
+	user, err := models.GetUserBy(ctx, Redis, DB, "id = ?", []interface{}{e.UserID})
+	if err != nil {
+		return err
+	}
+	userJSON, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	otp, err := utils.HashPassword(e.OTP)
+	if err != nil {
+		return err
+	}
+
+	pipe := Redis.TxPipeline()
+	pipe.Set(ctx, "otp:"+e.Token, otp, activationTTL)
+	pipe.Set(ctx, cache.PendingUserKey(e.Token), userJSON, activationTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store activation code: %w", err)
+	}
+
+	return utils.SendActivationEmail(ctx, EmailCfg, e.Email, user.Settings.Locale, e.Username, e.Token, e.OTP, Logger)
+}
+
+// alertLogin emails a user about the sign-in activity of a login event.
+func alertLogin(ctx context.Context, e *models.LoginAnomalyEvent) error {
+	event, err := models.GetLoginEvent(ctx, DB, e.LoginEventID)
+	if err != nil {
+		return err
+	}
+	user, err := models.GetUserBy(ctx, Redis, DB, "id = ?", []interface{}{event.UserID}, models.PreloadMinimal)
+	if err != nil {
+		return err
+	}
+	device := event.UserAgent
+	if device == "" {
+		device = "Unknown"
+	}
+	location := geoip.Location{Country: event.Country, Region: event.Region, City: event.City}
+	return utils.SendLoginAlertEmail(ctx, EmailCfg, user.Email, user.Settings.Locale, utils.LoginAlert{
+		Username: user.Username,
+		Failed:   !event.Success,
+		Time:     event.CreatedAt.UTC().Format("2006-01-02 15:04 MST"),
+		IP:       event.IP,
+		Location: location.String(),
+		Device:   device,
+		Link:     EmailCfg.AppURL + "/settings/security",
+	}, Logger)
+}
@@ -0,0 +1,205 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// AutosavePost keeps the in-flight content of the post editor without touching the post. Fields
+// left out keep their autosaved value, or the saved one when nothing was autosaved yet. Saving the
+// post drops the autosave.
+func AutosavePost(c *fiber.Ctx) error {
+	post, userID, err := editablePost(c)
+	if post == nil {
+		return err
+	}
+
+	type AutosavePostRequest struct {
+		Title   *string `json:"title" validate:"omitempty,max=200"`
+		Content *string `json:"content"`
+		Excerpt *string `json:"excerpt" validate:"omitempty,max=300"`
+	}
+
+	var req AutosavePostRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	autosave, err := models.GetAutosave(c.Context(), Redis, post.ID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to fetch autosave")
+		return postError(c, err, "Failed to autosave post")
+	}
+	if autosave == nil {
+		autosave = &models.PostAutosave{PostID: post.ID, Title: post.Title, Content: post.Content, Excerpt: post.Excerpt}
+	}
+	autosave.EditorID = userID
+	if req.Title != nil {
+		autosave.Title = *req.Title
+	}
+	if req.Content != nil {
+		autosave.Content = *req.Content
+	}
+	if req.Excerpt != nil {
+		autosave.Excerpt = *req.Excerpt
+	}
+
+	if err := models.SaveAutosave(c.Context(), Redis, autosave); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to autosave post")
+		return postError(c, err, "Failed to autosave post")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Post autosaved successfully",
+		"status":   fiber.StatusOK,
+		"autosave": autosave,
+	})
+}
+
+// DiscardAutosave drops the in-flight content of a post
+func DiscardAutosave(c *fiber.Ctx) error {
+	post, userID, err := editablePost(c)
+	if post == nil {
+		return err
+	}
+
+	if err := models.DiscardAutosave(c.Context(), Redis, post.ID); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to discard autosave")
+		return postError(c, err, "Failed to discard autosave")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", post.ID).Logs("Autosave discarded")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Autosave discarded successfully",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// ListPostRevisions lists the saved revisions of a post, newest first, along with the in-flight
+// autosave if there is one
+func ListPostRevisions(c *fiber.Ctx) error {
+	post, _, err := editablePost(c)
+	if post == nil {
+		return err
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	revisions, total, err := models.ListPostRevisions(c.Context(), DB, post.ID, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to fetch post revisions")
+		return postError(c, err, "Failed to fetch revisions")
+	}
+	autosave, err := models.GetAutosave(c.Context(), Redis, post.ID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to fetch autosave")
+		return postError(c, err, "Failed to fetch revisions")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":   "Revisions retrieved successfully",
+		"status":    fiber.StatusOK,
+		"revisions": revisions,
+		"autosave":  autosave,
+		"page":      page,
+		"limit":     limit,
+		"total":     total,
+	})
+}
+
+// GetPostRevision returns a revision of a post with its content
+func GetPostRevision(c *fiber.Ctx) error {
+	post, _, err := editablePost(c)
+	if post == nil {
+		return err
+	}
+	revisionID, err := uuid.Parse(c.Params("revision_id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid revision ID")
+	}
+
+	revision, err := models.GetPostRevision(c.Context(), DB, post.ID, revisionID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "post_id", post.ID, "revision_id", revisionID).Logs("Failed to fetch post revision")
+		return postError(c, err, "Failed to fetch revision")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Revision retrieved successfully",
+		"status":   fiber.StatusOK,
+		"revision": revision,
+	})
+}
+
+// RestorePostRevision puts the content of an earlier revision back into a post
+func RestorePostRevision(c *fiber.Ctx) error {
+	post, userID, err := editablePost(c)
+	if post == nil {
+		return err
+	}
+	revisionID, err := uuid.Parse(c.Params("revision_id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid revision ID")
+	}
+
+	restored, err := models.RestorePostRevision(c.Context(), Redis, DB, post.ID, revisionID, userID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "post_id", post.ID, "revision_id", revisionID).Logs("Failed to restore post revision")
+		return postError(c, err, "Failed to restore revision")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", post.ID, "revision_id", revisionID).Logs("Post revision restored")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Revision restored successfully",
+		"status":  fiber.StatusOK,
+		"post":    renderedPostResponse(c, restored),
+	})
+}
+
+// GetPostHistory lists the saved versions of a post, newest first, each with a unified diff of
+// its content from the version before it. Only the author and moderators may read it
+func GetPostHistory(c *fiber.Ctx) error {
+	post, _, err := managedPost(c, "moderate_post")
+	if post == nil {
+		return err
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	history, total, err := models.GetPostHistory(c.Context(), DB, post.ID, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to fetch post history")
+		return postError(c, err, "Failed to fetch history")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "History retrieved successfully",
+		"status":  fiber.StatusOK,
+		"history": history,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
@@ -0,0 +1,763 @@
+package v1
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/contentfilter"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// postResponse is the public representation of a post
+func postResponse(post *models.Posts) fiber.Map {
+	res := fiber.Map{
+		"id":                post.ID,
+		"title":             post.Title,
+		"slug":              post.Slug,
+		"content":           post.Content,
+		"content_format":    post.ContentFormat,
+		"excerpt":           post.Excerpt,
+		"cover_image_url":   post.FeaturedImageURL,
+		"canonical_url":     post.CanonicalURL,
+		"status":            post.Status,
+		"published":         post.Published,
+		"published_at":      post.PublishedAt,
+		"scheduled_at":      post.ScheduledAt,
+		"schedule_timezone": post.ScheduleTimezone,
+		"visibility":        post.Visibility,
+		"author_id":         post.AuthorID,
+		"organization_id":   post.OrganizationID,
+		"series_id":         post.SeriesID,
+		"tags":              tagSummaries(post.Tags),
+		"edited":            post.Edited(),
+		"edited_at":         post.EditedAt,
+		"created_at":        post.CreatedAt,
+		"updated_at":        post.UpdatedAt,
+	}
+	if post.Author.ID != uuid.Nil {
+		res["author"] = fiber.Map{
+			"id":         post.Author.ID,
+			"username":   post.Author.Username,
+			"name":       post.Author.Profile.Name,
+			"avatar_url": post.Author.Profile.AvatarURL,
+		}
+	}
+	if post.GuestAuthorName != "" {
+		res["guest_author"] = fiber.Map{"name": post.GuestAuthorName, "url": post.GuestAuthorURL}
+	}
+	if len(post.CoAuthors) > 0 {
+		coAuthors := []fiber.Map{}
+		for _, a := range post.CoAuthors {
+			if !a.Accepted() {
+				continue
+			}
+			coAuthors = append(coAuthors, fiber.Map{
+				"id":         a.User.ID,
+				"username":   a.User.Username,
+				"name":       a.User.Profile.Name,
+				"avatar_url": a.User.Profile.AvatarURL,
+			})
+		}
+		res["co_authors"] = coAuthors
+	}
+	if post.Organization != nil {
+		res["organization"] = fiber.Map{
+			"id":         post.Organization.ID,
+			"name":       post.Organization.Name,
+			"slug":       post.Organization.Slug,
+			"avatar_url": post.Organization.AvatarURL,
+		}
+	}
+	return res
+}
+
+// renderedPostResponse is postResponse with the post's content rendered to HTML and, for a
+// published part of a series, the series navigation.
+func renderedPostResponse(c *fiber.Ctx, post *models.Posts) fiber.Map {
+	res := postResponse(post)
+	res["content_html"] = renderContent(c, cache.PostHTMLKey(post.ID), post.Content, post.ContentFormat)
+	if nav := seriesNavigation(c, post); nav != nil {
+		res["series"] = nav
+	}
+	return res
+}
+
+func postsResponse(posts []models.Posts) []fiber.Map {
+	res := make([]fiber.Map, len(posts))
+	for i := range posts {
+		res[i] = postResponse(&posts[i])
+	}
+	return res
+}
+
+// postError answers a failed post operation, passing client errors through
+func postError(c *fiber.Ctx, err error, message string) error {
+	return apierror.From(err, message)
+}
+
+// viewerID is the authenticated user reading a public route, or nil for an anonymous visitor
+func viewerID(c *fiber.Ctx) *uuid.UUID {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		return nil
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
+// canViewPost asks the post policy whether the requester may read a published post, honouring
+// the ?preview token of a preview link
+func canViewPost(c *fiber.Ctx, post *models.Posts) error {
+	return PostPolicy.CanView(c.Context(), DB, post, viewerID(c), c.Query("preview"))
+}
+
+// guestAuthorRequest credits a writer without an account on a post
+type guestAuthorRequest struct {
+	Name string `json:"name" validate:"max=100"`
+	URL  string `json:"url" validate:"omitempty,url,max=500"`
+}
+
+// managedPost loads the post named by the :id param for the authenticated user, who must be its
+// author or hold anyPerm. On failure the post is nil and the error answers the request.
+func managedPost(c *fiber.Ctx, anyPerm string) (*models.Posts, uuid.UUID, error) {
+	return loadManagedPost(c, anyPerm, false)
+}
+
+// editablePost is managedPost for editing the post: its co-authors may edit it as well.
+func editablePost(c *fiber.Ctx) (*models.Posts, uuid.UUID, error) {
+	return loadManagedPost(c, "edit_any_post", true)
+}
+
+func loadManagedPost(c *fiber.Ctx, anyPerm string, coAuthors bool) (*models.Posts, uuid.UUID, error) {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("Post management attempted without user_id in context")
+		return nil, uuid.Nil, apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in post management")
+		return nil, uuid.Nil, apierror.BadRequest("Invalid user ID")
+	}
+
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("post_id", c.Params("id")).Logs("Invalid post ID")
+		return nil, userID, apierror.BadRequest("Invalid post ID")
+	}
+
+	post, err := models.GetPostsBy(c.Context(), Redis, DB, "id = ?", []interface{}{postID}, "Tags")
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "post_id", postID).Logs("Failed to fetch post")
+		return nil, userID, postError(c, err, "Failed to fetch post")
+	}
+	if post.AuthorID == userID {
+		return post, userID, nil
+	}
+	if coAuthors {
+		coAuthor, err := models.IsCoAuthor(c.Context(), DB, post.ID, userID)
+		if err != nil {
+			Logger.Error(c.Context()).WithFields("error", err, "user_id", userID, "post_id", postID).Logs("Failed to check co-author in post management")
+			return nil, userID, postError(c, err, "Failed to fetch post")
+		}
+		if coAuthor {
+			return post, userID, nil
+		}
+	}
+
+	allowed, err := hasPermission(c, userID, anyPerm)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch permissions in post management")
+		return nil, userID, postError(c, err, "Failed to fetch user")
+	}
+	if allowed {
+		return post, userID, nil
+	}
+
+	Logger.Warn(c.Context()).WithFields("user_id", userID, "post_id", postID).Logs("User is not allowed to manage post")
+	return nil, userID, apierror.Forbidden("You can only manage your own posts")
+}
+
+// PostOwner resolves the author of the post named by the :id path parameter for owner-or-permission
+// access rules
+func PostOwner(c *fiber.Ctx) (uuid.UUID, error) {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, apierror.BadRequest("Invalid post ID")
+	}
+	post, err := models.GetPostsBy(c.Context(), Redis, DB, "id = ?", []interface{}{postID})
+	if err != nil {
+		return uuid.Nil, postError(c, err, "Failed to fetch post")
+	}
+	return post.AuthorID, nil
+}
+
+// PostEditor resolves who edits the post named by the :id path parameter for owner-or-permission
+// access rules: the authenticated user when they co-author the post, its author otherwise
+func PostEditor(c *fiber.Ctx) (uuid.UUID, error) {
+	author, err := PostOwner(c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	userIDRaw, _ := c.Locals("user_id").(string)
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil || userID == author {
+		return author, nil
+	}
+	postID, _ := uuid.Parse(c.Params("id"))
+	coAuthor, err := models.IsCoAuthor(c.Context(), DB, postID, userID)
+	if err != nil {
+		return uuid.Nil, postError(c, err, "Failed to fetch post")
+	}
+	if coAuthor {
+		return userID, nil
+	}
+	return author, nil
+}
+
+// CreatePost creates a draft, published or scheduled post for the authenticated user
+func CreatePost(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("CreatePost attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in CreatePost")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	type CreatePostRequest struct {
+		Title         string   `json:"title" validate:"required,min=10,max=200"`
+		Slug          string   `json:"slug" validate:"omitempty,max=220,slug"`
+		Content       string   `json:"content" validate:"required,min=100"`
+		Excerpt       string   `json:"excerpt" validate:"omitempty,max=300"`
+		CoverImageURL string   `json:"cover_image_url" validate:"omitempty,url,max=500"`
+		CanonicalURL  string   `json:"canonical_url" validate:"omitempty,url,max=500"`
+		Tags          []string `json:"tags" validate:"omitempty,max=4,dive,min=2,max=30"`
+		Organization  string   `json:"organization" validate:"omitempty,max=60"`
+		Visibility    string   `json:"visibility" validate:"omitempty,oneof=public followers members unlisted"`
+		Publish       bool     `json:"publish"`
+		PublishAt     string   `json:"publish_at"`
+		Timezone      string   `json:"timezone" validate:"omitempty,timezone,max=64"`
+
+		// GuestAuthor credits a writer without an account the post is published for
+		GuestAuthor *guestAuthorRequest `json:"guest_author"`
+	}
+
+	var req CreatePostRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	if err := filterContent(c, contentField{Name: "title", Field: contentfilter.FieldPostTitle, Text: req.Title}); err != nil {
+		return err
+	}
+	publishAt, err := parsePublishAt(req.PublishAt, req.Timezone)
+	if err != nil {
+		return err
+	}
+
+	if req.Slug == "" {
+		req.Slug, err = models.GeneratePostSlug(c.Context(), DB, req.Title)
+		if err != nil {
+			Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to generate post slug")
+			return postError(c, err, "Failed to create post")
+		}
+	}
+
+	tags, err := models.ResolveTags(c.Context(), DB, req.Tags)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid post tags")
+		return postError(c, err, "Failed to create post")
+	}
+
+	post := &models.Posts{
+		AuthorID:         userID,
+		Title:            req.Title,
+		Slug:             req.Slug,
+		Content:          req.Content,
+		ContentFormat:    "markdown",
+		Excerpt:          req.Excerpt,
+		FeaturedImageURL: req.CoverImageURL,
+		CanonicalURL:     req.CanonicalURL,
+		Status:           models.PostStatusDraft,
+		Visibility:       req.Visibility,
+		Tags:             tags,
+	}
+	if req.GuestAuthor != nil && strings.TrimSpace(req.GuestAuthor.Name) != "" {
+		post.GuestAuthorName, post.GuestAuthorURL = strings.TrimSpace(req.GuestAuthor.Name), req.GuestAuthor.URL
+	}
+	if req.Organization != "" {
+		post.OrganizationID, err = bylineOrganization(c, userID, req.Organization)
+		if err != nil {
+			return err
+		}
+	}
+	switch {
+	case publishAt != nil && publishAt.After(time.Now()):
+		post.Status = models.PostStatusScheduled
+		post.ScheduledAt = publishAt
+		post.ScheduleTimezone = req.Timezone
+	case req.Publish || publishAt != nil:
+		post.Status = models.PostStatusPublished
+	}
+
+	if err := models.CreatePost(c.Context(), Redis, DB, post); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to create post")
+		return postError(c, err, "Failed to create post")
+	}
+
+	if post.Status == models.PostStatusPublished {
+		recordActivity(c, userID, models.ActivityPost)
+	}
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", post.ID, "status", post.Status).Logs("Post created successfully")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Post created successfully",
+		"status":  fiber.StatusCreated,
+		"post":    renderedPostResponse(c, post),
+	})
+}
+
+// publishedPost loads a published post by its slug through the cache, if the requester may read it.
+// The cached post is shared by every reader, so the post policy is asked on every request.
+func publishedPost(c *fiber.Ctx, slug string) (*models.Posts, error) {
+	post, err := cache.Fetch(c.Context(), Redis, cache.PublicPostKey(slug), Redis.TTL("post"), func(ctx context.Context) (*models.Posts, []string, error) {
+		post, err := models.GetPublishedPost(ctx, DB, slug)
+		if err != nil {
+			return nil, nil, err
+		}
+		tags := []string{cache.UserTag(post.AuthorID)}
+		if post.OrganizationID != nil {
+			tags = append(tags, cache.OrganizationTag(*post.OrganizationID))
+		}
+		return post, tags, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := canViewPost(c, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// GetPost returns a published post by its slug
+func GetPost(c *fiber.Ctx) error {
+	slug := strings.ToLower(strings.TrimSpace(c.Params("slug")))
+	if slug == "" {
+		return apierror.BadRequest("Slug is required")
+	}
+
+	post, err := publishedPost(c, slug)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "slug", slug).Logs("Failed to fetch post")
+		return postError(c, err, "Failed to fetch post")
+	}
+	if post.Visibility != models.VisibilityPublic {
+		// Posts not open to everyone stay out of shared caches and search engines
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+		c.Set("X-Robots-Tag", "noindex")
+	}
+	// Authors reading their own posts do not keep a reading streak going
+	if viewer := viewerID(c); viewer != nil && *viewer != post.AuthorID {
+		recordActivity(c, *viewer, models.ActivityRead)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Post retrieved successfully",
+		"status":  fiber.StatusOK,
+		"post":    renderedPostResponse(c, post),
+	})
+}
+
+// ListPosts lists published posts, optionally by one author
+func ListPosts(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	var authorID *uuid.UUID
+	if username := strings.TrimSpace(c.Query("author")); username != "" {
+		author, err := models.GetUserBy(c.Context(), Redis, DB, "username = ?", []interface{}{username})
+		if err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "author", username).Logs("Author not found in ListPosts")
+			return postError(c, err, "Failed to fetch posts")
+		}
+		authorID = &author.ID
+	}
+
+	posts, total, err := models.GetPublishedPosts(c.Context(), DB, authorID, viewerID(c), page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch posts")
+		return postError(c, err, "Failed to fetch posts")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Posts retrieved successfully",
+		"status":  fiber.StatusOK,
+		"posts":   postsResponse(posts),
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
+
+// GetMyPosts lists the authenticated user's posts including drafts and scheduled posts
+func GetMyPosts(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetMyPosts attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetMyPosts")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+	if status != "" && status != models.PostStatusDraft && status != models.PostStatusScheduled && status != models.PostStatusPublished {
+		return apierror.BadRequest("Status must be draft, scheduled or published")
+	}
+
+	posts, total, err := models.GetAuthorPosts(c.Context(), DB, userID, status, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user posts")
+		return postError(c, err, "Failed to fetch posts")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Posts retrieved successfully",
+		"status":  fiber.StatusOK,
+		"posts":   postsResponse(posts),
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
+
+// GetMyScheduledPosts lists the authenticated user's scheduled posts in the order they will be published
+func GetMyScheduledPosts(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetMyScheduledPosts attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetMyScheduledPosts")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	posts, total, err := models.GetScheduledPosts(c.Context(), DB, userID, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch scheduled posts")
+		return postError(c, err, "Failed to fetch scheduled posts")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Scheduled posts retrieved successfully",
+		"status":  fiber.StatusOK,
+		"posts":   postsResponse(posts),
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
+
+// parsePublishAt reads the publish_at of a request. An RFC 3339 time carries its own offset; a
+// wall clock time such as 2025-06-01T09:00 is read in timezone, or UTC without one, so authors
+// can schedule for a local hour across daylight saving changes.
+func parsePublishAt(publishAt, timezone string) (*time.Time, error) {
+	if publishAt == "" {
+		return nil, nil
+	}
+	if at, err := time.Parse(time.RFC3339, publishAt); err == nil {
+		return &at, nil
+	}
+
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, apierror.BadRequest("Unknown timezone")
+		}
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if at, err := time.ParseInLocation(layout, publishAt, loc); err == nil {
+			return &at, nil
+		}
+	}
+	return nil, apierror.BadRequest("publish_at must be an RFC 3339 time or a local time such as 2006-01-02T15:04")
+}
+
+// UpdatePost edits the content of a post
+func UpdatePost(c *fiber.Ctx) error {
+	post, userID, err := editablePost(c)
+	if post == nil {
+		return err
+	}
+
+	type UpdatePostRequest struct {
+		Title         *string   `json:"title" validate:"omitempty,min=10,max=200"`
+		Slug          *string   `json:"slug" validate:"omitempty,max=220,slug"`
+		Content       *string   `json:"content" validate:"omitempty,min=100"`
+		Excerpt       *string   `json:"excerpt" validate:"omitempty,max=300"`
+		CoverImageURL *string   `json:"cover_image_url" validate:"omitempty,url,max=500"`
+		CanonicalURL  *string   `json:"canonical_url" validate:"omitempty,url,max=500"`
+		Tags          *[]string `json:"tags" validate:"omitempty,max=4,dive,min=2,max=30"`
+		// Organization moves the post under an organization's byline; empty takes it off
+		Organization *string `json:"organization" validate:"omitempty,max=60"`
+		Visibility   *string `json:"visibility" validate:"omitempty,oneof=public followers members unlisted"`
+
+		// GuestAuthor credits a guest writer; an empty name takes the credit off
+		GuestAuthor *guestAuthorRequest `json:"guest_author"`
+	}
+
+	var req UpdatePostRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	if req.Title != nil {
+		if err := filterContent(c, contentField{Name: "title", Field: contentfilter.FieldPostTitle, Text: *req.Title}); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	opts := []models.PostsOption{models.WithEditedAt(&now), models.WithLastEditedByID(&userID)}
+	if req.Title != nil {
+		opts = append(opts, models.WithTitle(strings.TrimSpace(*req.Title)))
+	}
+	if req.Slug != nil {
+		opts = append(opts, models.WithSlug(*req.Slug))
+	}
+	if req.Content != nil {
+		opts = append(opts, models.WithContent(strings.TrimSpace(*req.Content)))
+	}
+	if req.Excerpt != nil {
+		opts = append(opts, models.WithExcerpt(*req.Excerpt))
+	}
+	if req.CoverImageURL != nil {
+		opts = append(opts, models.WithFeaturedImageURL(*req.CoverImageURL))
+	}
+	if req.CanonicalURL != nil {
+		opts = append(opts, models.WithCanonicalURL(*req.CanonicalURL))
+	}
+	if req.GuestAuthor != nil {
+		opts = append(opts, models.WithGuestAuthor(strings.TrimSpace(req.GuestAuthor.Name), req.GuestAuthor.URL))
+	}
+	if req.Tags != nil {
+		tags, err := models.ResolveTags(c.Context(), DB, *req.Tags)
+		if err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Invalid post tags")
+			return postError(c, err, "Failed to update post")
+		}
+		opts = append(opts, models.WithTags(tags))
+	}
+	if req.Organization != nil {
+		var orgID *uuid.UUID
+		if *req.Organization != "" {
+			// The byline belongs to the author, so it is their membership that counts
+			orgID, err = bylineOrganization(c, post.AuthorID, *req.Organization)
+			if err != nil {
+				return err
+			}
+		}
+		opts = append(opts, models.WithOrganizationID(orgID))
+	}
+	if req.Visibility != nil {
+		opts = append(opts, models.WithVisibility(*req.Visibility))
+	}
+
+	updated, err := models.UpdatePost(c.Context(), Redis, DB, post, opts...)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to update post")
+		return postError(c, err, "Failed to update post")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", post.ID).Logs("Post updated successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Post updated successfully",
+		"status":  fiber.StatusOK,
+		"post":    renderedPostResponse(c, updated),
+	})
+}
+
+// PublishPost publishes a post now or schedules it for a later time
+func PublishPost(c *fiber.Ctx) error {
+	post, userID, err := managedPost(c, "edit_any_post")
+	if post == nil {
+		return err
+	}
+
+	type PublishPostRequest struct {
+		PublishAt string `json:"publish_at"`
+		Timezone  string `json:"timezone" validate:"omitempty,timezone,max=64"`
+	}
+
+	var req PublishPostRequest
+	if len(c.Body()) > 0 {
+		if err := utils.StrictBodyParser(c, &req); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+			return apierror.BadRequest("Invalid request body")
+		}
+		if err := Validator.Validate(req); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+			return apierror.Validation(err)
+		}
+	}
+	publishAt, err := parsePublishAt(req.PublishAt, req.Timezone)
+	if err != nil {
+		return err
+	}
+
+	updated, err := models.PublishPost(c.Context(), Redis, DB, post.ID, publishAt, req.Timezone)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to publish post")
+		return postError(c, err, "Failed to publish post")
+	}
+
+	message := "Post published successfully"
+	if updated.Status == models.PostStatusScheduled {
+		message = "Post scheduled successfully"
+	} else {
+		recordActivity(c, userID, models.ActivityPost)
+	}
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", post.ID, "status", updated.Status).Logs(message)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": message,
+		"status":  fiber.StatusOK,
+		"post":    renderedPostResponse(c, updated),
+	})
+}
+
+// UnpublishPost turns a published or scheduled post back into a draft
+func UnpublishPost(c *fiber.Ctx) error {
+	post, userID, err := managedPost(c, "edit_any_post")
+	if post == nil {
+		return err
+	}
+
+	updated, err := models.UnpublishPost(c.Context(), Redis, DB, post.ID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to unpublish post")
+		return postError(c, err, "Failed to unpublish post")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", post.ID).Logs("Post unpublished successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Post moved back to drafts",
+		"status":  fiber.StatusOK,
+		"post":    renderedPostResponse(c, updated),
+	})
+}
+
+// CreatePreviewLink signs a link that opens a published post to anyone holding it, whatever the
+// post's visibility. It is how unlisted posts are shared.
+func CreatePreviewLink(c *fiber.Ctx) error {
+	post, userID, err := managedPost(c, "edit_any_post")
+	if post == nil {
+		return err
+	}
+
+	type CreatePreviewLinkRequest struct {
+		// ExpiresInHours defaults to a week
+		ExpiresInHours int `json:"expires_in_hours" validate:"omitempty,min=1,max=720"`
+	}
+	var req CreatePreviewLinkRequest
+	if len(c.Body()) > 0 {
+		if err := utils.StrictBodyParser(c, &req); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+			return apierror.BadRequest("Invalid request body")
+		}
+		if err := Validator.Validate(req); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+			return apierror.Validation(err)
+		}
+	}
+	if req.ExpiresInHours == 0 {
+		req.ExpiresInHours = 7 * 24
+	}
+	if !post.Published {
+		return apierror.Conflict("Only published posts can be previewed")
+	}
+
+	token, expires, err := PostPolicy.PreviewToken(post.ID, time.Duration(req.ExpiresInHours)*time.Hour)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to sign preview link")
+		return postError(c, err, "Failed to create preview link")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", post.ID, "expires_at", expires).Logs("Preview link created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":    "Preview link created successfully",
+		"status":     fiber.StatusCreated,
+		"url":        postURL(post.Slug) + "?preview=" + token,
+		"token":      token,
+		"expires_at": expires,
+	})
+}
+
+// DeletePost deletes a post
+func DeletePost(c *fiber.Ctx) error {
+	post, userID, err := managedPost(c, "delete_any_post")
+	if post == nil {
+		return err
+	}
+
+	if err := models.DeletePost(c.Context(), Redis, DB, post.ID); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "post_id", post.ID).Logs("Failed to delete post")
+		return postError(c, err, "Failed to delete post")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "post_id", post.ID).Logs("Post deleted successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Post deleted successfully",
+		"status":  fiber.StatusOK,
+	})
+}
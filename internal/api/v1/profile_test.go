@@ -0,0 +1,345 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	v1 "github.com/mnuddindev/devpulse/internal/api/v1"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"gorm.io/gorm"
+)
+
+type profileBody struct {
+	Message string                 `json:"message"`
+	User    map[string]interface{} `json:"user"`
+}
+
+// asMember fills in the token of a new member, who req then acts as.
+func asMember(user **models.User) func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+	return func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+		*user = e.CreateUser(t, models.RoleMember)
+		req.Token = e.Token(t, *user)
+	}
+}
+
+func TestGetProfile(t *testing.T) {
+	var user *models.User
+	testutil.Run(t, []testutil.Case{
+		{
+			Name:    "own profile",
+			Setup:   asMember(&user),
+			Request: testutil.Request{Method: http.MethodGet, Path: "/users/me"},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body profileBody
+				res.JSON(t, &body)
+				if body.User["username"] != user.Username || body.User["id"] != user.ID.String() {
+					t.Fatalf("profile of %v, want %s", body.User["username"], user.Username)
+				}
+				if _, ok := body.User["settings"]; !ok {
+					t.Fatal("default sections missing settings")
+				}
+			},
+		},
+		{
+			Name:    "chosen sections",
+			Setup:   asMember(&user),
+			Request: testutil.Request{Method: http.MethodGet, Path: "/users/me?fields=profile"},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body profileBody
+				res.JSON(t, &body)
+				if _, ok := body.User["bio"]; !ok {
+					t.Fatal("profile section missing")
+				}
+				if _, ok := body.User["settings"]; ok {
+					t.Fatal("settings returned though not asked for")
+				}
+			},
+		},
+		{
+			Name:    "unknown section",
+			Setup:   asMember(&user),
+			Request: testutil.Request{Method: http.MethodGet, Path: "/users/me?fields=secrets"},
+			Status:  http.StatusBadRequest,
+		},
+		{
+			Name:    "unauthenticated",
+			Request: testutil.Request{Method: http.MethodGet, Path: "/users/me"},
+			Status:  http.StatusUnauthorized,
+		},
+		{
+			Name:    "invalid token",
+			Request: testutil.Request{Method: http.MethodGet, Path: "/users/me", Token: "not-a-token"},
+			Status:  http.StatusUnauthorized,
+		},
+	})
+}
+
+func TestUpdateProfile(t *testing.T) {
+	const path = "/user/update/profile/me"
+	var user *models.User
+	testutil.Run(t, []testutil.Case{
+		{
+			Name:    "profile fields",
+			Setup:   asMember(&user),
+			Request: testutil.Request{Method: http.MethodPut, Path: path, Body: map[string]interface{}{"profile": map[string]string{"bio": "Writes Go", "location": "Dhaka"}}},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				// Read back through the cache the update wrote to
+				res = e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/users/me?fields=profile", Token: e.Token(t, user)})
+				var body profileBody
+				res.JSON(t, &body)
+				if body.User["bio"] != "Writes Go" || body.User["location"] != "Dhaka" {
+					t.Fatalf("profile after update: %v", body.User)
+				}
+			},
+		},
+		{
+			Name:    "no changes",
+			Setup:   asMember(&user),
+			Request: testutil.Request{Method: http.MethodPut, Path: path, Body: map[string]interface{}{}},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body profileBody
+				res.JSON(t, &body)
+				if body.Message != "No changes provided" {
+					t.Fatalf("message %q, want no changes", body.Message)
+				}
+			},
+		},
+		{
+			Name:    "bio too long",
+			Setup:   asMember(&user),
+			Request: testutil.Request{Method: http.MethodPut, Path: path, Body: map[string]interface{}{"profile": map[string]string{"bio": strings.Repeat("a", 256)}}},
+			Status:  http.StatusUnprocessableEntity,
+		},
+		{
+			Name:    "unknown field",
+			Setup:   asMember(&user),
+			Request: testutil.Request{Method: http.MethodPut, Path: path, Body: map[string]interface{}{"role_id": "admin"}},
+			Status:  http.StatusBadRequest,
+		},
+		{
+			Name: "taken username",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				asMember(&user)(t, e, req)
+				req.Body = map[string]string{"username": e.CreateUser(t, models.RoleMember).Username}
+			},
+			Request: testutil.Request{Method: http.MethodPut, Path: path},
+			Status:  http.StatusConflict,
+		},
+		{
+			Name: "username changed recently",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				asMember(&user)(t, e, req)
+				first := testutil.Request{Method: http.MethodPut, Path: path, Token: req.Token, Body: map[string]string{"username": "renamedonce"}}
+				if res := e.Do(t, first); res.Status != http.StatusOK {
+					t.Fatalf("first rename: status %d: %s", res.Status, res.Body)
+				}
+				req.Body = map[string]string{"username": "renamedtwice"}
+			},
+			Request: testutil.Request{Method: http.MethodPut, Path: path},
+			Status:  http.StatusTooManyRequests,
+		},
+		{
+			Name:    "unauthenticated",
+			Request: testutil.Request{Method: http.MethodPut, Path: path, Body: map[string]interface{}{"profile": map[string]string{"bio": "Writes Go"}}},
+			Status:  http.StatusUnauthorized,
+		},
+	})
+}
+
+// TestProfileUpdateDuringRead races a profile update against a read that loaded the user before
+// the update committed and fills the cache after it, in both cache write modes. The read must not
+// leave the old profile cached.
+func TestProfileUpdateDuringRead(t *testing.T) {
+	for _, mode := range []string{storage.WriteThrough, storage.Invalidate} {
+		t.Run(mode, func(t *testing.T) {
+			e := testutil.Setup(t)
+			e.Redis.SetWriteMode(mode)
+			t.Cleanup(func() { e.Redis.SetWriteMode(storage.WriteThrough) })
+			ctx := context.Background()
+			user := e.CreateUser(t, models.RoleMember, models.WithBio("Before"))
+			key := cache.UserKey(user.ID)
+			e.Redis.Del(ctx, key)
+
+			loaded, updated := make(chan struct{}), make(chan struct{})
+			read := make(chan error, 1)
+			go func() {
+				_, err := cache.Fetch(ctx, e.Redis, key, e.Redis.TTL("user"), func(ctx context.Context) (*models.User, []string, error) {
+					stale, err := models.GetUserBy(ctx, e.Redis, e.DB, "id = ?", []interface{}{user.ID})
+					close(loaded)
+					<-updated
+					return stale, nil, err
+				})
+				read <- err
+			}()
+
+			<-loaded
+			res := e.Do(t, testutil.Request{Method: http.MethodPut, Path: "/user/update/profile/me", Token: e.Token(t, user), Body: map[string]interface{}{"profile": map[string]string{"bio": "After"}}})
+			close(updated)
+			if err := <-read; err != nil {
+				t.Fatalf("reading during the update: %v", err)
+			}
+			if res.Status != http.StatusOK {
+				t.Fatalf("updating: status %d: %s", res.Status, res.Body)
+			}
+
+			raw, err := e.Redis.Get(ctx, key).Bytes()
+			switch {
+			case err == nil:
+				var cached models.User
+				if err := json.Unmarshal(raw, &cached); err != nil {
+					t.Fatal(err)
+				}
+				if cached.Profile.Bio != "After" {
+					t.Fatalf("cached bio %q after the update", cached.Profile.Bio)
+				}
+			case mode == storage.WriteThrough:
+				t.Fatalf("write-through left nothing cached: %v", err)
+			}
+
+			res = e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/users/me?fields=profile", Token: e.Token(t, user)})
+			var body profileBody
+			res.JSON(t, &body)
+			if body.User["bio"] != "After" {
+				t.Fatalf("profile after the update: %v", body.User)
+			}
+		})
+	}
+}
+
+func TestProfileFieldInterval(t *testing.T) {
+	e := testutil.Setup(t)
+	ctx := context.Background()
+	user := e.CreateUser(t, models.RoleMember)
+	token := e.Token(t, user)
+	update := func(body interface{}) *testutil.Response {
+		t.Helper()
+		return e.Do(t, testutil.Request{Method: http.MethodPut, Path: "/user/update/profile/me", Token: token, Body: body})
+	}
+
+	if res := update(map[string]string{"username": "renamedonce"}); res.Status != http.StatusOK {
+		t.Fatalf("first change: status %d: %s", res.Status, res.Body)
+	}
+
+	res := update(map[string]string{"username": "renamedtwice"})
+	if res.Status != http.StatusTooManyRequests {
+		t.Fatalf("change within the interval: status %d, want 429: %s", res.Status, res.Body)
+	}
+	var body struct {
+		Details struct {
+			Field       string    `json:"field"`
+			NextAllowed time.Time `json:"next_allowed"`
+		} `json:"details"`
+	}
+	res.JSON(t, &body)
+	want := time.Now().Add(v1.ProfileFieldIntervals["username"])
+	if body.Details.Field != "username" || body.Details.NextAllowed.Sub(want).Abs() > time.Minute {
+		t.Fatalf("refused %q until %v, want username until about %v", body.Details.Field, body.Details.NextAllowed, want)
+	}
+
+	if res := update(map[string]interface{}{"profile": map[string]string{"bio": "Unlimited"}}); res.Status != http.StatusOK {
+		t.Fatalf("changing a field without an interval: status %d: %s", res.Status, res.Body)
+	}
+
+	// Age the last change past the interval.
+	past := time.Now().Add(-v1.ProfileFieldIntervals["username"] - time.Minute).Unix()
+	if err := e.Redis.Set(ctx, "profile_field:"+user.ID.String()+":username", past, time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if res := update(map[string]string{"username": "renamedtwice"}); res.Status != http.StatusOK {
+		t.Fatalf("change past the interval: status %d: %s", res.Status, res.Body)
+	}
+	var stored models.User
+	if err := e.DB.Select("username").Where("id = ?", user.ID).First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Username != "renamedtwice" {
+		t.Fatalf("username %q, want renamedtwice", stored.Username)
+	}
+}
+
+func TestGetUsersByID(t *testing.T) {
+	e := testutil.Setup(t)
+	ctx := context.Background()
+	cached := e.CreateUser(t, models.RoleMember)
+	uncached := e.CreateUser(t, models.RoleMember)
+	missing := uuid.New()
+	if _, err := models.GetUsersByID(ctx, e.Redis, e.DB, cached.ID); err != nil {
+		t.Fatal(err)
+	}
+	e.Redis.Del(ctx, cache.UserKey(uncached.ID))
+
+	users, err := models.GetUsersByID(ctx, e.Redis, e.DB, cached.ID, uncached.ID, missing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[cached.ID] == nil || users[uncached.ID] == nil {
+		t.Fatalf("got %v, want both existing users and not the missing one", users)
+	}
+	if users[uncached.ID].Role.Name != models.RoleMember || users[uncached.ID].NotificationPreferences.UserID != uncached.ID {
+		t.Fatalf("loaded user lacks its relations: %+v", users[uncached.ID])
+	}
+	if n := e.Redis.Exists(ctx, cache.UserKey(uncached.ID), cache.UserKey(missing)).Val(); n != 1 {
+		t.Fatalf("%d of the loaded and missing users cached, want only the loaded one", n)
+	}
+
+	// With every user cached the database is not queried at all.
+	var queries int
+	if err := e.DB.Callback().Query().Before("gorm:query").Register("test:count_queries", func(*gorm.DB) { queries++ }); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.DB.Callback().Query().Remove("test:count_queries") })
+	if users, err := models.GetUsersByID(ctx, e.Redis, e.DB, cached.ID, uncached.ID); err != nil || len(users) != 2 {
+		t.Fatalf("got %d users, %v", len(users), err)
+	}
+	if queries != 0 {
+		t.Fatalf("%d queries with every user cached, want none", queries)
+	}
+}
+
+func TestFetchMany(t *testing.T) {
+	e := testutil.Setup(t)
+	ctx := context.Background()
+	e.Redis.Set(ctx, "test:hit", `"cached"`, time.Hour)
+
+	var loads [][]string
+	found, err := cache.FetchMany(ctx, e.Redis, []string{"test:hit", "test:miss", "test:raced", "test:absent"}, time.Hour, func(ctx context.Context, missing []string) (map[string]string, error) {
+		loads = append(loads, missing)
+		// An invalidation landing while the value loads keeps the stale value out of the cache.
+		e.Redis.Invalidate(ctx, "test:raced")
+		return map[string]string{"test:miss": "loaded", "test:raced": "stale"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loads) != 1 || !equalNames(loads[0], "test:miss", "test:raced", "test:absent") {
+		t.Fatalf("loads %v, want one load of the three misses", loads)
+	}
+	if len(found) != 3 || found["test:hit"] != "cached" || found["test:miss"] != "loaded" || found["test:raced"] != "stale" {
+		t.Fatalf("got %v", found)
+	}
+	cases := []struct {
+		key    string
+		cached bool
+	}{
+		{key: "test:miss", cached: true},
+		{key: "test:raced", cached: false},
+		{key: "test:absent", cached: false},
+	}
+	for _, tc := range cases {
+		if cached := e.Redis.Exists(ctx, tc.key).Val() == 1; cached != tc.cached {
+			t.Errorf("%s cached: %v, want %v", tc.key, cached, tc.cached)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"github.com/mnuddindev/devpulse/pkg/webpush"
+)
+
+// pushTTL is how long push services hold a notification for a device that is offline.
+const pushTTL = 24 * time.Hour
+
+// ListPushSubscriptions returns the authenticated user's push subscriptions and the public key
+// browsers subscribe with
+func ListPushSubscriptions(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("ListPushSubscriptions attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in ListPushSubscriptions")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	subs, err := models.GetUserPushSubscriptions(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch push subscriptions")
+		return apierror.From(err, "Failed to fetch push subscriptions")
+	}
+	publicKey := ""
+	if Push != nil {
+		publicKey = Push.PublicKey()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":       "Push subscriptions retrieved successfully",
+		"status":        fiber.StatusOK,
+		"subscriptions": subs,
+		"public_key":    publicKey,
+	})
+}
+
+// CreatePushSubscription registers a browser for push notifications. The body is the JSON form
+// of the browser's PushSubscription.
+func CreatePushSubscription(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("CreatePushSubscription attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in CreatePushSubscription")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	if Push == nil {
+		Logger.Error(c.Context()).Logs("Push subscription attempted without VAPID keys configured")
+		return apierror.Unavailable("Push notifications are not configured")
+	}
+
+	type CreatePushSubscriptionRequest struct {
+		Endpoint       string   `json:"endpoint" validate:"required,url,max=500"`
+		ExpirationTime *float64 `json:"expirationTime"`
+		Keys           struct {
+			P256dh string `json:"p256dh" validate:"required,max=100"`
+			Auth   string `json:"auth" validate:"required,max=50"`
+		} `json:"keys"`
+	}
+	var req CreatePushSubscriptionRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	if err := webpush.Validate(webpush.Subscription{Endpoint: req.Endpoint, P256dh: req.Keys.P256dh, Auth: req.Keys.Auth}); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid push subscription")
+		return apierror.BadRequest("Invalid push subscription")
+	}
+
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if runes := []rune(userAgent); len(runes) > 255 {
+		userAgent = string(runes[:255])
+	}
+	sub := &models.PushSubscription{
+		UserID:    userID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: userAgent,
+	}
+	if err := models.SavePushSubscription(c.Context(), DB, sub); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to save push subscription")
+		return apierror.From(err, "Failed to save push subscription")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "subscription_id", sub.ID).Logs("Push subscription saved")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":      "Push subscription saved successfully",
+		"status":       fiber.StatusCreated,
+		"subscription": sub,
+	})
+}
+
+// DeletePushSubscription unsubscribes one of the authenticated user's browsers
+func DeletePushSubscription(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("DeletePushSubscription attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in DeletePushSubscription")
+		return apierror.BadRequest("Invalid user ID")
+	}
+	subID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("subscription_id", c.Params("id")).Logs("Invalid push subscription ID")
+		return apierror.BadRequest("Invalid push subscription ID")
+	}
+
+	if err := models.DeletePushSubscription(c.Context(), DB, userID, subID); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "subscription_id", subID).Logs("Failed to delete push subscription")
+		return apierror.From(err, "Failed to delete push subscription")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "subscription_id", subID).Logs("Push subscription deleted")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Push subscription deleted successfully",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// pushNotification sends a notification to every browser its user subscribed; the user opted
+// into push messages of its type when it was queued. Subscriptions the push service dropped are
+// deleted. The event only fails, and is retried, when no browser could be reached, so one flaky
+// push service does not repeat the message on every other device.
+func pushNotification(ctx context.Context, e *models.PushNotificationEvent) error {
+	if Push == nil {
+		return nil
+	}
+	subs, err := models.GetUserPushSubscriptions(ctx, DB, e.UserID)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(fiber.Map{
+		"id":         e.NotificationID,
+		"type":       e.Type,
+		"title":      "DevPulse",
+		"body":       e.Message,
+		"url":        EmailCfg.AppURL + "/notifications",
+		"created_at": e.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	msg := webpush.Message{Payload: payload, TTL: pushTTL, Urgency: "normal"}
+
+	var lastErr error
+	delivered := 0
+	for i := range subs {
+		sub := &subs[i]
+		err := Push.Send(ctx, webpush.Subscription{Endpoint: sub.Endpoint, P256dh: sub.P256dh, Auth: sub.Auth}, msg)
+		switch {
+		case errors.Is(err, webpush.ErrGone), errors.Is(err, webpush.ErrInvalidSubscription):
+			if err := models.ExpirePushSubscription(ctx, DB, sub.ID); err != nil {
+				Logger.Warn(ctx).WithFields("error", err, "subscription_id", sub.ID).Logs("Failed to delete expired push subscription")
+			}
+		case err != nil:
+			Logger.Warn(ctx).WithFields("error", err, "subscription_id", sub.ID).Logs("Failed to send push message")
+			lastErr = err
+		default:
+			delivered++
+			if err := models.TouchPushSubscription(ctx, DB, sub.ID); err != nil {
+				Logger.Warn(ctx).WithFields("error", err, "subscription_id", sub.ID).Logs("Failed to record push delivery")
+			}
+		}
+	}
+	if delivered == 0 && lastErr != nil {
+		return fmt.Errorf("failed to push notification to user %s: %w", e.UserID, lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+package v1
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/auth"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/middleware/ratelimit"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// Limiter enforces the rate limit policies below; it is set up by the router.
+var Limiter *ratelimit.Limiter
+
+// RateLimitRefresh is how often each instance reads the rate limit overrides again, so a change
+// made through another instance takes effect here.
+var RateLimitRefresh = 30 * time.Second
+
+// Rate limit policies of the API. Routes attach them with Limiter.Handle; handlers whose key is only
+// known after parsing the request check them with rateLimited.
+var (
+	// GlobalRateLimit counts every request per IP except anonymous reads, which count against
+	// GuestReadRateLimit instead.
+	GlobalRateLimit = ratelimit.Policy{
+		Name:   "global",
+		Limit:  10,
+		Window: time.Minute,
+		Key:    exceptGuestReads(ratelimit.ByIP),
+	}
+	// GuestReadRateLimit lets logged-out readers and crawlers browse the public pages at a pace
+	// that would be too much for writes.
+	GuestReadRateLimit = ratelimit.Policy{
+		Name:    "guest_read",
+		Limit:   120,
+		Window:  time.Minute,
+		Key:     guestReads(ratelimit.ByIP),
+		Message: "Too many requests, slow down or sign in",
+	}
+	LoginRateLimit = ratelimit.Policy{
+		Name:           "login",
+		Limit:          5,
+		Window:         15 * time.Minute,
+		Key:            ratelimit.ByIP,
+		SkipSuccessful: true,
+		Message:        "Too many login attempts. Try again later.",
+	}
+	RefreshRateLimit = ratelimit.Policy{
+		Name:    "refresh",
+		Limit:   5,
+		Window:  15 * time.Minute,
+		Key:     ratelimit.ByIP,
+		Message: "Too many refresh attempts. Try again later.",
+	}
+	ForgotPasswordRateLimit = ratelimit.Policy{
+		Name:    "forgot_password",
+		Limit:   5,
+		Window:  30 * time.Second,
+		Message: "Too many update attempts, try again later",
+	}
+	ResetPasswordRateLimit = ratelimit.Policy{
+		Name:    "reset_password",
+		Limit:   5,
+		Window:  time.Minute,
+		Message: "Too many update attempts, try again later",
+	}
+	ProfileUpdateRateLimit = ratelimit.Policy{
+		Name:    "profile_update",
+		Limit:   5,
+		Window:  time.Minute,
+		Key:     ratelimit.ByUser,
+		Message: "Too many update attempts, try again later",
+	}
+	ChangePasswordRateLimit = ratelimit.Policy{
+		Name:    "change_password",
+		Limit:   5,
+		Window:  5 * time.Minute,
+		Key:     ratelimit.ByUser,
+		Message: "Too many update attempts, try again later",
+	}
+	DeleteAccountRateLimit = ratelimit.Policy{
+		Name:    "delete_account",
+		Limit:   5,
+		Window:  5 * time.Minute,
+		Key:     ratelimit.ByUser,
+		Message: "Too many update attempts, try again later",
+	}
+	// FollowRateLimit uses a token bucket so a burst of follows is fine as long as it is not sustained.
+	FollowRateLimit = ratelimit.Policy{
+		Name:      "follow",
+		Limit:     10,
+		Window:    5 * time.Minute,
+		Algorithm: ratelimit.TokenBucket,
+		Key:       ratelimit.ByUser,
+		Message:   "Too many update attempts, try again later",
+	}
+	TwoFactorRateLimit = ratelimit.Policy{
+		Name:    "two_factor",
+		Limit:   5,
+		Window:  15 * time.Minute,
+		Key:     ratelimit.ByUser,
+		Message: "Too many two-factor attempts, try again later",
+	}
+	UploadRateLimit = ratelimit.Policy{
+		Name:    "upload",
+		Limit:   20,
+		Window:  time.Hour,
+		Key:     ratelimit.ByUser,
+		Message: "Too many uploads, try again later",
+	}
+)
+
+// RateLimitPolicies are the policies admins may override, by name.
+func RateLimitPolicies() map[string]ratelimit.Policy {
+	policies := map[string]ratelimit.Policy{}
+	for _, p := range []ratelimit.Policy{
+		GlobalRateLimit, GuestReadRateLimit, LoginRateLimit, RefreshRateLimit, ForgotPasswordRateLimit,
+		ResetPasswordRateLimit, ProfileUpdateRateLimit, ChangePasswordRateLimit, DeleteAccountRateLimit,
+		FollowRateLimit, TwoFactorRateLimit, UploadRateLimit,
+	} {
+		policies[p.Name] = p
+	}
+	return policies
+}
+
+// RateLimitOverrides loads the rate limit overrides for the limiter.
+func RateLimitOverrides(ctx context.Context) ([]ratelimit.Override, error) {
+	stored, err := models.GetRateLimitOverrides(ctx, Redis, DB)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make([]ratelimit.Override, len(stored))
+	for i, o := range stored {
+		overrides[i] = ratelimit.Override{
+			Policy: o.Policy,
+			Limit:  o.Limit,
+			Window: time.Duration(o.WindowSeconds) * time.Second,
+		}
+		if o.RoleID != nil {
+			overrides[i].RoleID = o.RoleID.String()
+		}
+	}
+	return overrides, nil
+}
+
+// RateLimitRole tells the limiter the role of an authenticated request.
+func RateLimitRole(c *fiber.Ctx) string {
+	roleID, ok := c.Locals("role_id").(uuid.UUID)
+	if !ok {
+		return ""
+	}
+	return roleID.String()
+}
+
+// guestRead reports whether a request is a read made without credentials.
+func guestRead(c *fiber.Ctx) bool {
+	return (c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead) && auth.Anonymous(c)
+}
+
+// guestReads applies key to anonymous reads only.
+func guestReads(key ratelimit.KeyFunc) ratelimit.KeyFunc {
+	return func(c *fiber.Ctx) string {
+		if !guestRead(c) {
+			return ""
+		}
+		return key(c)
+	}
+}
+
+// exceptGuestReads applies key to everything but anonymous reads.
+func exceptGuestReads(key ratelimit.KeyFunc) ratelimit.KeyFunc {
+	return func(c *fiber.Ctx) string {
+		if guestRead(c) {
+			return ""
+		}
+		return key(c)
+	}
+}
+
+// rateLimited counts a request against a policy under key and reports whether it must be rejected.
+// It sets the rate limit headers and lets the request through when Redis is unavailable.
+func rateLimited(c *fiber.Ctx, policy ratelimit.Policy, key string) bool {
+	p := Limiter.Effective(c, policy)
+	if p.Limit <= 0 {
+		return false
+	}
+	res, err := Limiter.Allow(c.Context(), p, key)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "policy", p.Name).Logs("Failed to check rate limit")
+		return false
+	}
+	ratelimit.SetHeaders(c, res)
+	if !res.Allowed {
+		Logger.Warn(c.Context()).WithFields("policy", p.Name, "key", key).Logs("Rate limit exceeded")
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(res.Reset.Seconds()))))
+		return true
+	}
+	return false
+}
+
+// rateLimitView is a policy as admins see it: its own limit and the overrides replacing it.
+type rateLimitView struct {
+	Name          string                     `json:"name"`
+	Algorithm     ratelimit.Algorithm        `json:"algorithm"`
+	Limit         int                        `json:"limit"`
+	WindowSeconds int                        `json:"window_seconds"`
+	Overrides     []models.RateLimitOverride `json:"overrides"`
+}
+
+// rateLimitAudit is the part of an override recorded in the audit log.
+func rateLimitAudit(o *models.RateLimitOverride) map[string]interface{} {
+	return map[string]interface{}{"policy": o.Policy, "role_id": o.RoleID, "limit": o.Limit, "window_seconds": o.WindowSeconds}
+}
+
+// ListRateLimits lists the rate limit policies with their overrides
+func ListRateLimits(c *fiber.Ctx) error {
+	overrides, err := models.GetRateLimitOverrides(c.Context(), Redis, DB)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch rate limit overrides")
+		return apierror.From(err, "Failed to fetch rate limits")
+	}
+
+	views := map[string]*rateLimitView{}
+	policies := []*rateLimitView{}
+	for name, p := range RateLimitPolicies() {
+		algorithm := p.Algorithm
+		if algorithm == "" {
+			algorithm = ratelimit.SlidingWindow
+		}
+		views[name] = &rateLimitView{
+			Name:          name,
+			Algorithm:     algorithm,
+			Limit:         p.Limit,
+			WindowSeconds: int(p.Window.Seconds()),
+			Overrides:     []models.RateLimitOverride{},
+		}
+		policies = append(policies, views[name])
+	}
+	for _, o := range overrides {
+		if view, ok := views[o.Policy]; ok {
+			view.Overrides = append(view.Overrides, o)
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Rate limits retrieved successfully",
+		"status":   fiber.StatusOK,
+		"policies": policies,
+	})
+}
+
+// SetRateLimit overrides the limit of the :policy rate limit for the users of a role or, without
+// role_id, for everyone. Every instance applies it within RateLimitRefresh
+func SetRateLimit(c *fiber.Ctx) error {
+	type SetRateLimitRequest struct {
+		RoleID        *uuid.UUID `json:"role_id"`
+		Limit         *int       `json:"limit" validate:"required,min=0,max=100000"`
+		WindowSeconds int        `json:"window_seconds" validate:"min=0,max=86400"`
+	}
+
+	actorID := auditActor(c)
+	if actorID == uuid.Nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+	policy := c.Params("policy")
+	if _, ok := RateLimitPolicies()[policy]; !ok {
+		return apierror.NotFound("Rate limit policy not found")
+	}
+	var req SetRateLimitRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	before, override, err := models.SetRateLimitOverride(c.Context(), Redis, DB, actorID, policy, req.RoleID, *req.Limit, req.WindowSeconds)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "actor_id", actorID, "policy", policy).Logs("Failed to set rate limit override")
+		return apierror.From(err, "Failed to set rate limit")
+	}
+	var previous map[string]interface{}
+	if before != nil {
+		previous = rateLimitAudit(before)
+	}
+	recordAudit(c, actorID, models.AuditRateLimitSet, models.AuditTargetRateLimit, override.ID, previous, rateLimitAudit(override))
+	Limiter.Reload()
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "policy", policy, "role_id", req.RoleID, "limit", override.Limit).Logs("Rate limit override set")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Rate limit updated successfully",
+		"status":   fiber.StatusOK,
+		"override": override,
+	})
+}
+
+// DeleteRateLimit removes a rate limit override, putting the policy's own limit back
+func DeleteRateLimit(c *fiber.Ctx) error {
+	actorID := auditActor(c)
+	if actorID == uuid.Nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid rate limit override ID")
+	}
+
+	override, err := models.DeleteRateLimitOverride(c.Context(), Redis, DB, id)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "override_id", id).Logs("Failed to delete rate limit override")
+		return apierror.From(err, "Failed to delete rate limit override")
+	}
+	recordAudit(c, actorID, models.AuditRateLimitRemove, models.AuditTargetRateLimit, override.ID, rateLimitAudit(override), nil)
+	Limiter.Reload()
+
+	Logger.Info(c.Context()).WithFields("actor_id", actorID, "override_id", override.ID, "policy", override.Policy).Logs("Rate limit override removed")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Rate limit override removed successfully",
+		"status":  fiber.StatusOK,
+	})
+}
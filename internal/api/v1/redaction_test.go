@@ -0,0 +1,111 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+)
+
+// sessionKeyPrefixes are the session store's keys, which hold refresh tokens by design; every
+// other Redis value is a cache or a counter and must not carry a secret.
+var sessionKeyPrefixes = []string{"refresh:", "refresh_family:", "refresh_used:", "sessions:"}
+
+// TestSecretsAreRedacted reads and updates a user with secrets in every sensitive column through
+// the API, then checks that no secret shows up in a response or in a value left in Redis.
+func TestSecretsAreRedacted(t *testing.T) {
+	e := testutil.Setup(t)
+	ctx := context.Background()
+
+	user := e.CreateUser(t, models.RoleMember)
+	if err := e.DB.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"otp":                     "redaction-otp",
+		"previous_passwords":      "redaction-previous-passwords",
+		"two_factor_secret":       "redaction-totp-secret",
+		"two_factor_backup_codes": "redaction-backup-codes",
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var stored models.User
+	if err := e.DB.Where("id = ?", user.ID).First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Logging in and refreshing answer with tokens by design, so they are only used to start a
+	// session and are not checked.
+	tokens := login(t, e, user.Email)
+
+	secrets := map[string]string{
+		"password hash":      stored.Password,
+		"otp":                stored.OTP,
+		"previous passwords": stored.PreviousPasswords,
+		"totp secret":        stored.TwoFactorSecret,
+		"backup codes":       stored.TwoFactorBackupCodes,
+		"refresh token":      tokens.Tokens.RefreshToken,
+	}
+	leaks := func(where string, value string) {
+		t.Helper()
+		for name, secret := range secrets {
+			if secret != "" && strings.Contains(value, secret) {
+				t.Errorf("%s leaks the %s", where, name)
+			}
+		}
+	}
+
+	admin := e.Token(t, e.CreateUser(t, models.RoleAdmin))
+	requests := []testutil.Request{
+		{Method: http.MethodGet, Path: "/users/me", Token: tokens.Tokens.AccessToken},
+		{Method: http.MethodGet, Path: "/users/me/security/activity", Token: tokens.Tokens.AccessToken},
+		{Method: http.MethodGet, Path: "/users/me/identities", Token: tokens.Tokens.AccessToken},
+		{Method: http.MethodPut, Path: "/user/update/profile/me", Token: tokens.Tokens.AccessToken, Body: map[string]interface{}{"profile": map[string]string{"bio": "Still here"}}},
+		{Method: http.MethodGet, Path: "/users/" + user.Username},
+		{Method: http.MethodGet, Path: "/users/" + user.Username, Token: tokens.Tokens.AccessToken},
+		{Method: http.MethodGet, Path: "/admin/users", Token: admin},
+		{Method: http.MethodGet, Path: "/admin/users/" + user.ID.String(), Token: admin},
+	}
+	for _, req := range requests {
+		res := e.Do(t, req)
+		if res.Status != http.StatusOK {
+			t.Fatalf("%s %s: status %d: %s", req.Method, req.Path, res.Status, res.Body)
+		}
+		leaks(req.Method+" "+req.Path, string(res.Body))
+	}
+
+	iter := e.Redis.Scan(ctx, 0, "*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if hasPrefix(key, sessionKeyPrefixes) {
+			continue
+		}
+		var values []string
+		switch kind := e.Redis.Type(ctx, key).Val(); kind {
+		case "string":
+			values = []string{e.Redis.Get(ctx, key).Val()}
+		case "hash":
+			for field, value := range e.Redis.HGetAll(ctx, key).Val() {
+				values = append(values, field, value)
+			}
+		case "set":
+			values = e.Redis.SMembers(ctx, key).Val()
+		case "zset":
+			values = e.Redis.ZRange(ctx, key, 0, -1).Val()
+		case "list":
+			values = e.Redis.LRange(ctx, key, 0, -1).Val()
+		}
+		leaks("Redis key "+key, key+" "+strings.Join(values, " "))
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("scanning Redis: %v", err)
+	}
+}
+
+func hasPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,334 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// ListRoles returns a page of roles with their permissions, ordered by name. Pages are chained
+// with the returned next_cursor; ?total=true adds the number of roles.
+func ListRoles(c *fiber.Ctx) error {
+	p, err := Paginator.Parse(c, 20, 100)
+	if err != nil {
+		Logger.Warn(c.Context()).Logs("Invalid cursor in ListRoles")
+		return apierror.From(err, "Invalid cursor")
+	}
+
+	page, err := Services.Roles.List(c.Context(), p)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch roles")
+		return apierror.Internal("Failed to fetch roles")
+	}
+	next, err := Paginator.Seal(page.Next)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to seal roles cursor")
+		return apierror.Internal("Failed to fetch roles")
+	}
+
+	Logger.Info(c.Context()).WithFields("count", len(page.Items)).Logs("Roles retrieved successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Roles retrieved successfully",
+		"status":      fiber.StatusOK,
+		"roles":       page.Items,
+		"limit":       p.Limit,
+		"next_cursor": next,
+		"total":       page.Total,
+	})
+}
+
+// ListPermissions returns a page of permissions ordered by name. Pages are chained with the
+// returned next_cursor; ?total=true adds the number of permissions.
+func ListPermissions(c *fiber.Ctx) error {
+	p, err := Paginator.Parse(c, 20, 100)
+	if err != nil {
+		Logger.Warn(c.Context()).Logs("Invalid cursor in ListPermissions")
+		return apierror.From(err, "Invalid cursor")
+	}
+
+	page, err := Services.Roles.ListPermissions(c.Context(), p)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch permissions")
+		return apierror.Internal("Failed to fetch permissions")
+	}
+	next, err := Paginator.Seal(page.Next)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to seal permissions cursor")
+		return apierror.Internal("Failed to fetch permissions")
+	}
+
+	Logger.Info(c.Context()).WithFields("count", len(page.Items)).Logs("Permissions retrieved successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Permissions retrieved successfully",
+		"status":      fiber.StatusOK,
+		"permissions": page.Items,
+		"limit":       p.Limit,
+		"next_cursor": next,
+		"total":       page.Total,
+	})
+}
+
+// ListRoleTemplates returns the templates roles can be created from, with their permissions
+func ListRoleTemplates(c *fiber.Ctx) error {
+	templates, err := Services.Roles.Templates()
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to resolve role templates")
+		return apierror.Internal("Failed to fetch role templates")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":   "Role templates retrieved successfully",
+		"status":    fiber.StatusOK,
+		"templates": templates,
+	})
+}
+
+// CreateRole creates a role from a template, an explicit permission list, or both
+func CreateRole(c *fiber.Ctx) error {
+	type CreateRoleRequest struct {
+		Name        string   `json:"name" validate:"required,min=2,max=50"`
+		Template    string   `json:"template" validate:"omitempty,max=50"`
+		Permissions []string `json:"permissions" validate:"omitempty,dive,required,max=50"`
+	}
+
+	var req CreateRoleRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	role, err := Services.Roles.Create(c.Context(), req.Name, req.Template, req.Permissions)
+	if err != nil {
+		return roleError(c, err, uuid.Nil, "Failed to create role")
+	}
+	after := roleAuditState(role)
+	if req.Template != "" {
+		after["template"] = req.Template
+	}
+	recordAudit(c, auditActor(c), models.AuditRoleCreate, models.AuditTargetRole, role.ID, nil, after)
+
+	Logger.Info(c.Context()).WithFields("role_id", role.ID, "template", req.Template).Logs("Role created successfully")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Role created successfully",
+		"status":  fiber.StatusCreated,
+		"role":    role,
+	})
+}
+
+// CloneRole creates a role with the permissions of an existing one
+func CloneRole(c *fiber.Ctx) error {
+	type CloneRoleRequest struct {
+		Name string `json:"name" validate:"required,min=2,max=50"`
+	}
+
+	roleID, err := uuid.Parse(c.Params("role_id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("role_id", c.Params("role_id")).Logs("Invalid role ID in CloneRole")
+		return apierror.BadRequest("Invalid role ID")
+	}
+
+	var req CloneRoleRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("role_id", roleID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("role_id", roleID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	role, err := Services.Roles.Clone(c.Context(), roleID, req.Name)
+	if err != nil {
+		return roleError(c, err, roleID, "Failed to clone role")
+	}
+	after := roleAuditState(role)
+	after["cloned_from"] = roleID.String()
+	recordAudit(c, auditActor(c), models.AuditRoleCreate, models.AuditTargetRole, role.ID, nil, after)
+
+	Logger.Info(c.Context()).WithFields("role_id", role.ID, "cloned_from", roleID).Logs("Role cloned successfully")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Role cloned successfully",
+		"status":  fiber.StatusCreated,
+		"role":    role,
+	})
+}
+
+// ListRoleUsers returns the users assigned a role
+func ListRoleUsers(c *fiber.Ctx) error {
+	roleID, err := uuid.Parse(c.Params("role_id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("role_id", c.Params("role_id")).Logs("Invalid role ID in ListRoleUsers")
+		return apierror.BadRequest("Invalid role ID")
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	users, total, err := Services.Roles.Users(c.Context(), roleID, page, limit)
+	if err != nil {
+		var appErr *utils.CustomError
+		if utils.As(err, &appErr) && appErr.Code == fiber.StatusNotFound {
+			Logger.Warn(c.Context()).WithFields("role_id", roleID).Logs("Role not found in ListRoleUsers")
+			return apierror.NotFound("Role not found")
+		}
+		Logger.Error(c.Context()).WithFields("role_id", roleID, "error", err).Logs("Failed to fetch role users")
+		return apierror.Internal("Failed to fetch role users")
+	}
+
+	Logger.Info(c.Context()).WithFields("role_id", roleID, "page", page, "limit", limit).Logs("Role users retrieved successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Role users retrieved successfully",
+		"status":  fiber.StatusOK,
+		"users":   users,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// UpdateRole updates a role's name and permissions
+func UpdateRole(c *fiber.Ctx) error {
+	type UpdateRoleRequest struct {
+		Name        string   `json:"name" validate:"required,min=2,max=50"`
+		Permissions []string `json:"permissions" validate:"required,dive,required,max=50"`
+		Version     int      `json:"version" validate:"required,min=1"`
+	}
+
+	roleID, err := uuid.Parse(c.Params("role_id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("role_id", c.Params("role_id")).Logs("Invalid role ID in UpdateRole")
+		return apierror.BadRequest("Invalid role ID")
+	}
+
+	var req UpdateRoleRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("role_id", roleID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("role_id", roleID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	before, _ := Services.Roles.Get(c.Context(), roleID)
+	role, err := Services.Roles.Update(c.Context(), roleID, req.Version, req.Name, req.Permissions)
+	if err != nil {
+		return roleError(c, err, roleID, "Failed to update role")
+	}
+	recordAudit(c, auditActor(c), models.AuditRoleUpdate, models.AuditTargetRole, roleID, roleAuditState(before), roleAuditState(role))
+
+	Logger.Info(c.Context()).WithFields("role_id", roleID, "version", role.Version).Logs("Role updated successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Role updated successfully",
+		"status":  fiber.StatusOK,
+		"role":    role,
+	})
+}
+
+// UpdateRolePermissions grants (POST) or revokes (DELETE) permissions on a role
+func UpdateRolePermissions(c *fiber.Ctx) error {
+	type RolePermissionsRequest struct {
+		Permissions []string `json:"permissions" validate:"required,min=1,dive,required,max=50"`
+		Version     int      `json:"version" validate:"required,min=1"`
+	}
+
+	roleID, err := uuid.Parse(c.Params("role_id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("role_id", c.Params("role_id")).Logs("Invalid role ID in UpdateRolePermissions")
+		return apierror.BadRequest("Invalid role ID")
+	}
+
+	var req RolePermissionsRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("role_id", roleID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("role_id", roleID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	before, _ := Services.Roles.Get(c.Context(), roleID)
+	var role *models.Role
+	action := models.AuditRolePermissionsGrant
+	if c.Method() == fiber.MethodDelete {
+		action = models.AuditRolePermissionsRevoke
+		role, err = Services.Roles.Revoke(c.Context(), roleID, req.Version, req.Permissions)
+	} else {
+		role, err = Services.Roles.Grant(c.Context(), roleID, req.Version, req.Permissions)
+	}
+	if err != nil {
+		return roleError(c, err, roleID, "Failed to update role permissions")
+	}
+	recordAudit(c, auditActor(c), action, models.AuditTargetRole, roleID, roleAuditState(before), roleAuditState(role))
+
+	Logger.Info(c.Context()).WithFields("role_id", roleID, "version", role.Version).Logs("Role permissions updated successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Role permissions updated successfully",
+		"status":  fiber.StatusOK,
+		"role":    role,
+	})
+}
+
+// PreviewRolePermissions returns the effective permissions a proposed set would give a role without saving it
+func PreviewRolePermissions(c *fiber.Ctx) error {
+	type PreviewRolePermissionsRequest struct {
+		Permissions []string `json:"permissions" validate:"required,dive,required,max=50"`
+	}
+
+	roleID, err := uuid.Parse(c.Params("role_id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("role_id", c.Params("role_id")).Logs("Invalid role ID in PreviewRolePermissions")
+		return apierror.BadRequest("Invalid role ID")
+	}
+
+	var req PreviewRolePermissionsRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("role_id", roleID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("role_id", roleID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	preview, err := Services.Roles.Preview(c.Context(), roleID, req.Permissions)
+	if err != nil {
+		return roleError(c, err, roleID, "Failed to preview role permissions")
+	}
+
+	Logger.Info(c.Context()).WithFields("role_id", roleID, "added", len(preview.Added), "removed", len(preview.Removed)).Logs("Role permissions previewed successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Role permissions previewed successfully",
+		"status":  fiber.StatusOK,
+		"preview": preview,
+	})
+}
+
+// roleError maps role model errors to responses, passing conflicts and other client errors through
+func roleError(c *fiber.Ctx, err error, roleID uuid.UUID, message string) error {
+	var appErr *utils.CustomError
+	if utils.As(err, &appErr) && appErr.Code < fiber.StatusInternalServerError {
+		Logger.Warn(c.Context()).WithFields("role_id", roleID, "error", appErr.Message).Logs(message)
+	} else {
+		Logger.Error(c.Context()).WithFields("role_id", roleID, "error", err).Logs(message)
+	}
+	return apierror.From(err, message)
+}
@@ -0,0 +1,542 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+type roleBody struct {
+	Role struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Version     int    `json:"version"`
+		Permissions []struct {
+			Name string `json:"name"`
+		} `json:"permissions"`
+	} `json:"role"`
+}
+
+// has reports whether the role in the body grants perm.
+func (b roleBody) has(perm string) bool {
+	for _, p := range b.Role.Permissions {
+		if p.Name == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// asAdmin fills in the token of a new admin, who req then acts as.
+func asAdmin(t *testing.T, e *testutil.Env, req *testutil.Request) {
+	req.Token = e.Token(t, e.CreateUser(t, models.RoleAdmin))
+}
+
+// createRole stores a role named name granting perms.
+func createRole(t *testing.T, e *testutil.Env, name string, perms ...string) *models.Role {
+	t.Helper()
+	role, err := models.NewRole(context.Background(), e.Redis, e.DB, name, perms...)
+	if err != nil {
+		t.Fatalf("creating role %s: %v", name, err)
+	}
+	return role
+}
+
+func TestListRoles(t *testing.T) {
+	testutil.Run(t, []testutil.Case{
+		{
+			Name:    "as admin",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodGet, Path: "/admin/roles?limit=100"},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body struct {
+					Roles []struct {
+						Name string `json:"name"`
+					} `json:"roles"`
+				}
+				res.JSON(t, &body)
+				found := false
+				for _, r := range body.Roles {
+					found = found || r.Name == models.RoleMember
+				}
+				if !found {
+					t.Fatalf("member role not listed: %s", res.Body)
+				}
+			},
+		},
+		{
+			Name: "as member",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				req.Token = e.Token(t, e.CreateUser(t, models.RoleMember))
+			},
+			Request: testutil.Request{Method: http.MethodGet, Path: "/admin/roles"},
+			Status:  http.StatusForbidden,
+		},
+		{
+			Name:    "unauthenticated",
+			Request: testutil.Request{Method: http.MethodGet, Path: "/admin/roles"},
+			Status:  http.StatusUnauthorized,
+		},
+	})
+}
+
+func TestCreateRole(t *testing.T) {
+	testutil.Run(t, []testutil.Case{
+		{
+			Name:    "with permissions",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodPost, Path: "/admin/roles", Body: map[string]interface{}{"name": "editors", "permissions": []string{"create_post", "edit_post"}}},
+			Status:  http.StatusCreated,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body roleBody
+				res.JSON(t, &body)
+				if body.Role.Name != "editors" || !body.has("create_post") || !body.has("edit_post") {
+					t.Fatalf("created role %+v", body.Role)
+				}
+			},
+		},
+		{
+			Name: "taken name",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				asAdmin(t, e, req)
+				createRole(t, e, "editors")
+			},
+			Request: testutil.Request{Method: http.MethodPost, Path: "/admin/roles", Body: map[string]interface{}{"name": "editors"}},
+			Status:  http.StatusConflict,
+		},
+		{
+			Name:    "unknown template",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodPost, Path: "/admin/roles", Body: map[string]interface{}{"name": "editors", "template": "no-such-template"}},
+			Status:  http.StatusNotFound,
+		},
+		{
+			Name:    "name too short",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodPost, Path: "/admin/roles", Body: map[string]interface{}{"name": "e"}},
+			Status:  http.StatusUnprocessableEntity,
+		},
+		{
+			Name: "as member",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				req.Token = e.Token(t, e.CreateUser(t, models.RoleMember))
+			},
+			Request: testutil.Request{Method: http.MethodPost, Path: "/admin/roles", Body: map[string]interface{}{"name": "editors"}},
+			Status:  http.StatusForbidden,
+		},
+	})
+}
+
+func TestUpdateRole(t *testing.T) {
+	var role *models.Role
+	withRole := func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+		asAdmin(t, e, req)
+		role = createRole(t, e, "editors", "create_post")
+		req.Path = "/admin/roles/" + role.ID.String()
+	}
+
+	testutil.Run(t, []testutil.Case{
+		{
+			Name: "current version",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				withRole(t, e, req)
+				req.Body = map[string]interface{}{"name": "writers", "permissions": []string{"edit_post"}, "version": role.Version}
+			},
+			Request: testutil.Request{Method: http.MethodPut},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body roleBody
+				res.JSON(t, &body)
+				if body.Role.Name != "writers" || body.Role.Version != role.Version+1 || body.has("create_post") || !body.has("edit_post") {
+					t.Fatalf("updated role %+v", body.Role)
+				}
+			},
+		},
+		{
+			Name: "stale version",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				withRole(t, e, req)
+				req.Body = map[string]interface{}{"name": "writers", "permissions": []string{}, "version": role.Version + 1}
+			},
+			Request: testutil.Request{Method: http.MethodPut},
+			Status:  http.StatusConflict,
+		},
+		{
+			Name: "renaming a built-in role",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				asAdmin(t, e, req)
+				var member models.Role
+				if err := e.DB.Where("name = ?", models.RoleMember).First(&member).Error; err != nil {
+					t.Fatal(err)
+				}
+				req.Path = "/admin/roles/" + member.ID.String()
+				req.Body = map[string]interface{}{"name": "members", "permissions": []string{}, "version": member.Version}
+			},
+			Request: testutil.Request{Method: http.MethodPut},
+			Status:  http.StatusForbidden,
+		},
+		{
+			Name:    "unknown role",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodPut, Path: "/admin/roles/00000000-0000-0000-0000-000000000001", Body: map[string]interface{}{"name": "writers", "permissions": []string{}, "version": 1}},
+			Status:  http.StatusNotFound,
+		},
+		{
+			Name:    "invalid role ID",
+			Setup:   asAdmin,
+			Request: testutil.Request{Method: http.MethodPut, Path: "/admin/roles/not-a-uuid", Body: map[string]interface{}{"name": "writers", "permissions": []string{}, "version": 1}},
+			Status:  http.StatusBadRequest,
+		},
+	})
+}
+
+func TestUpdateRolePermissions(t *testing.T) {
+	var role *models.Role
+	withRole := func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+		asAdmin(t, e, req)
+		role = createRole(t, e, "editors", "create_post")
+		req.Path = "/admin/roles/" + role.ID.String() + "/permissions"
+	}
+
+	testutil.Run(t, []testutil.Case{
+		{
+			Name: "grant",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				withRole(t, e, req)
+				req.Body = map[string]interface{}{"permissions": []string{"edit_post"}, "version": role.Version}
+			},
+			Request: testutil.Request{Method: http.MethodPost},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body roleBody
+				res.JSON(t, &body)
+				if !body.has("create_post") || !body.has("edit_post") {
+					t.Fatalf("role after grant %+v", body.Role)
+				}
+			},
+		},
+		{
+			Name: "revoke",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				withRole(t, e, req)
+				req.Body = map[string]interface{}{"permissions": []string{"create_post"}, "version": role.Version}
+			},
+			Request: testutil.Request{Method: http.MethodDelete},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body roleBody
+				res.JSON(t, &body)
+				if body.has("create_post") {
+					t.Fatalf("role after revoke %+v", body.Role)
+				}
+			},
+		},
+		{
+			Name: "stale version",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				withRole(t, e, req)
+				req.Body = map[string]interface{}{"permissions": []string{"edit_post"}, "version": role.Version + 1}
+			},
+			Request: testutil.Request{Method: http.MethodPost},
+			Status:  http.StatusConflict,
+		},
+		{
+			Name: "no permissions",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				withRole(t, e, req)
+				req.Body = map[string]interface{}{"permissions": []string{}, "version": role.Version}
+			},
+			Request: testutil.Request{Method: http.MethodPost},
+			Status:  http.StatusUnprocessableEntity,
+		},
+	})
+}
+
+func TestAssignUserRole(t *testing.T) {
+	var target *models.User
+	var role *models.Role
+	withTarget := func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+		asAdmin(t, e, req)
+		target = e.CreateUser(t, models.RoleMember)
+		role = createRole(t, e, "editors", "manage_account", "create_post")
+		req.Path = "/admin/users/" + target.ID.String() + "/role"
+		req.Body = map[string]string{"role_id": role.ID.String()}
+	}
+
+	testutil.Run(t, []testutil.Case{
+		{
+			Name:    "assigns the role",
+			Setup:   withTarget,
+			Request: testutil.Request{Method: http.MethodPut},
+			Status:  http.StatusOK,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var stored models.User
+				if err := e.DB.Where("id = ?", target.ID).First(&stored).Error; err != nil {
+					t.Fatal(err)
+				}
+				if stored.RoleID != role.ID {
+					t.Fatalf("user has role %s, want %s", stored.RoleID, role.ID)
+				}
+				// The new role's permissions apply straight away
+				stored.Role = *role
+				token := e.Token(t, &stored)
+				if res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/users/me", Token: token}); res.Status != http.StatusOK {
+					t.Fatalf("GET /users/me with manage_account: status %d", res.Status)
+				}
+				if res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/users/" + target.Username + "/block", Token: token}); res.Status != http.StatusForbidden {
+					t.Fatalf("blocking without block_user: status %d, want %d", res.Status, http.StatusForbidden)
+				}
+			},
+		},
+		{
+			Name: "unknown role",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				withTarget(t, e, req)
+				req.Body = map[string]string{"role_id": "00000000-0000-0000-0000-000000000001"}
+			},
+			Request: testutil.Request{Method: http.MethodPut},
+			Status:  http.StatusNotFound,
+		},
+		{
+			Name: "unknown user",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				withTarget(t, e, req)
+				req.Path = "/admin/users/00000000-0000-0000-0000-000000000001/role"
+			},
+			Request: testutil.Request{Method: http.MethodPut},
+			Status:  http.StatusNotFound,
+		},
+		{
+			Name: "invalid role ID",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				withTarget(t, e, req)
+				req.Body = map[string]string{"role_id": "editors"}
+			},
+			Request: testutil.Request{Method: http.MethodPut},
+			Status:  http.StatusUnprocessableEntity,
+		},
+		{
+			Name: "as member",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				withTarget(t, e, req)
+				req.Token = e.Token(t, e.CreateUser(t, models.RoleMember))
+			},
+			Request: testutil.Request{Method: http.MethodPut},
+			Status:  http.StatusForbidden,
+		},
+	})
+}
+
+func TestListRoleUsers(t *testing.T) {
+	e := testutil.Setup(t)
+	admin := e.Token(t, e.CreateUser(t, models.RoleAdmin))
+	role := createRole(t, e, "editors", "create_post")
+	var editors []string
+	for i := 0; i < 3; i++ {
+		editors = append(editors, e.CreateUser(t, role.Name).Username)
+	}
+	e.CreateUser(t, models.RoleMember)
+	deleted := e.CreateUser(t, role.Name)
+	if err := e.DB.Delete(&models.User{}, "id = ?", deleted.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(editors)
+
+	page := func(t *testing.T, query string) ([]string, int64) {
+		t.Helper()
+		res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/admin/roles/" + role.ID.String() + "/users" + query, Token: admin})
+		if res.Status != http.StatusOK {
+			t.Fatalf("status %d: %s", res.Status, res.Body)
+		}
+		var body struct {
+			Users []models.UserSummary `json:"users"`
+			Total int64                `json:"total"`
+		}
+		res.JSON(t, &body)
+		names := make([]string, len(body.Users))
+		for i, u := range body.Users {
+			names[i] = u.Username
+		}
+		return names, body.Total
+	}
+
+	first, total := page(t, "?limit=2&page=1")
+	if total != 3 || !equalNames(first, editors[:2]...) {
+		t.Fatalf("page 1: got %v of %d, want %v of 3", first, total, editors[:2])
+	}
+	second, total := page(t, "?limit=2&page=2")
+	if total != 3 || !equalNames(second, editors[2:]...) {
+		t.Fatalf("page 2: got %v of %d, want %v of 3", second, total, editors[2:])
+	}
+	if beyond, _ := page(t, "?limit=2&page=3"); len(beyond) != 0 {
+		t.Fatalf("page 3: got %v, want none", beyond)
+	}
+
+	for _, tc := range []struct {
+		path   string
+		token  string
+		status int
+	}{
+		{path: "/admin/roles/00000000-0000-0000-0000-000000000001/users", token: admin, status: http.StatusNotFound},
+		{path: "/admin/roles/not-a-uuid/users", token: admin, status: http.StatusBadRequest},
+		{path: "/admin/roles/" + role.ID.String() + "/users", token: e.Token(t, e.CreateUser(t, models.RoleMember)), status: http.StatusForbidden},
+	} {
+		if res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: tc.path, Token: tc.token}); res.Status != tc.status {
+			t.Errorf("GET %s: status %d, want %d", tc.path, res.Status, tc.status)
+		}
+	}
+}
+
+func TestConcurrentRoleEdits(t *testing.T) {
+	type edit func(ctx context.Context, e *testutil.Env, role *models.Role) (*models.Role, error)
+	rename := func(name string) edit {
+		return func(ctx context.Context, e *testutil.Env, role *models.Role) (*models.Role, error) {
+			return models.UpdateRole(ctx, e.Redis, e.DB, role.ID, role.Version, name, []string{"edit_post"})
+		}
+	}
+	grant := func(ctx context.Context, e *testutil.Env, role *models.Role) (*models.Role, error) {
+		return models.AddRolePermissions(ctx, e.Redis, e.DB, role.ID, role.Version, []string{"delete_post"})
+	}
+	revoke := func(ctx context.Context, e *testutil.Env, role *models.Role) (*models.Role, error) {
+		return models.RemoveRolePermissions(ctx, e.Redis, e.DB, role.ID, role.Version, []string{"create_post"})
+	}
+
+	cases := []struct {
+		name  string
+		edits [2]edit
+	}{
+		{name: "two updates", edits: [2]edit{rename("writers"), rename("authors")}},
+		{name: "update and grant", edits: [2]edit{rename("writers"), grant}},
+		{name: "grant and revoke", edits: [2]edit{grant, revoke}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := testutil.Setup(t)
+			role := createRole(t, e, "editors", "create_post")
+
+			// Both edits are made against the same version and start together
+			var wg sync.WaitGroup
+			start := make(chan struct{})
+			results := make([]*models.Role, 2)
+			errs := make([]error, 2)
+			for i, apply := range tc.edits {
+				wg.Add(1)
+				go func(i int, apply edit) {
+					defer wg.Done()
+					<-start
+					results[i], errs[i] = apply(context.Background(), e, role)
+				}(i, apply)
+			}
+			close(start)
+			wg.Wait()
+
+			winner := -1
+			for i, err := range errs {
+				if err == nil {
+					if winner >= 0 {
+						t.Fatal("both edits of the same version succeeded")
+					}
+					winner = i
+					continue
+				}
+				var appErr *utils.CustomError
+				if !utils.As(err, &appErr) || appErr.Code != http.StatusConflict {
+					t.Fatalf("edit %d: got %v, want a conflict", i, err)
+				}
+			}
+			if winner < 0 {
+				t.Fatalf("neither edit succeeded: %v", errs)
+			}
+
+			// The stored role is the winner's, not a mix of both
+			stored, err := models.GetRoleBy(context.Background(), e.Redis, e.DB, "id = ?", []interface{}{role.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := results[winner]
+			if stored.Version != role.Version+1 || stored.Name != want.Name || !samePermissions(stored, want) {
+				t.Fatalf("stored role %s v%d %v, want the winner's %s v%d %v", stored.Name, stored.Version, permissionNames(stored), want.Name, role.Version+1, permissionNames(want))
+			}
+		})
+	}
+}
+
+func permissionNames(role *models.Role) []string {
+	names := make([]string, len(role.Permissions))
+	for i, p := range role.Permissions {
+		names[i] = p.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func samePermissions(a, b *models.Role) bool {
+	return equalNames(permissionNames(a), permissionNames(b)...)
+}
+
+func TestPreviewRolePermissions(t *testing.T) {
+	e := testutil.Setup(t)
+	admin := e.Token(t, e.CreateUser(t, models.RoleAdmin))
+	role := createRole(t, e, "editors", "create_post", "edit_post")
+	before := permissionNames(role)
+	proposed := []string{"create_post", "*_comment", "pin_announcements"}
+
+	res := e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/admin/roles/" + role.ID.String() + "/permissions/preview", Token: admin, Body: map[string]interface{}{"permissions": proposed}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("preview: status %d: %s", res.Status, res.Body)
+	}
+	var body struct {
+		Preview models.PermissionPreview `json:"preview"`
+	}
+	res.JSON(t, &body)
+	preview := body.Preview
+	if preview.Version != role.Version || !equalNames(preview.Removed, "edit_post") {
+		t.Fatalf("preview %+v, want version %d removing edit_post", preview, role.Version)
+	}
+	for _, name := range preview.Effective {
+		if strings.ContainsAny(name, "*?[") {
+			t.Fatalf("preview left the pattern %q unexpanded", name)
+		}
+	}
+	if !utils.Contains(preview.Effective, "create_comment") || !utils.Contains(preview.Added, "pin_announcements") {
+		t.Fatalf("preview %+v, want *_comment expanded and pin_announcements added", preview)
+	}
+
+	// Previewing saves nothing
+	unchanged, err := models.GetRoleBy(context.Background(), e.Redis, e.DB, "id = ?", []interface{}{role.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unchanged.Version != role.Version || !equalNames(permissionNames(unchanged), before...) {
+		t.Fatalf("preview changed the role to v%d %v", unchanged.Version, permissionNames(unchanged))
+	}
+
+	// Committing the same set gives the role exactly the previewed permissions
+	res = e.Do(t, testutil.Request{Method: http.MethodPut, Path: "/admin/roles/" + role.ID.String(), Token: admin, Body: map[string]interface{}{"name": role.Name, "permissions": proposed, "version": preview.Version}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("update: status %d: %s", res.Status, res.Body)
+	}
+	committed, err := models.GetRoleBy(context.Background(), e.Redis, e.DB, "id = ?", []interface{}{role.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := permissionNames(committed); !equalNames(got, preview.Effective...) {
+		t.Fatalf("committed permissions %v, previewed %v", got, preview.Effective)
+	}
+	for _, name := range preview.Added {
+		if utils.Contains(before, name) {
+			t.Fatalf("preview adds %q, which the role already had", name)
+		}
+	}
+	if len(preview.Added) != len(preview.Effective)-len(before)+len(preview.Removed) {
+		t.Fatalf("preview adds %v and removes %v, which does not account for %v -> %v", preview.Added, preview.Removed, before, preview.Effective)
+	}
+}
@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+)
+
+// Search finds published posts the requester may see and users matching ?q=, optionally limited
+// to one ?type=. The index trails changes by a few seconds as the outbox worker applies them.
+func Search(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return apierror.BadRequest("Query is required")
+	}
+	if len(query) > 200 {
+		return apierror.BadRequest("Query must be at most 200 characters")
+	}
+	kind := c.Query("type")
+	if kind != "" && kind != models.SearchKindPost && kind != models.SearchKindUser {
+		return apierror.BadRequest("Type must be post or user")
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	results, total, err := models.Search(c.Context(), DB, query, kind, viewerID(c), page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "query", query).Logs("Failed to search")
+		return postError(c, err, "Failed to search")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Search results retrieved successfully",
+		"status":  fiber.StatusOK,
+		"results": results,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
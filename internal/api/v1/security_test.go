@@ -0,0 +1,260 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	v1 "github.com/mnuddindev/devpulse/internal/api/v1"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestGetSecuritySummary(t *testing.T) {
+	e := testutil.Setup(t)
+	ctx := context.Background()
+	user := e.CreateUser(t, models.RoleMember)
+	other := e.CreateUser(t, models.RoleMember)
+
+	lastLogin := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	if err := e.DB.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"last_login_at":      lastLogin,
+		"last_login_ip":      "203.0.113.7",
+		"two_factor_enabled": true,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	models.TrackSession(ctx, e.Redis, user.ID, "session-one", time.Hour)
+	models.TrackSession(ctx, e.Redis, user.ID, "session-two", time.Hour)
+	models.TrackSession(ctx, e.Redis, other.ID, "someone-elses-session", time.Hour)
+	if err := e.DB.Create(&models.Identity{UserID: user.ID, Provider: "github", ProviderUserID: "1234"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range []string{models.EventLoginFailed, models.EventLogin} {
+		if err := models.RecordSecurityEvent(ctx, e.DB, user.ID, event, "203.0.113.7", "test"); err != nil {
+			t.Fatal(err)
+		}
+		// Events are ordered by when they happened
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := models.RecordSecurityEvent(ctx, e.DB, other.ID, models.EventLogin, "198.51.100.1", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/me/security", Token: e.Token(t, user)})
+	if res.Status != http.StatusOK {
+		t.Fatalf("status %d: %s", res.Status, res.Body)
+	}
+	var body struct {
+		Security models.SecuritySummary `json:"security"`
+	}
+	res.JSON(t, &body)
+	summary := body.Security
+
+	if summary.LastLoginAt == nil || !summary.LastLoginAt.Equal(lastLogin) || summary.LastLoginIP != "203.0.113.7" {
+		t.Fatalf("last login %v from %q, want %v from 203.0.113.7", summary.LastLoginAt, summary.LastLoginIP, lastLogin)
+	}
+	if summary.ActiveSessions != 2 {
+		t.Fatalf("active sessions %d, want 2", summary.ActiveSessions)
+	}
+	if !summary.TwoFactorEnabled {
+		t.Fatal("two factor authentication not reported")
+	}
+	if !equalNames(summary.LinkedProviders, "github") {
+		t.Fatalf("linked providers %v, want github", summary.LinkedProviders)
+	}
+	if len(summary.RecentEvents) != 2 || summary.RecentEvents[0].Event != models.EventLogin || summary.RecentEvents[1].Event != models.EventLoginFailed {
+		t.Fatalf("recent events %+v, want the user's login after the failed one", summary.RecentEvents)
+	}
+	if res.Header.Get("Cache-Control") != "no-store, private" {
+		t.Fatalf("Cache-Control %q, want the summary kept out of caches", res.Header.Get("Cache-Control"))
+	}
+
+	if res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/me/security"}); res.Status != http.StatusUnauthorized {
+		t.Fatalf("without a token: status %d, want 401", res.Status)
+	}
+}
+
+// withSessionPolicy sets the session policy for the rest of the test.
+func withSessionPolicy(t *testing.T, policy models.SessionPolicy) {
+	previous := v1.SessionPolicy
+	v1.SessionPolicy = policy
+	t.Cleanup(func() { v1.SessionPolicy = previous })
+}
+
+// sessionAlive reports whether both tokens of a session are still accepted.
+func sessionAlive(t *testing.T, e *testutil.Env, session tokensBody) (access, refresh bool) {
+	t.Helper()
+	access = e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/users/me", Token: session.Tokens.AccessToken}).Status == http.StatusOK
+	refresh = e.Do(t, testutil.Request{Method: http.MethodPost, Path: "/refresh-token", Body: map[string]string{"refresh_token": session.Tokens.RefreshToken}}).Status == http.StatusOK
+	return access, refresh
+}
+
+func TestPasswordChangeRevokesSessions(t *testing.T) {
+	for _, revoke := range []bool{true, false} {
+		t.Run(fmt.Sprintf("policy %v", revoke), func(t *testing.T) {
+			e := testutil.Setup(t)
+			withSessionPolicy(t, models.SessionPolicy{OnPasswordChange: revoke})
+			user := e.CreateUser(t, models.RoleMember)
+			current := login(t, e, user.Email)
+			other := login(t, e, user.Email)
+
+			res := e.Do(t, testutil.Request{Method: http.MethodPut, Path: "/user/update/account/me", Token: current.Tokens.AccessToken, Body: map[string]string{
+				"current_password": testutil.Password,
+				"new_password":     "New-passw0rd!2",
+				"confirm_password": "New-passw0rd!2",
+			}})
+			if res.Status != http.StatusOK {
+				t.Fatalf("changing password: status %d: %s", res.Status, res.Body)
+			}
+
+			access, refresh := sessionAlive(t, e, other)
+			if access == revoke || refresh == revoke {
+				t.Fatalf("other session after password change: access %v, refresh %v; want both %v", access, refresh, !revoke)
+			}
+			var events int64
+			e.DB.Model(&models.SecurityEvent{}).Where("user_id = ? AND event = ?", user.ID, models.EventSessionsRevoked).Count(&events)
+			if (events == 1) != revoke {
+				t.Fatalf("%d sessions_revoked events recorded", events)
+			}
+		})
+	}
+}
+
+func TestRoleChangeRevokesSessions(t *testing.T) {
+	for _, revoke := range []bool{true, false} {
+		t.Run(fmt.Sprintf("policy %v", revoke), func(t *testing.T) {
+			e := testutil.Setup(t)
+			withSessionPolicy(t, models.SessionPolicy{OnPasswordChange: true, OnRoleChange: revoke})
+			user := e.CreateUser(t, models.RoleMember)
+			session := login(t, e, user.Email)
+			role := createRole(t, e, "editors", "manage_account")
+
+			res := e.Do(t, testutil.Request{Method: http.MethodPut, Path: "/admin/users/" + user.ID.String() + "/role", Token: e.Token(t, e.CreateUser(t, models.RoleAdmin)), Body: map[string]string{"role_id": role.ID.String()}})
+			if res.Status != http.StatusOK {
+				t.Fatalf("assigning role: status %d: %s", res.Status, res.Body)
+			}
+
+			// Without revocation the access token still names the old role and is refused, but the
+			// session can refresh into a token for the new one.
+			if _, refresh := sessionAlive(t, e, session); refresh == revoke {
+				t.Fatalf("refreshing after role change: %v, want %v", refresh, !revoke)
+			}
+		})
+	}
+}
+
+// viaProvider marks a session as established via provider, as an OAuth login would.
+func viaProvider(t *testing.T, e *testutil.Env, session tokensBody, provider string) {
+	t.Helper()
+	ctx := context.Background()
+	key := "refresh:" + session.Tokens.RefreshToken
+	var refreshData map[string]interface{}
+	if err := json.Unmarshal([]byte(e.Redis.Get(ctx, key).Val()), &refreshData); err != nil {
+		t.Fatalf("reading refresh data: %v", err)
+	}
+	refreshData["provider"] = provider
+	raw, _ := json.Marshal(refreshData)
+	if err := e.Redis.SetArgs(ctx, key, raw, redis.SetArgs{KeepTTL: true}).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevokeProviderSessions(t *testing.T) {
+	e := testutil.Setup(t)
+	user := e.CreateUser(t, models.RoleMember)
+	other := e.CreateUser(t, models.RoleMember)
+
+	password := login(t, e, user.Email)
+	github := login(t, e, user.Email)
+	google := login(t, e, user.Email)
+	othersGithub := login(t, e, other.Email)
+	viaProvider(t, e, github, "github")
+	viaProvider(t, e, google, "google")
+	viaProvider(t, e, othersGithub, "github")
+
+	res := e.Do(t, testutil.Request{Method: http.MethodDelete, Path: "/me/sessions/providers/GitHub", Token: password.Tokens.AccessToken})
+	if res.Status != http.StatusOK {
+		t.Fatalf("status %d: %s", res.Status, res.Body)
+	}
+	var body struct {
+		Provider string `json:"provider"`
+		Revoked  int    `json:"revoked"`
+	}
+	res.JSON(t, &body)
+	if body.Provider != "github" || body.Revoked != 1 {
+		t.Fatalf("revoked %d %s sessions, want 1 github session", body.Revoked, body.Provider)
+	}
+	if n := e.Redis.ZCard(context.Background(), "sessions:"+user.ID.String()).Val(); n != 2 {
+		t.Fatalf("%d sessions tracked, want the 2 left", n)
+	}
+
+	sessions := []struct {
+		name    string
+		session tokensBody
+		alive   bool
+	}{
+		{name: "github", session: github, alive: false},
+		{name: "password", session: password, alive: true},
+		{name: "google", session: google, alive: true},
+		{name: "another user's github", session: othersGithub, alive: true},
+	}
+	for _, s := range sessions {
+		if _, refresh := sessionAlive(t, e, s.session); refresh != s.alive {
+			t.Errorf("%s session refreshes: %v, want %v", s.name, refresh, s.alive)
+		}
+	}
+	var events int64
+	e.DB.Model(&models.SecurityEvent{}).Where("user_id = ? AND event = ?", user.ID, models.EventSessionsRevoked).Count(&events)
+	if events != 1 {
+		t.Fatalf("%d sessions_revoked events recorded, want 1", events)
+	}
+}
+
+// impersonate has an admin impersonate user and returns the impersonation token.
+func impersonate(t *testing.T, e *testutil.Env, user *models.User) string {
+	t.Helper()
+	res := e.Do(t, testutil.Request{
+		Method: http.MethodPost,
+		Path:   "/admin/users/" + user.ID.String() + "/impersonate",
+		Token:  e.Token(t, e.CreateUser(t, models.RoleAdmin)),
+		Body:   map[string]string{"reason": "Support ticket"},
+	})
+	if res.Status != http.StatusOK {
+		t.Fatalf("impersonating: status %d: %s", res.Status, res.Body)
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+	}
+	res.JSON(t, &body)
+	return body.AccessToken
+}
+
+func TestImpersonationCannotChangeSignIn(t *testing.T) {
+	e := testutil.Setup(t)
+	user := e.CreateUser(t, models.RoleMember)
+	token := impersonate(t, e, user)
+
+	if res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/users/me", Token: token}); res.Status != http.StatusOK {
+		t.Fatalf("reading the profile: status %d, want 200", res.Status)
+	}
+	res := e.Do(t, testutil.Request{Method: http.MethodPut, Path: "/user/update/profile/me", Token: token, Body: map[string]string{
+		"email":    "taken-over@example.com",
+		"username": "takenover",
+	}})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("updating the profile: status %d, want 403: %s", res.Status, res.Body)
+	}
+
+	var stored models.User
+	if err := e.DB.First(&stored, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Email != user.Email || stored.Username != user.Username {
+		t.Fatalf("profile changed to %s %s, want %s %s", stored.Username, stored.Email, user.Username, user.Email)
+	}
+}
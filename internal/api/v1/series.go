@@ -0,0 +1,407 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/contentfilter"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// seriesResponse is the public representation of a series
+func seriesResponse(series *models.Series) fiber.Map {
+	res := fiber.Map{
+		"id":              series.ID,
+		"title":           series.Title,
+		"slug":            series.Slug,
+		"description":     series.Description,
+		"cover_image_url": series.CoverImageURL,
+		"author_id":       series.AuthorID,
+		"total_posts":     series.TotalPosts,
+		"created_at":      series.CreatedAt,
+		"updated_at":      series.UpdatedAt,
+	}
+	if series.Author.ID != uuid.Nil {
+		res["author"] = fiber.Map{
+			"id":         series.Author.ID,
+			"username":   series.Author.Username,
+			"name":       series.Author.Profile.Name,
+			"avatar_url": series.Author.Profile.AvatarURL,
+		}
+	}
+	return res
+}
+
+// seriesNavigation places a post within its series for readers. It is nil when the post is not a
+// published part of a series, or when the series cannot be loaded, which only costs the links.
+func seriesNavigation(c *fiber.Ctx, post *models.Posts) *models.SeriesNavigation {
+	if post.SeriesID == nil {
+		return nil
+	}
+	series, err := models.GetPublishedSeries(c.Context(), Redis, DB, *post.SeriesID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "post_id", post.ID, "series_id", *post.SeriesID).Logs("Failed to fetch post series")
+		return nil
+	}
+	return series.Navigation(post.ID)
+}
+
+// managedSeries loads the series named by the :id param for the authenticated user, who must be
+// its author or hold edit_any_post. On failure the series is nil and the error answers the request.
+func managedSeries(c *fiber.Ctx) (*models.Series, uuid.UUID, error) {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("Series management attempted without user_id in context")
+		return nil, uuid.Nil, apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in series management")
+		return nil, uuid.Nil, apierror.BadRequest("Invalid user ID")
+	}
+
+	seriesID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("series_id", c.Params("id")).Logs("Invalid series ID")
+		return nil, userID, apierror.BadRequest("Invalid series ID")
+	}
+	series, err := models.GetSeries(c.Context(), DB, seriesID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "series_id", seriesID).Logs("Failed to fetch series")
+		return nil, userID, apierror.From(err, "Failed to fetch series")
+	}
+	if series.AuthorID == userID {
+		return series, userID, nil
+	}
+
+	allowed, err := hasPermission(c, userID, "edit_any_post")
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch permissions in series management")
+		return nil, userID, apierror.From(err, "Failed to fetch user")
+	}
+	if allowed {
+		return series, userID, nil
+	}
+
+	Logger.Warn(c.Context()).WithFields("user_id", userID, "series_id", seriesID).Logs("User is not allowed to manage series")
+	return nil, userID, apierror.Forbidden("You can only manage your own series")
+}
+
+// SeriesOwner resolves the author of the series named by the :id path parameter for
+// owner-or-permission access rules
+func SeriesOwner(c *fiber.Ctx) (uuid.UUID, error) {
+	seriesID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, apierror.BadRequest("Invalid series ID")
+	}
+	series, err := models.GetSeries(c.Context(), DB, seriesID)
+	if err != nil {
+		return uuid.Nil, apierror.From(err, "Failed to fetch series")
+	}
+	return series.AuthorID, nil
+}
+
+// seriesPartsResponse answers a series management request with the series and all of its posts in
+// order, drafts included
+func seriesPartsResponse(c *fiber.Ctx, seriesID uuid.UUID, status int, message string) error {
+	series, err := models.GetSeries(c.Context(), DB, seriesID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "series_id", seriesID).Logs("Failed to fetch series")
+		return apierror.From(err, "Failed to fetch series")
+	}
+	parts, err := models.GetSeriesParts(c.Context(), DB, seriesID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "series_id", seriesID).Logs("Failed to fetch series posts")
+		return apierror.From(err, "Failed to fetch series posts")
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"message": message,
+		"status":  status,
+		"series":  seriesResponse(series),
+		"parts":   parts,
+	})
+}
+
+// CreateSeries creates an empty series for the authenticated user
+func CreateSeries(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("CreateSeries attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in CreateSeries")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	type CreateSeriesRequest struct {
+		Title         string `json:"title" validate:"required,min=3,max=120"`
+		Description   string `json:"description" validate:"omitempty,max=1000"`
+		CoverImageURL string `json:"cover_image_url" validate:"omitempty,url,max=500"`
+	}
+
+	var req CreateSeriesRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	if err := filterContent(c, contentField{Name: "title", Field: contentfilter.FieldPostTitle, Text: req.Title}); err != nil {
+		return err
+	}
+
+	series := &models.Series{
+		AuthorID:      userID,
+		Title:         req.Title,
+		Description:   req.Description,
+		CoverImageURL: req.CoverImageURL,
+	}
+	if err := models.CreateSeries(c.Context(), Redis, DB, series); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to create series")
+		return apierror.From(err, "Failed to create series")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "series_id", series.ID).Logs("Series created successfully")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Series created successfully",
+		"status":  fiber.StatusCreated,
+		"series":  seriesResponse(series),
+	})
+}
+
+// GetSeries returns a series by its slug with its published parts in reading order
+func GetSeries(c *fiber.Ctx) error {
+	slug := strings.ToLower(strings.TrimSpace(c.Params("slug")))
+	if slug == "" {
+		return apierror.BadRequest("Slug is required")
+	}
+
+	series, err := models.GetPublishedSeriesBySlug(c.Context(), Redis, DB, slug)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "slug", slug).Logs("Failed to fetch series")
+		return apierror.From(err, "Failed to fetch series")
+	}
+	// A series shows up once its first part is published
+	if len(series.Parts) == 0 {
+		return apierror.NotFound("Series not found")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Series retrieved successfully",
+		"status":  fiber.StatusOK,
+		"series":  seriesResponse(&series.Series),
+		"parts":   series.Parts,
+	})
+}
+
+// GetMySeries lists the authenticated user's series, including those without published parts
+func GetMySeries(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetMySeries attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetMySeries")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	series, total, err := models.ListAuthorSeries(c.Context(), DB, userID, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user series")
+		return apierror.From(err, "Failed to fetch series")
+	}
+
+	res := make([]fiber.Map, len(series))
+	for i := range series {
+		res[i] = seriesResponse(&series[i])
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Series retrieved successfully",
+		"status":  fiber.StatusOK,
+		"series":  res,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
+
+// UpdateSeries edits the title, slug, description or cover of a series
+func UpdateSeries(c *fiber.Ctx) error {
+	series, userID, err := managedSeries(c)
+	if series == nil {
+		return err
+	}
+
+	type UpdateSeriesRequest struct {
+		Title         *string `json:"title" validate:"omitempty,min=3,max=120"`
+		Slug          *string `json:"slug" validate:"omitempty,max=130,slug"`
+		Description   *string `json:"description" validate:"omitempty,max=1000"`
+		CoverImageURL *string `json:"cover_image_url" validate:"omitempty,url,max=500"`
+	}
+
+	var req UpdateSeriesRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	opts := []models.SeriesOption{}
+	if req.Title != nil {
+		if err := filterContent(c, contentField{Name: "title", Field: contentfilter.FieldPostTitle, Text: *req.Title}); err != nil {
+			return err
+		}
+		opts = append(opts, models.WithSeriesTitle(*req.Title))
+	}
+	if req.Slug != nil {
+		opts = append(opts, models.WithSeriesSlug(*req.Slug))
+	}
+	if req.Description != nil {
+		opts = append(opts, models.WithSeriesDescription(*req.Description))
+	}
+	if req.CoverImageURL != nil {
+		opts = append(opts, models.WithSeriesCoverImageURL(*req.CoverImageURL))
+	}
+
+	updated, err := models.UpdateSeries(c.Context(), Redis, DB, series.ID, opts...)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "series_id", series.ID).Logs("Failed to update series")
+		return apierror.From(err, "Failed to update series")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "series_id", series.ID).Logs("Series updated successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Series updated successfully",
+		"status":  fiber.StatusOK,
+		"series":  seriesResponse(updated),
+	})
+}
+
+// DeleteSeries deletes a series, leaving its posts in place
+func DeleteSeries(c *fiber.Ctx) error {
+	series, userID, err := managedSeries(c)
+	if series == nil {
+		return err
+	}
+
+	if err := models.DeleteSeries(c.Context(), Redis, DB, series.ID); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "series_id", series.ID).Logs("Failed to delete series")
+		return apierror.From(err, "Failed to delete series")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "series_id", series.ID).Logs("Series deleted successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Series deleted successfully",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// AddSeriesPost adds one of the series author's posts to a series, at the end or at a given
+// position
+func AddSeriesPost(c *fiber.Ctx) error {
+	series, userID, err := managedSeries(c)
+	if series == nil {
+		return err
+	}
+
+	type AddSeriesPostRequest struct {
+		PostID   string `json:"post_id" validate:"required,uuid"`
+		Position int    `json:"position" validate:"omitempty,min=1"`
+	}
+
+	var req AddSeriesPostRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	postID := uuid.MustParse(req.PostID)
+
+	if err := models.AddSeriesPost(c.Context(), Redis, DB, series.ID, postID, req.Position); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "series_id", series.ID, "post_id", postID).Logs("Failed to add post to series")
+		return apierror.From(err, "Failed to add post to series")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "series_id", series.ID, "post_id", postID).Logs("Post added to series")
+	return seriesPartsResponse(c, series.ID, fiber.StatusOK, "Post added to series successfully")
+}
+
+// RemoveSeriesPost takes a post out of a series
+func RemoveSeriesPost(c *fiber.Ctx) error {
+	series, userID, err := managedSeries(c)
+	if series == nil {
+		return err
+	}
+	postID, err := uuid.Parse(c.Params("post_id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("post_id", c.Params("post_id")).Logs("Invalid post ID")
+		return apierror.BadRequest("Invalid post ID")
+	}
+
+	if err := models.RemoveSeriesPost(c.Context(), Redis, DB, series.ID, postID); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "series_id", series.ID, "post_id", postID).Logs("Failed to remove post from series")
+		return apierror.From(err, "Failed to remove post from series")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "series_id", series.ID, "post_id", postID).Logs("Post removed from series")
+	return seriesPartsResponse(c, series.ID, fiber.StatusOK, "Post removed from series successfully")
+}
+
+// ReorderSeriesPosts sets the reading order of a series from the full list of its post IDs
+func ReorderSeriesPosts(c *fiber.Ctx) error {
+	series, userID, err := managedSeries(c)
+	if series == nil {
+		return err
+	}
+
+	type ReorderSeriesPostsRequest struct {
+		PostIDs []string `json:"post_ids" validate:"required,min=1,max=100,dive,uuid"`
+	}
+
+	var req ReorderSeriesPostsRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	postIDs := make([]uuid.UUID, len(req.PostIDs))
+	for i, id := range req.PostIDs {
+		postIDs[i] = uuid.MustParse(id)
+	}
+
+	if err := models.ReorderSeriesPosts(c.Context(), Redis, DB, series.ID, postIDs); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "series_id", series.ID).Logs("Failed to reorder series")
+		return apierror.From(err, "Failed to reorder series")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "series_id", series.ID).Logs("Series reordered")
+	return seriesPartsResponse(c, series.ID, fiber.StatusOK, "Series reordered successfully")
+}
@@ -0,0 +1,244 @@
+package v1
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/syndication"
+)
+
+// feedSize is the number of posts in a feed.
+const feedSize = 20
+
+// Sitemap limits on the pages of each kind, posts taking the rest of syndication.MaxSitemapURLs.
+const (
+	sitemapTags    = 1000
+	sitemapAuthors = 5000
+)
+
+// syndicated is a rendered feed or sitemap along with when its content last changed.
+type syndicated struct {
+	Body    string    `json:"body"`
+	Updated time.Time `json:"updated"`
+}
+
+// profileURL is the link to a user's profile on the site.
+func profileURL(username string) string {
+	return EmailCfg.AppURL + "/" + username
+}
+
+// tagURL is the link to a tag's page on the site.
+func tagURL(slug string) string {
+	return EmailCfg.AppURL + "/tags/" + slug
+}
+
+// sendSyndicated answers with a rendered feed or sitemap, or with 304 Not Modified when the
+// client's copy, named by If-None-Match or If-Modified-Since, is still current.
+func sendSyndicated(c *fiber.Ctx, doc *syndicated, contentType string) error {
+	sum := sha1.Sum([]byte(doc.Body))
+	c.Set(fiber.HeaderETag, `W/"`+hex.EncodeToString(sum[:])+`"`)
+	if !doc.Updated.IsZero() {
+		c.Set(fiber.HeaderLastModified, doc.Updated.UTC().Format(http.TimeFormat))
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=900")
+	if c.Fresh() {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Status(fiber.StatusOK).SendString(doc.Body)
+}
+
+// serveFeed answers with the feed of posts loaded by load, as RSS or, with ?format=atom, Atom.
+// Feeds are cached under scope along with tags for their owner.
+func serveFeed(c *fiber.Ctx, scope string, feed syndication.Feed, tags []string, load func(ctx context.Context) ([]models.Posts, error)) error {
+	format := c.Query("format", "rss")
+	contentType := syndication.ContentTypeRSS
+	switch format {
+	case "rss":
+		feed.Self = c.BaseURL() + c.Path()
+	case "atom":
+		contentType = syndication.ContentTypeAtom
+		feed.Self = c.BaseURL() + c.Path() + "?format=atom"
+	default:
+		return apierror.BadRequest("Format must be rss or atom")
+	}
+
+	doc, err := cache.Fetch(c.Context(), Redis, cache.SyndicationFeedKey(scope, format), Redis.TTL("syndication"), func(ctx context.Context) (*syndicated, []string, error) {
+		posts, err := load(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		feed.Items = make([]syndication.Item, len(posts))
+		for i := range posts {
+			post := &posts[i]
+			item := syndication.Item{
+				Title:       post.Title,
+				Link:        postURL(post.Slug),
+				Description: post.Excerpt,
+				Content:     renderContent(c, cache.PostHTMLKey(post.ID), post.Content, post.ContentFormat),
+				Author:      authorName(post),
+				Updated:     post.UpdatedAt,
+			}
+			if post.PublishedAt != nil {
+				item.Published = *post.PublishedAt
+			}
+			for _, tag := range post.Tags {
+				item.Categories = append(item.Categories, tag.Name)
+			}
+			if post.UpdatedAt.After(feed.Updated) {
+				feed.Updated = post.UpdatedAt
+			}
+			feed.Items[i] = item
+		}
+
+		var body []byte
+		if format == "atom" {
+			body, err = feed.Atom()
+		} else {
+			body, err = feed.RSS()
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return &syndicated{Body: string(body), Updated: feed.Updated}, tags, nil
+	})
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "scope", scope).Logs("Failed to render feed")
+		return postError(c, err, "Failed to render feed")
+	}
+	return sendSyndicated(c, doc, contentType)
+}
+
+// GetSiteFeed returns the latest published posts of the site as RSS or Atom
+func GetSiteFeed(c *fiber.Ctx) error {
+	feed := syndication.Feed{
+		Title:       providerName,
+		Link:        EmailCfg.AppURL,
+		Description: "Latest posts on " + providerName,
+	}
+	return serveFeed(c, "site", feed, []string{cache.PublishedPostsTag}, func(ctx context.Context) ([]models.Posts, error) {
+		posts, _, err := models.GetPublishedPosts(ctx, DB, nil, nil, 1, feedSize)
+		return posts, err
+	})
+}
+
+// GetUserRSSFeed returns the latest published posts of a user as RSS or Atom
+func GetUserRSSFeed(c *fiber.Ctx) error {
+	username, err := publicUsername(c, "GetUserRSSFeed")
+	if err != nil {
+		return err
+	}
+	user, err := getPublicUser(c, username)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "username", username).Logs("Failed to fetch user for feed")
+		return apierror.NotFound("User not found")
+	}
+
+	name := user.Profile.Name
+	if name == "" {
+		name = user.Username
+	}
+	feed := syndication.Feed{
+		Title:       name + " on " + providerName,
+		Link:        profileURL(user.Username),
+		Description: "Latest posts by " + name + " on " + providerName,
+	}
+	return serveFeed(c, "user:"+user.Username, feed, []string{cache.UserTag(user.ID)}, func(ctx context.Context) ([]models.Posts, error) {
+		posts, _, err := models.GetPublishedPosts(ctx, DB, &user.ID, nil, 1, feedSize)
+		return posts, err
+	})
+}
+
+// GetTagRSSFeed returns the latest published posts of a tag as RSS or Atom
+func GetTagRSSFeed(c *fiber.Ctx) error {
+	tag, err := approvedTag(c)
+	if tag == nil {
+		return err
+	}
+
+	feed := syndication.Feed{
+		Title:       "#" + tag.Name + " on " + providerName,
+		Link:        tagURL(tag.Slug),
+		Description: tag.ShortDescription,
+	}
+	if feed.Description == "" {
+		feed.Description = "Latest posts tagged #" + tag.Name + " on " + providerName
+	}
+	return serveFeed(c, "tag:"+tag.Slug, feed, []string{cache.TagsTag, cache.PublishedPostsTag}, func(ctx context.Context) ([]models.Posts, error) {
+		posts, _, err := models.GetTagPosts(ctx, DB, tag.ID, nil, 1, feedSize)
+		return posts, err
+	})
+}
+
+// BuildSitemap renders the sitemap of the site's public pages and stores it for GetSitemap. It
+// returns the number of pages listed.
+func BuildSitemap(ctx context.Context) (int, error) {
+	doc, count, err := buildSitemap(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := cache.Set(ctx, Redis, cache.SitemapKey, doc, Redis.TTL("sitemap")); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func buildSitemap(ctx context.Context) (*syndicated, int, error) {
+	tags, err := models.ListSitemapTags(ctx, DB, sitemapTags)
+	if err != nil {
+		return nil, 0, err
+	}
+	authors, err := models.ListSitemapAuthors(ctx, DB, sitemapAuthors)
+	if err != nil {
+		return nil, 0, err
+	}
+	posts, err := models.ListSitemapPosts(ctx, DB, syndication.MaxSitemapURLs-1-len(tags)-len(authors))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	urls := make([]syndication.URL, 0, 1+len(posts)+len(tags)+len(authors))
+	urls = append(urls, syndication.URL{Loc: EmailCfg.AppURL + "/", ChangeFreq: "hourly", Priority: 1})
+	updated := time.Time{}
+	for _, post := range posts {
+		urls = append(urls, syndication.URL{Loc: postURL(post.Slug), LastMod: post.UpdatedAt, ChangeFreq: "weekly", Priority: 0.8})
+		if post.UpdatedAt.After(updated) {
+			updated = post.UpdatedAt
+		}
+	}
+	for _, tag := range tags {
+		urls = append(urls, syndication.URL{Loc: tagURL(tag.Slug), LastMod: tag.UpdatedAt, ChangeFreq: "daily", Priority: 0.6})
+	}
+	for _, author := range authors {
+		urls = append(urls, syndication.URL{Loc: profileURL(author.Slug), LastMod: author.UpdatedAt, ChangeFreq: "weekly", Priority: 0.5})
+	}
+
+	body, err := syndication.Sitemap(urls)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &syndicated{Body: string(body), Updated: updated}, len(urls), nil
+}
+
+// GetSitemap returns the sitemap built by the background job, building it on the spot when the
+// job has not run yet
+func GetSitemap(c *fiber.Ctx) error {
+	doc, ok, err := cache.Get[*syndicated](c.Context(), Redis, cache.SitemapKey)
+	if err != nil || !ok {
+		if err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err).Logs("Failed to read cached sitemap")
+		}
+		if doc, _, err = buildSitemap(c.Context()); err != nil {
+			Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to build sitemap")
+			return postError(c, err, "Failed to build sitemap")
+		}
+	}
+	return sendSyndicated(c, doc, syndication.ContentTypeSitemap)
+}
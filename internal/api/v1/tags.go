@@ -0,0 +1,375 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// tagResponse is the public representation of a tag
+func tagResponse(tag *models.Tag) fiber.Map {
+	return fiber.Map{
+		"id":                tag.ID,
+		"name":              tag.Name,
+		"slug":              tag.Slug,
+		"description":       tag.Description,
+		"short_description": tag.ShortDescription,
+		"icon_url":          tag.IconURL,
+		"background_url":    tag.BackgroundURL,
+		"text_color":        tag.TextColor,
+		"background_color":  tag.BackgroundColor,
+		"rules":             tag.Rules,
+		"is_featured":       tag.IsFeatured,
+		"is_moderated":      tag.IsModerated,
+		"posts_count":       tag.PostsCount,
+		"followers_count":   tag.FollowersCount,
+		"created_at":        tag.CreatedAt,
+	}
+}
+
+func tagsResponse(tags []models.Tag) []fiber.Map {
+	res := make([]fiber.Map, len(tags))
+	for i := range tags {
+		res[i] = tagResponse(&tags[i])
+	}
+	return res
+}
+
+// tagSummaries is the short form of tags embedded in posts
+func tagSummaries(tags []models.Tag) []fiber.Map {
+	res := make([]fiber.Map, len(tags))
+	for i, tag := range tags {
+		res[i] = fiber.Map{
+			"id":               tag.ID,
+			"name":             tag.Name,
+			"slug":             tag.Slug,
+			"text_color":       tag.TextColor,
+			"background_color": tag.BackgroundColor,
+		}
+	}
+	return res
+}
+
+// approvedTag loads the approved tag named by the :slug param. On failure the tag is nil and the
+// error answers the request.
+func approvedTag(c *fiber.Ctx) (*models.Tag, error) {
+	slug := strings.ToLower(strings.TrimSpace(c.Params("slug")))
+	tag, err := models.GetTagBy(c.Context(), Redis, DB, "slug = ?", []interface{}{slug})
+	if err == nil && !tag.IsApproved {
+		err = utils.NewError(utils.ErrNotFound.Code, "Tag not found")
+	}
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "slug", slug).Logs("Failed to fetch tag")
+		return nil, postError(c, err, "Failed to fetch tag")
+	}
+	return tag, nil
+}
+
+// ListTags returns the most followed tags
+func ListTags(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	tags, err := models.GetPopularTags(c.Context(), Redis, DB, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch tags")
+		return postError(c, err, "Failed to fetch tags")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Tags retrieved successfully",
+		"status":  fiber.StatusOK,
+		"tags":    tagsResponse(tags),
+	})
+}
+
+// GetTag returns a tag by its slug
+func GetTag(c *fiber.Ctx) error {
+	tag, err := approvedTag(c)
+	if tag == nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Tag retrieved successfully",
+		"status":  fiber.StatusOK,
+		"tag":     tagResponse(tag),
+	})
+}
+
+// GetTagFeed lists the published posts of a tag, newest first
+func GetTagFeed(c *fiber.Ctx) error {
+	tag, err := approvedTag(c)
+	if tag == nil {
+		return err
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	posts, total, err := models.GetTagPosts(c.Context(), DB, tag.ID, viewerID(c), page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "tag_id", tag.ID).Logs("Failed to fetch tag posts")
+		return postError(c, err, "Failed to fetch posts")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Posts retrieved successfully",
+		"status":  fiber.StatusOK,
+		"tag":     tagResponse(tag),
+		"posts":   postsResponse(posts),
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
+
+// CreateTag creates an approved tag
+func CreateTag(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("CreateTag attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	type CreateTagRequest struct {
+		Name             string `json:"name" validate:"required,min=2,max=30,alphanumunicode"`
+		Slug             string `json:"slug" validate:"omitempty,max=35,slug"`
+		Description      string `json:"description" validate:"omitempty,max=200"`
+		ShortDescription string `json:"short_description" validate:"omitempty,max=100"`
+		IconURL          string `json:"icon_url" validate:"omitempty,url,max=500"`
+		BackgroundURL    string `json:"background_url" validate:"omitempty,url,max=500"`
+		TextColor        string `json:"text_color" validate:"omitempty,hexcolor,max=7"`
+		BackgroundColor  string `json:"background_color" validate:"omitempty,hexcolor,max=7"`
+		Rules            string `json:"rules" validate:"omitempty,max=500"`
+		IsModerated      bool   `json:"is_moderated"`
+	}
+
+	var req CreateTagRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	if req.Slug == "" {
+		req.Slug = utils.Slugify(req.Name, 35)
+	}
+
+	tag := &models.Tag{
+		Name:             req.Name,
+		Slug:             req.Slug,
+		Description:      req.Description,
+		ShortDescription: req.ShortDescription,
+		IconURL:          req.IconURL,
+		BackgroundURL:    req.BackgroundURL,
+		TextColor:        req.TextColor,
+		BackgroundColor:  req.BackgroundColor,
+		Rules:            req.Rules,
+		IsModerated:      req.IsModerated,
+		IsApproved:       true,
+	}
+	if err := models.CreateTag(c.Context(), Redis, DB, tag); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Failed to create tag")
+		return postError(c, err, "Failed to create tag")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userIDRaw, "tag_id", tag.ID).Logs("Tag created successfully")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Tag created successfully",
+		"status":  fiber.StatusCreated,
+		"tag":     tagResponse(tag),
+	})
+}
+
+// UpdateTag edits a tag. Holders of edit_tag may edit any tag; holders of moderate_tag only the
+// tags they moderate, and they cannot change whether a tag is featured or moderated.
+func UpdateTag(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("UpdateTag attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in UpdateTag")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	tag, err := approvedTag(c)
+	if tag == nil {
+		return err
+	}
+
+	type UpdateTagRequest struct {
+		Name             *string `json:"name" validate:"omitempty,min=2,max=30,alphanumunicode"`
+		Description      *string `json:"description" validate:"omitempty,max=200"`
+		ShortDescription *string `json:"short_description" validate:"omitempty,max=100"`
+		IconURL          *string `json:"icon_url" validate:"omitempty,url,max=500"`
+		BackgroundURL    *string `json:"background_url" validate:"omitempty,url,max=500"`
+		TextColor        *string `json:"text_color" validate:"omitempty,hexcolor,max=7"`
+		BackgroundColor  *string `json:"background_color" validate:"omitempty,hexcolor,max=7"`
+		Rules            *string `json:"rules" validate:"omitempty,max=500"`
+		IsFeatured       *bool   `json:"is_featured"`
+		IsModerated      *bool   `json:"is_moderated"`
+	}
+
+	var req UpdateTagRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	editor, err := hasPermission(c, userID, "edit_tag")
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user in UpdateTag")
+		return postError(c, err, "Failed to update tag")
+	}
+	if !editor {
+		moderator, err := models.IsModerator(c.Context(), Redis, DB, tag.ID, userID)
+		if err != nil {
+			Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to check tag moderator")
+			return postError(c, err, "Failed to update tag")
+		}
+		if !moderator || req.IsFeatured != nil || req.IsModerated != nil {
+			Logger.Warn(c.Context()).WithFields("user_id", userID, "tag_id", tag.ID).Logs("User is not allowed to edit tag")
+			return apierror.Forbidden("You can only edit the tags you moderate")
+		}
+	}
+
+	var opts []models.TagOption
+	if req.Name != nil {
+		opts = append(opts, models.WithTagName(strings.TrimSpace(*req.Name)))
+	}
+	if req.Description != nil {
+		opts = append(opts, models.WithTagDescription(*req.Description))
+	}
+	if req.ShortDescription != nil {
+		opts = append(opts, models.WithTagShortDescription(*req.ShortDescription))
+	}
+	if req.IconURL != nil {
+		opts = append(opts, models.WithTagIconURL(*req.IconURL))
+	}
+	if req.BackgroundURL != nil {
+		opts = append(opts, models.WithTagBackgroundURL(*req.BackgroundURL))
+	}
+	if req.TextColor != nil {
+		opts = append(opts, models.WithTagTextColor(*req.TextColor))
+	}
+	if req.BackgroundColor != nil {
+		opts = append(opts, models.WithTagBackgroundColor(*req.BackgroundColor))
+	}
+	if req.Rules != nil {
+		opts = append(opts, models.WithTagRules(*req.Rules))
+	}
+	if req.IsFeatured != nil {
+		opts = append(opts, models.WithTagIsFeatured(*req.IsFeatured))
+	}
+	if req.IsModerated != nil {
+		opts = append(opts, models.WithTagIsModerated(*req.IsModerated))
+	}
+
+	updated, err := models.UpdateTag(c.Context(), Redis, DB, tag.ID, opts...)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "tag_id", tag.ID).Logs("Failed to update tag")
+		return postError(c, err, "Failed to update tag")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "tag_id", tag.ID).Logs("Tag updated successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Tag updated successfully",
+		"status":  fiber.StatusOK,
+		"tag":     tagResponse(updated),
+	})
+}
+
+// FollowTag makes the authenticated user follow a tag
+func FollowTag(c *fiber.Ctx) error {
+	return setTagFollowState(c, true)
+}
+
+// UnfollowTag makes the authenticated user stop following a tag
+func UnfollowTag(c *fiber.Ctx) error {
+	return setTagFollowState(c, false)
+}
+
+func setTagFollowState(c *fiber.Ctx, follow bool) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("Tag follow attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in tag follow")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	tag, err := approvedTag(c)
+	if tag == nil {
+		return err
+	}
+
+	message := "Tag unfollowed successfully"
+	if follow {
+		message = "Tag followed successfully"
+		err = models.FollowTag(c.Context(), Redis, DB, tag.ID, []uuid.UUID{userID})
+	} else {
+		err = models.UnfollowTag(c.Context(), Redis, DB, tag.ID, []uuid.UUID{userID})
+	}
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID, "tag_id", tag.ID).Logs("Failed to update tag follow state")
+		return postError(c, err, "Failed to update tag follow state")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "tag_id", tag.ID).Logs(message)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":   message,
+		"status":    fiber.StatusOK,
+		"following": follow,
+	})
+}
+
+// GetMyTags lists the tags the authenticated user follows
+func GetMyTags(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetMyTags attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetMyTags")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	tags, err := models.GetFollowedTags(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch followed tags")
+		return postError(c, err, "Failed to fetch tags")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Tags retrieved successfully",
+		"status":  fiber.StatusOK,
+		"tags":    tagsResponse(tags),
+	})
+}
@@ -0,0 +1,246 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+const backupCodeCount = 10
+
+// verifySecondFactor checks a TOTP code, or failing that a backup code, for a user with two-factor
+// enabled. Each TOTP code is accepted only once.
+func verifySecondFactor(c *fiber.Ctx, u *models.User, code string) (bool, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if len(code) == 6 {
+		secret, err := utils.DecryptSecret(TwoFactorKey, u.TwoFactorSecret)
+		if err != nil {
+			return false, err
+		}
+		step, ok := utils.ValidateTOTP(secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return Redis.SetNX(c.Context(), fmt.Sprintf("2fa_used:%s:%d", u.ID, step), 1, 2*time.Minute).Result()
+	}
+	return models.ConsumeBackupCode(c.Context(), Redis, DB, u, code)
+}
+
+// EnableTwoFactor starts two-factor enrollment by generating a TOTP secret for the authenticated user
+func EnableTwoFactor(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("EnableTwoFactor attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in EnableTwoFactor")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	if len(TwoFactorKey) == 0 {
+		Logger.Error(c.Context()).Logs("Two-factor enrollment attempted without an encryption key configured")
+		return apierror.Unavailable("Two-factor authentication is not configured")
+	}
+
+	type EnableRequest struct {
+		Password string `json:"password" validate:"required,min=6,max=100"`
+	}
+	var req EnableRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	user, err := models.GetTwoFactorUser(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user in EnableTwoFactor")
+		return apierror.Internal("Failed to enable two-factor authentication")
+	}
+	if user.TwoFactorEnabled {
+		return apierror.Conflict("Two-factor authentication is already enabled")
+	}
+	if err := utils.ComparePasswords(user.Password, req.Password); err != nil {
+		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Invalid password in EnableTwoFactor")
+		return apierror.Unauthorized("Invalid password")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to generate TOTP secret")
+		return apierror.Internal("Failed to enable two-factor authentication")
+	}
+	encrypted, err := utils.EncryptSecret(TwoFactorKey, secret)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to encrypt TOTP secret")
+		return apierror.Internal("Failed to enable two-factor authentication")
+	}
+	if err := models.SetTwoFactorSecret(c.Context(), Redis, DB, userID, encrypted); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to store TOTP secret")
+		return apierror.Internal("Failed to enable two-factor authentication")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("Two-factor enrollment started")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Add the secret to your authenticator app and verify a code to finish enabling two-factor authentication",
+		"status":      fiber.StatusOK,
+		"secret":      secret,
+		"otpauth_url": utils.TOTPURL("DevPulse", user.Email, secret),
+	})
+}
+
+// VerifyTwoFactor completes enrollment with a code from the authenticator app and returns backup codes
+func VerifyTwoFactor(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("VerifyTwoFactor attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in VerifyTwoFactor")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	type VerifyRequest struct {
+		Code string `json:"code" validate:"required,len=6,numeric"`
+	}
+	var req VerifyRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	user, err := models.GetTwoFactorUser(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user in VerifyTwoFactor")
+		return apierror.Internal("Failed to verify two-factor authentication")
+	}
+	if user.TwoFactorEnabled {
+		return apierror.Conflict("Two-factor authentication is already enabled")
+	}
+	if user.TwoFactorSecret == "" {
+		return apierror.BadRequest("Start two-factor enrollment first")
+	}
+
+	valid, err := verifySecondFactor(c, user, req.Code)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to check two-factor code")
+		return apierror.Internal("Failed to verify two-factor authentication")
+	}
+	if !valid {
+		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Invalid two-factor code in VerifyTwoFactor")
+		return apierror.Unauthorized("Invalid two-factor code")
+	}
+
+	codes, err := utils.GenerateBackupCodes(backupCodeCount)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to generate backup codes")
+		return apierror.Internal("Failed to verify two-factor authentication")
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		if hashes[i], err = utils.HashPassword(code); err != nil {
+			Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to hash backup code")
+			return apierror.Internal("Failed to verify two-factor authentication")
+		}
+	}
+
+	if err := models.EnableTwoFactor(c.Context(), Redis, DB, userID, hashes); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to enable two-factor authentication")
+		return apierror.Internal("Failed to verify two-factor authentication")
+	}
+	if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventTwoFactorEnabled, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record two-factor enabled")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("Two-factor authentication enabled")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":      "Two-factor authentication enabled. Store the backup codes somewhere safe, they are shown only once.",
+		"status":       fiber.StatusOK,
+		"backup_codes": codes,
+	})
+}
+
+// DisableTwoFactor turns off two-factor authentication after checking the password and a code
+func DisableTwoFactor(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("DisableTwoFactor attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in DisableTwoFactor")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	type DisableRequest struct {
+		Password string `json:"password" validate:"required,min=6,max=100"`
+		Code     string `json:"code" validate:"required,max=20"`
+	}
+	var req DisableRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	user, err := models.GetTwoFactorUser(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user in DisableTwoFactor")
+		return apierror.Internal("Failed to disable two-factor authentication")
+	}
+	if !user.TwoFactorEnabled {
+		return apierror.BadRequest("Two-factor authentication is not enabled")
+	}
+	if err := utils.ComparePasswords(user.Password, req.Password); err != nil {
+		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Invalid password in DisableTwoFactor")
+		return apierror.Unauthorized("Invalid password")
+	}
+
+	valid, err := verifySecondFactor(c, user, req.Code)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to check two-factor code")
+		return apierror.Internal("Failed to disable two-factor authentication")
+	}
+	if !valid {
+		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Invalid two-factor code in DisableTwoFactor")
+		return apierror.Unauthorized("Invalid two-factor code")
+	}
+
+	if err := models.DisableTwoFactor(c.Context(), Redis, DB, userID); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to disable two-factor authentication")
+		return apierror.Internal("Failed to disable two-factor authentication")
+	}
+	if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventTwoFactorDisabled, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record two-factor disabled")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("Two-factor authentication disabled")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Two-factor authentication disabled",
+		"status":  fiber.StatusOK,
+	})
+}
@@ -0,0 +1,297 @@
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/uploads"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// signedUploadURLTTL is how long a signed upload URL stays valid unless the client asks otherwise.
+const signedUploadURLTTL = 15 * time.Minute
+
+var (
+	// Uploads stores uploaded files; uploads are unavailable without it.
+	Uploads uploads.Driver
+	// ImageUploadPolicy limits the size of uploaded images per content type.
+	ImageUploadPolicy = utils.UploadPolicy{
+		MaxSize: map[string]int64{
+			"image/jpeg": 8 << 20,
+			"image/png":  8 << 20,
+			"image/gif":  4 << 20,
+		},
+		AsyncScanSize: 2 << 20,
+	}
+)
+
+// uploadResponse is the representation of an upload shown to its owner. Uploads the content
+// scanner has not cleared have no url.
+func uploadResponse(upload *models.Upload) fiber.Map {
+	var url interface{}
+	if upload.Status == models.UploadClean {
+		url = Uploads.URL(upload.Key)
+	}
+	return fiber.Map{
+		"id":           upload.ID,
+		"kind":         upload.Kind,
+		"url":          url,
+		"status":       upload.Status,
+		"scan_reason":  upload.ScanReason,
+		"content_type": upload.ContentType,
+		"size":         upload.Size,
+		"width":        upload.Width,
+		"height":       upload.Height,
+		"created_at":   upload.CreatedAt,
+	}
+}
+
+// CreateUpload stores an image sent as the multipart "file" field. The "kind" field, avatar or
+// cover, decides how the image is cropped and resized; every image is converted to JPEG. The
+// returned url can be used as the avatar_url of a profile or the cover_image_url of a post.
+// Files over the policy's AsyncScanSize are quarantined and scanned in the background: they are
+// answered with 202 and no url, which GetUpload returns once the scanner cleared them.
+func CreateUpload(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("CreateUpload attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in CreateUpload")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	if Uploads == nil {
+		Logger.Error(c.Context()).Logs("Upload attempted without a storage driver configured")
+		return apierror.Unavailable("Uploads are not configured")
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(c.FormValue("kind")))
+	if kind != uploads.KindAvatar && kind != uploads.KindCover {
+		return apierror.BadRequest("Kind must be avatar or cover")
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Upload without a file")
+		return apierror.BadRequest("A file is required")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to open uploaded file")
+		return apierror.Internal("Failed to read upload")
+	}
+	defer file.Close()
+	// Read one byte past the largest limit so oversized files are caught without reading them whole
+	data, err := io.ReadAll(io.LimitReader(file, 8<<20+1))
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to read uploaded file")
+		return apierror.Internal("Failed to read upload")
+	}
+
+	// The type is sniffed from the content; the type the client declared is not trusted
+	contentType, err := uploads.SniffImage(data)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("user_id", userID, "content_type", contentType).Logs("Unsupported upload type")
+		return apierror.New(fiber.StatusUnsupportedMediaType, "Only JPEG, PNG and GIF images are supported")
+	}
+	scanLater := ImageUploadPolicy.ScanLater(int64(len(data)))
+	if scanLater {
+		err = utils.CheckUploadSize(ImageUploadPolicy, header.Filename, contentType, int64(len(data)))
+	} else {
+		err = utils.CheckUpload(c.Context(), ImageUploadPolicy, header.Filename, contentType, int64(len(data)), bytes.NewReader(data))
+	}
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Upload rejected")
+		return apierror.From(err, "Upload rejected")
+	}
+
+	img, err := uploads.ProcessImage(data, kind)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to process image")
+		if errors.Is(err, uploads.ErrImageTooLarge) {
+			return apierror.New(fiber.StatusUnprocessableEntity, "Image dimensions are too large")
+		}
+		return apierror.New(fiber.StatusUnprocessableEntity, "Image could not be processed")
+	}
+
+	upload := &models.Upload{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Kind:        kind,
+		ContentType: img.ContentType,
+		Size:        len(img.Data),
+		Width:       img.Width,
+		Height:      img.Height,
+	}
+	if scanLater {
+		return quarantineUpload(c, upload, img.Data, data, header.Filename, contentType)
+	}
+	upload.Key = models.UploadKey(kind, upload.ID)
+	if err := Uploads.Put(c.Context(), upload.Key, img.ContentType, img.Data); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID, "key", upload.Key).Logs("Failed to store upload")
+		return apierror.Internal("Failed to store upload")
+	}
+	if err := models.CreateUpload(c.Context(), DB, upload); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record upload")
+		if err := Uploads.Delete(c.Context(), upload.Key); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "key", upload.Key).Logs("Failed to remove unrecorded upload")
+		}
+		return apierror.From(err, "Failed to store upload")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "upload_id", upload.ID, "kind", kind).Logs("Upload stored")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Upload stored successfully",
+		"status":  fiber.StatusCreated,
+		"upload":  uploadResponse(upload),
+	})
+}
+
+// quarantineUpload stores an upload to be scanned in the background and records it. Its files are
+// kept under the quarantine prefix, which is never served, until the scanner clears them.
+func quarantineUpload(c *fiber.Ctx, upload *models.Upload, img, original []byte, filename, contentType string) error {
+	if err := models.StoreQuarantined(c.Context(), Uploads, upload.ID, upload.ContentType, img, original); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", upload.UserID, "upload_id", upload.ID).Logs("Failed to store quarantined upload")
+		return apierror.Internal("Failed to store upload")
+	}
+	if err := models.QuarantineUpload(c.Context(), DB, upload, filename, contentType); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", upload.UserID).Logs("Failed to record upload")
+		processed, orig := models.QuarantineKeys(upload.ID)
+		if err := errors.Join(Uploads.Delete(c.Context(), processed), Uploads.Delete(c.Context(), orig)); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "upload_id", upload.ID).Logs("Failed to remove unrecorded upload")
+		}
+		return apierror.From(err, "Failed to store upload")
+	}
+	wakeOutbox()
+
+	Logger.Info(c.Context()).WithFields("user_id", upload.UserID, "upload_id", upload.ID, "kind", upload.Kind).Logs("Upload quarantined for scanning")
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Upload stored and waiting for a content scan",
+		"status":  fiber.StatusAccepted,
+		"upload":  uploadResponse(upload),
+	})
+}
+
+// GetUpload returns one of the authenticated user's uploads, with its scan status.
+func GetUpload(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetUpload attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetUpload")
+		return apierror.BadRequest("Invalid user ID")
+	}
+	if Uploads == nil {
+		return apierror.Unavailable("Uploads are not configured")
+	}
+	uploadID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid upload ID")
+	}
+
+	upload, err := models.GetUpload(c.Context(), DB, userID, uploadID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "upload_id", uploadID).Logs("Failed to fetch upload")
+		return apierror.From(err, "Failed to fetch upload")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Upload retrieved successfully",
+		"status":  fiber.StatusOK,
+		"upload":  uploadResponse(upload),
+	})
+}
+
+// GetUploadURL returns a signed, time-limited URL of one of the authenticated user's uploads.
+// ?expires_in= sets its lifetime in seconds, 15 minutes by default and at most 7 days.
+func GetUploadURL(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetUploadURL attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetUploadURL")
+		return apierror.BadRequest("Invalid user ID")
+	}
+	if Uploads == nil {
+		return apierror.Unavailable("Uploads are not configured")
+	}
+	uploadID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid upload ID")
+	}
+
+	ttl := signedUploadURLTTL
+	if raw := c.QueryInt("expires_in"); raw != 0 {
+		ttl = time.Duration(raw) * time.Second
+		if ttl <= 0 || ttl > uploads.MaxSignedURLTTL {
+			return apierror.BadRequest("expires_in must be between 1 second and 7 days")
+		}
+	}
+
+	upload, err := models.GetUpload(c.Context(), DB, userID, uploadID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "upload_id", uploadID).Logs("Failed to fetch upload")
+		return apierror.From(err, "Failed to fetch upload")
+	}
+	switch upload.Status {
+	case models.UploadQuarantined:
+		return apierror.Conflict("Upload is waiting for a content scan")
+	case models.UploadRejected:
+		return apierror.New(fiber.StatusUnprocessableEntity, "Upload was rejected by the content scanner")
+	}
+	signed, err := Uploads.SignedURL(upload.Key, ttl)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "upload_id", uploadID).Logs("Failed to sign upload URL")
+		return apierror.Internal("Failed to sign upload URL")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":    "Signed URL generated successfully",
+		"status":     fiber.StatusOK,
+		"url":        signed,
+		"expires_at": time.Now().Add(ttl),
+	})
+}
+
+// ServeUpload serves files kept by the local storage driver. A signed URL is refused once it has
+// expired or when its signature does not match.
+func ServeUpload(c *fiber.Ctx) error {
+	local, ok := Uploads.(*uploads.LocalDriver)
+	if !ok {
+		return apierror.NotFound("File not found")
+	}
+	key := c.Params("*")
+	// Export archives are private; they are downloaded through their owner's export. Quarantined
+	// uploads are not served until the scanner clears them
+	if strings.HasPrefix(key, models.ExportKeyPrefix) || strings.HasPrefix(key, models.QuarantineKeyPrefix) {
+		return apierror.NotFound("File not found")
+	}
+	if signature := c.Query("signature"); signature != "" && !local.Verify(key, c.Query("expires"), signature) {
+		Logger.Warn(c.Context()).WithFields("key", key).Logs("Invalid or expired signed upload URL")
+		return apierror.Forbidden("Link is invalid or has expired")
+	}
+
+	file, err := local.Open(c.Context(), key)
+	if err != nil {
+		return apierror.NotFound("File not found")
+	}
+	c.Set(fiber.HeaderContentType, "image/jpeg")
+	c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	c.Set("X-Content-Type-Options", "nosniff")
+	return c.SendStream(file)
+}
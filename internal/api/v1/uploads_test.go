@@ -0,0 +1,177 @@
+package v1_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	v1 "github.com/mnuddindev/devpulse/internal/api/v1"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// signature is what the stub scanner flags.
+var signature = []byte("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*")
+
+// stubScanner makes the uploads of the test go through a scanner flagging signature.
+func stubScanner(t *testing.T) {
+	t.Helper()
+	previous := utils.Scanner
+	utils.Scanner = utils.StubScanner{Signature: signature, Reason: "EICAR test file"}
+	t.Cleanup(func() { utils.Scanner = previous })
+}
+
+// pngFile returns a small PNG padded to size bytes, ending with tail. Decoders stop at the end of
+// the image, so the padding does not keep it from being processed.
+func pngFile(t *testing.T, size int, tail []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+	if pad := size - buf.Len() - len(tail); pad > 0 {
+		buf.Write(make([]byte, pad))
+	}
+	buf.Write(tail)
+	return buf.Bytes()
+}
+
+// uploadRequest is a multipart upload of file as an avatar.
+func uploadRequest(t *testing.T, token string, file []byte) testutil.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.WriteField("kind", "avatar"); err != nil {
+		t.Fatal(err)
+	}
+	part, err := w.CreateFormFile("file", "avatar.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(file)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return testutil.Request{
+		Method: http.MethodPost,
+		Path:   "/uploads",
+		Token:  token,
+		Raw:    body.Bytes(),
+		Header: map[string]string{"Content-Type": w.FormDataContentType()},
+	}
+}
+
+type uploadBody struct {
+	Upload struct {
+		ID         string  `json:"id"`
+		URL        *string `json:"url"`
+		Status     string  `json:"status"`
+		ScanReason string  `json:"scan_reason"`
+	} `json:"upload"`
+}
+
+func TestCreateUploadScansSmallFiles(t *testing.T) {
+	testutil.Run(t, []testutil.Case{
+		{
+			Name: "clean file is stored",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				stubScanner(t)
+				*req = uploadRequest(t, e.Token(t, e.CreateUser(t, "member")), pngFile(t, 0, nil))
+			},
+			Status: http.StatusCreated,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var body uploadBody
+				res.JSON(t, &body)
+				if body.Upload.Status != models.UploadClean || body.Upload.URL == nil {
+					t.Fatalf("upload %+v, want a clean upload with a url", body.Upload)
+				}
+			},
+		},
+		{
+			Name: "flagged file is rejected",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				stubScanner(t)
+				*req = uploadRequest(t, e.Token(t, e.CreateUser(t, "member")), pngFile(t, 0, signature))
+			},
+			Status: http.StatusUnprocessableEntity,
+			Check: func(t *testing.T, e *testutil.Env, res *testutil.Response) {
+				var n int64
+				if err := e.DB.Model(&models.Upload{}).Count(&n).Error; err != nil {
+					t.Fatal(err)
+				}
+				if n != 0 {
+					t.Fatalf("%d uploads recorded, want none", n)
+				}
+			},
+		},
+	})
+}
+
+func TestCreateUploadQuarantinesLargeFiles(t *testing.T) {
+	cases := []struct {
+		name   string
+		tail   []byte
+		status string
+	}{
+		{name: "clean file is released", status: models.UploadClean},
+		{name: "flagged file is rejected", tail: signature, status: models.UploadRejected},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := testutil.Setup(t)
+			stubScanner(t)
+			token := e.Token(t, e.CreateUser(t, "member"))
+
+			file := pngFile(t, int(v1.ImageUploadPolicy.AsyncScanSize)+1024, tc.tail)
+			res := e.Do(t, uploadRequest(t, token, file))
+			if res.Status != http.StatusAccepted {
+				t.Fatalf("POST /uploads: status %d, want %d: %s", res.Status, http.StatusAccepted, res.Body)
+			}
+			var created uploadBody
+			res.JSON(t, &created)
+			if created.Upload.Status != models.UploadQuarantined || created.Upload.URL != nil {
+				t.Fatalf("upload %+v, want a quarantined upload without a url", created.Upload)
+			}
+
+			// Quarantined files are neither served nor signed
+			processed, _ := models.QuarantineKeys(mustUUID(t, created.Upload.ID))
+			if res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/uploads/files/" + processed}); res.Status != http.StatusNotFound {
+				t.Fatalf("GET quarantined file: status %d, want %d", res.Status, http.StatusNotFound)
+			}
+			if res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/uploads/" + created.Upload.ID + "/url", Token: token}); res.Status != http.StatusConflict {
+				t.Fatalf("GET quarantined upload url: status %d, want %d", res.Status, http.StatusConflict)
+			}
+
+			// The outbox worker may get to the scan first; scanning again is a no-op
+			event := &models.UploadScanEvent{UploadID: mustUUID(t, created.Upload.ID), Filename: "avatar.png", ContentType: "image/png"}
+			if err := models.ScanQuarantinedUpload(context.Background(), e.DB, v1.Uploads, utils.Scanner, event); err != nil {
+				t.Fatalf("ScanQuarantinedUpload: %v", err)
+			}
+
+			res = e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/uploads/" + created.Upload.ID, Token: token})
+			if res.Status != http.StatusOK {
+				t.Fatalf("GET /uploads/:id: status %d: %s", res.Status, res.Body)
+			}
+			var scanned uploadBody
+			res.JSON(t, &scanned)
+			if scanned.Upload.Status != tc.status {
+				t.Fatalf("upload status %q, want %q", scanned.Upload.Status, tc.status)
+			}
+			if tc.status == models.UploadClean {
+				if scanned.Upload.URL == nil {
+					t.Fatal("released upload has no url")
+				}
+				if res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/uploads/files/" + models.UploadKey("avatar", event.UploadID)}); res.Status != http.StatusOK {
+					t.Fatalf("GET released file: status %d, want %d", res.Status, http.StatusOK)
+				}
+			} else if scanned.Upload.URL != nil || scanned.Upload.ScanReason != "EICAR test file" {
+				t.Fatalf("rejected upload %+v, want no url and the scanner's reason", scanned.Upload)
+			}
+		})
+	}
+}
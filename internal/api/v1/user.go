@@ -2,6 +2,7 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,41 +11,52 @@ import (
 	"github.com/google/uuid"
 	"github.com/mnuddindev/devpulse/internal/auth"
 	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/services"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/contentfilter"
+	"github.com/mnuddindev/devpulse/pkg/i18n"
+	"github.com/mnuddindev/devpulse/pkg/metrics"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
-	"github.com/redis/go-redis/v9"
-	"gorm.io/gorm"
 )
 
-func RateLimitting(c *fiber.Ctx, userID string, rateTTL time.Duration, maxUpdates int, prefix string) bool {
-	rateKey := prefix + userID
-	count, err := Redis.Get(c.Context(), rateKey).Int()
-	if err == redis.Nil {
-		count = 0
-	} else if err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to check rate limit")
-	}
-	if count >= maxUpdates {
-		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Rate limit exceeded")
-		return false
+// checkFieldIntervals returns the first field changed too recently and when it may change again
+func checkFieldIntervals(c *fiber.Ctx, userID string, fields []string) (string, time.Time, bool) {
+	for _, field := range fields {
+		interval := ProfileFieldIntervals[field]
+		if interval <= 0 {
+			continue
+		}
+		last, err := Redis.Get(c.Context(), "profile_field:"+userID+":"+field).Int64()
+		if err != nil {
+			continue
+		}
+		if next := time.Unix(last, 0).Add(interval); time.Now().Before(next) {
+			return field, next, false
+		}
 	}
+	return "", time.Time{}, true
+}
 
-	pipe := Redis.TxPipeline()
-	pipe.Incr(c.Context(), rateKey)
-	pipe.Expire(c.Context(), rateKey, rateTTL)
-	if _, err := pipe.Exec(c.Context()); err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to update rate limit")
-
+// markFieldUpdates records when interval-limited fields were last changed
+func markFieldUpdates(c *fiber.Ctx, userID string, fields []string) {
+	now := time.Now().Unix()
+	for _, field := range fields {
+		interval := ProfileFieldIntervals[field]
+		if interval <= 0 {
+			continue
+		}
+		if err := Redis.Set(c.Context(), "profile_field:"+userID+":"+field, now, interval).Err(); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "field", field).Logs("Failed to record profile field update")
+		}
 	}
-	return true
 }
 
 func Register(c *fiber.Ctx) error {
 	if utils.IsLoggedIn(c) {
 		Logger.Warn(c.Context()).Logs("User already logged in, registration not allowed")
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error":   "Already logged in",
-			"message": "You are already authenticated. Please log out first if you want to register a new account.",
-		})
+		return apierror.Forbidden("Already logged in. Please log out first if you want to register a new account.")
 	}
 	type UserInput struct {
 		AvatarURL       string `json:"avatar_url" validate:"omitempty,url"`
@@ -53,105 +65,59 @@ func Register(c *fiber.Ctx) error {
 		Email           string `json:"email" validate:"required,email,max=100"`
 		Password        string `json:"password" validate:"required,min=6,eqfield=ConfirmPassword"`
 		ConfirmPassword string `json:"confirm_password" validate:"required,min=6"`
+		InvitationCode  string `json:"invitation_code" validate:"omitempty,max=32"`
 	}
 	ui := new(UserInput)
 	if err := utils.StrictBodyParser(c, &ui); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).Logs(fmt.Sprintf("Failed to parse request body: %v", err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request format",
-		})
+		return apierror.BadRequest("Invalid request format")
 	}
 
 	if err := Validator.Validate(ui); err != nil {
 		Logger.Warn(c.Context()).WithFields("errors", err).Logs(fmt.Sprintf("Validation failed: %s", err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   "Validation failed",
-			"details": err,
-		})
-	}
-
-	if utils.ContainsInvalidChars(ui.Password) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"Message": "password contains invalid characters",
-			"status":  fiber.StatusBadRequest,
-		})
-	}
-
-	ui.Email = strings.ToLower(strings.TrimSpace(ui.Email))
-
-	hashedPass, err := utils.HashPassword(ui.Password)
-	if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).Logs(fmt.Sprintf("Failed to hash password: %v", err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to process password",
-		})
-	}
-
-	gotp, err := utils.GenerateOTP(10)
-	if err != nil {
-		Logger.Error(c.Context()).Logs(fmt.Sprintf("Failed to generate OTP: %v", err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to generate activation code",
-		})
+		return apierror.Validation(err)
 	}
 
-	if !utils.IsStrongPassword(ui.Password) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"Message": "new password does not meet strength requirements",
-			"status":  fiber.StatusBadRequest,
-		})
+	if err := filterContent(c,
+		contentField{Name: "username", Field: contentfilter.FieldUsername, Text: ui.Username},
+		contentField{Name: "name", Field: contentfilter.FieldName, Text: ui.Name},
+	); err != nil {
+		return err
 	}
 
-	user, err := models.NewUser(c.Context(), Redis, DB, ui.Username, ui.Email, hashedPass, gotp, models.WithName(ui.Name), models.WithAvatarURL(ui.AvatarURL))
+	user, err := Services.Auth.Register(c.Context(), services.Registration{
+		Username:       ui.Username,
+		Email:          ui.Email,
+		Password:       ui.Password,
+		Name:           ui.Name,
+		AvatarURL:      ui.AvatarURL,
+		InvitationCode: ui.InvitationCode,
+		Locale:         i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage)),
+	})
 	if err != nil {
-		if strings.Contains(err.Error(), "duplicate") {
+		var appErr *utils.CustomError
+		if utils.As(err, &appErr) && appErr.Code == fiber.StatusConflict {
 			Logger.Warn(c.Context()).Logs(fmt.Sprintf("Duplicate username or email: %s", ui.Email))
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-				"error": "Username or email already exists",
-			})
-		}
-		Logger.Error(c.Context()).Logs(fmt.Sprintf("Failed to create user: %v", err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	token, _ := utils.GenerateRandomToken(64, 124)
-	otp, err := utils.HashPassword(gotp)
-	if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to hash OTP")
-	} else {
-		otpKey := fmt.Sprintf("otp:%s", token)
-		if err := Redis.Set(c.Context(), otpKey, otp, 24*time.Hour).Err(); err != nil {
-			Logger.Warn(c.Context()).WithFields("key", otpKey).Logs(fmt.Sprintf("Failed to store OTP in Redis: %v", err))
+			if PrivacyMode {
+				return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+					"message": "Registration successful. Check your email to activate your account.",
+				})
+			}
 		} else {
-			Logger.Info(c.Context()).WithFields("key", otpKey).Logs("OTP stored in Redis")
+			Logger.Warn(c.Context()).WithFields("error", err).Logs("Failed to create user")
 		}
+		return apierror.From(err, "Failed to create user")
 	}
-
-	if err := utils.SendActivationEmail(c.Context(), EmailCfg, ui.Email, ui.Username, token, gotp, Logger); err != nil {
-		Logger.Warn(c.Context()).Logs(fmt.Sprintf("Email sending failed but user created: %v", err))
-	} else {
-		Logger.Info(c.Context()).Logs(fmt.Sprintf("Activation email sent successfully for user: %s", ui.Username))
-	}
-
-	key := "user:" + token
-	userJSON, err := json.Marshal(user)
-	if err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).Logs("Failed to serialize user data")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Failed to serialize user data",
-		})
-	}
-	if err := Redis.Set(c.Context(), key, userJSON, 10*time.Minute).Err(); err != nil {
-		Logger.Warn(c.Context()).Logs(fmt.Sprintf("Failed to cache user in Redis: %v, key: %s", err, key))
-	} else {
-		Logger.Info(c.Context()).Logs(fmt.Sprintf("User cached in Redis: %s", key))
-	}
+	wakeOutbox()
 
 	// Log success
 	Logger.Info(c.Context()).Logs(fmt.Sprintf("User registered successfully: %s (ID: %s)", ui.Username, user.ID.String()))
 
+	if PrivacyMode {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"message": "Registration successful. Check your email to activate your account.",
+		})
+	}
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message": "Registration successful. Check your email to activate your account.",
 		"user": fiber.Map{
@@ -173,116 +139,27 @@ func ActivateUser(c *fiber.Ctx) error {
 	var ar ActivateRequest
 	if err := utils.StrictBodyParser(c, &ar); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).Logs(fmt.Sprintf("Failed to parse activation request: %v", err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request format",
-		})
+		return apierror.BadRequest("Invalid request format")
 	}
 
 	if err := Validator.Validate(ar); err != nil {
 		Logger.Warn(c.Context()).WithFields("errors", err).Logs(fmt.Sprintf("Validation failed: %s", err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   "Validation failed",
-			"details": err,
-		})
-	}
-
-	cachedUser, err := Redis.Get(c.Context(), "user:"+token).Result()
-	if err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).Logs("User not found or expired")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid or expired User data",
-		})
-	}
-
-	var marshedUser models.User
-	err = json.Unmarshal([]byte(cachedUser), &marshedUser)
-	if err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).Logs("Failed to deserialize user data")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Failed to deserialize user data",
-		})
-	}
-
-	user, err := models.GetUserBy(c.Context(), Redis, DB, "email = ?", []interface{}{marshedUser.Email})
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			Logger.Warn(c.Context()).WithFields("email", marshedUser.Email).Logs("User not found")
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "User not found",
-			})
-		}
-		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch user")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to process activation",
-		})
-	}
-
-	otpKey := "otp:" + token
-	otpHash, err := Redis.Get(c.Context(), otpKey).Result()
-	if err != nil || otpHash == "" {
-		Logger.Warn(c.Context()).WithFields("key", otpKey).Logs("OTP not found or expired")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid or expired activation code",
-		})
+		return apierror.Validation(err)
 	}
 
-	fmt.Println("OTP Hash:", otpHash)
-	fmt.Print("OTP:", ar.OTP)
-	if err := utils.ComparePasswords(otpHash, ar.OTP); err != nil {
-		Logger.Warn(c.Context()).WithFields("email", user.Email).Logs("Invalid OTP provided")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid activation code",
-		})
-	}
-
-	updatedUser, err := models.UpdateUser(c.Context(), Redis, DB, user.ID, models.WithIsActive(true))
+	user, err := Services.Users.Activate(c.Context(), token, ar.OTP)
 	if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to activate user")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to activate account",
-		})
-	}
-
-	notificationTitle := []string{
-		"Welcome to DEVPULSE! 👋 I'm Heart, the community mascot and I'm here to help get you started. Let's begin by setting up your profile!",
-		"Heart here again! 👋 DEVPULSE is a friendly community. Why not introduce yourself by leaving a comment in the welcome thread!",
-		"You're on a roll! 🎉 Do you have a Facebook account? Consider connecting it.",
-		"Hi, it's me again! 👋 Now that you're a part of the DEVPULSE community, let's focus on personalizing your content. You can start by following some tags to help customize your feed! 🎉",
-		"Heart here! 👋 Did you know that that you can customize your DEVPULSE experience? Try changing your font and theme and find the best style for you!",
-		"Heart here! 👋 I noticed that you haven't asked a question or started a discussion yet. It's easy to do both of these; just click on 'Write a Post' in the sidebar of the tag page to get started!",
-	}
-
-	for _, title := range notificationTitle {
-		_, err := models.NewNotification(c.Context(), Redis, DB, updatedUser.ID, "all", title)
-		if err != nil {
-			Logger.Warn(c.Context()).WithFields("error", err, "user_id", updatedUser.ID).Logs("Failed to create welcome notification")
-		}
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Failed to activate user")
+		return apierror.From(err, "Failed to process activation")
 	}
-
-	Redis.Del(c.Context(), otpKey)
-	Redis.Del(c.Context(), "user:"+token)
 	Logger.Info(c.Context()).WithFields("user_id", user.ID).Logs(fmt.Sprintf("User activated successfully: %s", user.Username))
 
-	key := "user:" + user.Email
-	userJSON, err := json.Marshal(updatedUser)
-	if err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).Logs("Failed to serialize user data")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Failed to serialize user data",
-		})
-	}
-	if err := Redis.Set(c.Context(), key, userJSON, 0).Err(); err != nil {
-		Logger.Warn(c.Context()).Logs(fmt.Sprintf("Failed to cache user in Redis: %v, key: %s", err, key))
-	} else {
-		Logger.Info(c.Context()).Logs(fmt.Sprintf("User cached in Redis: %s", key))
-	}
-
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Account activated successfully",
 		"user": fiber.Map{
-			"id":       updatedUser.ID,
-			"username": updatedUser.Username,
-			"email":    updatedUser.Email,
+			"id":       user.ID,
+			"username": user.Username,
+			"email":    user.Email,
 			"message":  "Your account has been activated. Please log in now!",
 		},
 	})
@@ -292,169 +169,170 @@ func ActivateUser(c *fiber.Ctx) error {
 func Login(c *fiber.Ctx) error {
 	if utils.IsLoggedIn(c) {
 		Logger.Warn(c.Context()).Logs("User already logged in, login not allowed")
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error":   "Already logged in",
-			"message": "You are already authenticated. Please log out first if you want to log in again.",
-		})
+		return apierror.Forbidden("Already logged in. Please log out first if you want to log in again.")
 	}
 	type LoginRequest struct {
 		Email    string `json:"email" validate:"required,email,max=100"`
 		Password string `json:"password" validate:"required,min=6,max=100"`
+		Code     string `json:"code" validate:"omitempty,max=20"`
 	}
 
 	var lr LoginRequest
 	if err := utils.StrictBodyParser(c, &lr); err != nil {
 		Logger.Warn(c.Context()).Logs(fmt.Sprintf("Failed to parse login request body: %v", err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request format",
-		})
-	}
-
-	ipKey := "login:ip:" + c.IP()
-	count, err := Redis.Get(c.Context(), ipKey).Int()
-	if err != nil && count >= 5 {
-		Logger.Warn(c.Context()).WithFields("ip", c.IP()).Logs("Login rate limit exceeded")
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error": "Too many login attempts. Try again later.",
-		})
+		return apierror.BadRequest("Invalid request format")
 	}
-	Redis.Incr(c.Context(), ipKey)
-	Redis.Expire(c.Context(), ipKey, 15*time.Minute)
 
 	if err := Validator.Validate(lr); err != nil {
 		Logger.Warn(c.Context()).WithFields("errors", err).Logs("Login validation failed")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": fiber.Map{
-				"errors": err,
-			},
-		})
+		return apierror.Validation(err)
 	}
 
-	lr.Email = strings.ToLower(strings.TrimSpace(lr.Email))
-
-	user, err := models.GetUserBy(c.Context(), Redis, DB, "email = ?", []interface{}{lr.Email})
+	user, err := Services.Auth.Authenticate(c.Context(), lr.Email, lr.Password, services.Client{IP: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)})
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			Logger.Warn(c.Context()).WithFields("email", user.Email).Logs("User not found")
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "User not found",
-			})
+		var loginErr *services.LoginError
+		if !errors.As(err, &loginErr) {
+			Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch user")
+			return apierror.Internal("Failed to process login")
 		}
-		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch user")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to process activation",
-		})
+		Logger.Warn(c.Context()).WithFields("email", lr.Email, "reason", loginErr.Reason).Logs("Login refused")
+		metrics.LoginFailed(loginErr.Reason)
+		if loginErr.UserID != uuid.Nil {
+			recordLogin(c, loginErr.UserID, models.ProviderPassword, false, loginErr.Reason)
+		}
+		if loginErr.Reason == services.LoginRestricted {
+			return restrictedAccount(c, user)
+		}
+		return apierror.From(loginErr.Err, "Failed to process login")
 	}
 
-	if !user.IsActive || !user.IsEmailVerified {
-		Logger.Warn(c.Context()).WithFields("user_id", user.ID).Logs("Login attempt on inactive account")
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Account not activated. Check your email.",
-		})
+	if user.TwoFactorEnabled {
+		if lr.Code == "" {
+			return apierror.Unauthorized("Two-factor code required").WithDetails(fiber.Map{"two_factor_required": true})
+		}
+		if rateLimited(c, TwoFactorRateLimit, user.ID.String()) {
+			return apierror.RateLimited("Too many two-factor attempts, try again later")
+		}
+		valid, err := verifySecondFactor(c, user, lr.Code)
+		if err != nil {
+			Logger.Error(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to check two-factor code")
+			return apierror.Internal("Failed to process login")
+		}
+		if !valid {
+			Logger.Warn(c.Context()).WithFields("user_id", user.ID).Logs("Invalid two-factor code provided")
+			metrics.LoginFailed("invalid_two_factor")
+			recordLogin(c, user.ID, models.ProviderPassword, true, "invalid_two_factor")
+			if err := models.RecordSecurityEvent(c.Context(), DB, user.ID, models.EventLoginFailed, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+				Logger.Warn(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to record failed login")
+			}
+			return apierror.Unauthorized("Invalid two-factor code").WithDetails(fiber.Map{"two_factor_required": true})
+		}
 	}
 
-	if err := utils.ComparePasswords(user.Password, lr.Password); err != nil {
-		Logger.Warn(c.Context()).WithFields("email", lr.Email).Logs("Invalid password provided")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid email or password",
-		})
+	tokens, err := startSession(c, user, models.ProviderPassword)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to start session")
+		return apierror.Internal("Failed to process login")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", user.ID).Logs(fmt.Sprintf("User logged in successfully: %s", user.Username))
+
+	res := fiber.Map{
+		"message": "Login successful",
+		"user": fiber.Map{
+			"id":       user.ID,
+			"username": user.Username,
+			"email":    user.Email,
+			"name":     user.Profile.Name,
+			"avatar":   user.Profile.AvatarURL,
+		},
 	}
+	if tokens != nil {
+		res["tokens"] = tokens
+	}
+	return c.Status(fiber.StatusOK).JSON(res)
+}
 
+// startSession issues the tokens of a user who just signed in via provider and records the login.
+// The tokens are returned for the response body when the client asked for them there. A refresh
+// token that cannot be stored fails the sign-in rather than handing out a session that cannot renew.
+func startSession(c *fiber.Ctx, user *models.User, provider string) (fiber.Map, error) {
 	user.UpdateLastSeen(c.Context(), Redis, DB)
 
-	accessToken, err := auth.GenerateAccessToken(user.ID.String(), user.RoleID.String())
+	accessToken, err := auth.GenerateAccessToken(user.ID.String(), user.RoleID.String(), user.TokenVersion)
 	if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to generate access token")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to process login",
-		})
+		return nil, err
 	}
 	refreshToken := auth.GenerateRefreshToken()
 
-	refreshKey := "refresh:" + refreshToken
 	refreshData := map[string]interface{}{
-		"user_id": user.ID.String(),
-		"ip":      c.IP(),
+		"user_id":  user.ID.String(),
+		"ip":       c.IP(),
+		"provider": provider,
 	}
-	refreshJSON, _ := json.Marshal(refreshData)
-	if err := Redis.Set(c.Context(), refreshKey, refreshJSON, 7*24*time.Hour).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("key", refreshKey).Logs(fmt.Sprintf("Failed to store refresh token: %v", err))
+	if err := models.StoreRefreshToken(c.Context(), Redis, user.ID, refreshToken, refreshData, auth.RefreshTokenTTL); err != nil {
+		return nil, err
 	}
 
-	c.Cookie(&fiber.Cookie{
-		Name:     "access_token",
-		Value:    accessToken,
-		Expires:  time.Now().Add(15 * time.Minute),
-		HTTPOnly: true,
-		// Secure:   true,
-		// SameSite: "Strict",
-	})
-	c.Cookie(&fiber.Cookie{
-		Name:     "refresh_token",
-		Value:    refreshToken,
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
-		HTTPOnly: true,
-		// Secure:   true,
-		// SameSite: "Strict",
-	})
-
-	Redis.Del(c.Context(), ipKey)
-	Redis.Del(c.Context(), "user:"+user.ID.String())
-
-	Logger.Info(c.Context()).WithFields("user_id", user.ID).Logs(fmt.Sprintf("User logged in successfully: %s", user.Username))
-
-	key := "user:" + user.ID.String()
-	userJSON, err := json.Marshal(user)
-	if err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).Logs("Failed to serialize user data")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Failed to serialize user data",
-		})
+	now := time.Now()
+	user.LastLoginAt = &now
+	user.LastLoginIP = c.IP()
+	if err := DB.WithContext(c.Context()).Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"last_login_at": now,
+		"last_login_ip": c.IP(),
+	}).Error; err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to store last login")
 	}
-
-	if err := Redis.Set(c.Context(), key, userJSON, 30*time.Minute).Err(); err != nil {
-		Logger.Warn(c.Context()).Logs(fmt.Sprintf("Failed to cache user in Redis: %v, key: %s", err, key))
-	} else {
-		Logger.Info(c.Context()).Logs(fmt.Sprintf("User cached in Redis: %s", key))
+	if err := models.RecordSecurityEvent(c.Context(), DB, user.ID, models.EventLogin, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to record login")
 	}
+	recordLogin(c, user.ID, provider, user.TwoFactorEnabled, "")
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Login successful",
-		"user": fiber.Map{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-			"name":     user.Profile.Name,
-			"avatar":   user.Profile.AvatarURL,
-		},
-	})
+	return auth.IssueTokens(c, accessToken, refreshToken), nil
+}
+
+// recordLogin adds a sign-in attempt via method to the user's login history; an attempt refused
+// for reason failed. Recording is best effort and never fails the sign-in.
+func recordLogin(c *fiber.Ctx, userID uuid.UUID, method string, twoFactor bool, reason string) {
+	location := GeoIP.Lookup(c.IP())
+	event := &models.LoginEvent{
+		UserID:    userID,
+		Success:   reason == "",
+		Method:    method,
+		TwoFactor: twoFactor,
+		Reason:    reason,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		Country:   location.Country,
+		Region:    location.Region,
+		City:      location.City,
+	}
+	if err := models.RecordLoginEvent(c.Context(), DB, event); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record login event")
+	}
 }
 
-// Logout ensures user logged out from the server.
+// Logout ensures user logged out from the server. Browsers are logged out by their cookies; other
+// clients send their access token as a bearer token and post their refresh token as
+// "refresh_token".
 func Logout(c *fiber.Ctx) error {
-	accessToken := c.Cookies("access_token")
-	refreshToken := c.Cookies("refresh_token")
-	accessTokenKey := "blacklist:access:" + accessToken
-	refreshTokenKey := "blacklist:refresh:" + refreshToken
+	accessToken, _ := auth.AccessTokenOf(c)
+	refreshToken := auth.RefreshTokenOf(c)
 
 	if accessToken != "" {
-		if err := Redis.Set(c.Context(), accessTokenKey, "invalid", 15*time.Minute).Err(); err != nil {
+		if err := Redis.Revoke(c.Context(), storage.AccessToken, accessToken, 15*time.Minute); err != nil {
 			Logger.Warn(c.Context()).WithFields("error", err).Logs("Failed to blacklist access token in Redis")
 		}
 	}
 
 	if utils.IsLoggedIn(c) {
 		Logger.Warn(c.Context()).Logs("Refresh token not found in Redis")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "User not logged in",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("User not logged in")
 	}
 
 	var refreshData map[string]interface{}
 	if refreshToken != "" {
-		if err := Redis.Set(c.Context(), refreshTokenKey, "invalid", 7*24*time.Hour).Err(); err != nil {
+		if err := Redis.Revoke(c.Context(), storage.RefreshToken, refreshToken, 7*24*time.Hour); err != nil {
 			Logger.Warn(c.Context()).WithFields("error", err).Logs("Failed to blacklist refresh token in Redis")
 		}
 		refreshKey := "refresh:" + refreshToken
@@ -462,6 +340,12 @@ func Logout(c *fiber.Ctx) error {
 		if err == nil && refreshDataJSON != "" {
 			if err := json.Unmarshal([]byte(refreshDataJSON), &refreshData); err == nil {
 				if userID, ok := refreshData["user_id"].(string); ok {
+					if uid, err := uuid.Parse(userID); err == nil {
+						models.UntrackSession(c.Context(), Redis, uid, refreshToken)
+						if err := models.RecordSecurityEvent(c.Context(), DB, uid, models.EventLogout, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+							Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record logout")
+						}
+					}
 					Logger.Info(c.Context()).WithFields("user_id", userID).Logs("User logged out, refresh token revoked")
 				}
 			}
@@ -473,27 +357,12 @@ func Logout(c *fiber.Ctx) error {
 		Logger.Warn(c.Context()).Logs("No refresh token provided for logout")
 	}
 
-	c.Cookie(&fiber.Cookie{
-		Name:     "access_token",
-		Value:    "",
-		Expires:  time.Now().Add(-time.Hour),
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Strict",
-	})
-	c.Cookie(&fiber.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Expires:  time.Now().Add(-time.Hour),
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Strict",
-	})
-	c.ClearCookie("access_token")
-	c.ClearCookie("refresh_token")
+	auth.ClearTokens(c)
 	c.Locals("user_id", "")
 
-	Redis.Del(c.Context(), refreshData["user_id"].(string))
+	if userID, ok := refreshData["user_id"].(string); ok {
+		Redis.Del(c.Context(), userID)
+	}
 
 	c.Set("Authorization", "")
 	c.Set("Cache-Control", "no-store, no-cache, must-revalidate, private")
@@ -508,113 +377,44 @@ func Logout(c *fiber.Ctx) error {
 	})
 }
 
-// UserProfile retrieves and returns the authenticated user’s profile, optimized with Redis caching
+// UserProfile retrieves and returns the authenticated user’s profile, limited to the sections
+// named by ?fields=. The cached record is read in one round trip and, on a miss, loaded with all
+// its relations in one preloaded query
 func GetProfile(c *fiber.Ctx) error {
 	userID, ok := c.Locals("user_id").(string)
 	if !ok || userID == "" {
 		Logger.Warn(c.Context()).Logs("UserProfile attempted without user ID in context")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Unauthorized",
-			"status": fiber.StatusUnauthorized,
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 
 	uid, err := uuid.Parse(userID)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).Logs("Invalid user ID format in UserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid user ID",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid user ID")
 	}
 
-	userKey := "user:" + uid.String()
-	var user *models.User
-	cachedUser, err := Redis.Get(c.Context(), userKey).Result()
-	if err == nil {
-		user = &models.User{}
-		if err := json.Unmarshal([]byte(cachedUser), user); err != nil {
-			Logger.Warn(c.Context()).WithFields("error", err, "userID", uid).Logs("Failed to unmarshal cached user from Redis")
-			user = nil
-		}
-	}
-	if err == redis.Nil || user == nil {
-		user, err = models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{uid}, "")
-		if err != nil {
-			Logger.Error(c.Context()).WithFields("error", err, "userID", uid).Logs("Database error while fetching user profile")
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":  "Failed to fetch user profile",
-				"status": fiber.StatusInternalServerError,
-			})
-		}
-		userJSON, err := json.Marshal(user)
-		if err != nil {
-			Logger.Warn(c.Context()).WithFields("error", err, "userID", uid).Logs("Failed to marshal user for Redis caching")
-		} else {
-			if err := Redis.Set(c.Context(), userKey, userJSON, 30*time.Minute).Err(); err != nil {
-				Logger.Warn(c.Context()).WithFields("error", err, "userID", uid).Logs("Failed to cache user profile in Redis")
-			}
-		}
-	} else if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err, "userID", uid).Logs("Redis error fetching user profile")
-		user, err = models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{uid})
-		if err != nil {
-			Logger.Error(c.Context()).WithFields("error", err, "userID", uid).Logs("Database error while fetching user profile")
-			c.ClearCookie("access_token")
-			c.ClearCookie("refresh_token")
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":  "Failed to fetch user profile",
-				"status": fiber.StatusInternalServerError,
-			})
-		}
+	fields, err := profileFields(c)
+	if err != nil {
+		return err
 	}
 
-	profileResponse := fiber.Map{
-		"id":                       user.ID,
-		"username":                 user.Username,
-		"email":                    user.Email,
-		"name":                     user.Profile.Name,
-		"bio":                      user.Profile.Bio,
-		"avatar_url":               user.Profile.AvatarURL,
-		"job_title":                user.Profile.JobTitle,
-		"employer":                 user.Profile.Employer,
-		"location":                 user.Profile.Location,
-		"social_links":             user.Profile.SocialLinks,
-		"current_learning":         user.Profile.CurrentLearning,
-		"available_for":            user.Profile.AvailableFor,
-		"currently_hacking_on":     user.Profile.CurrentlyHackingOn,
-		"pronouns":                 user.Profile.Pronouns,
-		"education":                user.Profile.Education,
-		"brand_color":              user.Settings.BrandColor,
-		"posts_count":              user.Stats.PostsCount,
-		"comments_count":           user.Stats.CommentsCount,
-		"likes_count":              user.Stats.LikesCount,
-		"bookmarks_count":          user.Stats.BookmarksCount,
-		"last_seen":                user.Stats.LastSeen,
-		"theme_preference":         user.Settings.ThemePreference,
-		"base_font":                user.Settings.BaseFont,
-		"site_navbar":              user.Settings.SiteNavbar,
-		"content_editor":           user.Settings.ContentEditor,
-		"content_mode":             user.Settings.ContentMode,
-		"created_at":               user.CreatedAt,
-		"updated_at":               user.UpdatedAt,
-		"skills":                   user.Profile.Skills,
-		"interests":                user.Profile.Interests,
-		"badges":                   user.Badges,
-		"roles":                    user.Role,
-		"followers":                user.Followers,
-		"following":                user.Following,
-		"notifications":            user.Notifications,
-		"notification_preferences": user.NotificationPreferences,
-		"previous_passwords":       user.PreviousPasswords,
-		"last_password_change":     user.LastPasswordChange,
+	users, err := models.GetUsersByID(c.Context(), Redis, DB, uid)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "userID", uid).Logs("Database error while fetching user profile")
+		return apierror.Internal("Failed to fetch user profile")
+	}
+	user, ok := users[uid]
+	if !ok {
+		Logger.Warn(c.Context()).WithFields("userID", uid).Logs("User not found in UserProfile")
+		auth.ClearTokens(c)
+		return apierror.NotFound("User not found")
 	}
 
 	Logger.Info(c.Context()).WithFields("userID", uid).Logs("User profile retrieved successfully")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Profile retrieved successfully",
 		"status":  fiber.StatusOK,
-		"user":    profileResponse,
+		"user":    BuildProfileResponse(selfViewer(user), user, fields...),
 	})
 }
 
@@ -623,47 +423,41 @@ func UpdateUserProfile(c *fiber.Ctx) error {
 	userIDRaw, ok := c.Locals("user_id").(string)
 	if !ok || userIDRaw == "" {
 		Logger.Warn(c.Context()).Logs("UpdateUserProfile attempted without user ID in context")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Unauthorized",
-			"status": fiber.StatusUnauthorized,
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 
 	userID, err := uuid.Parse(userIDRaw)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err, "userID", userIDRaw).Logs("Invalid user ID format in UpdateUserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid user ID",
-			"status": fiber.StatusBadRequest,
-		})
-	}
-
-	allowed := RateLimitting(c, userIDRaw, 1*time.Minute, 5, "profile_update_rate:")
-	if !allowed {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error":  "Too many update attempts, try again later",
-			"status": fiber.StatusTooManyRequests,
-		})
+		return apierror.BadRequest("Invalid user ID")
 	}
 
 	var req models.UpdateUserRequest
 	if err := utils.StrictBodyParser(c, &req); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Invalid request body")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid request body",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid request body")
 	}
 
 	if err := Validator.Validate(req); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Validation failed")
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"error":  err,
-			"status": fiber.StatusUnprocessableEntity,
-		})
+		return apierror.Validation(err)
 	}
 
-	userKey := "user:" + userIDRaw
+	filtered := []contentField{}
+	if req.Username != nil {
+		filtered = append(filtered, contentField{Name: "username", Field: contentfilter.FieldUsername, Text: *req.Username})
+	}
+	if req.Profile != nil && req.Profile.Name != nil {
+		filtered = append(filtered, contentField{Name: "profile.name", Field: contentfilter.FieldName, Text: *req.Profile.Name})
+	}
+	if req.Profile != nil && req.Profile.Bio != nil {
+		filtered = append(filtered, contentField{Name: "profile.bio", Field: contentfilter.FieldBio, Text: *req.Profile.Bio})
+	}
+	if err := filterContent(c, filtered...); err != nil {
+		return err
+	}
+
+	userKey := cache.UserKey(userID)
 	var user *models.User
 	cachedUser, err := Redis.Get(c.Context(), userKey).Result()
 	if err == nil {
@@ -678,14 +472,14 @@ func UpdateUserProfile(c *fiber.Ctx) error {
 	updatedFields := []string{}
 	if req.Username != nil {
 		if err := DB.Where("username = ? AND id != ?", *req.Username, userID).First(&models.User{}).Error; err == nil {
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Username already taken"})
+			return apierror.Conflict("Username already taken")
 		}
 		opts = append(opts, models.WithUsername(*req.Username))
 		updatedFields = append(updatedFields, "username")
 	}
 	if req.Email != nil {
 		if err := DB.Where("email = ? AND id != ?", *req.Email, userID).First(&models.User{}).Error; err == nil {
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Email already taken"})
+			return apierror.Conflict("Email already taken")
 		}
 		opts = append(opts, models.WithEmail(*req.Email))
 		updatedFields = append(updatedFields, "email")
@@ -760,22 +554,29 @@ func UpdateUserProfile(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{
 			"message": "No changes provided",
 			"status":  fiber.StatusOK,
-			"user":    user,
+			"user":    selfProfile(user),
 		})
 	}
 
+	if field, next, ok := checkFieldIntervals(c, userIDRaw, updatedFields); !ok {
+		Logger.Warn(c.Context()).WithFields("user_id", userID, "field", field).Logs("Profile field changed too recently")
+		return apierror.RateLimited(fmt.Sprintf("%s was changed recently and cannot be changed again yet", field)).
+			WithDetails(fiber.Map{"field": field, "next_allowed": next})
+	}
+
 	updatedUser, err := models.UpdateUser(c.Context(), Redis, DB, userID, opts...)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to update user")
 		if err.Error() == "user not found" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+			return apierror.NotFound("User not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update profile"})
+		return apierror.Internal("Failed to update profile")
 	}
 
-	Redis.Del(c.Context(), userKey)
+	markFieldUpdates(c, userIDRaw, updatedFields)
+
 	userJSON, _ := json.Marshal(updatedUser)
-	if err := Redis.Set(c.Context(), userKey, userJSON, 30*time.Minute).Err(); err != nil {
+	if err := Redis.WriteBack(c.Context(), userKey, userJSON, Redis.TTL("user")); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to update Redis cache")
 	}
 
@@ -784,7 +585,7 @@ func UpdateUserProfile(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Section updated successfully",
 		"status":  fiber.StatusOK,
-		"user":    updatedUser,
+		"user":    selfProfile(updatedUser),
 	})
 }
 
@@ -795,80 +596,81 @@ func getBool(b *bool) bool {
 	return false
 }
 
-// UpdateUserNotificationPrefrences updates the user's notification preferences
+func getString(s *string) string {
+	if s != nil {
+		return *s
+	}
+	return ""
+}
+
+// UpdateUserNotificationPrefrences updates the user's notification preferences from the flat
+// fields that predate the channel matrix; each maps to one cell of it and omitted ones are kept
 func UpdateUserNotificationPrefrences(c *fiber.Ctx) error {
 	type UpdateData struct {
-		EmailOnLikes    *bool `json:"email_on_likes" validate:"omitempty"`
-		EmailOnComments *bool `json:"email_on_comments" validate:"omitempty"`
-		EmailOnMentions *bool `json:"email_on_mentions" validate:"omitempty"`
-		EmailOnFollower *bool `json:"email_on_followers" validate:"omitempty"`
-		EmailOnBadge    *bool `json:"email_on_badge" validate:"omitempty"`
-		EmailOnUnread   *bool `json:"email_on_unread" validate:"omitempty"`
-		EmailOnNewPosts *bool `json:"email_on_new_posts" validate:"omitempty"`
+		EmailOnLikes    *bool   `json:"email_on_likes" validate:"omitempty"`
+		EmailOnComments *bool   `json:"email_on_comments" validate:"omitempty"`
+		EmailOnMentions *bool   `json:"email_on_mentions" validate:"omitempty"`
+		EmailOnFollower *bool   `json:"email_on_followers" validate:"omitempty"`
+		EmailOnBadge    *bool   `json:"email_on_badge" validate:"omitempty"`
+		EmailOnUnread   *bool   `json:"email_on_unread" validate:"omitempty"`
+		EmailOnNewPosts *bool   `json:"email_on_new_posts" validate:"omitempty"`
+		PushOnReactions *bool   `json:"push_on_reactions" validate:"omitempty"`
+		PushOnComments  *bool   `json:"push_on_comments" validate:"omitempty"`
+		PushOnMentions  *bool   `json:"push_on_mentions" validate:"omitempty"`
+		DigestFrequency *string `json:"digest_frequency" validate:"omitempty,oneof=daily weekly"`
 	}
 
 	userIDRaw, ok := c.Locals("user_id").(string)
 	if !ok || userIDRaw == "" {
 		Logger.Warn(c.Context()).Logs("UpdateUserProfile attempted without user ID in context")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Unauthorized",
-			"status": fiber.StatusUnauthorized,
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 
 	userID, err := uuid.Parse(userIDRaw)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err, "userID", userIDRaw).Logs("Invalid user ID format in UpdateUserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid user ID",
-			"status": fiber.StatusBadRequest,
-		})
-	}
-
-	allowed := RateLimitting(c, userIDRaw, 1*time.Minute, 5, "profile_update_rate:")
-	if !allowed {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error":  "Too many update attempts, try again later",
-			"status": fiber.StatusTooManyRequests,
-		})
+		return apierror.BadRequest("Invalid user ID")
 	}
 
 	var data UpdateData
 	if err := utils.StrictBodyParser(c, &data); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Invalid request body")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid request body",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid request body")
 	}
 
 	if err := Validator.Validate(data); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Validation failed")
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"error":  err,
-			"status": fiber.StatusUnprocessableEntity,
-		})
+		return apierror.Validation(err)
+	}
+
+	changes := models.NotificationMatrix{}
+	for _, field := range []struct {
+		value          *bool
+		channel, event string
+	}{
+		{data.EmailOnLikes, models.ChannelEmail, models.EventReactions},
+		{data.EmailOnComments, models.ChannelEmail, models.EventComments},
+		{data.EmailOnMentions, models.ChannelEmail, models.EventMentions},
+		{data.EmailOnFollower, models.ChannelEmail, models.EventFollowers},
+		{data.EmailOnBadge, models.ChannelEmail, models.EventBadges},
+		{data.EmailOnUnread, models.ChannelEmail, models.EventUnread},
+		{data.EmailOnNewPosts, models.ChannelEmail, models.EventNewPosts},
+		{data.PushOnReactions, models.ChannelPush, models.EventReactions},
+		{data.PushOnComments, models.ChannelPush, models.EventComments},
+		{data.PushOnMentions, models.ChannelPush, models.EventMentions},
+	} {
+		if field.value != nil {
+			changes.Set(field.channel, field.event, *field.value)
+		}
 	}
 
-	updatedUser, err := models.UpdateNotificationPreferences(
-		c.Context(),
-		Redis,
-		DB,
-		userID,
-		getBool(data.EmailOnLikes),
-		getBool(data.EmailOnComments),
-		getBool(data.EmailOnMentions),
-		getBool(data.EmailOnFollower),
-		getBool(data.EmailOnBadge),
-		getBool(data.EmailOnUnread),
-		getBool(data.EmailOnNewPosts),
-	)
+	updatedUser, err := models.UpdateNotificationPreferences(c.Context(), Redis, DB, userID, changes, getString(data.DigestFrequency))
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to update user")
 		if err.Error() == "user not found" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+			return apierror.NotFound("User not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update profile"})
+		return apierror.Internal("Failed to update profile")
 	}
 
 	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("User profile updated successfully")
@@ -888,6 +690,7 @@ func UpdateUserCustomization(c *fiber.Ctx) error {
 		SiteNavbar      *string `json:"site_navbar" validate:"omitempty,oneof=fixed static"`
 		ContentEditor   *string `json:"content_editor" validate:"omitempty,oneof=rich basic"`
 		ContentMode     *int    `json:"content_mode" validate:"omitempty,oneof=1 2 3 4 5"`
+		Locale          *string `json:"locale"`
 	}
 
 	userIDRaw, ok := c.Locals("user_id").(string)
@@ -900,32 +703,21 @@ func UpdateUserCustomization(c *fiber.Ctx) error {
 		Logger.Error(c.Context()).WithFields("error", err, "userID", userIDRaw).Logs("Invalid user ID format in UpdateUserProfile")
 	}
 
-	allowed := RateLimitting(c, userIDRaw, 1*time.Minute, 5, "profile_update_rate:")
-	if !allowed {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error":  "Too many update attempts, try again later",
-			"status": fiber.StatusTooManyRequests,
-		})
-	}
-
 	var data UpdateData
 	if err := utils.StrictBodyParser(c, &data); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Invalid request body")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid request body",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid request body")
 	}
 
 	if err := Validator.Validate(data); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Validation failed")
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"error":  err,
-			"status": fiber.StatusUnprocessableEntity,
-		})
+		return apierror.Validation(err)
+	}
+	if data.Locale != nil && !i18n.Supports(*data.Locale) {
+		return apierror.BadRequest("Unsupported locale").WithDetails(fiber.Map{"supported": i18n.Supported()})
 	}
 
-	userKey := "user:" + userIDRaw
+	userKey := cache.UserKey(userID)
 	var user *models.User
 	cachedUser, err := Redis.Get(c.Context(), userKey).Result()
 	if err == nil {
@@ -958,13 +750,17 @@ func UpdateUserCustomization(c *fiber.Ctx) error {
 		opts = append(opts, models.WithContentMode(*data.ContentMode))
 		updatedFields = append(updatedFields, "settings.content_mode")
 	}
+	if data.Locale != nil {
+		opts = append(opts, models.WithLocale(*data.Locale))
+		updatedFields = append(updatedFields, "settings.locale")
+	}
 
 	if len(opts) == 0 {
 		Logger.Info(c.Context()).WithFields("user_id", userID).Logs("No fields provided for update")
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{
 			"message": "No changes provided",
 			"status":  fiber.StatusOK,
-			"user":    user,
+			"user":    selfProfile(user),
 		})
 	}
 
@@ -972,14 +768,13 @@ func UpdateUserCustomization(c *fiber.Ctx) error {
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to update user")
 		if err.Error() == "user not found" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+			return apierror.NotFound("User not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update profile"})
+		return apierror.Internal("Failed to update profile")
 	}
 
-	Redis.Del(c.Context(), userKey)
 	userJSON, _ := json.Marshal(updatedUser)
-	if err := Redis.Set(c.Context(), userKey, userJSON, 30*time.Minute).Err(); err != nil {
+	if err := Redis.WriteBack(c.Context(), userKey, userJSON, Redis.TTL("user")); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to update Redis cache")
 	}
 
@@ -988,7 +783,7 @@ func UpdateUserCustomization(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Section updated successfully",
 		"status":  fiber.StatusOK,
-		"user":    updatedUser,
+		"user":    selfProfile(updatedUser),
 	})
 }
 
@@ -1003,100 +798,58 @@ func UpdateUserAccount(c *fiber.Ctx) error {
 	userIDRaw, ok := c.Locals("user_id").(string)
 	if !ok || userIDRaw == "" {
 		Logger.Warn(c.Context()).Logs("UpdateUserPassword attempted without user ID in context")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Unauthorized",
-			"status": fiber.StatusUnauthorized,
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 
 	userID, err := uuid.Parse(userIDRaw)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err, "userID", userIDRaw).Logs("Invalid user ID format in UpdateUserPassword")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid user ID",
-			"status": fiber.StatusBadRequest,
-		})
-	}
-
-	allowed := RateLimitting(c, userIDRaw, 5*time.Minute, 5, "rate:change-password:")
-	if !allowed {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error":  "Too many update attempts, try again later",
-			"status": fiber.StatusTooManyRequests,
-		})
+		return apierror.BadRequest("Invalid user ID")
 	}
 
 	var req UpdatePasswordRequest
 	if err := utils.StrictBodyParser(c, &req); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Invalid request body")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid request body",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid request body")
 	}
 
 	if err := Validator.Validate(req); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Validation failed")
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"error":  err,
-			"status": fiber.StatusUnprocessableEntity,
-		})
+		return apierror.Validation(err)
 	}
 
 	if utils.ContainsInvalidChars(req.NewPassword) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"Message": "password contains invalid characters",
-			"status":  fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("password contains invalid characters")
 	}
 
-	userKey := "user:" + userID.String()
-	var user *models.User
-	cachedUser, err := Redis.Get(c.Context(), userKey).Result()
-	if err == nil {
-		user = &models.User{}
-		if err := json.Unmarshal([]byte(cachedUser), user); err != nil {
-			Logger.Warn(c.Context()).WithFields("error", err, "userID", userID.String()).Logs("Failed to unmarshal cached user from Redis")
-			user = nil
-		}
+	// Password hashes are never cached, so the user is read from the database
+	user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{userID})
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user in UpdateUserPassword")
+		return apierror.From(err, "Failed to update password")
 	}
 
 	if err := utils.ComparePasswords(user.Password, req.CurrentPassword); err != nil {
 		Logger.Warn(c.Context()).WithFields("email", user.Email).Logs("Invalid password provided")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Invalid current password",
-			"status": fiber.StatusUnauthorized,
-		})
+		return apierror.Unauthorized("Invalid current password")
 	}
 
 	if !utils.IsStrongPassword(req.NewPassword) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"Message": "new password does not meet strength requirements",
-			"status":  fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("new password does not meet strength requirements")
 	}
 
 	if req.CurrentPassword == req.NewPassword {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"Message": "new password cannot be the same as current",
-			"status":  fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("new password cannot be the same as current")
 	}
 
 	if utils.IsPasswordReused(user.PreviousPasswords, req.NewPassword) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"Message": "new password cannot match previous passwords",
-			"status":  fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("new password cannot match previous passwords")
 	}
 
 	hashedPassword, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to hash new password")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":  "Failed to process password",
-			"status": fiber.StatusInternalServerError,
-		})
+		return apierror.Internal("Failed to process password")
 	}
 
 	_, err = models.UpdateUser(
@@ -1112,29 +865,17 @@ func UpdateUserAccount(c *fiber.Ctx) error {
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to update user password")
 		if err.Error() == "user not found" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+			return apierror.NotFound("User not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update password"})
+		return apierror.Internal("Failed to update password")
 	}
 
-	c.Cookie(&fiber.Cookie{
-		Name:     "access_token",
-		Value:    "",
-		Expires:  time.Now().Add(-time.Hour),
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Strict",
-	})
-	c.Cookie(&fiber.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Expires:  time.Now().Add(-time.Hour),
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Strict",
-	})
+	recordAudit(c, userID, models.AuditPasswordChange, models.AuditTargetUser, userID, nil, map[string]interface{}{"password_changed_at": time.Now().UTC()})
+	revokeSessionsOnPasswordChange(c, userID)
 
-	Redis.Del(c.Context(), userKey)
+	auth.ClearTokens(c)
+
+	Redis.Del(c.Context(), cache.UserKey(userID))
 
 	c.Set("Authorization", "")
 	c.Set("Cache-Control", "no-store, no-cache, must-revalidate, private")
@@ -1160,47 +901,27 @@ func DeleteUserAccount(c *fiber.Ctx) error {
 	userIDRaw, ok := c.Locals("user_id").(string)
 	if !ok || userIDRaw == "" {
 		Logger.Warn(c.Context()).Logs("UpdateUserPassword attempted without user ID in context")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Unauthorized",
-			"status": fiber.StatusUnauthorized,
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 
 	userID, err := uuid.Parse(userIDRaw)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err, "userID", userIDRaw).Logs("Invalid user ID format in UpdateUserPassword")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid user ID",
-			"status": fiber.StatusBadRequest,
-		})
-	}
-
-	allowed := RateLimitting(c, userIDRaw, 5*time.Minute, 5, "rate:delete-user:")
-	if !allowed {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error":  "Too many update attempts, try again later",
-			"status": fiber.StatusTooManyRequests,
-		})
+		return apierror.BadRequest("Invalid user ID")
 	}
 
 	var req ConfirmData
 	if err := utils.StrictBodyParser(c, &req); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Invalid request body")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid request body",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid request body")
 	}
 
 	if err := Validator.Validate(req); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Validation failed")
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"error":  err,
-			"status": fiber.StatusUnprocessableEntity,
-		})
+		return apierror.Validation(err)
 	}
 
-	userKey := "user:id:" + userID.String()
+	userKey := cache.UserKey(userID)
 	exists, err := Redis.Exists(c.Context(), userKey).Result()
 	if err == nil {
 		Logger.Warn(c.Context()).WithFields("error", err, "userID", userID.String()).Logs("Failed to check user existence in Redis")
@@ -1210,14 +931,11 @@ func DeleteUserAccount(c *fiber.Ctx) error {
 		user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{userID})
 		if err != nil {
 			Logger.Error(c.Context()).WithFields("error", err, "userID", userID).Logs("Database error while fetching user profile")
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":  "Failed to fetch user profile",
-				"status": fiber.StatusInternalServerError,
-			})
+			return apierror.Internal("Failed to fetch user profile")
 		}
 
 		userJSON, _ := json.Marshal(user)
-		if err := Redis.Set(c.Context(), userKey, userJSON, 30*time.Minute).Err(); err != nil {
+		if err := Redis.Set(c.Context(), userKey, userJSON, Redis.TTL("user")).Err(); err != nil {
 			Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to update Redis cache")
 		}
 	}
@@ -1226,28 +944,14 @@ func DeleteUserAccount(c *fiber.Ctx) error {
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to delete user")
 		if err.Error() == "user not found" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+			return apierror.NotFound("User not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete user"})
+		return apierror.Internal("Failed to delete user")
 	}
+	recordAudit(c, userID, models.AuditUserDelete, models.AuditTargetUser, userID, map[string]interface{}{"deleted": false}, map[string]interface{}{"deleted": true})
 	Redis.Del(c.Context(), userKey)
 
-	c.Cookie(&fiber.Cookie{
-		Name:     "access_token",
-		Value:    "",
-		Expires:  time.Now().Add(-time.Hour),
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Strict",
-	})
-	c.Cookie(&fiber.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Expires:  time.Now().Add(-time.Hour),
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Strict",
-	})
+	auth.ClearTokens(c)
 
 	c.Set("Authorization", "")
 	c.Set("Cache-Control", "no-store, no-cache, must-revalidate, private")
@@ -1255,8 +959,6 @@ func DeleteUserAccount(c *fiber.Ctx) error {
 	c.Set("X-Content-Type-Options", "nosniff")
 	c.Set("X-Frame-Options", "DENY")
 	c.Set("Content-Security-Policy", "default-src 'self'")
-	c.ClearCookie("access_token")
-	c.ClearCookie("refresh_token")
 	c.Locals("user_id", nil)
 
 	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("User account deleted successfully")
@@ -1272,17 +974,11 @@ func FollowUser(c *fiber.Ctx) error {
 	username := c.Params("username")
 	if username == "" {
 		Logger.Warn(c.Context()).Logs("FollowUser attempted without username in URL")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username is required",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Username is required")
 	}
 	if len(username) > 20 {
 		Logger.Warn(c.Context()).Logs("FollowUser attempted with username exceeding length limit")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username exceeds length limit",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Username exceeds length limit")
 	}
 	if strings.Contains(username, " ") {
 		Logger.Warn(c.Context()).Logs("FollowUser attempted with username containing spaces")
@@ -1292,30 +988,16 @@ func FollowUser(c *fiber.Ctx) error {
 	userIDRaw, ok := c.Locals("user_id").(string)
 	if !ok || userIDRaw == "" {
 		Logger.Warn(c.Context()).Logs("FollowUser attempted without user_id in context")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Unauthorized",
-			"status": fiber.StatusUnauthorized,
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 
 	followerID, err := uuid.Parse(userIDRaw)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in FollowUser")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid user ID",
-			"status": fiber.StatusBadRequest,
-		})
-	}
-
-	allowed := RateLimitting(c, followerID.String(), 5*time.Minute, 10, "follow_user_rate")
-	if !allowed {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error":  "Too many update attempts, try again later",
-			"status": fiber.StatusTooManyRequests,
-		})
+		return apierror.BadRequest("Invalid user ID")
 	}
 
-	userKey := "user:" + followerID.String()
+	userKey := cache.UserKey(followerID)
 	var followU *models.User
 	followerUser, err := Redis.Get(c.Context(), userKey).Result()
 	if err == nil {
@@ -1328,10 +1010,7 @@ func FollowUser(c *fiber.Ctx) error {
 		followU, err = models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{followerID})
 		if err != nil {
 			Logger.Warn(c.Context()).WithFields("error", err, "user_id", followerID).Logs("Follower not found")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error":  "User not found",
-				"status": fiber.StatusUnauthorized,
-			})
+			return apierror.Unauthorized("User not found")
 		}
 	}
 
@@ -1339,10 +1018,7 @@ func FollowUser(c *fiber.Ctx) error {
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			Logger.Warn(c.Context()).WithFields("error", err, "following_username", username).Logs("Target user not found")
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error":  "Target user not found",
-				"status": fiber.StatusNotFound,
-			})
+			return apierror.NotFound("Target user not found")
 		}
 		// Check if the error is due to already following (GORM doesn't return a specific error for this, so we need to check the association)
 		if err := DB.WithContext(c.Context()).Model(&followU).Association("Following").Find(&followU.Following); err == nil {
@@ -1357,15 +1033,10 @@ func FollowUser(c *fiber.Ctx) error {
 			}
 		}
 		Logger.Error(c.Context()).WithFields("error", err.Error(), "user_id", followerID, "following_username", username).Logs("Failed to follow user")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":  err.Error(),
-			"status": fiber.StatusInternalServerError,
-		})
+		return apierror.Internal("Failed to follow user")
 	}
 
-	followerKey := "user:" + followerID.String()
-	followingKey := "user:" + username
-	Redis.Del(c.Context(), followerKey, followingKey)
+	Redis.Del(c.Context(), cache.UserKey(followerID))
 
 	Logger.Info(c.Context()).WithFields("user_id", followerID, "following_username", username).Logs("User followed successfully")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -1379,17 +1050,11 @@ func UnfollowUser(c *fiber.Ctx) error {
 	username := c.Params("username")
 	if username == "" {
 		Logger.Warn(c.Context()).Logs("FollowUser attempted without username in URL")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username is required",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Username is required")
 	}
 	if len(username) > 20 {
 		Logger.Warn(c.Context()).Logs("FollowUser attempted with username exceeding length limit")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username exceeds length limit",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Username exceeds length limit")
 	}
 	if strings.Contains(username, " ") {
 		Logger.Warn(c.Context()).Logs("FollowUser attempted with username containing spaces")
@@ -1398,30 +1063,16 @@ func UnfollowUser(c *fiber.Ctx) error {
 	userIDRaw, ok := c.Locals("user_id").(string)
 	if !ok || userIDRaw == "" {
 		Logger.Warn(c.Context()).Logs("UnfollowUser attempted without user_id in context")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Unauthorized",
-			"status": fiber.StatusUnauthorized,
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 
 	followerID, err := uuid.Parse(userIDRaw)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in UnfollowUser")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid user ID",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid user ID")
 	}
 
-	allowed := RateLimitting(c, followerID.String(), 5*time.Minute, 10, "follow_user_rate")
-	if !allowed {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error":  "Too many update attempts, try again later",
-			"status": fiber.StatusTooManyRequests,
-		})
-	}
-
-	userKey := "user:" + followerID.String()
+	userKey := cache.UserKey(followerID)
 	var followU *models.User
 	followerUser, err := Redis.Get(c.Context(), userKey).Result()
 	if err == nil {
@@ -1434,10 +1085,7 @@ func UnfollowUser(c *fiber.Ctx) error {
 		followU, err = models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{followerID})
 		if err != nil {
 			Logger.Warn(c.Context()).WithFields("error", err, "user_id", followerID).Logs("Follower not found")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error":  "User not found",
-				"status": fiber.StatusUnauthorized,
-			})
+			return apierror.Unauthorized("User not found")
 		}
 	}
 
@@ -1445,16 +1093,10 @@ func UnfollowUser(c *fiber.Ctx) error {
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			Logger.Warn(c.Context()).WithFields("error", err, "following_username", username).Logs("Target user not found")
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error":  "Target user not found",
-				"status": fiber.StatusNotFound,
-			})
+			return apierror.NotFound("Target user not found")
 		}
 		Logger.Error(c.Context()).WithFields("error", err, "user_id", followerID, "following_username", username).Logs("Failed to unfollow user")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":  "Failed to unfollow user",
-			"status": fiber.StatusInternalServerError,
-		})
+		return apierror.Internal("Failed to unfollow user")
 	}
 
 	// Check if the user was actually following (since the helper doesn't explicitly tell us)
@@ -1468,11 +1110,6 @@ func UnfollowUser(c *fiber.Ctx) error {
 		}
 		if !isFollowing {
 			Logger.Info(c.Context()).WithFields("user_id", followerID, "following_username", username).Logs("User unfollowed successfully")
-			following, _ := models.GetUserBy(c.Context(), Redis, DB, "username = ?", []interface{}{username})
-			followingKey := "user:" + username
-			publicFollowerKey := "public_user:" + followU.Username
-			publicFollowingKey := "public_user:" + following.Username
-			Redis.Del(c.Context(), followingKey, publicFollowerKey, publicFollowingKey)
 			return c.Status(fiber.StatusOK).JSON(fiber.Map{
 				"message": "User unfollowed successfully",
 				"status":  fiber.StatusOK,
@@ -1487,66 +1124,47 @@ func UnfollowUser(c *fiber.Ctx) error {
 	})
 }
 
-// GetUserNotifications returns user notifications
+// GetUserNotifications returns a page of the user's notifications, newest first. Pages are chained
+// with the returned next_cursor; ?unread=true limits the page to unread notifications and
+// ?total=true adds the number of matching notifications.
 func GetUserNotifications(c *fiber.Ctx) error {
 	userIDRaw, ok := c.Locals("user_id").(string)
 	if !ok || userIDRaw == "" {
 		Logger.Warn(c.Context()).Logs("GetUserNotifications attempted without user_id in context")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Unauthorized",
-			"status": fiber.StatusUnauthorized,
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 
 	userID, err := uuid.Parse(userIDRaw)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err, "userID", userIDRaw).Logs("Invalid user ID format in GetUserNotifications")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid user ID",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid user ID")
 	}
 
-	cacheKey := "user:" + userIDRaw
-	cachedNotifications, err := Redis.Get(c.Context(), cacheKey).Result()
-	if err == nil {
-		var publicUser models.User
-		if err := json.Unmarshal([]byte(cachedNotifications), &publicUser); err == nil {
-			Logger.Info(c.Context()).WithFields("user_id", userIDRaw).Logs("user notifications served from cache")
-			return c.Status(fiber.StatusOK).JSON(fiber.Map{
-				"message":       "User notifications retrieved successfully",
-				"status":        fiber.StatusOK,
-				"notifications": publicUser.Notifications,
-			})
-		}
-		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Failed to unmarshal cached user notifications")
-	}
-	user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{userID}, "")
+	p, err := Paginator.Parse(c, 20, 50)
 	if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userIDRaw).Logs("Failed to fetch user by id")
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":  "User not found",
-			"status": fiber.StatusNotFound,
-		})
+		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Invalid cursor in GetUserNotifications")
+		return apierror.From(err, "Invalid cursor")
 	}
 
-	if len(user.Notifications) == 0 {
-		Logger.Info(c.Context()).WithFields("user_id", userIDRaw).Logs("No notifications found for user")
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message":       "No notifications found",
-			"status":        fiber.StatusOK,
-			"notifications": []string{},
-		})
+	page, err := models.GetNotificationsPage(c.Context(), Redis, DB, userID, p, c.QueryBool("unread"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch user notifications")
+		return postError(c, err, "Failed to fetch notifications")
 	}
-	notificationsJSON, _ := json.Marshal(user.Notifications)
-	if err := Redis.Set(c.Context(), cacheKey, notificationsJSON, 5*time.Minute).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userIDRaw).Logs("Failed to cache user notifications")
+	next, err := Paginator.Seal(page.Next)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to seal notifications cursor")
+		return apierror.Internal("Failed to fetch notifications")
 	}
-	Logger.Info(c.Context()).WithFields("user_id", userIDRaw).Logs("user notifications retrieved successfully")
+
+	Logger.Info(c.Context()).WithFields("user_id", userIDRaw, "count", len(page.Items)).Logs("user notifications retrieved successfully")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":       "Public notifications retrieved successfully",
+		"message":       "User notifications retrieved successfully",
 		"status":        fiber.StatusOK,
-		"notifications": user.Notifications,
+		"notifications": page.Items,
+		"limit":         p.Limit,
+		"next_cursor":   next,
+		"total":         page.Total,
 	})
 }
 
@@ -1555,73 +1173,513 @@ func GetUserNotificationID(c *fiber.Ctx) error {
 	userIDRaw, ok := c.Locals("user_id").(string)
 	if !ok || userIDRaw == "" {
 		Logger.Warn(c.Context()).Logs("GetUserNotificationID attempted without user_id in context")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Unauthorized",
-			"status": fiber.StatusUnauthorized,
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 
-	notificationID := c.Params("notificationId")
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetUserNotificationID")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	notificationID := strings.ReplaceAll(c.Params("notificationId"), " ", "")
 	if notificationID == "" {
 		Logger.Warn(c.Context()).Logs("GetUserNotificationID attempted without notification_id in URL")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Notification ID is required",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Notification ID is required")
 	}
 
 	notiID, err := uuid.Parse(notificationID)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err, "notification_id", notificationID).Logs("Invalid notification ID format in GetUserNotificationID")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid notification ID",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid notification ID")
 	}
 
-	cacheKey := "user:" + userIDRaw
-	cachedNotifications, err := Redis.Get(c.Context(), cacheKey).Result()
-	if err == nil {
-		var publicUser models.User
-		if err := json.Unmarshal([]byte(cachedNotifications), &publicUser); err == nil {
-			Logger.Info(c.Context()).WithFields("user_id", userIDRaw).Logs("user notifications served from cache")
-			var k models.Notification
-			for v, notification := range publicUser.Notifications {
-				if notification.ID == notiID {
-					k = publicUser.Notifications[v]
-					break
-				}
-			}
-			return c.Status(fiber.StatusOK).JSON(fiber.Map{
-				"message":      "User notifications retrieved successfully",
-				"status":       fiber.StatusOK,
-				"notification": k,
-			})
+	notification, err := models.GetUserNotification(c.Context(), Redis, DB, userID, notiID)
+	if err != nil {
+		var appErr *utils.CustomError
+		if utils.As(err, &appErr) && appErr.Code == fiber.StatusNotFound {
+			Logger.Warn(c.Context()).WithFields("user_id", userID, "notification_id", notiID).Logs("Notification not found for user")
+			return apierror.NotFound("Notification not found")
 		}
-		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Failed to unmarshal cached user notifications")
+		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to fetch user notification")
+		return apierror.Internal("Failed to fetch notification")
 	}
 
-	if strings.Contains(notificationID, " ") {
-		Logger.Warn(c.Context()).Logs("GetUserNotificationID attempted with notification_id containing spaces")
-		notificationID = strings.ReplaceAll(notificationID, " ", "")
+	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("user notification retrieved successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":      "User notification retrieved successfully",
+		"status":       fiber.StatusOK,
+		"notification": notification,
+	})
+}
+
+// MarkNotificationRead sets the read state of one of the user's notifications; the body may carry
+// {"read": false} to mark it unread again
+func MarkNotificationRead(c *fiber.Ctx) error {
+	type MarkReadRequest struct {
+		Read *bool `json:"read"`
+	}
+
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("MarkNotificationRead attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
 	}
-	notificationid, _ := uuid.Parse(notificationID)
-	notification, err := models.GetNotification(c.Context(), Redis, DB, notificationid)
+
+	userID, err := uuid.Parse(userIDRaw)
 	if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userIDRaw).Logs("Failed to fetch user notification")
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":  "Notification not found",
-			"status": fiber.StatusNotFound,
-		})
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in MarkNotificationRead")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	notiID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("notification_id", c.Params("id")).Logs("Invalid notification ID format in MarkNotificationRead")
+		return apierror.BadRequest("Invalid notification ID")
 	}
-	notificationJSON, _ := json.Marshal(notification)
-	if err := Redis.Set(c.Context(), cacheKey, notificationJSON, 5*time.Minute).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userIDRaw).Logs("Failed to cache user notification")
+
+	var req MarkReadRequest
+	if len(c.Body()) > 0 {
+		if err := utils.StrictBodyParser(c, &req); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+			return apierror.BadRequest("Invalid request body")
+		}
+	}
+	read := req.Read == nil || *req.Read
+
+	notification, err := models.UpdateNotification(c.Context(), Redis, DB, userID, notiID, read)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID, "notification_id", notiID).Logs("Failed to update notification")
+		return postError(c, err, "Failed to update notification")
 	}
-	Logger.Info(c.Context()).WithFields("user_id", userIDRaw).Logs("user notification retrieved successfully")
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "notification_id", notiID, "read", read).Logs("Notification read state updated")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":      "User notification retrieved successfully",
+		"message":      "Notification updated successfully",
 		"status":       fiber.StatusOK,
 		"notification": notification,
 	})
 }
+
+// MarkAllNotificationsRead marks every unread notification of the user as read
+func MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("MarkAllNotificationsRead attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in MarkAllNotificationsRead")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	updated, err := models.MarkAllNotificationsRead(c.Context(), Redis, DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to mark notifications as read")
+		return postError(c, err, "Failed to update notifications")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "updated", updated).Logs("All notifications marked as read")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "All notifications marked as read",
+		"status":  fiber.StatusOK,
+		"updated": updated,
+	})
+}
+
+// ArchiveNotifications moves notifications of the user to their archive: those listed in ids, those
+// created before before, or, with neither, every read notification. Unread notifications are only
+// archived with {"unread": true}
+func ArchiveNotifications(c *fiber.Ctx) error {
+	type ArchiveRequest struct {
+		IDs    []uuid.UUID `json:"ids" validate:"omitempty,max=500"`
+		Before *time.Time  `json:"before"`
+		Unread bool        `json:"unread"`
+	}
+
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("ArchiveNotifications attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in ArchiveNotifications")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	var req ArchiveRequest
+	if len(c.Body()) > 0 {
+		if err := utils.StrictBodyParser(c, &req); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body")
+			return apierror.BadRequest("Invalid request body")
+		}
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	archived, err := models.ArchiveNotifications(c.Context(), Redis, DB, userID, models.NotificationArchiveFilter{
+		IDs:       req.IDs,
+		Before:    req.Before,
+		UnreadToo: req.Unread,
+	})
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to archive notifications")
+		return postError(c, err, "Failed to archive notifications")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "archived", archived).Logs("Notifications archived")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Notifications archived successfully",
+		"status":   fiber.StatusOK,
+		"archived": archived,
+	})
+}
+
+// GetArchivedNotifications returns a page of the user's archived notifications, newest first,
+// chained with the returned next_cursor
+func GetArchivedNotifications(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetArchivedNotifications attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetArchivedNotifications")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	p, err := Paginator.Parse(c, 20, 50)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Invalid cursor in GetArchivedNotifications")
+		return apierror.From(err, "Invalid cursor")
+	}
+
+	page, err := models.GetArchivedNotificationsPage(c.Context(), DB, userID, p)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch archived notifications")
+		return postError(c, err, "Failed to fetch archived notifications")
+	}
+	next, err := Paginator.Seal(page.Next)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to seal archived notifications cursor")
+		return apierror.Internal("Failed to fetch archived notifications")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":       "Archived notifications retrieved successfully",
+		"status":        fiber.StatusOK,
+		"notifications": page.Items,
+		"limit":         p.Limit,
+		"next_cursor":   next,
+		"total":         page.Total,
+	})
+}
+
+// GetUnreadNotificationCount returns how many unread notifications the user has
+func GetUnreadNotificationCount(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetUnreadNotificationCount attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetUnreadNotificationCount")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	summary, err := models.GetNotificationSummary(c.Context(), Redis, DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to count unread notifications")
+		return apierror.Internal("Failed to count unread notifications")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Unread notification count retrieved successfully",
+		"status":  fiber.StatusOK,
+		"unread":  summary.Total,
+	})
+}
+
+// SetFollowState idempotently sets whether the authenticated user follows a user
+func SetFollowState(c *fiber.Ctx) error {
+	type FollowStateRequest struct {
+		Following *bool `json:"following" validate:"required"`
+	}
+
+	username := strings.ReplaceAll(c.Params("username"), " ", "")
+	if username == "" || len(username) > 255 {
+		Logger.Warn(c.Context()).WithFields("username", username).Logs("SetFollowState attempted with invalid username")
+		return apierror.BadRequest("Valid username is required")
+	}
+
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("SetFollowState attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	followerID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in SetFollowState")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	var req FollowStateRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", followerID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", followerID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	follower := &models.User{ID: followerID}
+	if err := DB.WithContext(c.Context()).Select("id, username").First(follower).Error; err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", followerID).Logs("Follower not found")
+		return apierror.Unauthorized("User not found")
+	}
+
+	_, changed, err := follower.SetFollowState(c.Context(), Redis, DB, username, *req.Following)
+	if err != nil {
+		var appErr *utils.CustomError
+		if utils.As(err, &appErr) && appErr.Code < fiber.StatusInternalServerError {
+			Logger.Warn(c.Context()).WithFields("error", err, "following_username", username).Logs("Follow state change rejected")
+			return apierror.New(appErr.Code, appErr.Message)
+		}
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", followerID, "following_username", username).Logs("Failed to set follow state")
+		return apierror.Internal("Failed to update follow state")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", followerID, "following_username", username, "following", *req.Following, "changed", changed).Logs("Follow state set")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":   "Follow state updated",
+		"status":    fiber.StatusOK,
+		"following": *req.Following,
+		"changed":   changed,
+	})
+}
+
+// GetSecurityActivity returns a page of the authenticated user's sign-in attempts, most recent
+// first, narrowed by ?status=success or ?status=failed
+func GetSecurityActivity(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetSecurityActivity attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetSecurityActivity")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	var success *bool
+	switch status := c.Query("status"); status {
+	case "":
+	case "success", "failed":
+		ok := status == "success"
+		success = &ok
+	default:
+		return apierror.BadRequest("Status must be success or failed")
+	}
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	events, total, err := models.GetLoginEvents(c.Context(), DB, userID, success, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch login events")
+		return apierror.Internal("Failed to fetch account activity")
+	}
+
+	c.Set("Cache-Control", "no-store, private")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Account activity retrieved successfully",
+		"status":  fiber.StatusOK,
+		"events":  events,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetSecuritySummary returns the login and security overview of the authenticated user
+func GetSecuritySummary(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetSecuritySummary attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetSecuritySummary")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	summary, err := models.GetSecuritySummary(c.Context(), Redis, DB, userID)
+	if err != nil {
+		var appErr *utils.CustomError
+		if utils.As(err, &appErr) && appErr.Code == fiber.StatusNotFound {
+			Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("User not found in GetSecuritySummary")
+			return apierror.NotFound("User not found")
+		}
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch security summary")
+		return apierror.Internal("Failed to fetch security summary")
+	}
+
+	c.Set("Cache-Control", "no-store, private")
+	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("Security summary retrieved successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Security summary retrieved successfully",
+		"status":   fiber.StatusOK,
+		"security": summary,
+	})
+}
+
+// revokeSessionsOnPasswordChange signs the user out everywhere when the session policy asks for it
+func revokeSessionsOnPasswordChange(c *fiber.Ctx, userID uuid.UUID) {
+	if !SessionPolicy.OnPasswordChange {
+		return
+	}
+	if err := models.RevokeSessions(c.Context(), Redis, DB, userID); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to revoke sessions after password change")
+		return
+	}
+	if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventSessionsRevoked, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record session revocation")
+	}
+	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("All sessions revoked after password change")
+}
+
+// GetNotificationSummary returns the authenticated user's unread notification counts per type
+func GetNotificationSummary(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetNotificationSummary attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetNotificationSummary")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	summary, err := models.GetNotificationSummary(c.Context(), Redis, DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch notification summary")
+		return apierror.Internal("Failed to fetch notification summary")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "total", summary.Total).Logs("Notification summary retrieved successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Notification summary retrieved successfully",
+		"status":  fiber.StatusOK,
+		"summary": summary,
+	})
+}
+
+// RevokeProviderSessions signs the authenticated user out of every session established via a provider
+func RevokeProviderSessions(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("RevokeProviderSessions attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in RevokeProviderSessions")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	provider := strings.ToLower(strings.TrimSpace(c.Params("provider")))
+	if provider == "" || len(provider) > 50 {
+		Logger.Warn(c.Context()).WithFields("user_id", userID, "provider", provider).Logs("Invalid provider in RevokeProviderSessions")
+		return apierror.BadRequest("Valid provider is required")
+	}
+
+	revoked, err := models.RevokeProviderSessions(c.Context(), Redis, userID, provider)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID, "provider", provider).Logs("Failed to revoke provider sessions")
+		return apierror.Internal("Failed to revoke sessions")
+	}
+
+	if revoked > 0 {
+		if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventSessionsRevoked, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+			Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record session revocation")
+		}
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "provider", provider, "revoked", revoked).Logs("Provider sessions revoked")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Provider sessions revoked successfully",
+		"status":   fiber.StatusOK,
+		"provider": provider,
+		"revoked":  revoked,
+	})
+}
+
+// GetMyMentions lists the posts and comments that mention the authenticated user
+func GetMyMentions(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetMyMentions attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetMyMentions")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	sourceType := strings.ToLower(strings.TrimSpace(c.Query("type")))
+	if sourceType != "" && sourceType != models.MentionSourcePost && sourceType != models.MentionSourceComment {
+		Logger.Warn(c.Context()).WithFields("user_id", userID, "type", sourceType).Logs("Invalid mention type in GetMyMentions")
+		return apierror.BadRequest("Type must be post or comment")
+	}
+
+	mentions, total, err := models.GetUserMentions(c.Context(), DB, userID, sourceType, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch mentions")
+		return apierror.Internal("Failed to fetch mentions")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "type", sourceType, "page", page, "limit", limit).Logs("Mentions retrieved successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Mentions retrieved successfully",
+		"status":   fiber.StatusOK,
+		"mentions": mentions,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+	})
+}
@@ -3,11 +3,15 @@ package v1
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/auth"
 	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/cache"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"github.com/redis/go-redis/v9"
 )
@@ -20,29 +24,21 @@ func ForgotPassword(c *fiber.Ctx) error {
 	var data ForgotPasswordRequest
 	if err := utils.StrictBodyParser(c, &data); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_email", data.Email).Logs("Invalid request body")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid request body",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid request body")
 	}
 
 	if err := Validator.Validate(data); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_email", data.Email).Logs("Validation failed")
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"error":  err,
-			"status": fiber.StatusUnprocessableEntity,
-		})
+		return apierror.Validation(err)
 	}
 
-	allowed := RateLimitting(c, data.Email, 30*time.Second, 5, "forgot_password_rate:")
-	if !allowed {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error":  "Too many update attempts, try again later",
-			"status": fiber.StatusTooManyRequests,
-		})
+	data.Email = strings.ToLower(strings.TrimSpace(data.Email))
+
+	if rateLimited(c, ForgotPasswordRateLimit, data.Email) {
+		return apierror.RateLimited("Too many update attempts, try again later")
 	}
 
-	user, err := models.GetUserBy(c.Context(), Redis, DB, "email = ?", []interface{}{data.Email}, "")
+	user, err := models.GetUserBy(c.Context(), Redis, DB, "email = ?", []interface{}{data.Email}, models.PreloadMinimal)
 	if err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("email", data.Email).Logs("User not found in ForgotPassword")
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -51,38 +47,32 @@ func ForgotPassword(c *fiber.Ctx) error {
 		})
 	}
 
-	token, _ := utils.GenerateRandomToken(26, 40)
-	gotp, err := utils.GenerateOTP(10)
+	token, err := utils.GenerateRandomToken(43, 64)
 	if err != nil {
-		Logger.Error(c.Context()).Logs(fmt.Sprintf("Failed to generate OTP: %v", err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to generate activation code",
-		})
+		Logger.Error(c.Context()).Logs(fmt.Sprintf("Failed to generate reset token: %v", err))
+		return apierror.Internal("Failed to process request")
 	}
 
-	tokenKey := "reset_token:" + token
+	// Only a hash of the token is stored, and only the newest link of a user stays valid.
+	tokenHash := utils.HashToken(token)
 	expiresIn := 1 * time.Hour
+	userTokenKey := "reset_token_user:" + user.ID.String()
+	if previous, err := Redis.Get(c.Context(), userTokenKey).Result(); err == nil {
+		Redis.Del(c.Context(), "reset_token:"+previous)
+	}
 
-	if err := Redis.Set(c.Context(), tokenKey, gotp, expiresIn).Err(); err != nil {
+	pipe := Redis.TxPipeline()
+	pipe.Set(c.Context(), "reset_token:"+tokenHash, user.ID.String(), expiresIn)
+	pipe.Set(c.Context(), userTokenKey, tokenHash, expiresIn)
+	if _, err := pipe.Exec(c.Context()); err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", user.ID).Logs("Failed to store reset token in Redis")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":  "Failed to process request",
-			"status": fiber.StatusInternalServerError,
-		})
-	}
-	userKey := "user:" + user.ID.String()
-	Redis.Del(c.Context(), userKey)
-	key := "user:" + token
-	if err := Redis.Set(c.Context(), key, user.ID.String(), 1*time.Hour).Err(); err != nil {
-		Logger.Warn(c.Context()).Logs(fmt.Sprintf("Failed to cache user in Redis: %v, key: %s", err, key))
-	} else {
-		Logger.Info(c.Context()).Logs(fmt.Sprintf("User cached in Redis: %s", key))
+		return apierror.Internal("Failed to process request")
 	}
 
-	if err := utils.SendActivationEmail(c.Context(), EmailCfg, user.Email, user.Username, token, gotp, Logger); err != nil {
-		Logger.Warn(c.Context()).Logs(fmt.Sprintf("Email sending failed but user created: %v", err))
+	if err := utils.SendPasswordResetEmail(c.Context(), EmailCfg, user.Email, user.Settings.Locale, user.Username, token, expiresIn, Logger); err != nil {
+		Logger.Warn(c.Context()).Logs(fmt.Sprintf("Password reset email sending failed: %v", err))
 	} else {
-		Logger.Info(c.Context()).Logs(fmt.Sprintf("Activation email sent successfully for user: %s", user.Username))
+		Logger.Info(c.Context()).Logs(fmt.Sprintf("Password reset email sent successfully for user: %s", user.Username))
 	}
 
 	Logger.Info(c.Context()).WithFields("user_id", user.ID).Logs("Password reset token generated and stored in Redis")
@@ -95,8 +85,7 @@ func ForgotPassword(c *fiber.Ctx) error {
 // ResetPassword handles the password reset process
 func ResetPassword(c *fiber.Ctx) error {
 	type ResetPasswordRequest struct {
-		Token           string `json:"token" validate:"required,min=26,max=40"`
-		OTP             string `json:"otp" validate:"required,min=6,max=10"`
+		Token           string `json:"token" validate:"required,min=43,max=64"`
 		NewPassword     string `json:"new_password" validate:"required,min=6"`
 		ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
 	}
@@ -104,114 +93,72 @@ func ResetPassword(c *fiber.Ctx) error {
 	var req ResetPasswordRequest
 	if err := utils.StrictBodyParser(c, &req); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid request body in ResetPassword")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid request body",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid request body")
 	}
 
 	if err := Validator.Validate(req); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).Logs("Validation failed in ResetPassword")
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"error":  err,
-			"status": fiber.StatusUnprocessableEntity,
-		})
+		return apierror.Validation(err)
 	}
 
 	if utils.ContainsInvalidChars(req.NewPassword) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"Message": "password contains invalid characters",
-			"status":  fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("password contains invalid characters")
 	}
 
-	tokenKey := "reset_token:" + req.Token
-	otp, err := Redis.Get(c.Context(), tokenKey).Result()
+	tokenKey := "reset_token:" + utils.HashToken(req.Token)
+	userIDRaw, err := Redis.Get(c.Context(), tokenKey).Result()
 	if err == redis.Nil {
-		Logger.Warn(c.Context()).WithFields("token", req.Token).Logs("Invalid or expired reset token")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid or expired reset token",
-			"status": fiber.StatusBadRequest,
-		})
+		Logger.Warn(c.Context()).Logs("Invalid or expired reset token")
+		return apierror.BadRequest("Invalid or expired reset token")
 	} else if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).WithFields("token", req.Token).Logs("Failed to fetch reset token from Redis")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":  "Failed to process request",
-			"status": fiber.StatusInternalServerError,
-		})
-	}
-
-	userIDRaw, err := Redis.Get(c.Context(), "user:"+req.Token).Result()
-	if err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).Logs("User not found or expired")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid or expired User data",
-		})
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch reset token from Redis")
+		return apierror.Internal("Failed to process request")
 	}
 
 	userID, err := uuid.Parse(userIDRaw)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err, "user_id_raw", userIDRaw).Logs("Invalid user ID in reset token")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":  "Failed to process request",
-			"status": fiber.StatusInternalServerError,
-		})
-	}
-
-	if req.OTP != otp {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Invalid OTP in ResetPassword")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid OTP",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.Internal("Failed to process request")
 	}
 
-	allowed := RateLimitting(c, userIDRaw, 1*time.Minute, 5, "forgot_password_rate:")
-	if !allowed {
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error":  "Too many update attempts, try again later",
-			"status": fiber.StatusTooManyRequests,
-		})
+	if rateLimited(c, ResetPasswordRateLimit, userIDRaw) {
+		return apierror.RateLimited("Too many update attempts, try again later")
 	}
 
-	user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{userID}, "")
+	user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{userID}, models.PreloadMinimal)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("User not found in ResetPassword")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Invalid or expired reset token", // Don’t leak user existence
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Invalid or expired reset token") // Don’t leak user existence
 	}
 
 	if !utils.IsStrongPassword(req.NewPassword) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"Message": "new password does not meet strength requirements",
-			"status":  fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("new password does not meet strength requirements")
 	}
 
 	if utils.IsPasswordReused(user.PreviousPasswords, req.NewPassword) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"Message": "new password cannot match previous passwords",
-			"status":  fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("new password cannot match previous passwords")
 	}
 
 	hashedPassword, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to hash new password")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":  "Failed to process password",
-			"status": fiber.StatusInternalServerError,
-		})
+		return apierror.Internal("Failed to process password")
 	}
 
+	// Consume the token before updating so concurrent requests cannot reuse it.
+	if consumed, err := Redis.Del(c.Context(), tokenKey).Result(); err != nil || consumed == 0 {
+		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Reset token already used")
+		return apierror.BadRequest("Invalid or expired reset token")
+	}
+	Redis.Del(c.Context(), "reset_token_user:"+userID.String())
+
 	updatedUser, err := models.UpdateUser(
 		c.Context(),
 		Redis,
 		DB,
 		userID,
 		models.WithPassword(string(hashedPassword)),
+		models.WithPasswordless(false),
 		models.WithPreviousPasswords(utils.UpdatePreviousPasswords(user.PreviousPasswords, user.Password)),
 		models.WithPasswordChangedAt(time.Now()),
 	)
@@ -219,38 +166,13 @@ func ResetPassword(c *fiber.Ctx) error {
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to update user password")
 		if err.Error() == "user not found" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+			return apierror.NotFound("User not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update password"})
-	}
-
-	if err := Redis.Del(c.Context(), tokenKey).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("token", req.Token).Logs("Failed to delete reset token from Redis")
-	}
-
-	if err := Redis.Del(c.Context(), tokenKey).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("token", req.Token).Logs("Failed to delete reset token from Redis")
+		return apierror.Internal("Failed to update password")
 	}
 
 	if utils.IsLoggedIn(c) {
-		c.Cookie(&fiber.Cookie{
-			Name:     "access_token",
-			Value:    "",
-			Expires:  time.Now().Add(-time.Hour),
-			HTTPOnly: true,
-			Secure:   true,
-			SameSite: "Strict",
-		})
-		c.Cookie(&fiber.Cookie{
-			Name:     "refresh_token",
-			Value:    "",
-			Expires:  time.Now().Add(-time.Hour),
-			HTTPOnly: true,
-			Secure:   true,
-			SameSite: "Strict",
-		})
-		c.ClearCookie("access_token")
-		c.ClearCookie("refresh_token")
+		auth.ClearTokens(c)
 
 		Redis.Del(c.Context(), userID.String())
 
@@ -262,13 +184,19 @@ func ResetPassword(c *fiber.Ctx) error {
 		c.Set("Content-Security-Policy", "default-src 'self'")
 	}
 
-	userKey := "user:" + userID.String()
-	Redis.Del(c.Context(), userKey)
+	userKey := cache.UserKey(userID)
 	userJSON, _ := json.Marshal(updatedUser)
-	if err := Redis.Set(c.Context(), userKey, userJSON, 30*time.Minute).Err(); err != nil {
+	if err := Redis.WriteBack(c.Context(), userKey, userJSON, Redis.TTL("user")); err != nil {
 		Logger.Warn(c.Context()).WithFields("error", err).WithFields("user_id", userID).Logs("Failed to update Redis cache")
 	}
 
+	recordAudit(c, userID, models.AuditPasswordReset, models.AuditTargetUser, userID, nil, map[string]interface{}{"password_changed_at": time.Now().UTC()})
+	revokeSessionsOnPasswordChange(c, userID)
+
+	if err := models.RecordSecurityEvent(c.Context(), DB, userID, models.EventPasswordReset, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to record password reset")
+	}
+
 	Logger.Info(c.Context()).WithFields("user_id", userID).Logs("Password reset successfully")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Password reset successfully. Please try logging in",
@@ -276,100 +204,240 @@ func ResetPassword(c *fiber.Ctx) error {
 	})
 }
 
-// GetUserByUsername returns a user by username
-func GetUserByUsername(c *fiber.Ctx) error {
-	username := c.Params("username")
-	if username == "" {
-		Logger.Warn(c.Context()).Logs("Missing username query parameter in GetPublicUserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username is required",
-			"status": fiber.StatusBadRequest,
-		})
+// getPublicUser loads the user behind the public profile endpoints, which share one cache entry
+func getPublicUser(c *fiber.Ctx, username string) (*models.User, error) {
+	return Services.Users.GetByUsername(c.Context(), username)
+}
+
+// profileAccess is how much of a user's account a viewer may see, from least to most.
+type profileAccess int
+
+const (
+	// accessPublic is what anyone may see: no contact details, settings, notifications or
+	// credentials.
+	accessPublic profileAccess = iota
+	// accessStaff adds the account details staff act on: email, role and standing.
+	accessStaff
+	// accessSelf is everything users see of their own account.
+	accessSelf
+)
+
+// staffProfilePermissions let a viewer see the account details of other users.
+var staffProfilePermissions = []string{"moderate_user", "edit_any_user"}
+
+// Viewer is who a profile is rendered for: a user and the permissions of their role, or an
+// anonymous visitor with neither.
+type Viewer struct {
+	ID          *uuid.UUID
+	Permissions *models.PermissionSnapshot
+}
+
+// access is how much of subject the viewer may see.
+func (v Viewer) access(subject *models.User) profileAccess {
+	switch {
+	case v.ID != nil && *v.ID == subject.ID:
+		return accessSelf
+	case v.Permissions != nil && v.Permissions.Has(staffProfilePermissions...):
+		return accessStaff
+	default:
+		return accessPublic
 	}
+}
 
-	if len(username) < 3 || len(username) > 255 {
-		Logger.Warn(c.Context()).WithFields("username", username).Logs("Invalid username length in GetPublicUserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username must be between 3 and 255 characters",
-			"status": fiber.StatusBadRequest,
-		})
+// selfViewer is a user looking at their own account.
+func selfViewer(user *models.User) Viewer {
+	return Viewer{ID: &user.ID}
+}
+
+// requestViewer is whoever authentication found behind the request. A viewer whose permissions
+// cannot be read is served as an anonymous one, never as staff.
+func requestViewer(c *fiber.Ctx) Viewer {
+	viewer := Viewer{ID: viewerID(c)}
+	roleID, ok := c.Locals("role_id").(uuid.UUID)
+	if viewer.ID == nil || !ok {
+		return viewer
+	}
+	snapshot, err := models.GetPermissionSnapshot(c.Context(), Redis, DB, roleID)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "role_id", roleID).Logs("Failed to get viewer permissions")
+		return viewer
+	}
+	viewer.Permissions = snapshot
+	return viewer
+}
+
+// publicViewer is the viewer of the public profile endpoints. Users see their own public profile
+// as anyone holding their permissions would; the private sections are served by /users/me, and
+// the record behind the public profile does not carry them.
+func publicViewer(c *fiber.Ctx) Viewer {
+	viewer := requestViewer(c)
+	viewer.ID = nil
+	return viewer
+}
+
+// profileSection adds one section of a user to a profile response, with the fields access allows.
+type profileSection func(res fiber.Map, user *models.User, access profileAccess)
+
+// profileSections are the sections clients can pick with ?fields=. Every response carries the
+// id, username and created_at of the user whatever the sections.
+var profileSections = map[string]profileSection{
+	"profile": func(res fiber.Map, user *models.User, access profileAccess) {
+		res["name"] = user.Profile.Name
+		res["bio"] = user.Profile.Bio
+		res["avatar_url"] = user.Profile.AvatarURL
+		res["job_title"] = user.Profile.JobTitle
+		res["employer"] = user.Profile.Employer
+		res["location"] = user.Profile.Location
+		res["social_links"] = user.Profile.SocialLinks
+		res["current_learning"] = user.Profile.CurrentLearning
+		res["available_for"] = user.Profile.AvailableFor
+		res["currently_hacking_on"] = user.Profile.CurrentlyHackingOn
+		res["pronouns"] = user.Profile.Pronouns
+		res["education"] = user.Profile.Education
+		res["skills"] = user.Profile.Skills
+		res["interests"] = user.Profile.Interests
+	},
+	"settings": func(res fiber.Map, user *models.User, access profileAccess) {
+		res["brand_color"] = user.Settings.BrandColor
+		if access < accessSelf {
+			return
+		}
+		res["theme_preference"] = user.Settings.ThemePreference
+		res["base_font"] = user.Settings.BaseFont
+		res["site_navbar"] = user.Settings.SiteNavbar
+		res["content_editor"] = user.Settings.ContentEditor
+		res["content_mode"] = user.Settings.ContentMode
+		res["locale"] = user.Settings.Locale
+	},
+	"stats": func(res fiber.Map, user *models.User, access profileAccess) {
+		res["posts_count"] = user.Stats.PostsCount
+		res["comments_count"] = user.Stats.CommentsCount
+		res["likes_count"] = user.Stats.LikesCount
+		res["followers_count"] = user.Stats.FollowersCount
+		res["following_count"] = user.Stats.FollowingCount
+		res["last_seen"] = user.Stats.LastSeen
+		if access == accessSelf {
+			res["bookmarks_count"] = user.Stats.BookmarksCount
+		}
+	},
+	"badges": func(res fiber.Map, user *models.User, access profileAccess) {
+		res["badges"] = user.Badges
+	},
+	"account": func(res fiber.Map, user *models.User, access profileAccess) {
+		if access < accessStaff {
+			return
+		}
+		res["email"] = user.Email
+		res["updated_at"] = user.UpdatedAt
+		res["roles"] = user.Role
+		res["status"] = user.Status
+		res["status_reason"] = user.StatusReason
+		res["suspended_until"] = user.SuspendedUntil
+		res["is_active"] = user.IsActive
+		res["is_email_verified"] = user.IsEmailVerified
+		res["last_login_at"] = user.LastLoginAt
+		res["two_factor_enabled"] = user.TwoFactorEnabled
+	},
+	"notification_preferences": func(res fiber.Map, user *models.User, access profileAccess) {
+		if access == accessSelf {
+			res["notification_preferences"] = user.NotificationPreferences
+		}
+	},
+	"followers": func(res fiber.Map, user *models.User, access profileAccess) {
+		if access == accessSelf {
+			res["followers"] = models.Summaries(user.Followers)
+		}
+	},
+	"following": func(res fiber.Map, user *models.User, access profileAccess) {
+		if access == accessSelf {
+			res["following"] = models.Summaries(user.Following)
+		}
+	},
+	"notifications": func(res fiber.Map, user *models.User, access profileAccess) {
+		if access == accessSelf {
+			res["notifications"] = user.Notifications
+		}
+	},
+}
+
+// defaultProfileSections are rendered when a client names none. The follow lists and
+// notifications have paginated endpoints of their own and must be asked for.
+var defaultProfileSections = []string{"profile", "settings", "stats", "badges", "account", "notification_preferences"}
+
+// profileFields reads the sections requested with ?fields=profile,settings,stats. Without the
+// parameter it returns the default sections.
+func profileFields(c *fiber.Ctx) ([]string, error) {
+	raw := strings.TrimSpace(c.Query("fields"))
+	if raw == "" {
+		return defaultProfileSections, nil
+	}
+	fields := []string{}
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if _, ok := profileSections[field]; !ok {
+			Logger.Warn(c.Context()).WithFields("field", field).Logs("Unknown profile field requested")
+			return nil, apierror.BadRequest("Unknown profile field: " + field)
+		}
+		fields = append(fields, field)
 	}
+	return fields, nil
+}
 
-	cacheKey := "public_user:" + username
-	cachedProfile, err := Redis.Get(c.Context(), cacheKey).Result()
-	if err == nil {
-		var publicUser *models.User
-		if err := json.Unmarshal([]byte(cachedProfile), &publicUser); err == nil {
-			Logger.Info(c.Context()).WithFields("username", username).Logs("Public user profile served from cache")
-			return c.Status(fiber.StatusOK).JSON(fiber.Map{
-				"message": "Public profile retrieved successfully",
-				"status":  fiber.StatusOK,
-				"user":    publicUser,
-			})
+// BuildProfileResponse renders the given sections of a user for viewer, or the default sections
+// when none are given. What each section holds depends on what the viewer may see of the user:
+// everything of their own account, the account details for staff, the public fields otherwise.
+func BuildProfileResponse(viewer Viewer, user *models.User, sections ...string) fiber.Map {
+	if len(sections) == 0 {
+		sections = defaultProfileSections
+	}
+	access := viewer.access(user)
+	res := fiber.Map{
+		"id":         user.ID,
+		"username":   user.Username,
+		"created_at": user.CreatedAt,
+	}
+	for _, name := range sections {
+		if section, ok := profileSections[name]; ok {
+			section(res, user, access)
 		}
-		Logger.Warn(c.Context()).WithFields("error", err, "username", username).Logs("Failed to unmarshal cached public user")
 	}
+	return res
+}
+
+// selfProfile renders a user's own profile with the default sections, or nil for no user
+func selfProfile(user *models.User) fiber.Map {
+	if user == nil {
+		return nil
+	}
+	return BuildProfileResponse(selfViewer(user), user)
+}
 
-	user, err := models.GetUserBy(c.Context(), Redis, DB, "username = ?", []interface{}{username}, "")
+// GetUserByUsername returns the public profile of a user, with the account details when the
+// viewer is staff
+func GetUserByUsername(c *fiber.Ctx) error {
+	username, err := publicUsername(c, "GetUserByUsername")
 	if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to fetch user by username")
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":  "User not found",
-			"status": fiber.StatusNotFound,
-		})
+		return err
+	}
+	fields, err := profileFields(c)
+	if err != nil {
+		return err
 	}
 
-	userJSON, _ := json.Marshal(user)
-	if err := Redis.Set(c.Context(), cacheKey, userJSON, 5*time.Minute).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to cache public user profile")
-	}
-
-	profileResponse := fiber.Map{
-		"id":                       user.ID,
-		"username":                 user.Username,
-		"email":                    user.Email,
-		"name":                     user.Profile.Name,
-		"bio":                      user.Profile.Bio,
-		"avatar_url":               user.Profile.AvatarURL,
-		"job_title":                user.Profile.JobTitle,
-		"employer":                 user.Profile.Employer,
-		"location":                 user.Profile.Location,
-		"social_links":             user.Profile.SocialLinks,
-		"current_learning":         user.Profile.CurrentLearning,
-		"available_for":            user.Profile.AvailableFor,
-		"currently_hacking_on":     user.Profile.CurrentlyHackingOn,
-		"pronouns":                 user.Profile.Pronouns,
-		"education":                user.Profile.Education,
-		"brand_color":              user.Settings.BrandColor,
-		"posts_count":              user.Stats.PostsCount,
-		"comments_count":           user.Stats.CommentsCount,
-		"likes_count":              user.Stats.LikesCount,
-		"bookmarks_count":          user.Stats.BookmarksCount,
-		"last_seen":                user.Stats.LastSeen,
-		"theme_preference":         user.Settings.ThemePreference,
-		"base_font":                user.Settings.BaseFont,
-		"site_navbar":              user.Settings.SiteNavbar,
-		"content_editor":           user.Settings.ContentEditor,
-		"content_mode":             user.Settings.ContentMode,
-		"created_at":               user.CreatedAt,
-		"updated_at":               user.UpdatedAt,
-		"skills":                   user.Profile.Skills,
-		"interests":                user.Profile.Interests,
-		"badges":                   user.Badges,
-		"roles":                    user.Role,
-		"followers":                user.Followers,
-		"following":                user.Following,
-		"notifications":            user.Notifications,
-		"notification_preferences": user.NotificationPreferences,
-		"previous_passwords":       user.PreviousPasswords,
-		"last_password_change":     user.LastPasswordChange,
+	user, err := getPublicUser(c, username)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to fetch user by username")
+		return apierror.NotFound("User not found")
 	}
 
 	Logger.Info(c.Context()).WithFields("username", username).Logs("Public user profile retrieved successfully")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Public profile retrieved successfully",
 		"status":  fiber.StatusOK,
-		"user":    profileResponse,
+		"user":    BuildProfileResponse(publicViewer(c), user, fields...),
 	})
 }
 
@@ -378,46 +446,17 @@ func GetUserStats(c *fiber.Ctx) error {
 	username := c.Query("username")
 	if username == "" {
 		Logger.Warn(c.Context()).Logs("Missing username query parameter in GetUserStats")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username is required",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Username is required")
 	}
 	if len(username) < 3 || len(username) > 255 {
 		Logger.Warn(c.Context()).WithFields("username", username).Logs("Invalid username length in GetUserStats")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username must be between 3 and 255 characters",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Username must be between 3 and 255 characters")
 	}
 
-	var user *models.User
-	cacheKey := "public_user:" + username
-	cachedStats, err := Redis.Get(c.Context(), cacheKey).Result()
-	if err == nil {
-		if err := json.Unmarshal([]byte(cachedStats), &user); err == nil {
-			Logger.Info(c.Context()).WithFields("username", username).Logs("User stats served from cache")
-			return c.Status(fiber.StatusOK).JSON(fiber.Map{
-				"message": "User stats retrieved successfully",
-				"status":  fiber.StatusOK,
-				"stats":   user,
-			})
-		}
-		Logger.Warn(c.Context()).WithFields("error", err, "username", username).Logs("Failed to unmarshal cached stats")
-	} else {
-		user, err = models.GetUserBy(c.Context(), Redis, DB, "username = ?", []interface{}{username}, "")
-		if err != nil {
-			Logger.Warn(c.Context()).WithFields("error", err).WithFields("username", username).Logs("User not found in GetUserStats")
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error":  "User not found",
-				"status": fiber.StatusNotFound,
-			})
-		}
-	}
-
-	statsJSON, _ := json.Marshal(user.Stats)
-	if err := Redis.Set(c.Context(), cacheKey, statsJSON, 5*time.Minute).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to cache user stats")
+	user, err := getPublicUser(c, username)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("username", username).Logs("User not found in GetUserStats")
+		return apierror.NotFound("User not found")
 	}
 
 	Logger.Info(c.Context()).WithFields("username", username).Logs("User stats retrieved successfully")
@@ -428,110 +467,72 @@ func GetUserStats(c *fiber.Ctx) error {
 	})
 }
 
-// GetUserFollowers returns user followers
+// GetUserFollowers returns a page of the users following a user, ordered by username. Pages are
+// chained with the returned next_cursor; ?total=true adds the number of followers.
 func GetUserFollowers(c *fiber.Ctx) error {
-	username := c.Params("username")
-	if username == "" {
-		Logger.Warn(c.Context()).Logs("Missing username query parameter in GetPublicUserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username is required",
-			"status": fiber.StatusBadRequest,
-		})
-	}
+	return listFollows(c, true)
+}
 
-	if len(username) < 3 || len(username) > 255 {
-		Logger.Warn(c.Context()).WithFields("username", username).Logs("Invalid username length in GetPublicUserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username must be between 3 and 255 characters",
-			"status": fiber.StatusBadRequest,
-		})
+// GetUserFollowing returns a page of the users a user follows, ordered by username
+func GetUserFollowing(c *fiber.Ctx) error {
+	return listFollows(c, false)
+}
+
+func listFollows(c *fiber.Ctx, followers bool) error {
+	relation := "following"
+	if followers {
+		relation = "followers"
+	}
+	username, err := publicUsername(c, "list "+relation)
+	if err != nil {
+		return err
+	}
+	p, err := Paginator.Parse(c, 20, 100)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("username", username).Logs("Invalid cursor in list " + relation)
+		return apierror.From(err, "Invalid cursor")
 	}
 
-	cacheKey := "public_user:" + username
-	cachedFollowers, err := Redis.Get(c.Context(), cacheKey).Result()
-	if err == nil {
-		var publicUser models.User
-		if err := json.Unmarshal([]byte(cachedFollowers), &publicUser); err == nil {
-			Logger.Info(c.Context()).WithFields("username", username).Logs("Public user followers served from cache")
-			return c.Status(fiber.StatusOK).JSON(fiber.Map{
-				"message":   "Public followers retrieved successfully",
-				"status":    fiber.StatusOK,
-				"followers": publicUser.Followers,
-			})
-		}
-		Logger.Warn(c.Context()).WithFields("error", err, "username", username).Logs("Failed to unmarshal cached public user followers")
+	user, err := getPublicUser(c, username)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to fetch user by username")
+		return apierror.NotFound("User not found")
 	}
-	user, err := models.GetUserBy(c.Context(), Redis, DB, "username = ?", []interface{}{username}, "")
+
+	page, err := models.GetFollowsPage(c.Context(), Redis, DB, user.ID, followers, p)
 	if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to fetch user by username")
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":  "User not found",
-			"status": fiber.StatusNotFound,
-		})
+		Logger.Error(c.Context()).WithFields("error", err, "username", username).Logs("Failed to fetch " + relation)
+		return apierror.From(err, "Failed to fetch "+relation)
 	}
-	followersJSON, _ := json.Marshal(user.Followers)
-	if err := Redis.Set(c.Context(), cacheKey, followersJSON, 5*time.Minute).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to cache public user followers")
+	next, err := Paginator.Seal(page.Next)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to seal " + relation + " cursor")
+		return apierror.Internal("Failed to fetch " + relation)
 	}
-	Logger.Info(c.Context()).WithFields("username", username).Logs("Public user followers retrieved successfully")
+
+	Logger.Info(c.Context()).WithFields("username", username, "count", len(page.Items)).Logs("Public user " + relation + " retrieved successfully")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":   "Public followers retrieved successfully",
-		"status":    fiber.StatusOK,
-		"followers": user.Followers,
+		"message":     "Public " + relation + " retrieved successfully",
+		"status":      fiber.StatusOK,
+		relation:      page.Items,
+		"limit":       p.Limit,
+		"next_cursor": next,
+		"total":       page.Total,
 	})
 }
 
-// GetUserFollowing returns user following
-func GetUserFollowing(c *fiber.Ctx) error {
+// publicUsername reads and checks the username path parameter of the public profile endpoints
+func publicUsername(c *fiber.Ctx, handler string) (string, error) {
 	username := c.Params("username")
 	if username == "" {
-		Logger.Warn(c.Context()).Logs("Missing username query parameter in GetPublicUserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username is required",
-			"status": fiber.StatusBadRequest,
-		})
+		Logger.Warn(c.Context()).Logs("Missing username parameter in " + handler)
+		return "", apierror.BadRequest("Username is required")
 	}
-
 	if len(username) < 3 || len(username) > 255 {
-		Logger.Warn(c.Context()).WithFields("username", username).Logs("Invalid username length in GetPublicUserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username must be between 3 and 255 characters",
-			"status": fiber.StatusBadRequest,
-		})
+		Logger.Warn(c.Context()).WithFields("username", username).Logs("Invalid username length in " + handler)
+		return "", apierror.BadRequest("Username must be between 3 and 255 characters")
 	}
-
-	cacheKey := "public_user:" + username
-	cachedFollowing, err := Redis.Get(c.Context(), cacheKey).Result()
-	if err == nil {
-		var publicUser models.User
-		if err := json.Unmarshal([]byte(cachedFollowing), &publicUser); err == nil {
-			Logger.Info(c.Context()).WithFields("username", username).Logs("Public user following served from cache")
-			return c.Status(fiber.StatusOK).JSON(fiber.Map{
-				"message":   "Public following retrieved successfully",
-				"status":    fiber.StatusOK,
-				"following": publicUser.Following,
-			})
-		}
-		Logger.Warn(c.Context()).WithFields("error", err, "username", username).Logs("Failed to unmarshal cached public user following")
-	}
-	user, err := models.GetUserBy(c.Context(), Redis, DB, "username = ?", []interface{}{username}, "")
-	if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to fetch user by username")
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":  "User not found",
-			"status": fiber.StatusNotFound,
-		})
-	}
-	followingJSON, _ := json.Marshal(user.Following)
-	if err := Redis.Set(c.Context(), cacheKey, followingJSON, 5*time.Minute).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to cache public user following")
-	}
-	Logger.Info(c.Context()).WithFields("username", username).Logs("Public user following retrieved successfully")
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":   "Public following retrieved successfully",
-		"status":    fiber.StatusOK,
-		"following": user.Following,
-	})
+	return username, nil
 }
 
 // GetUserBadges returns user badges
@@ -539,42 +540,18 @@ func GetUserBadges(c *fiber.Ctx) error {
 	username := c.Params("username")
 	if username == "" {
 		Logger.Warn(c.Context()).Logs("Missing username query parameter in GetPublicUserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username is required",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Username is required")
 	}
 
 	if len(username) < 3 || len(username) > 255 {
 		Logger.Warn(c.Context()).WithFields("username", username).Logs("Invalid username length in GetPublicUserProfile")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":  "Username must be between 3 and 255 characters",
-			"status": fiber.StatusBadRequest,
-		})
+		return apierror.BadRequest("Username must be between 3 and 255 characters")
 	}
 
-	cacheKey := "public_user:" + username
-	cachedBadges, err := Redis.Get(c.Context(), cacheKey).Result()
-	if err == nil {
-		var publicUser models.User
-		if err := json.Unmarshal([]byte(cachedBadges), &publicUser); err == nil {
-			Logger.Info(c.Context()).WithFields("username", username).Logs("user badges served from cache")
-			return c.Status(fiber.StatusOK).JSON(fiber.Map{
-				"message": "User badges retrieved successfully",
-				"status":  fiber.StatusOK,
-				"badges":  publicUser.Badges,
-			})
-		}
-		Logger.Warn(c.Context()).WithFields("error", err, "username", username).Logs("Failed to unmarshal cached user badges")
-	}
-
-	user, err := models.GetUserBy(c.Context(), Redis, DB, "username = ?", []interface{}{username}, "")
+	user, err := getPublicUser(c, username)
 	if err != nil {
-		Logger.Error(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to fetch user by id")
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":  "User not found",
-			"status": fiber.StatusNotFound,
-		})
+		Logger.Error(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to fetch user by username")
+		return apierror.NotFound("User not found")
 	}
 
 	if len(user.Badges) == 0 {
@@ -585,10 +562,6 @@ func GetUserBadges(c *fiber.Ctx) error {
 			"badges":  []string{},
 		})
 	}
-	badgesJSON, _ := json.Marshal(user.Badges)
-	if err := Redis.Set(c.Context(), cacheKey, badgesJSON, 5*time.Minute).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("error", err).WithFields("username", username).Logs("Failed to cache user badges")
-	}
 	Logger.Info(c.Context()).WithFields("username", username).Logs("user badges retrieved successfully")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "User badges retrieved successfully",
@@ -596,3 +569,113 @@ func GetUserBadges(c *fiber.Ctx) error {
 		"badges":  user.Badges,
 	})
 }
+
+// GetActiveUsers returns a directory of recently active users
+func GetActiveUsers(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	window := 7 * 24 * time.Hour
+	if raw := c.Query("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 || parsed > 30*24*time.Hour {
+			Logger.Warn(c.Context()).WithFields("window", raw).Logs("Invalid activity window in GetActiveUsers")
+			return apierror.BadRequest("Window must be a positive duration of at most 720h")
+		}
+		window = parsed
+	}
+
+	tag := strings.ToLower(strings.TrimSpace(c.Query("tag")))
+	if len(tag) > 50 {
+		Logger.Warn(c.Context()).WithFields("tag", tag).Logs("Invalid tag length in GetActiveUsers")
+		return apierror.BadRequest("Tag must be at most 50 characters")
+	}
+
+	users, err := models.GetActiveUsers(c.Context(), Redis, DB, window, tag, page, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch active users")
+		return apierror.Internal("Failed to fetch active users")
+	}
+
+	Logger.Info(c.Context()).WithFields("page", page, "limit", limit, "tag", tag).Logs("Active users retrieved successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Active users retrieved successfully",
+		"status":  fiber.StatusOK,
+		"users":   users,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetFollowSuggestions suggests users for the authenticated user to follow, ranked by mutual
+// follows, shared tags and recent activity.
+func GetFollowSuggestions(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("GetFollowSuggestions attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in GetFollowSuggestions")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	users, err := models.GetFollowSuggestions(c.Context(), Redis, DB, userID, limit)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch follow suggestions")
+		return apierror.Internal("Failed to fetch follow suggestions")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "count", len(users)).Logs("Follow suggestions retrieved successfully")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Follow suggestions retrieved successfully",
+		"status":  fiber.StatusOK,
+		"users":   users,
+		"limit":   limit,
+	})
+}
+
+// SuggestMentions completes the @-mention ?q= for the authenticated user with up to 10 users whose
+// username starts with it, people they follow and talk to first.
+func SuggestMentions(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("SuggestMentions attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in SuggestMentions")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	limit := c.QueryInt("limit", models.MaxMentionSuggestions)
+	users, err := models.SuggestMentions(c.Context(), Redis, DB, userID, c.Query("q"), limit)
+	if err != nil {
+		var appErr *utils.CustomError
+		if utils.As(err, &appErr) && appErr.Code < fiber.StatusInternalServerError {
+			return apierror.New(appErr.Code, appErr.Message)
+		}
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch mention suggestions")
+		return apierror.Internal("Failed to fetch mention suggestions")
+	}
+
+	c.Set("Cache-Control", "no-store, private")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Mention suggestions retrieved successfully",
+		"status":  fiber.StatusOK,
+		"users":   users,
+	})
+}
@@ -0,0 +1,184 @@
+package v1_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/testutil"
+)
+
+// seenAgo sets when user was last seen.
+func seenAgo(t *testing.T, e *testutil.Env, user *models.User, ago time.Duration) {
+	t.Helper()
+	if err := e.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("last_seen", time.Now().Add(-ago)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// activeUsernames returns the usernames of a page of the active users directory, in order.
+func activeUsernames(t *testing.T, e *testutil.Env, query string) []string {
+	t.Helper()
+	res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/users/active" + query})
+	if res.Status != http.StatusOK {
+		t.Fatalf("GET /users/active%s: status %d: %s", query, res.Status, res.Body)
+	}
+	var body struct {
+		Users []models.UserSummary `json:"users"`
+	}
+	res.JSON(t, &body)
+	names := make([]string, len(body.Users))
+	for i, u := range body.Users {
+		names[i] = u.Username
+	}
+	return names
+}
+
+func equalNames(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGetActiveUsers(t *testing.T) {
+	e := testutil.Setup(t)
+
+	recent := e.CreateUser(t, models.RoleMember, models.WithSkills([]string{"go"}))
+	earlier := e.CreateUser(t, models.RoleMember, models.WithInterests([]string{"go"}))
+	earliest := e.CreateUser(t, models.RoleMember)
+	stale := e.CreateUser(t, models.RoleMember)
+	unverified := e.CreateUser(t, models.RoleMember, models.WithEmailVerified(false))
+	banned := e.CreateUser(t, models.RoleMember)
+	seenAgo(t, e, recent, time.Minute)
+	seenAgo(t, e, earlier, time.Hour)
+	seenAgo(t, e, earliest, 2*time.Hour)
+	seenAgo(t, e, stale, 10*24*time.Hour)
+	seenAgo(t, e, unverified, time.Second)
+	seenAgo(t, e, banned, time.Second)
+	if err := e.DB.Model(&models.User{}).Where("id = ?", banned.ID).Update("status", models.UserStatusBanned).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("most recently active first", func(t *testing.T) {
+		if got := activeUsernames(t, e, ""); !equalNames(got, recent.Username, earlier.Username, earliest.Username) {
+			t.Fatalf("got %v, want %s, %s, %s", got, recent.Username, earlier.Username, earliest.Username)
+		}
+	})
+	t.Run("pages", func(t *testing.T) {
+		if got := activeUsernames(t, e, "?limit=2&page=1"); !equalNames(got, recent.Username, earlier.Username) {
+			t.Fatalf("page 1: got %v", got)
+		}
+		if got := activeUsernames(t, e, "?limit=2&page=2"); !equalNames(got, earliest.Username) {
+			t.Fatalf("page 2: got %v", got)
+		}
+	})
+	t.Run("window", func(t *testing.T) {
+		if got := activeUsernames(t, e, "?window=30m"); !equalNames(got, recent.Username) {
+			t.Fatalf("got %v, want only %s", got, recent.Username)
+		}
+		if got := activeUsernames(t, e, "?window=720h"); !equalNames(got, recent.Username, earlier.Username, earliest.Username, stale.Username) {
+			t.Fatalf("got %v, want %s last", got, stale.Username)
+		}
+	})
+	t.Run("tag matches skills and interests", func(t *testing.T) {
+		if got := activeUsernames(t, e, "?tag=Go"); !equalNames(got, recent.Username, earlier.Username) {
+			t.Fatalf("got %v, want %s, %s", got, recent.Username, earlier.Username)
+		}
+	})
+	t.Run("invalid window", func(t *testing.T) {
+		res := e.Do(t, testutil.Request{Method: http.MethodGet, Path: "/users/active?window=1000h"})
+		if res.Status != http.StatusBadRequest {
+			t.Fatalf("status %d, want 400: %s", res.Status, res.Body)
+		}
+	})
+}
+
+// followCounts returns how many users follow user and how many user follows, from its counters.
+func followCounts(t *testing.T, e *testutil.Env, user *models.User) (followers, following int) {
+	t.Helper()
+	var stored models.User
+	if err := e.DB.Select("followers_count, following_count").Where("id = ?", user.ID).First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	return stored.Stats.FollowersCount, stored.Stats.FollowingCount
+}
+
+func TestSetFollowState(t *testing.T) {
+	e := testutil.Setup(t)
+	follower := e.CreateUser(t, models.RoleMember)
+	followee := e.CreateUser(t, models.RoleMember)
+	token := e.Token(t, follower)
+
+	steps := []struct {
+		following bool
+		changed   bool
+		count     int
+	}{
+		{following: true, changed: true, count: 1},
+		{following: true, changed: false, count: 1},
+		{following: false, changed: true, count: 0},
+		{following: false, changed: false, count: 0},
+	}
+	for _, step := range steps {
+		res := e.Do(t, testutil.Request{Method: http.MethodPut, Path: "/users/" + followee.Username + "/follow", Token: token, Body: map[string]bool{"following": step.following}})
+		if res.Status != http.StatusOK {
+			t.Fatalf("following=%v: status %d: %s", step.following, res.Status, res.Body)
+		}
+		var body struct {
+			Following bool `json:"following"`
+			Changed   bool `json:"changed"`
+		}
+		res.JSON(t, &body)
+		if body.Following != step.following || body.Changed != step.changed {
+			t.Fatalf("following=%v: got following=%v changed=%v, want changed=%v", step.following, body.Following, body.Changed, step.changed)
+		}
+
+		var rows int64
+		if err := e.DB.Table("user_followers").Where("follower_id = ? AND following_id = ?", follower.ID, followee.ID).Count(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		if followers, _ := followCounts(t, e, followee); followers != step.count || rows != int64(step.count) {
+			t.Fatalf("following=%v: followee has %d followers and %d follow rows, want %d", step.following, followers, rows, step.count)
+		}
+		if _, following := followCounts(t, e, follower); following != step.count {
+			t.Fatalf("following=%v: follower follows %d users, want %d", step.following, following, step.count)
+		}
+	}
+}
+
+func TestSetFollowStateRefused(t *testing.T) {
+	var user *models.User
+	testutil.Run(t, []testutil.Case{
+		{
+			Name: "yourself",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				asMember(&user)(t, e, req)
+				req.Path = "/users/" + user.Username + "/follow"
+			},
+			Request: testutil.Request{Method: http.MethodPut, Body: map[string]bool{"following": true}},
+			Status:  http.StatusBadRequest,
+		},
+		{
+			Name:    "unknown user",
+			Setup:   asMember(&user),
+			Request: testutil.Request{Method: http.MethodPut, Path: "/users/nobody/follow", Body: map[string]bool{"following": true}},
+			Status:  http.StatusNotFound,
+		},
+		{
+			Name: "missing state",
+			Setup: func(t *testing.T, e *testutil.Env, req *testutil.Request) {
+				asMember(&user)(t, e, req)
+				req.Path = "/users/" + e.CreateUser(t, models.RoleMember).Username + "/follow"
+			},
+			Request: testutil.Request{Method: http.MethodPut, Body: map[string]string{}},
+			Status:  http.StatusUnprocessableEntity,
+		},
+	})
+}
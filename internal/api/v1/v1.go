@@ -2,141 +2,169 @@ package v1
 
 import (
 	"encoding/json"
-	"fmt"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/mnuddindev/devpulse/internal/auth"
 	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/services"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/contentfilter"
+	"github.com/mnuddindev/devpulse/pkg/geoip"
 	"github.com/mnuddindev/devpulse/pkg/logger"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
+	"github.com/mnuddindev/devpulse/pkg/webpush"
 	"gorm.io/gorm"
 )
 
 var (
-	DB       *gorm.DB
-	Redis    *storage.RedisClient
-	Logger   *logger.Logger
+	DB     *gorm.DB
+	Redis  *storage.RedisClient
+	Logger *logger.Logger
+	// EmailCfg gets its mail provider from the router
 	EmailCfg = utils.EmailConfig{
-		SMTPHost:     "0.0.0.0",
-		SMTPPort:     1025,
-		SMTPUsername: "",
-		SMTPPassword: "",
-		AppURL:       "http://localhost:3000",
-		FromEmail:    "no-reply@devpulse.com",
+		AppURL:    "http://localhost:3000",
+		FromEmail: "no-reply@devpulse.com",
 	}
 	Validator = utils.NewValidator()
+	// SessionPolicy decides when all of a user's sessions are revoked.
+	SessionPolicy = models.SessionPolicy{OnPasswordChange: true}
+	// ProfileFieldIntervals is the minimum time between two changes of a profile field.
+	ProfileFieldIntervals = map[string]time.Duration{
+		"username": 30 * 24 * time.Hour,
+		"email":    7 * 24 * time.Hour,
+	}
+	// PrivacyMode answers unauthenticated account lookups uniformly so they cannot be used
+	// to find out which emails are registered.
+	PrivacyMode bool
+	// TwoFactorKey encrypts TOTP secrets at rest; two-factor enrollment is unavailable without it.
+	TwoFactorKey []byte
+	// WebhookKey encrypts webhook signing secrets at rest; webhooks cannot be registered without it.
+	WebhookKey []byte
+	// Push sends Web Push messages; without it push subscriptions cannot be registered.
+	Push *webpush.Sender
+	// OAuthProviders holds the configured social login providers by name.
+	OAuthProviders = map[string]*auth.OAuthProvider{}
+	// Paginator seals the cursors of list endpoints.
+	Paginator *utils.Paginator
+	// CommentEditWindow is how long after posting authors may still edit a comment.
+	CommentEditWindow = 15 * time.Minute
+	// RegistrationMode decides who may create an account: anyone, holders of an invitation code,
+	// or nobody.
+	RegistrationMode = models.RegistrationOpen
+	// ContentFilter checks usernames, names, bios and post titles; without it nothing is filtered.
+	ContentFilter *contentfilter.Filter
+	// PostPolicy decides who may read posts that are not public and signs their preview links.
+	PostPolicy *models.PostPolicy
+	// GeoIP locates the IPs sign-ins come from; without it login history has no locations and
+	// only new devices are reported.
+	GeoIP *geoip.DB
+	// Services holds the account, authentication and role logic the handlers are built on.
+	Services *services.Services
 )
 
 // NotImplemented is a placeholder for unimplemented routes
 func NotImplemented(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
-		"error": "Not Implemented",
-	})
+	return apierror.New(fiber.StatusNotImplemented, "Not Implemented")
 }
 
-func Refresh(c *fiber.Ctx) error {
-	ipKey := "refresh:ip:" + c.IP()
-	count, err := Redis.Get(c.Context(), ipKey).Int()
-	if err == nil && count >= 5 {
-		Logger.Warn(c.Context()).WithFields("ip", c.IP()).Logs("Refresh rate limit exceeded")
-		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error": "Too many refresh attempts. Try again later.",
-		})
+// GetCSRFToken returns the CSRF token browsers echo in the X-CSRF-Token header of state-changing
+// requests, setting its cookie if the client has none yet.
+func GetCSRFToken(c *fiber.Ctx) error {
+	token, err := auth.CSRFToken(c)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to generate CSRF token")
+		return apierror.Internal("Failed to generate CSRF token")
 	}
-	Redis.Incr(c.Context(), ipKey)
-	Redis.Expire(c.Context(), ipKey, 15*time.Minute)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":    "CSRF token issued",
+		"status":     fiber.StatusOK,
+		"csrf_token": token,
+	})
+}
 
-	refreshToken := c.Cookies("refresh_token")
+// Refresh rotates the refresh token of a session and issues a new access token. Browsers send the
+// refresh token cookie; other clients post it as "refresh_token".
+func Refresh(c *fiber.Ctx) error {
+	refreshToken := auth.RefreshTokenOf(c)
 	if refreshToken == "" {
 		Logger.Warn(c.Context()).Logs("No refresh token provided")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Refresh token required",
-		})
+		return apierror.Unauthorized("Refresh token required")
 	}
 
 	refreshKey := "refresh:" + refreshToken
 	refreshDataJSON, err := Redis.Get(c.Context(), refreshKey).Result()
 	if err != nil || refreshDataJSON == "" {
+		if reused, err := models.RevokeReusedRefreshToken(c.Context(), Redis, DB, refreshToken, c.IP(), c.Get(fiber.HeaderUserAgent)); reused {
+			if err != nil {
+				Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to revoke reused refresh token family")
+			}
+			Logger.Warn(c.Context()).WithFields("ip", c.IP()).Logs("Refresh token reuse detected, session revoked")
+			auth.ClearTokens(c)
+			return apierror.Unauthorized("Refresh token reuse detected. Please log in again.")
+		}
 		Logger.Warn(c.Context()).WithFields("key", refreshKey).Logs("Invalid or expired refresh token")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid or expired refresh token",
-		})
+		return apierror.Unauthorized("Invalid or expired refresh token")
 	}
 
 	var refreshData map[string]interface{}
 	if err := json.Unmarshal([]byte(refreshDataJSON), &refreshData); err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to parse refresh token data")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to process refresh",
-		})
+		return apierror.Internal("Failed to process refresh")
 	}
 
 	userID, ok := refreshData["user_id"].(string)
 	if !ok || userID == "" {
 		Logger.Warn(c.Context()).WithFields("key", refreshKey).Logs("Invalid refresh token data")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid refresh token",
-		})
+		return apierror.Unauthorized("Invalid refresh token")
 	}
 
 	if ip, ok := refreshData["ip"].(string); !ok || ip != c.IP() {
 		Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Refresh token used from different IP")
 		Redis.Del(c.Context(), refreshKey) // Revoke on IP mismatch
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid refresh token (IP mismatch)",
-		})
+		return apierror.Unauthorized("Invalid refresh token (IP mismatch)")
 	}
 
-	user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{userID}, "")
+	user, err := models.GetUserBy(c.Context(), Redis, DB, "id = ?", []interface{}{userID}, models.PreloadMinimal)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to fetch user for refresh")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to process refresh",
-		})
+		return apierror.Internal("Failed to process refresh")
 	}
 
-	accessToken, err := auth.GenerateAccessToken(user.ID.String(), user.RoleID.String())
+	accessToken, err := auth.GenerateAccessToken(user.ID.String(), user.RoleID.String(), user.TokenVersion)
 	if err != nil {
 		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to generate new access token")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to process refresh",
-		})
+		return apierror.Internal("Failed to process refresh")
 	}
 	newRefreshToken := auth.GenerateRefreshToken()
 
-	newRefreshKey := "refresh:" + newRefreshToken
 	newRefreshData := map[string]interface{}{
-		"user_id": user.ID.String(),
-		"ip":      c.IP(),
+		"user_id":  user.ID.String(),
+		"ip":       c.IP(),
+		"provider": models.SessionProvider(refreshData),
+		"family":   models.SessionFamily(refreshData),
 	}
-	newRefreshJSON, _ := json.Marshal(newRefreshData)
-	if err := Redis.Set(c.Context(), newRefreshKey, newRefreshJSON, 7*24*time.Hour).Err(); err != nil {
-		Logger.Warn(c.Context()).WithFields("key", newRefreshKey).Logs(fmt.Sprintf("Failed to store new refresh token: %v", err))
+	rotated, err := models.RotateRefreshToken(c.Context(), Redis, user.ID, refreshToken, newRefreshToken, newRefreshData, auth.RefreshTokenTTL)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to store new refresh token")
+		return apierror.Internal("Failed to process refresh")
 	}
-	Redis.Del(c.Context(), refreshKey)
-
-	c.Cookie(&fiber.Cookie{
-		Name:     "access_token",
-		Value:    accessToken,
-		Expires:  time.Now().Add(15 * time.Minute),
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Strict",
-	})
-	c.Cookie(&fiber.Cookie{
-		Name:     "refresh_token",
-		Value:    newRefreshToken,
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Strict",
-	})
+	if !rotated {
+		Logger.Warn(c.Context()).WithFields("user_id", user.ID).Logs("Refresh token already consumed by a concurrent refresh")
+		return apierror.Unauthorized("Invalid or expired refresh token")
+	}
+
+	tokens := auth.RenewTokens(c, accessToken, newRefreshToken)
 
 	Logger.Info(c.Context()).WithFields("user_id", user.ID).Logs("Access token refreshed successfully")
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+	res := fiber.Map{
 		"message": "Token refreshed successfully",
-	})
+	}
+	if tokens != nil {
+		res["tokens"] = tokens
+	}
+	return c.Status(fiber.StatusOK).JSON(res)
 }
@@ -0,0 +1,335 @@
+package v1
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// webhookResponse is the representation of a webhook shown to its owner; the secret is only ever
+// returned when it is generated
+func webhookResponse(hook *models.Webhook) fiber.Map {
+	return fiber.Map{
+		"id":               hook.ID,
+		"url":              hook.URL,
+		"events":           hook.EventList(),
+		"global":           hook.Global,
+		"active":           hook.Active,
+		"failure_count":    hook.FailureCount,
+		"last_delivery_at": hook.LastDeliveryAt,
+		"created_at":       hook.CreatedAt,
+		"updated_at":       hook.UpdatedAt,
+	}
+}
+
+// WebhookOwner resolves the user who registered the webhook named by the :id param
+func WebhookOwner(c *fiber.Ctx) (uuid.UUID, error) {
+	hook, err := webhookByID(c)
+	if hook == nil {
+		return uuid.Nil, err
+	}
+	return hook.UserID, nil
+}
+
+// webhookByID loads the webhook named by the :id param. On failure the webhook is nil and the
+// error answers the request.
+func webhookByID(c *fiber.Ctx) (*models.Webhook, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("webhook_id", c.Params("id")).Logs("Invalid webhook ID")
+		return nil, apierror.BadRequest("Invalid webhook ID")
+	}
+	hook, err := models.GetWebhook(c.Context(), DB, id)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "webhook_id", id).Logs("Failed to fetch webhook")
+		return nil, apierror.From(err, "Failed to fetch webhook")
+	}
+	return hook, nil
+}
+
+// newWebhookSecret generates a signing secret and its encrypted form
+func newWebhookSecret(c *fiber.Ctx) (string, string, error) {
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to generate webhook secret")
+		return "", "", apierror.Internal("Failed to generate webhook secret")
+	}
+	encrypted, err := utils.EncryptSecret(WebhookKey, secret)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to encrypt webhook secret")
+		return "", "", apierror.Internal("Failed to generate webhook secret")
+	}
+	return secret, encrypted, nil
+}
+
+// SendWebhook signs and posts a delivery to its webhook; the webhook worker calls it for every
+// due delivery
+func SendWebhook(ctx context.Context, hook *models.Webhook, delivery *models.WebhookDelivery) (*utils.WebhookResponse, error) {
+	secret, err := utils.DecryptSecret(WebhookKey, hook.Secret)
+	if err != nil {
+		return nil, err
+	}
+	return utils.PostWebhook(ctx, hook.URL, secret, delivery.Event, delivery.ID.String(), delivery.Payload)
+}
+
+// ListWebhooks returns the webhooks the authenticated user registered
+func ListWebhooks(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("ListWebhooks attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in ListWebhooks")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	hooks, err := models.GetUserWebhooks(c.Context(), DB, userID)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to fetch webhooks")
+		return apierror.From(err, "Failed to fetch webhooks")
+	}
+	items := make([]fiber.Map, 0, len(hooks))
+	for i := range hooks {
+		items = append(items, webhookResponse(&hooks[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Webhooks retrieved successfully",
+		"status":   fiber.StatusOK,
+		"webhooks": items,
+		"events":   models.WebhookEvents,
+	})
+}
+
+// CreateWebhook registers an endpoint for the authenticated user. The signing secret is returned
+// once; only admins holding manage_webhooks can register global webhooks receiving every event.
+func CreateWebhook(c *fiber.Ctx) error {
+	userIDRaw, ok := c.Locals("user_id").(string)
+	if !ok || userIDRaw == "" {
+		Logger.Warn(c.Context()).Logs("CreateWebhook attempted without user_id in context")
+		return apierror.Unauthorized("Unauthorized")
+	}
+	userID, err := uuid.Parse(userIDRaw)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userIDRaw).Logs("Invalid user_id format in CreateWebhook")
+		return apierror.BadRequest("Invalid user ID")
+	}
+
+	if len(WebhookKey) == 0 {
+		Logger.Error(c.Context()).Logs("Webhook registration attempted without an encryption key configured")
+		return apierror.Unavailable("Webhooks are not configured")
+	}
+
+	type CreateWebhookRequest struct {
+		URL    string   `json:"url" validate:"required,url,max=500"`
+		Events []string `json:"events" validate:"required,min=1,max=10"`
+		Global bool     `json:"global"`
+	}
+	var req CreateWebhookRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	if !models.ValidWebhookURL(req.URL) {
+		return apierror.BadRequest("Webhook URL must be an https URL")
+	}
+	events, err := models.WebhookEventsJSON(req.Events)
+	if err != nil {
+		return apierror.From(err, "Invalid webhook events")
+	}
+
+	if req.Global {
+		roleID, _ := c.Locals("role_id").(uuid.UUID)
+		snapshot, err := models.GetPermissionSnapshot(c.Context(), Redis, DB, roleID)
+		if err != nil {
+			Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to get permissions")
+			return apierror.Internal("Failed to create webhook")
+		}
+		if !snapshot.Has("manage_webhooks") {
+			Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("Global webhook attempted without manage_webhooks")
+			return apierror.Forbidden("Only admins can register global webhooks")
+		}
+	}
+
+	secret, encrypted, err := newWebhookSecret(c)
+	if err != nil {
+		return err
+	}
+	hook := &models.Webhook{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: encrypted,
+		Events: events,
+		Global: req.Global,
+	}
+	if err := models.CreateWebhook(c.Context(), DB, hook); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to create webhook")
+		return apierror.From(err, "Failed to create webhook")
+	}
+
+	Logger.Info(c.Context()).WithFields("user_id", userID, "webhook_id", hook.ID, "global", hook.Global).Logs("Webhook created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Webhook created; store the secret now, it will not be shown again",
+		"status":  fiber.StatusCreated,
+		"webhook": webhookResponse(hook),
+		"secret":  secret,
+	})
+}
+
+// UpdateWebhook changes a webhook's URL, events or active state. Reactivating a webhook that was
+// disabled after repeated failures resets its failure count.
+func UpdateWebhook(c *fiber.Ctx) error {
+	hook, err := webhookByID(c)
+	if hook == nil {
+		return err
+	}
+
+	type UpdateWebhookRequest struct {
+		URL    *string  `json:"url" validate:"omitempty,url,max=500"`
+		Events []string `json:"events" validate:"omitempty,min=1,max=10"`
+		Active *bool    `json:"active"`
+	}
+	var req UpdateWebhookRequest
+	if err := utils.StrictBodyParser(c, &req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "webhook_id", hook.ID).Logs("Invalid request body")
+		return apierror.BadRequest("Invalid request body")
+	}
+	if err := Validator.Validate(req); err != nil {
+		Logger.Warn(c.Context()).WithFields("error", err, "webhook_id", hook.ID).Logs("Validation failed")
+		return apierror.Validation(err)
+	}
+
+	var opts []models.WebhookOption
+	if req.URL != nil {
+		opts = append(opts, models.WithWebhookURL(strings.TrimSpace(*req.URL)))
+	}
+	if req.Events != nil {
+		events, err := models.WebhookEventsJSON(req.Events)
+		if err != nil {
+			return apierror.From(err, "Invalid webhook events")
+		}
+		opts = append(opts, models.WithWebhookEvents(events))
+	}
+	if req.Active != nil {
+		opts = append(opts, models.WithWebhookActive(*req.Active))
+	}
+	if len(opts) == 0 {
+		return apierror.BadRequest("No changes requested")
+	}
+
+	hook, err = models.UpdateWebhook(c.Context(), DB, hook.ID, opts...)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "webhook_id", c.Params("id")).Logs("Failed to update webhook")
+		return apierror.From(err, "Failed to update webhook")
+	}
+
+	Logger.Info(c.Context()).WithFields("webhook_id", hook.ID).Logs("Webhook updated")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Webhook updated successfully",
+		"status":  fiber.StatusOK,
+		"webhook": webhookResponse(hook),
+	})
+}
+
+// RotateWebhookSecret replaces a webhook's signing secret and returns the new one once. Deliveries
+// still pending are signed with the new secret.
+func RotateWebhookSecret(c *fiber.Ctx) error {
+	if len(WebhookKey) == 0 {
+		Logger.Error(c.Context()).Logs("Webhook secret rotation attempted without an encryption key configured")
+		return apierror.Unavailable("Webhooks are not configured")
+	}
+	hook, err := webhookByID(c)
+	if hook == nil {
+		return err
+	}
+
+	secret, encrypted, err := newWebhookSecret(c)
+	if err != nil {
+		return err
+	}
+	hook, err = models.UpdateWebhook(c.Context(), DB, hook.ID, models.WithWebhookSecret(encrypted))
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "webhook_id", c.Params("id")).Logs("Failed to rotate webhook secret")
+		return apierror.From(err, "Failed to rotate webhook secret")
+	}
+
+	Logger.Info(c.Context()).WithFields("webhook_id", hook.ID).Logs("Webhook secret rotated")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Webhook secret rotated; store the secret now, it will not be shown again",
+		"status":  fiber.StatusOK,
+		"webhook": webhookResponse(hook),
+		"secret":  secret,
+	})
+}
+
+// DeleteWebhook removes a webhook; its pending deliveries are given up
+func DeleteWebhook(c *fiber.Ctx) error {
+	hook, err := webhookByID(c)
+	if hook == nil {
+		return err
+	}
+	if err := models.DeleteWebhook(c.Context(), DB, hook.ID); err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "webhook_id", hook.ID).Logs("Failed to delete webhook")
+		return apierror.From(err, "Failed to delete webhook")
+	}
+
+	Logger.Info(c.Context()).WithFields("webhook_id", hook.ID).Logs("Webhook deleted")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Webhook deleted successfully",
+		"status":  fiber.StatusOK,
+	})
+}
+
+// GetWebhookDeliveries returns a page of a webhook's delivery log, newest first. Pages are chained
+// with the returned next_cursor; ?status= limits the page to pending, succeeded or failed
+// deliveries and ?total=true adds the number of matching deliveries.
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+	hook, err := webhookByID(c)
+	if hook == nil {
+		return err
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+	default:
+		return apierror.BadRequest("Invalid delivery status")
+	}
+
+	p, err := Paginator.Parse(c, 20, 100)
+	if err != nil {
+		Logger.Warn(c.Context()).WithFields("webhook_id", hook.ID).Logs("Invalid cursor in GetWebhookDeliveries")
+		return apierror.From(err, "Invalid cursor")
+	}
+	page, err := models.GetWebhookDeliveriesPage(c.Context(), DB, hook.ID, status, p)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "webhook_id", hook.ID).Logs("Failed to fetch webhook deliveries")
+		return apierror.From(err, "Failed to fetch webhook deliveries")
+	}
+	next, err := Paginator.Seal(page.Next)
+	if err != nil {
+		Logger.Error(c.Context()).WithFields("error", err, "webhook_id", hook.ID).Logs("Failed to seal deliveries cursor")
+		return apierror.Internal("Failed to fetch webhook deliveries")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Webhook deliveries retrieved successfully",
+		"status":      fiber.StatusOK,
+		"deliveries":  page.Items,
+		"limit":       p.Limit,
+		"next_cursor": next,
+		"total":       page.Total,
+	})
+}
@@ -10,4 +10,8 @@ type Options struct {
 	DB      *gorm.DB
 	Rclient *storage.RedisClient
 	Logger  *logger.Logger
+	// Scopes lists the routes personal access tokens can reach; without it they reach none.
+	Scopes *TokenScopes
+	// Unimpersonable lists the routes staff cannot reach while impersonating a user.
+	Unimpersonable *Routes
 }
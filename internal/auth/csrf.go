@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+const (
+	// CSRFHeader must echo the csrf_token cookie on state-changing requests made by browsers.
+	CSRFHeader = "X-CSRF-Token"
+
+	csrfCookie = "csrf_token"
+)
+
+// CSRFMiddleware applies double-submit CSRF protection to every state-changing request: the
+// X-CSRF-Token header must match the csrf_token cookie, which browsers get from GET /auth/csrf
+// and with every new session. Another site can make a browser send the cookie but can neither
+// read it nor set the header.
+//
+// Requests with an Authorization: Bearer header or asking for body tokens are exempt. They carry
+// no ambient credentials, and cross-site requests cannot set those headers anyway.
+func CSRFMiddleware(opt Options) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+		if _, ok := bearerToken(c); ok || WantsBodyTokens(c) {
+			return c.Next()
+		}
+
+		if err := VerifyCSRF(c); err != nil {
+			opt.Logger.Warn(c.Context()).WithFields("path", c.Path(), "method", c.Method(), "ip", c.IP()).Logs("Missing or invalid CSRF token")
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// VerifyCSRF reports whether a request echoes its CSRF cookie in the CSRF header.
+func VerifyCSRF(c *fiber.Ctx) error {
+	cookie := c.Cookies(csrfCookie)
+	header := c.Get(CSRFHeader)
+	if cookie == "" || header == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+		return apierror.Forbidden("Missing or invalid CSRF token")
+	}
+	return nil
+}
+
+// CSRFToken returns the CSRF token of the client, issuing one if it has none.
+func CSRFToken(c *fiber.Ctx) (string, error) {
+	if token := c.Cookies(csrfCookie); token != "" {
+		return token, nil
+	}
+	return setCSRFCookie(c)
+}
+
+// setCSRFCookie issues a fresh CSRF token. The cookie is readable by scripts so the web client
+// can copy it into the CSRF header.
+func setCSRFCookie(c *fiber.Ctx) (string, error) {
+	token, err := utils.GenerateRandomToken(32, 32)
+	if err != nil {
+		return "", err
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     csrfCookie,
+		Value:    token,
+		Expires:  time.Now().Add(RefreshTokenTTL),
+		Secure:   true,
+		SameSite: "Strict",
+	})
+	return token, nil
+}
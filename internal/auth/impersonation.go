@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+)
+
+// Response headers marking a request made by staff acting as the user, so clients can show a
+// banner naming the impersonator until the token expires.
+const (
+	ImpersonatorHeader         = "X-Impersonated-By"
+	ImpersonationExpiresHeader = "X-Impersonation-Expires"
+)
+
+// ImpersonatePermission lets staff act as other users with an impersonation token.
+const ImpersonatePermission = "impersonate_user"
+
+// Routes is a set of routes given as "METHOD /path", using the same :param syntax as the router.
+type Routes struct {
+	routes []routeRule
+}
+
+// NewRoutes returns the set of the given routes.
+func NewRoutes(routes ...string) *Routes {
+	r := &Routes{}
+	for _, route := range routes {
+		r.routes = append(r.routes, parseRoute(route))
+	}
+	return r
+}
+
+// has reports whether a request matches one of the routes.
+func (r *Routes) has(method, path string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := matchRoute(r.routes, method, path)
+	return ok
+}
+
+// impersonate runs the rest of a request made with an impersonation token. The impersonator must
+// still be in good standing and hold ImpersonatePermission, and routes in opt.Unimpersonable are
+// refused. Every request is audited as the impersonator's, whatever its outcome.
+func impersonate(c *fiber.Ctx, opt Options, claims *Claims, bearer bool, next fiber.Handler) error {
+	if !bearer {
+		opt.Logger.Warn(c.Context()).WithFields("user_id", claims.UserID, "impersonator_id", claims.Impersonator).Logs("Impersonation token sent as a cookie")
+		return apierror.Unauthorized("Impersonation tokens must be sent as a bearer token")
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return apierror.Unauthorized("Invalid access token")
+	}
+	impersonatorID, err := uuid.Parse(claims.Impersonator)
+	if err != nil {
+		return apierror.Unauthorized("Invalid access token")
+	}
+
+	impersonator, err := models.GetUserBy(c.Context(), opt.Rclient, opt.DB, "id = ?", []interface{}{impersonatorID}, models.PreloadMinimal)
+	if err != nil || impersonator.IsRestricted() {
+		opt.Logger.Warn(c.Context()).WithFields("impersonator_id", impersonatorID).Logs("Impersonator no longer in good standing")
+		return apierror.Unauthorized("Impersonation has been revoked")
+	}
+	snapshot, err := models.GetPermissionSnapshot(c.Context(), opt.Rclient, opt.DB, impersonator.RoleID)
+	if err != nil {
+		opt.Logger.Warn(c.Context()).WithFields("error", err, "role_id", impersonator.RoleID).Logs("Failed to get permissions")
+		return apierror.Internal("Internal Server Error")
+	}
+	if !snapshot.Has(ImpersonatePermission) {
+		opt.Logger.Warn(c.Context()).WithFields("impersonator_id", impersonatorID).Logs("Impersonator lost the impersonation permission")
+		return apierror.Unauthorized("Impersonation has been revoked")
+	}
+
+	c.Locals("impersonator_id", impersonatorID)
+	c.Set(ImpersonatorHeader, impersonator.Username)
+	c.Set(ImpersonationExpiresHeader, claims.ExpiresAt.Time.UTC().Format(time.RFC3339))
+
+	if opt.Unimpersonable.has(c.Method(), c.Path()) {
+		err = apierror.Forbidden("This route cannot be used while impersonating a user")
+	} else {
+		err = next(c)
+	}
+
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = apierror.From(err, "").Status
+	}
+	if auditErr := models.RecordAudit(c.Context(), opt.DB, impersonatorID, models.AuditImpersonatedRequest, models.AuditTargetUser, userID, nil, map[string]interface{}{
+		"method":   c.Method(),
+		"path":     c.Path(),
+		"status":   status,
+		"token_id": claims.ID,
+	}, c.IP()); auditErr != nil {
+		opt.Logger.Error(c.Context()).WithFields("error", auditErr, "impersonator_id", impersonatorID, "user_id", userID).Logs("Failed to audit impersonated request")
+	}
+	return err
+}
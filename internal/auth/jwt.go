@@ -19,24 +19,41 @@ var (
 type Claims struct {
 	UserID string `json:"user_id"`
 	RoleID string `json:"role_id"`
+	// TokenVersion must match the user's token version; bumping it revokes the token.
+	TokenVersion int `json:"tv"`
+	// Impersonator is the staff member acting as the user; only impersonation tokens carry it.
+	Impersonator string `json:"imp,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateAccessToken generates token for user access.
-func GenerateAccessToken(userid, roleid string) (string, error) {
-	claims := Claims{
-		UserID: userid,
-		RoleID: roleid,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)), // Access token expires in 15 minutes
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "devpulse",
-			ID:        uuid.NewString(),
-		},
+func GenerateAccessToken(userid, roleid string, tokenVersion int) (string, error) {
+	token, _, err := signToken(Claims{UserID: userid, RoleID: roleid, TokenVersion: tokenVersion}, AccessTokenTTL)
+	return token, err
+}
+
+// GenerateImpersonationToken generates a token letting impersonatorID act as the user until the
+// returned expiry. It cannot be refreshed.
+func GenerateImpersonationToken(userid, roleid string, tokenVersion int, impersonatorID string) (string, time.Time, error) {
+	return signToken(Claims{UserID: userid, RoleID: roleid, TokenVersion: tokenVersion, Impersonator: impersonatorID}, ImpersonationTTL)
+}
+
+// signToken fills in the registered claims of a token valid for ttl and signs it.
+func signToken(claims Claims, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Issuer:    "devpulse",
+		ID:        uuid.NewString(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecretKey)
+	if err != nil {
+		return "", time.Time{}, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecretKey)
+	return token, expiresAt, nil
 }
 
 // VerifyToken verifies the token by extracting the token and cross checking secretkey, values, signing method returns
@@ -3,15 +3,23 @@ package auth
 import (
 	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/mnuddindev/devpulse/internal/models"
-	"github.com/redis/go-redis/v9"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
 )
 
+// RefreshTokenMiddleware authenticates a request by its API key, access token or refresh token
+// cookie, refreshing an expired session, and refuses it when none is valid.
 func RefreshTokenMiddleware(opt Options) fiber.Handler {
+	return authenticated(opt, func(c *fiber.Ctx) error { return c.Next() })
+}
+
+// authenticated returns middleware authenticating a request as RefreshTokenMiddleware describes,
+// then handing it to next.
+func authenticated(opt Options, next fiber.Handler) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		publicRoutes := map[string]bool{
 			"/register":      true,
@@ -22,111 +30,137 @@ func RefreshTokenMiddleware(opt Options) fiber.Handler {
 		}
 		path := c.Path()
 		if publicRoutes[path] {
-			return c.Next()
+			return next(c)
+		}
+
+		// Programmatic clients authenticate with a personal access token instead of session cookies
+		if token, ok := bearerAPIKey(c); ok {
+			if err := authenticateAPIKey(c, opt, token); err != nil {
+				return err
+			}
+			return next(c)
 		}
 
-		accessToken := c.Cookies("access_token")
-		refreshToken := c.Cookies("refresh_token")
-		opt.Logger.Info(c.Context()).WithFields("access_token", accessToken).WithFields("refresh_token", refreshToken).Logs("Tokens received in middleware")
+		// Browsers send their tokens as cookies, other clients their access token as a bearer token
+		accessToken, bearer := AccessTokenOf(c)
+		refreshToken := ""
+		if !bearer {
+			refreshToken = c.Cookies("refresh_token")
+		}
 
 		if accessToken != "" {
-			accessTokenKey := "blacklist:access:" + accessToken
-			if opt.Rclient.Exists(c.Context(), accessTokenKey).Val() > 0 {
+			if opt.Rclient.Revoked(c.Context(), storage.AccessToken, accessToken) {
 				opt.Logger.Warn(c.Context()).WithFields("token", accessToken).Logs("Attempted use of blacklisted access token")
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"error": "Access token has been invalidated",
-				})
+				return apierror.Unauthorized("Access token has been invalidated")
 			}
 		}
 		if refreshToken != "" {
-			refreshTokenKey := "blacklist:refresh:" + refreshToken
-			if opt.Rclient.Exists(c.Context(), refreshTokenKey).Val() > 0 {
+			if opt.Rclient.Revoked(c.Context(), storage.RefreshToken, refreshToken) {
 				opt.Logger.Warn(c.Context()).WithFields("token", refreshToken).Logs("Attempted use of blacklisted refresh token")
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"error": "Refresh token has been invalidated",
-				})
+				return apierror.Unauthorized("Refresh token has been invalidated")
 			}
 		}
 
 		if accessToken == "" {
+			if bearer {
+				return apierror.Unauthorized("Access token required")
+			}
 			opt.Logger.Debug(c.Context()).Logs("No access token found, attempting refresh")
 			newAccessToken, err := handleTokenRefresh(c, opt, refreshToken)
 			if err != nil {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Token refresh failed"})
+				return err
 			}
 			accessToken = newAccessToken
 		}
 
 		claims, err := VerifyToken(accessToken)
 		if err != nil {
+			// Bearer clients refresh their tokens themselves
+			if err.Error() == "token has expired" && bearer {
+				return apierror.Unauthorized("Access token has expired")
+			}
 			if err.Error() == "token has expired" {
 				opt.Logger.Debug(c.Context()).Logs("Access token expired, attempting refresh")
 				newAccessToken, err := handleTokenRefresh(c, opt, refreshToken)
 				if err != nil {
-					return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Token refresh failed"})
+					return err
 				}
 				accessToken = newAccessToken
 				claims, err = VerifyToken(accessToken)
 				if err != nil {
 					opt.Logger.Warn(c.Context()).WithFields("error", err).Logs("Invalid access token after refresh")
-					return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid access token"})
+					return apierror.Unauthorized("Invalid access token")
 				}
+			} else {
+				opt.Logger.Warn(c.Context()).WithFields("error", err).Logs("Access token invalid")
+				return apierror.Unauthorized("Invalid access token")
 			}
-			opt.Logger.Warn(c.Context()).WithFields("error", err).Logs("Access token invalid")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid access token",
-			})
 		}
 
-		var user *models.User
-		user, err = models.GetUserBy(c.Context(), opt.Rclient, opt.DB, "id = ?", []interface{}{claims.UserID}, "")
+		// The account row is all authentication reads, and the token version and status have to be
+		// current, so the cached full record is not used
+		user, err := models.GetUserBy(c.Context(), opt.Rclient, opt.DB, "id = ?", []interface{}{claims.UserID}, models.PreloadMinimal)
 		if err != nil {
 			opt.Logger.Warn(c.Context()).WithFields("user_id", claims.UserID).Logs("User not found")
-			c.ClearCookie("access_token")
-			c.ClearCookie("refresh_token")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "User not found",
-			})
+			ClearTokens(c)
+			return apierror.Unauthorized("User not found")
 		}
 
 		user.UpdateLastSeen(c.Context(), opt.Rclient, opt.DB)
 
-		userKey := "user:" + user.ID.String()
-		cachedUser, err := opt.Rclient.Get(c.Context(), userKey).Result()
-		if err == nil && cachedUser != "" {
-			user = &models.User{}
-			if err := json.Unmarshal([]byte(cachedUser), user); err != nil {
-				opt.Logger.Warn(c.Context()).WithFields("error", err).Logs("Failed to unmarshal cached user")
-				user = nil
-			}
-		}
-		if err == redis.Nil || user == nil {
-			userJSON, _ := json.Marshal(user)
-			opt.Rclient.Set(c.Context(), userKey, userJSON, 30*time.Minute)
-		} else if err != nil {
-			opt.Logger.Warn(c.Context()).WithFields("user_id", claims.UserID).Logs("Redis error fetching user")
-			user, err = models.GetUserBy(c.Context(), opt.Rclient, opt.DB, "id = ?", []interface{}{uuid.MustParse(claims.UserID)}, "")
-			if err != nil {
-				opt.Logger.Warn(c.Context()).WithFields("user_id", claims.UserID).Logs("User not found during access token validation")
-				c.ClearCookie("access_token")
-				c.ClearCookie("refresh_token")
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"error": "User not found",
-				})
-			}
+		c.Locals("user_id", claims.UserID)
+		c.Locals("role_id", user.RoleID)
+
+		if claims.TokenVersion != user.TokenVersion {
+			opt.Logger.Warn(c.Context()).WithFields("user_id", user.ID).WithFields("token_version", claims.TokenVersion).Logs("Revoked access token used")
+			ClearTokens(c)
+			return apierror.Unauthorized("Session has been revoked")
 		}
 
-		c.Locals("user_id", claims.UserID)
+		if user.IsRestricted() {
+			opt.Logger.Warn(c.Context()).WithFields("user_id", user.ID, "status", user.Status).Logs("Restricted account used")
+			ClearTokens(c)
+			return apierror.Forbidden("Account is " + user.Status)
+		}
 
 		if claims.RoleID != user.RoleID.String() {
 			opt.Logger.Warn(c.Context()).WithFields("user_id", user.ID).WithFields("token_role", claims.RoleID).WithFields("user_role", user.RoleID).Logs("Role mismatch")
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Role mismatch",
-			})
+			return apierror.Forbidden("Role mismatch")
 		}
 
 		opt.Logger.Info(c.Context()).WithFields("user_id", claims.UserID).Logs(fmt.Sprintf("User authenticated for route: %s", path))
-		return c.Next()
+		if claims.Impersonator != "" {
+			return impersonate(c, opt, claims, bearer, next)
+		}
+		return next(c)
+	}
+}
+
+// Anonymous reports whether a request carries no credentials: no Authorization header and no
+// session cookies.
+func Anonymous(c *fiber.Ctx) bool {
+	return c.Get(fiber.HeaderAuthorization) == "" && c.Cookies("access_token") == "" && c.Cookies("refresh_token") == ""
+}
+
+// OptionalAuth authenticates requests that carry credentials, as RefreshTokenMiddleware does, and
+// lets anonymous ones through, for public routes whose answer depends on who is asking. API keys
+// are only honoured on routes declared with a scope; elsewhere their holder reads as a visitor.
+func OptionalAuth(opt Options) fiber.Handler {
+	authenticate := RefreshTokenMiddleware(opt)
+	return func(c *fiber.Ctx) error {
+		if _, ok := bearerAPIKey(c); ok {
+			if _, declared := opt.Scopes.lookup(c.Method(), c.Path()); !declared {
+				return c.Next()
+			}
+			return authenticate(c)
+		}
+		if _, bearer := bearerToken(c); bearer {
+			return authenticate(c)
+		}
+		if c.Cookies("access_token") == "" && c.Cookies("refresh_token") == "" {
+			return c.Next()
+		}
+		return authenticate(c)
 	}
 }
 
@@ -134,118 +168,83 @@ func RefreshTokenMiddleware(opt Options) fiber.Handler {
 func handleTokenRefresh(c *fiber.Ctx, cfg Options, refreshToken string) (string, error) {
 	if refreshToken == "" {
 		cfg.Logger.Warn(c.Context()).Logs("Refresh token missing")
-		return "", c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Refresh token missing"})
+		return "", apierror.Unauthorized("Refresh token missing")
 	}
 
-	refreshTokenKey := "blacklist:refresh:" + refreshToken
-	if cfg.Rclient.Exists(c.Context(), refreshTokenKey).Val() > 0 {
+	if cfg.Rclient.Revoked(c.Context(), storage.RefreshToken, refreshToken) {
 		cfg.Logger.Warn(c.Context()).WithFields("token", refreshToken).Logs("Attempted use of blacklisted refresh token")
-		return "", c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":  "Refresh token has been invalidated",
-			"status": fiber.StatusUnauthorized,
-		})
+		return "", apierror.Unauthorized("Refresh token has been invalidated")
 	}
 
 	refreshKey := "refresh:" + refreshToken
 	refreshDataJSON, err := cfg.Rclient.Get(c.Context(), refreshKey).Result()
 	if err != nil || refreshDataJSON == "" {
+		if reused, err := models.RevokeReusedRefreshToken(c.Context(), cfg.Rclient, cfg.DB, refreshToken, c.IP(), c.Get(fiber.HeaderUserAgent)); reused {
+			if err != nil {
+				cfg.Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to revoke reused refresh token family")
+			}
+			cfg.Logger.Warn(c.Context()).WithFields("ip", c.IP()).Logs("Refresh token reuse detected, session revoked")
+			ClearTokens(c)
+			return "", apierror.Unauthorized("Refresh token reuse detected. Please log in again.")
+		}
 		cfg.Logger.Warn(c.Context()).WithFields("token", refreshToken).Logs("Invalid/expired refresh token")
-		return "", c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid/expired refresh token"})
+		return "", apierror.Unauthorized("Invalid/expired refresh token")
 	}
 
 	var refreshData map[string]interface{}
 	if err := json.Unmarshal([]byte(refreshDataJSON), &refreshData); err != nil {
 		cfg.Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to parse refresh data")
-		return "", c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process refresh"})
+		return "", apierror.Internal("Failed to process refresh")
 	}
 
 	userID, ok := refreshData["user_id"].(string)
 	if !ok || userID == "" {
 		cfg.Logger.Warn(c.Context()).Logs("Invalid refresh token data")
-		return "", c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token"})
+		return "", apierror.Unauthorized("Invalid refresh token")
 	}
 
 	if ip, ok := refreshData["ip"].(string); !ok || ip != c.IP() {
 		cfg.Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("IP mismatch")
 		cfg.Rclient.Del(c.Context(), refreshKey)
-		return "", c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "IP mismatch"})
-	}
-
-	var user *models.User
-	cachedUser, err := cfg.Rclient.Get(c.Context(), "user:"+userID).Result()
-	if err == nil {
-		user := &models.User{}
-		if err := json.Unmarshal([]byte(cachedUser), user); err != nil {
-			cfg.Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to unmarshal cached user from Redis")
-			user = nil
-		}
+		return "", apierror.Unauthorized("IP mismatch")
 	}
 
-	if err == redis.Nil || user == nil {
-		user, err = models.GetUserBy(c.Context(), cfg.Rclient, cfg.DB, "id = ?", []interface{}{uuid.MustParse(userID)}, "Role")
-		if err != nil {
-			cfg.Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("User not found")
-			c.ClearCookie("access_token")
-			c.ClearCookie("refresh_token")
-			return "", c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User not found"})
-		}
-
-		userJson, err := json.Marshal(user)
-		if err != nil {
-			cfg.Logger.Warn(c.Context()).WithFields("error", err, "user_id", userID).Logs("Failed to marshal cached user from Redis")
-		} else {
-			if err := cfg.Rclient.Set(c.Context(), "user:"+userID, userJson, 30*time.Minute).Err(); err != nil {
-				cfg.Logger.Warn(c.Context()).WithFields("error", err, "user_id", refreshData["user_id"]).Logs("refreshData")
-			}
-		}
-	} else if err != nil {
-		cfg.Logger.Warn(c.Context()).WithFields("error", err).Logs("Redis error fetching user")
-		user, err = models.GetUserBy(c.Context(), cfg.Rclient, cfg.DB, "id = ?", []interface{}{refreshData["user_id"]}, "")
-		if err != nil {
-			cfg.Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("User not found")
-			c.ClearCookie("access_token")
-			c.ClearCookie("refresh_token")
-			return "", c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User not found"})
-		}
+	user, err := models.GetUserBy(c.Context(), cfg.Rclient, cfg.DB, "id = ?", []interface{}{uuid.MustParse(userID)}, models.PreloadMinimal)
+	if err != nil {
+		cfg.Logger.Warn(c.Context()).WithFields("user_id", userID).Logs("User not found")
+		ClearTokens(c)
+		return "", apierror.Unauthorized("User not found")
 	}
 
 	if roleID, ok := refreshData["role_id"].(string); ok && roleID != "" && uuid.MustParse(roleID) != user.RoleID {
 		cfg.Logger.Warn(c.Context()).WithFields("user_id", user.ID).WithFields("token_role", roleID).WithFields("user_role", user.RoleID).Logs("Role mismatch")
-		return "", c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Role mismatch"})
+		return "", apierror.Forbidden("Role mismatch")
 	}
 
-	newAccessToken, err := GenerateAccessToken(user.ID.String(), user.RoleID.String())
+	newAccessToken, err := GenerateAccessToken(user.ID.String(), user.RoleID.String(), user.TokenVersion)
 	if err != nil {
 		cfg.Logger.Error(c.Context()).WithFields("error", err).Logs("Failed to generate access token")
-		return "", c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to refresh"})
+		return "", apierror.Internal("Failed to refresh")
 	}
 	newRefreshToken := GenerateRefreshToken()
 
-	newRefreshKey := "refresh:" + newRefreshToken
 	newRefreshData := map[string]interface{}{
-		"user_id": user.ID,
-		"ip":      c.IP(),
+		"user_id":  user.ID,
+		"ip":       c.IP(),
+		"provider": models.SessionProvider(refreshData),
+		"family":   models.SessionFamily(refreshData),
+	}
+	rotated, err := models.RotateRefreshToken(c.Context(), cfg.Rclient, user.ID, refreshToken, newRefreshToken, newRefreshData, RefreshTokenTTL)
+	if err != nil {
+		cfg.Logger.Error(c.Context()).WithFields("error", err, "user_id", user.ID).Logs("Failed to rotate refresh token")
+		return "", apierror.Internal("Failed to refresh")
+	}
+	if !rotated {
+		cfg.Logger.Warn(c.Context()).WithFields("user_id", user.ID).Logs("Refresh token already consumed by a concurrent refresh")
+		return "", apierror.Unauthorized("Invalid/expired refresh token")
 	}
-	newRefreshJSON, _ := json.Marshal(newRefreshData)
-	cfg.Rclient.Set(c.Context(), newRefreshKey, newRefreshJSON, 7*24*time.Hour)
-	cfg.Rclient.Del(c.Context(), refreshKey)
-
-	c.Cookie(&fiber.Cookie{
-		Name:     "access_token",
-		Value:    newAccessToken,
-		Expires:  time.Now().Add(15 * time.Minute),
-		HTTPOnly: true,
-		// SameSite: "strict",
-		// Path:     "/",
-	})
-	c.Cookie(&fiber.Cookie{
-		Name:     "refresh_token",
-		Value:    newRefreshToken,
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
-		HTTPOnly: true,
-		// SameSite: "strict",
-		// Path:     "/",
-	})
+
+	setSessionCookies(c, newAccessToken, newRefreshToken)
 
 	c.Locals("user_id", user.ID.String())
 
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mnuddindev/devpulse/internal/models"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+// OAuthCredentials are the client credentials registered with an OAuth2 provider.
+type OAuthCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// OAuthProvider runs the authorization code flow against one social login provider.
+type OAuthProvider struct {
+	Name    string
+	config  *oauth2.Config
+	profile func(ctx context.Context, client *http.Client) (*models.OAuthProfile, error)
+}
+
+var oauthProviders = map[string]struct {
+	endpoint oauth2.Endpoint
+	scopes   []string
+	profile  func(ctx context.Context, client *http.Client) (*models.OAuthProfile, error)
+}{
+	"google":   {endpoints.Google, []string{"openid", "email", "profile"}, googleProfile},
+	"github":   {endpoints.GitHub, []string{"read:user", "user:email"}, githubProfile},
+	"facebook": {endpoints.Facebook, []string{"email", "public_profile"}, facebookProfile},
+}
+
+// NewOAuthProviders configures the supported providers that have credentials. Callbacks are
+// served at <callbackBase>/auth/oauth/<provider>/callback.
+func NewOAuthProviders(creds map[string]OAuthCredentials, callbackBase string) map[string]*OAuthProvider {
+	providers := map[string]*OAuthProvider{}
+	for name, cred := range creds {
+		def, ok := oauthProviders[name]
+		if !ok || cred.ClientID == "" || cred.ClientSecret == "" {
+			continue
+		}
+		providers[name] = &OAuthProvider{
+			Name: name,
+			config: &oauth2.Config{
+				ClientID:     cred.ClientID,
+				ClientSecret: cred.ClientSecret,
+				Endpoint:     def.endpoint,
+				RedirectURL:  strings.TrimRight(callbackBase, "/") + "/auth/oauth/" + name + "/callback",
+				Scopes:       def.scopes,
+			},
+			profile: def.profile,
+		}
+	}
+	return providers
+}
+
+// AuthCodeURL returns the provider consent URL for a state and PKCE verifier.
+func (p *OAuthProvider) AuthCodeURL(state, verifier string) string {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+// Exchange trades an authorization code for the profile of the signed in account.
+func (p *OAuthProvider) Exchange(ctx context.Context, code, verifier string) (*models.OAuthProfile, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange %s code: %w", p.Name, err)
+	}
+
+	profile, err := p.profile(ctx, p.config.Client(ctx, token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s profile: %w", p.Name, err)
+	}
+	profile.Provider = p.Name
+	profile.Email = strings.ToLower(strings.TrimSpace(profile.Email))
+	return profile, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func googleProfile(ctx context.Context, client *http.Client) (*models.OAuthProfile, error) {
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+		Picture       string `json:"picture"`
+	}
+	if err := getJSON(ctx, client, "https://openidconnect.googleapis.com/v1/userinfo", &info); err != nil {
+		return nil, err
+	}
+	return &models.OAuthProfile{
+		ProviderUserID: info.Sub,
+		Email:          info.Email,
+		EmailVerified:  info.EmailVerified,
+		Name:           info.Name,
+		AvatarURL:      info.Picture,
+	}, nil
+}
+
+func githubProfile(ctx context.Context, client *http.Client) (*models.OAuthProfile, error) {
+	var info struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, client, "https://api.github.com/user", &info); err != nil {
+		return nil, err
+	}
+
+	// The public profile email may be unverified, so take the primary address from the emails API.
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, "https://api.github.com/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	profile := &models.OAuthProfile{
+		ProviderUserID: strconv.FormatInt(info.ID, 10),
+		Username:       info.Login,
+		Name:           info.Name,
+		AvatarURL:      info.AvatarURL,
+	}
+	for _, e := range emails {
+		if e.Primary {
+			profile.Email = e.Email
+			profile.EmailVerified = e.Verified
+		}
+	}
+	return profile, nil
+}
+
+func facebookProfile(ctx context.Context, client *http.Client) (*models.OAuthProfile, error) {
+	var info struct {
+		ID      string `json:"id"`
+		Name    string `json:"name"`
+		Email   string `json:"email"`
+		Picture struct {
+			Data struct {
+				URL string `json:"url"`
+			} `json:"data"`
+		} `json:"picture"`
+	}
+	if err := getJSON(ctx, client, "https://graph.facebook.com/me?fields=id,name,email,picture", &info); err != nil {
+		return nil, err
+	}
+	// Facebook only returns confirmed email addresses.
+	return &models.OAuthProfile{
+		ProviderUserID: info.ID,
+		Email:          info.Email,
+		EmailVerified:  info.Email != "",
+		Name:           info.Name,
+		AvatarURL:      info.Picture.Data.URL,
+	}, nil
+}
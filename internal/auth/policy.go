@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+)
+
+// OwnerFunc resolves the owner of the resource a request targets, usually from a path parameter.
+// Its error answers the request, so a missing resource can surface as a 404.
+type OwnerFunc func(c *fiber.Ctx) (uuid.UUID, error)
+
+// Rule is what a route requires of the authenticated user. A user passes when their role grants
+// any of Any, or when they own the targeted resource and their role grants any of Own.
+type Rule struct {
+	Any   []string
+	Own   []string
+	Owner OwnerFunc
+	// Public lets every request through, authenticated or not; it documents routes that are open
+	// on purpose.
+	Public bool
+	// Authenticated lets every signed in user through, for routes whose handler decides the rest,
+	// such as those checking an organization role.
+	Authenticated bool
+}
+
+type routeRule struct {
+	method  string
+	pattern string
+	params  int
+}
+
+// parseRoute splits a route given as "METHOD /path", using the same :param syntax as the router.
+func parseRoute(route string) routeRule {
+	method, pattern, ok := strings.Cut(route, " ")
+	if !ok {
+		panic("auth: route must be \"METHOD /path\", got " + route)
+	}
+	return routeRule{method: strings.ToUpper(method), pattern: pattern, params: strings.Count(pattern, ":")}
+}
+
+// routePath trims the trailing slash the router ignores.
+func routePath(path string) string {
+	if len(path) > 1 {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// requestMethod is the method a request is checked as: HEAD requests are answered by the GET route.
+func requestMethod(c *fiber.Ctx) string {
+	if c.Method() == fiber.MethodHead {
+		return fiber.MethodGet
+	}
+	return c.Method()
+}
+
+// matchRoute returns the index of the route a request matches. When several patterns match, the
+// one with the fewest parameters wins, as the router prefers static segments. Like the router, a
+// trailing slash is ignored.
+func matchRoute(routes []routeRule, method, path string) (int, bool) {
+	path = routePath(path)
+	found := -1
+	for i, r := range routes {
+		if r.method != method || !fiber.RoutePatternMatch(path, r.pattern) {
+			continue
+		}
+		if found < 0 || r.params < routes[found].params {
+			found = i
+		}
+	}
+	return found, found >= 0
+}
+
+// Policy maps routes to the rules guarding them, so what every route requires is declared in one
+// place instead of next to each handler. Every route is declared, public ones included, which
+// Verify checks against the router.
+type Policy struct {
+	opt    Options
+	routes []routeRule
+	rules  []Rule
+}
+
+// NewPolicy returns an empty policy.
+func NewPolicy(opt Options) *Policy {
+	return &Policy{opt: opt}
+}
+
+// Declare sets the rule of a route given as "METHOD /path", using the same :param syntax as the
+// router. A rule needing an owner check without an OwnerFunc panics, as it could never pass.
+func (p *Policy) Declare(route string, rule Rule) *Policy {
+	if len(rule.Own) > 0 && rule.Owner == nil {
+		panic("auth: policy route " + route + " has owner permissions but no owner resolver")
+	}
+	p.routes = append(p.routes, parseRoute(route))
+	p.rules = append(p.rules, rule)
+	return p
+}
+
+// lookup finds the rule of a request.
+func (p *Policy) lookup(method, path string) (Rule, bool) {
+	i, ok := matchRoute(p.routes, method, path)
+	if !ok {
+		return Rule{}, false
+	}
+	return p.rules[i], true
+}
+
+// Enforce returns middleware applying the policy, used once on the whole app. Public routes are
+// let through as they are; any other route is authenticated as RefreshTokenMiddleware does and
+// then checked against its rule. Requests matching no declared route are left to the router, which
+// answers them with a 404 once Verify has passed.
+func (p *Policy) Enforce() fiber.Handler {
+	check := authenticated(p.opt, func(c *fiber.Ctx) error {
+		rule, _ := p.lookup(requestMethod(c), c.Path())
+		if !rule.Authenticated {
+			if err := authorize(c, p.opt, rule); err != nil {
+				return err
+			}
+		}
+		return c.Next()
+	})
+	return func(c *fiber.Ctx) error {
+		rule, ok := p.lookup(requestMethod(c), c.Path())
+		if !ok || rule.Public {
+			return c.Next()
+		}
+		return check(c)
+	}
+}
+
+// Verify checks the policy against the routes of an app: every route must be declared, with the
+// same path, and every declaration must name a route. HEAD routes follow their GET route.
+func (p *Policy) Verify(routes []fiber.Route) error {
+	declared := make(map[string]bool, len(p.routes))
+	for _, r := range p.routes {
+		declared[r.method+" "+routePath(r.pattern)] = false
+	}
+
+	var undeclared []string
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		key := route.Method + " " + routePath(route.Path)
+		if _, ok := declared[key]; !ok {
+			undeclared = append(undeclared, key)
+			continue
+		}
+		declared[key] = true
+	}
+	var unused []string
+	for key, used := range declared {
+		if !used {
+			unused = append(unused, key)
+		}
+	}
+	if len(undeclared) == 0 && len(unused) == 0 {
+		return nil
+	}
+	sort.Strings(undeclared)
+	sort.Strings(unused)
+	return fmt.Errorf("access policy does not match the routes: undeclared %q, declared without a route %q", undeclared, unused)
+}
+
+// RequirePermission returns middleware letting users through whose role grants any of perms.
+func RequirePermission(opt Options, perms ...string) fiber.Handler {
+	return requireRule(opt, Rule{Any: perms})
+}
+
+// RequireOwnerOrPermission returns middleware letting users through whose role grants any of
+// perms, or who own the targeted resource and hold any of own.
+func RequireOwnerOrPermission(opt Options, owner OwnerFunc, own []string, perms ...string) fiber.Handler {
+	if len(own) > 0 && owner == nil {
+		panic("auth: owner permissions need an owner resolver")
+	}
+	return requireRule(opt, Rule{Any: perms, Own: own, Owner: owner})
+}
+
+func requireRule(opt Options, rule Rule) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := authorize(c, opt, rule); err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// authorize checks a rule against the role RefreshTokenMiddleware left in the request locals,
+// using the role's cached permission snapshot.
+func authorize(c *fiber.Ctx, opt Options, rule Rule) error {
+	userIDRaw, _ := c.Locals("user_id").(string)
+	roleID, ok := c.Locals("role_id").(uuid.UUID)
+	if !ok || userIDRaw == "" {
+		opt.Logger.Warn(c.Context()).WithFields("path", c.Path()).Logs("Permission check without authenticated role")
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	snapshot, err := models.GetPermissionSnapshot(c.Context(), opt.Rclient, opt.DB, roleID)
+	if err != nil {
+		opt.Logger.Warn(c.Context()).WithFields("error", err, "role_id", roleID).Logs("Failed to get permissions")
+		return apierror.Internal("Internal Server Error")
+	}
+	if snapshot.Has(rule.Any...) {
+		opt.Logger.Debug(c.Context()).WithFields("user_id", userIDRaw).Logs("Permission authorized")
+		return nil
+	}
+
+	if len(rule.Own) > 0 && snapshot.Has(rule.Own...) {
+		owner, err := rule.Owner(c)
+		if err != nil {
+			return err
+		}
+		if owner.String() == userIDRaw {
+			opt.Logger.Debug(c.Context()).WithFields("user_id", userIDRaw).Logs("Owner authorized")
+			return nil
+		}
+	}
+
+	opt.Logger.Warn(c.Context()).WithFields(
+		"user_id", userIDRaw,
+		"required_perms", rule.Any,
+		"owner_perms", rule.Own,
+		"role_version", snapshot.Version,
+	).Logs("Insufficient permissions")
+	return apierror.Forbidden("Insufficient permissions")
+}
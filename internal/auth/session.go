@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// TokenTransportHeader lets clients without a cookie jar, such as mobile apps and CLIs, ask
+	// for tokens in the response body by sending "body". Such clients then authenticate with an
+	// "Authorization: Bearer" access token and refresh by posting their refresh token.
+	TokenTransportHeader = "X-Token-Transport"
+
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+	// ImpersonationTTL is how long support staff can act as a user with one impersonation token.
+	ImpersonationTTL = 10 * time.Minute
+)
+
+// WantsBodyTokens reports whether the client asked for tokens in the response body.
+func WantsBodyTokens(c *fiber.Ctx) bool {
+	return strings.EqualFold(c.Get(TokenTransportHeader), "body")
+}
+
+// IssueTokens hands the token pair of a new session to the client. Browsers get them as HTTP-only
+// cookies along with a new CSRF token and nil is returned; clients that asked for body tokens get
+// them returned for the response instead, and no cookies are set.
+func IssueTokens(c *fiber.Ctx, accessToken, refreshToken string) fiber.Map {
+	if WantsBodyTokens(c) {
+		return bodyTokens(accessToken, refreshToken)
+	}
+	setSessionCookies(c, accessToken, refreshToken)
+	setCSRFCookie(c)
+	return nil
+}
+
+// RenewTokens is IssueTokens for a refreshed session, which keeps its CSRF token so requests
+// already in flight are not refused.
+func RenewTokens(c *fiber.Ctx, accessToken, refreshToken string) fiber.Map {
+	if WantsBodyTokens(c) {
+		return bodyTokens(accessToken, refreshToken)
+	}
+	setSessionCookies(c, accessToken, refreshToken)
+	return nil
+}
+
+func bodyTokens(accessToken, refreshToken string) fiber.Map {
+	return fiber.Map{
+		"token_type":    "Bearer",
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(AccessTokenTTL.Seconds()),
+	}
+}
+
+// setSessionCookies stores a token pair in cookies, adding a CSRF token if the client has none.
+func setSessionCookies(c *fiber.Ctx, accessToken, refreshToken string) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "access_token",
+		Value:    accessToken,
+		Expires:  time.Now().Add(AccessTokenTTL),
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Strict",
+	})
+	c.Cookie(&fiber.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Expires:  time.Now().Add(RefreshTokenTTL),
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Strict",
+	})
+	if c.Cookies(csrfCookie) == "" {
+		setCSRFCookie(c)
+	}
+}
+
+// ClearTokens expires the session cookies.
+func ClearTokens(c *fiber.Ctx) {
+	for _, name := range []string{"access_token", "refresh_token", csrfCookie} {
+		c.Cookie(&fiber.Cookie{
+			Name:     name,
+			Value:    "",
+			Expires:  time.Now().Add(-time.Hour),
+			HTTPOnly: name != csrfCookie,
+			Secure:   true,
+			SameSite: "Strict",
+		})
+	}
+}
+
+// RefreshTokenOf returns the refresh token of a request: the cookie for browsers, or the
+// "refresh_token" field of the JSON body for clients using body tokens.
+func RefreshTokenOf(c *fiber.Ctx) string {
+	if token := c.Cookies("refresh_token"); token != "" {
+		return token
+	}
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+		c.BodyParser(&body)
+	}
+	return strings.TrimSpace(body.RefreshToken)
+}
+
+// AccessTokenOf returns the access token of a request, from the Authorization header or the
+// cookie, and whether it came from the header.
+func AccessTokenOf(c *fiber.Ctx) (string, bool) {
+	if token, ok := bearerToken(c); ok {
+		return token, true
+	}
+	return c.Cookies("access_token"), false
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header, if any.
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	token = strings.TrimSpace(token)
+	return token, ok && token != ""
+}
@@ -19,6 +19,84 @@ type Config struct {
 	RedisAddr  string
 	ServerAddr string
 	JWTSecret  string
+	CacheTTLs  string
+	TTLJitter  string
+	CacheWrite string
+	// MigrateOnStart applies pending migrations at startup; unset, it does so outside production.
+	MigrateOnStart string
+
+	LogoutOnPasswordChange string
+	LogoutOnRoleChange     string
+
+	ForceHTTPS            string
+	HSTSMaxAge            string
+	HSTSIncludeSubdomains string
+	TrustedProxies        string
+
+	MetricsToken string
+	Compression  string
+
+	GRPCAddr         string
+	GRPCCertFile     string
+	GRPCKeyFile      string
+	GRPCClientCAFile string
+
+	// RedisMode is standalone, sentinel or cluster; RedisAddr is then a comma separated list of
+	// the sentinels or cluster nodes.
+	RedisMode             string
+	RedisMasterName       string
+	RedisPassword         string
+	RedisSentinelPassword string
+
+	ProfileFieldIntervals string
+	// NotificationRetentionCount is how many of their latest notifications users keep before the
+	// rest are archived; NotificationRetentionAge, a duration, is how long notifications are kept.
+	NotificationRetentionCount string
+	NotificationRetentionAge   string
+
+	RegistrationMode   string
+	ContentFilterRules string
+	// GeoIPDatabase is a CSV file of networks and their locations used to place sign-ins.
+	GeoIPDatabase string
+
+	PrivacyMode  string
+	TwoFactorKey string
+	CursorKey    string
+	WebhookKey   string
+	PreviewKey   string
+
+	VAPIDPrivateKey string
+	VAPIDSubject    string
+
+	UploadDriver     string
+	UploadDir        string
+	UploadPublicURL  string
+	UploadSigningKey string
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+
+	// MailProvider is smtp, ses or sendgrid.
+	MailProvider   string
+	MailFrom       string
+	SMTPHost       string
+	SMTPPort       string
+	SMTPUsername   string
+	SMTPPassword   string
+	SESRegion      string
+	SESAccessKey   string
+	SESSecretKey   string
+	SendGridAPIKey string
+
+	OAuthCallbackURL     string
+	GoogleClientID       string
+	GoogleClientSecret   string
+	GitHubClientID       string
+	GitHubClientSecret   string
+	FacebookClientID     string
+	FacebookClientSecret string
 }
 
 func LoadConfig() *Config {
@@ -36,5 +114,77 @@ func LoadConfig() *Config {
 		RedisAddr:  os.Getenv("REDIS_ADDR"),
 		ServerAddr: os.Getenv("PORT"),
 		JWTSecret:  os.Getenv("JWT_SECRET"),
+		CacheTTLs:  os.Getenv("CACHE_TTLS"),
+		TTLJitter:  os.Getenv("CACHE_TTL_JITTER"),
+		CacheWrite: os.Getenv("CACHE_WRITE_MODE"),
+
+		MigrateOnStart: os.Getenv("MIGRATE_ON_START"),
+
+		LogoutOnPasswordChange: os.Getenv("LOGOUT_ON_PASSWORD_CHANGE"),
+		LogoutOnRoleChange:     os.Getenv("LOGOUT_ON_ROLE_CHANGE"),
+
+		ForceHTTPS:            os.Getenv("FORCE_HTTPS"),
+		HSTSMaxAge:            os.Getenv("HSTS_MAX_AGE"),
+		HSTSIncludeSubdomains: os.Getenv("HSTS_INCLUDE_SUBDOMAINS"),
+		TrustedProxies:        os.Getenv("TRUSTED_PROXIES"),
+
+		MetricsToken: os.Getenv("METRICS_TOKEN"),
+		Compression:  os.Getenv("COMPRESSION"),
+
+		GRPCAddr:         os.Getenv("GRPC_ADDR"),
+		GRPCCertFile:     os.Getenv("GRPC_TLS_CERT"),
+		GRPCKeyFile:      os.Getenv("GRPC_TLS_KEY"),
+		GRPCClientCAFile: os.Getenv("GRPC_CLIENT_CA"),
+
+		RedisMode:             os.Getenv("REDIS_MODE"),
+		RedisMasterName:       os.Getenv("REDIS_MASTER_NAME"),
+		RedisPassword:         os.Getenv("REDIS_PASSWORD"),
+		RedisSentinelPassword: os.Getenv("REDIS_SENTINEL_PASSWORD"),
+
+		ProfileFieldIntervals:      os.Getenv("PROFILE_FIELD_INTERVALS"),
+		NotificationRetentionCount: os.Getenv("NOTIFICATION_RETENTION_COUNT"),
+		NotificationRetentionAge:   os.Getenv("NOTIFICATION_RETENTION_AGE"),
+
+		RegistrationMode:   os.Getenv("REGISTRATION_MODE"),
+		ContentFilterRules: os.Getenv("CONTENT_FILTER_RULES"),
+		GeoIPDatabase:      os.Getenv("GEOIP_DATABASE"),
+
+		PrivacyMode:  os.Getenv("PRIVACY_MODE"),
+		TwoFactorKey: os.Getenv("TWO_FACTOR_KEY"),
+		CursorKey:    os.Getenv("CURSOR_KEY"),
+		WebhookKey:   os.Getenv("WEBHOOK_KEY"),
+		PreviewKey:   os.Getenv("POST_PREVIEW_KEY"),
+
+		VAPIDPrivateKey: os.Getenv("VAPID_PRIVATE_KEY"),
+		VAPIDSubject:    os.Getenv("VAPID_SUBJECT"),
+
+		UploadDriver:     os.Getenv("UPLOAD_DRIVER"),
+		UploadDir:        os.Getenv("UPLOAD_DIR"),
+		UploadPublicURL:  os.Getenv("UPLOAD_PUBLIC_URL"),
+		UploadSigningKey: os.Getenv("UPLOAD_SIGNING_KEY"),
+		S3Endpoint:       os.Getenv("S3_ENDPOINT"),
+		S3Region:         os.Getenv("S3_REGION"),
+		S3Bucket:         os.Getenv("S3_BUCKET"),
+		S3AccessKey:      os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:      os.Getenv("S3_SECRET_KEY"),
+
+		MailProvider:   os.Getenv("MAIL_PROVIDER"),
+		MailFrom:       os.Getenv("MAIL_FROM"),
+		SMTPHost:       os.Getenv("SMTP_HOST"),
+		SMTPPort:       os.Getenv("SMTP_PORT"),
+		SMTPUsername:   os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:   os.Getenv("SMTP_PASSWORD"),
+		SESRegion:      os.Getenv("SES_REGION"),
+		SESAccessKey:   os.Getenv("SES_ACCESS_KEY"),
+		SESSecretKey:   os.Getenv("SES_SECRET_KEY"),
+		SendGridAPIKey: os.Getenv("SENDGRID_API_KEY"),
+
+		OAuthCallbackURL:     os.Getenv("OAUTH_CALLBACK_URL"),
+		GoogleClientID:       os.Getenv("GOOGLE_CLIENT_ID"),
+		GoogleClientSecret:   os.Getenv("GOOGLE_CLIENT_SECRET"),
+		GitHubClientID:       os.Getenv("GITHUB_CLIENT_ID"),
+		GitHubClientSecret:   os.Getenv("GITHUB_CLIENT_SECRET"),
+		FacebookClientID:     os.Getenv("FACEBOOK_CLIENT_ID"),
+		FacebookClientSecret: os.Getenv("FACEBOOK_CLIENT_SECRET"),
 	}
 }
@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/mnuddindev/devpulse/internal/models"
 	"github.com/mnuddindev/devpulse/pkg/logger"
@@ -15,7 +17,10 @@ var (
 	DBInstance *gorm.DB
 )
 
-func NewDB(ctx context.Context, dsn string, rclient *storage.RedisClient, log *logger.Logger) (*gorm.DB, error) {
+// NewDB connects to the database, brings its schema up to date and applies the role policy. With
+// migrate unset pending migrations are not applied but refused, so a release never runs against a
+// schema it does not know; operators apply them first with devpulsectl migrate up.
+func NewDB(ctx context.Context, dsn string, rclient *storage.RedisClient, log *logger.Logger, migrate bool) (*gorm.DB, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -24,9 +29,9 @@ func NewDB(ctx context.Context, dsn string, rclient *storage.RedisClient, log *l
 		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "DB initialization canceled")
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := Open(dsn)
 	if err != nil {
-		return nil, utils.NewError(utils.ErrInternalServerError.Code, "Failed to connect to Database", err.Error())
+		return nil, err
 	}
 
 	DBInstance = db
@@ -34,17 +39,40 @@ func NewDB(ctx context.Context, dsn string, rclient *storage.RedisClient, log *l
 		return nil, utils.NewError(utils.ErrInternalServerError.Code, "Database not initialized")
 	}
 
-	if err := db.WithContext(ctx).AutoMigrate(models.RegisterModels()...); err != nil {
-		return nil, utils.NewError(utils.ErrInternalServerError.Code, "Failed to auto-migrate models", err.Error())
+	if migrate {
+		if err := Migrate(ctx, db, log); err != nil {
+			return nil, err
+		}
+	} else {
+		pending, err := PendingMigrations(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		if len(pending) > 0 {
+			return nil, utils.NewError(utils.ErrInternalServerError.Code,
+				fmt.Sprintf("%d pending migrations, run devpulsectl migrate up first", len(pending)), strings.Join(pending, ", "))
+		}
 	}
 
-	if err := models.SeedRoles(ctx, db, rclient, log); err != nil {
+	if err := models.ApplyRolePolicy(ctx, db, rclient, log); err != nil {
+		return nil, err
+	}
+	if err := models.InvalidateRoleCaches(ctx, rclient, db); err != nil {
 		return nil, err
 	}
 
 	return DBInstance, nil
 }
 
+// Open connects to the database without migrating it.
+func Open(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, utils.NewError(utils.ErrInternalServerError.Code, "Failed to connect to Database", err.Error())
+	}
+	return db, nil
+}
+
 func GetDB() *gorm.DB {
 	if DBInstance == nil {
 		panic("Database connection not initialized; call NewDB first")
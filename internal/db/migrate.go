@@ -0,0 +1,267 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// migrationLock is the advisory lock key serializing migrations across instances.
+const migrationLock = 7_241_000
+
+// baselineID is the migration creating the schema as auto-migration last left it. Databases that
+// auto-migration created already have that schema, recognized by their users table, so the
+// baseline is recorded for them without running.
+const baselineID = "0000_baseline_schema"
+
+// sqlMigrations holds the SQL migrations: <id>.up.sql files with their <id>.down.sql.
+//
+//go:embed migrations/*.sql
+var sqlMigrations embed.FS
+
+// Migration is a versioned change to the schema or the data. Each one runs once, in the order of
+// the IDs, inside its own transaction.
+type Migration struct {
+	ID string
+	Up func(tx *gorm.DB) error
+	// Down reverts Up; a migration without it cannot be rolled back.
+	Down func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration.
+type SchemaMigration struct {
+	ID        string    `gorm:"size:100;primaryKey"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// MigrationState tells whether a migration was applied.
+type MigrationState struct {
+	ID         string
+	AppliedAt  *time.Time
+	Reversible bool
+}
+
+// allMigrations is every migration, the SQL files and those written in Go, ordered by ID.
+var allMigrations = mustLoadMigrations()
+
+// mustLoadMigrations merges the SQL migrations into the Go ones. The files are embedded, so a
+// malformed or duplicate one fails at startup rather than halfway through migrating.
+func mustLoadMigrations() []Migration {
+	byID := map[string]*Migration{}
+	for i := range migrations {
+		m := migrations[i]
+		byID[m.ID] = &m
+	}
+
+	files, err := sqlMigrations.ReadDir("migrations")
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range files {
+		name := f.Name()
+		var id, direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			id, direction = strings.TrimSuffix(name, ".up.sql"), "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			id, direction = strings.TrimSuffix(name, ".down.sql"), "down"
+		default:
+			panic("migration file " + name + " is neither .up.sql nor .down.sql")
+		}
+		raw, err := sqlMigrations.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			panic(err)
+		}
+
+		m := byID[id]
+		if m == nil {
+			m = &Migration{ID: id}
+			byID[id] = m
+		}
+		run := execSQL(string(raw))
+		if direction == "up" {
+			if m.Up != nil {
+				panic("migration " + id + " is declared twice")
+			}
+			m.Up = run
+		} else {
+			if m.Down != nil {
+				panic("migration " + id + " is declared twice")
+			}
+			m.Down = run
+		}
+	}
+
+	all := make([]Migration, 0, len(byID))
+	for _, m := range byID {
+		if m.Up == nil {
+			panic("migration " + m.ID + " has a down file but no up file")
+		}
+		all = append(all, *m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	return all
+}
+
+// execSQL runs a migration file as one statement batch; files holding only comments do nothing.
+func execSQL(sql string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, line := range strings.Split(sql, "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+				return tx.Exec(sql).Error
+			}
+		}
+		return nil
+	}
+}
+
+// ensureMigrationsTable creates the table recording applied migrations.
+func ensureMigrationsTable(ctx context.Context, db *gorm.DB) error {
+	if err := db.WithContext(ctx).Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		id varchar(100) PRIMARY KEY,
+		applied_at timestamptz NOT NULL
+	)`).Error; err != nil {
+		return utils.NewError(utils.ErrInternalServerError.Code, "Failed to create migrations table", err.Error())
+	}
+	return nil
+}
+
+// Migrate applies the migrations that have not run yet, each in its own transaction.
+func Migrate(ctx context.Context, db *gorm.DB, log *logger.Logger) error {
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return err
+	}
+
+	for _, m := range allMigrations {
+		applied, baselined := false, false
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLock).Error; err != nil {
+				return err
+			}
+			var count int64
+			if err := tx.Model(&SchemaMigration{}).Where("id = ?", m.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+			if m.ID == baselineID && tx.Migrator().HasTable("users") {
+				baselined = true
+			} else if err := m.Up(tx); err != nil {
+				return err
+			}
+			applied = true
+			return tx.Create(&SchemaMigration{ID: m.ID, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return utils.NewError(utils.ErrInternalServerError.Code, "Failed to apply migration "+m.ID, err.Error())
+		}
+		switch {
+		case baselined:
+			log.Info(ctx).WithFields("migration", m.ID).Logs("Recorded baseline of a database created by auto-migration")
+		case applied:
+			log.Info(ctx).WithFields("migration", m.ID).Logs("Applied migration")
+		}
+	}
+	return nil
+}
+
+// Rollback reverts the last steps applied migrations, newest first. It stops at the first one
+// that cannot be rolled back, leaving those before it applied.
+func Rollback(ctx context.Context, db *gorm.DB, log *logger.Logger, steps int) error {
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return err
+	}
+
+	for ; steps > 0; steps-- {
+		var reverted string
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLock).Error; err != nil {
+				return err
+			}
+			var done []string
+			if err := tx.Model(&SchemaMigration{}).Order("id DESC").Pluck("id", &done).Error; err != nil {
+				return err
+			}
+			for _, id := range done {
+				m := findMigration(id)
+				if m == nil {
+					// Applied by a newer release; rolling back past it needs that release
+					return fmt.Errorf("migration %s is unknown to this build", id)
+				}
+				if m.Down == nil {
+					return fmt.Errorf("migration %s cannot be rolled back", id)
+				}
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+				reverted = id
+				return tx.Where("id = ?", id).Delete(&SchemaMigration{}).Error
+			}
+			return nil
+		})
+		if err != nil {
+			return utils.NewError(utils.ErrInternalServerError.Code, "Failed to roll back migration", err.Error())
+		}
+		if reverted == "" {
+			return nil
+		}
+		log.Info(ctx).WithFields("migration", reverted).Logs("Rolled back migration")
+	}
+	return nil
+}
+
+func findMigration(id string) *Migration {
+	for i := range allMigrations {
+		if allMigrations[i].ID == id {
+			return &allMigrations[i]
+		}
+	}
+	return nil
+}
+
+// MigrationStates lists every migration with when it was applied.
+func MigrationStates(ctx context.Context, db *gorm.DB) ([]MigrationState, error) {
+	var done []SchemaMigration
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		if err := db.WithContext(ctx).Find(&done).Error; err != nil {
+			return nil, utils.NewError(utils.ErrInternalServerError.Code, "Failed to get applied migrations", err.Error())
+		}
+	}
+	appliedAt := make(map[string]time.Time, len(done))
+	for _, m := range done {
+		appliedAt[m.ID] = m.AppliedAt
+	}
+
+	states := make([]MigrationState, len(allMigrations))
+	for i, m := range allMigrations {
+		states[i] = MigrationState{ID: m.ID, Reversible: m.Down != nil}
+		if at, ok := appliedAt[m.ID]; ok {
+			states[i].AppliedAt = &at
+		}
+	}
+	return states, nil
+}
+
+// PendingMigrations lists the migrations that have not run yet.
+func PendingMigrations(ctx context.Context, db *gorm.DB) ([]string, error) {
+	states, err := MigrationStates(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	pending := []string{}
+	for _, s := range states {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.ID)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// migrations holds the migrations written in Go, for changes SQL alone cannot express; the others
+// are SQL files under migrations/. Both run in the order of their IDs, and applied migrations are
+// recorded by ID, so new migrations only ever get the next free ID. The schema no longer follows
+// the models by itself: a model change needs a migration.
+var migrations = []Migration{
+	{
+		// trusted_member became trusted in the declared role hierarchy; renaming keeps its users
+		ID: "0001_rename_trusted_member_role",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("UPDATE roles SET name = ? WHERE name = ? AND NOT EXISTS (SELECT 1 FROM roles WHERE name = ?)",
+				models.RoleTrusted, "trusted_member", models.RoleTrusted).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("UPDATE roles SET name = ? WHERE name = ? AND NOT EXISTS (SELECT 1 FROM roles WHERE name = ?)",
+				"trusted_member", models.RoleTrusted, "trusted_member").Error
+		},
+	},
+	{
+		// The old role seed granted permissions nothing checks; the catalog names replace them
+		ID: "0002_remove_retired_permissions",
+		Up: func(tx *gorm.DB) error {
+			retired := []string{"edit_user", "delete_user", "edit_reaction", "delete_reaction"}
+			if err := tx.Exec("DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ?)", retired).Error; err != nil {
+				return err
+			}
+			return tx.Exec("DELETE FROM permissions WHERE name IN ?", retired).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// The old seed granted them to moderators and admins
+			retired := []string{"edit_user", "delete_user", "edit_reaction", "delete_reaction"}
+			for _, name := range retired {
+				if err := tx.Exec("INSERT INTO permissions (name, created_at, updated_at) VALUES (?, NOW(), NOW()) ON CONFLICT (name) DO NOTHING", name).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Exec(`INSERT INTO role_permissions (role_id, permission_id)
+				SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions
+				WHERE roles.name IN ? AND permissions.name IN ?
+				ON CONFLICT DO NOTHING`, []string{models.RoleModerator, models.RoleAdmin}, retired).Error
+		},
+	},
+	{
+		// Accounts without a usable password, an empty one or the sentinel social logins store,
+		// cannot sign in with one. Older social accounts got a random bcrypt hash instead and
+		// cannot be told apart from password accounts, so they keep counting as having one
+		ID: "0003_mark_social_accounts_passwordless",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("UPDATE users SET passwordless = TRUE WHERE passwordless = FALSE AND password IN ?",
+				[]string{"", utils.UnusablePassword}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("UPDATE users SET passwordless = FALSE WHERE passwordless = TRUE AND password IN ?",
+				[]string{"", utils.UnusablePassword}).Error
+		},
+	},
+}
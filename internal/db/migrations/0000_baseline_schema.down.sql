@@ -0,0 +1,45 @@
+-- Drops every table of the baseline schema, dependents first.
+
+DROP TABLE IF EXISTS "search_documents";
+DROP TABLE IF EXISTS "organization_members";
+DROP TABLE IF EXISTS "data_exports";
+DROP TABLE IF EXISTS "mentions";
+DROP TABLE IF EXISTS "bookmarks";
+DROP TABLE IF EXISTS "collections";
+DROP TABLE IF EXISTS "comment_mentions";
+DROP TABLE IF EXISTS "comments";
+DROP TABLE IF EXISTS "post_revisions";
+DROP TABLE IF EXISTS "post_analytics";
+DROP TABLE IF EXISTS "series_posts";
+DROP TABLE IF EXISTS "post_mentions";
+DROP TABLE IF EXISTS "post_co_authors";
+DROP TABLE IF EXISTS "tag_analytics";
+DROP TABLE IF EXISTS "post_tags";
+DROP TABLE IF EXISTS "posts";
+DROP TABLE IF EXISTS "organizations";
+DROP TABLE IF EXISTS "series";
+DROP TABLE IF EXISTS "tag_followers";
+DROP TABLE IF EXISTS "tag_moderators";
+DROP TABLE IF EXISTS "tags";
+DROP TABLE IF EXISTS "push_subscriptions";
+DROP TABLE IF EXISTS "uploads";
+DROP TABLE IF EXISTS "blocked_terms";
+DROP TABLE IF EXISTS "invitation_redemptions";
+DROP TABLE IF EXISTS "invitations";
+DROP TABLE IF EXISTS "api_keys";
+DROP TABLE IF EXISTS "outbox_events";
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhooks";
+DROP TABLE IF EXISTS "audit_logs";
+DROP TABLE IF EXISTS "moderation_actions";
+DROP TABLE IF EXISTS "identities";
+DROP TABLE IF EXISTS "security_events";
+DROP TABLE IF EXISTS "notification_preferences";
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "permissions";
+DROP TABLE IF EXISTS "user_followers";
+DROP TABLE IF EXISTS "user_badges";
+DROP TABLE IF EXISTS "badges";
+DROP TABLE IF EXISTS "users";
+DROP TABLE IF EXISTS "roles";
@@ -0,0 +1,718 @@
+-- The schema as the models defined it when versioned migrations replaced auto-migration.
+-- Databases created before then already have it and only get this migration recorded.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE "roles" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(50) NOT NULL,
+    "version" bigint NOT NULL DEFAULT 1,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_roles_name" UNIQUE ("name")
+);
+
+CREATE TABLE "users" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "username" varchar(255) NOT NULL,
+    "email" varchar(100) NOT NULL,
+    "password" varchar(255) NOT NULL,
+    "passwordless" boolean NOT NULL DEFAULT false,
+    "otp" text NOT NULL,
+    "is_active" boolean DEFAULT false,
+    "is_email_verified" boolean DEFAULT false,
+    "role_id" uuid NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'active',
+    "status_reason" varchar(255),
+    "suspended_until" timestamptz,
+    "previous_passwords" text,
+    "last_password_change" timestamptz DEFAULT current_timestamp,
+    "last_login_at" timestamptz,
+    "last_login_ip" varchar(45),
+    "token_version" bigint NOT NULL DEFAULT 0,
+    "two_factor_enabled" boolean DEFAULT false,
+    "two_factor_secret" varchar(255),
+    "two_factor_backup_codes" text,
+    "name" varchar(100),
+    "bio" text,
+    "avatar_url" text,
+    "job_title" varchar(100),
+    "employer" varchar(100),
+    "location" varchar(100),
+    "social_links" jsonb DEFAULT '{}',
+    "current_learning" text,
+    "available_for" text,
+    "currently_hacking_on" text,
+    "pronouns" text,
+    "education" text,
+    "skills" jsonb DEFAULT '[]',
+    "interests" jsonb DEFAULT '[]',
+    "brand_color" text,
+    "theme_preference" varchar(20) DEFAULT 'light',
+    "base_font" varchar(50) DEFAULT 'sans-serif',
+    "site_navbar" varchar(20) DEFAULT 'fixed',
+    "content_editor" varchar(20) DEFAULT 'rich',
+    "content_mode" bigint DEFAULT 1,
+    "locale" varchar(10) NOT NULL DEFAULT 'en',
+    "posts_count" bigint DEFAULT 0,
+    "comments_count" bigint DEFAULT 0,
+    "likes_count" bigint DEFAULT 0,
+    "bookmarks_count" bigint DEFAULT 0,
+    "tag_count" bigint DEFAULT 0,
+    "followers_count" bigint DEFAULT 0,
+    "following_count" bigint DEFAULT 0,
+    "reactions_count" bigint DEFAULT 0,
+    "last_seen" timestamptz DEFAULT current_timestamp,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id"),
+    CONSTRAINT "uni_users_username" UNIQUE ("username"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_users_status" ON "users" ("status");
+
+CREATE TABLE "badges" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(100) NOT NULL,
+    "image" varchar(255) NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_badges_name" UNIQUE ("name")
+);
+
+CREATE TABLE "user_badges" (
+    "user_id" uuid DEFAULT uuid_generate_v4(),
+    "badge_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("user_id","badge_id"),
+    CONSTRAINT "fk_user_badges_badge" FOREIGN KEY ("badge_id") REFERENCES "badges"("id"),
+    CONSTRAINT "fk_user_badges_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE "user_followers" (
+    "following_id" uuid DEFAULT uuid_generate_v4(),
+    "follower_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("following_id","follower_id"),
+    CONSTRAINT "fk_user_followers_user" FOREIGN KEY ("following_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_user_followers_followers" FOREIGN KEY ("follower_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE "permissions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(50) NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_permissions_name" UNIQUE ("name")
+);
+
+CREATE TABLE "role_permissions" (
+    "role_id" uuid DEFAULT uuid_generate_v4(),
+    "permission_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("role_id","permission_id"),
+    CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id"),
+    CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions"("id")
+);
+
+CREATE TABLE "notifications" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "type" varchar(50) NOT NULL,
+    "message" varchar(255) NOT NULL,
+    "is_read" boolean DEFAULT false,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_notifications" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notification_user_created" ON "notifications" ("user_id","created_at");
+
+CREATE TABLE "notification_preferences" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "email_on_likes" boolean DEFAULT false,
+    "email_on_comments" boolean DEFAULT false,
+    "email_on_mentions" boolean DEFAULT false,
+    "email_on_followers" boolean DEFAULT false,
+    "email_on_badge" boolean DEFAULT false,
+    "email_on_unread" boolean DEFAULT false,
+    "email_on_new_posts" boolean DEFAULT false,
+    "push_on_reactions" boolean DEFAULT false,
+    "push_on_comments" boolean DEFAULT false,
+    "push_on_mentions" boolean DEFAULT false,
+    "push_on_followers" boolean DEFAULT false,
+    "push_on_badges" boolean DEFAULT false,
+    "push_on_new_posts" boolean DEFAULT false,
+    "in_app_on_reactions" boolean DEFAULT true,
+    "in_app_on_comments" boolean DEFAULT true,
+    "in_app_on_mentions" boolean DEFAULT true,
+    "in_app_on_followers" boolean DEFAULT true,
+    "in_app_on_badges" boolean DEFAULT true,
+    "in_app_on_new_posts" boolean DEFAULT true,
+    "digest_frequency" varchar(10) NOT NULL DEFAULT 'daily',
+    "last_digest_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_notification_preferences" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "uni_notification_preferences_user_id" UNIQUE ("user_id")
+);
+
+CREATE TABLE "security_events" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "event" varchar(50) NOT NULL,
+    "ip" varchar(45),
+    "user_agent" varchar(255),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_security_events_user_id" ON "security_events" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_security_events_created_at" ON "security_events" ("created_at");
+
+CREATE TABLE "identities" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "provider" varchar(20) NOT NULL,
+    "provider_user_id" varchar(255) NOT NULL,
+    "email" varchar(100),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_identity_provider_user" ON "identities" ("provider","provider_user_id");
+CREATE INDEX IF NOT EXISTS "idx_identities_user_id" ON "identities" ("user_id");
+
+CREATE TABLE "moderation_actions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "actor_id" uuid NOT NULL,
+    "action" varchar(30) NOT NULL,
+    "reason" varchar(255),
+    "expires_at" timestamptz,
+    "ip" varchar(45),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_moderation_actions_created_at" ON "moderation_actions" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_moderation_actions_actor_id" ON "moderation_actions" ("actor_id");
+CREATE INDEX IF NOT EXISTS "idx_moderation_actions_user_id" ON "moderation_actions" ("user_id");
+
+CREATE TABLE "audit_logs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "actor_id" uuid NOT NULL,
+    "action" varchar(50) NOT NULL,
+    "target_type" varchar(30) NOT NULL,
+    "target_id" uuid NOT NULL,
+    "before" jsonb NOT NULL DEFAULT '{}',
+    "after" jsonb NOT NULL DEFAULT '{}',
+    "ip" varchar(45),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_created_at" ON "audit_logs" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_target" ON "audit_logs" ("target_type","target_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_action" ON "audit_logs" ("action");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_actor_id" ON "audit_logs" ("actor_id");
+
+CREATE TABLE "webhooks" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "url" varchar(500) NOT NULL,
+    "secret" varchar(255) NOT NULL,
+    "events" jsonb NOT NULL DEFAULT '[]',
+    "global" boolean DEFAULT false,
+    "active" boolean DEFAULT true,
+    "failure_count" bigint DEFAULT 0,
+    "last_delivery_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhooks_deleted_at" ON "webhooks" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_webhooks_global" ON "webhooks" ("global");
+CREATE INDEX IF NOT EXISTS "idx_webhooks_user_id" ON "webhooks" ("user_id");
+
+CREATE TABLE "webhook_deliveries" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "webhook_id" uuid NOT NULL,
+    "event" varchar(50) NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "response_status" bigint,
+    "response_body" varchar(1024),
+    "error" varchar(255),
+    "duration_ms" bigint,
+    "delivered_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_due" ON "webhook_deliveries" ("status","next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_log" ON "webhook_deliveries" ("webhook_id","created_at");
+
+CREATE TABLE "outbox_events" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "kind" varchar(50) NOT NULL,
+    "payload" jsonb,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "error" varchar(255),
+    "processed_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_outbox_due" ON "outbox_events" ("status","next_attempt_at");
+
+CREATE TABLE "api_keys" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "prefix" varchar(20) NOT NULL,
+    "token_hash" varchar(64) NOT NULL,
+    "scopes" jsonb NOT NULL DEFAULT '[]',
+    "last_used_at" timestamptz,
+    "last_used_ip" varchar(45),
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_api_keys_revoked_at" ON "api_keys" ("revoked_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_keys_token_hash" ON "api_keys" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_api_keys_user_id" ON "api_keys" ("user_id");
+
+CREATE TABLE "invitations" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "code" varchar(32) NOT NULL,
+    "created_by_id" uuid NOT NULL,
+    "max_uses" bigint NOT NULL DEFAULT 1,
+    "uses" bigint NOT NULL DEFAULT 0,
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invitations_code" ON "invitations" ("code");
+CREATE INDEX IF NOT EXISTS "idx_invitations_revoked_at" ON "invitations" ("revoked_at");
+CREATE INDEX IF NOT EXISTS "idx_invitations_created_by_id" ON "invitations" ("created_by_id");
+
+CREATE TABLE "invitation_redemptions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "invitation_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invitation_redemptions_user_id" ON "invitation_redemptions" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_invitation_redemptions_invitation_id" ON "invitation_redemptions" ("invitation_id");
+
+CREATE TABLE "blocked_terms" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "kind" varchar(10) NOT NULL,
+    "value" varchar(100) NOT NULL,
+    "substring" boolean NOT NULL DEFAULT false,
+    "created_by_id" uuid NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_blocked_term" ON "blocked_terms" ("kind","value");
+
+CREATE TABLE "uploads" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "key" varchar(255) NOT NULL,
+    "content_type" varchar(50) NOT NULL,
+    "size" bigint NOT NULL,
+    "width" bigint,
+    "height" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_uploads_key" ON "uploads" ("key");
+CREATE INDEX IF NOT EXISTS "idx_uploads_user_id" ON "uploads" ("user_id");
+
+CREATE TABLE "push_subscriptions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "endpoint" varchar(500) NOT NULL,
+    "p256dh" varchar(100) NOT NULL,
+    "auth" varchar(50) NOT NULL,
+    "user_agent" varchar(255),
+    "last_used_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_push_subscriptions_user_id" ON "push_subscriptions" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_push_subscriptions_endpoint" ON "push_subscriptions" ("endpoint");
+
+CREATE TABLE "tags" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(30) NOT NULL,
+    "slug" varchar(35) NOT NULL,
+    "description" varchar(200),
+    "short_description" varchar(100),
+    "icon_url" varchar(500),
+    "background_url" varchar(500),
+    "text_color" varchar(7),
+    "background_color" varchar(7),
+    "is_approved" boolean DEFAULT false,
+    "is_featured" boolean DEFAULT false,
+    "is_moderated" boolean DEFAULT false,
+    "posts_count" bigint DEFAULT 0,
+    "followers_count" bigint DEFAULT 0,
+    "rules" varchar(500),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tags_is_featured" ON "tags" ("is_featured");
+CREATE INDEX IF NOT EXISTS "idx_tags_is_approved" ON "tags" ("is_approved");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tag_slug" ON "tags" ("slug");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tag_name" ON "tags" ("name");
+CREATE INDEX IF NOT EXISTS "idx_tags_deleted_at" ON "tags" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_tags_is_moderated" ON "tags" ("is_moderated");
+
+CREATE TABLE "tag_moderators" (
+    "tag_id" uuid,
+    "user_id" uuid,
+    "created_at" timestamptz,
+    PRIMARY KEY ("tag_id","user_id"),
+    CONSTRAINT "fk_tag_moderators_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id"),
+    CONSTRAINT "fk_tag_moderators_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tag_moderators_user_id" ON "tag_moderators" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_tag_moderators_tag_id" ON "tag_moderators" ("tag_id");
+
+CREATE TABLE "tag_followers" (
+    "tag_id" uuid,
+    "user_id" uuid,
+    "created_at" timestamptz,
+    PRIMARY KEY ("tag_id","user_id"),
+    CONSTRAINT "fk_tag_followers_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id"),
+    CONSTRAINT "fk_tag_followers_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tag_followers_user_id" ON "tag_followers" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_tag_followers_tag_id" ON "tag_followers" ("tag_id");
+
+CREATE TABLE "series" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "title" varchar(120) NOT NULL,
+    "slug" varchar(140) NOT NULL,
+    "description" text NOT NULL,
+    "cover_image_url" varchar(500),
+    "author_id" uuid NOT NULL,
+    "is_published" boolean DEFAULT false,
+    "total_posts" bigint DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_series_author" FOREIGN KEY ("author_id") REFERENCES "users"("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_series_deleted_at" ON "series" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_series_is_published" ON "series" ("is_published");
+CREATE INDEX IF NOT EXISTS "idx_series_author" ON "series" ("author_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_series_slug" ON "series" ("slug");
+
+CREATE TABLE "organizations" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(100) NOT NULL,
+    "slug" varchar(60) NOT NULL,
+    "summary" varchar(250),
+    "avatar_url" varchar(500),
+    "website_url" varchar(500),
+    "location" varchar(100),
+    "brand_color" varchar(7),
+    "members_count" bigint DEFAULT 0,
+    "created_by_id" uuid NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_organizations_deleted_at" ON "organizations" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_organization_slug" ON "organizations" ("slug");
+
+CREATE TABLE "posts" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "title" varchar(200) NOT NULL,
+    "slug" varchar(220) NOT NULL,
+    "content" text NOT NULL,
+    "excerpt" varchar(300),
+    "featured_image_url" varchar(500),
+    "published" boolean DEFAULT false,
+    "published_at" timestamptz,
+    "scheduled_at" timestamptz,
+    "schedule_timezone" varchar(64),
+    "visibility" varchar(20) NOT NULL DEFAULT 'public',
+    "status" varchar(20) DEFAULT 'draft',
+    "publishing_status" varchar(50) DEFAULT 'draft',
+    "content_format" varchar(20) DEFAULT 'markdown',
+    "canonical_url" varchar(500),
+    "meta_title" varchar(200),
+    "meta_description" varchar(300),
+    "seo_keywords" varchar(255),
+    "og_title" varchar(200),
+    "og_description" varchar(300),
+    "og_image_url" varchar(500),
+    "twitter_title" varchar(200),
+    "twitter_description" varchar(300),
+    "twitter_image_url" varchar(500),
+    "author_id" uuid NOT NULL,
+    "series_id" uuid,
+    "organization_id" uuid,
+    "edited_at" timestamptz,
+    "last_edited_by_id" uuid,
+    "needs_review" boolean DEFAULT false,
+    "reviewed_by_id" uuid,
+    "reviewed_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_posts_series" FOREIGN KEY ("series_id") REFERENCES "series"("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "fk_posts_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "fk_posts_author" FOREIGN KEY ("author_id") REFERENCES "users"("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_posts_edited_at" ON "posts" ("edited_at");
+CREATE INDEX IF NOT EXISTS "idx_post_series" ON "posts" ("series_id");
+CREATE INDEX IF NOT EXISTS "idx_posts_status" ON "posts" ("status");
+CREATE INDEX IF NOT EXISTS "idx_posts_visibility" ON "posts" ("visibility");
+CREATE INDEX IF NOT EXISTS "idx_post_scheduled_at" ON "posts" ("scheduled_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_slug" ON "posts" ("slug");
+CREATE INDEX IF NOT EXISTS "idx_posts_deleted_at" ON "posts" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_posts_reviewed_at" ON "posts" ("reviewed_at");
+CREATE INDEX IF NOT EXISTS "idx_posts_needs_review" ON "posts" ("needs_review");
+CREATE INDEX IF NOT EXISTS "idx_post_organization" ON "posts" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_post_author" ON "posts" ("author_id");
+CREATE INDEX IF NOT EXISTS "idx_post_published_at" ON "posts" ("published_at");
+CREATE INDEX IF NOT EXISTS "idx_posts_published" ON "posts" ("published");
+CREATE INDEX IF NOT EXISTS "idx_post_title" ON "posts" ("title");
+
+CREATE TABLE "post_tags" (
+    "posts_id" uuid DEFAULT uuid_generate_v4(),
+    "tag_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("posts_id","tag_id"),
+    CONSTRAINT "fk_post_tags_posts" FOREIGN KEY ("posts_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_post_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+
+CREATE TABLE "tag_analytics" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tag_id" uuid NOT NULL,
+    "daily_views" bigint DEFAULT 0,
+    "weekly_views" bigint DEFAULT 0,
+    "monthly_views" bigint DEFAULT 0,
+    "daily_followers" bigint DEFAULT 0,
+    "weekly_followers" bigint DEFAULT 0,
+    "monthly_followers" bigint DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_tags_analytics" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tag_analytics_tag_id" ON "tag_analytics" ("tag_id");
+
+CREATE TABLE "post_co_authors" (
+    "posts_id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("posts_id","user_id"),
+    CONSTRAINT "fk_post_co_authors_posts" FOREIGN KEY ("posts_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_post_co_authors_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE "post_mentions" (
+    "posts_id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("posts_id","user_id"),
+    CONSTRAINT "fk_post_mentions_posts" FOREIGN KEY ("posts_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_post_mentions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE "series_posts" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "series_id" uuid NOT NULL,
+    "post_id" uuid NOT NULL,
+    "position" bigint NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_series_posts_post" FOREIGN KEY ("post_id") REFERENCES "posts"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "fk_series_series_posts" FOREIGN KEY ("series_id") REFERENCES "series"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_series_post_post" ON "series_posts" ("post_id");
+CREATE INDEX IF NOT EXISTS "idx_series_post_series" ON "series_posts" ("series_id");
+CREATE INDEX IF NOT EXISTS "idx_series_position" ON "series_posts" ("position");
+
+CREATE TABLE "post_analytics" (
+    "post_id" uuid,
+    "views_count" bigint DEFAULT 0,
+    "comments_count" bigint DEFAULT 0,
+    "reactions_count" bigint DEFAULT 0,
+    "bookmarks_count" bigint DEFAULT 0,
+    "read_time" bigint DEFAULT 1,
+    "shares_count" bigint DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("post_id"),
+    CONSTRAINT "fk_posts_post_analytics" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+
+CREATE TABLE "post_revisions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "post_id" uuid NOT NULL,
+    "editor_id" uuid NOT NULL,
+    "title" varchar(200) NOT NULL,
+    "content" text NOT NULL,
+    "excerpt" varchar(300),
+    "featured_image_url" varchar(500),
+    "canonical_url" varchar(500),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_revision_post" ON "post_revisions" ("post_id","created_at");
+
+CREATE TABLE "comments" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "content" text NOT NULL,
+    "post_id" uuid NOT NULL,
+    "author_id" uuid NOT NULL,
+    "parent_comment_id" uuid,
+    "depth" bigint DEFAULT 0,
+    "upvotes_count" bigint DEFAULT 0,
+    "downvotes_count" bigint DEFAULT 0,
+    "edited" boolean DEFAULT false,
+    "pinned" boolean DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_posts_comments" FOREIGN KEY ("post_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_comments_author" FOREIGN KEY ("author_id") REFERENCES "users"("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "fk_comments_replies" FOREIGN KEY ("parent_comment_id") REFERENCES "comments"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comments_depth" ON "comments" ("depth");
+CREATE INDEX IF NOT EXISTS "idx_comment_parent" ON "comments" ("parent_comment_id");
+CREATE INDEX IF NOT EXISTS "idx_comment_author" ON "comments" ("author_id");
+CREATE INDEX IF NOT EXISTS "idx_comment_post" ON "comments" ("post_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_deleted_at" ON "comments" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_comments_pinned" ON "comments" ("pinned");
+CREATE INDEX IF NOT EXISTS "idx_comments_edited" ON "comments" ("edited");
+
+CREATE TABLE "comment_mentions" (
+    "comment_id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("comment_id","user_id"),
+    CONSTRAINT "fk_comment_mentions_comment" FOREIGN KEY ("comment_id") REFERENCES "comments"("id"),
+    CONSTRAINT "fk_comment_mentions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE "collections" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "description" varchar(200),
+    "is_private" boolean DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_collections_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_collections_deleted_at" ON "collections" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_collections_is_private" ON "collections" ("is_private");
+CREATE INDEX IF NOT EXISTS "idx_collection_user" ON "collections" ("user_id");
+
+CREATE TABLE "bookmarks" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "post_id" uuid NOT NULL,
+    "collection_id" uuid,
+    "notes" text,
+    "is_private" boolean DEFAULT false,
+    "tags" text[],
+    "archived_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_collections_bookmarks" FOREIGN KEY ("collection_id") REFERENCES "collections"("id"),
+    CONSTRAINT "fk_posts_bookmarks" FOREIGN KEY ("post_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_bookmarks_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_bookmark_user" ON "bookmarks" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_deleted_at" ON "bookmarks" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_archived_at" ON "bookmarks" ("archived_at");
+CREATE INDEX IF NOT EXISTS "idx_bookmark_tags" ON "bookmarks" ("tags");
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_is_private" ON "bookmarks" ("is_private");
+CREATE INDEX IF NOT EXISTS "idx_bookmark_collection" ON "bookmarks" ("collection_id");
+CREATE INDEX IF NOT EXISTS "idx_bookmark_post" ON "bookmarks" ("post_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_bookmark_user_post" ON "bookmarks" ("user_id","post_id");
+
+CREATE TABLE "mentions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "source_type" varchar(20) NOT NULL,
+    "source_id" uuid NOT NULL,
+    "post_id" uuid NOT NULL,
+    "author_id" uuid NOT NULL,
+    "excerpt" varchar(200),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_mentions_post_id" ON "mentions" ("post_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_mention_source_user" ON "mentions" ("user_id","source_type","source_id");
+CREATE INDEX IF NOT EXISTS "idx_mention_user" ON "mentions" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_mentions_created_at" ON "mentions" ("created_at");
+
+CREATE TABLE "data_exports" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "archive" bytea,
+    "size" bigint DEFAULT 0,
+    "error" varchar(255),
+    "completed_at" timestamptz,
+    "expires_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_data_exports_status" ON "data_exports" ("status");
+CREATE INDEX IF NOT EXISTS "idx_data_exports_user_id" ON "data_exports" ("user_id");
+
+CREATE TABLE "organization_members" (
+    "organization_id" uuid,
+    "user_id" uuid,
+    "role" varchar(20) NOT NULL DEFAULT 'member',
+    "status" varchar(20) NOT NULL DEFAULT 'invited',
+    "invited_by_id" uuid,
+    "created_at" timestamptz,
+    "joined_at" timestamptz,
+    PRIMARY KEY ("organization_id","user_id"),
+    CONSTRAINT "fk_organization_members_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_organization_members_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_organization_members_status" ON "organization_members" ("status");
+CREATE INDEX IF NOT EXISTS "idx_organization_members_user_id" ON "organization_members" ("user_id");
+
+CREATE TABLE "search_documents" (
+    "kind" varchar(10),
+    "entity_id" uuid,
+    "title" varchar(255) NOT NULL,
+    "summary" text,
+    "slug" varchar(255) NOT NULL,
+    "vector" tsvector,
+    "indexed_at" timestamptz NOT NULL,
+    PRIMARY KEY ("kind","entity_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_search_vector" ON "search_documents" USING gin("vector");
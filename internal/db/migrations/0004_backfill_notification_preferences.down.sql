@@ -0,0 +1 @@
+-- The backfilled rows cannot be told apart from preferences users saved since, so they are kept.
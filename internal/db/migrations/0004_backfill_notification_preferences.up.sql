@@ -0,0 +1,6 @@
+-- Accounts created before notification preferences existed have no row, so updating their
+-- preferences answers 404. They get the defaults new accounts start with.
+INSERT INTO notification_preferences (user_id, email_on_followers, email_on_mentions, email_on_unread, created_at, updated_at)
+SELECT u.id, TRUE, TRUE, TRUE, NOW(), NOW()
+FROM users u
+WHERE NOT EXISTS (SELECT 1 FROM notification_preferences np WHERE np.user_id = u.id);
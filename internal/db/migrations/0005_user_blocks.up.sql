@@ -0,0 +1,12 @@
+-- Users blocking and muting each other. A user may both block and mute someone, so the kind is
+-- part of the key.
+CREATE TABLE "user_blocks" (
+    "user_id" uuid NOT NULL,
+    "target_id" uuid NOT NULL,
+    "kind" varchar(10) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("user_id","target_id","kind"),
+    CONSTRAINT "fk_user_blocks_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_user_blocks_target" FOREIGN KEY ("target_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_user_blocks_target_id" ON "user_blocks" ("target_id");
@@ -0,0 +1,15 @@
+-- Rate limit overrides set by admins. An override without a role applies to everyone, so the
+-- uniqueness of a policy's overrides counts a missing role as a role of its own.
+CREATE TABLE "rate_limit_overrides" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "policy" varchar(50) NOT NULL,
+    "role_id" uuid,
+    "limit" bigint NOT NULL,
+    "window_seconds" bigint NOT NULL DEFAULT 0,
+    "updated_by_id" uuid NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_rate_limit_overrides_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_rate_limit_override" ON "rate_limit_overrides" ("policy", COALESCE("role_id", '00000000-0000-0000-0000-000000000000'));
@@ -0,0 +1,20 @@
+-- Sign-in attempts on accounts, for the login history users see and the alerts they get.
+CREATE TABLE "login_events" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "success" boolean NOT NULL,
+    "method" varchar(30) NOT NULL,
+    "two_factor" boolean NOT NULL DEFAULT false,
+    "reason" varchar(30),
+    "ip" varchar(45),
+    "user_agent" varchar(255),
+    "country" varchar(100),
+    "region" varchar(100),
+    "city" varchar(100),
+    "new_location" boolean NOT NULL DEFAULT false,
+    "new_device" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_login_events_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_login_event_user" ON "login_events" ("user_id","created_at");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "comment_flags";
+DROP TABLE IF EXISTS "platform_stats";
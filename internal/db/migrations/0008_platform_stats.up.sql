@@ -0,0 +1,32 @@
+-- Daily platform statistics aggregated for the admin dashboard.
+CREATE TABLE "platform_stats" (
+    "day" date,
+    "signups" bigint NOT NULL DEFAULT 0,
+    "active_users" bigint NOT NULL DEFAULT 0,
+    "weekly_active_users" bigint NOT NULL DEFAULT 0,
+    "posts" bigint NOT NULL DEFAULT 0,
+    "comments" bigint NOT NULL DEFAULT 0,
+    "pending_reports" bigint NOT NULL DEFAULT 0,
+    "top_tags" jsonb NOT NULL DEFAULT '[]',
+    "computed_at" timestamptz NOT NULL,
+    PRIMARY KEY ("day")
+);
+
+-- Reports of comments. The model was declared without its table ever being created; the pending
+-- reports of the statistics count the unresolved ones.
+CREATE TABLE IF NOT EXISTS "comment_flags" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "comment_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "reason" varchar(100) NOT NULL,
+    "notes" varchar(500),
+    "resolved" boolean DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_comments_flags" FOREIGN KEY ("comment_id") REFERENCES "comments"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "fk_comment_flags_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_flag_comment" ON "comment_flags" ("comment_id");
+CREATE INDEX IF NOT EXISTS "idx_flag_user" ON "comment_flags" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_comment_flags_resolved" ON "comment_flags" ("resolved");
@@ -0,0 +1,16 @@
+-- Feature flags. Users and roles hold the IDs of the users and roles a flag is turned on for
+-- regardless of its rollout.
+CREATE TABLE "feature_flags" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "key" varchar(50) NOT NULL,
+    "description" varchar(255),
+    "enabled" boolean NOT NULL DEFAULT false,
+    "rollout" bigint NOT NULL DEFAULT 0,
+    "users" text[],
+    "roles" text[],
+    "updated_by_id" uuid NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_feature_flags_key" ON "feature_flags" ("key");
@@ -0,0 +1,11 @@
+-- Earlier versions of edited comments, and when a comment was last edited.
+ALTER TABLE "comments" ADD COLUMN IF NOT EXISTS "edited_at" timestamptz;
+CREATE TABLE "comment_revisions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "comment_id" uuid NOT NULL,
+    "editor_id" uuid NOT NULL,
+    "content" text NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comment_revision_comment" ON "comment_revisions" ("comment_id", "created_at");
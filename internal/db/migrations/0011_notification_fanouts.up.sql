@@ -0,0 +1,9 @@
+-- Progress of notifying an author's followers of a newly published post.
+CREATE TABLE "notification_fanouts" (
+    "post_id" uuid,
+    "cursor" uuid NOT NULL,
+    "notified" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("post_id")
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_notification_created";
+DROP TABLE IF EXISTS "notification_archives";
@@ -0,0 +1,15 @@
+-- Notifications archived by their users or by the retention policy, and the index the policy
+-- finds expired notifications with.
+CREATE TABLE "notification_archives" (
+    "id" uuid,
+    "user_id" uuid NOT NULL,
+    "type" varchar(50) NOT NULL,
+    "message" varchar(255) NOT NULL,
+    "is_read" boolean DEFAULT false,
+    "created_at" timestamptz,
+    "archived_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notification_archive_user_created" ON "notification_archives" ("user_id", "created_at");
+CREATE INDEX IF NOT EXISTS "idx_notification_archive_created" ON "notification_archives" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_notification_created" ON "notifications" ("created_at");
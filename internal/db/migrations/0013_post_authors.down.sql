@@ -0,0 +1,14 @@
+CREATE TABLE "post_co_authors" (
+    "posts_id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("posts_id","user_id"),
+    CONSTRAINT "fk_post_co_authors_posts" FOREIGN KEY ("posts_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_post_co_authors_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+INSERT INTO "post_co_authors" ("posts_id", "user_id")
+SELECT "post_id", "user_id" FROM "post_authors" WHERE "status" = 'accepted';
+
+DROP TABLE IF EXISTS "post_authors";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "guest_author_url";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "guest_author_name";
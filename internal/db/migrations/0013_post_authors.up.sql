@@ -0,0 +1,24 @@
+-- Co-authors of posts, invited by the author and shown once they accept, and the credit of guest
+-- writers without an account. Co-authors recorded before invitations existed are kept as accepted.
+ALTER TABLE "posts" ADD COLUMN IF NOT EXISTS "guest_author_name" varchar(100);
+ALTER TABLE "posts" ADD COLUMN IF NOT EXISTS "guest_author_url" varchar(500);
+
+CREATE TABLE "post_authors" (
+    "post_id" uuid,
+    "user_id" uuid,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "invited_by_id" uuid NOT NULL,
+    "created_at" timestamptz,
+    "accepted_at" timestamptz,
+    PRIMARY KEY ("post_id", "user_id"),
+    CONSTRAINT "fk_posts_co_authors" FOREIGN KEY ("post_id") REFERENCES "posts"("id"),
+    CONSTRAINT "fk_post_authors_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_authors_user" ON "post_authors" ("user_id");
+
+INSERT INTO "post_authors" ("post_id", "user_id", "status", "invited_by_id", "created_at", "accepted_at")
+SELECT pca."posts_id", pca."user_id", 'accepted', p."author_id", now(), now()
+FROM "post_co_authors" pca JOIN "posts" p ON p."id" = pca."posts_id"
+WHERE pca."user_id" <> p."author_id";
+
+DROP TABLE IF EXISTS "post_co_authors";
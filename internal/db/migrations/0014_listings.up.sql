@@ -0,0 +1,22 @@
+-- Classifieds on the listings board: events, job postings and calls for collaborators.
+CREATE TABLE "listings" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "author_id" uuid NOT NULL,
+    "category" varchar(20) NOT NULL,
+    "title" varchar(128) NOT NULL,
+    "body" text NOT NULL,
+    "tags" text[],
+    "location" varchar(100),
+    "contact_url" varchar(500),
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_listings_author" FOREIGN KEY ("author_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_listings_deleted_at" ON "listings" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_listing_author" ON "listings" ("author_id");
+CREATE INDEX IF NOT EXISTS "idx_listing_category_expires" ON "listings" ("category", "expires_at");
+CREATE INDEX IF NOT EXISTS "idx_listing_expires" ON "listings" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_listing_tags" ON "listings" USING gin ("tags");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "user_streaks";
+DROP TABLE IF EXISTS "user_activities";
@@ -0,0 +1,23 @@
+-- Daily activity rolled up from Redis for the activity heatmap, and every user's streak of active
+-- days.
+CREATE TABLE "user_activities" (
+    "user_id" uuid,
+    "day" date,
+    "reads" bigint NOT NULL DEFAULT 0,
+    "posts" bigint NOT NULL DEFAULT 0,
+    "comments" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("user_id","day"),
+    CONSTRAINT "fk_user_activities_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_user_activity_day" ON "user_activities" ("day");
+
+CREATE TABLE "user_streaks" (
+    "user_id" uuid,
+    "current" bigint NOT NULL DEFAULT 0,
+    "longest" bigint NOT NULL DEFAULT 0,
+    "last_active_on" date,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("user_id"),
+    CONSTRAINT "fk_user_streaks_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
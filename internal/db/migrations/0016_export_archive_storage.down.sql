@@ -0,0 +1,3 @@
+UPDATE "data_exports" SET "expires_at" = NOW() WHERE "status" = 'ready' AND "expires_at" > NOW();
+ALTER TABLE "data_exports" ADD COLUMN "archive" bytea;
+ALTER TABLE "data_exports" DROP COLUMN "archive_key";
@@ -0,0 +1,5 @@
+-- Export archives move from the database to upload storage. Archives still held in the table are
+-- expired rather than copied out, so their owners can request fresh ones straight away.
+ALTER TABLE "data_exports" ADD COLUMN "archive_key" varchar(255);
+UPDATE "data_exports" SET "expires_at" = NOW() WHERE "status" = 'ready' AND "expires_at" > NOW();
+ALTER TABLE "data_exports" DROP COLUMN "archive";
@@ -0,0 +1,5 @@
+DELETE FROM "role_permissions" WHERE "permission_id" IN (
+    SELECT "id" FROM "permissions"
+    WHERE "name" IN ('manage_account', 'block_user', 'bookmark_post', 'upload_media', 'create_webhook')
+);
+DELETE FROM "permissions" WHERE "name" IN ('manage_account', 'block_user', 'bookmark_post', 'upload_media', 'create_webhook');
@@ -0,0 +1,13 @@
+-- Account, blocking, bookmarking, upload and webhook routes used to let every signed in user
+-- through; they now check permissions of their own. Every existing role is granted them, custom
+-- roles included, so nobody loses access. The declared roles get them from the role policy too.
+INSERT INTO "permissions" ("name", "created_at", "updated_at")
+SELECT "name", NOW(), NOW()
+FROM unnest(ARRAY['manage_account', 'block_user', 'bookmark_post', 'upload_media', 'create_webhook']) AS "name"
+ON CONFLICT ("name") DO NOTHING;
+
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT "roles"."id", "permissions"."id"
+FROM "roles" CROSS JOIN "permissions"
+WHERE "permissions"."name" IN ('manage_account', 'block_user', 'bookmark_post', 'upload_media', 'create_webhook')
+ON CONFLICT DO NOTHING;
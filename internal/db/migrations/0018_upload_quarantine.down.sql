@@ -0,0 +1,4 @@
+-- Quarantined and rejected uploads cannot be told apart from clean ones once the status is gone.
+DELETE FROM "uploads" WHERE "status" <> 'clean';
+ALTER TABLE "uploads" DROP COLUMN "scan_reason";
+ALTER TABLE "uploads" DROP COLUMN "status";
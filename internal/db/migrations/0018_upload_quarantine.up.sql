@@ -0,0 +1,3 @@
+-- Uploads scanned in the background are quarantined until the content scanner clears them.
+ALTER TABLE "uploads" ADD COLUMN "status" varchar(20) NOT NULL DEFAULT 'clean';
+ALTER TABLE "uploads" ADD COLUMN "scan_reason" varchar(255);
@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/grpc/pb"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// userMessage is the part of a user record shared with other services.
+func userMessage(u *models.User) *pb.User {
+	return &pb.User{
+		Id:              u.ID.String(),
+		Username:        u.Username,
+		Email:           u.Email,
+		Name:            u.Profile.Name,
+		AvatarUrl:       u.Profile.AvatarURL,
+		RoleId:          u.RoleID.String(),
+		Role:            u.Role.Name,
+		Status:          u.Status,
+		Restricted:      u.IsRestricted(),
+		IsActive:        u.IsActive,
+		IsEmailVerified: u.IsEmailVerified,
+		CreatedAt:       timestamppb.New(u.CreatedAt),
+	}
+}
+
+// userByID loads a user from the cache shared with the HTTP API, falling back to the database.
+func (s *server) userByID(ctx context.Context, raw string) (*models.User, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid user ID")
+	}
+	user, err := s.opt.Services.Users.Get(ctx, id)
+	if err != nil {
+		return nil, s.fail(ctx, err, "Failed to fetch user")
+	}
+	return user, nil
+}
+
+// GetUser returns a user by ID or username.
+func (s *server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	switch lookup := req.GetLookup().(type) {
+	case *pb.GetUserRequest_Id:
+		user, err := s.userByID(ctx, lookup.Id)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.GetUserResponse{User: userMessage(user)}, nil
+	case *pb.GetUserRequest_Username:
+		if lookup.Username == "" {
+			return nil, status.Error(codes.InvalidArgument, "Username is required")
+		}
+		user, err := s.opt.Services.Users.GetByUsername(ctx, lookup.Username)
+		if err != nil {
+			return nil, s.fail(ctx, err, "Failed to fetch user")
+		}
+		return &pb.GetUserResponse{User: userMessage(user)}, nil
+	}
+	return nil, status.Error(codes.InvalidArgument, "Either id or username is required")
+}
+
+// CheckPermission reports whether a user's role grants any of the requested permissions. Banned
+// and suspended users are granted nothing, as the HTTP API refuses them before any check.
+func (s *server) CheckPermission(ctx context.Context, req *pb.CheckPermissionRequest) (*pb.CheckPermissionResponse, error) {
+	if len(req.GetPermissions()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "At least one permission is required")
+	}
+	user, err := s.userByID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, snapshot, err := s.opt.Services.Roles.Check(ctx, user.RoleID, req.GetPermissions()...)
+	if err != nil {
+		return nil, s.fail(ctx, err, "Failed to get permissions")
+	}
+	return &pb.CheckPermissionResponse{
+		Allowed:     allowed && !user.IsRestricted(),
+		RoleId:      snapshot.RoleID.String(),
+		RoleVersion: int32(snapshot.Version),
+	}, nil
+}
+
+// ValidateToken checks an access token as the HTTP API does. Expired tokens are not refreshed; the
+// calling service forwards the client's refresh to the HTTP API instead.
+func (s *server) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	claims, user, err := s.opt.Services.Auth.ValidateToken(ctx, req.GetAccessToken())
+	if err != nil {
+		return nil, s.fail(ctx, err, "Failed to validate access token")
+	}
+
+	res := &pb.ValidateTokenResponse{User: userMessage(user), ImpersonatorId: claims.Impersonator}
+	if claims.ExpiresAt != nil {
+		res.ExpiresAt = timestamppb.New(claims.ExpiresAt.Time)
+	}
+	return res, nil
+}
@@ -0,0 +1,151 @@
+// Package grpc serves the internal gRPC API other DevPulse services use for user, permission and
+// token lookups. It runs on the same services, and so the same Redis caches, as the HTTP API, so
+// both agree on who a user is and what their role grants.
+package grpc
+
+//go:generate protoc -I proto --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative internal.proto
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mnuddindev/devpulse/internal/grpc/pb"
+	"github.com/mnuddindev/devpulse/internal/services"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// Options configures the internal gRPC server.
+type Options struct {
+	Services *services.Services
+	Logger   *logger.Logger
+	// CertFile and KeyFile are the server's PEM certificate and key. Without them the server
+	// speaks plaintext, which only suits a private network.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is the PEM bundle client certificates must be signed by. Setting it turns on
+	// mutual TLS: callers without such a certificate are refused during the handshake.
+	ClientCAFile string
+}
+
+// server implements pb.InternalServer.
+type server struct {
+	pb.UnimplementedInternalServer
+	opt Options
+}
+
+// NewServer returns a gRPC server exposing the internal API, secured as opt asks.
+func NewServer(opt Options) (*gogrpc.Server, error) {
+	serverOpts := []gogrpc.ServerOption{gogrpc.ChainUnaryInterceptor(logCalls(opt.Logger))}
+	if opt.CertFile != "" || opt.KeyFile != "" || opt.ClientCAFile != "" {
+		tlsCfg, err := tlsConfig(opt)
+		if err != nil {
+			return nil, err
+		}
+		serverOpts = append(serverOpts, gogrpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	srv := gogrpc.NewServer(serverOpts...)
+	pb.RegisterInternalServer(srv, &server{opt: opt})
+	return srv, nil
+}
+
+// Serve accepts connections on addr until ctx is cancelled, then lets in-flight calls finish.
+func Serve(ctx context.Context, srv *gogrpc.Server, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", addr, err)
+	}
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+	if err := srv.Serve(lis); err != nil && !errors.Is(err, gogrpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// tlsConfig loads the server certificate and, when a client CA is configured, requires and
+// verifies client certificates against it.
+func tlsConfig(opt Options) (*tls.Config, error) {
+	if opt.CertFile == "" || opt.KeyFile == "" {
+		return nil, errors.New("gRPC TLS needs both a certificate and a key")
+	}
+	cert, err := tls.LoadX509KeyPair(opt.CertFile, opt.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gRPC certificate: %v", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if opt.ClientCAFile != "" {
+		pem, err := os.ReadFile(opt.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gRPC client CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("gRPC client CA holds no PEM certificate")
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// logCalls logs failed calls and recovers from panics, so one bad call cannot take the HTTP API
+// down with it.
+func logCalls(log *logger.Logger) gogrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (res interface{}, err error) {
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error(ctx).WithFields("method", info.FullMethod, "panic", r).Logs("gRPC call panicked")
+				err = status.Error(codes.Internal, "Internal server error")
+			}
+		}()
+
+		res, err = handler(ctx, req)
+		if err != nil {
+			log.Warn(ctx).WithFields("method", info.FullMethod, "code", status.Code(err), "error", err, "duration", time.Since(start)).Logs("gRPC call failed")
+		}
+		return res, err
+	}
+}
+
+// fail turns a model error into a gRPC status. Server errors are logged with their cause and
+// reported to the caller as fallback only.
+func (s *server) fail(ctx context.Context, err error, fallback string) error {
+	var appErr *utils.CustomError
+	if utils.As(err, &appErr) {
+		switch appErr.Code {
+		case http.StatusBadRequest:
+			return status.Error(codes.InvalidArgument, appErr.Message)
+		case http.StatusUnauthorized:
+			return status.Error(codes.Unauthenticated, appErr.Message)
+		case http.StatusForbidden:
+			return status.Error(codes.PermissionDenied, appErr.Message)
+		case http.StatusNotFound:
+			return status.Error(codes.NotFound, appErr.Message)
+		case http.StatusConflict:
+			return status.Error(codes.FailedPrecondition, appErr.Message)
+		case http.StatusServiceUnavailable:
+			return status.Error(codes.Unavailable, appErr.Message)
+		}
+	}
+	s.opt.Logger.Error(ctx).WithFields("error", err).Logs(fallback)
+	return status.Error(codes.Internal, fallback)
+}
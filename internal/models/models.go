@@ -13,48 +13,356 @@ func RegisterModels() []interface{} {
 		//&user.Badge{},
 		&user.Notification{},
 		&user.NotificationPreferences{},
+		&user.NotificationArchive{},
+		&user.SecurityEvent{},
+		&user.LoginEvent{},
+		&user.Identity{},
+		&user.ModerationAction{},
+		&user.AuditLog{},
+		&user.Webhook{},
+		&user.WebhookDelivery{},
+		&user.OutboxEvent{},
+		&user.APIKey{},
+		&user.Invitation{},
+		&user.InvitationRedemption{},
+		&user.BlockedTerm{},
+		&user.Upload{},
+		&user.PushSubscription{},
+		&user.UserBlock{},
+		&user.RateLimitOverride{},
+		&user.FeatureFlag{},
+		&user.UserActivity{},
+		&user.UserStreak{},
+		&posts.Tag{},
+		&posts.TagAnalytics{},
+		&posts.TagFollower{},
+		&posts.TagModerator{},
+		&posts.Series{},
+		&posts.Posts{},
+		&posts.SeriesPost{},
+		&posts.PostAnalytics{},
+		&posts.PostRevision{},
+		&posts.CommentRevision{},
+		&posts.Comment{},
+		&posts.CommentFlag{},
+		&posts.Collection{},
+		&posts.Bookmark{},
+		&posts.Mention{},
+		&posts.DataExport{},
+		&posts.Organization{},
+		&posts.OrganizationMember{},
+		&posts.SearchDocument{},
+		&posts.PlatformStats{},
+		&posts.NotificationFanout{},
+		&posts.PostAuthor{},
+		&posts.Listing{},
 	}
 }
 
 type (
-	User                    = user.User
-	UpdateUserRequest       = user.UpdateUserRequest
-	Role                    = user.Role
-	Permission              = user.Permission
-	Badge                   = user.Badge
-	Notification            = user.Notification
-	NotificationPreferences = user.NotificationPreferences
-	UserOption              = user.UserOption
-
-	Posts            = posts.Posts
-	PostAnalytics    = posts.PostAnalytics
-	Series           = posts.Series
-	SeriesPost       = posts.SeriesPost
-	SeriesAnalytics  = posts.SeriesAnalytics
-	Bookmark         = posts.Bookmark
-	Collection       = posts.Collection
-	Tag              = posts.Tag
-	TagFollower      = posts.TagFollower
-	TagAnalytics     = posts.TagAnalytics
-	TagModerator     = posts.TagModerator
-	Reaction         = posts.Reaction
-	ReadingListEntry = posts.ReadingListEntry
-	Comment          = posts.Comment
-	CommentFlag      = posts.CommentFlag
-	CommentMention   = posts.CommentMention
+	User                      = user.User
+	UpdateUserRequest         = user.UpdateUserRequest
+	UserSummary               = user.UserSummary
+	UserPreload               = user.UserPreload
+	UserBlock                 = user.UserBlock
+	RateLimitOverride         = user.RateLimitOverride
+	FeatureFlag               = user.FeatureFlag
+	FeatureFlagUpdate         = user.FeatureFlagUpdate
+	BlockedUser               = user.BlockedUser
+	Role                      = user.Role
+	PermissionPreview         = user.PermissionPreview
+	PermissionSnapshot        = user.PermissionSnapshot
+	Permission                = user.Permission
+	RoleSpec                  = user.RoleSpec
+	RoleTemplate              = user.RoleTemplate
+	ResolvedRoleTemplate      = user.ResolvedRoleTemplate
+	RoleDrift                 = user.RoleDrift
+	RolePolicyDiff            = user.RolePolicyDiff
+	Badge                     = user.Badge
+	Notification              = user.Notification
+	NotificationSummary       = user.NotificationSummary
+	NotificationPreferences   = user.NotificationPreferences
+	NotificationMatrix        = user.NotificationMatrix
+	UserOption                = user.UserOption
+	SecurityEvent             = user.SecurityEvent
+	LoginEvent                = user.LoginEvent
+	LoginAnomalyEvent         = user.LoginAnomalyEvent
+	UserActivity              = user.UserActivity
+	UserStreak                = user.UserStreak
+	Streak                    = user.Streak
+	ActivityDay               = user.ActivityDay
+	ActivityHeatmap           = user.ActivityHeatmap
+	StreakMilestoneEvent      = user.StreakMilestoneEvent
+	UploadScanEvent           = user.UploadScanEvent
+	SecuritySummary           = user.SecuritySummary
+	SessionPolicy             = user.SessionPolicy
+	OAuthProfile              = user.OAuthProfile
+	Identity                  = user.Identity
+	ModerationAction          = user.ModerationAction
+	UserFilter                = user.UserFilter
+	AdminUser                 = user.AdminUser
+	AuditLog                  = user.AuditLog
+	AuditFilter               = user.AuditFilter
+	Webhook                   = user.Webhook
+	WebhookDelivery           = user.WebhookDelivery
+	WebhookOption             = user.WebhookOption
+	WebhookSender             = user.WebhookSender
+	OutboxEvent               = user.OutboxEvent
+	OutboxHandler             = user.OutboxHandler
+	UserRegisteredEvent       = user.UserRegisteredEvent
+	UserChangedEvent          = user.UserChangedEvent
+	PostChangedEvent          = user.PostChangedEvent
+	PostFanoutEvent           = user.PostFanoutEvent
+	NotificationInput         = user.NotificationInput
+	NotificationBatch         = user.NotificationBatch
+	NotificationRetention     = user.NotificationRetention
+	NotificationArchive       = user.NotificationArchive
+	NotificationArchiveFilter = user.NotificationArchiveFilter
+	PushNotificationEvent     = user.PushNotificationEvent
+	PushSubscription          = user.PushSubscription
+	APIKey                    = user.APIKey
+	Invitation                = user.Invitation
+	BlockedTerm               = user.BlockedTerm
+	Upload                    = user.Upload
+
+	Posts               = posts.Posts
+	PostPolicy          = posts.PostPolicy
+	PostsOption         = posts.PostsOption
+	TagOption           = posts.TagOption
+	FeedEntry           = posts.FeedEntry
+	FollowSuggestion    = posts.FollowSuggestion
+	MentionSuggestion   = posts.MentionSuggestion
+	PostAnalytics       = posts.PostAnalytics
+	PostRevision        = posts.PostRevision
+	PostAutosave        = posts.PostAutosave
+	CommentRevision     = posts.CommentRevision
+	PostAuthor          = posts.PostAuthor
+	Listing             = posts.Listing
+	ListingOption       = posts.ListingOption
+	PostHistoryEntry    = posts.PostHistoryEntry
+	CommentHistoryEntry = posts.CommentHistoryEntry
+	SitemapEntry        = posts.SitemapEntry
+	SearchDocument      = posts.SearchDocument
+	SearchResult        = posts.SearchResult
+	ReindexProgress     = posts.ReindexProgress
+	Series              = posts.Series
+	SeriesPost          = posts.SeriesPost
+	SeriesAnalytics     = posts.SeriesAnalytics
+	SeriesOption        = posts.SeriesOption
+	SeriesPart          = posts.SeriesPart
+	PublishedSeries     = posts.PublishedSeries
+	SeriesNavigation    = posts.SeriesNavigation
+	Bookmark            = posts.Bookmark
+	Collection          = posts.Collection
+	Tag                 = posts.Tag
+	TagFollower         = posts.TagFollower
+	TagAnalytics        = posts.TagAnalytics
+	TagModerator        = posts.TagModerator
+	Reaction            = posts.Reaction
+	ReadingListEntry    = posts.ReadingListEntry
+	Comment             = posts.Comment
+	CommentFlag         = posts.CommentFlag
+	CommentMention      = posts.CommentMention
+	Mention             = posts.Mention
+	MentionEmailer      = posts.MentionEmailer
+	Digest              = posts.Digest
+	DigestSender        = posts.DigestSender
+	DataExport          = posts.DataExport
+	ExportNotifier      = posts.ExportNotifier
+	PlatformStats       = posts.PlatformStats
+	TagUsage            = posts.TagUsage
+
+	Organization              = posts.Organization
+	OrganizationOption        = posts.OrganizationOption
+	OrganizationMember        = posts.OrganizationMember
+	OrganizationMemberSummary = posts.OrganizationMemberSummary
+	UserOrganization          = posts.UserOrganization
+)
+
+const (
+	EventLogin           = user.EventLogin
+	EventLoginFailed     = user.EventLoginFailed
+	EventLogout          = user.EventLogout
+	EventPasswordReset   = user.EventPasswordReset
+	EventSessionsRevoked = user.EventSessionsRevoked
+	EventRefreshReuse    = user.EventRefreshReuse
+	EventLoginAdded      = user.EventLoginAdded
+	EventLoginRemoved    = user.EventLoginRemoved
+	ProviderPassword     = user.ProviderPassword
+
+	PreloadFull    = user.PreloadFull
+	PreloadMinimal = user.PreloadMinimal
+	PreloadAuth    = user.PreloadAuth
+	PreloadPublic  = user.PreloadPublic
+
+	BlockKindBlock = user.BlockKindBlock
+	BlockKindMute  = user.BlockKindMute
+
+	UserStatusActive      = user.UserStatusActive
+	UserStatusSuspended   = user.UserStatusSuspended
+	UserStatusBanned      = user.UserStatusBanned
+	ModerationSuspend     = user.ModerationSuspend
+	ModerationBan         = user.ModerationBan
+	ModerationReinstate   = user.ModerationReinstate
+	ModerationForceLogout = user.ModerationForceLogout
+
+	DigestDaily  = user.DigestDaily
+	DigestWeekly = user.DigestWeekly
+
+	AuditRoleCreate            = user.AuditRoleCreate
+	AuditRoleUpdate            = user.AuditRoleUpdate
+	AuditRolePermissionsGrant  = user.AuditRolePermissionsGrant
+	AuditRolePermissionsRevoke = user.AuditRolePermissionsRevoke
+	AuditUserRoleAssign        = user.AuditUserRoleAssign
+	AuditUserDelete            = user.AuditUserDelete
+	AuditPasswordChange        = user.AuditPasswordChange
+	AuditPasswordReset         = user.AuditPasswordReset
+	AuditPasswordSet           = user.AuditPasswordSet
+	AuditPasswordRemove        = user.AuditPasswordRemove
+	AuditIdentityLink          = user.AuditIdentityLink
+	AuditIdentityUnlink        = user.AuditIdentityUnlink
+	AuditUserImpersonate       = user.AuditUserImpersonate
+	AuditImpersonatedRequest   = user.AuditImpersonatedRequest
+	AuditBlocklistAdd          = user.AuditBlocklistAdd
+	AuditBlocklistRemove       = user.AuditBlocklistRemove
+	AuditRateLimitSet          = user.AuditRateLimitSet
+	AuditRateLimitRemove       = user.AuditRateLimitRemove
+	AuditFeatureFlagCreate     = user.AuditFeatureFlagCreate
+	AuditFeatureFlagUpdate     = user.AuditFeatureFlagUpdate
+	AuditFeatureFlagDelete     = user.AuditFeatureFlagDelete
+	AuditTargetUser            = user.AuditTargetUser
+	AuditTargetRole            = user.AuditTargetRole
+	AuditTargetBlockedTerm     = user.AuditTargetBlockedTerm
+	AuditTargetRateLimit       = user.AuditTargetRateLimit
+	AuditTargetFeatureFlag     = user.AuditTargetFeatureFlag
+
+	EventTwoFactorEnabled  = user.EventTwoFactorEnabled
+	EventTwoFactorDisabled = user.EventTwoFactorDisabled
+
+	WebhookPostPublished        = user.WebhookPostPublished
+	WebhookCommentCreated       = user.WebhookCommentCreated
+	WebhookUserFollowed         = user.WebhookUserFollowed
+	WebhookDeliveryPending      = user.WebhookDeliveryPending
+	WebhookDeliverySucceeded    = user.WebhookDeliverySucceeded
+	WebhookDeliveryFailed       = user.WebhookDeliveryFailed
+	MaxWebhooksPerUser          = user.MaxWebhooksPerUser
+	OutboxUserRegistered        = user.OutboxUserRegistered
+	OutboxUserUpdated           = user.OutboxUserUpdated
+	OutboxPostPublished         = user.OutboxPostPublished
+	OutboxPostUpdated           = user.OutboxPostUpdated
+	OutboxPostDeleted           = user.OutboxPostDeleted
+	OutboxPostFanout            = user.OutboxPostFanout
+	OutboxNotificationPush      = user.OutboxNotificationPush
+	OutboxLoginAnomaly          = user.OutboxLoginAnomaly
+	OutboxStreakMilestone       = user.OutboxStreakMilestone
+	OutboxUploadScan            = user.OutboxUploadScan
+	UploadClean                 = user.UploadClean
+	UploadQuarantined           = user.UploadQuarantined
+	UploadRejected              = user.UploadRejected
+	QuarantineKeyPrefix         = user.QuarantineKeyPrefix
+	NotificationReaction        = user.NotificationReaction
+	NotificationComment         = user.NotificationComment
+	NotificationMention         = user.NotificationMention
+	NotificationNewPost         = user.NotificationNewPost
+	NotificationCoAuthor        = user.NotificationCoAuthor
+	NotificationBadge           = user.NotificationBadge
+	ActivityRead                = user.ActivityRead
+	ActivityPost                = user.ActivityPost
+	ActivityComment             = user.ActivityComment
+	CoAuthorPending             = posts.CoAuthorPending
+	CoAuthorAccepted            = posts.CoAuthorAccepted
+	MaxCoAuthors                = posts.MaxCoAuthors
+	ListingEvents               = posts.ListingEvents
+	ListingJobs                 = posts.ListingJobs
+	ListingCollabs              = posts.ListingCollabs
+	ListingLifetime             = posts.ListingLifetime
+	MaxActiveListings           = posts.MaxActiveListings
+	ChannelEmail                = user.ChannelEmail
+	ChannelPush                 = user.ChannelPush
+	ChannelInApp                = user.ChannelInApp
+	EventReactions              = user.EventReactions
+	EventComments               = user.EventComments
+	EventMentions               = user.EventMentions
+	EventFollowers              = user.EventFollowers
+	EventBadges                 = user.EventBadges
+	EventNewPosts               = user.EventNewPosts
+	EventUnread                 = user.EventUnread
+	MaxPushSubscriptionsPerUser = user.MaxPushSubscriptionsPerUser
+	OutboxPending               = user.OutboxPending
+	OutboxProcessed             = user.OutboxProcessed
+	OutboxFailed                = user.OutboxFailed
+
+	EventAPIKeyCreated = user.EventAPIKeyCreated
+	EventAPIKeyRevoked = user.EventAPIKeyRevoked
+	ScopeReadProfile   = user.ScopeReadProfile
+	RoleMember         = user.RoleMember
+	RoleTrusted        = user.RoleTrusted
+	RoleModerator      = user.RoleModerator
+	RoleAdmin          = user.RoleAdmin
+	ScopeWritePosts    = user.ScopeWritePosts
+	APIKeyPrefix       = user.APIKeyPrefix
+	MaxAPIKeysPerUser  = user.MaxAPIKeysPerUser
+
+	RegistrationOpen       = user.RegistrationOpen
+	RegistrationInviteOnly = user.RegistrationInviteOnly
+	RegistrationClosed     = user.RegistrationClosed
+	MaxInvitationsPerUser  = user.MaxInvitationsPerUser
+	MaxInvitationUses      = user.MaxInvitationUses
+	MaxInvitationLifetime  = user.MaxInvitationLifetime
+	BlockedTermKind        = user.BlockedTermKind
+	BlockedDomainKind      = user.BlockedDomainKind
+
+	PostStatusDraft     = posts.PostStatusDraft
+	PostStatusScheduled = posts.PostStatusScheduled
+	PostStatusPublished = posts.PostStatusPublished
+
+	VisibilityPublic      = posts.VisibilityPublic
+	VisibilityFollowers   = posts.VisibilityFollowers
+	VisibilityMembers     = posts.VisibilityMembers
+	VisibilityUnlisted    = posts.VisibilityUnlisted
+	MaxPreviewTTL         = posts.MaxPreviewTTL
+	MaxPlatformStatsDays  = posts.MaxPlatformStatsDays
+	MaxMentionSuggestions = posts.MaxMentionSuggestions
+
+	MentionSourcePost    = posts.MentionSourcePost
+	MentionSourceComment = posts.MentionSourceComment
+
+	ExportPending    = posts.ExportPending
+	ExportProcessing = posts.ExportProcessing
+	ExportReady      = posts.ExportReady
+	ExportFailed     = posts.ExportFailed
+	ExportCooldown   = posts.ExportCooldown
+	ExportKeyPrefix  = posts.ExportKeyPrefix
+
+	OrgRoleAdmin     = posts.OrgRoleAdmin
+	OrgRoleEditor    = posts.OrgRoleEditor
+	OrgRoleMember    = posts.OrgRoleMember
+	OrgMemberInvited = posts.OrgMemberInvited
+	OrgMemberActive  = posts.OrgMemberActive
+
+	SearchKindPost = posts.SearchKindPost
+	SearchKindUser = posts.SearchKindUser
 )
 
 var (
-	NewUser         = user.NewUser
-	GetUserBy       = user.GetUserBy
-	GetUsers        = user.GetUsers
-	UpdateUser      = user.UpdateUser
-	UpdateUserStats = user.UpdateUserStats
-	DeleteUser      = user.DeleteUser
+	NewUser        = user.NewUser
+	RegisterUser   = user.RegisterUser
+	GetUserBy      = user.GetUserBy
+	GetUsersByID   = user.GetUsersByID
+	Summaries      = user.Summaries
+	GetUsers       = user.GetUsers
+	GetActiveUsers = user.GetActiveUsers
+	GetFollowsPage = user.GetFollowsPage
+	UpdateUser     = user.UpdateUser
+	AdjustUserStat = user.AdjustUserStat
+	DeleteUser     = user.DeleteUser
+	HasBlocked     = user.HasBlocked
+	ListBlocks     = user.ListBlocks
 
 	WithUsername           = user.WithUsername
 	WithEmail              = user.WithEmail
 	WithPassword           = user.WithPassword
+	WithPasswordless       = user.WithPasswordless
 	WithPreviousPasswords  = user.WithPreviousPasswords
 	WithPasswordChangedAt  = user.WithPasswordChangedAt
 	WithOTP                = user.WithOTP
@@ -82,6 +390,7 @@ var (
 	WithSiteNavbar         = user.WithThemePreference
 	WithContentEditor      = user.WithContentEditor
 	WithContentMode        = user.WithContentMode
+	WithLocale             = user.WithLocale
 	WithPostsCount         = user.WithPostsCount
 	WithCommentsCount      = user.WithCommentsCount
 	WithLikesCount         = user.WithLikesCount
@@ -95,21 +404,325 @@ var (
 	WithEmailOnUnread      = user.WithEmailOnUnread
 	WithEmailOnNewPosts    = user.WithEmailOnNewPosts
 
-	NewRole       = user.NewRole
-	GetRoleBy     = user.GetRoleBy
-	GetRoles      = user.GetRoles
-	UpdateRole    = user.UpdateRole
-	DeleteRole    = user.DeleteRole
-	NewPermission = user.NewPermission
-	NewBadge      = user.NewBadge
-	SeedRoles     = user.SeedRoles
-
-	NewNotification    = user.NewNotification
-	GetNotification    = user.GetNotification
-	GetNotifications   = user.GetNotifications
-	UpdateNotification = user.UpdateNotification
-	DeleteNotification = user.DeleteNotification
-
-	NewNotificationPreferences    = user.NewNotificationPreferences
-	UpdateNotificationPreferences = user.UpdateNotificationPreferences
+	NewRole                = user.NewRole
+	CloneRole              = user.CloneRole
+	GetRoleBy              = user.GetRoleBy
+	GetRoles               = user.GetRoles
+	UpdateRole             = user.UpdateRole
+	DeleteRole             = user.DeleteRole
+	GetRoleUsers           = user.GetRoleUsers
+	GetPermissionSnapshot  = user.GetPermissionSnapshot
+	ListRoles              = user.ListRoles
+	ListPermissions        = user.ListPermissions
+	AddRolePermissions     = user.AddRolePermissions
+	RemoveRolePermissions  = user.RemoveRolePermissions
+	PreviewRolePermissions = user.PreviewRolePermissions
+	AssignRole             = user.AssignRole
+	NewPermission          = user.NewPermission
+	GetPermission          = user.GetPermission
+	UpdatePermission       = user.UpdatePermission
+	DeletePermission       = user.DeletePermission
+	NewBadge               = user.NewBadge
+	ApplyRolePolicy        = user.ApplyRolePolicy
+	InvalidateRoleCaches   = user.InvalidateRoleCaches
+	DiffRolePolicy         = user.DiffRolePolicy
+	ResolveRolePolicy      = user.ResolveRolePolicy
+	ResolveRoleTemplates   = user.ResolveRoleTemplates
+	GetRoleTemplate        = user.GetRoleTemplate
+	IsDeclaredRole         = user.IsDeclaredRole
+
+	RecordSecurityEvent      = user.RecordSecurityEvent
+	GetSecurityEvents        = user.GetSecurityEvents
+	RecordLoginEvent         = user.RecordLoginEvent
+	GetLoginEvent            = user.GetLoginEvent
+	GetLoginEvents           = user.GetLoginEvents
+	GetSecuritySummary       = user.GetSecuritySummary
+	TrackSession             = user.TrackSession
+	UntrackSession           = user.UntrackSession
+	CountSessions            = user.CountSessions
+	RevokeSessions           = user.RevokeSessions
+	ListUsers                = user.ListUsers
+	SetUserStatus            = user.SetUserStatus
+	ForceLogout              = user.ForceLogout
+	GetModerationActions     = user.GetModerationActions
+	RecordAudit              = user.RecordAudit
+	ListAuditLogs            = user.ListAuditLogs
+	ExportAuditLogs          = user.ExportAuditLogs
+	SessionProvider          = user.SessionProvider
+	RevokeProviderSessions   = user.RevokeProviderSessions
+	SessionFamily            = user.SessionFamily
+	StoreRefreshToken        = user.StoreRefreshToken
+	RotateRefreshToken       = user.RotateRefreshToken
+	RevokeReusedRefreshToken = user.RevokeReusedRefreshToken
+	ResolveOAuthUser         = user.ResolveOAuthUser
+	GetLinkedProviders       = user.GetLinkedProviders
+	ListIdentities           = user.ListIdentities
+	LinkIdentity             = user.LinkIdentity
+	UnlinkIdentity           = user.UnlinkIdentity
+	SetPassword              = user.SetPassword
+	RemovePassword           = user.RemovePassword
+
+	GetTwoFactorUser   = user.GetTwoFactorUser
+	SetTwoFactorSecret = user.SetTwoFactorSecret
+	EnableTwoFactor    = user.EnableTwoFactor
+	DisableTwoFactor   = user.DisableTwoFactor
+	ConsumeBackupCode  = user.ConsumeBackupCode
+
+	WebhookEvents            = user.WebhookEvents
+	ValidWebhookURL          = user.ValidWebhookURL
+	WebhookEventsJSON        = user.WebhookEventsJSON
+	CreateWebhook            = user.CreateWebhook
+	GetWebhook               = user.GetWebhook
+	GetUserWebhooks          = user.GetUserWebhooks
+	UpdateWebhook            = user.UpdateWebhook
+	DeleteWebhook            = user.DeleteWebhook
+	GetWebhookDeliveriesPage = user.GetWebhookDeliveriesPage
+	EmitWebhookEvent         = user.EmitWebhookEvent
+	DeliverDueWebhooks       = user.DeliverDueWebhooks
+	PurgeWebhookDeliveries   = user.PurgeWebhookDeliveries
+	EnqueueOutbox            = user.EnqueueOutbox
+	ProcessOutbox            = user.ProcessOutbox
+	PurgeOutbox              = user.PurgeOutbox
+	RequeueOutbox            = user.RequeueOutbox
+	SavePushSubscription     = user.SavePushSubscription
+	GetUserPushSubscriptions = user.GetUserPushSubscriptions
+	DeletePushSubscription   = user.DeletePushSubscription
+	ExpirePushSubscription   = user.ExpirePushSubscription
+	TouchPushSubscription    = user.TouchPushSubscription
+	WithWebhookURL           = user.WithWebhookURL
+	WithWebhookEvents        = user.WithWebhookEvents
+	WithWebhookSecret        = user.WithWebhookSecret
+	WithWebhookActive        = user.WithWebhookActive
+
+	APIKeyScopes         = user.APIKeyScopes
+	NotificationChannels = user.NotificationChannels
+	NotificationEvents   = user.NotificationEvents
+	PermissionCatalog    = user.PermissionCatalog
+	RolePolicy           = user.RolePolicy
+	RoleTemplates        = user.RoleTemplates
+	IsAPIKey             = user.IsAPIKey
+	CreateAPIKey         = user.CreateAPIKey
+	GetUserAPIKeys       = user.GetUserAPIKeys
+	RevokeAPIKey         = user.RevokeAPIKey
+	AuthenticateAPIKey   = user.AuthenticateAPIKey
+
+	RegistrationModes = user.RegistrationModes
+	CreateInvitation  = user.CreateInvitation
+	ListInvitations   = user.ListInvitations
+	RevokeInvitation  = user.RevokeInvitation
+
+	GetContentBlocklist = user.GetContentBlocklist
+	ListBlockedTerms    = user.ListBlockedTerms
+	AddBlockedTerm      = user.AddBlockedTerm
+	DeleteBlockedTerm   = user.DeleteBlockedTerm
+
+	GetRateLimitOverrides   = user.GetRateLimitOverrides
+	SetRateLimitOverride    = user.SetRateLimitOverride
+	DeleteRateLimitOverride = user.DeleteRateLimitOverride
+
+	GetFeatureFlags   = user.GetFeatureFlags
+	ListFeatureFlags  = user.ListFeatureFlags
+	GetFeatureFlag    = user.GetFeatureFlag
+	CreateFeatureFlag = user.CreateFeatureFlag
+	UpdateFeatureFlag = user.UpdateFeatureFlag
+	DeleteFeatureFlag = user.DeleteFeatureFlag
+
+	CreateUpload          = user.CreateUpload
+	GetUpload             = user.GetUpload
+	QuarantineUpload      = user.QuarantineUpload
+	StoreQuarantined      = user.StoreQuarantined
+	ScanQuarantinedUpload = user.ScanQuarantinedUpload
+	UploadKey             = user.UploadKey
+	QuarantineKeys        = user.QuarantineKeys
+
+	NewNotification          = user.NewNotification
+	NewNotifications         = user.NewNotifications
+	BuildNotifications       = user.BuildNotifications
+	GetNotification          = user.GetNotification
+	GetUserNotification      = user.GetUserNotification
+	GetNotificationSummary   = user.GetNotificationSummary
+	GetNotifications         = user.GetNotifications
+	GetNotificationsAfter    = user.GetNotificationsAfter
+	NotificationChannel      = user.NotificationChannel
+	UpdateNotification       = user.UpdateNotification
+	MarkAllNotificationsRead = user.MarkAllNotificationsRead
+	GetNotificationsPage     = user.GetNotificationsPage
+	DeleteNotification       = user.DeleteNotification
+
+	DefaultNotificationRetention = user.DefaultNotificationRetention
+	ArchiveNotifications         = user.ArchiveNotifications
+	GetArchivedNotificationsPage = user.GetArchivedNotificationsPage
+	ApplyNotificationRetention   = user.ApplyNotificationRetention
+	StreakMilestones             = user.StreakMilestones
+	RecordActivity               = user.RecordActivity
+	GetStreak                    = user.GetStreak
+	GetActivityHeatmap           = user.GetActivityHeatmap
+	RollupActivity               = user.RollupActivity
+	AwardStreakBadge             = user.AwardStreakBadge
+
+	CreatePost       = posts.CreatePost
+	GetPostsBy       = posts.GetPostsBy
+	UpdatePost       = posts.UpdatePost
+	DeletePost       = posts.DeletePost
+	GeneratePostSlug = posts.GeneratePostSlug
+	PublishPost      = posts.PublishPost
+	UnpublishPost    = posts.UnpublishPost
+	PublishDuePosts  = posts.PublishDuePosts
+	SendDigests      = posts.SendDigests
+
+	RequestDataExport        = posts.RequestDataExport
+	GetDataExport            = posts.GetDataExport
+	OpenDataExportArchive    = posts.OpenDataExportArchive
+	NextDataExport           = posts.NextDataExport
+	ProcessDataExport        = posts.ProcessDataExport
+	PurgeDataExports         = posts.PurgeDataExports
+	RecountUserStats         = posts.RecountUserStats
+	ReconcileCounters        = posts.ReconcileCounters
+	GetPublishedPost         = posts.GetPublishedPost
+	GetPublishedPosts        = posts.GetPublishedPosts
+	GetAuthorPosts           = posts.GetAuthorPosts
+	GetScheduledPosts        = posts.GetScheduledPosts
+	NewPostPolicy            = posts.NewPostPolicy
+	ValidVisibility          = posts.ValidVisibility
+	ListPostRevisions        = posts.ListPostRevisions
+	GetPostRevision          = posts.GetPostRevision
+	RestorePostRevision      = posts.RestorePostRevision
+	GetPostHistory           = posts.GetPostHistory
+	FanoutPost               = posts.FanoutPost
+	GetCommentHistory        = posts.GetCommentHistory
+	IsCoAuthor               = posts.IsCoAuthor
+	GetCoAuthors             = posts.GetCoAuthors
+	GetCoAuthorInvitations   = posts.GetCoAuthorInvitations
+	InviteCoAuthor           = posts.InviteCoAuthor
+	AnswerCoAuthorInvitation = posts.AnswerCoAuthorInvitation
+	RemoveCoAuthor           = posts.RemoveCoAuthor
+	ListingCategories        = posts.ListingCategories
+	IsListingCategory        = posts.IsListingCategory
+	CreateListing            = posts.CreateListing
+	GetListing               = posts.GetListing
+	UpdateListing            = posts.UpdateListing
+	RenewListing             = posts.RenewListing
+	DeleteListing            = posts.DeleteListing
+	GetListings              = posts.GetListings
+	GetAuthorListings        = posts.GetAuthorListings
+	SaveAutosave             = posts.SaveAutosave
+	GetAutosave              = posts.GetAutosave
+	DiscardAutosave          = posts.DiscardAutosave
+	ListSitemapPosts         = posts.ListSitemapPosts
+	ListSitemapTags          = posts.ListSitemapTags
+	ListSitemapAuthors       = posts.ListSitemapAuthors
+	IndexPosts               = posts.IndexPosts
+	IndexUsers               = posts.IndexUsers
+	Search                   = posts.Search
+	ReindexSearch            = posts.ReindexSearch
+
+	WithTitle            = posts.WithTitle
+	WithVisibility       = posts.WithVisibility
+	WithSlug             = posts.WithSlug
+	WithContent          = posts.WithContent
+	WithExcerpt          = posts.WithExcerpt
+	WithFeaturedImageURL = posts.WithFeaturedImageURL
+	WithContentFormat    = posts.WithContentFormat
+	WithCanonicalURL     = posts.WithCanonicalURL
+	WithGuestAuthor      = posts.WithGuestAuthor
+	WithEditedAt         = posts.WithEditedAt
+	WithLastEditedByID   = posts.WithLastEditedByID
+
+	ExtractMentions     = posts.ExtractMentions
+	SyncMentions        = posts.SyncMentions
+	DeleteMentions      = posts.DeleteMentions
+	GetUserMentions     = posts.GetUserMentions
+	UpdateComment       = posts.UpdateComment
+	CreateComment       = posts.CreateComment
+	DeleteComment       = posts.DeleteComment
+	GetPostComments     = posts.GetPostComments
+	GetComment          = posts.GetComment
+	GetCommentAuthor    = posts.GetCommentAuthor
+	SetMentionEmailer   = posts.SetMentionEmailer
+	AddBookmark         = posts.AddBookmark
+	RemoveBookmark      = posts.RemoveBookmark
+	SetBookmarkArchived = posts.SetBookmarkArchived
+	GetReadingList      = posts.GetReadingList
+
+	CreateTag       = posts.CreateTag
+	GetTagBy        = posts.GetTagBy
+	UpdateTag       = posts.UpdateTag
+	FollowTag       = posts.FollowTag
+	UnfollowTag     = posts.UnfollowTag
+	IsFollowingTag  = posts.IsFollowingTag
+	IsModerator     = posts.IsModerator
+	GetPopularTags  = posts.GetPopularTags
+	GetTagPosts     = posts.GetTagPosts
+	ResolveTags     = posts.ResolveTags
+	GetFollowedTags = posts.GetFollowedTags
+	GetFeed         = posts.GetFeed
+
+	AggregatePlatformStats = posts.AggregatePlatformStats
+	GetPlatformStats       = posts.GetPlatformStats
+
+	GetFollowSuggestions = posts.GetFollowSuggestions
+	SuggestMentions      = posts.SuggestMentions
+	IndexMentionUsers    = posts.IndexMentionUsers
+	RebuildMentionIndex  = posts.RebuildMentionIndex
+
+	WithTags                = posts.WithTags
+	WithTagName             = posts.WithTagName
+	WithTagDescription      = posts.WithTagDescription
+	WithTagShortDescription = posts.WithTagShortDescription
+	WithTagIconURL          = posts.WithTagIconURL
+	WithTagBackgroundURL    = posts.WithTagBackgroundURL
+	WithTagTextColor        = posts.WithTagTextColor
+	WithTagBackgroundColor  = posts.WithTagBackgroundColor
+	WithTagIsFeatured       = posts.WithTagIsFeatured
+	WithTagIsModerated      = posts.WithTagIsModerated
+	WithTagRules            = posts.WithTagRules
+
+	CreateOrganization         = posts.CreateOrganization
+	GetOrganization            = posts.GetOrganization
+	UpdateOrganization         = posts.UpdateOrganization
+	GetOrganizationPosts       = posts.GetOrganizationPosts
+	GetUserOrganizations       = posts.GetUserOrganizations
+	GetOrganizationMember      = posts.GetOrganizationMember
+	InviteOrganizationMember   = posts.InviteOrganizationMember
+	AcceptOrganizationInvite   = posts.AcceptOrganizationInvite
+	SetOrganizationMemberRole  = posts.SetOrganizationMemberRole
+	RemoveOrganizationMember   = posts.RemoveOrganizationMember
+	GetOrganizationMembersPage = posts.GetOrganizationMembersPage
+	ValidOrgRole               = posts.ValidOrgRole
+
+	CreateSeries             = posts.CreateSeries
+	GetSeries                = posts.GetSeries
+	UpdateSeries             = posts.UpdateSeries
+	DeleteSeries             = posts.DeleteSeries
+	ListAuthorSeries         = posts.ListAuthorSeries
+	GetSeriesParts           = posts.GetSeriesParts
+	GetPublishedSeries       = posts.GetPublishedSeries
+	GetPublishedSeriesBySlug = posts.GetPublishedSeriesBySlug
+	AddSeriesPost            = posts.AddSeriesPost
+	RemoveSeriesPost         = posts.RemoveSeriesPost
+	ReorderSeriesPosts       = posts.ReorderSeriesPosts
+
+	WithSeriesTitle         = posts.WithSeriesTitle
+	WithSeriesSlug          = posts.WithSeriesSlug
+	WithSeriesDescription   = posts.WithSeriesDescription
+	WithSeriesCoverImageURL = posts.WithSeriesCoverImageURL
+
+	WithOrganizationID = posts.WithOrganizationID
+	WithOrgName        = posts.WithOrgName
+	WithOrgSummary     = posts.WithOrgSummary
+	WithOrgAvatarURL   = posts.WithOrgAvatarURL
+	WithOrgWebsiteURL  = posts.WithOrgWebsiteURL
+	WithOrgLocation    = posts.WithOrgLocation
+	WithOrgBrandColor  = posts.WithOrgBrandColor
+
+	WithListingCategory   = posts.WithListingCategory
+	WithListingTitle      = posts.WithListingTitle
+	WithListingBody       = posts.WithListingBody
+	WithListingTags       = posts.WithListingTags
+	WithListingLocation   = posts.WithListingLocation
+	WithListingContactURL = posts.WithListingContactURL
+
+	NewNotificationPreferences       = user.NewNotificationPreferences
+	UpdateNotificationPreferences    = user.UpdateNotificationPreferences
+	GetNotificationPreferencesByUser = user.GetNotificationPreferencesByUser
 )
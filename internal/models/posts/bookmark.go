@@ -1,21 +1,25 @@
 package models
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
 	user "github.com/mnuddindev/devpulse/internal/models/user"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
 )
 
 type Bookmark struct {
 	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
-	UserID       uuid.UUID  `gorm:"type:uuid;not null;index:idx_bookmark_user" json:"user_id" validate:"required"`
-	PostID       uuid.UUID  `gorm:"type:uuid;not null;index:idx_bookmark_post" json:"post_id" validate:"required"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index:idx_bookmark_user;uniqueIndex:idx_bookmark_user_post" json:"user_id" validate:"required"`
+	PostID       uuid.UUID  `gorm:"type:uuid;not null;index:idx_bookmark_post;uniqueIndex:idx_bookmark_user_post" json:"post_id" validate:"required"`
 	CollectionID *uuid.UUID `gorm:"type:uuid;index:idx_bookmark_collection" json:"collection_id" validate:"omitempty"`
 	Notes        string     `gorm:"type:text" json:"notes" validate:"omitempty,max=500"`
 	IsPrivate    bool       `gorm:"default:false;index" json:"is_private"`
 	Tags         []string   `gorm:"type:text[];index:idx_bookmark_tags,gin" json:"tags" validate:"max=5,dive,max=20,alphanumunicode"`
+	ArchivedAt   *time.Time `gorm:"index" json:"archived_at"`
 
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
@@ -25,3 +29,111 @@ type Bookmark struct {
 	Post       Posts       `gorm:"foreignKey:PostID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"post" validate:"-"`
 	Collection *Collection `gorm:"foreignKey:CollectionID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"collection" validate:"-"`
 }
+
+// AddBookmark bookmarks a published post for a user who may see it. Bookmarking a post twice is a
+// no-op, reported by created being false.
+func AddBookmark(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID, postID uuid.UUID) (bookmark *Bookmark, created bool, err error) {
+	bookmark = &Bookmark{UserID: userID, PostID: postID}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Posts{}).Where("id = ?", postID).Scopes(ListedTo(&userID)).Count(&count).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get post")
+		}
+		if count == 0 {
+			return utils.NewError(utils.ErrNotFound.Code, "Post not found")
+		}
+
+		if err := tx.Where("user_id = ? AND post_id = ?", userID, postID).First(bookmark).Error; err == nil {
+			return nil
+		} else if err != gorm.ErrRecordNotFound {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get bookmark")
+		}
+
+		if err := tx.Create(bookmark).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create bookmark")
+		}
+		created = true
+		return adjustCounts(tx, userID, postID, "bookmarks_count", 1)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if created {
+		dropCountCaches(ctx, rclient, userID, postID)
+	}
+	return bookmark, created, nil
+}
+
+// RemoveBookmark removes a user's bookmark of a post. The row is deleted for good so the post can
+// be bookmarked again later.
+func RemoveBookmark(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID, postID uuid.UUID) error {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Where("user_id = ? AND post_id = ?", userID, postID).Delete(&Bookmark{})
+		if res.Error != nil {
+			return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to delete bookmark")
+		}
+		if res.RowsAffected == 0 {
+			return utils.NewError(utils.ErrNotFound.Code, "Bookmark not found")
+		}
+		return adjustCounts(tx, userID, postID, "bookmarks_count", -1)
+	})
+	if err != nil {
+		return err
+	}
+
+	dropCountCaches(ctx, rclient, userID, postID)
+	return nil
+}
+
+// SetBookmarkArchived moves a bookmark out of or back into a user's active reading list.
+func SetBookmarkArchived(ctx context.Context, db *gorm.DB, userID, postID uuid.UUID, archived bool) (*Bookmark, error) {
+	var bookmark Bookmark
+	if err := db.WithContext(ctx).Where("user_id = ? AND post_id = ?", userID, postID).First(&bookmark).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Bookmark not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get bookmark")
+	}
+
+	var archivedAt *time.Time
+	if archived {
+		if bookmark.ArchivedAt != nil {
+			return &bookmark, nil
+		}
+		now := time.Now()
+		archivedAt = &now
+	}
+	if err := db.WithContext(ctx).Model(&bookmark).Update("archived_at", archivedAt).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update bookmark")
+	}
+	bookmark.ArchivedAt = archivedAt
+	return &bookmark, nil
+}
+
+// GetReadingList retrieves a page of a user's bookmarks of published posts, newest first, either
+// the active ones or the archived ones.
+func GetReadingList(ctx context.Context, db *gorm.DB, userID uuid.UUID, archived bool, page, limit int) ([]Bookmark, int64, error) {
+	query := db.WithContext(ctx).Model(&Bookmark{}).
+		Joins("JOIN posts ON posts.id = bookmarks.post_id AND posts.published = ? AND posts.deleted_at IS NULL", true).
+		Where("bookmarks.user_id = ?", userID)
+	if archived {
+		query = query.Where("bookmarks.archived_at IS NOT NULL")
+	} else {
+		query = query.Where("bookmarks.archived_at IS NULL")
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count bookmarks")
+	}
+
+	bookmarks := []Bookmark{}
+	if err := query.Preload("Post").Preload("Post.Author", authorSummary).
+		Order("bookmarks.created_at DESC").Offset((page - 1) * limit).Limit(limit).
+		Find(&bookmarks).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get bookmarks")
+	}
+	return bookmarks, total, nil
+}
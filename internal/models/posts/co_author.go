@@ -0,0 +1,266 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Statuses of a post co-author.
+const (
+	CoAuthorPending  = "pending"
+	CoAuthorAccepted = "accepted"
+)
+
+// MaxCoAuthors caps the co-authors of a post, counting pending invitations.
+const MaxCoAuthors = 3
+
+// PostAuthor is a user the author of a post invited to write it with them. Once they accept,
+// they are shown as one of its authors, may edit it, and the post counts towards their
+// PostsCount while published.
+type PostAuthor struct {
+	PostID      uuid.UUID  `gorm:"type:uuid;primaryKey" json:"post_id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;primaryKey;index:idx_post_authors_user" json:"user_id"`
+	Status      string     `gorm:"size:20;not null;default:'pending'" json:"status"`
+	InvitedByID uuid.UUID  `gorm:"type:uuid;not null" json:"invited_by_id"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+
+	User user.User `gorm:"foreignKey:UserID" json:"user"`
+	Post *Posts    `gorm:"foreignKey:PostID" json:"post,omitempty"`
+}
+
+// Accepted reports whether the co-author accepted their invitation.
+func (a *PostAuthor) Accepted() bool {
+	return a.Status == CoAuthorAccepted
+}
+
+// acceptedCoAuthors limits a CoAuthors preload to the co-authors who accepted.
+func acceptedCoAuthors(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", CoAuthorAccepted).Order("accepted_at")
+}
+
+// CoAuthoredBy is a subquery of the posts the user with ID userID co-authors.
+func CoAuthoredBy(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Model(&PostAuthor{}).Select("post_id").Where("user_id = ? AND status = ?", userID, CoAuthorAccepted)
+}
+
+// IsCoAuthor reports whether the user accepted to co-author the post.
+func IsCoAuthor(ctx context.Context, db *gorm.DB, postID, userID uuid.UUID) (bool, error) {
+	var count int64
+	if err := db.WithContext(ctx).Model(&PostAuthor{}).
+		Where("post_id = ? AND user_id = ? AND status = ?", postID, userID, CoAuthorAccepted).
+		Count(&count).Error; err != nil {
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check co-author")
+	}
+	return count > 0, nil
+}
+
+// coAuthorIDs returns the users who accepted to co-author the post.
+func coAuthorIDs(tx *gorm.DB, postID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := tx.Model(&PostAuthor{}).Where("post_id = ? AND status = ?", postID, CoAuthorAccepted).Pluck("user_id", &ids).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get co-authors")
+	}
+	return ids, nil
+}
+
+// adjustAuthorsPostsCount moves the PostsCount of the post's author and accepted co-authors by
+// delta within tx, and returns the co-authors whose count moved.
+func adjustAuthorsPostsCount(ctx context.Context, tx *gorm.DB, post *Posts, delta int) ([]uuid.UUID, error) {
+	if err := user.AdjustUserStat(ctx, tx, post.AuthorID, "posts_count", delta); err != nil {
+		return nil, err
+	}
+	ids, err := coAuthorIDs(tx, post.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if err := user.AdjustUserStat(ctx, tx, id, "posts_count", delta); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// invalidateCoAuthoredPost drops the cached copies of a post whose authors changed, and the
+// cached records of the users whose PostsCount moved.
+func invalidateCoAuthoredPost(ctx context.Context, rclient *storage.RedisClient, post *Posts, users ...uuid.UUID) {
+	cache.Delete(ctx, rclient, cache.PostKey(post.ID), cache.PublicPostKey(post.Slug))
+	for _, id := range users {
+		cache.InvalidateUser(ctx, rclient, id)
+	}
+	if len(users) > 0 {
+		cache.Invalidate(ctx, rclient, cache.PublishedPostsTag)
+	}
+}
+
+// GetCoAuthors lists the co-authors of a post, accepted or invited, in the order they were
+// invited.
+func GetCoAuthors(ctx context.Context, db *gorm.DB, postID uuid.UUID) ([]PostAuthor, error) {
+	authors := []PostAuthor{}
+	if err := db.WithContext(ctx).Preload("User", authorSummary).
+		Where("post_id = ?", postID).Order("created_at").Find(&authors).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get co-authors")
+	}
+	return authors, nil
+}
+
+// GetCoAuthorInvitations lists the invitations to co-author a post the user has not answered yet,
+// newest first.
+func GetCoAuthorInvitations(ctx context.Context, db *gorm.DB, userID uuid.UUID) ([]PostAuthor, error) {
+	invitations := []PostAuthor{}
+	if err := db.WithContext(ctx).
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Select("id, title, slug, author_id") }).
+		Preload("Post.Author", authorSummary).
+		Where("user_id = ? AND status = ?", userID, CoAuthorPending).
+		Order("created_at DESC").Find(&invitations).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get co-author invitations")
+	}
+	return invitations, nil
+}
+
+// InviteCoAuthor invites the user named username to co-author the post on behalf of its author,
+// and notifies them. Users who blocked the author cannot be invited.
+func InviteCoAuthor(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, post *Posts, username string) (*PostAuthor, error) {
+	var invitee user.User
+	if err := db.WithContext(ctx).Select("id, username").Where("username = ?", username).First(&invitee).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "User not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get user")
+	}
+	if invitee.ID == post.AuthorID {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "The author of a post cannot co-author it")
+	}
+	blocked, err := user.HasBlocked(ctx, db, invitee.ID, post.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, utils.NewError(utils.ErrForbidden.Code, "You cannot invite this user")
+	}
+
+	var author string
+	coAuthor := &PostAuthor{PostID: post.ID, UserID: invitee.ID, Status: CoAuthorPending, InvitedByID: post.AuthorID}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The post row is locked so concurrent invitations cannot go over the limit
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", post.ID).First(&Posts{}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get post")
+		}
+		var count int64
+		if err := tx.Model(&PostAuthor{}).Where("post_id = ?", post.ID).Count(&count).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count co-authors")
+		}
+		if count >= MaxCoAuthors {
+			return utils.NewError(utils.ErrBadRequest.Code, "A post can have at most 3 co-authors")
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(coAuthor)
+		if res.Error != nil {
+			return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to invite co-author")
+		}
+		if res.RowsAffected == 0 {
+			return utils.NewError(utils.ErrConflict.Code, "User is already a co-author of this post")
+		}
+		return tx.Model(&user.User{}).Select("username").Where("id = ?", post.AuthorID).Scan(&author).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	coAuthor.User = invitee
+	user.NewNotification(ctx, rclient, db, invitee.ID, user.NotificationCoAuthor, "%s invited you to co-author \"%s\"", author, post.Title)
+	cache.Delete(ctx, rclient, cache.PostKey(post.ID))
+	return coAuthor, nil
+}
+
+// AnswerCoAuthorInvitation accepts or declines the user's invitation to co-author a post. A
+// declined invitation is deleted; an accepted one counts the post towards the user's PostsCount
+// while it is published, and the author is notified.
+func AnswerCoAuthorInvitation(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, postID, userID uuid.UUID, accept bool) (*PostAuthor, error) {
+	var coAuthor PostAuthor
+	var post Posts
+	var username string
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("post_id = ? AND user_id = ? AND status = ?", postID, userID, CoAuthorPending).
+			First(&coAuthor).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Invitation not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get invitation")
+		}
+		if err := tx.Select("id, title, slug, author_id, published").Where("id = ?", postID).First(&post).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get post")
+		}
+
+		if !accept {
+			if err := tx.Where("post_id = ? AND user_id = ?", postID, userID).Delete(&PostAuthor{}).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to decline invitation")
+			}
+			return nil
+		}
+		now := time.Now()
+		coAuthor.Status, coAuthor.AcceptedAt = CoAuthorAccepted, &now
+		if err := tx.Model(&PostAuthor{}).Where("post_id = ? AND user_id = ?", postID, userID).
+			Updates(map[string]interface{}{"status": CoAuthorAccepted, "accepted_at": now}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to accept invitation")
+		}
+		if post.Published {
+			if err := user.AdjustUserStat(ctx, tx, userID, "posts_count", 1); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&user.User{}).Select("username").Where("id = ?", userID).Scan(&username).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !accept {
+		cache.Delete(ctx, rclient, cache.PostKey(postID))
+		return &coAuthor, nil
+	}
+	var counted []uuid.UUID
+	if post.Published {
+		counted = append(counted, userID)
+	}
+	invalidateCoAuthoredPost(ctx, rclient, &post, counted...)
+	user.NewNotification(ctx, rclient, db, post.AuthorID, user.NotificationCoAuthor, "%s accepted your invitation to co-author \"%s\"", username, post.Title)
+	return &coAuthor, nil
+}
+
+// RemoveCoAuthor takes a user off the co-authors of a post, or withdraws their invitation.
+func RemoveCoAuthor(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, post *Posts, userID uuid.UUID) error {
+	var counted []uuid.UUID
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var coAuthor PostAuthor
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("post_id = ? AND user_id = ?", post.ID, userID).First(&coAuthor).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Co-author not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get co-author")
+		}
+		if err := tx.Where("post_id = ? AND user_id = ?", post.ID, userID).Delete(&PostAuthor{}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to remove co-author")
+		}
+		if !coAuthor.Accepted() || !post.Published {
+			return nil
+		}
+		counted = append(counted, userID)
+		return user.AdjustUserStat(ctx, tx, userID, "posts_count", -1)
+	})
+	if err != nil {
+		return err
+	}
+	invalidateCoAuthoredPost(ctx, rclient, post, counted...)
+	return nil
+}
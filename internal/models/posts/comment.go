@@ -1,10 +1,15 @@
 package models
 
 import (
+	"context"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
 )
 
@@ -18,6 +23,7 @@ type Comment struct {
 	UpvotesCount    int        `gorm:"default:0" json:"upvotes_count" validate:"min=0"`
 	DownvotesCount  int        `gorm:"default:0" json:"downvotes_count" validate:"min=0"`
 	Edited          bool       `gorm:"default:false;index" json:"edited"`
+	EditedAt        *time.Time `json:"edited_at"`
 	Pinned          bool       `gorm:"default:false;index" json:"pinned"`
 
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
@@ -32,3 +38,299 @@ type Comment struct {
 	Reactions     []Reaction    `gorm:"foreignKey:ReactableID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"reactions" validate:"-"`
 	Flags         []CommentFlag `gorm:"foreignKey:CommentID" json:"flags" validate:"-"`
 }
+
+// maxCommentDepth is the deepest a reply may be nested; top-level comments have depth 0.
+const maxCommentDepth = 10
+
+func validCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if length := len([]rune(content)); length < 2 || length > 1000 {
+		return "", utils.NewError(utils.ErrBadRequest.Code, "Comment must be between 2 and 1000 characters")
+	}
+	return content, nil
+}
+
+// CreateComment adds a comment to a published post, as a reply when parentID is set. Users
+// blocked by the post's author, or by the author of the comment replied to, cannot comment.
+// Mentioned users are notified once the comment is committed.
+func CreateComment(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, postID, authorID uuid.UUID, parentID *uuid.UUID, content string) (*Comment, error) {
+	content, err := validCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &Comment{
+		PostID:          postID,
+		AuthorID:        authorID,
+		ParentCommentID: parentID,
+		Content:         content,
+	}
+	var post Posts
+	var parent Comment
+	var mentioned []uuid.UUID
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id, title, slug, published, author_id").Where("id = ?", postID).First(&post).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Post not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get post")
+		}
+		if !post.Published {
+			return utils.NewError(utils.ErrNotFound.Code, "Post not found")
+		}
+
+		if parentID != nil {
+			if err := tx.Select("id, depth, author_id").Where("id = ? AND post_id = ?", *parentID, postID).First(&parent).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return utils.NewError(utils.ErrNotFound.Code, "Parent comment not found")
+				}
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get parent comment")
+			}
+			if parent.Depth >= maxCommentDepth {
+				return utils.NewError(utils.ErrBadRequest.Code, "Replies cannot be nested any deeper")
+			}
+			comment.Depth = parent.Depth + 1
+		}
+
+		for _, ownerID := range []uuid.UUID{post.AuthorID, parent.AuthorID} {
+			if ownerID == uuid.Nil {
+				continue
+			}
+			blocked, err := user.HasBlocked(ctx, tx, ownerID, authorID)
+			if err != nil {
+				return err
+			}
+			if blocked {
+				return utils.NewError(utils.ErrForbidden.Code, "You cannot comment here")
+			}
+		}
+
+		if err := tx.Create(comment).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create comment")
+		}
+
+		added, err := SyncMentions(ctx, tx, MentionSourceComment, comment.ID, postID, authorID, content)
+		if err != nil {
+			return err
+		}
+		mentioned = added
+		if err := adjustCounts(tx, authorID, postID, "comments_count", 1); err != nil {
+			return err
+		}
+		return user.EmitWebhookEvent(ctx, tx, user.WebhookCommentCreated, []uuid.UUID{post.AuthorID}, map[string]interface{}{
+			"id":                comment.ID,
+			"post_id":           postID,
+			"post_slug":         post.Slug,
+			"author_id":         authorID,
+			"parent_comment_id": parentID,
+			"content":           content,
+			"created_at":        comment.CreatedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dropCountCaches(ctx, rclient, authorID, postID)
+	notifyMentions(ctx, rclient, db, authorID, mentioned, MentionSourceComment, content, post.Slug)
+	notifyComment(ctx, rclient, db, comment, &post, parent.AuthorID, mentioned)
+	return comment, nil
+}
+
+// notifyComment tells the post's author about a new comment and, for replies, the author of the
+// comment replied to. Commenters are not notified of their own comments, users the comment
+// mentions were already notified of it, and users who muted the commenter hear nothing.
+func notifyComment(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, comment *Comment, post *Posts, parentAuthorID uuid.UUID, mentioned []uuid.UUID) {
+	skip := map[uuid.UUID]bool{comment.AuthorID: true, uuid.Nil: true}
+	for _, id := range mentioned {
+		skip[id] = true
+	}
+	owners := []uuid.UUID{parentAuthorID, post.AuthorID}
+	heard := map[uuid.UUID]bool{}
+	for _, id := range user.WithoutSilenced(ctx, db, owners, comment.AuthorID) {
+		heard[id] = true
+	}
+	for _, id := range owners {
+		if !heard[id] {
+			skip[id] = true
+		}
+	}
+	notify := func(userID uuid.UUID, message string) {
+		if skip[userID] {
+			return
+		}
+		skip[userID] = true
+		user.NewNotification(ctx, rclient, db, userID, user.NotificationComment, message, post.Title)
+	}
+	notify(parentAuthorID, "New reply to your comment on: %s")
+	notify(post.AuthorID, "New comment on your post: %s")
+}
+
+// UpdateComment edits a comment, reconciling its mentions so only newly mentioned users are
+// notified and dropped mentions are removed. The content it replaces is kept in the comment's
+// history. Authors may edit within editWindow of posting;
+// moderators may edit any comment at any time.
+func UpdateComment(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, commentID, editorID uuid.UUID, content string, editWindow time.Duration, moderator bool) (*Comment, error) {
+	content, err := validCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var comment Comment
+	var postSlug string
+	var mentioned []uuid.UUID
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", commentID).First(&comment).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Comment not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get comment")
+		}
+		if !moderator {
+			if comment.AuthorID != editorID {
+				return utils.NewError(utils.ErrForbidden.Code, "You can only edit your own comments")
+			}
+			if editWindow > 0 && time.Since(comment.CreatedAt) > editWindow {
+				return utils.NewError(utils.ErrForbidden.Code, "Comments can only be edited within "+editWindow.String()+" of posting")
+			}
+		}
+
+		if comment.Content != content {
+			if err := recordCommentRevision(tx, &comment, editorID); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		comment.Content = content
+		comment.Edited = true
+		comment.EditedAt = &now
+		if err := tx.Model(&comment).Updates(map[string]interface{}{"content": content, "edited": true, "edited_at": now}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update comment")
+		}
+
+		if err := tx.Model(&Posts{}).Where("id = ?", comment.PostID).Pluck("slug", &postSlug).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get post")
+		}
+
+		added, err := SyncMentions(ctx, tx, MentionSourceComment, comment.ID, comment.PostID, comment.AuthorID, content)
+		if err != nil {
+			return err
+		}
+		mentioned = added
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Delete(ctx, rclient, cache.CommentHTMLKey(comment.ID))
+	notifyMentions(ctx, rclient, db, comment.AuthorID, mentioned, MentionSourceComment, content, postSlug)
+	return &comment, nil
+}
+
+// DeleteComment soft deletes a comment. Its replies stay visible under a placeholder. Only the
+// author or a moderator may delete a comment.
+func DeleteComment(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, commentID, userID uuid.UUID, moderator bool) error {
+	var comment Comment
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", commentID).First(&comment).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Comment not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get comment")
+		}
+		if !moderator && comment.AuthorID != userID {
+			return utils.NewError(utils.ErrForbidden.Code, "You can only delete your own comments")
+		}
+
+		if err := tx.Delete(&comment).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete comment")
+		}
+		if err := DeleteMentions(ctx, tx, MentionSourceComment, comment.ID); err != nil {
+			return err
+		}
+		return adjustCounts(tx, comment.AuthorID, comment.PostID, "comments_count", -1)
+	})
+	if err != nil {
+		return err
+	}
+
+	dropCountCaches(ctx, rclient, comment.AuthorID, comment.PostID)
+	cache.Delete(ctx, rclient, cache.CommentHTMLKey(comment.ID))
+	return nil
+}
+
+// GetComment returns a comment by ID.
+func GetComment(ctx context.Context, db *gorm.DB, commentID uuid.UUID) (*Comment, error) {
+	var comment Comment
+	if err := db.WithContext(ctx).Where("id = ?", commentID).First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Comment not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get comment")
+	}
+	return &comment, nil
+}
+
+// GetCommentAuthor returns the author of a comment.
+func GetCommentAuthor(ctx context.Context, db *gorm.DB, commentID uuid.UUID) (uuid.UUID, error) {
+	var authors []uuid.UUID
+	if err := db.WithContext(ctx).Model(&Comment{}).Where("id = ?", commentID).Limit(1).Pluck("author_id", &authors).Error; err != nil {
+		return uuid.Nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get comment")
+	}
+	if len(authors) == 0 {
+		return uuid.Nil, utils.NewError(utils.ErrNotFound.Code, "Comment not found")
+	}
+	return authors[0], nil
+}
+
+// GetPostComments returns the comment threads of a post, with replies nested under their parents.
+// Deleted comments are kept, without content or author, only while they still have visible replies.
+func GetPostComments(ctx context.Context, db *gorm.DB, postID uuid.UUID) ([]Comment, error) {
+	var all []Comment
+	if err := db.WithContext(ctx).Unscoped().
+		Preload("Author", authorSummary).
+		Where("post_id = ?", postID).
+		Order("pinned DESC, created_at ASC").
+		Find(&all).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get comments")
+	}
+
+	children := map[uuid.UUID][]Comment{}
+	roots := []Comment{}
+	for _, c := range all {
+		if c.ParentCommentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+		children[*c.ParentCommentID] = append(children[*c.ParentCommentID], c)
+	}
+
+	var build func(c Comment) (Comment, bool)
+	build = func(c Comment) (Comment, bool) {
+		c.Replies = []Comment{}
+		for _, child := range children[c.ID] {
+			if reply, ok := build(child); ok {
+				c.Replies = append(c.Replies, reply)
+			}
+		}
+		if c.DeletedAt.Valid {
+			if len(c.Replies) == 0 {
+				return c, false
+			}
+			c.Content = ""
+			c.AuthorID = uuid.Nil
+			c.Author = user.User{}
+		}
+		return c, true
+	}
+
+	threads := []Comment{}
+	for _, root := range roots {
+		if thread, ok := build(root); ok {
+			threads = append(threads, thread)
+		}
+	}
+	return threads, nil
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// digestItems caps how many notifications and how many posts one digest lists.
+	digestItems = 10
+	// digestBatch caps how many digests one run sends.
+	digestBatch = 100
+)
+
+// Digest is what a user's periodic email lists: unread notifications and new posts from followed
+// users and tags since the previous digest.
+type Digest struct {
+	UserID        uuid.UUID
+	Email         string
+	Username      string
+	Locale        string
+	Frequency     string
+	Since         time.Time
+	Unread        int64
+	Notifications []user.Notification
+	Posts         []Posts
+}
+
+// DigestSender delivers a digest; it is supplied by the API layer which owns the mail settings.
+type DigestSender func(ctx context.Context, digest *Digest) error
+
+type digestRecipient struct {
+	UserID          uuid.UUID
+	Email           string
+	Username        string
+	Locale          string
+	DigestFrequency string
+	LastDigestAt    *time.Time
+	EmailOnUnread   bool
+	EmailOnNewPosts bool
+}
+
+// dueDigests finds active users who opted into digests and whose last one is a full period old.
+func dueDigests(ctx context.Context, db *gorm.DB, now time.Time) ([]digestRecipient, error) {
+	var recipients []digestRecipient
+	err := db.WithContext(ctx).Table("notification_preferences").
+		Select("notification_preferences.user_id, users.email, users.username, users.locale, notification_preferences.digest_frequency, notification_preferences.last_digest_at, notification_preferences.email_on_unread, notification_preferences.email_on_new_posts").
+		Joins("JOIN users ON users.id = notification_preferences.user_id AND users.deleted_at IS NULL").
+		Where("notification_preferences.email_on_unread OR notification_preferences.email_on_new_posts").
+		Where("users.is_active = ? AND users.status = ?", true, user.UserStatusActive).
+		Where("notification_preferences.last_digest_at IS NULL OR (notification_preferences.digest_frequency = ? AND notification_preferences.last_digest_at <= ?) OR (notification_preferences.digest_frequency <> ? AND notification_preferences.last_digest_at <= ?)",
+			user.DigestWeekly, now.Add(-user.DigestInterval(user.DigestWeekly)),
+			user.DigestWeekly, now.Add(-user.DigestInterval(user.DigestDaily))).
+		Order("notification_preferences.last_digest_at ASC NULLS FIRST").
+		Limit(digestBatch).
+		Scan(&recipients).Error
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch due digests")
+	}
+	return recipients, nil
+}
+
+// setLastDigest moves a user's digest marker from prev to at. It reports false when another
+// instance moved it first, so each digest is sent once.
+func setLastDigest(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID uuid.UUID, prev, at *time.Time) (bool, error) {
+	res := db.WithContext(ctx).Model(&user.NotificationPreferences{}).
+		Where("user_id = ? AND last_digest_at IS NOT DISTINCT FROM ?", userID, prev).
+		Update("last_digest_at", at)
+	if res.Error != nil {
+		return false, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to update digest state")
+	}
+	rclient.Del(ctx, "notif_prefs:user:"+userID.String())
+	return res.RowsAffected == 1, nil
+}
+
+// buildDigest collects what happened for a recipient since the given time.
+func buildDigest(ctx context.Context, db *gorm.DB, r digestRecipient, since time.Time) (*Digest, error) {
+	digest := &Digest{
+		UserID:        r.UserID,
+		Email:         r.Email,
+		Username:      r.Username,
+		Locale:        r.Locale,
+		Frequency:     r.DigestFrequency,
+		Since:         since,
+		Notifications: []user.Notification{},
+		Posts:         []Posts{},
+	}
+
+	if r.EmailOnUnread {
+		unread := db.WithContext(ctx).Model(&user.Notification{}).Where("user_id = ? AND is_read = ? AND created_at > ?", r.UserID, false, since)
+		if err := unread.Session(&gorm.Session{}).Count(&digest.Unread).Error; err != nil {
+			return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count unread notifications")
+		}
+		if digest.Unread > 0 {
+			if err := unread.Order("created_at DESC").Limit(digestItems).Find(&digest.Notifications).Error; err != nil {
+				return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch unread notifications")
+			}
+		}
+	}
+
+	if r.EmailOnNewPosts {
+		followedAuthors := db.Table("user_followers").Select("following_id").Where("follower_id = ?", r.UserID)
+		followedTags := db.Model(&TagFollower{}).Select("tag_id").Where("user_id = ?", r.UserID)
+		taggedPosts := db.Table("post_tags").Select("posts_id").Where("tag_id IN (?)", followedTags)
+		if err := db.WithContext(ctx).Preload("Author", authorSummary).
+			Scopes(ListedTo(&r.UserID)).
+			Where("published_at > ? AND author_id != ?", since, r.UserID).
+			Where("author_id IN (?) OR id IN (?)", followedAuthors, taggedPosts).
+			Order("published_at DESC").Limit(digestItems).
+			Find(&digest.Posts).Error; err != nil {
+			return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch digest posts")
+		}
+	}
+	return digest, nil
+}
+
+// SendDigests sends every due digest through send and returns how many went out, along with the
+// last delivery error. Digests with nothing to report are skipped but still advance the user's
+// digest marker; failed ones are retried on the next run.
+func SendDigests(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, send DigestSender) (int, error) {
+	now := time.Now()
+	recipients, err := dueDigests(ctx, db, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var failed error
+	for _, r := range recipients {
+		if ctx.Err() != nil {
+			break
+		}
+		claimed, err := setLastDigest(ctx, rclient, db, r.UserID, r.LastDigestAt, &now)
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+
+		since := now.Add(-user.DigestInterval(r.DigestFrequency))
+		if r.LastDigestAt != nil {
+			since = *r.LastDigestAt
+		}
+		digest, err := buildDigest(ctx, db, r, since)
+		if err == nil && len(digest.Notifications) == 0 && len(digest.Posts) == 0 {
+			continue
+		}
+		if err == nil {
+			err = send(ctx, digest)
+		}
+		if err != nil {
+			// Hand the digest back so the next run retries it.
+			setLastDigest(ctx, rclient, db, r.UserID, &now, r.LastDigestAt)
+			failed = err
+			continue
+		}
+		sent++
+	}
+	return sent, failed
+}
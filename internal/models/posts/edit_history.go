@@ -0,0 +1,166 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/diff"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxCommentRevisions caps the earlier versions kept per comment; the oldest are pruned as new
+	// ones are saved.
+	MaxCommentRevisions = 50
+	// historyDiffContext is how many unchanged lines the diffs of the edit history show around
+	// each change.
+	historyDiffContext = 3
+)
+
+// CommentRevision is the content a comment had before one of its edits, kept so moderators can
+// settle disputes about what a comment said.
+type CommentRevision struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CommentID uuid.UUID `gorm:"type:uuid;not null;index:idx_comment_revision_comment" json:"comment_id"`
+	EditorID  uuid.UUID `gorm:"type:uuid;not null" json:"editor_id"`
+	Content   string    `gorm:"type:text;not null" json:"content"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_comment_revision_comment" json:"created_at"`
+}
+
+// recordCommentRevision keeps the content of comment before an edit by editorID within tx, and
+// prunes the comment's revisions beyond MaxCommentRevisions.
+func recordCommentRevision(tx *gorm.DB, comment *Comment, editorID uuid.UUID) error {
+	revision := &CommentRevision{CommentID: comment.ID, EditorID: editorID, Content: comment.Content}
+	if err := tx.Create(revision).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record comment revision")
+	}
+	kept := tx.Model(&CommentRevision{}).Select("id").Where("comment_id = ?", comment.ID).Order("created_at DESC").Limit(MaxCommentRevisions)
+	if err := tx.Where("comment_id = ? AND id NOT IN (?)", comment.ID, kept).Delete(&CommentRevision{}).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to prune comment revisions")
+	}
+	return nil
+}
+
+// PostHistoryEntry is a saved version of a post with what changed from the version before it:
+// the fields that differ and a unified diff of the content. The oldest kept version is diffed
+// against nothing.
+type PostHistoryEntry struct {
+	PostRevision
+	Changes []string `json:"changes"`
+	Diff    string   `json:"diff"`
+}
+
+// CommentHistoryEntry is a version of a comment with a unified diff from the version before it.
+// The current content comes first, with Current set, and the original content last.
+type CommentHistoryEntry struct {
+	ID        uuid.UUID  `json:"id"`
+	EditorID  *uuid.UUID `json:"editor_id"`
+	Content   string     `json:"content"`
+	Current   bool       `json:"current"`
+	Diff      string     `json:"diff"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// revisionName labels a version in a diff.
+func revisionName(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return "/dev/null"
+	}
+	return id.String()
+}
+
+// changedFields lists the fields of a post revision that differ from the one before it.
+func changedFields(prev, r *PostRevision) []string {
+	changes := []string{}
+	if prev.Title != r.Title {
+		changes = append(changes, "title")
+	}
+	if prev.Content != r.Content {
+		changes = append(changes, "content")
+	}
+	if prev.Excerpt != r.Excerpt {
+		changes = append(changes, "excerpt")
+	}
+	if prev.FeaturedImageURL != r.FeaturedImageURL {
+		changes = append(changes, "cover_image_url")
+	}
+	if prev.CanonicalURL != r.CanonicalURL {
+		changes = append(changes, "canonical_url")
+	}
+	return changes
+}
+
+// GetPostHistory returns a page of a post's saved versions, newest first, each with what changed
+// from the version before it.
+func GetPostHistory(ctx context.Context, db *gorm.DB, postID uuid.UUID, page, limit int) ([]PostHistoryEntry, int64, error) {
+	query := db.WithContext(ctx).Model(&PostRevision{}).Where("post_id = ?", postID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count post revisions")
+	}
+
+	// One more revision than the page is read: the one the last of the page is diffed against
+	revisions := []PostRevision{}
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit + 1).Find(&revisions).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch post revisions")
+	}
+
+	entries := []PostHistoryEntry{}
+	for i := 0; i < len(revisions) && i < limit; i++ {
+		prev := &PostRevision{}
+		if i+1 < len(revisions) {
+			prev = &revisions[i+1]
+		}
+		r := revisions[i]
+		entries = append(entries, PostHistoryEntry{
+			PostRevision: r,
+			Changes:      changedFields(prev, &r),
+			Diff:         diff.Unified(revisionName(prev.ID), revisionName(r.ID), prev.Content, r.Content, historyDiffContext),
+		})
+	}
+	return entries, total, nil
+}
+
+// GetCommentHistory returns the versions of a comment, newest first, each with a diff from the
+// version before it.
+func GetCommentHistory(ctx context.Context, db *gorm.DB, comment *Comment) ([]CommentHistoryEntry, error) {
+	revisions := []CommentRevision{}
+	if err := db.WithContext(ctx).Where("comment_id = ?", comment.ID).Order("created_at DESC").Find(&revisions).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch comment revisions")
+	}
+
+	// Each revision holds the content an edit replaced, so a version was written by the editor
+	// of the revision after it, and the original by the author.
+	versions := make([]CommentHistoryEntry, 0, len(revisions)+1)
+	updatedAt := comment.CreatedAt
+	if comment.EditedAt != nil {
+		updatedAt = *comment.EditedAt
+	}
+	versions = append(versions, CommentHistoryEntry{ID: comment.ID, Content: comment.Content, Current: true, CreatedAt: updatedAt})
+	for i, r := range revisions {
+		editorID := r.EditorID
+		versions[i].EditorID = &editorID
+		versions = append(versions, CommentHistoryEntry{ID: r.ID, Content: r.Content})
+		if i+1 < len(revisions) {
+			versions[i+1].CreatedAt = revisions[i+1].CreatedAt
+		} else {
+			versions[i+1].CreatedAt = comment.CreatedAt
+		}
+	}
+	last := &versions[len(versions)-1]
+	if last.EditorID == nil {
+		last.EditorID = &comment.AuthorID
+	}
+
+	for i := range versions {
+		prevID, prevContent := uuid.Nil, ""
+		if i+1 < len(versions) {
+			prevID, prevContent = versions[i+1].ID, versions[i+1].Content
+		}
+		versions[i].Diff = diff.Unified(revisionName(prevID), revisionName(versions[i].ID), prevContent, versions[i].Content, historyDiffContext)
+	}
+	return versions, nil
+}
@@ -0,0 +1,413 @@
+package models
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/uploads"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Data export states.
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportReady      = "ready"
+	ExportFailed     = "failed"
+)
+
+const (
+	// exportQueue is the Redis list export jobs are queued on.
+	exportQueue = "exports:queue"
+	// ExportRetention is how long a finished archive can be downloaded.
+	ExportRetention = 7 * 24 * time.Hour
+	// ExportCooldown is how long a user waits before requesting a fresh archive.
+	ExportCooldown = 24 * time.Hour
+	// exportStale is how long a job may sit unclaimed before the worker picks it up without a queue
+	// entry, which covers jobs lost with a Redis restart.
+	exportStale = 10 * time.Minute
+	// ExportKeyPrefix starts the storage keys of export archives, which are only ever served to
+	// their owner.
+	ExportKeyPrefix = "exports/"
+)
+
+// DataExport is a user's request for a copy of their personal data. Once built, the archive is kept
+// in upload storage under ArchiveKey.
+type DataExport struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Status      string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	ArchiveKey  string     `gorm:"size:255" json:"-"`
+	Size        int64      `gorm:"default:0" json:"size"`
+	Error       string     `gorm:"size:255" json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// exportPost, exportComment and exportReaction are the exported columns of a user's content.
+type exportPost struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Content     string     `json:"content"`
+	Excerpt     string     `json:"excerpt"`
+	Status      string     `json:"status"`
+	Published   bool       `json:"published"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type exportComment struct {
+	ID              uuid.UUID  `json:"id"`
+	PostID          uuid.UUID  `json:"post_id"`
+	ParentCommentID *uuid.UUID `json:"parent_comment_id"`
+	Content         string     `json:"content"`
+	Edited          bool       `json:"edited"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type exportReaction struct {
+	ID            uuid.UUID `json:"id"`
+	PostID        uuid.UUID `json:"post_id"`
+	ReactableID   uuid.UUID `json:"reactable_id"`
+	ReactableType string    `json:"reactable_type"`
+	Type          string    `json:"type"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ExportNotifier tells a user, in their locale, their archive is ready; it is supplied by the API
+// layer which owns the mail settings.
+type ExportNotifier func(ctx context.Context, export *DataExport, email, username, locale string) error
+
+// RequestDataExport queues an archive of a user's data. A job still in progress, or an archive
+// built within the cooldown, is returned instead of queueing another.
+func RequestDataExport(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID uuid.UUID) (*DataExport, bool, error) {
+	var latest DataExport
+	err := db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch data export")
+	}
+	if err == nil {
+		switch latest.Status {
+		case ExportPending, ExportProcessing:
+			return &latest, false, nil
+		case ExportReady:
+			if time.Since(latest.CreatedAt) < ExportCooldown && latest.ExpiresAt != nil && latest.ExpiresAt.After(time.Now()) {
+				return &latest, false, nil
+			}
+		}
+	}
+
+	export := &DataExport{UserID: userID, Status: ExportPending}
+	if err := db.WithContext(ctx).Create(export).Error; err != nil {
+		return nil, false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create data export")
+	}
+	// A failed push is picked up later as a stale job.
+	rclient.LPush(ctx, exportQueue, export.ID.String())
+	return export, true, nil
+}
+
+// GetDataExport retrieves an export of a user.
+func GetDataExport(ctx context.Context, db *gorm.DB, userID, id uuid.UUID) (*DataExport, error) {
+	var export DataExport
+	if err := db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&export).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Data export not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch data export")
+	}
+	return &export, nil
+}
+
+// OpenDataExportArchive opens the archive of a ready, unexpired export of a user for reading. The
+// caller closes the archive.
+func OpenDataExportArchive(ctx context.Context, db *gorm.DB, store uploads.Driver, userID, id uuid.UUID) (*DataExport, io.ReadCloser, error) {
+	export, err := GetDataExport(ctx, db, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.Status != ExportReady {
+		return nil, nil, utils.NewError(utils.ErrConflict.Code, "Data export is not ready yet")
+	}
+	if export.ExpiresAt == nil || export.ExpiresAt.Before(time.Now()) || export.ArchiveKey == "" {
+		return nil, nil, utils.NewError(utils.ErrNotFound.Code, "Data export has expired")
+	}
+	archive, err := store.Open(ctx, export.ArchiveKey)
+	if err != nil {
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to open data export archive")
+	}
+	return export, archive, nil
+}
+
+// NextDataExport waits up to timeout for a queued export and returns its ID. When the queue stays
+// empty it falls back to the oldest stale pending job, and returns uuid.Nil when there is none.
+func NextDataExport(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, timeout time.Duration) (uuid.UUID, error) {
+	res, err := rclient.BRPop(ctx, timeout, exportQueue).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return uuid.Nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to read export queue")
+	}
+	if len(res) == 2 {
+		if id, err := uuid.Parse(res[1]); err == nil {
+			return id, nil
+		}
+	}
+
+	var ids []uuid.UUID
+	if err := db.WithContext(ctx).Model(&DataExport{}).
+		Where("status = ? AND created_at <= ?", ExportPending, time.Now().Add(-exportStale)).
+		Order("created_at").Limit(1).Pluck("id", &ids).Error; err != nil {
+		return uuid.Nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch pending exports")
+	}
+	if len(ids) == 0 {
+		return uuid.Nil, nil
+	}
+	return ids[0], nil
+}
+
+// ProcessDataExport builds the archive of a queued export, keeps it in store and notifies its
+// owner. The archive is written to a temporary file rather than memory. The job is claimed first
+// so each export is built once even with several workers; it reports false when another worker
+// had it.
+func ProcessDataExport(ctx context.Context, db *gorm.DB, store uploads.Driver, id uuid.UUID, notify ExportNotifier) (bool, error) {
+	claim := db.WithContext(ctx).Model(&DataExport{}).
+		Where("id = ? AND status = ?", id, ExportPending).
+		Update("status", ExportProcessing)
+	if claim.Error != nil {
+		return false, utils.WrapError(claim.Error, utils.ErrInternalServerError.Code, "Failed to claim data export")
+	}
+	if claim.RowsAffected == 0 {
+		return false, nil
+	}
+
+	var export DataExport
+	if err := db.WithContext(ctx).Where("id = ?", id).First(&export).Error; err != nil {
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch data export")
+	}
+	var u user.User
+	if err := db.WithContext(ctx).Where("id = ?", export.UserID).First(&u).Error; err != nil {
+		failDataExport(ctx, db, id, "Account not found")
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch user")
+	}
+
+	archive, err := os.CreateTemp("", "devpulse-export-*.zip")
+	if err != nil {
+		failDataExport(ctx, db, id, "Failed to assemble data")
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create archive")
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if err := writeDataExport(ctx, db, &u, archive); err != nil {
+		failDataExport(ctx, db, id, "Failed to assemble data")
+		return false, err
+	}
+	size, err := archive.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = archive.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		failDataExport(ctx, db, id, "Failed to assemble data")
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to read archive")
+	}
+	key := ExportKeyPrefix + export.UserID.String() + "/" + export.ID.String() + ".zip"
+	if err := store.PutStream(ctx, key, "application/zip", archive); err != nil {
+		failDataExport(ctx, db, id, "Failed to store archive")
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to store data export")
+	}
+
+	now := time.Now()
+	expires := now.Add(ExportRetention)
+	if err := db.WithContext(ctx).Model(&DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       ExportReady,
+		"archive_key":  key,
+		"size":         size,
+		"completed_at": now,
+		"expires_at":   expires,
+	}).Error; err != nil {
+		store.Delete(ctx, key)
+		failDataExport(ctx, db, id, "Failed to store archive")
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to store data export")
+	}
+
+	export.Status = ExportReady
+	export.ArchiveKey = key
+	export.Size = size
+	export.CompletedAt = &now
+	export.ExpiresAt = &expires
+	return true, notify(ctx, &export, u.Email, u.Username, u.Settings.Locale)
+}
+
+func failDataExport(ctx context.Context, db *gorm.DB, id uuid.UUID, reason string) {
+	db.WithContext(ctx).Model(&DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status": ExportFailed,
+		"error":  reason,
+	})
+}
+
+// PurgeDataExports drops exports past their retention along with their archives and returns how
+// many were removed. An export whose archive cannot be deleted is kept for the next purge.
+func PurgeDataExports(ctx context.Context, db *gorm.DB, store uploads.Driver) (int64, error) {
+	var expired []DataExport
+	if err := db.WithContext(ctx).Select("id, archive_key").Where("expires_at < ?", time.Now()).Find(&expired).Error; err != nil {
+		return 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch expired data exports")
+	}
+
+	var purged int64
+	for _, export := range expired {
+		if export.ArchiveKey != "" {
+			if err := store.Delete(ctx, export.ArchiveKey); err != nil {
+				continue
+			}
+		}
+		res := db.WithContext(ctx).Where("id = ?", export.ID).Delete(&DataExport{})
+		if res.Error != nil {
+			return purged, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to purge data exports")
+		}
+		purged += res.RowsAffected
+	}
+	return purged, nil
+}
+
+// writeDataExport writes everything stored about a user to out as a ZIP archive with one JSON file
+// per section. Secrets such as password hashes and two-factor material are left out.
+func writeDataExport(ctx context.Context, db *gorm.DB, u *user.User, out io.Writer) error {
+	tx := db.WithContext(ctx)
+
+	var roleName string
+	tx.Model(&user.Role{}).Where("id = ?", u.RoleID).Pluck("name", &roleName)
+	profile := map[string]interface{}{
+		"id":                 u.ID,
+		"username":           u.Username,
+		"email":              u.Email,
+		"role":               roleName,
+		"status":             u.Status,
+		"is_email_verified":  u.IsEmailVerified,
+		"two_factor_enabled": u.TwoFactorEnabled,
+		"last_login_at":      u.LastLoginAt,
+		"last_login_ip":      u.LastLoginIP,
+		"created_at":         u.CreatedAt,
+		"updated_at":         u.UpdatedAt,
+		"profile":            u.Profile,
+		"stats":              u.Stats,
+	}
+
+	var prefs []user.NotificationPreferences
+	if err := tx.Where("user_id = ?", u.ID).Find(&prefs).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch notification preferences")
+	}
+	settings := map[string]interface{}{
+		"appearance": u.Settings,
+	}
+	if len(prefs) > 0 {
+		settings["notifications"] = prefs[0]
+	}
+
+	followers := []user.UserSummary{}
+	following := []user.UserSummary{}
+	summary := "users.id, users.username, users.name, users.avatar_url, users.bio, users.job_title, users.location, users.skills"
+	if err := tx.Model(&user.User{}).Select(summary).
+		Joins("JOIN user_followers ON user_followers.follower_id = users.id").
+		Where("user_followers.following_id = ?", u.ID).Scan(&followers).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch followers")
+	}
+	if err := tx.Model(&user.User{}).Select(summary).
+		Joins("JOIN user_followers ON user_followers.following_id = users.id").
+		Where("user_followers.follower_id = ?", u.ID).Scan(&following).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch followed users")
+	}
+
+	// Posts, comments, reactions and notifications grow without bound, so they are copied into the
+	// archive a row at a time rather than loaded whole.
+	zw := zip.NewWriter(out)
+	sections := []struct {
+		name string
+		fail string
+		// write encodes the section, which is either data or the rows of a query.
+		write func(w io.Writer) error
+	}{
+		{"profile.json", "Failed to write archive", encodeSection(profile)},
+		{"settings.json", "Failed to write archive", encodeSection(settings)},
+		{"posts.json", "Failed to fetch posts", streamSection[exportPost](tx.Model(&Posts{}).Where("author_id = ?", u.ID).Order("created_at"))},
+		{"comments.json", "Failed to fetch comments", streamSection[exportComment](tx.Model(&Comment{}).Where("author_id = ?", u.ID).Order("created_at"))},
+		{"reactions.json", "Failed to fetch reactions", streamSection[exportReaction](tx.Model(&Reaction{}).Where("user_id = ?", u.ID).Order("created_at"))},
+		{"followers.json", "Failed to write archive", encodeSection(map[string]interface{}{"followers": followers, "following": following})},
+		{"notifications.json", "Failed to fetch notifications", streamSection[user.Notification](tx.Model(&user.Notification{}).Where("user_id = ?", u.ID).Order("created_at"))},
+		{"archived_notifications.json", "Failed to fetch archived notifications", streamSection[user.NotificationArchive](tx.Model(&user.NotificationArchive{}).Where("user_id = ?", u.ID).Order("created_at"))},
+	}
+	for _, section := range sections {
+		w, err := zw.Create(section.name)
+		if err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to write archive")
+		}
+		if err := section.write(w); err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, section.fail)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to write archive")
+	}
+	return nil
+}
+
+// encodeSection writes data as indented JSON.
+func encodeSection(data interface{}) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	}
+}
+
+// streamSection writes the rows of query as an indented JSON array of T, reading and encoding one
+// row at a time.
+func streamSection[T any](query *gorm.DB) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		rows, err := query.Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		sep := "[\n  "
+		for rows.Next() {
+			var row T
+			if err := query.ScanRows(rows, &row); err != nil {
+				return err
+			}
+			data, err := json.MarshalIndent(&row, "  ", "  ")
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, sep); err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			sep = ",\n  "
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		end := "\n]\n"
+		if sep == "[\n  " {
+			end = "[]\n"
+		}
+		_, err = io.WriteString(w, end)
+		return err
+	}
+}
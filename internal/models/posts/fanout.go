@@ -0,0 +1,122 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FanoutBatchSize is how many followers one fan-out batch notifies.
+const FanoutBatchSize = 500
+
+// NotificationFanout tracks how far the followers of a post's author were notified of the post.
+// Followers are notified in batches by the outbox, after publishing committed, so publishing does
+// not wait on them; Cursor, the last follower notified, makes every batch run once only.
+type NotificationFanout struct {
+	PostID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"post_id"`
+	Cursor    uuid.UUID `gorm:"type:uuid;not null" json:"cursor"`
+	Notified  int       `gorm:"not null;default:0" json:"notified"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// enqueueFanout queues the notification of the author's followers of a post published within tx.
+func enqueueFanout(ctx context.Context, tx *gorm.DB, postID uuid.UUID) error {
+	return user.EnqueueOutbox(ctx, tx, user.OutboxPostFanout, user.PostFanoutEvent{PostID: postID})
+}
+
+// FanoutPost notifies the next batch of the author's followers, those after the follower after,
+// of a published post, and queues the batch after it. Followers are only notified the first time
+// a post is published, and not of unlisted posts or of authors they blocked or muted.
+func FanoutPost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, postID, after uuid.UUID) error {
+	var batch *user.NotificationBatch
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var fanout NotificationFanout
+		if after == uuid.Nil {
+			fanout.PostID = postID
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&fanout)
+			if res.Error != nil {
+				return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to start notification fan-out")
+			}
+			if res.RowsAffected == 0 {
+				return nil
+			}
+		} else {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("post_id = ?", postID).First(&fanout).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return nil
+				}
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get notification fan-out")
+			}
+			if fanout.Cursor != after {
+				return nil
+			}
+		}
+
+		var post Posts
+		if err := tx.Select("id, title, author_id, published, visibility").Where("id = ?", postID).First(&post).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get post")
+		}
+		if !post.Published || post.Visibility == VisibilityUnlisted {
+			return nil
+		}
+		var author string
+		if err := tx.Model(&user.User{}).Select("username").Where("id = ?", post.AuthorID).Scan(&author).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get post author")
+		}
+
+		var followers []uuid.UUID
+		if err := tx.Table("user_followers").
+			Where("following_id = ? AND follower_id > ?", post.AuthorID, after).
+			Where("NOT EXISTS (SELECT 1 FROM user_blocks WHERE user_blocks.user_id = user_followers.follower_id AND user_blocks.target_id = ?)", post.AuthorID).
+			Order("follower_id").
+			Limit(FanoutBatchSize).
+			Pluck("follower_id", &followers).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get followers")
+		}
+		if len(followers) == 0 {
+			return nil
+		}
+
+		inputs := make([]user.NotificationInput, len(followers))
+		for i, id := range followers {
+			inputs[i] = user.NotificationInput{UserID: id, Type: user.NotificationNewPost, Message: "%s published a new post: %s", Args: []interface{}{author, post.Title}}
+		}
+		built, err := user.BuildNotifications(ctx, tx, inputs)
+		if err != nil {
+			return err
+		}
+		if err := built.Insert(ctx, tx); err != nil {
+			return err
+		}
+		batch = built
+
+		last := followers[len(followers)-1]
+		if err := tx.Model(&fanout).Updates(map[string]interface{}{
+			"cursor":   last,
+			"notified": gorm.Expr("notified + ?", len(followers)),
+		}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record notification fan-out")
+		}
+		if len(followers) < FanoutBatchSize {
+			return nil
+		}
+		return user.EnqueueOutbox(ctx, tx, user.OutboxPostFanout, user.PostFanoutEvent{PostID: postID, After: last})
+	})
+	if err != nil {
+		return err
+	}
+	if batch != nil {
+		batch.Publish(ctx, rclient)
+	}
+	return nil
+}
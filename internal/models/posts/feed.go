@@ -0,0 +1,177 @@
+package models
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// feedWindow is how far back the feed looks for posts.
+	feedWindow = 30 * 24 * time.Hour
+	// feedSize caps how many ranked posts a precomputed feed holds.
+	feedSize = 500
+)
+
+// FeedEntry is one ranked post of a precomputed feed.
+type FeedEntry struct {
+	PostID uuid.UUID `json:"post_id"`
+	Score  float64   `json:"score"`
+}
+
+// feedScore ranks a post by engagement decayed over its age, so fresh posts lead unless older
+// ones drew clearly more interest.
+func feedScore(publishedAt time.Time, reactions, comments, bookmarks int) float64 {
+	engagement := 1 + float64(reactions) + 2*float64(comments) + 3*float64(bookmarks)
+	hours := time.Since(publishedAt).Hours()
+	if hours < 0 {
+		hours = 0
+	}
+	return engagement / math.Pow(hours+2, 1.5)
+}
+
+// buildFeed ranks the recent published posts of the authors and tags a user follows, leaving out
+// authors the user blocked or muted. It also returns the followed authors, whose publishing
+// invalidates the feed.
+func buildFeed(ctx context.Context, db *gorm.DB, userID uuid.UUID) ([]FeedEntry, []uuid.UUID, error) {
+	var authors []uuid.UUID
+	if err := db.WithContext(ctx).Table("user_followers").Where("follower_id = ?", userID).Pluck("following_id", &authors).Error; err != nil {
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch followed users")
+	}
+
+	var rows []struct {
+		ID             uuid.UUID
+		PublishedAt    *time.Time
+		ReactionsCount int
+		CommentsCount  int
+		BookmarksCount int
+	}
+	followedTags := db.Model(&TagFollower{}).Select("tag_id").Where("user_id = ?", userID)
+	taggedPosts := db.Table("post_tags").Select("posts_id").Where("tag_id IN (?)", followedTags)
+	query := db.WithContext(ctx).Model(&Posts{}).
+		Select("posts.id, posts.published_at, COALESCE(post_analytics.reactions_count, 0) AS reactions_count, COALESCE(post_analytics.comments_count, 0) AS comments_count, COALESCE(post_analytics.bookmarks_count, 0) AS bookmarks_count").
+		Joins("LEFT JOIN post_analytics ON post_analytics.post_id = posts.id").
+		Scopes(ListedTo(&userID)).
+		Where("posts.published_at >= ? AND posts.author_id != ?", time.Now().Add(-feedWindow), userID).
+		Where("posts.author_id NOT IN (?)", user.HiddenBy(db, userID))
+	if len(authors) > 0 {
+		query = query.Where("posts.author_id IN ? OR posts.id IN (?)", authors, taggedPosts)
+	} else {
+		query = query.Where("posts.id IN (?)", taggedPosts)
+	}
+	if err := query.Order("posts.published_at DESC").Limit(feedSize).Scan(&rows).Error; err != nil {
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to build feed")
+	}
+
+	entries := make([]FeedEntry, 0, len(rows))
+	for _, row := range rows {
+		if row.PublishedAt == nil {
+			continue
+		}
+		entries = append(entries, FeedEntry{
+			PostID: row.ID,
+			Score:  feedScore(*row.PublishedAt, row.ReactionsCount, row.CommentsCount, row.BookmarksCount),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Score > entries[j].Score })
+	return entries, authors, nil
+}
+
+// feedCursor points after entry; it carries the score so a page still resolves when the feed was
+// rebuilt and the entry dropped out.
+func feedCursor(entry FeedEntry) utils.Cursor {
+	return utils.Cursor{Key: strconv.FormatFloat(entry.Score, 'g', -1, 64), ID: entry.PostID.String()}
+}
+
+// GetFeed returns a page of a user's home feed. The ranked feed is precomputed in Redis and rebuilt when a followed author publishes, the
+// user's follows change, or it expires.
+func GetFeed(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID uuid.UUID, p utils.Pagination) (utils.Page[Posts], error) {
+	entries, err := cache.Fetch(ctx, rclient, cache.FeedKey(userID), rclient.TTL("feed"), func(ctx context.Context) ([]FeedEntry, []string, error) {
+		entries, authors, err := buildFeed(ctx, db, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		tags := []string{cache.UserTag(userID)}
+		for _, author := range authors {
+			tags = append(tags, cache.FeedAuthorTag(author))
+		}
+		return entries, tags, nil
+	})
+	if err != nil {
+		return utils.Page[Posts]{}, err
+	}
+
+	start := 0
+	if p.After != nil {
+		invalid := utils.NewError(utils.ErrBadRequest.Code, "Invalid cursor")
+		score, err := strconv.ParseFloat(p.After.Key, 64)
+		if err != nil {
+			return utils.Page[Posts]{}, invalid
+		}
+		afterID, err := uuid.Parse(p.After.ID)
+		if err != nil {
+			return utils.Page[Posts]{}, invalid
+		}
+		start = len(entries)
+		for i, entry := range entries {
+			if entry.PostID == afterID {
+				start = i + 1
+				break
+			}
+			if entry.Score < score {
+				start = i
+				break
+			}
+		}
+	}
+	end := start + p.Limit
+	if end > len(entries) {
+		end = len(entries)
+	}
+	page := entries[start:end]
+
+	result := utils.Page[Posts]{Items: []Posts{}}
+	if end < len(entries) && len(page) > 0 {
+		next := feedCursor(page[len(page)-1])
+		result.Next = &next
+	}
+	if p.WithTotal {
+		total := int64(len(entries))
+		result.Total = &total
+	}
+	if len(page) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uuid.UUID, len(page))
+	for i, entry := range page {
+		ids[i] = entry.PostID
+	}
+	var found []Posts
+	if err := db.WithContext(ctx).Preload("Tags").Preload("Author", authorSummary).
+		Where("posts.id IN ?", ids).Scopes(ListedTo(&userID)).
+		Find(&found).Error; err != nil {
+		return utils.Page[Posts]{}, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch posts")
+	}
+
+	// Keep the ranking; posts unpublished or hidden from the user since the feed was built are skipped.
+	byID := make(map[uuid.UUID]Posts, len(found))
+	for _, post := range found {
+		byID[post.ID] = post
+	}
+	for _, id := range ids {
+		if post, ok := byID[id]; ok {
+			result.Items = append(result.Items, post)
+		}
+	}
+	return result, nil
+}
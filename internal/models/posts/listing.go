@@ -0,0 +1,266 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Listing categories.
+const (
+	ListingEvents  = "events"
+	ListingJobs    = "jobs"
+	ListingCollabs = "collabs"
+)
+
+// ListingCategories lists the categories a listing may be filed under.
+var ListingCategories = []string{ListingEvents, ListingJobs, ListingCollabs}
+
+// ListingLifetime is how long a listing stays up, from when it is created or last renewed.
+const ListingLifetime = 30 * 24 * time.Hour
+
+// MaxActiveListings is how many unexpired listings a user may have at once.
+const MaxActiveListings = 5
+
+// Listing is a short classified on the listings board, such as an event, a job posting or a call
+// for collaborators. It is shown until ExpiresAt unless its author renews it; expired listings are
+// kept for their author to renew.
+type Listing struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	AuthorID   uuid.UUID `gorm:"type:uuid;not null;index:idx_listing_author" json:"author_id" validate:"required"`
+	Category   string    `gorm:"size:20;not null;index:idx_listing_category_expires,priority:1" json:"category" validate:"required,oneof=events jobs collabs"`
+	Title      string    `gorm:"size:128;not null" json:"title" validate:"required,min=5,max=128"`
+	Body       string    `gorm:"type:text;not null" json:"body" validate:"required,max=2000"`
+	Tags       []string  `gorm:"type:text[];index:idx_listing_tags,gin" json:"tags" validate:"max=4,dive,max=30"`
+	Location   string    `gorm:"size:100" json:"location" validate:"omitempty,max=100"`
+	ContactURL string    `gorm:"size:500" json:"contact_url" validate:"omitempty,url,max=500"`
+	ExpiresAt  time.Time `gorm:"not null;index:idx_listing_category_expires,priority:2;index:idx_listing_expires" json:"expires_at"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Author user.User `gorm:"foreignKey:AuthorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"author" validate:"-"`
+}
+
+// ListingOption configures a Listing.
+type ListingOption func(*Listing)
+
+// Expired reports whether the listing is no longer shown on the board.
+func (l *Listing) Expired() bool {
+	return !l.ExpiresAt.After(time.Now())
+}
+
+// IsListingCategory reports whether category is one a listing may be filed under.
+func IsListingCategory(category string) bool {
+	for _, c := range ListingCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeListingTags lowercases and trims tags, dropping empty and repeated ones.
+func normalizeListingTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// CreateListing puts a listing on the board until ttl from now, capped at ListingLifetime; a zero
+// ttl gives it the full lifetime. A user may have MaxActiveListings unexpired listings at most.
+func CreateListing(ctx context.Context, db *gorm.DB, listing *Listing, ttl time.Duration) error {
+	listing.Title = strings.TrimSpace(listing.Title)
+	listing.Body = strings.TrimSpace(listing.Body)
+	listing.Location = strings.TrimSpace(listing.Location)
+	listing.ContactURL = strings.TrimSpace(listing.ContactURL)
+	listing.Tags = normalizeListingTags(listing.Tags)
+	if listing.Title == "" || listing.Body == "" || listing.AuthorID == uuid.Nil {
+		return utils.NewError(utils.ErrBadRequest.Code, "Required fields missing: title, body, author_id")
+	}
+	if !IsListingCategory(listing.Category) {
+		return utils.NewError(utils.ErrBadRequest.Code, "Invalid listing category")
+	}
+	if ttl <= 0 || ttl > ListingLifetime {
+		ttl = ListingLifetime
+	}
+	listing.ExpiresAt = time.Now().Add(ttl)
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkActiveListings(tx, listing.AuthorID); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(listing).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create listing")
+		}
+		return nil
+	})
+}
+
+// GetListing retrieves a listing with its author by ID, expired or not.
+func GetListing(ctx context.Context, db *gorm.DB, id uuid.UUID) (*Listing, error) {
+	var listing Listing
+	if err := db.WithContext(ctx).Preload("Author", authorSummary).Where("id = ?", id).First(&listing).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Listing not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch listing")
+	}
+	return &listing, nil
+}
+
+// UpdateListing edits a listing's category, title, body, tags, location or contact link. Its
+// expiry only moves with RenewListing.
+func UpdateListing(ctx context.Context, db *gorm.DB, id uuid.UUID, opts ...ListingOption) (*Listing, error) {
+	var listing *Listing
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if listing, err = lockListing(tx, id); err != nil {
+			return err
+		}
+		expiresAt := listing.ExpiresAt
+		for _, opt := range opts {
+			opt(listing)
+		}
+		listing.ExpiresAt = expiresAt
+		listing.Tags = normalizeListingTags(listing.Tags)
+		if listing.Title == "" || listing.Body == "" {
+			return utils.NewError(utils.ErrBadRequest.Code, "Title and body cannot be empty")
+		}
+		if !IsListingCategory(listing.Category) {
+			return utils.NewError(utils.ErrBadRequest.Code, "Invalid listing category")
+		}
+		if err := tx.Omit(clause.Associations).Save(listing).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update listing")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetListing(ctx, db, id)
+}
+
+// RenewListing puts a listing back on the board for a full ListingLifetime from now. Renewing an
+// expired listing counts against its author's MaxActiveListings.
+func RenewListing(ctx context.Context, db *gorm.DB, id uuid.UUID) (*Listing, error) {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		listing, err := lockListing(tx, id)
+		if err != nil {
+			return err
+		}
+		if listing.Expired() {
+			if err := checkActiveListings(tx, listing.AuthorID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(listing).UpdateColumns(map[string]interface{}{
+			"expires_at": time.Now().Add(ListingLifetime),
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to renew listing")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetListing(ctx, db, id)
+}
+
+// DeleteListing takes a listing off the board for good.
+func DeleteListing(ctx context.Context, db *gorm.DB, id uuid.UUID) error {
+	res := db.WithContext(ctx).Delete(&Listing{}, "id = ?", id)
+	if res.Error != nil {
+		return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to delete listing")
+	}
+	if res.RowsAffected == 0 {
+		return utils.NewError(utils.ErrNotFound.Code, "Listing not found")
+	}
+	return nil
+}
+
+// GetListings retrieves a page of the board's unexpired listings, newest first, optionally of one
+// category or carrying one tag.
+func GetListings(ctx context.Context, db *gorm.DB, category, tag string, page, limit int) ([]Listing, int64, error) {
+	query := db.WithContext(ctx).Model(&Listing{}).Where("expires_at > ?", time.Now())
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+		query = query.Where("tags @> ARRAY[?]::text[]", tag)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count listings")
+	}
+
+	listings := []Listing{}
+	if err := query.Preload("Author", authorSummary).Order("created_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&listings).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch listings")
+	}
+	return listings, total, nil
+}
+
+// GetAuthorListings retrieves a page of an author's listings, expired ones included, newest first.
+func GetAuthorListings(ctx context.Context, db *gorm.DB, authorID uuid.UUID, page, limit int) ([]Listing, int64, error) {
+	query := db.WithContext(ctx).Model(&Listing{}).Where("author_id = ?", authorID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count listings")
+	}
+
+	listings := []Listing{}
+	if err := query.Order("created_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&listings).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch listings")
+	}
+	return listings, total, nil
+}
+
+// checkActiveListings fails when the author already has MaxActiveListings unexpired listings. The
+// author's row is locked so concurrent requests cannot both slip under the cap.
+func checkActiveListings(tx *gorm.DB, authorID uuid.UUID) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", authorID).First(&user.User{}).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return utils.NewError(utils.ErrNotFound.Code, "User not found")
+		}
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch user")
+	}
+	var active int64
+	if err := tx.Model(&Listing{}).Where("author_id = ? AND expires_at > ?", authorID, time.Now()).Count(&active).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count listings")
+	}
+	if active >= MaxActiveListings {
+		return utils.NewError(utils.ErrConflict.Code, "Too many active listings")
+	}
+	return nil
+}
+
+func lockListing(tx *gorm.DB, id uuid.UUID) (*Listing, error) {
+	var listing Listing
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&listing).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Listing not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch listing")
+	}
+	return &listing, nil
+}
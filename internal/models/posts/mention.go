@@ -0,0 +1,206 @@
+package models
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/i18n"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// Mention source types.
+const (
+	MentionSourcePost    = "post"
+	MentionSourceComment = "comment"
+)
+
+// mentionPattern matches @username where usernames are alphanumeric, as enforced on registration.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@])@([A-Za-z0-9]{3,255})`)
+
+type Mention struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index:idx_mention_user;uniqueIndex:idx_mention_source_user" json:"user_id"`
+	SourceType string    `gorm:"size:20;not null;uniqueIndex:idx_mention_source_user" json:"source_type"`
+	SourceID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_mention_source_user" json:"source_id"`
+	PostID     uuid.UUID `gorm:"type:uuid;not null;index" json:"post_id"`
+	AuthorID   uuid.UUID `gorm:"type:uuid;not null" json:"author_id"`
+	Excerpt    string    `gorm:"size:200" json:"excerpt"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// ExtractMentions returns the distinct usernames @-mentioned in content, in order of appearance.
+func ExtractMentions(content string) []string {
+	seen := map[string]bool{}
+	usernames := []string{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		name := strings.ToLower(match[1])
+		if !seen[name] {
+			seen[name] = true
+			usernames = append(usernames, name)
+		}
+	}
+	return usernames
+}
+
+// mentionExcerpt returns a short snippet of content around the first mention of username.
+func mentionExcerpt(content, username string) string {
+	idx := strings.Index(strings.ToLower(content), "@"+username)
+	if idx < 0 || idx > len(content) {
+		idx = 0
+	}
+	start := idx - 60
+	if start < 0 {
+		start = 0
+	}
+	runes := []rune(content[start:])
+	if len(runes) > 200 {
+		runes = runes[:200]
+	}
+	return strings.TrimSpace(string(runes))
+}
+
+// SyncMentions makes the mention records of a post or comment match its content and returns
+// the users that were newly mentioned. Authors mentioning themselves, or users who blocked them,
+// are ignored.
+func SyncMentions(ctx context.Context, tx *gorm.DB, sourceType string, sourceID, postID, authorID uuid.UUID, content string) ([]uuid.UUID, error) {
+	usernames := ExtractMentions(content)
+
+	var mentioned []struct {
+		ID       uuid.UUID
+		Username string
+	}
+	if len(usernames) > 0 {
+		if err := tx.WithContext(ctx).Model(&user.User{}).
+			Select("id, LOWER(username) AS username").
+			Where("LOWER(username) IN ? AND id != ?", usernames, authorID).
+			Where("id NOT IN (?)", user.BlockersOf(tx, authorID)).
+			Scan(&mentioned).Error; err != nil {
+			return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to resolve mentions")
+		}
+	}
+
+	var existing []Mention
+	if err := tx.WithContext(ctx).Where("source_type = ? AND source_id = ?", sourceType, sourceID).Find(&existing).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get mentions")
+	}
+
+	current := make(map[uuid.UUID]bool, len(mentioned))
+	for _, m := range mentioned {
+		current[m.ID] = true
+	}
+	previous := make(map[uuid.UUID]bool, len(existing))
+	var removed []uuid.UUID
+	for _, m := range existing {
+		previous[m.UserID] = true
+		if !current[m.UserID] {
+			removed = append(removed, m.UserID)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := tx.WithContext(ctx).Where("source_type = ? AND source_id = ? AND user_id IN ?", sourceType, sourceID, removed).
+			Delete(&Mention{}).Error; err != nil {
+			return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to remove mentions")
+		}
+	}
+
+	added := []uuid.UUID{}
+	for _, m := range mentioned {
+		if previous[m.ID] {
+			continue
+		}
+		mention := &Mention{
+			UserID:     m.ID,
+			SourceType: sourceType,
+			SourceID:   sourceID,
+			PostID:     postID,
+			AuthorID:   authorID,
+			Excerpt:    mentionExcerpt(content, m.Username),
+		}
+		if err := tx.WithContext(ctx).Create(mention).Error; err != nil {
+			return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to add mention")
+		}
+		added = append(added, m.ID)
+	}
+
+	return added, nil
+}
+
+// MentionEmailer emails a user about a mention made in the post with the given slug, in their
+// locale. The mail settings live with the API, so it is registered at startup through
+// SetMentionEmailer.
+type MentionEmailer func(ctx context.Context, email, username, locale, message, postSlug string)
+
+var mentionEmailer MentionEmailer
+
+// SetMentionEmailer registers the function used to email users who opted into mention emails.
+func SetMentionEmailer(fn MentionEmailer) {
+	mentionEmailer = fn
+}
+
+// notifyMentions notifies users newly mentioned in a post or comment by authorID, unless they
+// muted the author. It runs after the content was committed, so a failed notification never
+// rolls back the edit. Users with EmailOnMentions enabled are emailed as well, in the background.
+func notifyMentions(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, authorID uuid.UUID, userIDs []uuid.UUID, sourceType, title, postSlug string) {
+	userIDs = user.WithoutSilenced(ctx, db, userIDs, authorID)
+	if len(userIDs) == 0 {
+		return
+	}
+	message := "You were mentioned in a " + sourceType + ": %s"
+	for _, userID := range userIDs {
+		user.NewNotification(ctx, rclient, db, userID, user.NotificationMention, message, title)
+	}
+
+	if mentionEmailer == nil {
+		return
+	}
+	var recipients []struct {
+		Email    string
+		Username string
+		Locale   string
+	}
+	if err := db.WithContext(ctx).Model(&user.User{}).
+		Select("users.email, users.username, users.locale").
+		Joins("JOIN notification_preferences ON notification_preferences.user_id = users.id").
+		Where("users.id IN ? AND notification_preferences.email_on_mentions = ?", userIDs, true).
+		Scan(&recipients).Error; err != nil {
+		return
+	}
+	for _, r := range recipients {
+		go mentionEmailer(context.Background(), r.Email, r.Username, r.Locale, i18n.T(r.Locale, message, title), postSlug)
+	}
+}
+
+// DeleteMentions removes every mention record of a post or comment.
+func DeleteMentions(ctx context.Context, tx *gorm.DB, sourceType string, sourceID uuid.UUID) error {
+	if err := tx.WithContext(ctx).Where("source_type = ? AND source_id = ?", sourceType, sourceID).Delete(&Mention{}).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete mentions")
+	}
+	return nil
+}
+
+// GetUserMentions retrieves a page of the mentions of a user, optionally filtered by source type.
+func GetUserMentions(ctx context.Context, db *gorm.DB, userID uuid.UUID, sourceType string, page, limit int) ([]Mention, int64, error) {
+	query := db.WithContext(ctx).Model(&Mention{}).Where("user_id = ?", userID)
+	if sourceType != "" {
+		query = query.Where("source_type = ?", sourceType)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count mentions")
+	}
+
+	mentions := []Mention{}
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&mentions).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get mentions")
+	}
+	return mentions, total, nil
+}
@@ -0,0 +1,238 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxMentionSuggestions caps how many users a mention suggestion returns.
+	MaxMentionSuggestions = 10
+	// mentionCandidates is how many users matching the prefix are ranked for a suggestion.
+	mentionCandidates = 50
+	// mentionWindow is how far back mentions and comments count as recent interaction.
+	mentionWindow = 30 * 24 * time.Hour
+	// mentionRebuildLock is held while the mention index is rebuilt, so one request does it.
+	mentionRebuildLock = "{mention_index}:rebuilding"
+)
+
+// mentionRanking ranks the candidates of a mention suggestion for @viewer: users the viewer
+// follows weigh most, then users following the viewer, then recent interaction, meaning mentions
+// by the viewer and comments either way between the two. Users who blocked the viewer, the viewer
+// and accounts that are not active are left out.
+const mentionRanking = `
+WITH ranked AS (
+	SELECT u.id, u.username, u.name, u.avatar_url, u.bio, u.job_title, u.location, u.skills, u.interests, u.last_seen,
+		EXISTS (SELECT 1 FROM user_followers WHERE follower_id = @viewer AND following_id = u.id) AS following,
+		EXISTS (SELECT 1 FROM user_followers WHERE follower_id = u.id AND following_id = @viewer) AS follows_you,
+		(SELECT count(*) FROM mentions m
+			WHERE m.author_id = @viewer AND m.user_id = u.id AND m.created_at >= @since)
+		+ (SELECT count(*) FROM comments c JOIN posts p ON p.id = c.post_id
+			WHERE c.deleted_at IS NULL AND c.created_at >= @since
+			AND ((c.author_id = u.id AND p.author_id = @viewer) OR (c.author_id = @viewer AND p.author_id = u.id))) AS interactions
+	FROM users u
+	WHERE u.id IN @candidates AND u.deleted_at IS NULL AND u.is_active AND u.status = @active
+		AND u.id <> @viewer
+		AND u.id NOT IN (SELECT user_id FROM user_blocks WHERE target_id = @viewer AND kind = @block)
+)
+SELECT * FROM ranked
+ORDER BY 4 * following::int + 2 * follows_you::int + LEAST(interactions, 5) DESC, length(username), username
+LIMIT @limit`
+
+// MentionSuggestion is a user offered to complete an @-mention, with the signals that ranked them.
+type MentionSuggestion struct {
+	user.UserSummary
+	Following    bool `json:"following"`
+	FollowsYou   bool `json:"follows_you"`
+	Interactions int  `json:"interactions"`
+}
+
+// mentionMember is the member of a user in the mention index.
+func mentionMember(id uuid.UUID, username string) string {
+	return strings.ToLower(username) + ":" + id.String()
+}
+
+// IndexMentionUsers brings the mention index in line with users: active accounts are indexed
+// under their current username, and any other account is dropped.
+func IndexMentionUsers(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var users []struct {
+		ID       uuid.UUID
+		Username string
+	}
+	if err := db.WithContext(ctx).Model(&user.User{}).Select("id, username").
+		Where("id IN ? AND is_active = TRUE AND status = ?", ids, user.UserStatusActive).
+		Scan(&users).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get users to index")
+	}
+	current := make(map[uuid.UUID]string, len(users))
+	for _, u := range users {
+		current[u.ID] = mentionMember(u.ID, u.Username)
+	}
+
+	fields := make([]string, len(ids))
+	for i, id := range ids {
+		fields[i] = id.String()
+	}
+	previous, err := rclient.HMGet(ctx, cache.MentionNamesKey, fields...).Result()
+	if err != nil {
+		return err
+	}
+
+	pipe := rclient.TxPipeline()
+	for i, id := range ids {
+		member, indexed := current[id]
+		if old, ok := previous[i].(string); ok && old != member {
+			pipe.ZRem(ctx, cache.MentionIndexKey, old)
+		}
+		if indexed {
+			pipe.ZAdd(ctx, cache.MentionIndexKey, redis.Z{Member: member})
+			pipe.HSet(ctx, cache.MentionNamesKey, id.String(), member)
+		} else {
+			pipe.HDel(ctx, cache.MentionNamesKey, id.String())
+		}
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// RebuildMentionIndex indexes every active account from scratch, aside from the live index, and
+// swaps the result in. Renames made while it runs may be lost until the user changes again.
+func RebuildMentionIndex(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB) error {
+	tmpIndex, tmpNames := cache.MentionIndexKey+":rebuild", cache.MentionNamesKey+":rebuild"
+	if err := rclient.Del(ctx, tmpIndex, tmpNames).Err(); err != nil {
+		return err
+	}
+
+	var batch []struct {
+		ID       uuid.UUID
+		Username string
+	}
+	indexed := 0
+	err := db.WithContext(ctx).Model(&user.User{}).Select("id, username").
+		Where("is_active = TRUE AND status = ?", user.UserStatusActive).
+		FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+			pipe := rclient.Pipeline()
+			for _, u := range batch {
+				member := mentionMember(u.ID, u.Username)
+				pipe.ZAdd(ctx, tmpIndex, redis.Z{Member: member})
+				pipe.HSet(ctx, tmpNames, u.ID.String(), member)
+			}
+			indexed += len(batch)
+			_, err := pipe.Exec(ctx)
+			return err
+		}).Error
+	if err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to rebuild the mention index")
+	}
+	if indexed == 0 {
+		return nil
+	}
+
+	pipe := rclient.TxPipeline()
+	pipe.Rename(ctx, tmpIndex, cache.MentionIndexKey)
+	pipe.Rename(ctx, tmpNames, cache.MentionNamesKey)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// mentionCandidateIDs returns up to mentionCandidates users whose username starts with prefix,
+// from the mention index. A missing index is rebuilt by the first request to notice; the others,
+// and every request while Redis is down, look the prefix up in the database.
+func mentionCandidateIDs(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, prefix string) ([]uuid.UUID, error) {
+	members, err := mentionIndexRange(ctx, rclient, prefix)
+	if err == nil && members == nil {
+		if locked, lockErr := rclient.SetNX(ctx, mentionRebuildLock, 1, time.Minute).Result(); lockErr == nil && locked {
+			err = RebuildMentionIndex(ctx, rclient, db)
+			rclient.Del(ctx, mentionRebuildLock)
+			if err == nil {
+				members, err = mentionIndexRange(ctx, rclient, prefix)
+			}
+		}
+	}
+	if err != nil || members == nil {
+		var ids []uuid.UUID
+		if err := db.WithContext(ctx).Model(&user.User{}).
+			Where("LOWER(username) LIKE ? AND is_active = TRUE AND status = ?", prefix+"%", user.UserStatusActive).
+			Order("LOWER(username)").Limit(mentionCandidates).Pluck("id", &ids).Error; err != nil {
+			return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to find users to mention")
+		}
+		return ids, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		i := strings.LastIndexByte(member, ':')
+		if id, err := uuid.Parse(member[i+1:]); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// mentionIndexRange reads the members of the mention index starting with prefix. It returns nil
+// when the index does not exist.
+func mentionIndexRange(ctx context.Context, rclient *storage.RedisClient, prefix string) ([]string, error) {
+	pipe := rclient.Pipeline()
+	exists := pipe.Exists(ctx, cache.MentionIndexKey)
+	members := pipe.ZRangeByLex(ctx, cache.MentionIndexKey, &redis.ZRangeBy{
+		Min:   "[" + prefix,
+		Max:   "[" + prefix + "\xff",
+		Count: mentionCandidates,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	if exists.Val() == 0 {
+		return nil, nil
+	}
+	return append([]string{}, members.Val()...), nil
+}
+
+// SuggestMentions returns up to limit users whose username starts with prefix for viewerID to
+// @-mention, best first.
+func SuggestMentions(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, viewerID uuid.UUID, prefix string, limit int) ([]MentionSuggestion, error) {
+	prefix = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(prefix), "@"))
+	for _, r := range prefix {
+		if !('a' <= r && r <= 'z' || '0' <= r && r <= '9') {
+			return nil, utils.NewError(utils.ErrBadRequest.Code, "Usernames only contain letters and digits")
+		}
+	}
+	if prefix == "" || len(prefix) > 255 {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Query must be between 1 and 255 characters")
+	}
+	if limit < 1 || limit > MaxMentionSuggestions {
+		limit = MaxMentionSuggestions
+	}
+
+	candidates, err := mentionCandidateIDs(ctx, rclient, db, prefix)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := []MentionSuggestion{}
+	if len(candidates) == 0 {
+		return suggestions, nil
+	}
+	if err := db.WithContext(ctx).Raw(mentionRanking, map[string]interface{}{
+		"viewer":     viewerID,
+		"candidates": candidates,
+		"since":      time.Now().Add(-mentionWindow),
+		"active":     user.UserStatusActive,
+		"block":      user.BlockKindBlock,
+		"limit":      limit,
+	}).Scan(&suggestions).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to rank users to mention")
+	}
+	return suggestions, nil
+}
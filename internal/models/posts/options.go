@@ -63,6 +63,17 @@ func WithCanonicalURL(url string) PostsOption {
 	}
 }
 
+// WithGuestAuthor credits a guest writer; an empty name takes the credit off.
+func WithGuestAuthor(name, url string) PostsOption {
+	return func(p *Posts) {
+		p.GuestAuthorName = name
+		p.GuestAuthorURL = url
+		if name == "" {
+			p.GuestAuthorURL = ""
+		}
+	}
+}
+
 // SEO & Social Metadata
 func WithMetaTitle(title string) PostsOption {
 	return func(p *Posts) {
@@ -174,6 +185,24 @@ func WithPublishedAt(publishedAt *time.Time) PostsOption {
 	}
 }
 
+func WithScheduledAt(scheduledAt *time.Time) PostsOption {
+	return func(p *Posts) {
+		p.ScheduledAt = scheduledAt
+	}
+}
+
+func WithScheduleTimezone(timezone string) PostsOption {
+	return func(p *Posts) {
+		p.ScheduleTimezone = timezone
+	}
+}
+
+func WithVisibility(visibility string) PostsOption {
+	return func(p *Posts) {
+		p.Visibility = visibility
+	}
+}
+
 // Relationships
 func WithTags(tags []Tag) PostsOption {
 	return func(p *Posts) {
@@ -205,12 +234,6 @@ func WithMentions(mentions []user.User) PostsOption {
 	}
 }
 
-func WithCoAuthors(coAuthors []user.User) PostsOption {
-	return func(p *Posts) {
-		p.CoAuthors = coAuthors
-	}
-}
-
 // PostAnalytics
 func WithViewsCount(delta int) PostAnalyticsOption {
 	return func(pa *PostAnalytics) {
@@ -459,3 +482,59 @@ func WithSeriesAnalyticsCompletionRate(rate float64) SeriesAnalyticsOption {
 		sa.CompletionRate = rate
 	}
 }
+
+// Organization Options
+func WithOrganizationID(orgID *uuid.UUID) PostsOption {
+	return func(p *Posts) {
+		p.OrganizationID = orgID
+	}
+}
+
+func WithOrgName(name string) OrganizationOption {
+	return func(o *Organization) { o.Name = strings.TrimSpace(name) }
+}
+
+func WithOrgSummary(summary string) OrganizationOption {
+	return func(o *Organization) { o.Summary = strings.TrimSpace(summary) }
+}
+
+func WithOrgAvatarURL(url string) OrganizationOption {
+	return func(o *Organization) { o.AvatarURL = url }
+}
+
+func WithOrgWebsiteURL(url string) OrganizationOption {
+	return func(o *Organization) { o.WebsiteURL = url }
+}
+
+func WithOrgLocation(location string) OrganizationOption {
+	return func(o *Organization) { o.Location = strings.TrimSpace(location) }
+}
+
+func WithOrgBrandColor(color string) OrganizationOption {
+	return func(o *Organization) { o.BrandColor = color }
+}
+
+// Listing Options
+func WithListingCategory(category string) ListingOption {
+	return func(l *Listing) { l.Category = category }
+}
+
+func WithListingTitle(title string) ListingOption {
+	return func(l *Listing) { l.Title = strings.TrimSpace(title) }
+}
+
+func WithListingBody(body string) ListingOption {
+	return func(l *Listing) { l.Body = strings.TrimSpace(body) }
+}
+
+func WithListingTags(tags []string) ListingOption {
+	return func(l *Listing) { l.Tags = tags }
+}
+
+func WithListingLocation(location string) ListingOption {
+	return func(l *Listing) { l.Location = strings.TrimSpace(location) }
+}
+
+func WithListingContactURL(url string) ListingOption {
+	return func(l *Listing) { l.ContactURL = strings.TrimSpace(url) }
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Organization is a team whose members can publish posts under its byline.
+type Organization struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Name         string    `gorm:"size:100;not null" json:"name" validate:"required,min=2,max=100"`
+	Slug         string    `gorm:"size:60;not null;uniqueIndex:idx_organization_slug" json:"slug" validate:"required,max=60,customSlug"`
+	Summary      string    `gorm:"size:250" json:"summary" validate:"omitempty,max=250"`
+	AvatarURL    string    `gorm:"size:500" json:"avatar_url" validate:"omitempty,url,max=500"`
+	WebsiteURL   string    `gorm:"size:500" json:"website_url" validate:"omitempty,url,max=500"`
+	Location     string    `gorm:"size:100" json:"location" validate:"omitempty,max=100"`
+	BrandColor   string    `gorm:"size:7" json:"brand_color" validate:"omitempty,hexcolor,max=7"`
+	MembersCount int       `gorm:"default:0" json:"members_count" validate:"min=0"`
+	CreatedByID  uuid.UUID `gorm:"type:uuid;not null" json:"created_by_id"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// OrganizationOption configures an Organization.
+type OrganizationOption func(*Organization)
+
+// CreateOrganization creates an organization with its creator as the first admin.
+func CreateOrganization(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, org *Organization) error {
+	org.Name = strings.TrimSpace(org.Name)
+	org.Slug = strings.ToLower(strings.TrimSpace(org.Slug))
+	if org.Name == "" || org.Slug == "" || org.CreatedByID == uuid.Nil {
+		return utils.NewError(utils.ErrBadRequest.Code, "Required fields missing: name, slug, created_by_id")
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Deleted organizations keep their slug so old links never point at someone else
+		var taken int64
+		if err := tx.Unscoped().Model(&Organization{}).Where("slug = ?", org.Slug).Count(&taken).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check organization slug")
+		}
+		if taken > 0 {
+			return utils.NewError(utils.ErrConflict.Code, "Organization slug already taken")
+		}
+
+		org.MembersCount = 1
+		if err := tx.Create(org).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create organization")
+		}
+
+		now := time.Now()
+		admin := &OrganizationMember{
+			OrganizationID: org.ID,
+			UserID:         org.CreatedByID,
+			Role:           OrgRoleAdmin,
+			Status:         OrgMemberActive,
+			JoinedAt:       &now,
+		}
+		if err := tx.Create(admin).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to add organization admin")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cache.Invalidate(ctx, rclient, cache.UserTag(org.CreatedByID))
+	return nil
+}
+
+// GetOrganization retrieves an organization by its slug.
+func GetOrganization(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, slug string) (*Organization, error) {
+	return cache.Fetch(ctx, rclient, cache.OrganizationKey(slug), rclient.TTL("organization"), func(ctx context.Context) (*Organization, []string, error) {
+		var org Organization
+		if err := db.WithContext(ctx).Where("slug = ?", slug).First(&org).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, nil, utils.NewError(utils.ErrNotFound.Code, "Organization not found")
+			}
+			return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch organization")
+		}
+		return &org, []string{cache.OrganizationTag(org.ID)}, nil
+	})
+}
+
+// UpdateOrganization updates an organization's profile.
+func UpdateOrganization(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID, opts ...OrganizationOption) (*Organization, error) {
+	var org Organization
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&org).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Organization not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch organization")
+		}
+		for _, opt := range opts {
+			opt(&org)
+		}
+		if err := tx.Save(&org).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update organization")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Invalidate(ctx, rclient, cache.OrganizationTag(org.ID))
+	return &org, nil
+}
+
+// GetOrganizationPosts retrieves a page of the published posts under an organization's byline
+// listed to viewerID, newest first.
+func GetOrganizationPosts(ctx context.Context, db *gorm.DB, orgID uuid.UUID, viewerID *uuid.UUID, page, limit int) ([]Posts, int64, error) {
+	query := db.WithContext(ctx).Model(&Posts{}).Where("organization_id = ?", orgID).Scopes(ListedTo(viewerID))
+	return listPosts(query, "published_at DESC", page, limit)
+}
+
+// GetUserOrganizations lists the organizations a user is an active member of, with their role.
+func GetUserOrganizations(ctx context.Context, db *gorm.DB, userID uuid.UUID) ([]UserOrganization, error) {
+	orgs := []UserOrganization{}
+	if err := db.WithContext(ctx).Model(&Organization{}).
+		Select("organizations.id, organizations.name, organizations.slug, organizations.avatar_url, organization_members.role").
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.id").
+		Where("organization_members.user_id = ? AND organization_members.status = ?", userID, OrgMemberActive).
+		Order("organizations.name").
+		Scan(&orgs).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch organizations")
+	}
+	return orgs, nil
+}
+
+// UserOrganization is an organization as listed for one of its members.
+type UserOrganization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	AvatarURL string    `json:"avatar_url"`
+	Role      string    `json:"role"`
+}
+
+// organizationSummary only loads the public columns of a post's organization.
+func organizationSummary(db *gorm.DB) *gorm.DB {
+	return db.Select("id, name, slug, avatar_url")
+}
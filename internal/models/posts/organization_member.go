@@ -0,0 +1,288 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Membership roles, from most to least privileged. Admins manage members and the profile,
+// editors may edit the profile, and every active member may publish under the byline.
+const (
+	OrgRoleAdmin  = "admin"
+	OrgRoleEditor = "editor"
+	OrgRoleMember = "member"
+)
+
+// Membership states. An invited user only becomes a member once they accept.
+const (
+	OrgMemberInvited = "invited"
+	OrgMemberActive  = "active"
+)
+
+var orgRoleRank = map[string]int{OrgRoleMember: 1, OrgRoleEditor: 2, OrgRoleAdmin: 3}
+
+// ValidOrgRole reports whether role is a membership role.
+func ValidOrgRole(role string) bool {
+	return orgRoleRank[role] > 0
+}
+
+type OrganizationMember struct {
+	OrganizationID uuid.UUID  `gorm:"type:uuid;primaryKey" json:"organization_id" validate:"required"`
+	UserID         uuid.UUID  `gorm:"type:uuid;primaryKey;index" json:"user_id" validate:"required"`
+	Role           string     `gorm:"size:20;not null;default:'member'" json:"role" validate:"required,oneof=admin editor member"`
+	Status         string     `gorm:"size:20;not null;default:'invited';index" json:"status" validate:"required,oneof=invited active"`
+	InvitedByID    *uuid.UUID `gorm:"type:uuid" json:"invited_by_id"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	JoinedAt       *time.Time `json:"joined_at"`
+
+	Organization Organization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE" json:"-" validate:"-"`
+	User         user.User    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-" validate:"-"`
+}
+
+// Can reports whether the membership is active and grants at least role.
+func (m *OrganizationMember) Can(role string) bool {
+	return m != nil && m.Status == OrgMemberActive && orgRoleRank[m.Role] >= orgRoleRank[role]
+}
+
+// OrganizationMemberSummary is the public projection of an active member.
+type OrganizationMemberSummary struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Name      string    `json:"name"`
+	AvatarURL string    `json:"avatar_url"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+// GetOrganizationMember retrieves a user's membership of an organization, invitations included.
+func GetOrganizationMember(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, orgID, userID uuid.UUID) (*OrganizationMember, error) {
+	return cache.Fetch(ctx, rclient, cache.OrganizationMemberKey(orgID, userID), rclient.TTL("organization"), func(ctx context.Context) (*OrganizationMember, []string, error) {
+		var m OrganizationMember
+		if err := db.WithContext(ctx).Where("organization_id = ? AND user_id = ?", orgID, userID).First(&m).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, nil, utils.NewError(utils.ErrNotFound.Code, "Membership not found")
+			}
+			return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch membership")
+		}
+		return &m, []string{cache.OrganizationTag(orgID)}, nil
+	})
+}
+
+// InviteOrganizationMember invites a user to join an organization with the given role and
+// notifies them.
+func InviteOrganizationMember(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, org *Organization, inviterID, userID uuid.UUID, role string) (*OrganizationMember, error) {
+	if !ValidOrgRole(role) {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Role must be admin, editor or member")
+	}
+
+	m := &OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         userID,
+		Role:           role,
+		Status:         OrgMemberInvited,
+		InvitedByID:    &inviterID,
+	}
+	res := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(m)
+	if res.Error != nil {
+		return nil, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to invite member")
+	}
+	if res.RowsAffected == 0 {
+		return nil, utils.NewError(utils.ErrConflict.Code, "User is already a member or invited")
+	}
+
+	cache.Invalidate(ctx, rclient, cache.OrganizationTag(org.ID))
+	user.NewNotification(ctx, rclient, db, userID, "organization_invite", "You have been invited to join %s as %s", org.Name, role)
+	return m, nil
+}
+
+// AcceptOrganizationInvite turns a pending invitation into an active membership.
+func AcceptOrganizationInvite(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, orgID, userID uuid.UUID) (*OrganizationMember, error) {
+	var m OrganizationMember
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOrganization(tx, orgID); err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ? AND user_id = ? AND status = ?", orgID, userID, OrgMemberInvited).First(&m).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Invitation not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch invitation")
+		}
+
+		now := time.Now()
+		m.Status = OrgMemberActive
+		m.JoinedAt = &now
+		if err := tx.Model(&OrganizationMember{}).Where("organization_id = ? AND user_id = ?", orgID, userID).
+			Updates(map[string]interface{}{"status": m.Status, "joined_at": now}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to accept invitation")
+		}
+		return adjustMembersCount(tx, orgID, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Invalidate(ctx, rclient, cache.OrganizationTag(orgID), cache.UserTag(userID))
+	return &m, nil
+}
+
+// SetOrganizationMemberRole changes the role of an active member. The last admin cannot be
+// demoted, so an organization always has someone to manage it.
+func SetOrganizationMemberRole(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, orgID, userID uuid.UUID, role string) (*OrganizationMember, error) {
+	if !ValidOrgRole(role) {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Role must be admin, editor or member")
+	}
+
+	var m OrganizationMember
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOrganization(tx, orgID); err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&m).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Membership not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch membership")
+		}
+		if m.Role == role {
+			return nil
+		}
+		if role != OrgRoleAdmin {
+			if err := ensureOtherAdmin(tx, &m); err != nil {
+				return err
+			}
+		}
+
+		m.Role = role
+		if err := tx.Model(&OrganizationMember{}).Where("organization_id = ? AND user_id = ?", orgID, userID).
+			Update("role", role).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update member role")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Invalidate(ctx, rclient, cache.OrganizationTag(orgID), cache.UserTag(userID))
+	return &m, nil
+}
+
+// RemoveOrganizationMember removes a member or withdraws an invitation. Declining an invitation
+// and leaving an organization go through here too. The last admin cannot be removed.
+func RemoveOrganizationMember(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, orgID, userID uuid.UUID) error {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOrganization(tx, orgID); err != nil {
+			return err
+		}
+		var m OrganizationMember
+		if err := tx.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&m).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Membership not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch membership")
+		}
+		if err := ensureOtherAdmin(tx, &m); err != nil {
+			return err
+		}
+
+		if err := tx.Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&OrganizationMember{}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to remove member")
+		}
+		if m.Status == OrgMemberActive {
+			return adjustMembersCount(tx, orgID, -1)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cache.Invalidate(ctx, rclient, cache.OrganizationTag(orgID), cache.UserTag(userID))
+	return nil
+}
+
+// GetOrganizationMembersPage returns a page of the active members of an organization, ordered by
+// username.
+func GetOrganizationMembersPage(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, orgID uuid.UUID, p utils.Pagination) (utils.Page[OrganizationMemberSummary], error) {
+	key := cache.OrganizationMembersKey(orgID, p.CacheKey())
+	return cache.Fetch(ctx, rclient, key, rclient.TTL("organization"), func(ctx context.Context) (utils.Page[OrganizationMemberSummary], []string, error) {
+		query := db.WithContext(ctx).Model(&user.User{}).
+			Joins("JOIN organization_members ON organization_members.user_id = users.id").
+			Where("organization_members.organization_id = ? AND organization_members.status = ?", orgID, OrgMemberActive)
+		var total int64
+		if p.WithTotal {
+			if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+				return utils.Page[OrganizationMemberSummary]{}, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count members")
+			}
+		}
+		if p.After != nil {
+			query = query.Where("users.username > ?", p.After.Key)
+		}
+
+		members := []OrganizationMemberSummary{}
+		if err := query.Select("users.id, users.username, users.name, users.avatar_url, organization_members.role, organization_members.joined_at").
+			Order("users.username").Limit(p.Limit + 1).Scan(&members).Error; err != nil {
+			return utils.Page[OrganizationMemberSummary]{}, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get members")
+		}
+		page := utils.NewPage(members, p, func(m OrganizationMemberSummary) utils.Cursor {
+			return utils.Cursor{Key: m.Username, ID: m.ID.String()}
+		})
+		if p.WithTotal {
+			page.Total = &total
+		}
+
+		// Members' names and avatars are shown, so their own changes drop the page too
+		tags := []string{cache.OrganizationTag(orgID)}
+		for _, m := range page.Items {
+			tags = append(tags, cache.UserTag(m.ID))
+		}
+		return page, tags, nil
+	})
+}
+
+// lockOrganization serializes membership changes of an organization, so concurrent removals
+// cannot leave it without an admin.
+func lockOrganization(tx *gorm.DB, orgID uuid.UUID) error {
+	var org Organization
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", orgID).First(&org).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return utils.NewError(utils.ErrNotFound.Code, "Organization not found")
+		}
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch organization")
+	}
+	return nil
+}
+
+// ensureOtherAdmin fails when m is the only active admin of its organization.
+func ensureOtherAdmin(tx *gorm.DB, m *OrganizationMember) error {
+	if m.Role != OrgRoleAdmin || m.Status != OrgMemberActive {
+		return nil
+	}
+	var admins int64
+	if err := tx.Model(&OrganizationMember{}).
+		Where("organization_id = ? AND role = ? AND status = ? AND user_id <> ?", m.OrganizationID, OrgRoleAdmin, OrgMemberActive, m.UserID).
+		Count(&admins).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count admins")
+	}
+	if admins == 0 {
+		return utils.NewError(utils.ErrConflict.Code, "An organization needs at least one admin")
+	}
+	return nil
+}
+
+func adjustMembersCount(tx *gorm.DB, orgID uuid.UUID, delta int) error {
+	if err := tx.Model(&Organization{}).Where("id = ?", orgID).
+		UpdateColumn("members_count", gorm.Expr("GREATEST(members_count + ?, 0)", delta)).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update members count")
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxPlatformStatsDays caps how many days of platform statistics one request returns.
+	MaxPlatformStatsDays = 365
+	// platformTopTags is how many of the most used tags the statistics keep.
+	platformTopTags = 10
+	// platformTagWindow is how far back tags are counted for the top tags.
+	platformTagWindow = 7 * 24 * time.Hour
+)
+
+// PlatformStats are the statistics of the platform on one day, in UTC. They are aggregated by a
+// scheduled job rather than queried on demand: the row of the current day is rewritten on every
+// run, and the counts of the day before are settled once more after midnight. Active users are
+// read from when users were last seen, so they can only be measured on the day itself.
+type PlatformStats struct {
+	Day               time.Time       `gorm:"type:date;primaryKey" json:"day"`
+	Signups           int64           `gorm:"not null;default:0" json:"signups"`
+	ActiveUsers       int64           `gorm:"not null;default:0" json:"active_users"`
+	WeeklyActiveUsers int64           `gorm:"not null;default:0" json:"weekly_active_users"`
+	Posts             int64           `gorm:"not null;default:0" json:"posts"`
+	Comments          int64           `gorm:"not null;default:0" json:"comments"`
+	PendingReports    int64           `gorm:"not null;default:0" json:"pending_reports"`
+	TopTags           json.RawMessage `gorm:"type:jsonb;not null;default:'[]'" json:"top_tags"`
+	ComputedAt        time.Time       `gorm:"not null" json:"computed_at"`
+}
+
+// TagUsage is how many posts used a tag over the last week.
+type TagUsage struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Slug  string    `json:"slug"`
+	Posts int64     `json:"posts"`
+}
+
+// dailyCounts counts what happened on the day starting at day.
+func dailyCounts(ctx context.Context, db *gorm.DB, day time.Time) (signups, posts, comments int64, err error) {
+	next := day.AddDate(0, 0, 1)
+	if err = db.WithContext(ctx).Model(&user.User{}).
+		Where("created_at >= ? AND created_at < ?", day, next).Count(&signups).Error; err != nil {
+		return 0, 0, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count signups")
+	}
+	if err = db.WithContext(ctx).Model(&Posts{}).
+		Where("published = ? AND published_at >= ? AND published_at < ?", true, day, next).Count(&posts).Error; err != nil {
+		return 0, 0, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count posts")
+	}
+	if err = db.WithContext(ctx).Model(&Comment{}).
+		Where("created_at >= ? AND created_at < ?", day, next).Count(&comments).Error; err != nil {
+		return 0, 0, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count comments")
+	}
+	return signups, posts, comments, nil
+}
+
+// AggregatePlatformStats computes the statistics of the day of now and settles the counts of the
+// day before, which may have changed since its last run.
+func AggregatePlatformStats(ctx context.Context, db *gorm.DB, now time.Time) (*PlatformStats, error) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	stats := &PlatformStats{Day: today, ComputedAt: now}
+	var err error
+	if stats.Signups, stats.Posts, stats.Comments, err = dailyCounts(ctx, db, today); err != nil {
+		return nil, err
+	}
+	if err := db.WithContext(ctx).Model(&user.User{}).
+		Where("last_seen >= ?", today).Count(&stats.ActiveUsers).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count active users")
+	}
+	if err := db.WithContext(ctx).Model(&user.User{}).
+		Where("last_seen >= ?", today.AddDate(0, 0, -6)).Count(&stats.WeeklyActiveUsers).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count weekly active users")
+	}
+	if err := db.WithContext(ctx).Model(&CommentFlag{}).
+		Where("resolved = ?", false).Count(&stats.PendingReports).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count pending reports")
+	}
+
+	tags := []TagUsage{}
+	if err := db.WithContext(ctx).Table("post_tags").
+		Select("tags.id, tags.name, tags.slug, count(*) AS posts").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Joins("JOIN posts ON posts.id = post_tags.posts_id").
+		Where("posts.published = ? AND posts.published_at >= ? AND posts.deleted_at IS NULL", true, now.Add(-platformTagWindow)).
+		Group("tags.id, tags.name, tags.slug").
+		Order("posts DESC, tags.name").
+		Limit(platformTopTags).
+		Scan(&tags).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count tag usage")
+	}
+	stats.TopTags, _ = json.Marshal(tags)
+
+	if err := db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(stats).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to store platform stats")
+	}
+
+	yesterday := today.AddDate(0, 0, -1)
+	signups, posts, comments, err := dailyCounts(ctx, db, yesterday)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.WithContext(ctx).Model(&PlatformStats{}).Where("day = ?", yesterday).Updates(map[string]interface{}{
+		"signups":  signups,
+		"posts":    posts,
+		"comments": comments,
+	}).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to settle platform stats")
+	}
+	return stats, nil
+}
+
+// GetPlatformStats returns the statistics of the last days, oldest first.
+func GetPlatformStats(ctx context.Context, db *gorm.DB, days int) ([]PlatformStats, error) {
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+	stats := []PlatformStats{}
+	if err := db.WithContext(ctx).Where("day >= ?", since).Order("day").Find(&stats).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get platform stats")
+	}
+	return stats, nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"github.com/redis/go-redis/v9"
@@ -24,7 +25,10 @@ type Posts struct {
 	FeaturedImageURL string     `gorm:"size:500" json:"featured_image_url" validate:"omitempty,url,max=500"`
 	Published        bool       `gorm:"default:false;index" json:"published"`
 	PublishedAt      *time.Time `gorm:"index:idx_post_published_at" json:"published_at" validate:"omitempty"`
-	Status           string     `gorm:"type:varchar(20);default:'draft';index" json:"status" validate:"required,oneof=draft published unpublished public private"`
+	ScheduledAt      *time.Time `gorm:"index:idx_post_scheduled_at" json:"scheduled_at" validate:"omitempty"`
+	ScheduleTimezone string     `gorm:"size:64" json:"schedule_timezone" validate:"omitempty,timezone"`
+	Visibility       string     `gorm:"size:20;not null;default:'public';index" json:"visibility" validate:"omitempty,oneof=public followers members unlisted"`
+	Status           string     `gorm:"type:varchar(20);default:'draft';index" json:"status" validate:"required,oneof=draft scheduled published unpublished public private"`
 	PublishingStatus string     `gorm:"type:varchar(50);default:'draft'" json:"publishing_status"`
 	ContentFormat    string     `gorm:"size:20;default:'markdown'" json:"content_format" validate:"oneof=markdown html"`
 	CanonicalURL     string     `gorm:"size:500" json:"canonical_url" validate:"omitempty,url,max=500"`
@@ -43,11 +47,16 @@ type Posts struct {
 	// Collaboration & Review System
 	AuthorID       uuid.UUID  `gorm:"type:uuid;not null;index:idx_post_author" json:"author_id" validate:"required"`
 	SeriesID       *uuid.UUID `gorm:"type:uuid;index:idx_post_series" json:"series_id" validate:"omitempty"`
+	OrganizationID *uuid.UUID `gorm:"type:uuid;index:idx_post_organization" json:"organization_id" validate:"omitempty"`
 	EditedAt       *time.Time `gorm:"index" json:"edited_at" validate:"omitempty"`
 	LastEditedByID *uuid.UUID `gorm:"type:uuid" json:"last_edited_by_id" validate:"omitempty"`
 	NeedsReview    bool       `gorm:"default:false;index" json:"needs_review"`
 	ReviewedByID   *uuid.UUID `gorm:"type:uuid" json:"reviewed_by_id" validate:"omitempty"`
 	ReviewedAt     *time.Time `gorm:"index" json:"reviewed_at" validate:"omitempty"`
+	// GuestAuthorName credits a writer without an account whose post the author published on
+	// their behalf; GuestAuthorURL links to them.
+	GuestAuthorName string `gorm:"size:100" json:"guest_author_name" validate:"omitempty,max=100"`
+	GuestAuthorURL  string `gorm:"size:500" json:"guest_author_url" validate:"omitempty,url,max=500"`
 
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
@@ -56,15 +65,21 @@ type Posts struct {
 	// Relationships
 	Author        user.User      `gorm:"foreignKey:AuthorID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"author" validate:"-"`
 	Series        *Series        `gorm:"foreignKey:SeriesID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"series" validate:"-"`
+	Organization  *Organization  `gorm:"foreignKey:OrganizationID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"organization" validate:"-"`
 	Tags          []Tag          `gorm:"many2many:post_tags;" json:"tags" validate:"max=4,dive"`
 	Comments      []Comment      `gorm:"foreignKey:PostID" json:"comments" validate:"-"`
 	Reactions     []Reaction     `gorm:"foreignKey:ReactableID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"reactions" validate:"-"`
 	Bookmarks     []Bookmark     `gorm:"foreignKey:PostID" json:"bookmarks" validate:"-"`
 	Mentions      []user.User    `gorm:"many2many:post_mentions;" json:"mentions" validate:"max=5,dive"`
-	CoAuthors     []user.User    `gorm:"many2many:post_co_authors;" json:"co_authors" validate:"max=3,dive"`
+	CoAuthors     []PostAuthor   `gorm:"foreignKey:PostID" json:"co_authors" validate:"-"`
 	PostAnalytics *PostAnalytics `gorm:"foreignKey:PostID" json:"analytics" validate:"-"`
 }
 
+// Edited reports whether a post was edited after it was published; edits to drafts do not count.
+func (p *Posts) Edited() bool {
+	return p.EditedAt != nil && p.PublishedAt != nil && p.EditedAt.After(*p.PublishedAt)
+}
+
 // PostsOption configures a Post.
 type PostsOption func(*Posts)
 
@@ -87,10 +102,14 @@ func CreatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 	if post.AuthorID == uuid.Nil || post.Title == "" || post.Slug == "" || post.Content == "" {
 		return utils.NewError(utils.ErrBadRequest.Code, "Required fields missing: author_id, title, slug, content")
 	}
+	if err := checkVisibility(post); err != nil {
+		return err
+	}
 
+	var mentioned []uuid.UUID
 	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var author user.User
-		key := "user:" + post.AuthorID.String()
+		key := cache.UserKey(post.AuthorID)
 		autho, err := rclient.Get(ctx, key).Result()
 		if err != nil {
 			if err == redis.Nil {
@@ -99,7 +118,7 @@ func CreatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 					return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch author")
 				}
 				authorData, _ := json.Marshal(author)
-				rclient.Set(ctx, key, authorData, 10*time.Minute)
+				rclient.Set(ctx, key, authorData, rclient.TTL("user"))
 			} else {
 				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch author from cache")
 			}
@@ -109,12 +128,12 @@ func CreatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 			}
 		}
 
-		validStatuses := map[string]bool{"draft": true, "published": true, "unpublished": true, "public": true, "private": true}
+		validStatuses := map[string]bool{"draft": true, "scheduled": true, "published": true, "unpublished": true, "public": true, "private": true}
 		if !validStatuses[post.Status] {
 			return utils.NewError(utils.ErrBadRequest.Code, "Invalid post status")
 		}
 
-		if author.Role.Name == "member" {
+		if author.Role.Name == user.RoleMember {
 			post.PublishingStatus = "moderation"
 			post.Published = false
 			post.PublishedAt = nil
@@ -129,6 +148,14 @@ func CreatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 			}
 		}
 
+		var taken int64
+		if err := tx.Unscoped().Model(&Posts{}).Where("slug = ?", post.Slug).Count(&taken).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check slug")
+		}
+		if taken > 0 {
+			return utils.NewError(utils.ErrConflict.Code, "Slug already taken")
+		}
+
 		if err := tx.WithContext(ctx).Create(post).Error; err != nil {
 			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create post")
 		}
@@ -139,6 +166,16 @@ func CreatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 		}
 		post.PostAnalytics = analytics
 
+		if err := recordRevision(tx, revisionOf(post, post.AuthorID)); err != nil {
+			return err
+		}
+
+		added, err := SyncMentions(ctx, tx, MentionSourcePost, post.ID, post.ID, post.AuthorID, post.Content)
+		if err != nil {
+			return err
+		}
+		mentioned = added
+
 		for _, tag := range post.Tags {
 			if err := IncrementTagCounts(ctx, rclient, tx, tag.ID, 1, 0); err != nil {
 				return err
@@ -148,7 +185,20 @@ func CreatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 			}
 		}
 
-		return user.UpdateUserStats(ctx, rclient, tx, post.AuthorID, user.WithPostsCount(1))
+		// PostsCount only counts published posts; drafts are counted when they get published.
+		if !post.Published {
+			return nil
+		}
+		if err := user.AdjustUserStat(ctx, tx, post.AuthorID, "posts_count", 1); err != nil {
+			return err
+		}
+		if err := user.EnqueueOutbox(ctx, tx, user.OutboxPostPublished, user.PostChangedEvent{PostID: post.ID}); err != nil {
+			return err
+		}
+		if err := enqueueFanout(ctx, tx, post.ID); err != nil {
+			return err
+		}
+		return user.EmitWebhookEvent(ctx, tx, user.WebhookPostPublished, []uuid.UUID{post.AuthorID}, publishedEventData(post))
 	})
 
 	if err != nil {
@@ -156,8 +206,13 @@ func CreatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 	}
 
 	postData, _ := json.Marshal(post)
-	rclient.Set(ctx, "post:"+post.ID.String(), postData, 10*time.Minute)
-	rclient.Del(ctx, "public_post:"+post.Slug)
+	rclient.Set(ctx, cache.PostKey(post.ID), postData, rclient.TTL("post"))
+	cache.Delete(ctx, rclient, cache.PublicPostKey(post.Slug))
+	if post.Published {
+		cache.InvalidateUser(ctx, rclient, post.AuthorID)
+		cache.Invalidate(ctx, rclient, cache.FeedAuthorTag(post.AuthorID), cache.PublishedPostsTag)
+	}
+	notifyMentions(ctx, rclient, db, post.AuthorID, mentioned, MentionSourcePost, post.Title, post.Slug)
 
 	return nil
 }
@@ -209,16 +264,30 @@ func GetPosts(ctx context.Context, redisClient *storage.RedisClient, gormDB *gor
 // UpdatePost updates a post in the database
 func UpdatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, post *Posts, opts ...PostsOption) (*Posts, error) {
 	tx := db.WithContext(ctx).Begin()
-	post, err := GetPostsBy(ctx, rclient, tx, "id = ?", []interface{}{post.ID}, "Mentions", "CoAuthors", "PostAnalytics")
+	post, err := GetPostsBy(ctx, rclient, tx, "id = ?", []interface{}{post.ID}, "Mentions", "PostAnalytics")
 	if err != nil {
 		return nil, err
 	}
 
 	originalSlug := post.Slug
 	originalTags := post.Tags
+	wasPublished := post.Published
+	originalVisibility := post.Visibility
+	original := revisionOf(post, uuid.Nil)
+	var mentioned, coAuthors []uuid.UUID
 	for _, opt := range opts {
 		opt(post)
 	}
+	editorID := post.AuthorID
+	if post.LastEditedByID != nil {
+		editorID = *post.LastEditedByID
+	}
+	revision := revisionOf(post, editorID)
+	contentSaved := !revision.sameContent(original)
+	if err := checkVisibility(post); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	err = tx.Transaction(func(tx *gorm.DB) error {
 		if post.Slug != originalSlug && post.Slug != "" {
@@ -248,8 +317,10 @@ func UpdatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 			}
 		}
 
+		tagsChanged := len(originalTagIDs) != len(newTagIDs)
 		for tagID := range newTagIDs {
 			if !originalTagIDs[tagID] {
+				tagsChanged = true
 				if err := IncrementTagCounts(ctx, rclient, tx, tagID, 1, 0); err != nil {
 					return err
 				}
@@ -259,7 +330,46 @@ func UpdatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 		if err := tx.Save(post).Error; err != nil {
 			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update post")
 		}
+		if tagsChanged {
+			if err := tx.Model(post).Association("Tags").Replace(post.Tags); err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update post tags")
+			}
+		}
+		if contentSaved {
+			if err := recordRevision(tx, revision); err != nil {
+				return err
+			}
+		}
+
+		added, err := SyncMentions(ctx, tx, MentionSourcePost, post.ID, post.ID, post.AuthorID, post.Content)
+		if err != nil {
+			return err
+		}
+		mentioned = added
 
+		if post.Published != wasPublished {
+			delta := 1
+			if !post.Published {
+				delta = -1
+			}
+			ids, err := adjustAuthorsPostsCount(ctx, tx, post, delta)
+			if err != nil {
+				return err
+			}
+			coAuthors = ids
+		}
+		if post.Published && !wasPublished {
+			if err := user.EnqueueOutbox(ctx, tx, user.OutboxPostPublished, user.PostChangedEvent{PostID: post.ID}); err != nil {
+				return err
+			}
+			if err := enqueueFanout(ctx, tx, post.ID); err != nil {
+				return err
+			}
+			return user.EmitWebhookEvent(ctx, tx, user.WebhookPostPublished, []uuid.UUID{post.AuthorID}, publishedEventData(post))
+		}
+		if post.Published || wasPublished {
+			return user.EnqueueOutbox(ctx, tx, user.OutboxPostUpdated, user.PostChangedEvent{PostID: post.ID})
+		}
 		return nil
 	})
 	if err != nil {
@@ -270,11 +380,26 @@ func UpdatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 	tx.Commit()
 
 	postData, _ := json.Marshal(post)
-	rclient.Set(ctx, "post:"+post.ID.String(), postData, 10*time.Minute)
-	rclient.Del(ctx, "public_post:"+originalSlug)
-	if post.Slug != originalSlug {
-		rclient.Del(ctx, "public_post:"+post.Slug)
+	rclient.Set(ctx, cache.PostKey(post.ID), postData, rclient.TTL("post"))
+	cache.Delete(ctx, rclient, cache.PublicPostKey(originalSlug), cache.PublicPostKey(post.Slug), cache.PostHTMLKey(post.ID))
+	if contentSaved {
+		// The saved content supersedes whatever the editor had in flight
+		cache.Delete(ctx, rclient, cache.AutosaveKey(post.ID))
 	}
+	if post.Published != wasPublished || post.Visibility != originalVisibility {
+		cache.InvalidateUser(ctx, rclient, post.AuthorID)
+		cache.Invalidate(ctx, rclient, cache.PublishedPostsTag)
+	}
+	for _, id := range coAuthors {
+		cache.InvalidateUser(ctx, rclient, id)
+	}
+	if post.Published && (!wasPublished || post.Visibility != originalVisibility) {
+		cache.Invalidate(ctx, rclient, cache.FeedAuthorTag(post.AuthorID))
+	}
+	if post.SeriesID != nil {
+		cache.Invalidate(ctx, rclient, cache.SeriesTag(*post.SeriesID))
+	}
+	notifyMentions(ctx, rclient, db, post.AuthorID, mentioned, MentionSourcePost, post.Title, post.Slug)
 
 	return post, nil
 }
@@ -282,10 +407,11 @@ func UpdatePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 // DeletePost deletes a post from the database
 func DeletePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, postID uuid.UUID) error {
 	tx := db.WithContext(ctx).Begin()
-	post, err := GetPostsBy(ctx, rclient, tx, "id = ?", []interface{}{postID}, "Mentions", "CoAuthors", "PostAnalytics")
+	post, err := GetPostsBy(ctx, rclient, tx, "id = ?", []interface{}{postID}, "Mentions", "PostAnalytics")
 	if err != nil {
 		return err
 	}
+	var coAuthors []uuid.UUID
 
 	err = tx.Transaction(func(tx *gorm.DB) error {
 		if err := DeletePostAnalytics(ctx, rclient, db, postID); err != nil {
@@ -296,8 +422,19 @@ func DeletePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to clear post mentions")
 		}
 
-		if err := tx.Model(post).Association("CoAuthors").Clear(); err != nil {
-			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to clear post co-authors")
+		if post.Published {
+			ids, err := adjustAuthorsPostsCount(ctx, tx, post, -1)
+			if err != nil {
+				return err
+			}
+			coAuthors = ids
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&PostAuthor{}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete post co-authors")
+		}
+
+		if err := tx.Where("post_id = ?", postID).Delete(&Mention{}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete post mentions")
 		}
 
 		for _, tag := range post.Tags {
@@ -306,11 +443,20 @@ func DeletePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 			}
 		}
 
+		if post.SeriesID != nil {
+			if err := detachSeriesPost(tx, *post.SeriesID, post.ID); err != nil {
+				return err
+			}
+		}
+
 		if err := tx.Delete(post).Error; err != nil {
 			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete post")
 		}
 
-		return user.UpdateUserStats(ctx, rclient, tx, post.AuthorID, user.WithPostsCount(-1))
+		if !post.Published {
+			return nil
+		}
+		return user.EnqueueOutbox(ctx, tx, user.OutboxPostDeleted, user.PostChangedEvent{PostID: post.ID})
 	})
 	if err != nil {
 		tx.Rollback()
@@ -319,8 +465,89 @@ func DeletePost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 
 	tx.Commit()
 
-	rclient.Del(ctx, "post:"+post.ID.String())
-	rclient.Del(ctx, "public_post:"+post.Slug)
+	cache.Delete(ctx, rclient, cache.PostKey(post.ID), cache.PublicPostKey(post.Slug), cache.PostHTMLKey(post.ID), cache.AutosaveKey(post.ID))
+	if post.Published {
+		cache.InvalidateUser(ctx, rclient, post.AuthorID)
+		cache.Invalidate(ctx, rclient, cache.PublishedPostsTag)
+	}
+	for _, id := range coAuthors {
+		cache.InvalidateUser(ctx, rclient, id)
+	}
+	if post.SeriesID != nil {
+		cache.Invalidate(ctx, rclient, cache.SeriesTag(*post.SeriesID))
+	}
 
 	return nil
 }
+
+// GetPublishedPost retrieves a published post by slug with its tags and a public summary of its author.
+func GetPublishedPost(ctx context.Context, db *gorm.DB, slug string) (*Posts, error) {
+	var post Posts
+	err := db.WithContext(ctx).Preload("Tags").Preload("Author", authorSummary).Preload("Organization", organizationSummary).
+		Preload("CoAuthors", acceptedCoAuthors).Preload("CoAuthors.User", authorSummary).
+		Where("slug = ? AND published = ?", slug, true).First(&post).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Post not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch post")
+	}
+	return &post, nil
+}
+
+// GetPublishedPosts lists the published posts listed to viewerID, newest first, optionally
+// limited to those one author wrote or co-authored.
+func GetPublishedPosts(ctx context.Context, db *gorm.DB, authorID, viewerID *uuid.UUID, page, limit int) ([]Posts, int64, error) {
+	query := db.WithContext(ctx).Model(&Posts{}).Scopes(ListedTo(viewerID))
+	if authorID != nil {
+		query = query.Where("(author_id = ? OR id IN (?))", *authorID, CoAuthoredBy(db, *authorID))
+	}
+	return listPosts(query, "published_at DESC", page, limit)
+}
+
+// GetAuthorPosts lists an author's own posts in any state, optionally filtered by status.
+func GetAuthorPosts(ctx context.Context, db *gorm.DB, authorID uuid.UUID, status string, page, limit int) ([]Posts, int64, error) {
+	query := db.WithContext(ctx).Model(&Posts{}).Where("author_id = ?", authorID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return listPosts(query, "updated_at DESC", page, limit)
+}
+
+// GetScheduledPosts lists an author's scheduled posts, the next to go out first.
+func GetScheduledPosts(ctx context.Context, db *gorm.DB, authorID uuid.UUID, page, limit int) ([]Posts, int64, error) {
+	query := db.WithContext(ctx).Model(&Posts{}).Where("author_id = ? AND status = ?", authorID, PostStatusScheduled)
+	return listPosts(query, "scheduled_at ASC", page, limit)
+}
+
+func listPosts(query *gorm.DB, order string, page, limit int) ([]Posts, int64, error) {
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count posts")
+	}
+
+	posts := []Posts{}
+	if err := query.Preload("Tags").Preload("Author", authorSummary).Preload("Organization", organizationSummary).
+		Preload("CoAuthors", acceptedCoAuthors).Preload("CoAuthors.User", authorSummary).Order(order).Offset((page - 1) * limit).Limit(limit).Find(&posts).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch posts")
+	}
+	return posts, total, nil
+}
+
+// publishedEventData is the payload of a post.published webhook event.
+func publishedEventData(post *Posts) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              post.ID,
+		"title":           post.Title,
+		"slug":            post.Slug,
+		"excerpt":         post.Excerpt,
+		"author_id":       post.AuthorID,
+		"organization_id": post.OrganizationID,
+		"published_at":    post.PublishedAt,
+	}
+}
+
+// authorSummary only loads the public columns of a post's author.
+func authorSummary(db *gorm.DB) *gorm.DB {
+	return db.Select("id, username, name, avatar_url")
+}
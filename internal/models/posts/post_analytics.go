@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
@@ -66,7 +68,7 @@ func CreatePostAnalytics(ctx context.Context, redisClient *storage.RedisClient,
 	}
 
 	paData, _ := json.Marshal(pa)
-	redisClient.Set(ctx, "post_analytics:"+pa.PostID.String(), paData, 10*time.Minute)
+	redisClient.Set(ctx, "post_analytics:"+pa.PostID.String(), paData, redisClient.TTL("post_analytics"))
 	return nil
 }
 
@@ -93,7 +95,7 @@ func GetPostAnalyticsBy(ctx context.Context, rclient *storage.RedisClient, db *g
 	}
 
 	paData, _ := json.Marshal(pa)
-	rclient.Set(ctx, cacheKey, paData, 10*time.Minute)
+	rclient.Set(ctx, cacheKey, paData, rclient.TTL("post_analytics"))
 
 	return &pa, nil
 }
@@ -126,7 +128,7 @@ func UpdatePostAnalytics(ctx context.Context, rclient *storage.RedisClient, gorm
 	key := "post_analytics:" + postID.String()
 	rclient.Del(ctx, key)
 	paData, _ := json.Marshal(pa)
-	rclient.Set(ctx, key, paData, 10*time.Minute)
+	rclient.Set(ctx, key, paData, rclient.TTL("post_analytics"))
 
 	return pa, nil
 }
@@ -155,3 +157,23 @@ func DeletePostAnalytics(ctx context.Context, rclient *storage.RedisClient, gorm
 	rclient.Del(ctx, key)
 	return nil
 }
+
+// adjustCounts moves a counter kept both on a user's stats and on a post's analytics, such as
+// comments_count, by delta inside tx. Counters are updated in SQL so concurrent writers never
+// overwrite each other.
+func adjustCounts(tx *gorm.DB, userID, postID uuid.UUID, column string, delta int) error {
+	if err := user.AdjustUserStat(tx.Statement.Context, tx, userID, column, delta); err != nil {
+		return err
+	}
+	expr := gorm.Expr("GREATEST("+column+" + ?, 0)", delta)
+	if err := tx.Model(&PostAnalytics{}).Where("post_id = ?", postID).UpdateColumn(column, expr).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update post analytics")
+	}
+	return nil
+}
+
+// dropCountCaches drops the cached user and post analytics after adjustCounts committed.
+func dropCountCaches(ctx context.Context, rclient *storage.RedisClient, userID, postID uuid.UUID) {
+	cache.InvalidateUser(ctx, rclient, userID)
+	rclient.Del(ctx, "post_analytics:"+postID.String())
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// Post visibilities. Public posts are listed everywhere. Followers posts reach the author's
+// followers only, and members posts the active members of the organization they are published
+// under. Unlisted posts are listed nowhere and open only through a preview link.
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityMembers   = "members"
+	VisibilityUnlisted  = "unlisted"
+)
+
+// MaxPreviewTTL is the longest a preview link stays valid.
+const MaxPreviewTTL = 30 * 24 * time.Hour
+
+// ValidVisibility reports whether visibility is a post visibility.
+func ValidVisibility(visibility string) bool {
+	switch visibility {
+	case VisibilityPublic, VisibilityFollowers, VisibilityMembers, VisibilityUnlisted:
+		return true
+	}
+	return false
+}
+
+// PostPolicy decides who may read which post. Single posts are checked with CanView and listings
+// are scoped with ListedTo, so every read path, from the post page to the feed and search, applies
+// the same rules.
+type PostPolicy struct {
+	previewKey []byte
+}
+
+// NewPostPolicy returns a policy signing preview links with key. A nil key is replaced by a random
+// one, which keeps preview links valid only for the life of the process.
+func NewPostPolicy(key []byte) (*PostPolicy, error) {
+	if key == nil {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate preview key: %v", err)
+		}
+	}
+	return &PostPolicy{previewKey: key}, nil
+}
+
+// ListedTo scopes a query on posts to the published posts listed to viewerID, nil for an anonymous
+// visitor: public posts, followers posts of the authors the viewer follows, members posts of the
+// organizations the viewer belongs to, and the viewer's own such posts. Unlisted posts are never
+// listed. Listings cached for every visitor must pass nil.
+func ListedTo(viewerID *uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("posts.published = ?", true)
+		if viewerID == nil {
+			return db.Where("posts.visibility = ?", VisibilityPublic)
+		}
+		return db.Where(`(posts.visibility = ?
+			OR (posts.visibility IN ? AND posts.author_id = ?)
+			OR (posts.visibility = ? AND posts.author_id IN (SELECT following_id FROM user_followers WHERE follower_id = ?))
+			OR (posts.visibility = ? AND posts.organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = ? AND status = ?)))`,
+			VisibilityPublic,
+			[]string{VisibilityFollowers, VisibilityMembers}, *viewerID,
+			VisibilityFollowers, *viewerID,
+			VisibilityMembers, *viewerID, OrgMemberActive,
+		)
+	}
+}
+
+// CanView decides whether viewerID, nil for an anonymous visitor, may read a published post.
+// preview is the token of the preview link the request came with, if any; a valid one opens the
+// post whatever its visibility. Unlisted posts are reported as missing to everyone else.
+func (p *PostPolicy) CanView(ctx context.Context, db *gorm.DB, post *Posts, viewerID *uuid.UUID, preview string) error {
+	if viewerID != nil && *viewerID == post.AuthorID {
+		return nil
+	}
+	if preview != "" && p.verifyPreview(post.ID, preview) {
+		return nil
+	}
+
+	var count int64
+	switch post.Visibility {
+	case VisibilityPublic, "":
+		return nil
+	case VisibilityUnlisted:
+		return utils.NewError(utils.ErrNotFound.Code, "Post not found")
+	case VisibilityFollowers:
+		if viewerID == nil {
+			return utils.NewError(utils.ErrUnauthorized.Code, "Sign in and follow the author to read this post")
+		}
+		if err := db.WithContext(ctx).Table("user_followers").
+			Where("follower_id = ? AND following_id = ?", *viewerID, post.AuthorID).
+			Count(&count).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check follow")
+		}
+		if count == 0 {
+			return utils.NewError(utils.ErrForbidden.Code, "Only the author's followers can read this post")
+		}
+		return nil
+	case VisibilityMembers:
+		if viewerID == nil {
+			return utils.NewError(utils.ErrUnauthorized.Code, "Sign in as a member of the organization to read this post")
+		}
+		if post.OrganizationID != nil {
+			if err := db.WithContext(ctx).Model(&OrganizationMember{}).
+				Where("organization_id = ? AND user_id = ? AND status = ?", *post.OrganizationID, *viewerID, OrgMemberActive).
+				Count(&count).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check membership")
+			}
+		}
+		if count == 0 {
+			return utils.NewError(utils.ErrForbidden.Code, "Only members of the organization can read this post")
+		}
+		return nil
+	}
+	return utils.NewError(utils.ErrNotFound.Code, "Post not found")
+}
+
+// PreviewToken signs a preview link for a post, valid for ttl.
+func (p *PostPolicy) PreviewToken(postID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	if ttl <= 0 || ttl > MaxPreviewTTL {
+		return "", time.Time{}, utils.NewError(utils.ErrBadRequest.Code, fmt.Sprintf("Preview links must expire within %s", MaxPreviewTTL))
+	}
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	at := strconv.FormatInt(expires.Unix(), 10)
+	return at + "." + p.sign(postID, at), expires, nil
+}
+
+// verifyPreview reports whether token is a genuine, unexpired preview link of the post.
+func (p *PostPolicy) verifyPreview(postID uuid.UUID, token string) bool {
+	at, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(at, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(p.sign(postID, at)))
+}
+
+func (p *PostPolicy) sign(postID uuid.UUID, expires string) string {
+	mac := hmac.New(sha256.New, p.previewKey)
+	mac.Write([]byte(postID.String() + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkVisibility rejects visibilities a post cannot have.
+func checkVisibility(post *Posts) error {
+	if post.Visibility == "" {
+		post.Visibility = VisibilityPublic
+	}
+	if !ValidVisibility(post.Visibility) {
+		return utils.NewError(utils.ErrBadRequest.Code, "Visibility must be public, followers, members or unlisted")
+	}
+	if post.Visibility == VisibilityMembers && post.OrganizationID == nil {
+		return utils.NewError(utils.ErrBadRequest.Code, "Only posts published under an organization can be for members")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// MaxPostRevisions caps the revisions kept per post; the oldest are pruned as new ones are saved.
+const MaxPostRevisions = 50
+
+// PostRevision is a snapshot of a post's content taken every time the content is saved, so earlier
+// versions can be reviewed and restored and every edit is attributed to whoever made it.
+type PostRevision struct {
+	ID               uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	PostID           uuid.UUID `gorm:"type:uuid;not null;index:idx_post_revision_post" json:"post_id"`
+	EditorID         uuid.UUID `gorm:"type:uuid;not null" json:"editor_id"`
+	Title            string    `gorm:"size:200;not null" json:"title"`
+	Content          string    `gorm:"type:text;not null" json:"content,omitempty"`
+	Excerpt          string    `gorm:"size:300" json:"excerpt"`
+	FeaturedImageURL string    `gorm:"size:500" json:"cover_image_url"`
+	CanonicalURL     string    `gorm:"size:500" json:"canonical_url"`
+	CreatedAt        time.Time `gorm:"autoCreateTime;index:idx_post_revision_post" json:"created_at"`
+}
+
+// PostAutosave is the in-flight content of a post's editor. It lives in Redis only, until the
+// post is saved or it expires.
+type PostAutosave struct {
+	PostID   uuid.UUID `json:"post_id"`
+	EditorID uuid.UUID `json:"editor_id"`
+	Title    string    `json:"title"`
+	Content  string    `json:"content"`
+	Excerpt  string    `json:"excerpt"`
+	SavedAt  time.Time `json:"saved_at"`
+}
+
+// revisionOf snapshots the content of a post as saved by editorID.
+func revisionOf(post *Posts, editorID uuid.UUID) *PostRevision {
+	return &PostRevision{
+		PostID:           post.ID,
+		EditorID:         editorID,
+		Title:            post.Title,
+		Content:          post.Content,
+		Excerpt:          post.Excerpt,
+		FeaturedImageURL: post.FeaturedImageURL,
+		CanonicalURL:     post.CanonicalURL,
+	}
+}
+
+// sameContent reports whether two snapshots hold the same content.
+func (r *PostRevision) sameContent(other *PostRevision) bool {
+	return r.Title == other.Title && r.Content == other.Content && r.Excerpt == other.Excerpt &&
+		r.FeaturedImageURL == other.FeaturedImageURL && r.CanonicalURL == other.CanonicalURL
+}
+
+// recordRevision stores a revision within tx and prunes the post's revisions beyond
+// MaxPostRevisions.
+func recordRevision(tx *gorm.DB, revision *PostRevision) error {
+	if err := tx.Create(revision).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record post revision")
+	}
+	kept := tx.Model(&PostRevision{}).Select("id").Where("post_id = ?", revision.PostID).Order("created_at DESC").Limit(MaxPostRevisions)
+	if err := tx.Where("post_id = ? AND id NOT IN (?)", revision.PostID, kept).Delete(&PostRevision{}).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to prune post revisions")
+	}
+	return nil
+}
+
+// ListPostRevisions retrieves a page of a post's revisions, newest first, without their content.
+func ListPostRevisions(ctx context.Context, db *gorm.DB, postID uuid.UUID, page, limit int) ([]PostRevision, int64, error) {
+	query := db.WithContext(ctx).Model(&PostRevision{}).Where("post_id = ?", postID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count post revisions")
+	}
+
+	revisions := []PostRevision{}
+	if err := query.Omit("content").Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&revisions).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch post revisions")
+	}
+	return revisions, total, nil
+}
+
+// GetPostRevision retrieves a revision of a post with its content.
+func GetPostRevision(ctx context.Context, db *gorm.DB, postID, revisionID uuid.UUID) (*PostRevision, error) {
+	var revision PostRevision
+	if err := db.WithContext(ctx).Where("id = ? AND post_id = ?", revisionID, postID).First(&revision).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Revision not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch post revision")
+	}
+	return &revision, nil
+}
+
+// RestorePostRevision puts the content of a revision back into its post. The restore is saved
+// like any edit, as a new revision by editorID, so the history keeps what it replaced.
+func RestorePostRevision(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, postID, revisionID, editorID uuid.UUID) (*Posts, error) {
+	revision, err := GetPostRevision(ctx, db, postID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return UpdatePost(ctx, rclient, db, &Posts{ID: postID},
+		WithTitle(revision.Title),
+		WithContent(revision.Content),
+		WithExcerpt(revision.Excerpt),
+		WithFeaturedImageURL(revision.FeaturedImageURL),
+		WithCanonicalURL(revision.CanonicalURL),
+		WithEditedAt(&now),
+		WithLastEditedByID(&editorID),
+	)
+}
+
+// SaveAutosave keeps the in-flight content of a post's editor, replacing any earlier autosave.
+func SaveAutosave(ctx context.Context, rclient *storage.RedisClient, autosave *PostAutosave) error {
+	autosave.SavedAt = time.Now()
+	if err := cache.Set(ctx, rclient, cache.AutosaveKey(autosave.PostID), autosave, rclient.TTL("autosave")); err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to autosave post")
+	}
+	return nil
+}
+
+// GetAutosave retrieves the in-flight content of a post, or nil when there is none.
+func GetAutosave(ctx context.Context, rclient *storage.RedisClient, postID uuid.UUID) (*PostAutosave, error) {
+	autosave, ok, err := cache.Get[*PostAutosave](ctx, rclient, cache.AutosaveKey(postID))
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch autosave")
+	}
+	if !ok {
+		return nil, nil
+	}
+	return autosave, nil
+}
+
+// DiscardAutosave drops the in-flight content of a post.
+func DiscardAutosave(ctx context.Context, rclient *storage.RedisClient, postID uuid.UUID) error {
+	if err := cache.Delete(ctx, rclient, cache.AutosaveKey(postID)); err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to discard autosave")
+	}
+	return nil
+}
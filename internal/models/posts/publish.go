@@ -0,0 +1,148 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// Post states handled by the publishing workflow.
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+)
+
+const maxSlugLength = 200
+
+// GeneratePostSlug derives a unique slug from a title, adding a random suffix when the plain
+// slug is taken. Soft-deleted posts keep their slug reserved since the unique index covers them.
+func GeneratePostSlug(ctx context.Context, db *gorm.DB, title string) (string, error) {
+	return generateSlug(ctx, db, &Posts{}, title, "post", maxSlugLength)
+}
+
+// generateSlug derives a slug from a title that is unique among the rows of model, deleted ones
+// included, falling back to fallback when the title has nothing to slugify.
+func generateSlug(ctx context.Context, db *gorm.DB, model interface{}, title, fallback string, maxLength int) (string, error) {
+	base := utils.Slugify(title, maxLength)
+	if base == "" {
+		base = fallback
+	}
+
+	slug := base
+	for attempt := 0; attempt < 5; attempt++ {
+		var count int64
+		if err := db.WithContext(ctx).Unscoped().Model(model).Where("slug = ?", slug).Count(&count).Error; err != nil {
+			return "", utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check slug")
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		suffix, err := utils.GenerateOTP(6)
+		if err != nil {
+			return "", utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to generate slug")
+		}
+		slug = base + "-" + strings.ToLower(suffix)
+	}
+	return "", utils.NewError(utils.ErrConflict.Code, "Could not generate a unique slug, please choose one")
+}
+
+// PublishPost publishes a post now, or schedules it when at lies in the future. timezone is the IANA
+// timezone the author scheduled in, kept alongside the schedule for display.
+func PublishPost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, postID uuid.UUID, at *time.Time, timezone string) (*Posts, error) {
+	post, err := GetPostsBy(ctx, rclient, db, "id = ?", []interface{}{postID})
+	if err != nil {
+		return nil, err
+	}
+	if post.PublishingStatus == "moderation" {
+		return nil, utils.NewError(utils.ErrForbidden.Code, "Post is awaiting moderation")
+	}
+
+	now := time.Now()
+	if at != nil && at.After(now) {
+		return UpdatePost(ctx, rclient, db, post,
+			WithStatus(PostStatusScheduled),
+			WithPublished(false),
+			WithPublishedAt(nil),
+			WithScheduledAt(at),
+			WithScheduleTimezone(timezone),
+		)
+	}
+	if post.Published {
+		return post, nil
+	}
+	return UpdatePost(ctx, rclient, db, post,
+		WithStatus(PostStatusPublished),
+		WithPublished(true),
+		WithPublishedAt(&now),
+		WithScheduledAt(nil),
+		WithScheduleTimezone(""),
+	)
+}
+
+// UnpublishPost turns a published or scheduled post back into a draft.
+func UnpublishPost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, postID uuid.UUID) (*Posts, error) {
+	post, err := GetPostsBy(ctx, rclient, db, "id = ?", []interface{}{postID})
+	if err != nil {
+		return nil, err
+	}
+	return UpdatePost(ctx, rclient, db, post,
+		WithStatus(PostStatusDraft),
+		WithPublished(false),
+		WithPublishedAt(nil),
+		WithScheduledAt(nil),
+		WithScheduleTimezone(""),
+	)
+}
+
+// PublishDuePosts publishes scheduled posts whose publish time has passed and returns how many it
+// published. Every post is claimed before it is published, so workers running on several
+// instances publish it, and announce it, once. A post that fails is retried on the next run
+// without holding back the others.
+func PublishDuePosts(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB) (int, error) {
+	var due []uuid.UUID
+	if err := db.WithContext(ctx).Model(&Posts{}).
+		Where("status = ? AND scheduled_at <= ? AND publishing_status <> ?", PostStatusScheduled, time.Now(), "moderation").
+		Order("scheduled_at").
+		Pluck("id", &due).Error; err != nil {
+		return 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch scheduled posts")
+	}
+
+	published := 0
+	var errs []error
+	for _, id := range due {
+		claimed, err := rclient.SetNX(ctx, cache.ScheduledPublishKey(id), 1, time.Minute).Result()
+		if err != nil {
+			errs = append(errs, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to claim scheduled post"))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		// The author may have published, rescheduled or unpublished the post since it was listed
+		err = db.WithContext(ctx).Select("id").
+			Where("id = ? AND status = ? AND scheduled_at <= ?", id, PostStatusScheduled, time.Now()).
+			First(&Posts{}).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+		case err != nil:
+			errs = append(errs, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch scheduled post"))
+		default:
+			if _, err := PublishPost(ctx, rclient, db, id, nil, ""); err != nil {
+				errs = append(errs, err)
+			} else {
+				published++
+			}
+		}
+		cache.Delete(ctx, rclient, cache.ScheduledPublishKey(id))
+	}
+	return published, errors.Join(errs...)
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// reconcileLock is the advisory lock key letting one instance at a time reconcile counters.
+const reconcileLock = 7_241_002
+
+// userStatsRecount recomputes the content counters of users from the tables they count, setting
+// only the rows that drifted and returning their ids. The %s verb takes an extra filter on the
+// users. posts_count counts the published posts a user wrote or co-authors. likes_count is left
+// alone: no table records likes yet.
+const userStatsRecount = `
+WITH counts AS (
+	SELECT u.id,
+		(SELECT count(*) FROM posts p WHERE p.published AND p.deleted_at IS NULL AND (p.author_id = u.id
+			OR p.id IN (SELECT pa.post_id FROM post_authors pa WHERE pa.user_id = u.id AND pa.status = 'accepted'))) AS posts_count,
+		(SELECT count(*) FROM comments c WHERE c.author_id = u.id AND c.deleted_at IS NULL) AS comments_count,
+		(SELECT count(*) FROM bookmarks b WHERE b.user_id = u.id AND b.deleted_at IS NULL) AS bookmarks_count
+	FROM users u
+	WHERE u.deleted_at IS NULL %s
+)
+UPDATE users SET
+	posts_count = counts.posts_count,
+	comments_count = counts.comments_count,
+	bookmarks_count = counts.bookmarks_count
+FROM counts
+WHERE users.id = counts.id
+	AND (users.posts_count, users.comments_count, users.bookmarks_count)
+		IS DISTINCT FROM (counts.posts_count, counts.comments_count, counts.bookmarks_count)
+RETURNING users.id`
+
+// postCountsRecount does the same for the counters of post analytics.
+const postCountsRecount = `
+WITH counts AS (
+	SELECT pa.post_id,
+		(SELECT count(*) FROM comments c WHERE c.post_id = pa.post_id AND c.deleted_at IS NULL) AS comments_count,
+		(SELECT count(*) FROM bookmarks b WHERE b.post_id = pa.post_id AND b.deleted_at IS NULL) AS bookmarks_count
+	FROM post_analytics pa
+)
+UPDATE post_analytics SET
+	comments_count = counts.comments_count,
+	bookmarks_count = counts.bookmarks_count
+FROM counts
+WHERE post_analytics.post_id = counts.post_id
+	AND (post_analytics.comments_count, post_analytics.bookmarks_count)
+		IS DISTINCT FROM (counts.comments_count, counts.bookmarks_count)
+RETURNING post_analytics.post_id`
+
+// RecountUserStats recomputes the post, comment and bookmark counters of a user from the rows
+// they count and returns the user with the corrected stats.
+func RecountUserStats(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID uuid.UUID) (*user.User, error) {
+	var fixed []uuid.UUID
+	if err := db.WithContext(ctx).Raw(fmt.Sprintf(userStatsRecount, "AND u.id = ?"), userID).Scan(&fixed).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to recount user stats")
+	}
+	if len(fixed) > 0 {
+		cache.InvalidateUser(ctx, rclient, userID)
+	}
+	return user.GetUserBy(ctx, rclient, db, "id = ?", []interface{}{userID})
+}
+
+// ReconcileCounters corrects every user stat and post analytics counter that drifted from the
+// rows it counts and returns how many users and posts were corrected. Instances share the work
+// through an advisory lock: when another instance is already reconciling, it returns at once.
+func ReconcileCounters(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB) (int, int, error) {
+	var users, posts []uuid.UUID
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", reconcileLock).Scan(&locked).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to acquire reconciliation lock")
+		}
+		if !locked {
+			return nil
+		}
+		if err := tx.Raw(fmt.Sprintf(userStatsRecount, "")).Scan(&users).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to reconcile user stats")
+		}
+		if err := tx.Raw(postCountsRecount).Scan(&posts).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to reconcile post analytics")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, id := range users {
+		cache.InvalidateUser(ctx, rclient, id)
+	}
+	for _, id := range posts {
+		rclient.Del(ctx, "post_analytics:"+id.String())
+	}
+	return len(users), len(posts), nil
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// Kinds of search documents.
+const (
+	SearchKindPost = "post"
+	SearchKindUser = "user"
+)
+
+// searchLanguage is the text search configuration documents and queries are parsed with.
+const searchLanguage = "english"
+
+// SearchDocument is the entry of a published post or an active user in the search index. The index
+// is kept up to date by the outbox worker from post.* and user.updated events, so it trails the
+// source tables by a few seconds, and can be rebuilt in full with ReindexSearch.
+type SearchDocument struct {
+	Kind      string    `gorm:"size:10;primaryKey" json:"kind"`
+	EntityID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Title     string    `gorm:"size:255;not null" json:"title"`
+	Summary   string    `gorm:"type:text" json:"summary"`
+	Slug      string    `gorm:"size:255;not null" json:"slug"`
+	Vector    string    `gorm:"type:tsvector;index:idx_search_vector,type:gin" json:"-"`
+	IndexedAt time.Time `gorm:"not null" json:"indexed_at"`
+}
+
+// SearchResult is a document matching a query, ranked by relevance.
+type SearchResult struct {
+	SearchDocument
+	Rank float64 `json:"rank"`
+}
+
+// ReindexProgress reports how far a full reindex got through one kind of document.
+type ReindexProgress struct {
+	Kind  string
+	Done  int64
+	Total int64
+}
+
+// IndexPosts brings the search documents of posts in line with the posts: published posts are
+// indexed by title, tags, excerpt and content, and any other post is dropped from the index.
+// Unlisted posts are never indexed; Search hides the others from readers who may not see them.
+func IndexPosts(ctx context.Context, db *gorm.DB, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO search_documents (kind, entity_id, title, summary, slug, vector, indexed_at)
+			SELECT ?, p.id, p.title, p.excerpt, p.slug,
+				setweight(to_tsvector(?, coalesce(p.title, '')), 'A') ||
+				setweight(to_tsvector(?, coalesce((SELECT string_agg(t.name, ' ') FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.posts_id = p.id), '')), 'B') ||
+				setweight(to_tsvector(?, coalesce(p.excerpt, '')), 'B') ||
+				setweight(to_tsvector(?, coalesce(p.content, '')), 'C'),
+				NOW()
+			FROM posts p
+			WHERE p.id IN ? AND p.published = TRUE AND p.visibility <> ? AND p.deleted_at IS NULL
+			ON CONFLICT (kind, entity_id) DO UPDATE SET
+				title = EXCLUDED.title, summary = EXCLUDED.summary, slug = EXCLUDED.slug,
+				vector = EXCLUDED.vector, indexed_at = EXCLUDED.indexed_at`,
+			SearchKindPost, searchLanguage, searchLanguage, searchLanguage, searchLanguage, ids, VisibilityUnlisted).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to index posts")
+		}
+		if err := tx.Exec(`
+			DELETE FROM search_documents WHERE kind = ? AND entity_id IN ?
+			AND entity_id NOT IN (SELECT id FROM posts WHERE id IN ? AND published = TRUE AND visibility <> ? AND deleted_at IS NULL)`,
+			SearchKindPost, ids, ids, VisibilityUnlisted).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to drop posts from the search index")
+		}
+		return nil
+	})
+}
+
+// IndexUsers brings the search documents of users in line with the users: active accounts are
+// indexed by username, name, bio and job title, and any other account is dropped from the index.
+func IndexUsers(ctx context.Context, db *gorm.DB, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO search_documents (kind, entity_id, title, summary, slug, vector, indexed_at)
+			SELECT ?, u.id, coalesce(nullif(u.name, ''), u.username), u.bio, u.username,
+				setweight(to_tsvector('simple', u.username), 'A') ||
+				setweight(to_tsvector(?, coalesce(u.name, '')), 'A') ||
+				setweight(to_tsvector(?, coalesce(u.job_title, '') || ' ' || coalesce(u.employer, '')), 'B') ||
+				setweight(to_tsvector(?, coalesce(u.bio, '')), 'C'),
+				NOW()
+			FROM users u
+			WHERE u.id IN ? AND u.is_active = TRUE AND u.is_email_verified = TRUE AND u.status = ? AND u.deleted_at IS NULL
+			ON CONFLICT (kind, entity_id) DO UPDATE SET
+				title = EXCLUDED.title, summary = EXCLUDED.summary, slug = EXCLUDED.slug,
+				vector = EXCLUDED.vector, indexed_at = EXCLUDED.indexed_at`,
+			SearchKindUser, searchLanguage, searchLanguage, searchLanguage, ids, user.UserStatusActive).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to index users")
+		}
+		if err := tx.Exec(`
+			DELETE FROM search_documents WHERE kind = ? AND entity_id IN ?
+			AND entity_id NOT IN (SELECT id FROM users WHERE id IN ? AND is_active = TRUE AND is_email_verified = TRUE AND status = ? AND deleted_at IS NULL)`,
+			SearchKindUser, ids, ids, user.UserStatusActive).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to drop users from the search index")
+		}
+		return nil
+	})
+}
+
+// Search retrieves a page of the documents matching query, best match first. query takes web
+// search syntax: quoted phrases, OR and -excluded words. An empty kind searches every kind. Posts
+// are limited to those listed to viewerID, nil for an anonymous visitor.
+func Search(ctx context.Context, db *gorm.DB, query, kind string, viewerID *uuid.UUID, page, limit int) ([]SearchResult, int64, error) {
+	listed := db.Model(&Posts{}).Select("posts.id").Scopes(ListedTo(viewerID))
+	q := db.WithContext(ctx).Model(&SearchDocument{}).
+		Where("vector @@ websearch_to_tsquery(?, ?)", searchLanguage, query).
+		Where("(kind <> ? OR entity_id IN (?))", SearchKindPost, listed)
+	if kind != "" {
+		q = q.Where("kind = ?", kind)
+	}
+	q = q.Session(&gorm.Session{})
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count search results")
+	}
+
+	results := []SearchResult{}
+	if err := q.Select("kind, entity_id, title, summary, slug, indexed_at, ts_rank(vector, websearch_to_tsquery(?, ?)) AS rank", searchLanguage, query).
+		Order("rank DESC, indexed_at DESC").Offset((page - 1) * limit).Limit(limit).
+		Scan(&results).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to search")
+	}
+	return results, total, nil
+}
+
+// ReindexSearch rebuilds the whole search index in batches of batchSize, posts first and users
+// second, then drops documents whose post or user is gone. progress is called after every batch.
+// Documents are upserted in place, so search keeps working while the reindex runs.
+func ReindexSearch(ctx context.Context, db *gorm.DB, batchSize int, progress func(ReindexProgress)) error {
+	sources := []struct {
+		kind  string
+		model interface{}
+		index func(context.Context, *gorm.DB, []uuid.UUID) error
+	}{
+		{SearchKindPost, &Posts{}, IndexPosts},
+		{SearchKindUser, &user.User{}, IndexUsers},
+	}
+	for _, source := range sources {
+		p := ReindexProgress{Kind: source.kind}
+		if err := db.WithContext(ctx).Model(source.model).Count(&p.Total).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count "+source.kind+"s to reindex")
+		}
+		progress(p)
+
+		last := uuid.Nil
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var ids []uuid.UUID
+			if err := db.WithContext(ctx).Model(source.model).Where("id > ?", last).
+				Order("id").Limit(batchSize).Pluck("id", &ids).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to list "+source.kind+"s to reindex")
+			}
+			if len(ids) == 0 {
+				break
+			}
+			if err := source.index(ctx, db, ids); err != nil {
+				return err
+			}
+			last = ids[len(ids)-1]
+			p.Done += int64(len(ids))
+			progress(p)
+		}
+	}
+
+	if err := db.WithContext(ctx).Exec(`
+		DELETE FROM search_documents d WHERE
+		(d.kind = ? AND NOT EXISTS (SELECT 1 FROM posts p WHERE p.id = d.entity_id AND p.deleted_at IS NULL)) OR
+		(d.kind = ? AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = d.entity_id AND u.deleted_at IS NULL))`,
+		SearchKindPost, SearchKindUser).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to drop stale search documents")
+	}
+	return nil
+}
@@ -2,23 +2,26 @@ package models
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Series is an ordered run of posts by one author, such as a multi-part tutorial. Its parts are
+// SeriesPost rows numbered from 1, and each part's post points back at it through SeriesID, so a
+// post belongs to one series at most.
 type Series struct {
 	ID            uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
-	Title         string    `gorm:"size:120;not null;uniqueIndex:idx_series_title" json:"title" validate:"required,min=5,max=120"`
+	Title         string    `gorm:"size:120;not null" json:"title" validate:"required,min=3,max=120"`
 	Slug          string    `gorm:"size:140;not null;uniqueIndex:idx_series_slug" json:"slug" validate:"required,max=140,customSlug"`
-	Description   string    `gorm:"type:text;not null" json:"description" validate:"required,min=50,max=1000"`
+	Description   string    `gorm:"type:text;not null" json:"description" validate:"omitempty,max=1000"`
 	CoverImageURL string    `gorm:"size:500" json:"cover_image_url" validate:"omitempty,url,max=500"`
 	AuthorID      uuid.UUID `gorm:"type:uuid;not null;index:idx_series_author" json:"author_id" validate:"required"`
 	IsPublished   bool      `gorm:"default:false;index" json:"is_published"`
@@ -33,389 +36,397 @@ type Series struct {
 	Analytics   SeriesAnalytics `gorm:"foreignKey:SeriesID" json:"analytics" validate:"-"`
 }
 
-// SeriesOption defines a function type that takes a pointer to a Tag and modifies it.
+// SeriesOption configures a Series.
 type SeriesOption func(*Series)
 
-// CreateSeries initializes a new series with analytics
-func CreateSeries(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, series *Series) error {
-	series.Title = strings.TrimSpace(series.Title)
-	series.Slug = strings.ToLower(strings.TrimSpace(series.Slug))
-	series.Description = strings.TrimSpace(series.Description)
-	series.CoverImageURL = strings.TrimSpace(series.CoverImageURL)
+// SeriesPart is a post of a series as listed in it.
+type SeriesPart struct {
+	Part        int        `json:"part"`
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Excerpt     string     `json:"excerpt"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at"`
+}
 
-	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var existingByTitle Series
-		if err := tx.Where("title = ?", series.Title).First(&existingByTitle).Error; err == nil {
-			return utils.NewError(utils.ErrBadRequest.Code, "Series title already exists")
-		} else if err != gorm.ErrRecordNotFound {
-			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check series title")
-		}
+// PublishedSeries is a series as readers see it: its published parts in order, numbered from 1.
+type PublishedSeries struct {
+	Series Series       `json:"series"`
+	Parts  []SeriesPart `json:"parts"`
+}
 
-		_, err := user.GetUserBy(ctx, rclient, tx, "id = ?", []interface{}{series.AuthorID})
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return utils.NewError(utils.ErrNotFound.Code, "Author not found")
-			}
-			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch author")
-		}
+// SeriesNavigation places a post within its series: its part number, the number of parts and the
+// parts before and after it.
+type SeriesNavigation struct {
+	ID       uuid.UUID   `json:"id"`
+	Title    string      `json:"title"`
+	Slug     string      `json:"slug"`
+	Part     int         `json:"part"`
+	Total    int         `json:"total"`
+	Previous *SeriesPart `json:"previous"`
+	Next     *SeriesPart `json:"next"`
+}
 
-		if err := tx.Create(series).Error; err != nil {
-			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create series")
+// Navigation places a post within the series, or returns nil when the post is not one of its
+// published parts.
+func (s *PublishedSeries) Navigation(postID uuid.UUID) *SeriesNavigation {
+	for i, part := range s.Parts {
+		if part.ID != postID {
+			continue
 		}
-
-		analytics := &SeriesAnalytics{SeriesID: series.ID}
-		if err := CreateSeriesAnalytics(ctx, rclient, tx, analytics); err != nil {
-			return err
+		nav := &SeriesNavigation{ID: s.Series.ID, Title: s.Series.Title, Slug: s.Series.Slug, Part: part.Part, Total: len(s.Parts)}
+		if i > 0 {
+			previous := s.Parts[i-1]
+			nav.Previous = &previous
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		return err
+		if i+1 < len(s.Parts) {
+			next := s.Parts[i+1]
+			nav.Next = &next
+		}
+		return nav
 	}
-
-	seriesData, _ := json.Marshal(series)
-	rclient.Set(ctx, "series:"+series.ID.String(), seriesData, 24*time.Hour)
-	rclient.Set(ctx, "series:slug:"+series.Slug, seriesData, 24*time.Hour)
-
 	return nil
 }
 
-// GetSeries retrieves a series by condition
-func GetSeries(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, condition string, args []interface{}, preload ...string) (*Series, error) {
-	cacheKey := ""
-	switch condition {
-	case "id = ?":
-		cacheKey = "series:" + args[0].(uuid.UUID).String()
-	case "slug = ?":
-		cacheKey = "series:slug:" + args[0].(string)
-	}
-	if cacheKey != "" {
-		if cached, err := rclient.Get(ctx, cacheKey).Result(); err == nil {
-			var series Series
-			if json.Unmarshal([]byte(cached), &series) == nil {
-				return &series, nil
-			}
-		}
+const maxSeriesSlugLength = 130
+
+// CreateSeries creates a series without parts, deriving its slug from the title.
+func CreateSeries(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, series *Series) error {
+	series.Title = strings.TrimSpace(series.Title)
+	series.Description = strings.TrimSpace(series.Description)
+	series.CoverImageURL = strings.TrimSpace(series.CoverImageURL)
+	if series.Title == "" || series.AuthorID == uuid.Nil {
+		return utils.NewError(utils.ErrBadRequest.Code, "Required fields missing: title, author_id")
 	}
 
-	query := db.WithContext(ctx).Where(condition, args...)
-	for _, p := range preload {
-		query = query.Preload(p)
+	slug, err := generateSlug(ctx, db, &Series{}, series.Title, "series", maxSeriesSlugLength)
+	if err != nil {
+		return err
 	}
+	series.Slug = slug
+	series.TotalPosts = 0
+	if err := db.WithContext(ctx).Create(series).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create series")
+	}
+	return nil
+}
 
+// GetSeries retrieves a series by ID.
+func GetSeries(ctx context.Context, db *gorm.DB, id uuid.UUID) (*Series, error) {
 	var series Series
-	if err := query.First(&series).Error; err != nil {
+	if err := db.WithContext(ctx).Where("id = ?", id).First(&series).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, utils.NewError(utils.ErrNotFound.Code, "Series not found")
 		}
 		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch series")
 	}
-
-	if cacheKey != "" {
-		seriesData, _ := json.Marshal(series)
-		rclient.Set(ctx, cacheKey, seriesData, 24*time.Hour)
-	}
-
 	return &series, nil
 }
 
-// UpdateSeries modifies a series using options
-func UpdateSeries(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, seriesID uuid.UUID, options ...SeriesOption) (*Series, error) {
-	tx := db.WithContext(ctx).Begin()
-	series, err := GetSeries(ctx, rclient, db, "id = ?", []interface{}{seriesID}, "")
-	if err != nil {
-		return nil, err
-	}
-
-	originalSlug := series.Slug
-	for _, opt := range options {
-		opt(series)
-	}
-
-	err = tx.Transaction(func(tx *gorm.DB) error {
-		var original Series
-		if err := tx.Where("id = ?", series.ID).First(&original).Error; err != nil {
-			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch original series")
-		}
-		if series.Title != "" && series.Title != series.Title {
-			var existingByTitle Series
-			if err := tx.Where("title = ? AND id != ?", series.Title, series.ID).First(&existingByTitle).Error; err == nil {
-				return utils.NewError(utils.ErrBadRequest.Code, "Series title already exists")
-			} else if err != gorm.ErrRecordNotFound {
-				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check series title")
-			}
+// UpdateSeries updates a series' title, slug, description or cover.
+func UpdateSeries(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID, opts ...SeriesOption) (*Series, error) {
+	var series *Series
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if series, err = lockSeries(tx, id); err != nil {
+			return err
 		}
-
-		if series.Slug != originalSlug && series.Slug != "" {
-			var existingBySlug Series
-			if err := tx.Where("slug = ? AND id != ?", series.Slug, series.ID).First(&existingBySlug).Error; err == nil {
-				return utils.NewError(utils.ErrBadRequest.Code, "Series slug already exists")
-			} else if err != gorm.ErrRecordNotFound {
+		originalSlug := series.Slug
+		for _, opt := range opts {
+			opt(series)
+		}
+		if series.Slug == "" {
+			return utils.NewError(utils.ErrBadRequest.Code, "Slug cannot be empty")
+		}
+		if series.Slug != originalSlug {
+			// Deleted series keep their slug so old links never point at another series
+			var taken int64
+			if err := tx.Unscoped().Model(&Series{}).Where("slug = ?", series.Slug).Count(&taken).Error; err != nil {
 				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check series slug")
 			}
-		}
-
-		if series.AuthorID != uuid.Nil && series.AuthorID != series.AuthorID {
-			_, err := user.GetUserBy(ctx, rclient, tx, "id = ?", []interface{}{series.AuthorID})
-			if err != nil {
-				if err == gorm.ErrRecordNotFound {
-					return utils.NewError(utils.ErrNotFound.Code, "Author not found")
-				}
-				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch author")
+			if taken > 0 {
+				return utils.NewError(utils.ErrConflict.Code, "Series slug already taken")
 			}
 		}
-
-		if err := tx.Save(series).Error; err != nil {
+		if err := tx.Omit(clause.Associations).Save(series).Error; err != nil {
 			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update series")
 		}
-
 		return nil
 	})
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
-	tx.Commit()
-
-	seriesData, _ := json.Marshal(series)
-	rclient.Set(ctx, "series:"+series.ID.String(), seriesData, 24*time.Hour)
-	rclient.Set(ctx, "series:slug:"+series.Slug, seriesData, 24*time.Hour)
-	if series.Slug != originalSlug {
-		rclient.Del(ctx, "series:slug:"+originalSlug)
-	}
-	rclient.Set(ctx, "series:total_posts:"+series.ID.String(), series.TotalPosts, 24*time.Hour)
-
+	cache.Invalidate(ctx, rclient, cache.SeriesTag(id))
 	return series, nil
 }
 
-// DeleteSeries soft-deletes a series and its related data
-func DeleteSeries(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, seriesID uuid.UUID) error {
-	tx := db.WithContext(ctx).Begin()
-	series, err := GetSeries(ctx, rclient, db, "id = ?", []interface{}{seriesID})
-	if err != nil {
-		return err
-	}
-
-	err = tx.Transaction(func(tx *gorm.DB) error {
-		if err := DeleteSeriesPosts(ctx, rclient, tx, seriesID); err != nil {
+// DeleteSeries deletes a series. Its posts stay as they are, only no longer part of it.
+func DeleteSeries(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID) error {
+	var parts []Posts
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockSeries(tx, id); err != nil {
 			return err
 		}
-
-		if err := DeleteSeriesAnalytics(ctx, rclient, tx, seriesID); err != nil {
-			return err
+		if err := tx.Select("id, slug").Where("series_id = ?", id).Find(&parts).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch series posts")
 		}
-
-		if err := tx.Delete(&Series{ID: seriesID}).Error; err != nil {
+		if err := tx.Model(&Posts{}).Where("series_id = ?", id).UpdateColumn("series_id", nil).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to detach series posts")
+		}
+		if err := tx.Where("series_id = ?", id).Delete(&SeriesPost{}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete series posts")
+		}
+		if err := tx.Delete(&Series{}, "id = ?", id).Error; err != nil {
 			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete series")
 		}
-
 		return nil
 	})
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
-
-	rclient.Del(ctx,
-		"series:"+seriesID.String(),
-		"series:slug:"+series.Slug,
-		"series:analytics:"+seriesID.String(),
-		"series:total_posts:"+seriesID.String(),
-	)
-
+	cache.Invalidate(ctx, rclient, cache.SeriesTag(id))
+	for i := range parts {
+		dropPartCaches(ctx, rclient, &parts[i])
+	}
 	return nil
 }
 
-// ListSeries retrieves a paginated list of series
-func ListSeries(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, page, limit int, authorID uuid.UUID, isPublished *bool) ([]Series, int64, error) {
-	if page < 1 || limit < 1 {
-		return nil, 0, utils.NewError(utils.ErrBadRequest.Code, "Invalid page or limit")
-	}
-
-	cacheKey := fmt.Sprintf("series:list:page:%d:limit:%d:author:%s:pub:%v", page, limit, authorID.String(), isPublished)
-	if cached, err := rclient.Get(ctx, cacheKey).Result(); err == nil {
-		var seriesList struct {
-			Series []Series
-			Total  int64
-		}
-		if json.Unmarshal([]byte(cached), &seriesList) == nil {
-			return seriesList.Series, seriesList.Total, nil
-		}
-	}
-
-	query := db.WithContext(ctx)
-	if authorID != uuid.Nil {
-		query = query.Where("author_id = ?", authorID)
-	}
-	if isPublished != nil {
-		query = query.Where("is_published = ?", *isPublished)
-	}
+// ListAuthorSeries retrieves a page of an author's series, most recently updated first.
+func ListAuthorSeries(ctx context.Context, db *gorm.DB, authorID uuid.UUID, page, limit int) ([]Series, int64, error) {
+	query := db.WithContext(ctx).Model(&Series{}).Where("author_id = ?", authorID).Session(&gorm.Session{})
 
 	var total int64
-	if err := query.Model(&Series{}).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count series")
 	}
 
-	var series []Series
-	offset := (page - 1) * limit
-	if err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&series).Error; err != nil {
+	series := []Series{}
+	if err := query.Order("updated_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&series).Error; err != nil {
 		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch series")
 	}
+	return series, total, nil
+}
 
-	seriesList := struct {
-		Series []Series
-		Total  int64
-	}{series, total}
-	seriesData, _ := json.Marshal(seriesList)
-	rclient.Set(ctx, cacheKey, seriesData, 1*time.Hour)
+// GetSeriesParts lists every post of a series in order, drafts included, for its author.
+func GetSeriesParts(ctx context.Context, db *gorm.DB, seriesID uuid.UUID) ([]SeriesPart, error) {
+	parts := []SeriesPart{}
+	if err := seriesParts(db.WithContext(ctx), seriesID).Scan(&parts).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch series posts")
+	}
+	return parts, nil
+}
 
-	return series, total, nil
+// GetPublishedSeries retrieves a series with its published parts by ID.
+func GetPublishedSeries(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID) (*PublishedSeries, error) {
+	return cache.Fetch(ctx, rclient, cache.SeriesPartsKey(id), rclient.TTL("series_posts"), func(ctx context.Context) (*PublishedSeries, []string, error) {
+		return loadPublishedSeries(ctx, db, "id = ?", id)
+	})
 }
 
-// AddSeriesPost adds a post to a series
-func AddSeriesPost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, seriesID, postID uuid.UUID, order int) error {
-	tx := db.WithContext(ctx).Begin()
-	series, err := GetSeries(ctx, rclient, db, "id = ?", []interface{}{seriesID})
-	if err != nil {
-		return err
+// GetPublishedSeriesBySlug retrieves a series with its published parts by slug.
+func GetPublishedSeriesBySlug(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, slug string) (*PublishedSeries, error) {
+	return cache.Fetch(ctx, rclient, cache.SeriesKey(slug), rclient.TTL("series_posts"), func(ctx context.Context) (*PublishedSeries, []string, error) {
+		return loadPublishedSeries(ctx, db, "slug = ?", slug)
+	})
+}
+
+func loadPublishedSeries(ctx context.Context, db *gorm.DB, condition string, arg interface{}) (*PublishedSeries, []string, error) {
+	var series Series
+	if err := db.WithContext(ctx).Preload("Author", authorSummary).Where(condition, arg).First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, utils.NewError(utils.ErrNotFound.Code, "Series not found")
+		}
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch series")
+	}
+
+	parts := []SeriesPart{}
+	// The series is cached for every reader, so parts not open to everyone are left out
+	if err := seriesParts(db.WithContext(ctx), series.ID).Scopes(ListedTo(nil)).Scan(&parts).Error; err != nil {
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch series posts")
 	}
-	err = tx.Transaction(func(tx *gorm.DB) error {
-		_, err := GetPostsBy(ctx, rclient, tx, "id = ?", []interface{}{postID})
+	// Readers count the published parts only, so a draft between two parts leaves no gap
+	for i := range parts {
+		parts[i].Part = i + 1
+	}
+	return &PublishedSeries{Series: series, Parts: parts}, []string{cache.SeriesTag(series.ID), cache.UserTag(series.AuthorID)}, nil
+}
+
+// AddSeriesPost adds one of the series author's posts to a series at position, shifting the parts
+// from there on back. A position of 0 or past the end appends the post.
+func AddSeriesPost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, seriesID, postID uuid.UUID, position int) error {
+	var post Posts
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		series, err := lockSeries(tx, seriesID)
 		if err != nil {
+			return err
+		}
+		if err := tx.Select("id, slug, author_id, series_id").Where("id = ?", postID).First(&post).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return utils.NewError(utils.ErrNotFound.Code, "Post not found")
 			}
 			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch post")
 		}
-
-		var existing SeriesPost
-		if err := tx.Where("series_id = ? AND post_id = ?", seriesID, postID).First(&existing).Error; err == nil {
-			return utils.NewError(utils.ErrBadRequest.Code, "Post already in series")
-		} else if err != gorm.ErrRecordNotFound {
-			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check series post")
+		if post.AuthorID != series.AuthorID {
+			return utils.NewError(utils.ErrForbidden.Code, "Only posts by the series author can be added")
+		}
+		if post.SeriesID != nil {
+			if *post.SeriesID == seriesID {
+				return utils.NewError(utils.ErrConflict.Code, "Post is already part of the series")
+			}
+			return utils.NewError(utils.ErrConflict.Code, "Post is already part of another series")
 		}
 
-		seriesPost := &SeriesPost{
-			SeriesID: seriesID,
-			PostID:   postID,
-			Position: order,
+		var count int64
+		if err := tx.Model(&SeriesPost{}).Where("series_id = ?", seriesID).Count(&count).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count series posts")
+		}
+		if position < 1 || position > int(count)+1 {
+			position = int(count) + 1
 		}
-		if err := tx.Create(seriesPost).Error; err != nil {
+		if err := tx.Model(&SeriesPost{}).Where("series_id = ? AND position >= ?", seriesID, position).
+			UpdateColumn("position", gorm.Expr("position + 1")).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to move series posts")
+		}
+		if err := tx.Omit(clause.Associations).Create(&SeriesPost{SeriesID: seriesID, PostID: postID, Position: position}).Error; err != nil {
 			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to add series post")
 		}
-
-		series.TotalPosts++
-		if err := tx.Model(series).Update("total_posts", series.TotalPosts).Error; err != nil {
-			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update total posts")
+		if err := tx.Model(&Posts{}).Where("id = ?", postID).UpdateColumn("series_id", seriesID).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to add series post")
 		}
-
-		if err := SyncSeriesAnalytics(ctx, rclient, tx, seriesID, 1, 0); err != nil {
-			return err
+		if err := tx.Model(&Series{}).Where("id = ?", seriesID).Updates(map[string]interface{}{"total_posts": count + 1}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update series")
 		}
-
 		return nil
 	})
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
-
-	seriesData, _ := json.Marshal(series)
-	rclient.Set(ctx, "series:"+series.ID.String(), seriesData, 24*time.Hour)
-	rclient.Set(ctx, "series:slug:"+series.Slug, seriesData, 24*time.Hour)
-	rclient.Set(ctx, "series:total_posts:"+series.ID.String(), series.TotalPosts, 24*time.Hour)
-	rclient.Del(ctx, "series:posts:"+series.ID.String())
-
+	cache.Invalidate(ctx, rclient, cache.SeriesTag(seriesID))
+	dropPartCaches(ctx, rclient, &post)
 	return nil
 }
 
-// RemoveSeriesPost removes a post from a series
+// RemoveSeriesPost takes a post out of a series, closing the gap it leaves.
 func RemoveSeriesPost(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, seriesID, postID uuid.UUID) error {
-	tx := db.WithContext(ctx).Begin()
-	series, err := GetSeries(ctx, rclient, db, "id = ?", []interface{}{seriesID})
+	var post Posts
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockSeries(tx, seriesID); err != nil {
+			return err
+		}
+		if err := tx.Select("id, slug").Where("id = ?", postID).First(&post).Error; err != nil && err != gorm.ErrRecordNotFound {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch post")
+		}
+		return detachSeriesPost(tx, seriesID, postID)
+	})
 	if err != nil {
 		return err
 	}
-	err = tx.Transaction(func(tx *gorm.DB) error {
-		result := tx.Where("series_id = ? AND post_id = ?", seriesID, postID).Delete(&SeriesPost{})
-		if result.Error != nil {
-			return utils.WrapError(result.Error, utils.ErrInternalServerError.Code, "Failed to remove series post")
+
+	cache.Invalidate(ctx, rclient, cache.SeriesTag(seriesID))
+	dropPartCaches(ctx, rclient, &post)
+	return nil
+}
+
+// ReorderSeriesPosts puts the parts of a series in the order of postIDs, which must list each of
+// them exactly once.
+func ReorderSeriesPosts(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, seriesID uuid.UUID, postIDs []uuid.UUID) error {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockSeries(tx, seriesID); err != nil {
+			return err
 		}
-		if result.RowsAffected == 0 {
-			return utils.NewError(utils.ErrNotFound.Code, "Series post not found")
+		var current []SeriesPost
+		if err := tx.Where("series_id = ?", seriesID).Find(&current).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch series posts")
 		}
 
-		series.TotalPosts--
-		if series.TotalPosts < 0 {
-			series.TotalPosts = 0
+		positions := make(map[uuid.UUID]int, len(current))
+		for _, part := range current {
+			positions[part.PostID] = part.Position
 		}
-		if err := tx.Model(series).Update("total_posts", series.TotalPosts).Error; err != nil {
-			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update total posts")
+		seen := make(map[uuid.UUID]bool, len(postIDs))
+		for _, id := range postIDs {
+			if _, ok := positions[id]; !ok || seen[id] {
+				return utils.NewError(utils.ErrBadRequest.Code, "The order must list every post of the series exactly once")
+			}
+			seen[id] = true
 		}
-
-		if err := SyncSeriesAnalytics(ctx, rclient, tx, seriesID, -1, 0); err != nil {
-			return err
+		if len(postIDs) != len(current) {
+			return utils.NewError(utils.ErrBadRequest.Code, "The order must list every post of the series exactly once")
 		}
 
+		for i, id := range postIDs {
+			if positions[id] == i+1 {
+				continue
+			}
+			if err := tx.Model(&SeriesPost{}).Where("series_id = ? AND post_id = ?", seriesID, id).
+				UpdateColumn("position", i+1).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to reorder series posts")
+			}
+		}
 		return nil
 	})
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
-
-	seriesData, _ := json.Marshal(series)
-	rclient.Set(ctx, "series:"+series.ID.String(), seriesData, 24*time.Hour)
-	rclient.Set(ctx, "series:slug:"+series.Slug, seriesData, 24*time.Hour)
-	rclient.Set(ctx, "series:total_posts:"+series.ID.String(), series.TotalPosts, 24*time.Hour)
-	rclient.Del(ctx, "series:posts:"+series.ID.String())
-
+	cache.Invalidate(ctx, rclient, cache.SeriesTag(seriesID))
 	return nil
 }
 
-// DeleteSeriesPosts removes all series posts for a series
-func DeleteSeriesPosts(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, seriesID uuid.UUID) error {
-	tx := db.WithContext(ctx)
-
-	var postCount int64
-	if err := tx.Model(&SeriesPost{}).Where("series_id = ?", seriesID).Count(&postCount).Error; err != nil {
-		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count series posts")
+// detachSeriesPost takes a post out of a series within tx, closing the gap it leaves.
+func detachSeriesPost(tx *gorm.DB, seriesID, postID uuid.UUID) error {
+	var part SeriesPost
+	res := tx.Clauses(clause.Returning{}).Where("series_id = ? AND post_id = ?", seriesID, postID).Delete(&part)
+	if res.Error != nil {
+		return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to remove series post")
 	}
-	if postCount == 0 {
-		return nil
+	if res.RowsAffected == 0 {
+		return utils.NewError(utils.ErrNotFound.Code, "Post is not part of the series")
 	}
+	if err := tx.Model(&SeriesPost{}).Where("series_id = ? AND position > ?", seriesID, part.Position).
+		UpdateColumn("position", gorm.Expr("position - 1")).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to move series posts")
+	}
+	if err := tx.Model(&Posts{}).Where("id = ?", postID).UpdateColumn("series_id", nil).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to remove series post")
+	}
+	if err := tx.Model(&Series{}).Where("id = ?", seriesID).
+		Updates(map[string]interface{}{"total_posts": gorm.Expr("GREATEST(total_posts - 1, 0)")}).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update series")
+	}
+	return nil
+}
 
-	count := int(postCount)
-	err := tx.Transaction(func(tx *gorm.DB) error {
-		result := tx.Where("series_id = ?", seriesID).Delete(&SeriesPost{})
-		if result.Error != nil {
-			return utils.WrapError(result.Error, utils.ErrInternalServerError.Code, "Failed to delete series posts")
-		}
-
-		if err := tx.Model(&Series{ID: seriesID}).Update("total_posts", 0).Error; err != nil {
-			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to reset total posts")
-		}
-
-		if err := SyncSeriesAnalytics(ctx, rclient, tx, seriesID, -count, 0); err != nil {
-			return err
+// lockSeries loads a series for update, serializing changes to its parts.
+func lockSeries(tx *gorm.DB, id uuid.UUID) (*Series, error) {
+	var series Series
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Series not found")
 		}
-
-		return nil
-	})
-	if err != nil {
-		return err
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch series")
 	}
+	return &series, nil
+}
 
-	rclient.Del(ctx, "series:posts:"+seriesID.String())
+// seriesParts selects the posts of a series in order as SeriesParts, numbered by position.
+func seriesParts(db *gorm.DB, seriesID uuid.UUID) *gorm.DB {
+	return db.Model(&SeriesPost{}).
+		Select("series_posts.position AS part, posts.id, posts.title, posts.slug, posts.excerpt, posts.status, posts.published_at").
+		Joins("JOIN posts ON posts.id = series_posts.post_id AND posts.deleted_at IS NULL").
+		Where("series_posts.series_id = ?", seriesID).
+		Order("series_posts.position")
+}
 
-	return nil
+// dropPartCaches drops the cached records of a post that joined or left a series, which hold its
+// series ID.
+func dropPartCaches(ctx context.Context, rclient *storage.RedisClient, post *Posts) {
+	if post.ID == uuid.Nil {
+		return
+	}
+	cache.Delete(ctx, rclient, cache.PostKey(post.ID), cache.PublicPostKey(post.Slug))
 }
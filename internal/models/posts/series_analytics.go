@@ -29,7 +29,7 @@ type SeriesAnalyticsOption func(*SeriesAnalytics)
 // CreateSeriesAnalytics initializes analytics for a series
 func CreateSeriesAnalytics(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, analytics *SeriesAnalytics) error {
 	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		_, err := GetSeries(ctx, rclient, tx, "id = ?", []interface{}{analytics.SeriesID})
+		_, err := GetSeries(ctx, tx, analytics.SeriesID)
 		if err != nil {
 			return err
 		}
@@ -52,7 +52,7 @@ func CreateSeriesAnalytics(ctx context.Context, rclient *storage.RedisClient, db
 	}
 
 	data, _ := json.Marshal(analytics)
-	rclient.Set(ctx, "series:analytics:"+analytics.SeriesID.String(), data, 1*time.Hour)
+	rclient.Set(ctx, "series:analytics:"+analytics.SeriesID.String(), data, rclient.TTL("series_analytics"))
 
 	return nil
 }
@@ -76,7 +76,7 @@ func GetSeriesAnalytics(ctx context.Context, rclient *storage.RedisClient, db *g
 	}
 
 	data, _ := json.Marshal(analytics)
-	rclient.Set(ctx, cacheKey, data, 1*time.Hour)
+	rclient.Set(ctx, cacheKey, data, rclient.TTL("series_analytics"))
 
 	return &analytics, nil
 }
@@ -108,7 +108,7 @@ func UpdateSeriesAnalytics(ctx context.Context, rclient *storage.RedisClient, db
 	tx.Commit()
 
 	data, _ := json.Marshal(analytics)
-	rclient.Set(ctx, "series:analytics:"+seriesID.String(), data, 1*time.Hour)
+	rclient.Set(ctx, "series:analytics:"+seriesID.String(), data, rclient.TTL("series_analytics"))
 
 	return analytics, nil
 }
@@ -201,7 +201,7 @@ func SyncSeriesAnalytics(ctx context.Context, rclient *storage.RedisClient, db *
 	tx.Commit()
 
 	data, _ := json.Marshal(analytics)
-	rclient.Set(ctx, "series:analytics:"+seriesID.String(), data, 1*time.Hour)
+	rclient.Set(ctx, "series:analytics:"+seriesID.String(), data, rclient.TTL("series_analytics"))
 
 	return nil
 }
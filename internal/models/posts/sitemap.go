@@ -0,0 +1,52 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// SitemapEntry is a public page listed in the sitemap, named by the slug or username in its URL.
+type SitemapEntry struct {
+	Slug      string
+	UpdatedAt time.Time
+}
+
+// ListSitemapPosts lists up to limit published public posts, newest first.
+func ListSitemapPosts(ctx context.Context, db *gorm.DB, limit int) ([]SitemapEntry, error) {
+	entries := []SitemapEntry{}
+	if err := db.WithContext(ctx).Model(&Posts{}).Select("slug, updated_at").
+		Scopes(ListedTo(nil)).Order("published_at DESC").Limit(limit).
+		Scan(&entries).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to list sitemap posts")
+	}
+	return entries, nil
+}
+
+// ListSitemapTags lists up to limit approved tags, most used first.
+func ListSitemapTags(ctx context.Context, db *gorm.DB, limit int) ([]SitemapEntry, error) {
+	entries := []SitemapEntry{}
+	if err := db.WithContext(ctx).Model(&Tag{}).Select("slug, updated_at").
+		Where("is_approved = ? AND posts_count > 0", true).Order("posts_count DESC").Limit(limit).
+		Scan(&entries).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to list sitemap tags")
+	}
+	return entries, nil
+}
+
+// ListSitemapAuthors lists up to limit users with public posts by username, most recently
+// published first, dated by their latest post.
+func ListSitemapAuthors(ctx context.Context, db *gorm.DB, limit int) ([]SitemapEntry, error) {
+	entries := []SitemapEntry{}
+	if err := db.WithContext(ctx).Model(&Posts{}).
+		Select("users.username AS slug, MAX(posts.updated_at) AS updated_at").
+		Joins("JOIN users ON users.id = posts.author_id AND users.deleted_at IS NULL").
+		Scopes(ListedTo(nil)).
+		Group("users.username").Order("MAX(posts.published_at) DESC").Limit(limit).
+		Scan(&entries).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to list sitemap authors")
+	}
+	return entries, nil
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// suggestionsSize caps how many suggestions are computed and cached per user.
+	suggestionsSize = 50
+	// suggestionsWindow is how far back posts and visits count as recent activity.
+	suggestionsWindow = 30 * 24 * time.Hour
+)
+
+// followSuggestions ranks the users worth following for @viewer: people followed by the users
+// they follow weigh most, then people following the same tags, then recent authors. Users the
+// viewer already follows, the viewer and accounts that are not active are left out.
+const followSuggestions = `
+WITH followed AS (
+	SELECT following_id AS id FROM user_followers WHERE follower_id = @viewer
+), mutual AS (
+	SELECT uf.following_id AS id, count(*) AS n
+	FROM user_followers uf JOIN followed f ON f.id = uf.follower_id
+	GROUP BY uf.following_id
+), shared AS (
+	SELECT tf.user_id AS id, count(*) AS n
+	FROM tag_followers tf JOIN tag_followers mine ON mine.tag_id = tf.tag_id AND mine.user_id = @viewer
+	GROUP BY tf.user_id
+), recent AS (
+	SELECT author_id AS id, count(*) AS n
+	FROM posts
+	WHERE published AND deleted_at IS NULL AND published_at >= @since
+	GROUP BY author_id
+)
+SELECT u.id, u.username, u.name, u.avatar_url, u.bio, u.job_title, u.location, u.skills, u.interests, u.last_seen,
+	COALESCE(m.n, 0) AS mutual_follows,
+	COALESCE(s.n, 0) AS shared_tags,
+	COALESCE(r.n, 0) AS recent_posts
+FROM users u
+	LEFT JOIN mutual m ON m.id = u.id
+	LEFT JOIN shared s ON s.id = u.id
+	LEFT JOIN recent r ON r.id = u.id
+WHERE u.deleted_at IS NULL AND u.is_active AND u.status = @active
+	AND u.id <> @viewer
+	AND u.id NOT IN (SELECT id FROM followed)
+	AND (m.n IS NOT NULL OR s.n IS NOT NULL OR r.n IS NOT NULL)
+ORDER BY 3 * COALESCE(m.n, 0) + 2 * COALESCE(s.n, 0) + LEAST(COALESCE(r.n, 0), 5)
+		+ CASE WHEN u.last_seen >= @since THEN 1 ELSE 0 END DESC,
+	u.last_seen DESC, u.id
+LIMIT @limit`
+
+// FollowSuggestion is a user suggested to follow, with the signals that ranked them.
+type FollowSuggestion struct {
+	user.UserSummary
+	MutualFollows int `json:"mutual_follows"`
+	SharedTags    int `json:"shared_tags"`
+	RecentPosts   int `json:"recent_posts"`
+}
+
+// GetFollowSuggestions returns up to limit users the user may want to follow, best first. The
+// ranking is cached per user; it is recomputed when the user's follows change or it expires.
+func GetFollowSuggestions(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID uuid.UUID, limit int) ([]FollowSuggestion, error) {
+	suggestions, err := cache.Fetch(ctx, rclient, cache.SuggestionsKey(userID), rclient.TTL("suggestions"), func(ctx context.Context) ([]FollowSuggestion, []string, error) {
+		suggestions := []FollowSuggestion{}
+		if err := db.WithContext(ctx).Raw(followSuggestions, map[string]interface{}{
+			"viewer": userID,
+			"since":  time.Now().Add(-suggestionsWindow),
+			"active": user.UserStatusActive,
+			"limit":  suggestionsSize,
+		}).Scan(&suggestions).Error; err != nil {
+			return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to compute follow suggestions")
+		}
+		return suggestions, []string{cache.UserTag(userID)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
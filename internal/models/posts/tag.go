@@ -8,9 +8,11 @@ import (
 
 	"github.com/google/uuid"
 	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Tag struct {
@@ -80,8 +82,9 @@ func CreateTag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, t
 	}
 
 	tagData, _ := json.Marshal(tag)
-	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, 24*time.Hour)
-	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, 24*time.Hour)
+	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, rclient.TTL("tag"))
+	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, rclient.TTL("tag"))
+	cache.Invalidate(ctx, rclient, cache.TagsTag)
 
 	return nil
 }
@@ -114,7 +117,7 @@ func GetTagBy(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, co
 
 	if cacheKey != "" {
 		tagData, _ := json.Marshal(tag)
-		rclient.Set(ctx, cacheKey, tagData, 24*time.Hour)
+		rclient.Set(ctx, cacheKey, tagData, rclient.TTL("tag"))
 	}
 
 	return &tag, nil
@@ -144,8 +147,8 @@ func UpdateTag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, t
 			}
 		}
 
-		// Save tag
-		if err := tx.Save(tag).Error; err != nil {
+		// Save tag; the loaded followers, posts and moderators are left alone
+		if err := tx.Omit(clause.Associations).Save(tag).Error; err != nil {
 			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update tag")
 		}
 
@@ -160,11 +163,12 @@ func UpdateTag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, t
 
 	// Update caches
 	tagData, _ := json.Marshal(tag)
-	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, 24*time.Hour)
-	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, 24*time.Hour)
+	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, rclient.TTL("tag"))
+	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, rclient.TTL("tag"))
 	if tag.Slug != originalSlug {
 		rclient.Del(ctx, "tag:slug:"+originalSlug)
 	}
+	cache.Invalidate(ctx, rclient, cache.TagsTag)
 
 	return tag, nil
 }
@@ -204,6 +208,7 @@ func DeleteTag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, t
 	tx.Commit()
 
 	rclient.Del(ctx, "tag:"+tagID.String(), "tag:slug:"+tag.Slug)
+	cache.Invalidate(ctx, rclient, cache.TagsTag)
 
 	return nil
 }
@@ -255,8 +260,72 @@ func IncrementTagCounts(ctx context.Context, rclient *storage.RedisClient, db *g
 
 	// Update caches
 	tagData, _ := json.Marshal(tag)
-	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, 24*time.Hour)
-	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, 24*time.Hour)
+	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, rclient.TTL("tag"))
+	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, rclient.TTL("tag"))
 
 	return nil
 }
+
+// tagColumns are the columns of a tag without its relations, for listings.
+const tagColumns = "id, name, slug, description, short_description, icon_url, background_url, text_color, background_color, is_approved, is_featured, is_moderated, posts_count, followers_count, rules, created_at, updated_at"
+
+// GetPopularTags returns the most followed approved tags. The list is cached since it is read on
+// most pages and dropped whenever a tag changes.
+func GetPopularTags(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, limit int) ([]Tag, error) {
+	return cache.Fetch(ctx, rclient, cache.PopularTagsKey(limit), rclient.TTL("tag"), func(ctx context.Context) ([]Tag, []string, error) {
+		tags := []Tag{}
+		if err := db.WithContext(ctx).Select(tagColumns).
+			Where("is_approved = ?", true).
+			Order("is_featured DESC, followers_count DESC, posts_count DESC").
+			Limit(limit).
+			Find(&tags).Error; err != nil {
+			return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch tags")
+		}
+		return tags, []string{cache.TagsTag}, nil
+	})
+}
+
+// GetTagPosts retrieves a page of the published posts of a tag listed to viewerID, newest first.
+func GetTagPosts(ctx context.Context, db *gorm.DB, tagID uuid.UUID, viewerID *uuid.UUID, page, limit int) ([]Posts, int64, error) {
+	query := db.WithContext(ctx).Model(&Posts{}).
+		Joins("JOIN post_tags ON post_tags.posts_id = posts.id").
+		Where("post_tags.tag_id = ?", tagID).
+		Scopes(ListedTo(viewerID)).
+		Session(&gorm.Session{})
+	return listPosts(query, "posts.published_at DESC", page, limit)
+}
+
+// ResolveTags looks up approved tags by name or slug, failing on the first unknown one.
+func ResolveTags(ctx context.Context, db *gorm.DB, names []string) ([]Tag, error) {
+	tags := []Tag{}
+	if len(names) == 0 {
+		return tags, nil
+	}
+
+	slugs := make([]string, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		slug := utils.Slugify(name, 35)
+		if slug != "" && !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	if err := db.WithContext(ctx).Select(tagColumns).Where("slug IN ? AND is_approved = ?", slugs, true).Find(&tags).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch tags")
+	}
+	if len(tags) == len(slugs) {
+		return tags, nil
+	}
+
+	found := map[string]bool{}
+	for _, tag := range tags {
+		found[tag.Slug] = true
+	}
+	for _, slug := range slugs {
+		if !found[slug] {
+			return nil, utils.NewError(utils.ErrBadRequest.Code, "Unknown tag: "+slug)
+		}
+	}
+	return tags, nil
+}
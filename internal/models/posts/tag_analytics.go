@@ -54,7 +54,7 @@ func CreateTagAnalytics(ctx context.Context, rclient *storage.RedisClient, db *g
 	}
 
 	taData, _ := json.Marshal(ta)
-	rclient.Set(ctx, "tag_analytics:"+ta.TagID.String(), taData, 1*time.Hour)
+	rclient.Set(ctx, "tag_analytics:"+ta.TagID.String(), taData, rclient.TTL("tag_analytics"))
 
 	return nil
 }
@@ -78,7 +78,7 @@ func GetTagAnalytics(ctx context.Context, rclient *storage.RedisClient, db *gorm
 	}
 
 	taData, _ := json.Marshal(ta)
-	rclient.Set(ctx, cacheKey, taData, 1*time.Hour)
+	rclient.Set(ctx, cacheKey, taData, rclient.TTL("tag_analytics"))
 
 	return &ta, nil
 }
@@ -109,7 +109,7 @@ func UpdateTagAnalytics(ctx context.Context, rclient *storage.RedisClient, db *g
 	tx.Commit()
 
 	taData, _ := json.Marshal(ta)
-	rclient.Set(ctx, "tag_analytics:"+tagID.String(), taData, 1*time.Hour)
+	rclient.Set(ctx, "tag_analytics:"+tagID.String(), taData, rclient.TTL("tag_analytics"))
 
 	return ta, nil
 }
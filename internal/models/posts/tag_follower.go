@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	user "github.com/mnuddindev/devpulse/internal/models/user"
+	"github.com/mnuddindev/devpulse/pkg/cache"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
@@ -61,8 +62,7 @@ func FollowTag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, t
 				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to add tag followers")
 			}
 
-			tag.FollowersCount += len(newFollowers)
-			if err := tx.Model(&Tag{ID: tagID}).Update("followers_count", tag.FollowersCount).Error; err != nil {
+			if err := tx.Model(&Tag{ID: tagID}).UpdateColumn("followers_count", gorm.Expr("followers_count + ?", len(newFollowers))).Error; err != nil {
 				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update followers count")
 			}
 
@@ -81,10 +81,7 @@ func FollowTag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, t
 
 	tx.Commit()
 
-	tagData, _ := json.Marshal(tag)
-	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, 24*time.Hour)
-	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, 24*time.Hour)
-	rclient.Del(ctx, "tag:followers:"+tag.ID.String())
+	dropTagFollowerCaches(ctx, rclient, tag, userIDs)
 
 	return nil
 }
@@ -106,11 +103,7 @@ func UnfollowTag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 
 		countRemoved := int(result.RowsAffected)
 		if countRemoved > 0 {
-			tag.FollowersCount -= countRemoved
-			if tag.FollowersCount < 0 {
-				tag.FollowersCount = 0
-			}
-			if err := tx.Model(&Tag{ID: tagID}).Update("followers_count", tag.FollowersCount).Error; err != nil {
+			if err := tx.Model(&Tag{ID: tagID}).UpdateColumn("followers_count", gorm.Expr("GREATEST(followers_count - ?, 0)", countRemoved)).Error; err != nil {
 				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update followers count")
 			}
 
@@ -128,15 +121,21 @@ func UnfollowTag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 
 	tx.Commit()
 
-	// Update tag cache
-	tagData, _ := json.Marshal(tag)
-	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, 24*time.Hour)
-	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, 24*time.Hour)
-	rclient.Del(ctx, "tag:followers:"+tag.ID.String()) // Invalidate followers cache
+	dropTagFollowerCaches(ctx, rclient, tag, userIDs)
 
 	return nil
 }
 
+// dropTagFollowerCaches drops the cached tag, whose follower count changed, and the follow
+// state and feeds of the given users.
+func dropTagFollowerCaches(ctx context.Context, rclient *storage.RedisClient, tag *Tag, userIDs []uuid.UUID) {
+	keys := []string{"tag:" + tag.ID.String(), "tag:slug:" + tag.Slug, "tag:followers:" + tag.ID.String()}
+	for _, userID := range userIDs {
+		keys = append(keys, fmt.Sprintf("tag:follower:%s:%s", tag.ID.String(), userID.String()), cache.FeedKey(userID))
+	}
+	rclient.Del(ctx, keys...)
+}
+
 // GetTagFollowers retrieves a paginated list of a tag's followers
 func GetTagFollowers(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, tagID uuid.UUID, page, limit int) ([]user.User, error) {
 	cacheKey := fmt.Sprintf("tag:followers:%s:page:%d:limit:%d", tagID.String(), page, limit)
@@ -160,7 +159,7 @@ func GetTagFollowers(ctx context.Context, rclient *storage.RedisClient, db *gorm
 	}
 
 	followersData, _ := json.Marshal(followers)
-	rclient.Set(ctx, cacheKey, followersData, 1*time.Hour)
+	rclient.Set(ctx, cacheKey, followersData, rclient.TTL("tag_followers"))
 
 	return followers, nil
 }
@@ -178,13 +177,13 @@ func IsFollowingTag(ctx context.Context, rclient *storage.RedisClient, db *gorm.
 		First(&follower).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			rclient.Set(ctx, cacheKey, "false", 1*time.Hour)
+			rclient.Set(ctx, cacheKey, "false", rclient.TTL("tag_followers"))
 			return false, nil
 		}
 		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check follower status")
 	}
 
-	rclient.Set(ctx, cacheKey, "true", 1*time.Hour)
+	rclient.Set(ctx, cacheKey, "true", rclient.TTL("tag_followers"))
 	return true, nil
 }
 
@@ -224,3 +223,15 @@ func DeleteFollowers(ctx context.Context, rclient *storage.RedisClient, db *gorm
 
 	return nil
 }
+
+// GetFollowedTags returns the tags a user follows, most followed first.
+func GetFollowedTags(ctx context.Context, db *gorm.DB, userID uuid.UUID) ([]Tag, error) {
+	tags := []Tag{}
+	if err := db.WithContext(ctx).Select(tagColumns).
+		Where("id IN (?)", db.Model(&TagFollower{}).Select("tag_id").Where("user_id = ?", userID)).
+		Order("followers_count DESC").
+		Find(&tags).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch followed tags")
+	}
+	return tags, nil
+}
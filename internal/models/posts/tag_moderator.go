@@ -92,9 +92,9 @@ func AddTagModerator(ctx context.Context, rclient *storage.RedisClient, db *gorm
 	tx.Commit()
 
 	tagData, _ := json.Marshal(tag)
-	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, 24*time.Hour)
-	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, 24*time.Hour)
-	rclient.Set(ctx, "tag:moderators_count:"+tag.ID.String(), int(currentCount)+len(newModerators), 24*time.Hour)
+	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, rclient.TTL("tag"))
+	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, rclient.TTL("tag"))
+	rclient.Set(ctx, "tag:moderators_count:"+tag.ID.String(), int(currentCount)+len(newModerators), rclient.TTL("tag"))
 	rclient.Del(ctx, "tag:moderators:"+tag.ID.String())
 
 	return nil
@@ -140,9 +140,9 @@ func RemoveTagModerator(ctx context.Context, rclient *storage.RedisClient, db *g
 
 	// Update caches
 	tagData, _ := json.Marshal(tag)
-	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, 24*time.Hour)
-	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, 24*time.Hour)
-	rclient.Set(ctx, "tag:moderators_count:"+tag.ID.String(), 0, 24*time.Hour)
+	rclient.Set(ctx, "tag:"+tag.ID.String(), tagData, rclient.TTL("tag"))
+	rclient.Set(ctx, "tag:slug:"+tag.Slug, tagData, rclient.TTL("tag"))
+	rclient.Set(ctx, "tag:moderators_count:"+tag.ID.String(), 0, rclient.TTL("tag"))
 	rclient.Del(ctx, "tag:moderators:"+tag.ID.String())
 
 	return nil
@@ -176,7 +176,7 @@ func GetTagModerators(ctx context.Context, rclient *storage.RedisClient, db *gor
 	}
 
 	moderatorsData, _ := json.Marshal(moderators)
-	rclient.Set(ctx, cacheKey, moderatorsData, 1*time.Hour)
+	rclient.Set(ctx, cacheKey, moderatorsData, rclient.TTL("tag_moderators"))
 
 	return moderators, nil
 }
@@ -194,13 +194,13 @@ func IsModerator(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 		First(&moderator).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			rclient.Set(ctx, cacheKey, "false", 1*time.Hour)
+			rclient.Set(ctx, cacheKey, "false", rclient.TTL("tag_moderators"))
 			return false, nil
 		}
 		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check moderator status")
 	}
 
-	rclient.Set(ctx, cacheKey, "true", 1*time.Hour)
+	rclient.Set(ctx, cacheKey, "true", rclient.TTL("tag_moderators"))
 	return true, nil
 }
 
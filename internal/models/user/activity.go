@@ -0,0 +1,371 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Activity kinds counted towards streaks and the activity heatmap.
+const (
+	ActivityRead    = "read"
+	ActivityPost    = "post"
+	ActivityComment = "comment"
+)
+
+// StreakMilestones are the streak lengths, in days, that earn a badge.
+var StreakMilestones = []int{7, 30, 100, 365}
+
+const (
+	// activityBitmapTTL keeps a year's bitmap of active days until the year after it ended.
+	activityBitmapTTL = 400 * 24 * time.Hour
+	// activityCountsTTL keeps a day's counters until the rollups after the day settled them.
+	activityCountsTTL = 3 * 24 * time.Hour
+	// activityRollupBatch is how many rolled up days one upsert writes.
+	activityRollupBatch = 500
+)
+
+// UserActivity is what a user did on one day, in UTC. Activity is counted in Redis as it happens
+// and rolled up into these rows by a scheduled job; the row of the current day is rewritten on
+// every run, so it may lag behind until the next one.
+type UserActivity struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	Day       time.Time `gorm:"type:date;primaryKey;index:idx_user_activity_day" json:"day"`
+	Reads     int       `gorm:"not null;default:0" json:"reads"`
+	Posts     int       `gorm:"not null;default:0" json:"posts"`
+	Comments  int       `gorm:"not null;default:0" json:"comments"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"-"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+}
+
+// UserStreak is a user's run of consecutive active days, in UTC. It moves on the first activity of
+// each day; a day without activity breaks it.
+type UserStreak struct {
+	UserID       uuid.UUID  `gorm:"type:uuid;primaryKey" json:"-"`
+	Current      int        `gorm:"not null;default:0" json:"current"`
+	Longest      int        `gorm:"not null;default:0" json:"longest"`
+	LastActiveOn *time.Time `gorm:"type:date" json:"last_active_on"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"-"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+}
+
+// Streak is a user's streak as of a given day: Current is 0 once a day went by without activity.
+// NextMilestone is the next streak length that earns a badge, or 0 past the last one.
+type Streak struct {
+	Current       int        `json:"current"`
+	Longest       int        `json:"longest"`
+	LastActiveOn  *time.Time `json:"last_active_on"`
+	ActiveToday   bool       `json:"active_today"`
+	NextMilestone int        `json:"next_milestone"`
+}
+
+// ActivityDay is one active day of a heatmap. Level grades its total from 1 to 4.
+type ActivityDay struct {
+	Day      string `json:"day"`
+	Reads    int    `json:"reads"`
+	Posts    int    `json:"posts"`
+	Comments int    `json:"comments"`
+	Total    int    `json:"total"`
+	Level    int    `json:"level"`
+}
+
+// ActivityHeatmap is a user's activity over a year, listing the active days only.
+type ActivityHeatmap struct {
+	Year       int           `json:"year"`
+	Days       []ActivityDay `json:"days"`
+	ActiveDays int           `json:"active_days"`
+	Total      int           `json:"total"`
+}
+
+// StreakMilestoneEvent is the payload of OutboxStreakMilestone.
+type StreakMilestoneEvent struct {
+	UserID uuid.UUID `json:"user_id"`
+	Days   int       `json:"days"`
+}
+
+// activityBitmapKey holds a bit for every day of a year the user was active, numbered from 0.
+func activityBitmapKey(userID uuid.UUID, year int) string {
+	return "activity:days:" + userID.String() + ":" + strconv.Itoa(year)
+}
+
+// activityCountsKey holds the activity counters of every user for a day, by "user:kind".
+func activityCountsKey(day time.Time) string {
+	return "activity:counts:" + day.Format("2006-01-02")
+}
+
+// utcDay is the start of the UTC day of t.
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// RecordActivity counts an activity of a user at a given time. The first activity of a day also
+// moves the user's streak on, and queues the badge of a milestone it reaches. Should moving the
+// streak fail, the day is unmarked again so the user's next activity retries it.
+func RecordActivity(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, kind string, at time.Time) error {
+	day := utcDay(at)
+	bitmap := activityBitmapKey(userID, day.Year())
+	offset := int64(day.YearDay() - 1)
+	counts := activityCountsKey(day)
+
+	pipe := redisClient.Pipeline()
+	seen := pipe.SetBit(ctx, bitmap, offset, 1)
+	pipe.Expire(ctx, bitmap, activityBitmapTTL)
+	pipe.HIncrBy(ctx, counts, userID.String()+":"+kind, 1)
+	pipe.Expire(ctx, counts, activityCountsTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record activity")
+	}
+	if seen.Val() == 1 {
+		return nil
+	}
+	if err := advanceStreak(ctx, gormDB, userID, day); err != nil {
+		// advanceStreak ignores days it has already counted, so retrying is safe
+		if clearErr := redisClient.SetBit(context.WithoutCancel(ctx), bitmap, offset, 0).Err(); clearErr != nil {
+			return utils.WrapError(errors.Join(err, clearErr), utils.ErrInternalServerError.Code, "Failed to record activity")
+		}
+		return err
+	}
+	return nil
+}
+
+// advanceStreak counts day as active in the user's streak.
+func advanceStreak(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID, day time.Time) error {
+	return gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&UserStreak{UserID: userID}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create streak")
+		}
+		var streak UserStreak
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&streak).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get streak")
+		}
+
+		switch {
+		case streak.LastActiveOn != nil && !day.After(utcDay(*streak.LastActiveOn)):
+			return nil
+		case streak.LastActiveOn != nil && utcDay(*streak.LastActiveOn).AddDate(0, 0, 1).Equal(day):
+			streak.Current++
+		default:
+			streak.Current = 1
+		}
+		if streak.Current > streak.Longest {
+			streak.Longest = streak.Current
+		}
+		streak.LastActiveOn = &day
+		if err := tx.Omit(clause.Associations).Save(&streak).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update streak")
+		}
+
+		for _, milestone := range StreakMilestones {
+			if streak.Current == milestone {
+				return EnqueueOutbox(ctx, tx, OutboxStreakMilestone, StreakMilestoneEvent{UserID: userID, Days: milestone})
+			}
+		}
+		return nil
+	})
+}
+
+// GetStreak retrieves a user's streak as of now.
+func GetStreak(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID, now time.Time) (*Streak, error) {
+	var stored UserStreak
+	if err := gormDB.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&stored).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get streak")
+	}
+
+	today := utcDay(now)
+	streak := &Streak{Longest: stored.Longest, LastActiveOn: stored.LastActiveOn}
+	if stored.LastActiveOn != nil {
+		last := utcDay(*stored.LastActiveOn)
+		streak.ActiveToday = last.Equal(today)
+		// A streak holds until the end of the day after its last active day
+		if streak.ActiveToday || last.AddDate(0, 0, 1).Equal(today) {
+			streak.Current = stored.Current
+		}
+	}
+	for _, milestone := range StreakMilestones {
+		if milestone > streak.Current {
+			streak.NextMilestone = milestone
+			break
+		}
+	}
+	return streak, nil
+}
+
+// GetActivityHeatmap retrieves a user's active days of a year. Today's counts are read from Redis,
+// as the rollup of the current day may lag behind.
+func GetActivityHeatmap(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, year int, now time.Time) (*ActivityHeatmap, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var rows []UserActivity
+	if err := gormDB.WithContext(ctx).
+		Where("user_id = ? AND day >= ? AND day < ?", userID, start, start.AddDate(1, 0, 0)).
+		Order("day").Find(&rows).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get activity")
+	}
+
+	today := utcDay(now)
+	if today.Year() == year {
+		live, err := redisClient.HMGet(ctx, activityCountsKey(today),
+			userID.String()+":"+ActivityRead, userID.String()+":"+ActivityPost, userID.String()+":"+ActivityComment).Result()
+		if err == nil {
+			counts := make([]int, len(live))
+			for i, v := range live {
+				if s, ok := v.(string); ok {
+					counts[i], _ = strconv.Atoi(s)
+				}
+			}
+			if counts[0]+counts[1]+counts[2] > 0 {
+				if n := len(rows); n > 0 && utcDay(rows[n-1].Day).Equal(today) {
+					rows = rows[:n-1]
+				}
+				rows = append(rows, UserActivity{Day: today, Reads: counts[0], Posts: counts[1], Comments: counts[2]})
+			}
+		}
+	}
+
+	heatmap := &ActivityHeatmap{Year: year, Days: make([]ActivityDay, 0, len(rows))}
+	for _, row := range rows {
+		total := row.Reads + row.Posts + row.Comments
+		if total == 0 {
+			continue
+		}
+		heatmap.Days = append(heatmap.Days, ActivityDay{
+			Day:      row.Day.Format("2006-01-02"),
+			Reads:    row.Reads,
+			Posts:    row.Posts,
+			Comments: row.Comments,
+			Total:    total,
+			Level:    activityLevel(total),
+		})
+		heatmap.ActiveDays++
+		heatmap.Total += total
+	}
+	return heatmap, nil
+}
+
+// activityLevel grades a day's total activity from 1 to 4 for the heatmap.
+func activityLevel(total int) int {
+	switch {
+	case total >= 10:
+		return 4
+	case total >= 5:
+		return 3
+	case total >= 2:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// RollupActivity writes the activity counted in Redis on the day of now and the day before into
+// user_activities, replacing the counts rolled up before. It returns how many rows it wrote.
+func RollupActivity(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, now time.Time) (int, error) {
+	today := utcDay(now)
+	written := 0
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		rows := map[uuid.UUID]*UserActivity{}
+		iter := redisClient.HScan(ctx, activityCountsKey(day), 0, "", 1000).Iterator()
+		for iter.Next(ctx) {
+			field := iter.Val()
+			if !iter.Next(ctx) {
+				break
+			}
+			count, _ := strconv.Atoi(iter.Val())
+			user, kind, ok := strings.Cut(field, ":")
+			if !ok {
+				continue
+			}
+			userID, err := uuid.Parse(user)
+			if err != nil {
+				continue
+			}
+			row := rows[userID]
+			if row == nil {
+				row = &UserActivity{UserID: userID, Day: day}
+				rows[userID] = row
+			}
+			switch kind {
+			case ActivityRead:
+				row.Reads = count
+			case ActivityPost:
+				row.Posts = count
+			case ActivityComment:
+				row.Comments = count
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return written, utils.WrapError(err, utils.ErrInternalServerError.Code, fmt.Sprintf("Failed to read activity of %s", day.Format("2006-01-02")))
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		batch := make([]*UserActivity, 0, len(rows))
+		for _, row := range rows {
+			batch = append(batch, row)
+		}
+		if err := gormDB.WithContext(ctx).Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "day"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reads", "posts", "comments", "updated_at"}),
+		}).CreateInBatches(batch, activityRollupBatch).Error; err != nil {
+			return written, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to roll up activity")
+		}
+		written += len(batch)
+	}
+	return written, nil
+}
+
+// AwardStreakBadge gives a user the badge of a streak milestone, creating the badge with image the
+// first time it is earned, and notifies the user. A badge already held is left as it is, without
+// notifying the user again.
+func AwardStreakBadge(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, days int, image string) error {
+	name := fmt.Sprintf("%d-Day Streak", days)
+	var batch *NotificationBatch
+	err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		badge := Badge{Name: name, Image: image}
+		if err := tx.Where("name = ?", name).Attrs(badge).FirstOrCreate(&badge).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get badge")
+		}
+		res := tx.Exec("INSERT INTO user_badges (user_id, badge_id) VALUES (?, ?) ON CONFLICT DO NOTHING", userID, badge.ID)
+		if res.Error != nil {
+			return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to add badge to user")
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		built, err := BuildNotifications(ctx, tx, []NotificationInput{{
+			UserID:  userID,
+			Type:    NotificationBadge,
+			Message: "Heart here! 🔥 You've been active %d days in a row and earned the \"%s\" badge. Keep your streak going!",
+			Args:    []interface{}{days, name},
+		}})
+		if err != nil {
+			return err
+		}
+		if err := built.Insert(ctx, tx); err != nil {
+			return err
+		}
+		batch = built
+		return nil
+	})
+	if err != nil || batch == nil {
+		return err
+	}
+
+	cache.Delete(ctx, redisClient, cache.UserKey(userID))
+	cache.Invalidate(ctx, redisClient, cache.UserTag(userID))
+	batch.Publish(ctx, redisClient)
+	return nil
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// API key security events.
+const (
+	EventAPIKeyCreated = "api_key_created"
+	EventAPIKeyRevoked = "api_key_revoked"
+)
+
+// API key scopes, each granting a set of routes to requests authenticated with the key.
+const (
+	ScopeReadProfile = "read:profile"
+	ScopeWritePosts  = "write:posts"
+)
+
+// APIKeyScopes lists every scope a key can be issued with.
+var APIKeyScopes = []string{ScopeReadProfile, ScopeWritePosts}
+
+const (
+	// APIKeyPrefix starts every personal access token so it can be told apart from session tokens
+	// and found by secret scanners.
+	APIKeyPrefix = "devpulse_pat_"
+	// MaxAPIKeysPerUser caps how many active keys one user can hold.
+	MaxAPIKeysPerUser = 20
+	// apiKeyTouchInterval is how stale a key's last use may get before a request records it again,
+	// so busy clients do not write on every request.
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKey is a personal access token. Only the hash of the token is stored; the token itself is
+// shown once when the key is created.
+type APIKey struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID     uuid.UUID       `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name       string          `gorm:"size:100;not null" json:"name"`
+	Prefix     string          `gorm:"size:20;not null" json:"prefix"`
+	TokenHash  string          `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes     json.RawMessage `gorm:"type:jsonb;not null;default:'[]'" json:"scopes"`
+	LastUsedAt *time.Time      `json:"last_used_at"`
+	LastUsedIP string          `gorm:"size:45" json:"last_used_ip"`
+	ExpiresAt  *time.Time      `json:"expires_at"`
+	RevokedAt  *time.Time      `gorm:"index" json:"revoked_at"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ScopeList returns the scopes the key was issued with.
+func (k *APIKey) ScopeList() []string {
+	scopes := []string{}
+	json.Unmarshal(k.Scopes, &scopes)
+	return scopes
+}
+
+// HasScope reports whether the key was issued with scope.
+func (k *APIKey) HasScope(scope string) bool {
+	return utils.Contains(k.ScopeList(), scope)
+}
+
+// IsAPIKey reports whether a bearer token is a personal access token.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// CreateAPIKey issues a key for a user and returns it with its token, which cannot be recovered
+// later. A nil expiresAt issues a key that never expires.
+func CreateAPIKey(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if userID == uuid.Nil || name == "" {
+		return nil, "", utils.NewError(utils.ErrBadRequest.Code, "Required fields missing: user_id, name")
+	}
+	granted := []string{}
+	for _, scope := range scopes {
+		if !utils.Contains(APIKeyScopes, scope) {
+			return nil, "", utils.NewError(utils.ErrBadRequest.Code, "Unknown API key scope: "+scope)
+		}
+		if !utils.Contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	if len(granted) == 0 {
+		return nil, "", utils.NewError(utils.ErrBadRequest.Code, "Grant at least one scope")
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", utils.NewError(utils.ErrBadRequest.Code, "Expiry must lie in the future")
+	}
+
+	secret, err := utils.GenerateRandomToken(40, 40)
+	if err != nil {
+		return nil, "", utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to generate API key")
+	}
+	token := APIKeyPrefix + secret
+	scopesJSON, _ := json.Marshal(granted)
+	key := &APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    token[:len(APIKeyPrefix)+6],
+		TokenHash: utils.HashToken(token),
+		Scopes:    scopesJSON,
+		ExpiresAt: expiresAt,
+	}
+
+	err = gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the owner serializes concurrent issuing so the cap holds
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", userID).First(&User{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NewError(utils.ErrNotFound.Code, "User not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch user")
+		}
+		var count int64
+		if err := activeAPIKeys(tx).Model(&APIKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count API keys")
+		}
+		if count >= MaxAPIKeysPerUser {
+			return utils.NewError(utils.ErrConflict.Code, "API key limit reached")
+		}
+		if err := tx.Create(key).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create API key")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return key, token, nil
+}
+
+// GetUserAPIKeys lists a user's keys that are neither revoked nor expired, newest first.
+func GetUserAPIKeys(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID) ([]APIKey, error) {
+	keys := []APIKey{}
+	if err := activeAPIKeys(gormDB.WithContext(ctx)).Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch API keys")
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key of a user; requests using it are refused from then on.
+func RevokeAPIKey(ctx context.Context, gormDB *gorm.DB, userID, id uuid.UUID) error {
+	res := gormDB.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to revoke API key")
+	}
+	if res.RowsAffected == 0 {
+		return utils.NewError(utils.ErrNotFound.Code, "API key not found")
+	}
+	return nil
+}
+
+// AuthenticateAPIKey resolves the key of a personal access token and records its use. Unknown,
+// revoked and expired keys are refused alike.
+func AuthenticateAPIKey(ctx context.Context, gormDB *gorm.DB, token, ip string) (*APIKey, error) {
+	var key APIKey
+	if err := activeAPIKeys(gormDB.WithContext(ctx)).Where("token_hash = ?", utils.HashToken(token)).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewError(utils.ErrUnauthorized.Code, "Invalid API key")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch API key")
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// A failed update only loses usage data, so it does not fail the request
+		gormDB.WithContext(ctx).Model(&APIKey{}).Where("id = ?", key.ID).
+			Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ip})
+		key.LastUsedAt, key.LastUsedIP = &now, ip
+	}
+	return &key, nil
+}
+
+// activeAPIKeys limits a query to keys that are neither revoked nor expired.
+func activeAPIKeys(db *gorm.DB) *gorm.DB {
+	return db.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// Audited actions.
+const (
+	AuditRoleCreate            = "role.create"
+	AuditRoleUpdate            = "role.update"
+	AuditRolePermissionsGrant  = "role.permissions.grant"
+	AuditRolePermissionsRevoke = "role.permissions.revoke"
+	AuditUserRoleAssign        = "user.role.assign"
+	AuditUserDelete            = "user.delete"
+	AuditPasswordChange        = "user.password.change"
+	AuditPasswordReset         = "user.password.reset"
+	AuditPasswordSet           = "user.password.set"
+	AuditPasswordRemove        = "user.password.remove"
+	AuditIdentityLink          = "user.identity.link"
+	AuditIdentityUnlink        = "user.identity.unlink"
+	AuditUserImpersonate       = "user.impersonate"
+	AuditImpersonatedRequest   = "user.impersonate.request"
+	AuditBlocklistAdd          = "blocklist.add"
+	AuditBlocklistRemove       = "blocklist.remove"
+	AuditRateLimitSet          = "rate_limit.set"
+	AuditRateLimitRemove       = "rate_limit.remove"
+	AuditFeatureFlagCreate     = "feature_flag.create"
+	AuditFeatureFlagUpdate     = "feature_flag.update"
+	AuditFeatureFlagDelete     = "feature_flag.delete"
+)
+
+// Audit target types.
+const (
+	AuditTargetUser        = "user"
+	AuditTargetRole        = "role"
+	AuditTargetBlockedTerm = "blocked_term"
+	AuditTargetRateLimit   = "rate_limit_override"
+	AuditTargetFeatureFlag = "feature_flag"
+)
+
+const (
+	// auditExportLimit caps how many entries one export returns.
+	auditExportLimit = 10000
+	// auditExportBatch is how many entries an export reads at a time.
+	auditExportBatch = 500
+)
+
+var errAuditAppendOnly = utils.NewError(utils.ErrForbidden.Code, "Audit logs are append-only")
+
+// AuditLog records a privileged action. Before and After only hold the fields that changed.
+type AuditLog struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ActorID    uuid.UUID       `gorm:"type:uuid;not null;index" json:"actor_id"`
+	Action     string          `gorm:"size:50;not null;index" json:"action"`
+	TargetType string          `gorm:"size:30;not null;index:idx_audit_target" json:"target_type"`
+	TargetID   uuid.UUID       `gorm:"type:uuid;not null;index:idx_audit_target" json:"target_id"`
+	Before     json.RawMessage `gorm:"type:jsonb;not null;default:'{}'" json:"before"`
+	After      json.RawMessage `gorm:"type:jsonb;not null;default:'{}'" json:"after"`
+	IP         string          `gorm:"size:45" json:"ip"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// AuditFilter narrows an audit log listing.
+type AuditFilter struct {
+	ActorID    *uuid.UUID
+	TargetID   *uuid.UUID
+	Action     string
+	TargetType string
+	From       *time.Time
+	To         *time.Time
+}
+
+// BeforeUpdate keeps audit entries from being rewritten.
+func (*AuditLog) BeforeUpdate(*gorm.DB) error {
+	return errAuditAppendOnly
+}
+
+// BeforeDelete keeps audit entries from being removed.
+func (*AuditLog) BeforeDelete(*gorm.DB) error {
+	return errAuditAppendOnly
+}
+
+// auditDiff drops the fields before and after agree on.
+func auditDiff(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	b, a := map[string]interface{}{}, map[string]interface{}{}
+	for k, v := range before {
+		if w, ok := after[k]; !ok || !reflect.DeepEqual(v, w) {
+			b[k] = v
+		}
+	}
+	for k, v := range after {
+		if w, ok := before[k]; !ok || !reflect.DeepEqual(v, w) {
+			a[k] = v
+		}
+	}
+	return b, a
+}
+
+// RecordAudit appends an audit entry for an action by actorID on a target.
+func RecordAudit(ctx context.Context, db *gorm.DB, actorID uuid.UUID, action, targetType string, targetID uuid.UUID, before, after map[string]interface{}, ip string) error {
+	return recordAudit(db.WithContext(ctx), actorID, action, targetType, targetID, before, after, ip)
+}
+
+func recordAudit(tx *gorm.DB, actorID uuid.UUID, action, targetType string, targetID uuid.UUID, before, after map[string]interface{}, ip string) error {
+	b, a := auditDiff(before, after)
+	beforeJSON, err := json.Marshal(b)
+	if err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to encode audit entry")
+	}
+	afterJSON, err := json.Marshal(a)
+	if err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to encode audit entry")
+	}
+
+	entry := &AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     beforeJSON,
+		After:      afterJSON,
+		IP:         ip,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record audit entry")
+	}
+	return nil
+}
+
+func auditQuery(ctx context.Context, db *gorm.DB, filter AuditFilter) *gorm.DB {
+	query := db.WithContext(ctx).Model(&AuditLog{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+// ListAuditLogs retrieves a page of audit entries, newest first.
+func ListAuditLogs(ctx context.Context, db *gorm.DB, filter AuditFilter, page, limit int) ([]AuditLog, int64, error) {
+	query := auditQuery(ctx, db, filter).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count audit logs")
+	}
+
+	logs := []AuditLog{}
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&logs).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch audit logs")
+	}
+	return logs, total, nil
+}
+
+// ExportAuditLogs reads every audit entry matching filter, newest first, up to the export cap. It
+// returns a function yielding the entries a batch at a time, so an export never holds them all,
+// and an empty batch once they run out.
+func ExportAuditLogs(db *gorm.DB, filter AuditFilter) func(ctx context.Context) ([]AuditLog, error) {
+	var last *AuditLog
+	remaining := auditExportLimit
+	return func(ctx context.Context) ([]AuditLog, error) {
+		if remaining == 0 {
+			return nil, nil
+		}
+		query := auditQuery(ctx, db, filter)
+		if last != nil {
+			query = query.Where("(created_at, id) < (?, ?)", last.CreatedAt, last.ID)
+		}
+		logs := []AuditLog{}
+		if err := query.Order("created_at DESC, id DESC").Limit(min(auditExportBatch, remaining)).Find(&logs).Error; err != nil {
+			return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to export audit logs")
+		}
+		remaining -= len(logs)
+		if len(logs) < auditExportBatch {
+			remaining = 0
+		}
+		if len(logs) > 0 {
+			last = &logs[len(logs)-1]
+		}
+		return logs, nil
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
@@ -34,7 +35,7 @@ func NewBadge(ctx context.Context, redisClient *storage.RedisClient, gormDB *gor
 
 	badgeJSON, _ := json.Marshal(b)
 	key := "badge:" + b.ID.String()
-	redisClient.Set(ctx, key, badgeJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, badgeJSON, redisClient.TTL("badge"))
 	return b, nil
 }
 
@@ -57,7 +58,7 @@ func GetBadge(ctx context.Context, redisClient *storage.RedisClient, gormDB *gor
 	}
 
 	badgeJSON, _ := json.Marshal(b)
-	redisClient.Set(ctx, key, badgeJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, badgeJSON, redisClient.TTL("badge"))
 	return &b, nil
 }
 
@@ -77,7 +78,7 @@ func GetBadges(ctx context.Context, redisClient *storage.RedisClient, gormDB *go
 	}
 
 	badgesJSON, _ := json.Marshal(badges)
-	redisClient.Set(ctx, key, badgesJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, badgesJSON, redisClient.TTL("badge"))
 	return badges, nil
 }
 
@@ -101,7 +102,7 @@ func UpdateBadge(ctx context.Context, redisClient *storage.RedisClient, gormDB *
 
 	badgeJSON, _ := json.Marshal(b)
 	key := "badge:" + b.ID.String()
-	redisClient.Set(ctx, key, badgeJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, badgeJSON, redisClient.TTL("badge"))
 	return b, nil
 }
 
@@ -123,7 +124,7 @@ func DeleteBadge(ctx context.Context, redisClient *storage.RedisClient, gormDB *
 
 // AddBadgeToUser assigns a badge to a user (utility).
 func AddBadgeToUser(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID, badgeID uuid.UUID) error {
-	u, err := GetUserBy(ctx, redisClient, gormDB, "id = ?", []interface{}{userID}, "")
+	u, err := GetUserBy(ctx, redisClient, gormDB, "id = ?", []interface{}{userID})
 	if err != nil {
 		return err
 	}
@@ -137,7 +138,7 @@ func AddBadgeToUser(ctx context.Context, redisClient *storage.RedisClient, gormD
 	}
 
 	userJSON, _ := json.Marshal(u)
-	key := "user:" + u.ID.String()
-	redisClient.Set(ctx, key, userJSON, 10*time.Minute)
+	redisClient.Set(ctx, cache.UserKey(u.ID), userJSON, redisClient.TTL("user"))
+	cache.Invalidate(ctx, redisClient, cache.UserTag(u.ID))
 	return nil
 }
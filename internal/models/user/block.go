@@ -0,0 +1,171 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kinds of UserBlock. A block keeps the blocked user from following, commenting on or mentioning
+// the blocker, and hides them like a mute; a mute only hides them.
+const (
+	BlockKindBlock = "block"
+	BlockKindMute  = "mute"
+)
+
+// UserBlock records that a user blocked or muted another. A user may both block and mute someone,
+// so undoing one leaves the other in place.
+type UserBlock struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	TargetID  uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"target_id"`
+	Kind      string    `gorm:"size:10;primaryKey" json:"kind"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Target User `gorm:"foreignKey:TargetID" json:"-"`
+}
+
+// BlockedUser is an entry of a user's block or mute list.
+type BlockedUser struct {
+	UserSummary
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SetBlockState idempotently makes the user block or mute the given username, or undo it. It
+// reports whether anything changed. Blocking also drops the blocked user's follow of the user.
+func (u *User) SetBlockState(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, username, kind string, on bool) (*User, bool, error) {
+	if kind != BlockKindBlock && kind != BlockKindMute {
+		return nil, false, utils.NewError(utils.ErrBadRequest.Code, "Unknown block kind "+kind)
+	}
+	target, err := GetUserBy(ctx, redisClient, gormDB, "username = ?", []interface{}{username}, PreloadMinimal)
+	if err != nil {
+		return nil, false, err
+	}
+	if u.ID == target.ID {
+		return nil, false, utils.NewError(utils.ErrBadRequest.Code, "Cannot "+kind+" yourself")
+	}
+
+	changed := false
+	err = gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var res *gorm.DB
+		if on {
+			res = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&UserBlock{UserID: u.ID, TargetID: target.ID, Kind: kind})
+		} else {
+			res = tx.Where("user_id = ? AND target_id = ? AND kind = ?", u.ID, target.ID, kind).Delete(&UserBlock{})
+		}
+		if res.Error != nil {
+			return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to update "+kind)
+		}
+		changed = res.RowsAffected > 0
+		if !changed || !on || kind != BlockKindBlock {
+			return nil
+		}
+
+		unfollow := tx.Exec("DELETE FROM user_followers WHERE follower_id = ? AND following_id = ?", target.ID, u.ID)
+		if unfollow.Error != nil {
+			return utils.WrapError(unfollow.Error, utils.ErrInternalServerError.Code, "Failed to remove follow")
+		}
+		if unfollow.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Model(&User{}).Where("id = ?", target.ID).UpdateColumn("following_count", gorm.Expr("GREATEST(following_count - 1, 0)")).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update following count")
+		}
+		if err := tx.Model(&User{}).Where("id = ?", u.ID).UpdateColumn("followers_count", gorm.Expr("GREATEST(followers_count - 1, 0)")).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update followers count")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if changed {
+		// The user's feed is cached under their tag and filters what they block and mute
+		cache.InvalidateUser(ctx, redisClient, u.ID)
+		cache.InvalidateUser(ctx, redisClient, target.ID)
+	}
+	return target, changed, nil
+}
+
+// errBlocked answers what a blocked user tries to do to the user who blocked them.
+func errBlocked(action string) error {
+	return utils.NewError(utils.ErrForbidden.Code, "You cannot "+action+" this user")
+}
+
+// HasBlocked reports whether the user with ID blockerID blocked the user with ID userID.
+func HasBlocked(ctx context.Context, gormDB *gorm.DB, blockerID, userID uuid.UUID) (bool, error) {
+	var count int64
+	if err := gormDB.WithContext(ctx).Model(&UserBlock{}).
+		Where("user_id = ? AND target_id = ? AND kind = ?", blockerID, userID, BlockKindBlock).
+		Count(&count).Error; err != nil {
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check block")
+	}
+	return count > 0, nil
+}
+
+// BlockersOf is a subquery of the users who blocked the user with ID userID.
+func BlockersOf(gormDB *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return gormDB.Model(&UserBlock{}).Select("user_id").Where("target_id = ? AND kind = ?", userID, BlockKindBlock)
+}
+
+// HiddenBy is a subquery of the users whose content is hidden from the user with ID userID:
+// those they blocked or muted.
+func HiddenBy(gormDB *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return gormDB.Model(&UserBlock{}).Select("target_id").Where("user_id = ?", userID)
+}
+
+// WithoutSilenced drops from recipients the users who blocked or muted actorID, so they are not
+// notified of what the actor does. On a lookup failure every recipient is kept.
+func WithoutSilenced(ctx context.Context, gormDB *gorm.DB, recipients []uuid.UUID, actorID uuid.UUID) []uuid.UUID {
+	if len(recipients) == 0 {
+		return recipients
+	}
+	var silenced []uuid.UUID
+	if err := gormDB.WithContext(ctx).Model(&UserBlock{}).
+		Where("user_id IN ? AND target_id = ?", recipients, actorID).
+		Distinct().Pluck("user_id", &silenced).Error; err != nil || len(silenced) == 0 {
+		return recipients
+	}
+	skip := make(map[uuid.UUID]bool, len(silenced))
+	for _, id := range silenced {
+		skip[id] = true
+	}
+	kept := make([]uuid.UUID, 0, len(recipients))
+	for _, id := range recipients {
+		if !skip[id] {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// ListBlocks returns a page of the users the user blocked or muted, of kind when it is set, most
+// recent first.
+func ListBlocks(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID, kind string, page, limit int) ([]BlockedUser, int64, error) {
+	query := gormDB.WithContext(ctx).Model(&UserBlock{}).
+		Joins("JOIN users ON users.id = user_blocks.target_id").
+		Where("user_blocks.user_id = ? AND users.deleted_at IS NULL", userID)
+	if kind != "" {
+		query = query.Where("user_blocks.kind = ?", kind)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count blocks")
+	}
+	blocks := []BlockedUser{}
+	if err := query.Select("users.id, users.username, users.name, users.avatar_url, users.bio, users.job_title, users.location, users.skills, users.interests, users.last_seen, user_blocks.kind, user_blocks.created_at").
+		Order("user_blocks.created_at DESC").Offset((page - 1) * limit).Limit(limit).
+		Scan(&blocks).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get blocks")
+	}
+	return blocks, total, nil
+}
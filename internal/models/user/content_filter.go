@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/contentfilter"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kinds of blocklist entries.
+const (
+	BlockedTermKind   = "term"
+	BlockedDomainKind = "domain"
+)
+
+// BlockedTerm is an entry of the content filter blocklists: a term profile fields and post titles
+// cannot contain, or a domain they cannot link to.
+type BlockedTerm struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Kind        string    `gorm:"size:10;not null;uniqueIndex:idx_blocked_term" json:"kind"`
+	Value       string    `gorm:"size:100;not null;uniqueIndex:idx_blocked_term" json:"value"`
+	Substring   bool      `gorm:"not null;default:false" json:"substring"`
+	CreatedByID uuid.UUID `gorm:"type:uuid;not null" json:"created_by_id"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// GetContentBlocklist returns the blocklists the content filter checks text against. They are
+// read on every filtered write, so they are cached whole until an entry changes.
+func GetContentBlocklist(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB) (*contentfilter.Blocklist, error) {
+	return cache.Fetch(ctx, rclient, cache.BlocklistKey, rclient.TTL("blocklist"), func(ctx context.Context) (*contentfilter.Blocklist, []string, error) {
+		var entries []BlockedTerm
+		if err := db.WithContext(ctx).Find(&entries).Error; err != nil {
+			return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get blocklist")
+		}
+		list := &contentfilter.Blocklist{Terms: []contentfilter.Term{}, Domains: []string{}}
+		for _, e := range entries {
+			switch e.Kind {
+			case BlockedTermKind:
+				list.Terms = append(list.Terms, contentfilter.Term{Value: e.Value, Substring: e.Substring})
+			case BlockedDomainKind:
+				list.Domains = append(list.Domains, e.Value)
+			}
+		}
+		return list, nil, nil
+	})
+}
+
+// ListBlockedTerms lists the blocklist entries of a kind, or of every kind, alphabetically.
+func ListBlockedTerms(ctx context.Context, db *gorm.DB, kind string) ([]BlockedTerm, error) {
+	query := db.WithContext(ctx).Model(&BlockedTerm{})
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	entries := []BlockedTerm{}
+	if err := query.Order("kind, value").Find(&entries).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch blocklist")
+	}
+	return entries, nil
+}
+
+// AddBlockedTerm adds an entry to a blocklist. Terms are stored folded the way the filter reads
+// text and domains without scheme or path, so an entry matches however it was typed.
+func AddBlockedTerm(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, actorID uuid.UUID, kind, value string, substring bool) (*BlockedTerm, error) {
+	switch kind {
+	case BlockedTermKind:
+		value = contentfilter.NormalizeTerm(value)
+	case BlockedDomainKind:
+		value = contentfilter.NormalizeDomain(value)
+		substring = false
+		if !strings.Contains(value, ".") {
+			return nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid domain")
+		}
+	default:
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Kind must be term or domain")
+	}
+	if value == "" {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "The entry has no letters to match")
+	}
+
+	entry := &BlockedTerm{Kind: kind, Value: value, Substring: substring, CreatedByID: actorID}
+	res := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	if res.Error != nil {
+		return nil, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to add blocklist entry")
+	}
+	if res.RowsAffected == 0 {
+		return nil, utils.NewError(utils.ErrConflict.Code, "Entry is already blocked")
+	}
+	cache.Delete(ctx, rclient, cache.BlocklistKey)
+	return entry, nil
+}
+
+// DeleteBlockedTerm removes an entry from the blocklists and returns it.
+func DeleteBlockedTerm(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID) (*BlockedTerm, error) {
+	var entry BlockedTerm
+	res := db.WithContext(ctx).Clauses(clause.Returning{}).Where("id = ?", id).Delete(&entry)
+	if res.Error != nil {
+		return nil, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to delete blocklist entry")
+	}
+	if res.RowsAffected == 0 {
+		return nil, utils.NewError(utils.ErrNotFound.Code, "Blocklist entry not found")
+	}
+	cache.Delete(ctx, rclient, cache.BlocklistKey)
+	return &entry, nil
+}
@@ -0,0 +1,229 @@
+package models
+
+import (
+	"context"
+	"hash/fnv"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// featureFlagKey is the form of feature flag keys, such as new_feed.
+var featureFlagKey = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// FeatureFlag turns a feature on for part of the users. A disabled flag is off for everyone;
+// an enabled one is on for the users and roles it targets, and for Rollout percent of the other
+// signed-in users, each of whom always lands on the same side of the rollout.
+type FeatureFlag struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Key         string    `gorm:"size:50;not null;uniqueIndex" json:"key"`
+	Description string    `gorm:"size:255" json:"description"`
+	Enabled     bool      `gorm:"not null;default:false" json:"enabled"`
+	Rollout     int       `gorm:"not null;default:0" json:"rollout"`
+	Users       []string  `gorm:"type:text[]" json:"users"`
+	Roles       []string  `gorm:"type:text[]" json:"roles"`
+	UpdatedByID uuid.UUID `gorm:"type:uuid;not null" json:"updated_by_id"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// FeatureFlagUpdate holds the changes to a flag; nil fields are left as they are.
+type FeatureFlagUpdate struct {
+	Description *string
+	Enabled     *bool
+	Rollout     *int
+	Users       *[]uuid.UUID
+	Roles       *[]uuid.UUID
+}
+
+// EnabledFor reports whether the flag is on for a user with a role. Guests, with neither, only get
+// flags rolled out to everyone.
+func (f *FeatureFlag) EnabledFor(userID, roleID uuid.UUID) bool {
+	if !f.Enabled {
+		return false
+	}
+	if f.Rollout >= 100 {
+		return true
+	}
+	if userID == uuid.Nil {
+		return false
+	}
+	if utils.Contains(f.Users, userID.String()) || (roleID != uuid.Nil && utils.Contains(f.Roles, roleID.String())) {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(f.Key + ":" + userID.String()))
+	return int(h.Sum32()%100) < f.Rollout
+}
+
+// uuidStrings stores a targeting list.
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	return out
+}
+
+// GetFeatureFlags returns every feature flag by key. Flags are checked on many requests, so they
+// are cached whole until one changes.
+func GetFeatureFlags(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB) (map[string]*FeatureFlag, error) {
+	return cache.Fetch(ctx, rclient, cache.FeatureFlagsKey, rclient.TTL("feature_flags"), func(ctx context.Context) (map[string]*FeatureFlag, []string, error) {
+		var flags []*FeatureFlag
+		if err := db.WithContext(ctx).Find(&flags).Error; err != nil {
+			return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get feature flags")
+		}
+		byKey := make(map[string]*FeatureFlag, len(flags))
+		for _, f := range flags {
+			byKey[f.Key] = f
+		}
+		return byKey, nil, nil
+	})
+}
+
+// ListFeatureFlags lists the feature flags by key.
+func ListFeatureFlags(ctx context.Context, db *gorm.DB) ([]FeatureFlag, error) {
+	flags := []FeatureFlag{}
+	if err := db.WithContext(ctx).Order("key").Find(&flags).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to list feature flags")
+	}
+	return flags, nil
+}
+
+// GetFeatureFlag returns a feature flag by key.
+func GetFeatureFlag(ctx context.Context, db *gorm.DB, key string) (*FeatureFlag, error) {
+	var flag FeatureFlag
+	if err := db.WithContext(ctx).Where("key = ?", key).First(&flag).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Feature flag not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get feature flag")
+	}
+	return &flag, nil
+}
+
+// validateTargets checks the users and roles a flag targets exist.
+func validateTargets(tx *gorm.DB, users, roles *[]uuid.UUID) error {
+	if users != nil && len(*users) > 0 {
+		var count int64
+		if err := tx.Model(&User{}).Where("id IN ?", *users).Count(&count).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check targeted users")
+		}
+		if int(count) != len(*users) {
+			return utils.NewError(utils.ErrBadRequest.Code, "Targeted users do not all exist")
+		}
+	}
+	if roles != nil && len(*roles) > 0 {
+		var count int64
+		if err := tx.Model(&Role{}).Where("id IN ?", *roles).Count(&count).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check targeted roles")
+		}
+		if int(count) != len(*roles) {
+			return utils.NewError(utils.ErrBadRequest.Code, "Targeted roles do not all exist")
+		}
+	}
+	return nil
+}
+
+// applyFeatureFlagUpdate sets the changed fields of u on flag.
+func applyFeatureFlagUpdate(flag *FeatureFlag, u FeatureFlagUpdate) error {
+	if u.Rollout != nil && (*u.Rollout < 0 || *u.Rollout > 100) {
+		return utils.NewError(utils.ErrBadRequest.Code, "Rollout must be between 0 and 100")
+	}
+	if u.Description != nil {
+		flag.Description = *u.Description
+	}
+	if u.Enabled != nil {
+		flag.Enabled = *u.Enabled
+	}
+	if u.Rollout != nil {
+		flag.Rollout = *u.Rollout
+	}
+	if u.Users != nil {
+		flag.Users = uuidStrings(*u.Users)
+	}
+	if u.Roles != nil {
+		flag.Roles = uuidStrings(*u.Roles)
+	}
+	return nil
+}
+
+// CreateFeatureFlag creates a feature flag with the settings of u.
+func CreateFeatureFlag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, actorID uuid.UUID, key string, u FeatureFlagUpdate) (*FeatureFlag, error) {
+	if !featureFlagKey.MatchString(key) {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Key must be 2 to 50 lowercase letters, digits and underscores, starting with a letter")
+	}
+	flag := &FeatureFlag{Key: key, Users: []string{}, Roles: []string{}, UpdatedByID: actorID}
+	if err := applyFeatureFlagUpdate(flag, u); err != nil {
+		return nil, err
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := validateTargets(tx, u.Users, u.Roles); err != nil {
+			return err
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(flag)
+		if res.Error != nil {
+			return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to create feature flag")
+		}
+		if res.RowsAffected == 0 {
+			return utils.NewError(utils.ErrConflict.Code, "Feature flag already exists")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	cache.Delete(ctx, rclient, cache.FeatureFlagsKey)
+	return flag, nil
+}
+
+// UpdateFeatureFlag changes a feature flag and returns it as it was and as it is now.
+func UpdateFeatureFlag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, actorID uuid.UUID, key string, u FeatureFlagUpdate) (*FeatureFlag, *FeatureFlag, error) {
+	var before, flag FeatureFlag
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&flag).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Feature flag not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get feature flag")
+		}
+		before = flag
+		if err := applyFeatureFlagUpdate(&flag, u); err != nil {
+			return err
+		}
+		if err := validateTargets(tx, u.Users, u.Roles); err != nil {
+			return err
+		}
+		flag.UpdatedByID = actorID
+		if err := tx.Save(&flag).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update feature flag")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	cache.Delete(ctx, rclient, cache.FeatureFlagsKey)
+	return &before, &flag, nil
+}
+
+// DeleteFeatureFlag deletes a feature flag and returns it; the feature is then off for everyone.
+func DeleteFeatureFlag(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, key string) (*FeatureFlag, error) {
+	var flag FeatureFlag
+	res := db.WithContext(ctx).Clauses(clause.Returning{}).Where("key = ?", key).Delete(&flag)
+	if res.Error != nil {
+		return nil, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to delete feature flag")
+	}
+	if res.RowsAffected == 0 {
+		return nil, utils.NewError(utils.ErrNotFound.Code, "Feature flag not found")
+	}
+	cache.Delete(ctx, rclient, cache.FeatureFlagsKey)
+	return &flag, nil
+}
@@ -0,0 +1,308 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OAuthProfile is the account information returned by a social login provider.
+type OAuthProfile struct {
+	Provider       string
+	ProviderUserID string
+	Email          string
+	EmailVerified  bool
+	Username       string
+	Name           string
+	AvatarURL      string
+}
+
+// Identity links an external social login account to a user.
+type Identity struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider       string    `gorm:"size:20;not null;uniqueIndex:idx_identity_provider_user" json:"provider"`
+	ProviderUserID string    `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_user" json:"-"`
+	Email          string    `gorm:"size:100" json:"email"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ResolveOAuthUser returns the user signed in through a social login, linking the identity to an
+// existing account with the same verified email or, when allowSignup is set, creating a new
+// account. The boolean reports whether a new account was created.
+func ResolveOAuthUser(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, profile *OAuthProfile, allowSignup bool) (*User, bool, error) {
+	if profile.ProviderUserID == "" {
+		return nil, false, utils.NewError(utils.ErrBadRequest.Code, "Provider did not return an account ID")
+	}
+
+	var identity Identity
+	err := db.WithContext(ctx).Where("provider = ? AND provider_user_id = ?", profile.Provider, profile.ProviderUserID).First(&identity).Error
+	if err == nil {
+		u, err := GetUserBy(ctx, rclient, db, "id = ?", []interface{}{identity.UserID})
+		return u, false, err
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get identity")
+	}
+
+	if profile.Email == "" || !profile.EmailVerified {
+		return nil, false, utils.NewError(utils.ErrForbidden.Code, "A verified email is required to sign in with "+profile.Provider)
+	}
+
+	var userID uuid.UUID
+	created := false
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing User
+		err := tx.Select("id, is_email_verified").Where("email = ?", profile.Email).First(&existing).Error
+		switch {
+		case err == nil:
+			// Linking to an unverified account would let whoever registered the address first take over the login.
+			if !existing.IsEmailVerified {
+				return utils.NewError(utils.ErrConflict.Code, "An account with this email is awaiting activation")
+			}
+			userID = existing.ID
+		case err == gorm.ErrRecordNotFound:
+			if !allowSignup {
+				return utils.NewError(utils.ErrForbidden.Code, "Registration is not open to new accounts")
+			}
+			u, err := newOAuthUser(ctx, rclient, tx, profile)
+			if err != nil {
+				return err
+			}
+			userID = u.ID
+			created = true
+		default:
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get user")
+		}
+
+		identity = Identity{UserID: userID, Provider: profile.Provider, ProviderUserID: profile.ProviderUserID, Email: profile.Email}
+		if err := tx.Create(&identity).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to link identity")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if created {
+		cache.ForgetMissing(ctx, rclient, cache.MissingUsers)
+	}
+
+	u, err := GetUserBy(ctx, rclient, db, "id = ?", []interface{}{userID})
+	return u, created, err
+}
+
+// newOAuthUser creates an active account for a social login, without a usable password.
+func newOAuthUser(ctx context.Context, rclient *storage.RedisClient, tx *gorm.DB, profile *OAuthProfile) (*User, error) {
+	username, err := uniqueUsername(ctx, tx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUser(ctx, rclient, tx, username, profile.Email, utils.UnusablePassword, "",
+		WithIsActive(true), WithEmailVerified(true), WithPasswordless(true), WithName(profile.Name), WithAvatarURL(profile.AvatarURL))
+}
+
+// uniqueUsername derives an unused alphanumeric username from the provider username or email.
+func uniqueUsername(ctx context.Context, tx *gorm.DB, profile *OAuthProfile) (string, error) {
+	source := profile.Username
+	if source == "" {
+		source, _, _ = strings.Cut(profile.Email, "@")
+	}
+	base := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, source)
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if len(base) < 3 {
+		base = "user" + base
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 5; attempt++ {
+		var count int64
+		if err := tx.WithContext(ctx).Model(&User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check username")
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			return "", utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to generate username")
+		}
+		candidate = fmt.Sprintf("%s%06d", base, n.Int64())
+	}
+	return "", utils.NewError(utils.ErrConflict.Code, "Failed to find an available username")
+}
+
+// GetLinkedProviders returns the social login providers linked to a user.
+func GetLinkedProviders(ctx context.Context, db *gorm.DB, userID uuid.UUID) ([]string, error) {
+	providers := []string{}
+	if err := db.WithContext(ctx).Model(&Identity{}).Where("user_id = ?", userID).Order("provider").Pluck("provider", &providers).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get linked providers")
+	}
+	return providers, nil
+}
+
+// ListIdentities returns the social login accounts linked to a user.
+func ListIdentities(ctx context.Context, db *gorm.DB, userID uuid.UUID) ([]Identity, error) {
+	identities := []Identity{}
+	if err := db.WithContext(ctx).Where("user_id = ?", userID).Order("provider").Find(&identities).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get identities")
+	}
+	return identities, nil
+}
+
+// lockLoginMethods locks a user's row for the rest of tx, so concurrent changes to their login
+// methods run one after the other, and returns the user with how many identities they have.
+func lockLoginMethods(tx *gorm.DB, userID uuid.UUID) (*User, int64, error) {
+	var u User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, passwordless").Where("id = ?", userID).First(&u).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, 0, utils.NewError(utils.ErrNotFound.Code, "User not found")
+		}
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get user")
+	}
+	var identities int64
+	if err := tx.Model(&Identity{}).Where("user_id = ?", userID).Count(&identities).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count identities")
+	}
+	return &u, identities, nil
+}
+
+// LinkIdentity links a social login account to a signed in user. A user links at most one
+// account per provider, and an account links to one user only.
+func LinkIdentity(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID uuid.UUID, profile *OAuthProfile, ip string) (*Identity, error) {
+	if profile.ProviderUserID == "" {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Provider did not return an account ID")
+	}
+
+	identity := Identity{UserID: userID, Provider: profile.Provider, ProviderUserID: profile.ProviderUserID, Email: profile.Email}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, _, err := lockLoginMethods(tx, userID); err != nil {
+			return err
+		}
+
+		var existing Identity
+		err := tx.Where("(provider = ? AND provider_user_id = ?) OR (provider = ? AND user_id = ?)",
+			profile.Provider, profile.ProviderUserID, profile.Provider, userID).First(&existing).Error
+		switch {
+		case err == nil && existing.UserID != userID:
+			return utils.NewError(utils.ErrConflict.Code, "This "+profile.Provider+" account is linked to another user")
+		case err == nil:
+			return utils.NewError(utils.ErrConflict.Code, "A "+profile.Provider+" account is already linked")
+		case err != gorm.ErrRecordNotFound:
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get identity")
+		}
+
+		if err := tx.Create(&identity).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to link identity")
+		}
+		return recordAudit(tx, userID, AuditIdentityLink, AuditTargetUser, userID, nil, map[string]interface{}{"provider": profile.Provider}, ip)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cache.InvalidateUser(ctx, rclient, userID)
+	return &identity, nil
+}
+
+// UnlinkIdentity removes the social login account of provider from a user. The last way a user
+// can sign in, be it a social login or their password, cannot be removed.
+func UnlinkIdentity(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID uuid.UUID, provider, ip string) error {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		u, identities, err := lockLoginMethods(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		res := tx.Where("user_id = ? AND provider = ?", userID, provider).Delete(&Identity{})
+		if res.Error != nil {
+			return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to unlink identity")
+		}
+		if res.RowsAffected == 0 {
+			return utils.NewError(utils.ErrNotFound.Code, "No "+provider+" account is linked")
+		}
+		if u.Passwordless && identities <= 1 {
+			return utils.NewError(utils.ErrConflict.Code, "Set a password or link another account before removing your last way to sign in")
+		}
+		return recordAudit(tx, userID, AuditIdentityUnlink, AuditTargetUser, userID, map[string]interface{}{"provider": provider}, nil, ip)
+	})
+	if err != nil {
+		return err
+	}
+	cache.InvalidateUser(ctx, rclient, userID)
+	return nil
+}
+
+// SetPassword gives a user who signs in with social logins only a password to sign in with.
+// Users who have one change it instead, which requires the current password.
+func SetPassword(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID uuid.UUID, hash, ip string) error {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		u, _, err := lockLoginMethods(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !u.Passwordless {
+			return utils.NewError(utils.ErrConflict.Code, "A password is already set, change it instead")
+		}
+		if err := tx.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"password":             hash,
+			"passwordless":         false,
+			"last_password_change": time.Now(),
+		}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to set password")
+		}
+		return recordAudit(tx, userID, AuditPasswordSet, AuditTargetUser, userID, map[string]interface{}{"password": false}, map[string]interface{}{"password": true}, ip)
+	})
+	if err != nil {
+		return err
+	}
+	cache.InvalidateUser(ctx, rclient, userID)
+	return nil
+}
+
+// RemovePassword stops a user from signing in with a password, replacing it with
+// utils.UnusablePassword. The user must have a social login linked to sign in with instead.
+func RemovePassword(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID uuid.UUID, ip string) error {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		u, identities, err := lockLoginMethods(tx, userID)
+		if err != nil {
+			return err
+		}
+		if u.Passwordless {
+			return utils.NewError(utils.ErrNotFound.Code, "No password is set")
+		}
+		if identities == 0 {
+			return utils.NewError(utils.ErrConflict.Code, "Link another account before removing your password")
+		}
+		if err := tx.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"password":     utils.UnusablePassword,
+			"passwordless": true,
+		}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to remove password")
+		}
+		return recordAudit(tx, userID, AuditPasswordRemove, AuditTargetUser, userID, map[string]interface{}{"password": true}, map[string]interface{}{"password": false}, ip)
+	})
+	if err != nil {
+		return err
+	}
+	cache.InvalidateUser(ctx, rclient, userID)
+	return nil
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Registration modes. Open lets anyone sign up, invite-only requires an invitation code and closed
+// refuses every new account.
+const (
+	RegistrationOpen       = "open"
+	RegistrationInviteOnly = "invite_only"
+	RegistrationClosed     = "closed"
+)
+
+// RegistrationModes lists every registration mode.
+var RegistrationModes = []string{RegistrationOpen, RegistrationInviteOnly, RegistrationClosed}
+
+const (
+	// MaxInvitationsPerUser caps how many active invitations a user without staff rights holds.
+	MaxInvitationsPerUser = 5
+	// MaxInvitationUses caps how many accounts one invitation of such a user can create.
+	MaxInvitationUses = 5
+	// MaxInvitationLifetime is how long an invitation of such a user can stay valid.
+	MaxInvitationLifetime = 30 * 24 * time.Hour
+)
+
+// Invitation is a code letting up to MaxUses people register while registration is invite-only.
+type Invitation struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Code        string     `gorm:"size:32;not null;uniqueIndex" json:"code"`
+	CreatedByID uuid.UUID  `gorm:"type:uuid;not null;index" json:"created_by_id"`
+	MaxUses     int        `gorm:"not null;default:1" json:"max_uses"`
+	Uses        int        `gorm:"not null;default:0" json:"uses"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	RevokedAt   *time.Time `gorm:"index" json:"revoked_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// InvitationRedemption records which invitation an account registered with.
+type InvitationRedemption struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	InvitationID uuid.UUID `gorm:"type:uuid;not null;index" json:"invitation_id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// CreateInvitation issues an invitation code for a user. A limited user holds at most
+// MaxInvitationsPerUser active invitations, each valid for at most MaxInvitationUses accounts and
+// MaxInvitationLifetime; staff are not limited and may issue invitations that never expire.
+func CreateInvitation(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID, maxUses int, expiresAt *time.Time, limited bool) (*Invitation, error) {
+	if maxUses < 1 {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "An invitation must allow at least one use")
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Expiry must lie in the future")
+	}
+	if limited {
+		if maxUses > MaxInvitationUses {
+			return nil, utils.NewError(utils.ErrBadRequest.Code, "An invitation can allow at most "+strconv.Itoa(MaxInvitationUses)+" uses")
+		}
+		latest := time.Now().Add(MaxInvitationLifetime)
+		if expiresAt == nil || expiresAt.After(latest) {
+			expiresAt = &latest
+		}
+	}
+
+	code, err := utils.GenerateRandomToken(20, 20)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to generate invitation code")
+	}
+	invitation := &Invitation{Code: code, CreatedByID: userID, MaxUses: maxUses, ExpiresAt: expiresAt}
+
+	err = gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the owner serializes concurrent issuing so the cap holds
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", userID).First(&User{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NewError(utils.ErrNotFound.Code, "User not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch user")
+		}
+		if limited {
+			var count int64
+			if err := activeInvitations(tx).Model(&Invitation{}).Where("created_by_id = ?", userID).Count(&count).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count invitations")
+			}
+			if count >= MaxInvitationsPerUser {
+				return utils.NewError(utils.ErrConflict.Code, "Invitation limit reached")
+			}
+		}
+		if err := tx.Create(invitation).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create invitation")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// ListInvitations retrieves a page of invitations, newest first; a createdBy limits it to the
+// invitations of one user.
+func ListInvitations(ctx context.Context, gormDB *gorm.DB, createdBy *uuid.UUID, page, limit int) ([]Invitation, int64, error) {
+	query := gormDB.WithContext(ctx).Model(&Invitation{})
+	if createdBy != nil {
+		query = query.Where("created_by_id = ?", *createdBy)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count invitations")
+	}
+
+	invitations := []Invitation{}
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&invitations).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch invitations")
+	}
+	return invitations, total, nil
+}
+
+// RevokeInvitation revokes an invitation so it cannot be redeemed any more. Unless any is set,
+// only invitations created by userID can be revoked.
+func RevokeInvitation(ctx context.Context, gormDB *gorm.DB, userID, id uuid.UUID, any bool) error {
+	query := gormDB.WithContext(ctx).Model(&Invitation{}).Where("id = ? AND revoked_at IS NULL", id)
+	if !any {
+		query = query.Where("created_by_id = ?", userID)
+	}
+	res := query.Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to revoke invitation")
+	}
+	if res.RowsAffected == 0 {
+		return utils.NewError(utils.ErrNotFound.Code, "Invitation not found")
+	}
+	return nil
+}
+
+// redeemInvitation uses up one use of an invitation for a new account. Unknown, revoked, expired
+// and used up codes are refused alike.
+func redeemInvitation(tx *gorm.DB, code string, userID uuid.UUID) error {
+	var invitation Invitation
+	err := activeInvitations(tx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", strings.TrimSpace(code)).First(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NewError(utils.ErrForbidden.Code, "Invalid or expired invitation code")
+		}
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch invitation")
+	}
+	if err := tx.Model(&Invitation{}).Where("id = ?", invitation.ID).Update("uses", gorm.Expr("uses + 1")).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to redeem invitation")
+	}
+	if err := tx.Create(&InvitationRedemption{InvitationID: invitation.ID, UserID: userID}).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to redeem invitation")
+	}
+	return nil
+}
+
+// activeInvitations limits a query to invitations that are neither revoked, expired nor used up.
+func activeInvitations(db *gorm.DB) *gorm.DB {
+	return db.Where("revoked_at IS NULL AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+}
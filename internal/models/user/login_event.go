@@ -0,0 +1,127 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// LoginHistoryWindow is how far back a sign-in is compared with earlier ones to decide whether
+	// it came from a new location or device.
+	LoginHistoryWindow = 90 * 24 * time.Hour
+	// LoginFailureWindow and LoginFailureAlertThreshold decide when failed sign-ins are reported:
+	// once that many failed within the window.
+	LoginFailureWindow         = 15 * time.Minute
+	LoginFailureAlertThreshold = 5
+)
+
+// LoginEvent records a sign-in attempt on an account: how it was made, from where, and whether it
+// succeeded or why not. Successful sign-ins from a location or device not seen before, and bursts
+// of failed ones, are reported to the user.
+type LoginEvent struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index:idx_login_event_user" json:"-"`
+	Success     bool      `gorm:"not null" json:"success"`
+	Method      string    `gorm:"size:30;not null" json:"method"`
+	TwoFactor   bool      `gorm:"not null;default:false" json:"two_factor"`
+	Reason      string    `gorm:"size:30" json:"reason,omitempty"`
+	IP          string    `gorm:"size:45" json:"ip"`
+	UserAgent   string    `gorm:"size:255" json:"user_agent"`
+	Country     string    `gorm:"size:100" json:"country,omitempty"`
+	Region      string    `gorm:"size:100" json:"region,omitempty"`
+	City        string    `gorm:"size:100" json:"city,omitempty"`
+	NewLocation bool      `gorm:"not null;default:false" json:"new_location"`
+	NewDevice   bool      `gorm:"not null;default:false" json:"new_device"`
+	CreatedAt   time.Time `gorm:"autoCreateTime;index:idx_login_event_user" json:"created_at"`
+}
+
+// LoginAnomalyEvent is the payload of OutboxLoginAnomaly.
+type LoginAnomalyEvent struct {
+	LoginEventID uuid.UUID `json:"login_event_id"`
+}
+
+// RecordLoginEvent stores a sign-in attempt. A successful one is compared with the user's earlier
+// sign-ins, and the user is emailed when it came from a new location or device, or when it is the
+// failure that makes LoginFailureAlertThreshold within LoginFailureWindow.
+func RecordLoginEvent(ctx context.Context, gormDB *gorm.DB, event *LoginEvent) error {
+	if len(event.UserAgent) > 255 {
+		event.UserAgent = event.UserAgent[:255]
+	}
+	return gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		alert := false
+		if event.Success {
+			var seen struct {
+				Logins    int64
+				Located   int64
+				Countries int64
+				Devices   int64
+			}
+			if err := tx.Model(&LoginEvent{}).
+				Select(`count(*) AS logins, count(*) FILTER (WHERE country <> '') AS located,
+					count(*) FILTER (WHERE country = ?) AS countries, count(*) FILTER (WHERE user_agent = ?) AS devices`,
+					event.Country, event.UserAgent).
+				Where("user_id = ? AND success AND created_at >= ?", event.UserID, time.Now().Add(-LoginHistoryWindow)).
+				Scan(&seen).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to compare login")
+			}
+			// The first sign-in, or the first in a long while, has nothing to be compared with
+			if seen.Logins > 0 {
+				event.NewLocation = event.Country != "" && seen.Located > 0 && seen.Countries == 0
+				event.NewDevice = seen.Devices == 0
+			}
+			alert = event.NewLocation || event.NewDevice
+		} else {
+			var failures int64
+			if err := tx.Model(&LoginEvent{}).
+				Where("user_id = ? AND NOT success AND created_at >= ?", event.UserID, time.Now().Add(-LoginFailureWindow)).
+				Count(&failures).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count failed logins")
+			}
+			alert = failures+1 == LoginFailureAlertThreshold
+		}
+
+		if err := tx.Create(event).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record login")
+		}
+		if alert {
+			return EnqueueOutbox(ctx, tx, OutboxLoginAnomaly, LoginAnomalyEvent{LoginEventID: event.ID})
+		}
+		return nil
+	})
+}
+
+// GetLoginEvent returns a login event by ID.
+func GetLoginEvent(ctx context.Context, gormDB *gorm.DB, id uuid.UUID) (*LoginEvent, error) {
+	var event LoginEvent
+	if err := gormDB.WithContext(ctx).Where("id = ?", id).First(&event).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Login event not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get login event")
+	}
+	return &event, nil
+}
+
+// GetLoginEvents returns a page of a user's sign-in attempts, most recent first. success narrows
+// them to the successful or the failed ones.
+func GetLoginEvents(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID, success *bool, page, limit int) ([]LoginEvent, int64, error) {
+	query := gormDB.WithContext(ctx).Model(&LoginEvent{}).Where("user_id = ?", userID)
+	if success != nil {
+		query = query.Where("success = ?", *success)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count login events")
+	}
+	events := []LoginEvent{}
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get login events")
+	}
+	return events, total, nil
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// Account states set by moderators.
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
+
+// Moderation actions recorded in the moderation trail.
+const (
+	ModerationSuspend     = "suspend"
+	ModerationBan         = "ban"
+	ModerationReinstate   = "reinstate"
+	ModerationForceLogout = "force_logout"
+)
+
+// ModerationAction records a moderator acting on an account. Rows are only ever added.
+type ModerationAction struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	ActorID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"actor_id"`
+	Action    string     `gorm:"size:30;not null" json:"action"`
+	Reason    string     `gorm:"size:255" json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	IP        string     `gorm:"size:45" json:"ip"`
+	CreatedAt time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// UserFilter narrows an admin listing of users.
+type UserFilter struct {
+	Query  string
+	Status string
+	RoleID *uuid.UUID
+}
+
+// AdminUser is the projection of a user shown to moderators.
+type AdminUser struct {
+	ID             uuid.UUID  `json:"id"`
+	Username       string     `json:"username"`
+	Email          string     `json:"email"`
+	Name           string     `json:"name"`
+	RoleID         uuid.UUID  `json:"role_id"`
+	RoleName       string     `json:"role_name"`
+	IsActive       bool       `json:"is_active"`
+	Status         string     `json:"status"`
+	StatusReason   string     `json:"status_reason"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+	LastLoginAt    *time.Time `json:"last_login_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// IsRestricted reports whether the account is banned or serving a suspension.
+func (u *User) IsRestricted() bool {
+	switch u.Status {
+	case UserStatusBanned:
+		return true
+	case UserStatusSuspended:
+		return u.SuspendedUntil == nil || u.SuspendedUntil.After(time.Now())
+	}
+	return false
+}
+
+// ListUsers retrieves a page of users for moderators, newest first.
+func ListUsers(ctx context.Context, db *gorm.DB, filter UserFilter, page, limit int) ([]AdminUser, int64, error) {
+	query := db.WithContext(ctx).Model(&User{}).Joins("LEFT JOIN roles ON roles.id = users.role_id")
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(users.username) LIKE ? OR LOWER(users.email) LIKE ? OR LOWER(users.name) LIKE ?", like, like, like)
+	}
+	if filter.Status != "" {
+		query = query.Where("users.status = ?", filter.Status)
+	}
+	if filter.RoleID != nil {
+		query = query.Where("users.role_id = ?", *filter.RoleID)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count users")
+	}
+
+	users := []AdminUser{}
+	if err := query.Select("users.id, users.username, users.email, users.name, users.role_id, roles.name AS role_name, users.is_active, users.status, users.status_reason, users.suspended_until, users.last_login_at, users.created_at").
+		Order("users.created_at DESC").Offset((page - 1) * limit).Limit(limit).
+		Scan(&users).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch users")
+	}
+	return users, total, nil
+}
+
+// SetUserStatus suspends, bans or reinstates an account and records who did it in the moderation
+// trail and the audit log. Restricting an account also signs it out everywhere. A nil until suspends indefinitely.
+func SetUserStatus(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID, actorID uuid.UUID, status, reason string, until *time.Time, ip string) (*User, error) {
+	action := ModerationReinstate
+	switch status {
+	case UserStatusSuspended:
+		action = ModerationSuspend
+	case UserStatusBanned:
+		action = ModerationBan
+		until = nil
+	case UserStatusActive:
+		until = nil
+		reason = ""
+	default:
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid account status")
+	}
+	if userID == actorID {
+		return nil, utils.NewError(utils.ErrForbidden.Code, "You cannot moderate your own account")
+	}
+
+	var u User
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", userID).First(&u).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "User not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch user")
+		}
+
+		before := map[string]interface{}{
+			"status":          u.Status,
+			"status_reason":   u.StatusReason,
+			"suspended_until": u.SuspendedUntil,
+		}
+		u.Status = status
+		u.StatusReason = reason
+		u.SuspendedUntil = until
+		if err := tx.Model(&u).Updates(map[string]interface{}{
+			"status":          status,
+			"status_reason":   reason,
+			"suspended_until": until,
+		}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update user status")
+		}
+
+		if err := recordModeration(tx, userID, actorID, action, reason, until, ip); err != nil {
+			return err
+		}
+		if err := EnqueueOutbox(ctx, tx, OutboxUserUpdated, UserChangedEvent{UserID: userID}); err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, "user."+action, AuditTargetUser, userID, before, map[string]interface{}{
+			"status":          u.Status,
+			"status_reason":   u.StatusReason,
+			"suspended_until": u.SuspendedUntil,
+		}, ip)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if status != UserStatusActive {
+		if err := RevokeSessions(ctx, rclient, db, userID); err != nil {
+			return nil, err
+		}
+	}
+	cache.InvalidateUser(ctx, rclient, userID)
+	return &u, nil
+}
+
+// ForceLogout signs a user out everywhere on behalf of a moderator.
+func ForceLogout(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID, actorID uuid.UUID, reason, ip string) error {
+	var count int64
+	if err := db.WithContext(ctx).Model(&User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch user")
+	}
+	if count == 0 {
+		return utils.NewError(utils.ErrNotFound.Code, "User not found")
+	}
+
+	if err := RevokeSessions(ctx, rclient, db, userID); err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := recordModeration(tx, userID, actorID, ModerationForceLogout, reason, nil, ip); err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, "user."+ModerationForceLogout, AuditTargetUser, userID, nil, map[string]interface{}{"reason": reason}, ip)
+	})
+}
+
+func recordModeration(tx *gorm.DB, userID, actorID uuid.UUID, action, reason string, until *time.Time, ip string) error {
+	entry := &ModerationAction{
+		UserID:    userID,
+		ActorID:   actorID,
+		Action:    action,
+		Reason:    reason,
+		ExpiresAt: until,
+		IP:        ip,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record moderation action")
+	}
+	return nil
+}
+
+// GetModerationActions returns the moderation trail of a user, newest first.
+func GetModerationActions(ctx context.Context, db *gorm.DB, userID uuid.UUID, limit int) ([]ModerationAction, error) {
+	actions := []ModerationAction{}
+	if err := db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&actions).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch moderation actions")
+	}
+	return actions, nil
+}
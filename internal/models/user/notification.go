@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/i18n"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
@@ -14,31 +16,100 @@ import (
 
 type Notification struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index:idx_notification_user_created,priority:1" json:"user_id"`
 	Type      string    `gorm:"size:50;not null" json:"type"`
 	Message   string    `gorm:"size:255;not null" json:"message"`
 	IsRead    bool      `gorm:"default:false" json:"is_read"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_notification_user_created,priority:2;index:idx_notification_created" json:"created_at"`
 }
 
-// NewNotification creates a new notification.
-func NewNotification(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, notifType, message string) (*Notification, error) {
+type NotificationSummary struct {
+	Total  int64            `json:"total"`
+	ByType map[string]int64 `json:"by_type"`
+}
+
+// userLocale returns the locale a user reads notifications and emails in, or i18n.Default when it
+// cannot be read.
+func userLocale(ctx context.Context, db *gorm.DB, userID uuid.UUID) string {
+	var locale string
+	if err := db.WithContext(ctx).Model(&User{}).Select("locale").Where("id = ?", userID).Scan(&locale).Error; err != nil || locale == "" {
+		return i18n.Default
+	}
+	return locale
+}
+
+// NotificationChannel is the Redis pub/sub channel new notifications of a user are published on.
+func NotificationChannel(userID uuid.UUID) string {
+	return "notifications:stream:" + userID.String()
+}
+
+// NewNotification notifies a user through the channels they chose for the notification's event:
+// in-app, where it is stored and published to their live streams, and as a push message to their
+// subscribed browsers. It returns nil without error when the user turned in-app notifications of
+// the event off. The message is an i18n message, translated into the user's locale and formatted
+// with args, then cut to the 255 characters a notification holds.
+func NewNotification(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, notifType, message string, args ...interface{}) (*Notification, error) {
+	message = i18n.T(userLocale(ctx, gormDB, userID), message, args...)
+	if runes := []rune(message); len(runes) > 255 {
+		message = string(runes[:255])
+	}
 	n := &Notification{UserID: userID, Type: notifType, Message: message}
 	validate := validator.New()
 	if err := validate.Struct(n); err != nil {
 		return nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid notification data", err.Error())
 	}
 
-	if err := gormDB.WithContext(ctx).Create(n).Error; err != nil {
-		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create notification")
+	inApp, push := notificationChannels(ctx, gormDB, userID, notifType)
+	if !inApp && !push {
+		return nil, nil
+	}
+	err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if inApp {
+			if err := tx.Create(n).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create notification")
+			}
+		}
+		if !push {
+			return nil
+		}
+		return EnqueueOutbox(ctx, tx, OutboxNotificationPush, PushNotificationEvent{
+			NotificationID: n.ID,
+			UserID:         userID,
+			Type:           notifType,
+			Message:        message,
+			CreatedAt:      time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !inApp {
+		return nil, nil
 	}
 
 	notifJSON, _ := json.Marshal(n)
 	key := "notification:" + n.ID.String()
-	redisClient.Set(ctx, key, notifJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, notifJSON, redisClient.TTL("notification"))
+	redisClient.Del(ctx, "notifications:summary:"+userID.String())
+	cache.Invalidate(ctx, redisClient, cache.NotificationsTag(userID))
+	redisClient.Publish(ctx, NotificationChannel(userID), notifJSON)
 	return n, nil
 }
 
+// GetNotificationsAfter retrieves, oldest first, the notifications a user received after the given
+// one, so a reconnecting stream can catch up.
+func GetNotificationsAfter(ctx context.Context, gormDB *gorm.DB, userID, afterID uuid.UUID, limit int) ([]Notification, error) {
+	after := gormDB.Model(&Notification{}).Select("created_at").Where("id = ? AND user_id = ?", afterID, userID)
+	notifs := []Notification{}
+	if err := gormDB.WithContext(ctx).
+		Where("user_id = ? AND created_at > (?)", userID, after).
+		Order("created_at ASC").Limit(limit).
+		Find(&notifs).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get notifications")
+	}
+	return notifs, nil
+}
+
 // GetNotification retrieves a notification by ID.
 func GetNotification(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, id uuid.UUID) (*Notification, error) {
 	key := "notification:" + id.String()
@@ -58,7 +129,33 @@ func GetNotification(ctx context.Context, redisClient *storage.RedisClient, gorm
 	}
 
 	notifJSON, _ := json.Marshal(n)
-	redisClient.Set(ctx, key, notifJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, notifJSON, redisClient.TTL("notification"))
+	return &n, nil
+}
+
+// GetUserNotification retrieves a notification by ID only if it belongs to the user.
+func GetUserNotification(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID, id uuid.UUID) (*Notification, error) {
+	key := "notification:" + id.String()
+	if cached, err := redisClient.Get(ctx, key).Result(); err == nil {
+		var n Notification
+		if err := json.Unmarshal([]byte(cached), &n); err == nil {
+			if n.UserID != userID {
+				return nil, utils.NewError(utils.ErrNotFound.Code, "Notification not found")
+			}
+			return &n, nil
+		}
+	}
+
+	var n Notification
+	if err := gormDB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&n).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Notification not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get notification")
+	}
+
+	notifJSON, _ := json.Marshal(n)
+	redisClient.Set(ctx, key, notifJSON, redisClient.TTL("notification"))
 	return &n, nil
 }
 
@@ -78,40 +175,144 @@ func GetNotifications(ctx context.Context, redisClient *storage.RedisClient, gor
 	}
 
 	notifsJSON, _ := json.Marshal(notifs)
-	redisClient.Set(ctx, key, notifsJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, notifsJSON, redisClient.TTL("notifications"))
 	return notifs, nil
 }
 
-// UpdateNotification updates a notification (e.g., mark as read).
-func UpdateNotification(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, id uuid.UUID, isRead bool) (*Notification, error) {
-	n, err := GetNotification(ctx, redisClient, gormDB, id)
+// GetNotificationsPage retrieves a page of a user's notifications, newest first; unreadOnly limits
+// it to unread ones.
+func GetNotificationsPage(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, p utils.Pagination, unreadOnly bool) (utils.Page[Notification], error) {
+	key := cache.NotificationsKey(userID, unreadOnly, p.CacheKey())
+	return cache.Fetch(ctx, redisClient, key, redisClient.TTL("notifications"), func(ctx context.Context) (utils.Page[Notification], []string, error) {
+		query := gormDB.WithContext(ctx).Model(&Notification{}).Where("user_id = ?", userID)
+		if unreadOnly {
+			query = query.Where("is_read = ?", false)
+		}
+		var total int64
+		if p.WithTotal {
+			if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+				return utils.Page[Notification]{}, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count notifications")
+			}
+		}
+		if p.After != nil {
+			createdAt, err := time.Parse(time.RFC3339Nano, p.After.Key)
+			if err != nil {
+				return utils.Page[Notification]{}, nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid cursor")
+			}
+			id, err := uuid.Parse(p.After.ID)
+			if err != nil {
+				return utils.Page[Notification]{}, nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid cursor")
+			}
+			query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+		}
+
+		var notifs []Notification
+		if err := query.Order("created_at DESC, id DESC").Limit(p.Limit + 1).Find(&notifs).Error; err != nil {
+			return utils.Page[Notification]{}, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get notifications")
+		}
+		page := utils.NewPage(notifs, p, func(n Notification) utils.Cursor {
+			return utils.Cursor{Key: n.CreatedAt.Format(time.RFC3339Nano), ID: n.ID.String()}
+		})
+		if p.WithTotal {
+			page.Total = &total
+		}
+		return page, []string{cache.NotificationsTag(userID)}, nil
+	})
+}
+
+// UpdateNotification updates a notification owned by the user (e.g., mark as read).
+func UpdateNotification(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID, id uuid.UUID, isRead bool) (*Notification, error) {
+	n, err := GetUserNotification(ctx, redisClient, gormDB, userID, id)
 	if err != nil {
 		return nil, err
 	}
 
 	n.IsRead = isRead
-	if err := gormDB.WithContext(ctx).Save(n).Error; err != nil {
+	if err := gormDB.WithContext(ctx).Model(&Notification{}).Where("id = ? AND user_id = ?", id, userID).Update("is_read", isRead).Error; err != nil {
 		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update notification")
 	}
 
 	notifJSON, _ := json.Marshal(n)
 	key := "notification:" + n.ID.String()
-	redisClient.Set(ctx, key, notifJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, notifJSON, redisClient.TTL("notification"))
+	redisClient.Del(ctx, "notifications:summary:"+userID.String())
+	cache.Invalidate(ctx, redisClient, cache.NotificationsTag(userID))
 	return n, nil
 }
 
-// DeleteNotification deletes a notification.
-func DeleteNotification(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, id uuid.UUID) error {
-	n, err := GetNotification(ctx, redisClient, gormDB, id)
+// MarkAllNotificationsRead marks every unread notification of a user as read and returns how many
+// changed.
+func MarkAllNotificationsRead(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID) (int64, error) {
+	var ids []uuid.UUID
+	err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&Notification{}).Where("id IN ?", ids).Update("is_read", true).Error
+	})
+	if err != nil {
+		return 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update notifications")
+	}
+
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, "notification:"+id.String())
+	}
+	keys = append(keys, "notifications:summary:"+userID.String())
+	redisClient.Del(ctx, keys...)
+	cache.Invalidate(ctx, redisClient, cache.NotificationsTag(userID))
+	return int64(len(ids)), nil
+}
+
+// DeleteNotification deletes a notification owned by the user.
+func DeleteNotification(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID, id uuid.UUID) error {
+	n, err := GetUserNotification(ctx, redisClient, gormDB, userID, id)
 	if err != nil {
 		return err
 	}
 
-	if err := gormDB.WithContext(ctx).Delete(n).Error; err != nil {
+	if err := gormDB.WithContext(ctx).Where("id = ? AND user_id = ?", n.ID, userID).Delete(&Notification{}).Error; err != nil {
 		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete notification")
 	}
 
 	key := "notification:" + id.String()
-	redisClient.Del(ctx, key)
+	redisClient.Del(ctx, key, "notifications:summary:"+userID.String())
+	cache.Invalidate(ctx, redisClient, cache.NotificationsTag(userID))
 	return nil
 }
+
+// GetNotificationSummary counts a user's unread notifications per type.
+func GetNotificationSummary(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID) (*NotificationSummary, error) {
+	key := "notifications:summary:" + userID.String()
+	if cached, err := redisClient.Get(ctx, key).Result(); err == nil {
+		var summary NotificationSummary
+		if err := json.Unmarshal([]byte(cached), &summary); err == nil {
+			return &summary, nil
+		}
+	}
+
+	var rows []struct {
+		Type  string
+		Count int64
+	}
+	if err := gormDB.WithContext(ctx).Model(&Notification{}).
+		Select("type, COUNT(*) AS count").
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Group("type").
+		Scan(&rows).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count notifications")
+	}
+
+	summary := &NotificationSummary{ByType: make(map[string]int64, len(rows))}
+	for _, row := range rows {
+		summary.ByType[row.Type] = row.Count
+		summary.Total += row.Count
+	}
+
+	summaryJSON, _ := json.Marshal(summary)
+	redisClient.Set(ctx, key, summaryJSON, redisClient.TTL("notif_summary"))
+	return summary, nil
+}
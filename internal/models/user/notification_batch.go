@@ -0,0 +1,174 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/i18n"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// notificationInsertBatch is how many notifications one INSERT statement holds.
+const notificationInsertBatch = 500
+
+// NotificationInput is one notification of a batch: an i18n message, formatted with Args, for a
+// user.
+type NotificationInput struct {
+	UserID  uuid.UUID
+	Type    string
+	Message string
+	Args    []interface{}
+}
+
+// NotificationBatch is a set of notifications built for their users' locales and preferences,
+// stored with Insert and announced with Publish once stored. Users who turned a notification's
+// event off on every channel are left out.
+type NotificationBatch struct {
+	inApp  []*Notification
+	pushes []*Notification
+}
+
+// BuildNotifications prepares notifications like NewNotification does, reading the locales and
+// preferences of all their users with one query each.
+func BuildNotifications(ctx context.Context, gormDB *gorm.DB, inputs []NotificationInput) (*NotificationBatch, error) {
+	batch := &NotificationBatch{}
+	if len(inputs) == 0 {
+		return batch, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(inputs))
+	seen := make(map[uuid.UUID]bool, len(inputs))
+	for _, in := range inputs {
+		if !seen[in.UserID] {
+			seen[in.UserID] = true
+			ids = append(ids, in.UserID)
+		}
+	}
+
+	var locales []struct {
+		ID     uuid.UUID
+		Locale string
+	}
+	if err := gormDB.WithContext(ctx).Model(&User{}).Select("id, locale").Where("id IN ?", ids).Scan(&locales).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get notification locales")
+	}
+	localeOf := make(map[uuid.UUID]string, len(locales))
+	for _, l := range locales {
+		localeOf[l.ID] = l.Locale
+	}
+
+	var prefs []NotificationPreferences
+	if err := gormDB.WithContext(ctx).Where("user_id IN ?", ids).Find(&prefs).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get notification preferences")
+	}
+	prefsOf := make(map[uuid.UUID]*NotificationPreferences, len(prefs))
+	for i := range prefs {
+		prefsOf[prefs[i].UserID] = &prefs[i]
+	}
+
+	validate := validator.New()
+	for _, in := range inputs {
+		locale := localeOf[in.UserID]
+		if locale == "" {
+			locale = i18n.Default
+		}
+		message := i18n.T(locale, in.Message, in.Args...)
+		if runes := []rune(message); len(runes) > 255 {
+			message = string(runes[:255])
+		}
+		n := &Notification{UserID: in.UserID, Type: in.Type, Message: message}
+		if err := validate.Struct(n); err != nil {
+			return nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid notification data", err.Error())
+		}
+
+		inApp, push := prefsOf[in.UserID].channels(in.Type)
+		if inApp {
+			batch.inApp = append(batch.inApp, n)
+		}
+		if push {
+			batch.pushes = append(batch.pushes, n)
+		}
+	}
+	return batch, nil
+}
+
+// Insert stores the in-app notifications of the batch with multi-row inserts and queues its push
+// messages, within tx.
+func (b *NotificationBatch) Insert(ctx context.Context, tx *gorm.DB) error {
+	if len(b.inApp) > 0 {
+		if err := tx.WithContext(ctx).CreateInBatches(b.inApp, notificationInsertBatch).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create notifications")
+		}
+	}
+	now := time.Now()
+	for _, n := range b.pushes {
+		if err := EnqueueOutbox(ctx, tx, OutboxNotificationPush, PushNotificationEvent{
+			NotificationID: n.ID,
+			UserID:         n.UserID,
+			Type:           n.Type,
+			Message:        n.Message,
+			CreatedAt:      now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Publish caches the stored in-app notifications, drops each user's cached lists and summary once,
+// and publishes the notifications to their users' live streams, in one round trip where it can.
+func (b *NotificationBatch) Publish(ctx context.Context, redisClient *storage.RedisClient) {
+	if len(b.inApp) == 0 {
+		return
+	}
+	users := map[uuid.UUID]bool{}
+	pipe := redisClient.Pipeline()
+	for _, n := range b.inApp {
+		notifJSON, _ := json.Marshal(n)
+		pipe.Set(ctx, "notification:"+n.ID.String(), notifJSON, redisClient.TTL("notification"))
+		pipe.Publish(ctx, NotificationChannel(n.UserID), notifJSON)
+		if !users[n.UserID] {
+			users[n.UserID] = true
+			pipe.Del(ctx, "notifications:summary:"+n.UserID.String())
+		}
+	}
+	pipe.Exec(ctx)
+
+	tags := make([]string, 0, len(users))
+	for id := range users {
+		tags = append(tags, cache.NotificationsTag(id))
+	}
+	cache.Invalidate(ctx, redisClient, tags...)
+}
+
+// Notifications returns the in-app notifications of the batch.
+func (b *NotificationBatch) Notifications() []Notification {
+	out := make([]Notification, len(b.inApp))
+	for i, n := range b.inApp {
+		out[i] = *n
+	}
+	return out
+}
+
+// NewNotifications creates a batch of notifications, each as NewNotification would, with one
+// transaction and one round of cache invalidation for the whole batch. It returns the in-app
+// notifications created.
+func NewNotifications(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, inputs []NotificationInput) ([]Notification, error) {
+	batch, err := BuildNotifications(ctx, gormDB, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return batch.Insert(ctx, tx)
+	}); err != nil {
+		return nil, err
+	}
+	batch.Publish(ctx, redisClient)
+	return batch.Notifications(), nil
+}
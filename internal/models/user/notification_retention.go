@@ -0,0 +1,200 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// NotificationRetention bounds the notifications kept for each user. Notifications beyond the
+// Keep latest are moved to the user's archive, and notifications and archived notifications older
+// than MaxAge are deleted. A zero Keep or MaxAge leaves that bound off.
+type NotificationRetention struct {
+	Keep   int
+	MaxAge time.Duration
+}
+
+// DefaultNotificationRetention keeps the 500 latest notifications of a user, and none older than
+// 90 days.
+var DefaultNotificationRetention = NotificationRetention{Keep: 500, MaxAge: 90 * 24 * time.Hour}
+
+// NotificationArchive is a notification moved out of a user's notifications, by the user or by
+// the retention policy, so the notifications loaded with the user stay few. It is kept until it
+// is older than the retention's MaxAge.
+type NotificationArchive struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index:idx_notification_archive_user_created,priority:1" json:"user_id"`
+	Type       string    `gorm:"size:50;not null" json:"type"`
+	Message    string    `gorm:"size:255;not null" json:"message"`
+	IsRead     bool      `gorm:"default:false" json:"is_read"`
+	CreatedAt  time.Time `gorm:"index:idx_notification_archive_user_created,priority:2;index:idx_notification_archive_created" json:"created_at"`
+	ArchivedAt time.Time `gorm:"not null" json:"archived_at"`
+}
+
+// NotificationArchiveFilter picks the notifications of a user to archive: those listed in IDs,
+// those created before Before, or, with neither, those already read. UnreadToo archives unread
+// notifications among them as well.
+type NotificationArchiveFilter struct {
+	IDs       []uuid.UUID
+	Before    *time.Time
+	UnreadToo bool
+}
+
+// archiveNotificationsSQL moves the notifications matching a condition to the archive, returning
+// the moved notifications' IDs and users.
+const archiveNotificationsSQL = `WITH moved AS (
+	DELETE FROM notifications WHERE %s
+	RETURNING id, user_id, type, message, is_read, created_at
+), archived AS (
+	INSERT INTO notification_archives (id, user_id, type, message, is_read, created_at, archived_at)
+	SELECT id, user_id, type, message, is_read, created_at, now() FROM moved
+	ON CONFLICT (id) DO NOTHING
+)
+SELECT id, user_id FROM moved`
+
+// beyondKeptSQL matches the notifications of each user beyond the latest ones kept. Only users
+// with more than that many are ranked.
+const beyondKeptSQL = `id IN (
+	SELECT id FROM (
+		SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY created_at DESC, id DESC) AS rn
+		FROM notifications
+		WHERE user_id IN (SELECT user_id FROM notifications GROUP BY user_id HAVING count(*) > ?)
+	) ranked WHERE rn > ?
+)`
+
+// movedNotification is a notification moved out of a user's notifications.
+type movedNotification struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// ArchiveNotifications moves the user's notifications matching filter to their archive and returns
+// how many moved.
+func ArchiveNotifications(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, filter NotificationArchiveFilter) (int64, error) {
+	conds := []string{"user_id = ?"}
+	args := []interface{}{userID}
+	if len(filter.IDs) > 0 {
+		conds = append(conds, "id IN ?")
+		args = append(args, filter.IDs)
+	}
+	if filter.Before != nil {
+		conds = append(conds, "created_at < ?")
+		args = append(args, *filter.Before)
+	}
+	if !filter.UnreadToo {
+		conds = append(conds, "is_read = true")
+	}
+
+	var moved []movedNotification
+	query := fmt.Sprintf(archiveNotificationsSQL, strings.Join(conds, " AND "))
+	if err := gormDB.WithContext(ctx).Raw(query, args...).Scan(&moved).Error; err != nil {
+		return 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to archive notifications")
+	}
+	forgetNotifications(ctx, redisClient, moved)
+	return int64(len(moved)), nil
+}
+
+// GetArchivedNotificationsPage retrieves a page of a user's archived notifications, newest first.
+func GetArchivedNotificationsPage(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID, p utils.Pagination) (utils.Page[NotificationArchive], error) {
+	query := gormDB.WithContext(ctx).Model(&NotificationArchive{}).Where("user_id = ?", userID)
+	var total int64
+	if p.WithTotal {
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return utils.Page[NotificationArchive]{}, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count archived notifications")
+		}
+	}
+	if p.After != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, p.After.Key)
+		if err != nil {
+			return utils.Page[NotificationArchive]{}, utils.NewError(utils.ErrBadRequest.Code, "Invalid cursor")
+		}
+		id, err := uuid.Parse(p.After.ID)
+		if err != nil {
+			return utils.Page[NotificationArchive]{}, utils.NewError(utils.ErrBadRequest.Code, "Invalid cursor")
+		}
+		query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	var notifs []NotificationArchive
+	if err := query.Order("created_at DESC, id DESC").Limit(p.Limit + 1).Find(&notifs).Error; err != nil {
+		return utils.Page[NotificationArchive]{}, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get archived notifications")
+	}
+	page := utils.NewPage(notifs, p, func(n NotificationArchive) utils.Cursor {
+		return utils.Cursor{Key: n.CreatedAt.Format(time.RFC3339Nano), ID: n.ID.String()}
+	})
+	if p.WithTotal {
+		page.Total = &total
+	}
+	return page, nil
+}
+
+// ApplyNotificationRetention enforces the retention policy for every user: it deletes the
+// notifications and archived notifications older than MaxAge, then archives each user's
+// notifications beyond the Keep latest. It returns how many notifications were archived and
+// deleted.
+func ApplyNotificationRetention(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, policy NotificationRetention) (archived, deleted int64, err error) {
+	db := gormDB.WithContext(ctx)
+	var changed []movedNotification
+	if policy.MaxAge > 0 {
+		cutoff := time.Now().Add(-policy.MaxAge)
+		res := db.Where("created_at < ?", cutoff).Delete(&NotificationArchive{})
+		if res.Error != nil {
+			return 0, 0, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to delete expired archived notifications")
+		}
+		deleted = res.RowsAffected
+
+		var expired []movedNotification
+		if err := db.Raw("WITH gone AS (DELETE FROM notifications WHERE created_at < ? RETURNING id, user_id) SELECT id, user_id FROM gone", cutoff).Scan(&expired).Error; err != nil {
+			return 0, deleted, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete expired notifications")
+		}
+		deleted += int64(len(expired))
+		changed = append(changed, expired...)
+	}
+
+	if policy.Keep > 0 {
+		var moved []movedNotification
+		query := fmt.Sprintf(archiveNotificationsSQL, beyondKeptSQL)
+		if err := db.Raw(query, policy.Keep, policy.Keep).Scan(&moved).Error; err != nil {
+			return 0, deleted, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to archive notifications")
+		}
+		archived = int64(len(moved))
+		changed = append(changed, moved...)
+	}
+
+	forgetNotifications(ctx, redisClient, changed)
+	return archived, deleted, nil
+}
+
+// forgetNotifications drops the cached copies of notifications moved or deleted, and their users'
+// cached lists, summaries and records, which carry their notifications.
+func forgetNotifications(ctx context.Context, redisClient *storage.RedisClient, notifs []movedNotification) {
+	if len(notifs) == 0 {
+		return
+	}
+	users := map[uuid.UUID]bool{}
+	pipe := redisClient.Pipeline()
+	for _, n := range notifs {
+		pipe.Del(ctx, "notification:"+n.ID.String())
+		if !users[n.UserID] {
+			users[n.UserID] = true
+			pipe.Del(ctx, "notifications:summary:"+n.UserID.String())
+		}
+	}
+	pipe.Exec(ctx)
+
+	tags := make([]string, 0, len(users))
+	keys := make([]string, 0, len(users))
+	for id := range users {
+		tags = append(tags, cache.NotificationsTag(id))
+		keys = append(keys, cache.UserKey(id))
+	}
+	cache.Delete(ctx, redisClient, keys...)
+	cache.Invalidate(ctx, redisClient, tags...)
+}
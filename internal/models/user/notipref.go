@@ -10,20 +10,171 @@ import (
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// NotificationPreferences holds, for every channel a notification can reach a user through, which
+// events the user wants on it; Matrix presents the flags as a channel by event matrix. The email
+// flags keep the names they had when email was the only channel. In-app notifications are on by
+// default, email and push ones are opted into.
 type NotificationPreferences struct {
-	ID               uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	UserID           uuid.UUID `gorm:"type:uuid;not null;unique" json:"user_id"`
-	EmailOnLikes     bool      `gorm:"default:false" json:"email_on_likes"`
-	EmailOnComments  bool      `gorm:"default:false" json:"email_on_comments"`
-	EmailOnMentions  bool      `gorm:"default:false" json:"email_on_mentions"`
-	EmailOnFollowers bool      `gorm:"default:false" json:"email_on_followers"`
-	EmailOnBadge     bool      `gorm:"default:false" json:"email_on_badge"`
-	EmailOnUnread    bool      `gorm:"default:false" json:"email_on_unread"`
-	EmailOnNewPosts  bool      `gorm:"default:false" json:"email_on_new_posts"`
-	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID           uuid.UUID  `gorm:"type:uuid;not null;unique" json:"user_id"`
+	EmailOnLikes     bool       `gorm:"default:false" json:"email_on_likes"`
+	EmailOnComments  bool       `gorm:"default:false" json:"email_on_comments"`
+	EmailOnMentions  bool       `gorm:"default:false" json:"email_on_mentions"`
+	EmailOnFollowers bool       `gorm:"default:false" json:"email_on_followers"`
+	EmailOnBadge     bool       `gorm:"default:false" json:"email_on_badge"`
+	EmailOnUnread    bool       `gorm:"default:false" json:"email_on_unread"`
+	EmailOnNewPosts  bool       `gorm:"default:false" json:"email_on_new_posts"`
+	PushOnReactions  bool       `gorm:"default:false" json:"push_on_reactions"`
+	PushOnComments   bool       `gorm:"default:false" json:"push_on_comments"`
+	PushOnMentions   bool       `gorm:"default:false" json:"push_on_mentions"`
+	PushOnFollowers  bool       `gorm:"default:false" json:"push_on_followers"`
+	PushOnBadges     bool       `gorm:"default:false" json:"push_on_badges"`
+	PushOnNewPosts   bool       `gorm:"default:false" json:"push_on_new_posts"`
+	InAppOnReactions bool       `gorm:"default:true" json:"in_app_on_reactions"`
+	InAppOnComments  bool       `gorm:"default:true" json:"in_app_on_comments"`
+	InAppOnMentions  bool       `gorm:"default:true" json:"in_app_on_mentions"`
+	InAppOnFollowers bool       `gorm:"default:true" json:"in_app_on_followers"`
+	InAppOnBadges    bool       `gorm:"default:true" json:"in_app_on_badges"`
+	InAppOnNewPosts  bool       `gorm:"default:true" json:"in_app_on_new_posts"`
+	DigestFrequency  string     `gorm:"size:10;not null;default:'daily'" json:"digest_frequency"`
+	LastDigestAt     *time.Time `json:"last_digest_at"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Notification channels.
+const (
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+	ChannelInApp = "in_app"
+)
+
+// Notification events users choose channels for. EventUnread is the unread summary of the digest
+// email and exists on the email channel only.
+const (
+	EventReactions = "reactions"
+	EventComments  = "comments"
+	EventMentions  = "mentions"
+	EventFollowers = "followers"
+	EventBadges    = "badges"
+	EventNewPosts  = "new_posts"
+	EventUnread    = "unread"
+)
+
+// NotificationChannels and NotificationEvents list the rows and columns of the matrix.
+var (
+	NotificationChannels = []string{ChannelEmail, ChannelPush, ChannelInApp}
+	NotificationEvents   = []string{EventReactions, EventComments, EventMentions, EventFollowers, EventBadges, EventNewPosts, EventUnread}
+)
+
+// notificationEvents maps notification types to the event whose preferences decide where they go.
+// Notifications of other types, such as invitations, always reach the user in-app only.
+var notificationEvents = map[string]string{
+	NotificationReaction: EventReactions,
+	NotificationComment:  EventComments,
+	NotificationMention:  EventMentions,
+	NotificationNewPost:  EventNewPosts,
+	NotificationBadge:    EventBadges,
+}
+
+// NotificationMatrix holds notification preferences by channel, then event.
+type NotificationMatrix map[string]map[string]bool
+
+// Set turns an event on or off for a channel.
+func (m NotificationMatrix) Set(channel, event string, enabled bool) {
+	if m[channel] == nil {
+		m[channel] = map[string]bool{}
+	}
+	m[channel][event] = enabled
+}
+
+// cells points at the flag of every channel and event pair the matrix has.
+func (np *NotificationPreferences) cells() map[string]map[string]*bool {
+	return map[string]map[string]*bool{
+		ChannelEmail: {
+			EventReactions: &np.EmailOnLikes,
+			EventComments:  &np.EmailOnComments,
+			EventMentions:  &np.EmailOnMentions,
+			EventFollowers: &np.EmailOnFollowers,
+			EventBadges:    &np.EmailOnBadge,
+			EventNewPosts:  &np.EmailOnNewPosts,
+			EventUnread:    &np.EmailOnUnread,
+		},
+		ChannelPush: {
+			EventReactions: &np.PushOnReactions,
+			EventComments:  &np.PushOnComments,
+			EventMentions:  &np.PushOnMentions,
+			EventFollowers: &np.PushOnFollowers,
+			EventBadges:    &np.PushOnBadges,
+			EventNewPosts:  &np.PushOnNewPosts,
+		},
+		ChannelInApp: {
+			EventReactions: &np.InAppOnReactions,
+			EventComments:  &np.InAppOnComments,
+			EventMentions:  &np.InAppOnMentions,
+			EventFollowers: &np.InAppOnFollowers,
+			EventBadges:    &np.InAppOnBadges,
+			EventNewPosts:  &np.InAppOnNewPosts,
+		},
+	}
+}
+
+// Matrix returns the preferences as a channel by event matrix.
+func (np *NotificationPreferences) Matrix() NotificationMatrix {
+	m := NotificationMatrix{}
+	for channel, events := range np.cells() {
+		for event, enabled := range events {
+			m.Set(channel, event, *enabled)
+		}
+	}
+	return m
+}
+
+// Enabled reports whether the user wants event on channel. Pairs the matrix lacks are off.
+func (np *NotificationPreferences) Enabled(channel, event string) bool {
+	if enabled, ok := np.cells()[channel][event]; ok {
+		return *enabled
+	}
+	return false
+}
+
+// Apply sets the flags changes names, leaving the others alone. Nothing is set when changes names
+// a channel or event pair the matrix lacks.
+func (np *NotificationPreferences) Apply(changes NotificationMatrix) error {
+	cells := np.cells()
+	for channel, events := range changes {
+		if _, ok := cells[channel]; !ok {
+			return utils.NewError(utils.ErrBadRequest.Code, "Unknown notification channel: "+channel)
+		}
+		for event := range events {
+			if _, ok := cells[channel][event]; !ok {
+				return utils.NewError(utils.ErrBadRequest.Code, "Unknown notification event for "+channel+": "+event)
+			}
+		}
+	}
+	for channel, events := range changes {
+		for event, enabled := range events {
+			*cells[channel][event] = enabled
+		}
+	}
+	return nil
+}
+
+// Digest frequencies.
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestInterval is how long a digest of the given frequency covers.
+func DigestInterval(frequency string) time.Duration {
+	if frequency == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
 }
 
 // NewNotificationPreferences creates preferences for a user.
@@ -40,7 +191,7 @@ func NewNotificationPreferences(ctx context.Context, redisClient *storage.RedisC
 
 	npJSON, _ := json.Marshal(np)
 	key := "notif_prefs:" + np.ID.String()
-	redisClient.Set(ctx, key, npJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, npJSON, redisClient.TTL("notif_prefs"))
 	return np, nil
 }
 
@@ -63,7 +214,7 @@ func GetNotificationPreferences(ctx context.Context, redisClient *storage.RedisC
 	}
 
 	npJSON, _ := json.Marshal(np)
-	redisClient.Set(ctx, key, npJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, npJSON, redisClient.TTL("notif_prefs"))
 	return &np, nil
 }
 
@@ -86,30 +237,62 @@ func GetNotificationPreferencesByUser(ctx context.Context, redisClient *storage.
 	}
 
 	npJSON, _ := json.Marshal(np)
-	redisClient.Set(ctx, key, npJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, npJSON, redisClient.TTL("notif_prefs"))
 	return &np, nil
 }
 
-// UpdateNotificationPreferences updates preferences.
-func UpdateNotificationPreferences(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, id uuid.UUID, likes, comments, mentions, followers, badge, unread, newPosts bool) (*NotificationPreferences, error) {
-	np, err := GetNotificationPreferences(ctx, redisClient, gormDB, id)
+// UpdateNotificationPreferences changes the cells of a user's matrix that changes names, leaving
+// the others alone. An empty digest frequency keeps the current one.
+func UpdateNotificationPreferences(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, changes NotificationMatrix, digest string) (*NotificationPreferences, error) {
+	var np NotificationPreferences
+	err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&np).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return utils.NewError(utils.ErrNotFound.Code, "Notification preferences not found for user")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get notification preferences")
+		}
+		if err := np.Apply(changes); err != nil {
+			return err
+		}
+		if digest != "" {
+			np.DigestFrequency = digest
+		}
+		if err := tx.Save(&np).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update notification preferences")
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	np.EmailOnLikes = likes
-	np.EmailOnComments = comments
-	np.EmailOnMentions = mentions
-	np.EmailOnFollowers = followers
-	np.EmailOnBadge = badge
-	np.EmailOnUnread = unread
-	np.EmailOnNewPosts = newPosts
+	redisClient.Del(ctx, "notif_prefs:"+np.ID.String(), "notif_prefs:"+userID.String(), "notif_prefs:user:"+userID.String())
+	return &np, nil
+}
 
-	if err := gormDB.WithContext(ctx).Save(np).Error; err != nil {
-		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update notification preferences")
+// notificationChannels reports whether a notification of notifType reaches userID in-app and as a
+// push message. Preferences are read from the database, as a cached copy may predate a change.
+// Users without preferences get the defaults.
+func notificationChannels(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID, notifType string) (inApp, push bool) {
+	if _, ok := notificationEvents[notifType]; !ok {
+		return true, false
 	}
+	var np NotificationPreferences
+	if err := gormDB.WithContext(ctx).Where("user_id = ?", userID).First(&np).Error; err != nil {
+		return true, false
+	}
+	return np.channels(notifType)
+}
 
-	return np, nil
+// channels reports whether notifications of notifType reach the user of np in-app and by push.
+// A user without preferences, np nil, gets them in-app only.
+func (np *NotificationPreferences) channels(notifType string) (inApp, push bool) {
+	event, ok := notificationEvents[notifType]
+	if !ok || np == nil {
+		return true, false
+	}
+	return np.Enabled(ChannelInApp, event), np.Enabled(ChannelPush, event)
 }
 
 // DeleteNotificationPreferences deletes preferences.
@@ -19,6 +19,11 @@ func WithPassword(password string) UserOption {
 	return func(u *User) { u.Password = password }
 }
 
+// WithPasswordless sets whether the user lacks a password they can sign in with.
+func WithPasswordless(passwordless bool) UserOption {
+	return func(u *User) { u.Passwordless = passwordless }
+}
+
 func WithPreviousPasswords(passwords string) UserOption {
 	return func(u *User) { u.PreviousPasswords = passwords }
 }
@@ -141,6 +146,10 @@ func WithContentMode(mode int) UserOption {
 	return func(u *User) { u.Settings.ContentMode = mode }
 }
 
+func WithLocale(locale string) UserOption {
+	return func(u *User) { u.Settings.Locale = locale }
+}
+
 // Stats
 
 func WithPostsCount(delta int) UserOption {
@@ -247,3 +256,19 @@ func WithEmailOnUnread(ok bool) UserOption {
 func WithEmailOnNewPosts(ok bool) UserOption {
 	return func(u *User) { u.NotificationPreferences.EmailOnNewPosts = ok }
 }
+
+func WithWebhookURL(url string) WebhookOption {
+	return func(w *Webhook) { w.URL = url }
+}
+
+func WithWebhookEvents(events json.RawMessage) WebhookOption {
+	return func(w *Webhook) { w.Events = events }
+}
+
+func WithWebhookSecret(encrypted string) WebhookOption {
+	return func(w *Webhook) { w.Secret = encrypted }
+}
+
+func WithWebhookActive(active bool) WebhookOption {
+	return func(w *Webhook) { w.Active = active }
+}
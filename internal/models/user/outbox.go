@@ -0,0 +1,229 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Outbox event kinds.
+const (
+	// OutboxUserRegistered stores the activation code of a new account and mails it.
+	OutboxUserRegistered = "user.registered"
+	// OutboxUserUpdated refreshes a user's entries in the search and mention indexes.
+	OutboxUserUpdated = "user.updated"
+	// OutboxPostPublished, OutboxPostUpdated and OutboxPostDeleted refresh a post's entry in the
+	// search index, dropping it once the post is no longer public.
+	OutboxPostPublished = "post.published"
+	OutboxPostUpdated   = "post.updated"
+	OutboxPostDeleted   = "post.deleted"
+	// OutboxPostFanout notifies a batch of the author's followers of a newly published post, and
+	// queues itself again for the next batch.
+	OutboxPostFanout = "post.fanout"
+	// OutboxNotificationPush delivers a notification to the user's subscribed browsers.
+	OutboxNotificationPush = "notification.push"
+	// OutboxLoginAnomaly emails a user about a sign-in from a new location or device, or about
+	// repeated failed sign-ins.
+	OutboxLoginAnomaly = "login.anomaly"
+	// OutboxStreakMilestone awards a user the badge of the streak milestone they reached.
+	OutboxStreakMilestone = "streak.milestone"
+	// OutboxUploadScan runs the content scanner over a quarantined upload.
+	OutboxUploadScan = "upload.scan"
+)
+
+// Outbox event states.
+const (
+	OutboxPending   = "pending"
+	OutboxProcessed = "processed"
+	OutboxFailed    = "failed"
+)
+
+const (
+	// OutboxMaxAttempts is how often an event is tried before it is given up.
+	OutboxMaxAttempts = 8
+	// outboxBaseBackoff is the wait after the first failed attempt; it doubles with every attempt
+	// up to outboxMaxBackoff.
+	outboxBaseBackoff = 15 * time.Second
+	outboxMaxBackoff  = time.Hour
+	// outboxLease is how long a claimed event is hidden from other workers while it is handled.
+	outboxLease = 2 * time.Minute
+	// OutboxRetention is how long processed events are kept.
+	OutboxRetention = 7 * 24 * time.Hour
+)
+
+// OutboxEvent is a side effect, such as an email, recorded in the transaction of the change that
+// causes it and carried out by the outbox worker once that transaction committed. A rolled back
+// change therefore never has side effects, and a committed one always gets them even when the
+// process dies right after the commit.
+type OutboxEvent struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Kind          string          `gorm:"size:50;not null" json:"kind"`
+	Payload       json.RawMessage `gorm:"type:jsonb" json:"-"`
+	Status        string          `gorm:"size:20;not null;default:'pending';index:idx_outbox_due" json:"status"`
+	Attempts      int             `gorm:"default:0" json:"attempts"`
+	NextAttemptAt time.Time       `gorm:"not null;index:idx_outbox_due" json:"next_attempt_at"`
+	Error         string          `gorm:"size:255" json:"error,omitempty"`
+	ProcessedAt   *time.Time      `json:"processed_at,omitempty"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// UserRegisteredEvent is the payload of OutboxUserRegistered.
+type UserRegisteredEvent struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	Username string    `json:"username"`
+	Token    string    `json:"token"`
+	OTP      string    `json:"otp"`
+}
+
+// UserChangedEvent is the payload of OutboxUserUpdated.
+type UserChangedEvent struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// PostChangedEvent is the payload of OutboxPostPublished, OutboxPostUpdated and OutboxPostDeleted.
+type PostChangedEvent struct {
+	PostID uuid.UUID `json:"post_id"`
+}
+
+// PostFanoutEvent is the payload of OutboxPostFanout. After is the last follower notified by the
+// batch before, nil for the first batch.
+type PostFanoutEvent struct {
+	PostID uuid.UUID `json:"post_id"`
+	After  uuid.UUID `json:"after"`
+}
+
+// OutboxHandler carries out one event. Handlers must be idempotent: an event whose worker died
+// after handling it but before recording so is handled again once its lease runs out.
+type OutboxHandler func(ctx context.Context, event *OutboxEvent) error
+
+// EnqueueOutbox records an event in tx, to be handled once tx commits.
+func EnqueueOutbox(ctx context.Context, tx *gorm.DB, kind string, payload interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to encode outbox event")
+	}
+	event := OutboxEvent{
+		Kind:          kind,
+		Payload:       raw,
+		Status:        OutboxPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := tx.WithContext(ctx).Create(&event).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to queue outbox event")
+	}
+	return nil
+}
+
+// ProcessOutbox handles up to limit due events and returns how many succeeded. Events are claimed
+// with a lease first, so several workers never handle the same one at once.
+func ProcessOutbox(ctx context.Context, gormDB *gorm.DB, limit int, handle OutboxHandler) (int, error) {
+	var due []OutboxEvent
+	err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", OutboxPending, now).
+			Order("next_attempt_at").Limit(limit).Find(&due).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch due outbox events")
+		}
+		if len(due) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(due))
+		for i, e := range due {
+			ids[i] = e.ID
+		}
+		if err := tx.Model(&OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(outboxLease)).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to claim outbox events")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	succeeded := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		e := &due[i]
+		handleErr := handle(ctx, e)
+		if err := recordOutboxAttempt(ctx, gormDB, e, handleErr); err != nil {
+			return succeeded, err
+		}
+		if handleErr == nil {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// recordOutboxAttempt stores the outcome of an attempt and schedules the next one with an
+// exponential backoff. The payload of a handled event is dropped since it may hold secrets such
+// as activation codes.
+func recordOutboxAttempt(ctx context.Context, gormDB *gorm.DB, e *OutboxEvent, handleErr error) error {
+	now := time.Now()
+	updates := map[string]interface{}{"attempts": e.Attempts + 1, "error": ""}
+	switch {
+	case handleErr == nil:
+		updates["status"] = OutboxProcessed
+		updates["processed_at"] = now
+		updates["payload"] = nil
+	case e.Attempts+1 >= OutboxMaxAttempts:
+		updates["status"] = OutboxFailed
+		updates["error"] = truncate(handleErr.Error(), 255)
+	default:
+		backoff := outboxBaseBackoff << e.Attempts
+		if backoff > outboxMaxBackoff || backoff <= 0 {
+			backoff = outboxMaxBackoff
+		}
+		updates["next_attempt_at"] = now.Add(backoff)
+		updates["error"] = truncate(handleErr.Error(), 255)
+	}
+	if err := gormDB.WithContext(ctx).Model(&OutboxEvent{}).Where("id = ?", e.ID).Updates(updates).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update outbox event")
+	}
+	return nil
+}
+
+// RequeueOutbox gives events that were given up a fresh set of attempts, due right away, and
+// returns how many it requeued. A non-empty kind or id narrows which events are requeued.
+// Processed events cannot be replayed since their payload is gone.
+func RequeueOutbox(ctx context.Context, gormDB *gorm.DB, kind string, id uuid.UUID) (int64, error) {
+	query := gormDB.WithContext(ctx).Model(&OutboxEvent{}).Where("status = ?", OutboxFailed)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if id != uuid.Nil {
+		query = query.Where("id = ?", id)
+	}
+	res := query.Updates(map[string]interface{}{
+		"status":          OutboxPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"error":           "",
+	})
+	if res.Error != nil {
+		return 0, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to requeue outbox events")
+	}
+	return res.RowsAffected, nil
+}
+
+// PurgeOutbox deletes events processed more than OutboxRetention ago, and events given up that
+// were created that long ago.
+func PurgeOutbox(ctx context.Context, gormDB *gorm.DB) (int64, error) {
+	cutoff := time.Now().Add(-OutboxRetention)
+	res := gormDB.WithContext(ctx).
+		Where("(status = ? AND processed_at < ?) OR (status = ? AND created_at < ?)", OutboxProcessed, cutoff, OutboxFailed, cutoff).
+		Delete(&OutboxEvent{})
+	if res.Error != nil {
+		return 0, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to purge outbox events")
+	}
+	return res.RowsAffected, nil
+}
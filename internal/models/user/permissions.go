@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
@@ -33,11 +34,14 @@ func NewPermission(ctx context.Context, redisClient *storage.RedisClient, gormDB
 
 	permJSON, _ := json.Marshal(p)
 	key := "permission:" + p.ID.String()
-	redisClient.Set(ctx, key, permJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, permJSON, redisClient.TTL("permission"))
+	redisClient.Del(ctx, "permissions:all")
+	cache.Invalidate(ctx, redisClient, cache.PermissionsTag)
+	cache.ForgetMissing(ctx, redisClient, cache.MissingPermissions)
 	return p, nil
 }
 
-// GetPermission retrieves a permission by ID.
+// GetPermission retrieves a permission by ID. Lookups that find nothing are cached briefly.
 func GetPermission(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, id uuid.UUID) (*Permission, error) {
 	key := "permission:" + id.String()
 	if cached, err := redisClient.Get(ctx, key).Result(); err == nil {
@@ -46,17 +50,23 @@ func GetPermission(ctx context.Context, redisClient *storage.RedisClient, gormDB
 			return &p, nil
 		}
 	}
+	lookup := cache.LookupKey("id = ?", id)
+	missing, epoch := cache.Missing(ctx, redisClient, cache.MissingPermissions, lookup)
+	if missing {
+		return nil, utils.NewError(utils.ErrNotFound.Code, "Permission not found")
+	}
 
 	var p Permission
 	if err := gormDB.WithContext(ctx).Where("id = ?", id).First(&p).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
+			cache.MarkMissing(ctx, redisClient, cache.MissingPermissions, lookup, epoch, redisClient.TTL("missing"))
 			return nil, utils.NewError(utils.ErrNotFound.Code, "Permission not found")
 		}
 		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get permission")
 	}
 
 	permJSON, _ := json.Marshal(p)
-	redisClient.Set(ctx, key, permJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, permJSON, redisClient.TTL("permission"))
 	return &p, nil
 }
 
@@ -76,10 +86,23 @@ func GetPermissions(ctx context.Context, redisClient *storage.RedisClient, gormD
 	}
 
 	permsJSON, _ := json.Marshal(perms)
-	redisClient.Set(ctx, key, permsJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, permsJSON, redisClient.TTL("permission"))
 	return perms, nil
 }
 
+// ListPermissions retrieves a page of permissions ordered by name.
+func ListPermissions(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, p utils.Pagination) (utils.Page[Permission], error) {
+	return cache.Fetch(ctx, redisClient, cache.PermissionsPageKey(p.CacheKey()), redisClient.TTL("permission"), func(ctx context.Context) (utils.Page[Permission], []string, error) {
+		page, err := listByName(ctx, gormDB, p, func(perm Permission) utils.Cursor {
+			return utils.Cursor{Key: perm.Name, ID: perm.ID.String()}
+		})
+		if err != nil {
+			return page, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get permissions")
+		}
+		return page, []string{cache.PermissionsTag}, nil
+	})
+}
+
 // UpdatePermission updates a permission’s name.
 func UpdatePermission(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, id uuid.UUID, name string) (*Permission, error) {
 	p, err := GetPermission(ctx, redisClient, gormDB, id)
@@ -99,7 +122,9 @@ func UpdatePermission(ctx context.Context, redisClient *storage.RedisClient, gor
 
 	permJSON, _ := json.Marshal(p)
 	key := "permission:" + p.ID.String()
-	redisClient.Set(ctx, key, permJSON, 10*time.Minute)
+	redisClient.Set(ctx, key, permJSON, redisClient.TTL("permission"))
+	redisClient.Del(ctx, "permissions:all")
+	cache.Invalidate(ctx, redisClient, cache.PermissionsTag)
 	return p, nil
 }
 
@@ -115,6 +140,7 @@ func DeletePermission(ctx context.Context, redisClient *storage.RedisClient, gor
 	}
 
 	key := "permission:" + id.String()
-	redisClient.Del(ctx, key)
+	redisClient.Del(ctx, key, "permissions:all")
+	cache.Invalidate(ctx, redisClient, cache.PermissionsTag)
 	return nil
 }
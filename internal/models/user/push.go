@@ -0,0 +1,122 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Notification types whose delivery follows the user's notification preferences.
+const (
+	NotificationReaction = "reaction"
+	NotificationComment  = "comment"
+	NotificationMention  = "mention"
+	NotificationNewPost  = "new_post"
+	NotificationCoAuthor = "co_author"
+	NotificationBadge    = "badge"
+)
+
+// MaxPushSubscriptionsPerUser caps how many browsers and devices one user can subscribe.
+const MaxPushSubscriptionsPerUser = 10
+
+// PushSubscription is a browser or device that receives a user's notifications as Web Push
+// messages. The keys encrypt messages for that browser only.
+type PushSubscription struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Endpoint   string     `gorm:"size:500;not null;uniqueIndex" json:"endpoint"`
+	P256dh     string     `gorm:"size:100;not null" json:"-"`
+	Auth       string     `gorm:"size:50;not null" json:"-"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PushNotificationEvent is the payload of OutboxNotificationPush. It carries the notification
+// itself, as users may take notifications by push only; NotificationID is then nil.
+type PushNotificationEvent struct {
+	NotificationID uuid.UUID `json:"notification_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	Type           string    `json:"type"`
+	Message        string    `json:"message"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// SavePushSubscription stores a subscription for a user. A browser subscribes with the same
+// endpoint again when its keys change, and a shared browser may move to another user, so an
+// existing endpoint is taken over rather than duplicated.
+func SavePushSubscription(ctx context.Context, gormDB *gorm.DB, sub *PushSubscription) error {
+	if sub.UserID == uuid.Nil || sub.Endpoint == "" || sub.P256dh == "" || sub.Auth == "" {
+		return utils.NewError(utils.ErrBadRequest.Code, "Required fields missing: endpoint, keys")
+	}
+	return gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the owner serializes concurrent subscribing so the cap holds
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", sub.UserID).First(&User{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NewError(utils.ErrNotFound.Code, "User not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch user")
+		}
+		var count int64
+		if err := tx.Model(&PushSubscription{}).Where("user_id = ? AND endpoint <> ?", sub.UserID, sub.Endpoint).Count(&count).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count push subscriptions")
+		}
+		if count >= MaxPushSubscriptionsPerUser {
+			return utils.NewError(utils.ErrConflict.Code, "Too many push subscriptions, remove one first")
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "endpoint"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_id", "p256dh", "auth", "user_agent"}),
+		}).Create(sub).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to save push subscription")
+		}
+		// On conflict the returned ID is that of the new row, which was never inserted
+		if err := tx.Where("endpoint = ?", sub.Endpoint).First(sub).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch push subscription")
+		}
+		return nil
+	})
+}
+
+// GetUserPushSubscriptions returns a user's push subscriptions, newest first.
+func GetUserPushSubscriptions(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID) ([]PushSubscription, error) {
+	subs := []PushSubscription{}
+	if err := gormDB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&subs).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get push subscriptions")
+	}
+	return subs, nil
+}
+
+// DeletePushSubscription removes one of a user's push subscriptions.
+func DeletePushSubscription(ctx context.Context, gormDB *gorm.DB, userID, id uuid.UUID) error {
+	res := gormDB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&PushSubscription{})
+	if res.Error != nil {
+		return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to delete push subscription")
+	}
+	if res.RowsAffected == 0 {
+		return utils.NewError(utils.ErrNotFound.Code, "Push subscription not found")
+	}
+	return nil
+}
+
+// ExpirePushSubscription removes a subscription its push service no longer knows.
+func ExpirePushSubscription(ctx context.Context, gormDB *gorm.DB, id uuid.UUID) error {
+	if err := gormDB.WithContext(ctx).Where("id = ?", id).Delete(&PushSubscription{}).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete push subscription")
+	}
+	return nil
+}
+
+// TouchPushSubscription records that a message was handed to a subscription's push service.
+func TouchPushSubscription(ctx context.Context, gormDB *gorm.DB, id uuid.UUID) error {
+	if err := gormDB.WithContext(ctx).Model(&PushSubscription{}).Where("id = ?", id).Update("last_used_at", time.Now()).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update push subscription")
+	}
+	return nil
+}
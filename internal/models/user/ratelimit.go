@@ -0,0 +1,105 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RateLimitOverride replaces the limit of a rate limit policy for the users of a role or, without
+// a role, for everyone. A zero WindowSeconds keeps the policy's window and a zero Limit lifts the
+// policy altogether.
+type RateLimitOverride struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Policy        string     `gorm:"size:50;not null" json:"policy"`
+	RoleID        *uuid.UUID `gorm:"type:uuid" json:"role_id"`
+	Limit         int        `gorm:"not null" json:"limit"`
+	WindowSeconds int        `gorm:"not null;default:0" json:"window_seconds"`
+	UpdatedByID   uuid.UUID  `gorm:"type:uuid;not null" json:"updated_by_id"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Role *Role `gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// GetRateLimitOverrides returns every rate limit override. Each instance of the API reads them
+// periodically, so they are cached whole until one changes.
+func GetRateLimitOverrides(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB) ([]RateLimitOverride, error) {
+	return cache.Fetch(ctx, rclient, cache.RateLimitOverridesKey, rclient.TTL("rate_limits"), func(ctx context.Context) ([]RateLimitOverride, []string, error) {
+		overrides := []RateLimitOverride{}
+		if err := db.WithContext(ctx).Order("policy, role_id NULLS FIRST").Find(&overrides).Error; err != nil {
+			return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get rate limit overrides")
+		}
+		return overrides, nil, nil
+	})
+}
+
+// SetRateLimitOverride creates or replaces the override of a policy for a role, or for everyone
+// when roleID is nil. It returns the override it replaced, if any, and the one now in effect.
+func SetRateLimitOverride(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, actorID uuid.UUID, policy string, roleID *uuid.UUID, limit, windowSeconds int) (*RateLimitOverride, *RateLimitOverride, error) {
+	if limit < 0 || windowSeconds < 0 {
+		return nil, nil, utils.NewError(utils.ErrBadRequest.Code, "Limit and window cannot be negative")
+	}
+
+	var before *RateLimitOverride
+	override := &RateLimitOverride{Policy: policy, RoleID: roleID}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if roleID != nil {
+			var count int64
+			if err := tx.Model(&Role{}).Where("id = ?", *roleID).Count(&count).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check role")
+			}
+			if count == 0 {
+				return utils.NewError(utils.ErrNotFound.Code, "Role not found")
+			}
+		}
+
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("policy = ?", policy)
+		if roleID != nil {
+			query = query.Where("role_id = ?", *roleID)
+		} else {
+			query = query.Where("role_id IS NULL")
+		}
+		var existing RateLimitOverride
+		err := query.First(&existing).Error
+		switch {
+		case err == nil:
+			previous := existing
+			before, override = &previous, &existing
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get rate limit override")
+		}
+
+		override.Limit, override.WindowSeconds, override.UpdatedByID = limit, windowSeconds, actorID
+		if err := tx.Save(override).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to save rate limit override")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	cache.Delete(ctx, rclient, cache.RateLimitOverridesKey)
+	return before, override, nil
+}
+
+// DeleteRateLimitOverride removes an override, putting the policy's own limit back, and returns it.
+func DeleteRateLimitOverride(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID) (*RateLimitOverride, error) {
+	var override RateLimitOverride
+	res := db.WithContext(ctx).Clauses(clause.Returning{}).Where("id = ?", id).Delete(&override)
+	if res.Error != nil {
+		return nil, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to delete rate limit override")
+	}
+	if res.RowsAffected == 0 {
+		return nil, utils.NewError(utils.ErrNotFound.Code, "Rate limit override not found")
+	}
+	cache.Delete(ctx, rclient, cache.RateLimitOverridesKey)
+	return &override, nil
+}
@@ -3,11 +3,15 @@ package models
 import (
 	"context"
 	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
-	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/cache"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
@@ -17,89 +21,13 @@ type Role struct {
 	ID          uuid.UUID    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
 	Name        string       `gorm:"size:50;not null;unique" json:"name" validate:"required"`
 	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions"`
+	Version     int          `gorm:"not null;default:1" json:"version"`
 	CreatedAt   time.Time    `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
-var allPermissions []string
-var permissions = []Permission{
-	// Post-related permissions
-	{Name: "create_post"}, {Name: "delete_any_post"}, {Name: "delete_own_post"}, {Name: "edit_any_post"},
-	{Name: "edit_own_post"}, {Name: "feature_posts"}, {Name: "moderate_post"},
-	{Name: "read_post"},
-
-	// Comment-related permissions
-	{Name: "create_comment"}, {Name: "delete_any_comment"}, {Name: "delete_own_comment"},
-	{Name: "edit_any_comment"}, {Name: "edit_own_comment"},
-	{Name: "moderate_comment"},
-
-	// User-related permissions
-	{Name: "ban_user"}, {Name: "create_user"}, {Name: "delete_any_user"}, {Name: "delete_own_profile"},
-	{Name: "edit_any_user"}, {Name: "edit_own_profile"}, {Name: "follow_user"},
-	{Name: "moderate_user"}, {Name: "read_user_profile"},
-	{Name: "unfollow_user"},
-
-	// Role and permission management
-	{Name: "assign_roles"}, {Name: "create_roles"}, {Name: "delete_roles"},
-	{Name: "edit_roles"}, {Name: "manage_roles"},
-
-	// Reaction-related permissions
-	{Name: "create_reaction"}, {Name: "delete_any_reaction"}, {Name: "delete_own_reaction"},
-	{Name: "edit_any_reaction"}, {Name: "edit_own_reaction"},
-
-	// Tag-related permissions
-	{Name: "create_tag"}, {Name: "delete_tag"}, {Name: "edit_tag"},
-	{Name: "follow_tag"}, {Name: "moderate_tag"},
-	{Name: "unfollow_tag"},
-
-	// Site-wide and moderation permissions
-	{Name: "give_suggestion"}, {Name: "manage_analytics"}, {Name: "manage_notifications"},
-	{Name: "manage_site_settings"}, {Name: "need_moderation"},
-	{Name: "report_content"},
-}
-
-var roles = []struct {
-	Name        string
-	Permissions []string
-}{
-	{"member", []string{
-		"read_post", "create_comment", "edit_own_comment", "delete_own_comment",
-		"give_reaction", "follow_tag", "unfollow_tag", "follow_user", "unfollow_user",
-		"delete_own_profile", "edit_own_profile", "need_moderation", "report_content",
-	}},
-	{"author", []string{
-		"read_post", "create_post", "edit_own_post", "delete_own_post",
-		"create_comment", "edit_own_comment", "delete_own_comment",
-		"give_reaction", "follow_tag", "unfollow_tag", "follow_user", "unfollow_user",
-		"delete_own_profile", "edit_own_profile", "report_content",
-	}},
-	{"trusted_member", []string{
-		"read_post", "create_post", "edit_own_post", "delete_own_post",
-		"create_comment", "edit_own_comment", "delete_own_comment",
-		"give_reaction", "follow_tag", "unfollow_tag", "follow_user", "unfollow_user",
-		"delete_own_profile", "edit_own_profile", "give_suggestion", "report_content",
-	}},
-	{"tag_moderator", []string{
-		"read_post", "create_post", "edit_own_post", "delete_own_post",
-		"create_comment", "edit_own_comment", "delete_own_comment",
-		"give_reaction", "follow_tag", "unfollow_tag", "follow_user", "unfollow_user",
-		"delete_own_profile", "edit_own_profile", "give_suggestion",
-		"moderate_tag", "feature_posts", "ban_user", "report_content",
-	}},
-	{"moderator", []string{
-		"read_post", "create_post", "edit_own_post", "delete_own_post", "edit_any_post",
-		"delete_any_post", "moderate_post", "create_comment", "edit_own_comment",
-		"delete_own_comment", "edit_any_comment", "moderate_comment", "create_user",
-		"edit_user", "delete_user", "moderate_user", "ban_user", "follow_user",
-		"unfollow_user", "create_roles", "edit_roles", "delete_roles", "create_reaction",
-		"edit_reaction", "delete_reaction", "give_reaction", "create_tag", "edit_tag",
-		"delete_tag", "moderate_tag", "follow_tag", "unfollow_tag", "give_suggestion",
-		"feature_posts", "report_content",
-	}},
-	{"admin", allPermissions},
-}
-
-// NewRole creates a new role.
+// NewRole creates a role granted the given permissions, which may be patterns such as "edit_*".
+// It fails with a conflict when the name is taken.
 func NewRole(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, name string, permissions ...string) (*Role, error) {
 	r := &Role{Name: name}
 	validate := validator.New()
@@ -107,37 +35,77 @@ func NewRole(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, nam
 		return nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid role data", err.Error())
 	}
 
-	if err := db.WithContext(ctx).Create(r).Error; err != nil {
-		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create role")
-	}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&Role{}).Where("name = ?", name).Count(&taken).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get role")
+		}
+		if taken > 0 {
+			return utils.NewError(utils.ErrConflict.Code, "Role name already exists")
+		}
+		if err := tx.Create(r).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create role")
+		}
 
-	for _, permName := range permissions {
-		var perm Permission
-		if err := db.WithContext(ctx).Where("name = ?", permName).FirstOrCreate(&perm).Error; err != nil {
-			return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to add permission")
+		perms, err := findOrCreatePermissions(tx, permissions)
+		if err != nil {
+			return err
+		}
+		if len(perms) > 0 {
+			if err := tx.Model(r).Association("Permissions").Append(perms); err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to add role permissions")
+			}
 		}
-		db.WithContext(ctx).Model(r).Association("Permissions").Append(&perm)
+
+		return loadRole(tx, r)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	roleJSON, _ := json.Marshal(r)
-	key := "role:" + r.ID.String()
-	rclient.Set(ctx, key, roleJSON, 10*time.Minute)
+	invalidateRoleCache(ctx, rclient, r.ID)
+	cache.ForgetMissing(ctx, rclient, cache.MissingRoles)
+	cache.ForgetMissing(ctx, rclient, cache.MissingPermissions)
 	return r, nil
 }
 
-// GetRole retrieves a role by ID.
+// CloneRole creates a role named name with the permissions of the role with the given ID.
+func CloneRole(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID, name string) (*Role, error) {
+	source := &Role{}
+	if err := db.WithContext(ctx).Preload("Permissions").Where("id = ?", id).First(source).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Role not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get role")
+	}
+
+	perms := make([]string, len(source.Permissions))
+	for i, p := range source.Permissions {
+		perms[i] = p.Name
+	}
+	return NewRole(ctx, rclient, db, name, perms...)
+}
+
+// GetRole retrieves a role by ID. Lookups that find nothing are cached briefly.
 func GetRoleBy(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, condition string, args []interface{}) (*Role, error) {
+	lookup := cache.LookupKey(condition, args...)
+	missing, epoch := cache.Missing(ctx, rclient, cache.MissingRoles, lookup)
+	if missing {
+		return nil, utils.NewError(utils.ErrNotFound.Code, "Role not found")
+	}
+
 	var r Role
 	query := db.WithContext(ctx).Where(condition, args...)
 	if err := query.Preload("Permissions").Find(&r).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, utils.NewError(utils.ErrNotFound.Code, "Role not found")
-		}
 		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get role")
 	}
+	if r.ID == uuid.Nil {
+		cache.MarkMissing(ctx, rclient, cache.MissingRoles, lookup, epoch, rclient.TTL("missing"))
+		return nil, utils.NewError(utils.ErrNotFound.Code, "Role not found")
+	}
 
 	roleJSON, _ := json.Marshal(r)
-	rclient.Set(ctx, "role:"+r.ID.String(), roleJSON, 10*time.Minute)
+	rclient.Set(ctx, "role:"+r.ID.String(), roleJSON, rclient.TTL("role"))
 	return &r, nil
 }
 
@@ -157,42 +125,371 @@ func GetRoles(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB) ([
 	}
 
 	rolesJSON, _ := json.Marshal(roles)
-	rclient.Set(ctx, key, rolesJSON, 10*time.Minute)
+	rclient.Set(ctx, key, rolesJSON, rclient.TTL("role"))
 	return roles, nil
 }
 
-// UpdateRole updates a role’s name or permissions.
-func UpdateRole(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID, name string, permissions []string) (*Role, error) {
-	r, err := GetRoleBy(ctx, rclient, db, "id = ?", []interface{}{id})
-	if err != nil {
-		return nil, err
+// ListRoles retrieves a page of roles with their permissions, ordered by name.
+func ListRoles(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, p utils.Pagination) (utils.Page[Role], error) {
+	return cache.Fetch(ctx, rclient, cache.RolesPageKey(p.CacheKey()), rclient.TTL("role"), func(ctx context.Context) (utils.Page[Role], []string, error) {
+		page, err := listByName(ctx, db.Preload("Permissions"), p, func(r Role) utils.Cursor {
+			return utils.Cursor{Key: r.Name, ID: r.ID.String()}
+		})
+		if err != nil {
+			return page, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get roles")
+		}
+		return page, []string{cache.RolesTag}, nil
+	})
+}
+
+// listByName reads a page of a table ordered by its unique name column, which alone positions
+// the cursor.
+func listByName[T any](ctx context.Context, db *gorm.DB, p utils.Pagination, cursor func(T) utils.Cursor) (utils.Page[T], error) {
+	query := db.WithContext(ctx).Model(new(T))
+	var total int64
+	if p.WithTotal {
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return utils.Page[T]{}, err
+		}
+	}
+	if p.After != nil {
+		query = query.Where("name > ?", p.After.Key)
+	}
+
+	var rows []T
+	if err := query.Order("name").Limit(p.Limit + 1).Find(&rows).Error; err != nil {
+		return utils.Page[T]{}, err
+	}
+	page := utils.NewPage(rows, p, cursor)
+	if p.WithTotal {
+		page.Total = &total
 	}
+	return page, nil
+}
 
-	r.Name = name
+// UpdateRole updates a role’s name and permissions if the role is still at the expected version.
+func UpdateRole(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID, version int, name string, permissions []string) (*Role, error) {
+	r := &Role{ID: id, Name: name}
 	validate := validator.New()
 	if err := validate.Struct(r); err != nil {
 		return nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid role data", err.Error())
 	}
 
-	if err := db.WithContext(ctx).Model(r).Association("Permissions").Clear(); err != nil {
-		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to clear permissions")
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current []string
+		if err := tx.Model(&Role{}).Where("id = ?", id).Pluck("name", &current).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get role")
+		}
+		if len(current) > 0 && current[0] != name && IsDeclaredRole(current[0]) {
+			return utils.NewError(utils.ErrForbidden.Code, "Built-in roles cannot be renamed")
+		}
+		if err := bumpRoleVersion(tx, id, version, map[string]interface{}{"name": name}); err != nil {
+			return err
+		}
+
+		perms, err := findOrCreatePermissions(tx, permissions)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(r).Association("Permissions").Replace(perms); err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update role permissions")
+		}
+
+		return loadRole(tx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateRoleCache(ctx, rclient, id)
+	cache.ForgetMissing(ctx, rclient, cache.MissingPermissions)
+	return r, nil
+}
+
+// AddRolePermissions grants permissions to a role if the role is still at the expected version.
+func AddRolePermissions(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID, version int, permissions []string) (*Role, error) {
+	r := &Role{ID: id}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := bumpRoleVersion(tx, id, version, nil); err != nil {
+			return err
+		}
+
+		perms, err := findOrCreatePermissions(tx, permissions)
+		if err != nil {
+			return err
+		}
+		if len(perms) > 0 {
+			if err := tx.Model(r).Association("Permissions").Append(perms); err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to add role permissions")
+			}
+		}
+
+		return loadRole(tx, r)
+	})
+	if err != nil {
+		return nil, err
 	}
-	for _, permName := range permissions {
+
+	invalidateRoleCache(ctx, rclient, id)
+	cache.ForgetMissing(ctx, rclient, cache.MissingPermissions)
+	return r, nil
+}
+
+// RemoveRolePermissions revokes permissions from a role if the role is still at the expected version.
+func RemoveRolePermissions(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID, version int, permissions []string) (*Role, error) {
+	r := &Role{ID: id}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := bumpRoleVersion(tx, id, version, nil); err != nil {
+			return err
+		}
+
+		names, err := expandPermissionNames(tx, permissions)
+		if err != nil {
+			return err
+		}
+
+		var perms []Permission
+		if err := tx.Where("name IN ?", names).Find(&perms).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get permissions")
+		}
+		if len(perms) > 0 {
+			if err := tx.Model(r).Association("Permissions").Delete(perms); err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to remove role permissions")
+			}
+		}
+
+		return loadRole(tx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateRoleCache(ctx, rclient, id)
+	return r, nil
+}
+
+// PermissionPreview describes the effective permissions a role would have after a change.
+type PermissionPreview struct {
+	RoleID    uuid.UUID `json:"role_id"`
+	Version   int       `json:"version"`
+	Effective []string  `json:"effective"`
+	Added     []string  `json:"added"`
+	Removed   []string  `json:"removed"`
+}
+
+// PreviewRolePermissions computes the effective permissions of a role for a proposed
+// permission set without persisting anything.
+func PreviewRolePermissions(ctx context.Context, db *gorm.DB, id uuid.UUID, permissions []string) (*PermissionPreview, error) {
+	r := &Role{ID: id}
+	if err := db.WithContext(ctx).Preload("Permissions").Where("id = ?", id).First(r).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Role not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get role")
+	}
+
+	effective, err := expandPermissionNames(db.WithContext(ctx), permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]bool, len(r.Permissions))
+	for _, p := range r.Permissions {
+		current[p.Name] = true
+	}
+
+	preview := &PermissionPreview{RoleID: r.ID, Version: r.Version, Effective: effective, Added: []string{}, Removed: []string{}}
+	proposed := make(map[string]bool, len(effective))
+	for _, name := range effective {
+		proposed[name] = true
+		if !current[name] {
+			preview.Added = append(preview.Added, name)
+		}
+	}
+	for name := range current {
+		if !proposed[name] {
+			preview.Removed = append(preview.Removed, name)
+		}
+	}
+	sort.Strings(preview.Removed)
+	return preview, nil
+}
+
+// bumpRoleVersion increments the role version, failing with a conflict when it has moved on.
+func bumpRoleVersion(tx *gorm.DB, id uuid.UUID, version int, updates map[string]interface{}) error {
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["version"] = gorm.Expr("version + 1")
+	updates["updated_at"] = time.Now()
+
+	result := tx.Model(&Role{}).Where("id = ? AND version = ?", id, version).Updates(updates)
+	if result.Error != nil {
+		return utils.WrapError(result.Error, utils.ErrInternalServerError.Code, "Failed to update role")
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&Role{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get role")
+	}
+	if count == 0 {
+		return utils.NewError(utils.ErrNotFound.Code, "Role not found")
+	}
+	return utils.NewError(utils.ErrConflict.Code, "Role was modified by another request")
+}
+
+// findOrCreatePermissions resolves permission names, expanding wildcards, inside a transaction.
+func findOrCreatePermissions(tx *gorm.DB, names []string) ([]Permission, error) {
+	expanded, err := expandPermissionNames(tx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := make([]Permission, 0, len(expanded))
+	for _, permName := range expanded {
 		var perm Permission
-		if err := db.WithContext(ctx).Where("name = ?", permName).FirstOrCreate(&perm).Error; err != nil {
+		if err := tx.Where(Permission{Name: permName}).FirstOrCreate(&perm).Error; err != nil {
 			return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to add permission")
 		}
-		db.WithContext(ctx).Model(r).Association("Permissions").Append(&perm)
+		perms = append(perms, perm)
 	}
+	return perms, nil
+}
 
-	if err := db.WithContext(ctx).Save(r).Error; err != nil {
-		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update role")
+// expandPermissionNames replaces wildcard names such as "*_post" or "edit_*" with the
+// matching known permissions and returns the sorted, de-duplicated result.
+func expandPermissionNames(tx *gorm.DB, names []string) ([]string, error) {
+	var known []string
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !strings.ContainsAny(name, "*?[") {
+			seen[name] = true
+			continue
+		}
+
+		if known == nil {
+			if err := tx.Model(&Permission{}).Pluck("name", &known).Error; err != nil {
+				return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get permissions")
+			}
+		}
+		for _, k := range known {
+			matched, err := path.Match(name, k)
+			if err != nil {
+				return nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid permission pattern", name)
+			}
+			if matched {
+				seen[k] = true
+			}
+		}
 	}
 
-	roleJSON, _ := json.Marshal(r)
-	key := "role:" + r.ID.String()
-	rclient.Set(ctx, key, roleJSON, 10*time.Minute)
-	return r, nil
+	expanded := make([]string, 0, len(seen))
+	for name := range seen {
+		expanded = append(expanded, name)
+	}
+	sort.Strings(expanded)
+	return expanded, nil
+}
+
+// loadRole reloads a role with its permissions.
+func loadRole(tx *gorm.DB, r *Role) error {
+	if err := tx.Preload("Permissions").Where("id = ?", r.ID).First(r).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get role")
+	}
+	return nil
+}
+
+// invalidateRoleCache drops every cached view of a role once its changes are committed. Permission
+// snapshots are keyed by version, so dropping the version pointer is enough for them.
+func invalidateRoleCache(ctx context.Context, rclient *storage.RedisClient, id uuid.UUID) {
+	rclient.Del(ctx, "role:"+id.String(), "roles:all")
+	rclient.Invalidate(ctx, roleVersionKey(id))
+	cache.Invalidate(ctx, rclient, cache.RolesTag, cache.PermissionsTag)
+}
+
+// InvalidateRoleCaches drops the cached views of every role. Migrations change grants without
+// going through the role API, so the caches are dropped once they have run.
+func InvalidateRoleCaches(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB) error {
+	var ids []uuid.UUID
+	if err := db.WithContext(ctx).Model(&Role{}).Pluck("id", &ids).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch roles")
+	}
+	for _, id := range ids {
+		invalidateRoleCache(ctx, rclient, id)
+	}
+	return nil
+}
+
+// PermissionSnapshot is the permission set of a role at one version.
+type PermissionSnapshot struct {
+	RoleID      uuid.UUID `json:"role_id"`
+	Version     int       `json:"version"`
+	Permissions []string  `json:"permissions"`
+}
+
+// Has reports whether the snapshot grants any of perms.
+func (s *PermissionSnapshot) Has(perms ...string) bool {
+	for _, granted := range s.Permissions {
+		for _, p := range perms {
+			if granted == p {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func roleVersionKey(id uuid.UUID) string {
+	return "role_version:" + id.String()
+}
+
+func permissionSnapshotKey(id uuid.UUID, version int) string {
+	return "role_perms:" + id.String() + ":" + strconv.Itoa(version)
+}
+
+// GetPermissionSnapshot returns the permissions of a role. A request costs two Redis reads: the
+// role's current version, then the snapshot stored for it. Every permission change bumps the
+// version, so a stale snapshot is never read and simply expires.
+func GetPermissionSnapshot(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, id uuid.UUID) (*PermissionSnapshot, error) {
+	versionKey := roleVersionKey(id)
+	if version, err := rclient.Get(ctx, versionKey).Int(); err == nil {
+		if raw, err := rclient.Get(ctx, permissionSnapshotKey(id, version)).Bytes(); err == nil {
+			var snapshot PermissionSnapshot
+			if json.Unmarshal(raw, &snapshot) == nil {
+				return &snapshot, nil
+			}
+		}
+	}
+
+	lookup := cache.LookupKey("id = ?", id)
+	missing, epoch := cache.Missing(ctx, rclient, cache.MissingRoles, lookup)
+	if missing {
+		return nil, utils.NewError(utils.ErrNotFound.Code, "Role not found")
+	}
+
+	gen := rclient.Generation(ctx, versionKey)
+	var r Role
+	if err := db.WithContext(ctx).Preload("Permissions").Where("id = ?", id).First(&r).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			cache.MarkMissing(ctx, rclient, cache.MissingRoles, lookup, epoch, rclient.TTL("missing"))
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Role not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get role")
+	}
+	snapshot := &PermissionSnapshot{RoleID: r.ID, Version: r.Version, Permissions: make([]string, len(r.Permissions))}
+	for i, p := range r.Permissions {
+		snapshot.Permissions[i] = p.Name
+	}
+
+	ttl := rclient.TTL("role_perms")
+	if raw, err := json.Marshal(snapshot); err == nil {
+		rclient.Set(ctx, permissionSnapshotKey(id, r.Version), raw, ttl)
+	}
+	rclient.SetIfGeneration(ctx, versionKey, []byte(strconv.Itoa(r.Version)), ttl, gen)
+	return snapshot, nil
 }
 
 // DeleteRole deletes a role.
@@ -201,84 +498,79 @@ func DeleteRole(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB,
 	if err != nil {
 		return err
 	}
+	// Declared roles would be recreated at the next start, so they cannot be deleted
+	if IsDeclaredRole(r.Name) {
+		return utils.NewError(utils.ErrForbidden.Code, "Built-in roles cannot be deleted")
+	}
 
 	if err := db.WithContext(ctx).Delete(r).Error; err != nil {
 		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete role")
 	}
 
-	key := "role:" + id.String()
-	rclient.Del(ctx, key)
+	invalidateRoleCache(ctx, rclient, id)
 	return nil
 }
 
-// SeedRoles initializes default roles and permissions.
-func SeedRoles(ctx context.Context, db *gorm.DB, redisClient *storage.RedisClient, logger *logger.Logger) error {
-	for _, perm := range permissions {
-		allPermissions = append(allPermissions, perm.Name)
-		db.FirstOrCreate(&perm, Permission{Name: perm.Name})
-	}
-
-	// Seed roles and permissions into the database
-	for _, r := range roles {
-		var role Role
-		if err := db.WithContext(ctx).Where("name = ?", r.Name).First(&role).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				role = Role{Name: r.Name}
-				if err := db.Create(&role).Error; err != nil {
-					logger.Error(ctx).WithMeta(utils.Map{
-						"error": err.Error(),
-						"role":  r.Name,
-					}).Logs("Failed to create role")
-					continue
-				}
-				permNames := make([]string, len(r.Permissions))
-				for i, perm := range r.Permissions {
-					permNames[i] = perm
-				}
-				permJSON, _ := json.Marshal(permNames)
-				key := "role_perms:" + role.ID.String()
-				redisClient.Set(ctx, key, permJSON, 24*time.Hour)
-			} else {
-				logger.Error(ctx).WithMeta(utils.Map{
-					"error": err.Error(),
-					"role":  r.Name,
-				}).Logs("Database error fetching role")
-				continue
-			}
-		}
+// GetRoleUsers retrieves a page of users assigned a role, by username, along with the total
+// count. Deleted users are left out.
+func GetRoleUsers(ctx context.Context, db *gorm.DB, roleID uuid.UUID, page, limit int) ([]UserSummary, int64, error) {
+	var exists int64
+	if err := db.WithContext(ctx).Model(&Role{}).Where("id = ?", roleID).Count(&exists).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get role")
+	}
+	if exists == 0 {
+		return nil, 0, utils.NewError(utils.ErrNotFound.Code, "Role not found")
+	}
 
-		// Fetch or create permissions and associate them with the role
-		var perms []Permission
-		for _, permName := range r.Permissions {
-			var perm Permission
-			if err := db.WithContext(ctx).Where("name = ?", permName).First(&perm).Error; err != nil {
-				if err == gorm.ErrRecordNotFound {
-					perm = Permission{Name: permName}
-					if err := db.Create(&perm).Error; err != nil {
-						logger.Error(ctx).WithMeta(utils.Map{
-							"error": err.Error(),
-							"role":  permName,
-						}).Logs("Failed to create permission")
-						continue
-					}
-				} else {
-					logger.Error(ctx).WithMeta(utils.Map{
-						"error": err.Error(),
-						"role":  permName,
-					}).Logs("Database error fetching permission")
-					continue
-				}
-			}
-			perms = append(perms, perm)
+	query := db.WithContext(ctx).Table("users").
+		Joins("JOIN roles ON roles.id = users.role_id").
+		Where("roles.id = ? AND users.deleted_at IS NULL", roleID).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count role users")
+	}
+
+	summaries := []UserSummary{}
+	if total == 0 {
+		return summaries, 0, nil
+	}
+	if err := query.
+		Select("users.id, users.username, users.name, users.avatar_url, users.bio, users.job_title, users.location, users.skills, users.interests, users.last_seen").
+		Order("users.username ASC").
+		Offset((page - 1) * limit).Limit(limit).
+		Scan(&summaries).Error; err != nil {
+		return nil, 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get role users")
+	}
+	return summaries, total, nil
+}
+
+// AssignRole moves a user to another role and returns the role held before and the one assigned.
+func AssignRole(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, userID, roleID uuid.UUID) (*Role, *Role, error) {
+	var u User
+	if err := db.WithContext(ctx).Select("id, role_id").Where("id = ?", userID).First(&u).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, utils.NewError(utils.ErrNotFound.Code, "User not found")
 		}
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch user")
+	}
 
-		// Associate the permissions with the role
-		if err := db.WithContext(ctx).Model(&role).Association("Permissions").Replace(perms); err != nil {
-			logger.Error(ctx).WithMeta(utils.Map{
-				"error": err.Error(),
-				"role":  r.Name,
-			}).Logs("Failed to associate permissions with role")
+	var next Role
+	if err := db.WithContext(ctx).Where("id = ?", roleID).First(&next).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, utils.NewError(utils.ErrNotFound.Code, "Role not found")
 		}
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch role")
 	}
-	return nil
+	prev := Role{ID: u.RoleID}
+	if err := db.WithContext(ctx).Where("id = ?", u.RoleID).Limit(1).Find(&prev).Error; err != nil {
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch role")
+	}
+
+	if err := db.WithContext(ctx).Model(&User{}).Where("id = ?", userID).Update("role_id", roleID).Error; err != nil {
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to assign role")
+	}
+	cache.InvalidateUser(ctx, rclient, userID)
+	return &prev, &next, nil
 }
@@ -0,0 +1,351 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// Roles of the canonical hierarchy, from least to most privileged. New users join as members.
+const (
+	RoleMember    = "member"
+	RoleTrusted   = "trusted"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// rolePolicyLock is the advisory lock key serializing policy application across instances.
+const rolePolicyLock = 7_241_001
+
+// PermissionCatalog lists every permission the application checks. Roles may only be declared
+// with permissions from it.
+var PermissionCatalog = []string{
+	// Post-related permissions
+	"bookmark_post", "create_post", "delete_any_post", "delete_own_post",
+	"edit_any_post", "edit_own_post", "feature_posts", "moderate_post", "read_post",
+
+	// Comment-related permissions
+	"create_comment", "delete_any_comment", "delete_own_comment",
+	"edit_any_comment", "edit_own_comment", "moderate_comment",
+
+	// User-related permissions
+	"ban_user", "block_user", "create_user", "delete_any_user", "delete_own_profile",
+	"edit_any_user", "edit_own_profile", "follow_user", "moderate_user",
+	"impersonate_user", "manage_account", "read_user_profile", "unfollow_user",
+	"upload_media",
+
+	// Role and permission management
+	"assign_roles", "create_roles", "delete_roles",
+	"edit_roles", "manage_roles", "view_audit_logs",
+
+	// Invitation-related permissions
+	"create_invitation", "manage_invitations",
+
+	// Reaction-related permissions
+	"create_reaction", "delete_any_reaction", "delete_own_reaction",
+	"edit_any_reaction", "edit_own_reaction", "give_reaction",
+
+	// Tag-related permissions
+	"create_tag", "delete_tag", "edit_tag",
+	"follow_tag", "moderate_tag", "unfollow_tag",
+
+	// Organization-related permissions
+	"create_organization",
+
+	// Listing-related permissions
+	"create_listing", "moderate_listing",
+
+	// Site-wide and moderation permissions
+	"give_suggestion", "manage_analytics", "manage_content_filter",
+	"manage_notifications", "manage_site_settings", "create_webhook", "manage_webhooks",
+	"need_moderation", "report_content",
+}
+
+// RoleSpec declares a role. A role holds the permissions of the role it inherits plus its own,
+// less those it drops; permissions may be patterns such as "*" or "edit_*".
+type RoleSpec struct {
+	Name        string
+	Inherits    string
+	Drops       []string
+	Permissions []string
+}
+
+// RolePolicy declares the built-in roles, each after the role it inherits. The hierarchy runs
+// member < trusted < moderator < admin; author and tag_moderator branch off it.
+var RolePolicy = []RoleSpec{
+	{Name: RoleMember, Permissions: []string{
+		"read_post", "create_comment", "edit_own_comment", "delete_own_comment",
+		"give_reaction", "follow_tag", "unfollow_tag", "follow_user", "unfollow_user",
+		"delete_own_profile", "edit_own_profile", "need_moderation", "report_content",
+		"create_invitation", "manage_account", "block_user", "bookmark_post", "upload_media",
+		"create_webhook",
+	}},
+	{Name: "author", Inherits: RoleMember, Drops: []string{"need_moderation"}, Permissions: []string{
+		"create_post", "edit_own_post", "delete_own_post", "create_organization", "create_listing",
+	}},
+	{Name: RoleTrusted, Inherits: RoleMember, Drops: []string{"need_moderation"}, Permissions: []string{
+		"create_post", "edit_own_post", "delete_own_post", "create_organization", "create_listing",
+		"give_suggestion",
+	}},
+	{Name: "tag_moderator", Inherits: RoleTrusted, Permissions: []string{
+		"moderate_tag", "feature_posts", "ban_user",
+	}},
+	{Name: RoleModerator, Inherits: RoleTrusted, Permissions: []string{
+		"edit_any_post", "delete_any_post", "moderate_post", "feature_posts",
+		"edit_any_comment", "delete_any_comment", "moderate_comment",
+		"create_user", "edit_any_user", "delete_any_user", "moderate_user", "ban_user",
+		"create_roles", "edit_roles", "delete_roles",
+		"create_reaction", "edit_any_reaction", "delete_any_reaction",
+		"create_tag", "edit_tag", "delete_tag", "moderate_tag", "manage_content_filter",
+		"moderate_listing",
+	}},
+	{Name: RoleAdmin, Inherits: RoleModerator, Drops: []string{"need_moderation"}, Permissions: []string{"*"}},
+}
+
+// IsDeclaredRole reports whether a role is part of the declared policy.
+func IsDeclaredRole(name string) bool {
+	for _, spec := range RolePolicy {
+		if spec.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveRolePolicy returns the effective, sorted permissions of every declared role. It fails
+// when a role inherits one declared after it or names a permission missing from the catalog.
+func ResolveRolePolicy() (map[string][]string, error) {
+	resolved := make(map[string][]string, len(RolePolicy))
+	for _, spec := range RolePolicy {
+		if _, dup := resolved[spec.Name]; dup {
+			return nil, fmt.Errorf("role %q is declared twice", spec.Name)
+		}
+		granted, err := resolveRoleSpec(spec, resolved)
+		if err != nil {
+			return nil, err
+		}
+		resolved[spec.Name] = granted
+	}
+	return resolved, nil
+}
+
+// resolveRoleSpec returns the effective, sorted permissions of a spec given the already resolved
+// roles it may inherit.
+func resolveRoleSpec(spec RoleSpec, resolved map[string][]string) ([]string, error) {
+	granted := map[string]bool{}
+	if spec.Inherits != "" {
+		parent, ok := resolved[spec.Inherits]
+		if !ok {
+			return nil, fmt.Errorf("role %q inherits %q, which is not declared before it", spec.Name, spec.Inherits)
+		}
+		for _, name := range parent {
+			granted[name] = true
+		}
+	}
+	for _, pattern := range spec.Permissions {
+		matched := false
+		for _, name := range PermissionCatalog {
+			if ok, err := path.Match(pattern, name); err != nil {
+				return nil, fmt.Errorf("role %q has an invalid permission pattern %q", spec.Name, pattern)
+			} else if ok {
+				granted[name], matched = true, true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("role %q grants %q, which is not in the permission catalog", spec.Name, pattern)
+		}
+	}
+	for _, name := range spec.Drops {
+		delete(granted, name)
+	}
+	return sortedKeys(granted), nil
+}
+
+// RoleDrift is how a declared role differs from the database.
+type RoleDrift struct {
+	Role    string   `json:"role"`
+	Missing bool     `json:"missing"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// RolePolicyDiff is the drift between the database and the declared policy. Added permissions are
+// declared but not granted in the database, removed ones granted but not declared. Undeclared
+// roles and permissions, such as custom roles, are listed but are not drift.
+type RolePolicyDiff struct {
+	Roles                 []RoleDrift `json:"roles"`
+	MissingPermissions    []string    `json:"missing_permissions"`
+	UndeclaredRoles       []string    `json:"undeclared_roles"`
+	UndeclaredPermissions []string    `json:"undeclared_permissions"`
+}
+
+// Drifted reports whether applying the policy would change the database.
+func (d *RolePolicyDiff) Drifted() bool {
+	return len(d.Roles) > 0 || len(d.MissingPermissions) > 0
+}
+
+// DiffRolePolicy compares the roles and permissions in the database with the declared policy
+// without changing anything.
+func DiffRolePolicy(ctx context.Context, db *gorm.DB) (*RolePolicyDiff, error) {
+	declared, err := ResolveRolePolicy()
+	if err != nil {
+		return nil, err
+	}
+	diff, _, err := diffRolePolicy(db.WithContext(ctx), declared)
+	return diff, err
+}
+
+// diffRolePolicy computes the drift of the database and returns the stored roles by name.
+func diffRolePolicy(tx *gorm.DB, declared map[string][]string) (*RolePolicyDiff, map[string]Role, error) {
+	var stored []Role
+	if err := tx.Preload("Permissions").Order("name").Find(&stored).Error; err != nil {
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get roles")
+	}
+	var known []string
+	if err := tx.Model(&Permission{}).Order("name").Pluck("name", &known).Error; err != nil {
+		return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get permissions")
+	}
+
+	diff := &RolePolicyDiff{Roles: []RoleDrift{}, MissingPermissions: []string{}, UndeclaredRoles: []string{}, UndeclaredPermissions: []string{}}
+	knownSet := make(map[string]bool, len(known))
+	for _, name := range known {
+		knownSet[name] = true
+	}
+	catalog := make(map[string]bool, len(PermissionCatalog))
+	for _, name := range PermissionCatalog {
+		catalog[name] = true
+		if !knownSet[name] {
+			diff.MissingPermissions = append(diff.MissingPermissions, name)
+		}
+	}
+	sort.Strings(diff.MissingPermissions)
+	for _, name := range known {
+		if !catalog[name] {
+			diff.UndeclaredPermissions = append(diff.UndeclaredPermissions, name)
+		}
+	}
+
+	byName := make(map[string]Role, len(stored))
+	for _, r := range stored {
+		byName[r.Name] = r
+		if _, ok := declared[r.Name]; !ok {
+			diff.UndeclaredRoles = append(diff.UndeclaredRoles, r.Name)
+		}
+	}
+
+	for _, spec := range RolePolicy {
+		want := declared[spec.Name]
+		r, ok := byName[spec.Name]
+		if !ok {
+			diff.Roles = append(diff.Roles, RoleDrift{Role: spec.Name, Missing: true, Added: want, Removed: []string{}})
+			continue
+		}
+		have := make(map[string]bool, len(r.Permissions))
+		for _, p := range r.Permissions {
+			have[p.Name] = true
+		}
+		drift := RoleDrift{Role: spec.Name, Added: []string{}, Removed: []string{}}
+		for _, name := range want {
+			if !have[name] {
+				drift.Added = append(drift.Added, name)
+			}
+			delete(have, name)
+		}
+		drift.Removed = sortedKeys(have)
+		if len(drift.Added) > 0 || len(drift.Removed) > 0 {
+			diff.Roles = append(diff.Roles, drift)
+		}
+	}
+	return diff, byName, nil
+}
+
+// ApplyRolePolicy brings the database in line with the declared policy: missing permissions and
+// roles are created and every declared role is given exactly its declared permissions. Running it
+// again changes nothing, and concurrent runs from several instances are serialized.
+func ApplyRolePolicy(ctx context.Context, db *gorm.DB, redisClient *storage.RedisClient, logger *logger.Logger) error {
+	declared, err := ResolveRolePolicy()
+	if err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Invalid role policy")
+	}
+	if _, err := ResolveRoleTemplates(); err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Invalid role templates")
+	}
+
+	var changed []uuid.UUID
+	var applied []RoleDrift
+	var created []string
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", rolePolicyLock).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to lock role policy")
+		}
+		diff, stored, err := diffRolePolicy(tx, declared)
+		if err != nil {
+			return err
+		}
+		for _, name := range diff.MissingPermissions {
+			if err := tx.Where(Permission{Name: name}).FirstOrCreate(&Permission{}).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create permission "+name)
+			}
+		}
+		created = diff.MissingPermissions
+
+		for _, drift := range diff.Roles {
+			role, ok := stored[drift.Role]
+			if !ok {
+				role = Role{Name: drift.Role}
+				if err := tx.Create(&role).Error; err != nil {
+					return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create role "+drift.Role)
+				}
+			}
+			perms := []Permission{}
+			if err := tx.Where("name IN ?", declared[drift.Role]).Find(&perms).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get permissions")
+			}
+			if err := tx.Model(&role).Association("Permissions").Replace(perms); err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to set permissions of role "+drift.Role)
+			}
+			// Bumping the version keeps cached permission snapshots from being reused
+			if err := tx.Model(&Role{}).Where("id = ?", role.ID).UpdateColumn("version", gorm.Expr("version + 1")).Error; err != nil {
+				return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update role "+drift.Role)
+			}
+			changed = append(changed, role.ID)
+		}
+		applied = diff.Roles
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range changed {
+		invalidateRoleCache(ctx, redisClient, id)
+	}
+	if len(changed) > 0 {
+		cache.ForgetMissing(ctx, redisClient, cache.MissingRoles)
+	}
+	if len(created) > 0 || len(changed) > 0 {
+		cache.ForgetMissing(ctx, redisClient, cache.MissingPermissions)
+	}
+	for _, drift := range applied {
+		logger.Info(ctx).WithFields("role", drift.Role, "created", drift.Missing, "added", drift.Added, "removed", drift.Removed).Logs("Applied role policy")
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a set in order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,67 @@
+package models
+
+import "github.com/mnuddindev/devpulse/pkg/utils"
+
+// RoleTemplate is a starting point for a custom role: a spec inheriting one of the declared roles
+// plus a description for admins choosing between templates.
+type RoleTemplate struct {
+	RoleSpec
+	Description string
+}
+
+// RoleTemplates lists the templates custom roles can be created from.
+var RoleTemplates = []RoleTemplate{
+	{RoleSpec: RoleSpec{Name: "moderator_baseline", Inherits: RoleTrusted, Permissions: []string{
+		"moderate_post", "moderate_comment", "edit_any_comment", "delete_any_comment", "moderate_user",
+	}}, Description: "Reviews posts, comments and users without managing content, tags or roles"},
+	{RoleSpec: RoleSpec{Name: "content_editor", Inherits: RoleTrusted, Permissions: []string{
+		"edit_any_post", "feature_posts", "create_tag", "edit_tag",
+	}}, Description: "Edits and features posts of any author and curates tags"},
+	{RoleSpec: RoleSpec{Name: "community_manager", Inherits: RoleTrusted, Permissions: []string{
+		"feature_posts", "moderate_tag", "manage_notifications", "manage_analytics",
+	}}, Description: "Promotes content, runs tags and follows community analytics"},
+	{RoleSpec: RoleSpec{Name: "auditor", Inherits: RoleMember, Permissions: []string{
+		"manage_analytics", "view_audit_logs",
+	}}, Description: "Reads analytics and the audit log without moderating anything"},
+}
+
+// ResolvedRoleTemplate is a role template with its effective permissions.
+type ResolvedRoleTemplate struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Inherits    string   `json:"inherits"`
+	Permissions []string `json:"permissions"`
+}
+
+// ResolveRoleTemplates returns every template with its effective, sorted permissions. It fails
+// like ResolveRolePolicy when a template inherits an undeclared role or names a permission missing
+// from the catalog.
+func ResolveRoleTemplates() ([]ResolvedRoleTemplate, error) {
+	declared, err := ResolveRolePolicy()
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]ResolvedRoleTemplate, 0, len(RoleTemplates))
+	for _, t := range RoleTemplates {
+		perms, err := resolveRoleSpec(t.RoleSpec, declared)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, ResolvedRoleTemplate{Name: t.Name, Description: t.Description, Inherits: t.Inherits, Permissions: perms})
+	}
+	return templates, nil
+}
+
+// GetRoleTemplate returns the template of the given name with its effective permissions.
+func GetRoleTemplate(name string) (*ResolvedRoleTemplate, error) {
+	templates, err := ResolveRoleTemplates()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Invalid role templates")
+	}
+	for i := range templates {
+		if templates[i].Name == name {
+			return &templates[i], nil
+		}
+	}
+	return nil, utils.NewError(utils.ErrNotFound.Code, "Role template not found")
+}
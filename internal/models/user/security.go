@@ -0,0 +1,293 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Security event names.
+const (
+	EventLogin           = "login"
+	EventLoginFailed     = "login_failed"
+	EventLogout          = "logout"
+	EventPasswordReset   = "password_reset"
+	EventSessionsRevoked = "sessions_revoked"
+	EventRefreshReuse    = "refresh_token_reused"
+	EventLoginAdded      = "login_method_added"
+	EventLoginRemoved    = "login_method_removed"
+)
+
+// ProviderPassword marks sessions established with email and password.
+const ProviderPassword = "password"
+
+// SessionPolicy controls when every session of a user is revoked.
+type SessionPolicy struct {
+	OnPasswordChange bool
+	OnRoleChange     bool
+}
+
+type SecurityEvent struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Event     string    `gorm:"size:50;not null" json:"event"`
+	IP        string    `gorm:"size:45" json:"ip"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+type SecuritySummary struct {
+	LastLoginAt      *time.Time      `json:"last_login_at"`
+	LastLoginIP      string          `json:"last_login_ip"`
+	ActiveSessions   int64           `json:"active_sessions"`
+	TwoFactorEnabled bool            `json:"two_factor_enabled"`
+	LinkedProviders  []string        `json:"linked_providers"`
+	RecentEvents     []SecurityEvent `json:"recent_events"`
+}
+
+// RecordSecurityEvent stores a security event for a user.
+func RecordSecurityEvent(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID, event, ip, userAgent string) error {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	e := &SecurityEvent{UserID: userID, Event: event, IP: ip, UserAgent: userAgent}
+	if err := gormDB.WithContext(ctx).Create(e).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record security event")
+	}
+	return nil
+}
+
+// GetSecurityEvents retrieves a user's most recent security events.
+func GetSecurityEvents(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID, limit int) ([]SecurityEvent, error) {
+	events := []SecurityEvent{}
+	if err := gormDB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get security events")
+	}
+	return events, nil
+}
+
+// TrackSession indexes a refresh token under its user so sessions can be counted and revoked.
+func TrackSession(ctx context.Context, redisClient *storage.RedisClient, userID uuid.UUID, refreshToken string, ttl time.Duration) {
+	key := "sessions:" + userID.String()
+	redisClient.ZAdd(ctx, key, redis.Z{Score: float64(time.Now().Add(ttl).Unix()), Member: refreshToken})
+	redisClient.Expire(ctx, key, ttl)
+}
+
+// UntrackSession removes a refresh token from its user's session index.
+func UntrackSession(ctx context.Context, redisClient *storage.RedisClient, userID uuid.UUID, refreshToken string) {
+	redisClient.ZRem(ctx, "sessions:"+userID.String(), refreshToken)
+}
+
+// CountSessions returns the number of unexpired sessions of a user.
+func CountSessions(ctx context.Context, redisClient *storage.RedisClient, userID uuid.UUID) (int64, error) {
+	key := "sessions:" + userID.String()
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	redisClient.ZRemRangeByScore(ctx, key, "-inf", now)
+	count, err := redisClient.ZCard(ctx, key).Result()
+	if err != nil && err != redis.Nil {
+		return 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count sessions")
+	}
+	return count, nil
+}
+
+// GetSecuritySummary assembles the security overview of a user.
+func GetSecuritySummary(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID) (*SecuritySummary, error) {
+	var u User
+	if err := gormDB.WithContext(ctx).Select("id, last_login_at, last_login_ip, two_factor_enabled").Where("id = ?", userID).First(&u).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "User not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get user")
+	}
+
+	sessions, err := CountSessions(ctx, redisClient, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := GetSecurityEvents(ctx, gormDB, userID, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := GetLinkedProviders(ctx, gormDB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecuritySummary{
+		LastLoginAt:      u.LastLoginAt,
+		LastLoginIP:      u.LastLoginIP,
+		ActiveSessions:   sessions,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		LinkedProviders:  providers,
+		RecentEvents:     events,
+	}, nil
+}
+
+// RevokeSessions signs a user out everywhere by bumping their token version, which
+// invalidates outstanding access tokens, and deleting every tracked refresh token.
+func RevokeSessions(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID) error {
+	if err := gormDB.WithContext(ctx).Model(&User{}).Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to revoke sessions")
+	}
+
+	key := "sessions:" + userID.String()
+	tokens, err := redisClient.ZRange(ctx, key, 0, -1).Result()
+	if err != nil && err != redis.Nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get sessions")
+	}
+
+	pipe := redisClient.TxPipeline()
+	for _, token := range tokens {
+		pipe.Del(ctx, "refresh:"+token)
+		redisClient.RevokePipe(ctx, pipe, storage.RefreshToken, token, 7*24*time.Hour)
+	}
+	pipe.Del(ctx, key, cache.UserKey(userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to revoke refresh tokens")
+	}
+	return nil
+}
+
+// SessionProvider returns the provider a session was established with, carried in its refresh data.
+func SessionProvider(refreshData map[string]interface{}) string {
+	if provider, ok := refreshData["provider"].(string); ok && provider != "" {
+		return provider
+	}
+	return ProviderPassword
+}
+
+// RevokeProviderSessions revokes only the sessions of a user that were established via provider
+// and returns how many were revoked.
+func RevokeProviderSessions(ctx context.Context, redisClient *storage.RedisClient, userID uuid.UUID, provider string) (int, error) {
+	key := "sessions:" + userID.String()
+	tokens, err := redisClient.ZRange(ctx, key, 0, -1).Result()
+	if err != nil && err != redis.Nil {
+		return 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get sessions")
+	}
+
+	revoked := 0
+	pipe := redisClient.TxPipeline()
+	for _, token := range tokens {
+		raw, err := redisClient.Get(ctx, "refresh:"+token).Result()
+		if err == redis.Nil {
+			pipe.ZRem(ctx, key, token)
+			continue
+		}
+		if err != nil {
+			return 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get session")
+		}
+
+		var refreshData map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &refreshData); err != nil || SessionProvider(refreshData) != provider {
+			continue
+		}
+		pipe.Del(ctx, "refresh:"+token)
+		redisClient.RevokePipe(ctx, pipe, storage.RefreshToken, token, 7*24*time.Hour)
+		pipe.ZRem(ctx, key, token)
+		revoked++
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to revoke sessions")
+	}
+	return revoked, nil
+}
+
+// SessionFamily returns the family a refresh token belongs to. Every rotation of a session stays in
+// the family started at login.
+func SessionFamily(refreshData map[string]interface{}) string {
+	family, _ := refreshData["family"].(string)
+	return family
+}
+
+// StoreRefreshToken stores a refresh token as the live token of its session family, starting a new
+// family when the refresh data has none.
+func StoreRefreshToken(ctx context.Context, redisClient *storage.RedisClient, userID uuid.UUID, token string, refreshData map[string]interface{}, ttl time.Duration) error {
+	family := SessionFamily(refreshData)
+	if family == "" {
+		family = uuid.NewString()
+		refreshData["family"] = family
+	}
+	refreshJSON, _ := json.Marshal(refreshData)
+
+	pipe := redisClient.TxPipeline()
+	pipe.Set(ctx, "refresh:"+token, refreshJSON, ttl)
+	pipe.Set(ctx, "refresh_family:"+family, token, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to store refresh token")
+	}
+	TrackSession(ctx, redisClient, userID, token, ttl)
+	return nil
+}
+
+// RotateRefreshToken consumes oldToken, remembers it as used so a replay can be detected, and stores
+// newToken in the same family. It returns false when oldToken was already consumed, e.g. by a
+// concurrent refresh.
+func RotateRefreshToken(ctx context.Context, redisClient *storage.RedisClient, userID uuid.UUID, oldToken, newToken string, refreshData map[string]interface{}, ttl time.Duration) (bool, error) {
+	consumed, err := redisClient.Del(ctx, "refresh:"+oldToken).Result()
+	if err != nil {
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to consume refresh token")
+	}
+	if consumed == 0 {
+		return false, nil
+	}
+	UntrackSession(ctx, redisClient, userID, oldToken)
+
+	if SessionFamily(refreshData) == "" {
+		refreshData["family"] = uuid.NewString()
+	}
+	usedJSON, _ := json.Marshal(map[string]string{"user_id": userID.String(), "family": SessionFamily(refreshData)})
+	redisClient.Set(ctx, "refresh_used:"+oldToken, usedJSON, ttl)
+
+	return true, StoreRefreshToken(ctx, redisClient, userID, newToken, refreshData, ttl)
+}
+
+// RevokeReusedRefreshToken checks whether token was already rotated away. A replayed token means it
+// leaked, so the live token of its whole family is revoked and the reuse is recorded.
+func RevokeReusedRefreshToken(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, token, ip, userAgent string) (bool, error) {
+	raw, err := redisClient.GetDel(ctx, "refresh_used:"+token).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to check refresh token reuse")
+	}
+
+	var used struct {
+		UserID string `json:"user_id"`
+		Family string `json:"family"`
+	}
+	if err := json.Unmarshal([]byte(raw), &used); err != nil {
+		return true, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to parse used refresh token")
+	}
+	userID, err := uuid.Parse(used.UserID)
+	if err != nil {
+		return true, utils.WrapError(err, utils.ErrInternalServerError.Code, "Invalid used refresh token")
+	}
+
+	current, err := redisClient.GetDel(ctx, "refresh_family:"+used.Family).Result()
+	if err != nil && err != redis.Nil {
+		return true, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get session family")
+	}
+	if current != "" {
+		pipe := redisClient.TxPipeline()
+		pipe.Del(ctx, "refresh:"+current)
+		redisClient.RevokePipe(ctx, pipe, storage.RefreshToken, current, 7*24*time.Hour)
+		pipe.ZRem(ctx, "sessions:"+userID.String(), current)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return true, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to revoke session family")
+		}
+	}
+
+	return true, RecordSecurityEvent(ctx, gormDB, userID, EventRefreshReuse, ip, userAgent)
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// Two-factor security events.
+const (
+	EventTwoFactorEnabled  = "two_factor_enabled"
+	EventTwoFactorDisabled = "two_factor_disabled"
+)
+
+// GetTwoFactorUser loads the fields needed to check a user's second factor, which are never cached.
+func GetTwoFactorUser(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID) (*User, error) {
+	var u User
+	if err := gormDB.WithContext(ctx).Select("id, email, password, two_factor_enabled, two_factor_secret, two_factor_backup_codes").
+		Where("id = ?", userID).First(&u).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "User not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get user")
+	}
+	return &u, nil
+}
+
+// SetTwoFactorSecret stores a pending encrypted TOTP secret. Two-factor stays disabled until a code
+// generated from the secret is verified.
+func SetTwoFactorSecret(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, encryptedSecret string) error {
+	return updateTwoFactor(ctx, redisClient, gormDB, userID, map[string]interface{}{
+		"two_factor_enabled":      false,
+		"two_factor_secret":       encryptedSecret,
+		"two_factor_backup_codes": "",
+	})
+}
+
+// EnableTwoFactor turns on two-factor for a user and stores the hashes of their backup codes.
+func EnableTwoFactor(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, hashedCodes []string) error {
+	codesJSON, _ := json.Marshal(hashedCodes)
+	return updateTwoFactor(ctx, redisClient, gormDB, userID, map[string]interface{}{
+		"two_factor_enabled":      true,
+		"two_factor_backup_codes": string(codesJSON),
+	})
+}
+
+// DisableTwoFactor turns off two-factor and discards the secret and backup codes.
+func DisableTwoFactor(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID) error {
+	return updateTwoFactor(ctx, redisClient, gormDB, userID, map[string]interface{}{
+		"two_factor_enabled":      false,
+		"two_factor_secret":       "",
+		"two_factor_backup_codes": "",
+	})
+}
+
+// ConsumeBackupCode reports whether code matches one of the user's unused backup codes and, if so,
+// removes it so it cannot be used again.
+func ConsumeBackupCode(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, u *User, code string) (bool, error) {
+	var hashes []string
+	if u.TwoFactorBackupCodes == "" || json.Unmarshal([]byte(u.TwoFactorBackupCodes), &hashes) != nil {
+		return false, nil
+	}
+
+	for i, hash := range hashes {
+		if utils.ComparePasswords(hash, code) != nil {
+			continue
+		}
+		remaining := append(hashes[:i:i], hashes[i+1:]...)
+		codesJSON, _ := json.Marshal(remaining)
+		// Only consume the code if no concurrent login consumed it first.
+		result := gormDB.WithContext(ctx).Model(&User{}).
+			Where("id = ? AND two_factor_backup_codes = ?", u.ID, u.TwoFactorBackupCodes).
+			UpdateColumn("two_factor_backup_codes", string(codesJSON))
+		if result.Error != nil {
+			return false, utils.WrapError(result.Error, utils.ErrInternalServerError.Code, "Failed to consume backup code")
+		}
+		if result.RowsAffected == 0 {
+			return false, nil
+		}
+		u.TwoFactorBackupCodes = string(codesJSON)
+		cache.InvalidateUser(ctx, redisClient, u.ID)
+		return true, nil
+	}
+	return false, nil
+}
+
+func updateTwoFactor(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, fields map[string]interface{}) error {
+	if err := gormDB.WithContext(ctx).Model(&User{}).Where("id = ?", userID).UpdateColumns(fields).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update two-factor settings")
+	}
+	cache.InvalidateUser(ctx, redisClient, userID)
+	return nil
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/uploads"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// Upload scan states.
+const (
+	// UploadClean uploads passed the content scanner and are served.
+	UploadClean = "clean"
+	// UploadQuarantined uploads wait for the content scanner and are not served.
+	UploadQuarantined = "quarantined"
+	// UploadRejected uploads were flagged by the content scanner; their files are deleted.
+	UploadRejected = "rejected"
+)
+
+// QuarantineKeyPrefix is the storage prefix of uploads waiting for the content scanner. Objects
+// under it are never served.
+const QuarantineKeyPrefix = "quarantine/"
+
+// Upload records a file a user stored, such as an avatar or a post cover image.
+type Upload struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Kind        string    `gorm:"size:20;not null" json:"kind"`
+	Key         string    `gorm:"size:255;not null;uniqueIndex" json:"key"`
+	ContentType string    `gorm:"size:50;not null" json:"content_type"`
+	Size        int       `gorm:"not null" json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Status      string    `gorm:"size:20;not null;default:'clean'" json:"status"`
+	ScanReason  string    `gorm:"size:255" json:"scan_reason,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// UploadScanEvent is the payload of OutboxUploadScan. Filename and ContentType are those of the
+// original file, as the scanner is given them.
+type UploadScanEvent struct {
+	UploadID    uuid.UUID `json:"upload_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+}
+
+// UploadKey is where a clean upload of kind is stored.
+func UploadKey(kind string, id uuid.UUID) string {
+	return kind + "s/" + id.String() + ".jpg"
+}
+
+// QuarantineKeys are where a quarantined upload keeps its processed image and the original file
+// the scanner reads.
+func QuarantineKeys(id uuid.UUID) (processed, original string) {
+	return QuarantineKeyPrefix + id.String() + ".jpg", QuarantineKeyPrefix + id.String() + ".orig"
+}
+
+// CreateUpload records a stored file.
+func CreateUpload(ctx context.Context, gormDB *gorm.DB, upload *Upload) error {
+	if upload.UserID == uuid.Nil || upload.Key == "" || upload.Kind == "" {
+		return utils.NewError(utils.ErrBadRequest.Code, "Required fields missing: user_id, key, kind")
+	}
+	upload.Status = UploadClean
+	if err := gormDB.WithContext(ctx).Create(upload).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record upload")
+	}
+	return nil
+}
+
+// QuarantineUpload records a file stored under QuarantineKeys and queues it for the content
+// scanner, which ScanQuarantinedUpload runs. filename and contentType describe the original file.
+func QuarantineUpload(ctx context.Context, gormDB *gorm.DB, upload *Upload, filename, contentType string) error {
+	if upload.UserID == uuid.Nil || upload.ID == uuid.Nil || upload.Kind == "" {
+		return utils.NewError(utils.ErrBadRequest.Code, "Required fields missing: id, user_id, kind")
+	}
+	upload.Key, _ = QuarantineKeys(upload.ID)
+	upload.Status = UploadQuarantined
+	return gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(upload).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record upload")
+		}
+		return EnqueueOutbox(ctx, tx, OutboxUploadScan, UploadScanEvent{UploadID: upload.ID, Filename: filename, ContentType: contentType})
+	})
+}
+
+// ScanQuarantinedUpload runs the scanner over the original file of a quarantined upload. A clean
+// upload is moved to its UploadKey and served from then on; a flagged one is marked rejected and
+// its files deleted. Uploads that are no longer quarantined are left alone, so the scan may be
+// repeated.
+func ScanQuarantinedUpload(ctx context.Context, gormDB *gorm.DB, store uploads.Driver, scanner utils.ContentScanner, e *UploadScanEvent) error {
+	var upload Upload
+	if err := gormDB.WithContext(ctx).Where("id = ?", e.UploadID).First(&upload).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch upload")
+	}
+	if upload.Status != UploadQuarantined {
+		return nil
+	}
+	processed, original := QuarantineKeys(upload.ID)
+
+	file, err := store.Open(ctx, original)
+	if err != nil {
+		return fmt.Errorf("failed to open quarantined upload: %w", err)
+	}
+	result, err := scanner.Scan(ctx, e.Filename, e.ContentType, file)
+	file.Close()
+	if err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to scan file")
+	}
+
+	updates := map[string]interface{}{"status": UploadClean}
+	if result.Flagged {
+		updates = map[string]interface{}{"status": UploadRejected, "scan_reason": truncate(result.Reason, 255)}
+	} else {
+		file, err := store.Open(ctx, processed)
+		if err != nil {
+			return fmt.Errorf("failed to open quarantined upload: %w", err)
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read quarantined upload: %w", err)
+		}
+		key := UploadKey(upload.Kind, upload.ID)
+		if err := store.Put(ctx, key, upload.ContentType, data); err != nil {
+			return fmt.Errorf("failed to release upload: %w", err)
+		}
+		updates["key"] = key
+	}
+	if err := gormDB.WithContext(ctx).Model(&Upload{}).Where("id = ? AND status = ?", upload.ID, UploadQuarantined).Updates(updates).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update upload")
+	}
+
+	return errors.Join(store.Delete(ctx, processed), store.Delete(ctx, original))
+}
+
+// StoreQuarantined keeps the processed image and original file of an upload under its
+// QuarantineKeys until the scanner clears them. They are stored uncacheable, as they may never be
+// served.
+func StoreQuarantined(ctx context.Context, store uploads.Driver, id uuid.UUID, contentType string, img, original []byte) error {
+	processed, orig := QuarantineKeys(id)
+	if err := store.PutStream(ctx, orig, "application/octet-stream", bytes.NewReader(original)); err != nil {
+		return err
+	}
+	if err := store.PutStream(ctx, processed, contentType, bytes.NewReader(img)); err != nil {
+		store.Delete(ctx, orig)
+		return err
+	}
+	return nil
+}
+
+// GetUpload retrieves an upload of a user.
+func GetUpload(ctx context.Context, gormDB *gorm.DB, userID, id uuid.UUID) (*Upload, error) {
+	var upload Upload
+	if err := gormDB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&upload).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Upload not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch upload")
+	}
+	return &upload, nil
+}
@@ -3,13 +3,14 @@ package models
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/cache"
 	storage "github.com/mnuddindev/devpulse/pkg/redis"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 type User struct {
@@ -20,15 +21,27 @@ type User struct {
 
 	Username        string    `gorm:"size:255;not null;unique" json:"username" validate:"required,min=3,max=255,alphanum"`
 	Email           string    `gorm:"size:100;not null;unique" json:"email" validate:"required,email"`
-	Password        string    `gorm:"size:255;not null" json:"password" validate:"required,min=6"`
-	OTP             string    `gorm:"type:text;not null" json:"otp"`
+	Password        string    `gorm:"size:255;not null" json:"-" validate:"required,min=6"`
+	Passwordless    bool      `gorm:"not null;default:false" json:"passwordless"`
+	OTP             string    `gorm:"type:text;not null" json:"-"`
 	IsActive        bool      `gorm:"default:false" json:"is_active"`
 	IsEmailVerified bool      `gorm:"default:false" json:"is_email_verified"`
 	RoleID          uuid.UUID `gorm:"type:uuid;not null" json:"role_id"`
 	Role            Role      `gorm:"foreignKey:RoleID" json:"role"`
 
-	PreviousPasswords  string    `gorm:"type:text" json:"previous_passwords"`
-	LastPasswordChange time.Time `gorm:"default:current_timestamp"`
+	Status         string     `gorm:"size:20;not null;default:'active';index" json:"status"`
+	StatusReason   string     `gorm:"size:255" json:"status_reason"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+
+	PreviousPasswords  string     `gorm:"type:text" json:"-"`
+	LastPasswordChange time.Time  `gorm:"default:current_timestamp" json:"-"`
+	LastLoginAt        *time.Time `json:"last_login_at"`
+	LastLoginIP        string     `gorm:"size:45" json:"last_login_ip"`
+	TokenVersion       int        `gorm:"not null;default:0" json:"token_version"`
+
+	TwoFactorEnabled     bool   `gorm:"default:false" json:"two_factor_enabled"`
+	TwoFactorSecret      string `gorm:"size:255" json:"-"`
+	TwoFactorBackupCodes string `gorm:"type:text" json:"-"`
 
 	Profile struct {
 		Name               string `gorm:"size:100" json:"name" validate:"omitempty,max=100"`
@@ -54,6 +67,8 @@ type User struct {
 		SiteNavbar      string `gorm:"size:20;default:'fixed'" json:"site_navbar" validate:"oneof=fixed static"`
 		ContentEditor   string `gorm:"size:20;default:'rich'" json:"content_editor" validate:"oneof=rich basic"`
 		ContentMode     int    `gorm:"default:1" json:"content_mode" validate:"oneof=1 2 3 4 5"`
+		// Locale is the language notifications and emails are written in.
+		Locale string `gorm:"size:10;not null;default:'en'" json:"locale"`
 	} `gorm:"embedded"`
 
 	Stats struct {
@@ -75,6 +90,46 @@ type User struct {
 	NotificationPreferences NotificationPreferences `gorm:"foreignKey:UserID;references:ID" json:"notification_preferences"`
 }
 
+// UserSummary is the public projection of a user used in listings and directories.
+type UserSummary struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Name      string    `json:"name"`
+	AvatarURL string    `json:"avatar_url"`
+	Bio       string    `json:"bio"`
+	JobTitle  string    `json:"job_title"`
+	Location  string    `json:"location"`
+	Skills    string    `json:"skills"`
+	Interests string    `json:"interests"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Summary returns the public summary of a user, the shape in which users appear inside other
+// responses.
+func (u *User) Summary() UserSummary {
+	return UserSummary{
+		ID:        u.ID,
+		Username:  u.Username,
+		Name:      u.Profile.Name,
+		AvatarURL: u.Profile.AvatarURL,
+		Bio:       u.Profile.Bio,
+		JobTitle:  u.Profile.JobTitle,
+		Location:  u.Profile.Location,
+		Skills:    u.Profile.Skills,
+		Interests: u.Profile.Interests,
+		LastSeen:  u.Stats.LastSeen,
+	}
+}
+
+// Summaries returns the public summaries of users.
+func Summaries(users []User) []UserSummary {
+	summaries := make([]UserSummary, len(users))
+	for i := range users {
+		summaries[i] = users[i].Summary()
+	}
+	return summaries
+}
+
 type UpdateUserRequest struct {
 	Username *string `json:"username" validate:"omitempty,min=3,max=255,alphanum"`
 	Email    *string `json:"email" validate:"omitempty,email,max=100"`
@@ -121,7 +176,7 @@ func NewUser(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, use
 	}
 
 	var memberRole Role
-	if err := db.WithContext(ctx).Where("name = ?", "member").First(&memberRole).Error; err != nil {
+	if err := db.WithContext(ctx).Where("name = ?", RoleMember).First(&memberRole).Error; err != nil {
 		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Default 'member' not found!!")
 	}
 
@@ -146,78 +201,199 @@ func NewUser(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, use
 		opt(u)
 	}
 
+	// The user is not cached here: callers create it inside transactions that may roll back
 	if err := db.WithContext(ctx).Create(u).Error; err != nil {
 		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create user in database")
 	}
 
-	userJSON, _ := json.Marshal(u)
-	key := "user:" + u.ID.String()
-	if err := rclient.Set(ctx, key, userJSON, 10*time.Minute).Err(); err != nil {
-		logger.Default.Warn(ctx, "Failed to cache user in Redis: %v", err)
-	}
+	return u, nil
+}
 
+// RegisterUser creates an account awaiting activation. The user and the outbox event that mails
+// its activation code are written in one transaction, so either both exist or neither does. A
+// non-empty invitation code is redeemed in the same transaction, and an unusable one fails it.
+func RegisterUser(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, username, email, password, otp, token, invitation string, opts ...UserOption) (*User, error) {
+	var u *User
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		u, err = NewUser(ctx, rclient, tx, username, email, password, otp, opts...)
+		if err != nil {
+			return err
+		}
+		if invitation != "" {
+			if err := redeemInvitation(tx, invitation, u.ID); err != nil {
+				return err
+			}
+		}
+		return EnqueueOutbox(ctx, tx, OutboxUserRegistered, UserRegisteredEvent{
+			UserID:   u.ID,
+			Email:    u.Email,
+			Username: u.Username,
+			Token:    token,
+			OTP:      otp,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	cache.ForgetMissing(ctx, rclient, cache.MissingUsers)
 	return u, nil
 }
 
-// GetUser retrieves a user by condition, with optional preloading of relationships.
-func GetUserBy(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, condition string, args []interface{}, preload ...string) (*User, error) {
+// UserPreload selects the relations a user lookup loads along with the account.
+type UserPreload int
+
+const (
+	// PreloadFull loads every relation, as the record cached under cache.UserKey carries.
+	PreloadFull UserPreload = iota
+	// PreloadMinimal loads the account row alone, in a single query.
+	PreloadMinimal
+	// PreloadAuth loads the account with its role, enough to sign it in. Permissions are read
+	// from the role's permission snapshot rather than preloaded.
+	PreloadAuth
+	// PreloadPublic loads the account with its role and badges, what the public profile shows.
+	PreloadPublic
+)
+
+// GetUserBy retrieves a user by condition with the relations of preload, all of them when it is
+// left out. Lookups that find nothing are cached briefly, so probing for unknown emails or
+// usernames does not reach the database on every request.
+func GetUserBy(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, condition string, args []interface{}, preload ...UserPreload) (*User, error) {
+	lookup := cache.LookupKey(condition, args...)
+	missing, epoch := cache.Missing(ctx, redisClient, cache.MissingUsers, lookup)
+	if missing {
+		return nil, utils.NewError(utils.ErrNotFound.Code, "User not found")
+	}
+
+	profile := PreloadFull
+	if len(preload) > 0 {
+		profile = preload[0]
+	}
+	query := gormDB.WithContext(ctx)
+	switch profile {
+	case PreloadMinimal:
+	case PreloadAuth:
+		query = query.Preload("Role")
+	case PreloadPublic:
+		query = query.Preload("Role").Preload("Badges")
+	default:
+		query = withUserRelations(query)
+	}
+
 	var u User
-	query := gormDB.WithContext(ctx).Where(condition, args...)
-	query = query.Preload("Notifications").
+	if err := query.Where(condition, args...).First(&u).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get user")
+	}
+
+	if u.ID == uuid.Nil {
+		cache.MarkMissing(ctx, redisClient, cache.MissingUsers, lookup, epoch, redisClient.TTL("missing"))
+		return nil, utils.NewError(utils.ErrNotFound.Code, "User not found")
+	}
+
+	return &u, nil
+}
+
+// withUserRelations preloads the relations a full user record carries, as cached under
+// cache.UserKey.
+func withUserRelations(query *gorm.DB) *gorm.DB {
+	return query.Preload("Notifications").
 		Preload("Badges").
 		Preload("Followers").
 		Preload("Following").
-		Preload("NotificationPreferences", func(db *gorm.DB) *gorm.DB {
-			return db.Where("notification_preferences.user_id = ?", u.ID)
-		}).
+		Preload("NotificationPreferences").
 		Preload("Role").
 		Preload("Role.Permissions")
-	if err := query.First(&u).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, utils.NewError(utils.ErrNotFound.Code, "User not found")
-		}
-		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get user")
+}
+
+// GetUsersByID retrieves full user records by ID. Cached records are read in one round trip and
+// the rest are loaded together in one preloaded query; users that do not exist are left out.
+func GetUsersByID(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, ids ...uuid.UUID) (map[uuid.UUID]*User, error) {
+	keys := make([]string, len(ids))
+	byKey := make(map[string]uuid.UUID, len(ids))
+	for i, id := range ids {
+		keys[i] = cache.UserKey(id)
+		byKey[keys[i]] = id
 	}
 
-	if u.ID == uuid.Nil {
-		return nil, utils.NewError(utils.ErrNotFound.Code, "User not found")
+	found, err := cache.FetchMany(ctx, redisClient, keys, redisClient.TTL("user"), func(ctx context.Context, missing []string) (map[string]*User, error) {
+		missingIDs := make([]uuid.UUID, 0, len(missing))
+		for _, key := range missing {
+			missingIDs = append(missingIDs, byKey[key])
+		}
+		var users []User
+		if err := withUserRelations(gormDB.WithContext(ctx)).Where("id IN ?", missingIDs).Find(&users).Error; err != nil {
+			return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get users")
+		}
+		loaded := make(map[string]*User, len(users))
+		for i := range users {
+			loaded[cache.UserKey(users[i].ID)] = &users[i]
+		}
+		return loaded, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &u, nil
+	users := make(map[uuid.UUID]*User, len(found))
+	for _, u := range found {
+		users[u.ID] = u
+	}
+	return users, nil
 }
 
 // GetUsers retrieves multiple users with pagination and optional filters.
 func GetUsers(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, page, limit int, filters ...string) ([]User, error) {
-	key := "users:page:" + string(page) + ":limit:" + string(limit)
-	if cached, err := redisClient.Get(ctx, key).Result(); err == nil {
+	key := fmt.Sprintf("users:page:%d:limit:%d", page, limit)
+	return cache.Fetch(ctx, redisClient, key, redisClient.TTL("users"), func(ctx context.Context) ([]User, []string, error) {
 		var users []User
-		if err := json.Unmarshal([]byte(cached), &users); err == nil {
-			return users, nil
+		query := gormDB.WithContext(ctx).Offset((page - 1) * limit).Limit(limit)
+		for _, f := range filters {
+			query = query.Where(f)
 		}
-	}
+		if err := query.Find(&users).Error; err != nil {
+			return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get users")
+		}
+		return users, nil, nil
+	})
+}
 
-	var users []User
-	query := gormDB.WithContext(ctx).Offset((page - 1) * limit).Limit(limit)
-	for _, f := range filters {
-		query = query.Where(f)
-	}
-	if err := query.Find(&users).Error; err != nil {
-		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get users")
-	}
+// GetActiveUsers retrieves public summaries of users seen within the given window,
+// most recently active first, optionally filtered by a skill or interest tag. Suspended and
+// banned accounts are left out; a page is tagged with the users on it, so restricting one of them
+// drops the page.
+func GetActiveUsers(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, window time.Duration, tag string, page, limit int) ([]UserSummary, error) {
+	key := fmt.Sprintf("active_users:window:%d:tag:%s:page:%d:limit:%d", int64(window.Seconds()), tag, page, limit)
+	return cache.Fetch(ctx, redisClient, key, redisClient.TTL("active_users"), func(ctx context.Context) ([]UserSummary, []string, error) {
+		query := gormDB.WithContext(ctx).Model(&User{}).
+			Select("id, username, name, avatar_url, bio, job_title, location, skills, interests, last_seen").
+			Where("is_active = ? AND is_email_verified = ? AND status = ?", true, true, UserStatusActive).
+			Where("last_seen >= ?", time.Now().Add(-window))
+		if tag != "" {
+			tagJSON, _ := json.Marshal([]string{tag})
+			query = query.Where("(skills @> ?::jsonb OR interests @> ?::jsonb)", string(tagJSON), string(tagJSON))
+		}
 
-	usersJSON, _ := json.Marshal(users)
-	redisClient.Set(ctx, key, usersJSON, 10*time.Minute)
-	return users, nil
+		summaries := []UserSummary{}
+		if err := query.Order("last_seen DESC").Offset((page - 1) * limit).Limit(limit).Scan(&summaries).Error; err != nil {
+			return nil, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get active users")
+		}
+		tags := make([]string, len(summaries))
+		for i, s := range summaries {
+			tags[i] = cache.UserTag(s.ID)
+		}
+		return summaries, tags, nil
+	})
 }
 
 // UpdateUser updates a user’s fields and refreshes cache.
 func UpdateUser(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, id uuid.UUID, opts ...UserOption) (*User, error) {
 	tx := gormDB.WithContext(ctx).Begin()
-	u, err := GetUserBy(ctx, redisClient, gormDB, "id = ?", []interface{}{id}, "")
+	u, err := GetUserBy(ctx, redisClient, gormDB, "id = ?", []interface{}{id})
 	if err != nil {
 		return nil, err
 	}
 
+	username, email := u.Username, u.Email
 	for _, opt := range opts {
 		opt(u)
 	}
@@ -226,7 +402,16 @@ func UpdateUser(ctx context.Context, redisClient *storage.RedisClient, gormDB *g
 		tx.Rollback()
 		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update user")
 	}
+	if err := EnqueueOutbox(ctx, tx, OutboxUserUpdated, UserChangedEvent{UserID: u.ID}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 	tx.Commit()
+	cache.InvalidateUser(ctx, redisClient, u.ID)
+	// Lookups by the new username or email may have been recorded as missing
+	if u.Username != username || u.Email != email {
+		cache.ForgetMissing(ctx, redisClient, cache.MissingUsers)
+	}
 
 	return u, nil
 }
@@ -248,34 +433,43 @@ func DeleteUser(ctx context.Context, redisClient *storage.RedisClient, gormDB *g
 		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to delete user")
 	}
 
+	if err := EnqueueOutbox(ctx, tx, OutboxUserUpdated, UserChangedEvent{UserID: id}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to commit transaction")
 	}
 
-	key := "user:" + id.String()
-	redisClient.Del(ctx, key)
+	cache.InvalidateUser(ctx, redisClient, id)
 
 	return nil
 }
 
-// UpdateUserStats updates user statistics.
-func UpdateUserStats(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, options ...UserOption) error {
-	user, err := GetUserBy(ctx, redisClient, gormDB, "id = ?", []interface{}{userID}, "")
-	if err != nil {
-		return utils.WrapError(err, utils.ErrNotFound.Code, "User not found")
-	}
+// statColumns are the counters of User.Stats that AdjustUserStat may move.
+var statColumns = map[string]bool{
+	"posts_count":     true,
+	"comments_count":  true,
+	"likes_count":     true,
+	"bookmarks_count": true,
+	"tag_count":       true,
+	"followers_count": true,
+	"following_count": true,
+	"reactions_count": true,
+}
 
-	for _, opt := range options {
-		opt(user)
+// AdjustUserStat moves one of a user's counters, such as posts_count, by delta. The counter is
+// updated in SQL so concurrent writers never overwrite each other; callers run it in the
+// transaction of the change being counted and drop the cached user once that committed.
+func AdjustUserStat(ctx context.Context, tx *gorm.DB, userID uuid.UUID, column string, delta int) error {
+	if !statColumns[column] {
+		return utils.NewError(utils.ErrInternalServerError.Code, "Unknown user stat "+column)
 	}
-
-	if err := gormDB.WithContext(ctx).Model(&user).Update("stats", user.Stats).Error; err != nil {
+	expr := gorm.Expr("GREATEST("+column+" + ?, 0)", delta)
+	if err := tx.WithContext(ctx).Model(&User{}).Where("id = ?", userID).UpdateColumn(column, expr).Error; err != nil {
 		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update user stats")
 	}
-
-	userData, _ := json.Marshal(user)
-	redisClient.Set(ctx, "user:"+userID.String(), userData, 10*time.Minute)
-
 	return nil
 }
 
@@ -292,20 +486,25 @@ func (u *User) VerifyEmail(ctx context.Context, redisClient *storage.RedisClient
 	}
 
 	userJSON, _ := json.Marshal(u)
-	key := "user:" + u.ID.String()
-	redisClient.Set(ctx, key, userJSON, 10*time.Minute)
+	key := cache.UserKey(u.ID)
+	redisClient.Set(ctx, key, userJSON, redisClient.TTL("user"))
 	return nil
 }
 
 // FollowUser adds a follow relationship.
 func (u *User) FollowUser(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, username string) error {
-	followee, err := GetUserBy(ctx, redisClient, gormDB, "username = ?", []interface{}{username}, "")
+	followee, err := GetUserBy(ctx, redisClient, gormDB, "username = ?", []interface{}{username}, PreloadMinimal)
 	if err != nil {
 		return err
 	}
 	if u.ID == followee.ID {
 		return utils.NewError(utils.ErrBadRequest.Code, "Cannot follow yourself")
 	}
+	if blocked, err := HasBlocked(ctx, gormDB, followee.ID, u.ID); err != nil {
+		return err
+	} else if blocked {
+		return errBlocked("follow")
+	}
 
 	// Check if already following before appending
 	var existingFollow []*User
@@ -321,21 +520,28 @@ func (u *User) FollowUser(ctx context.Context, redisClient *storage.RedisClient,
 	}
 
 	// Append only if not already following
-	if err := gormDB.WithContext(ctx).Model(u).Association("Following").Append(followee); err != nil {
-		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to follow user")
+	err = gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(u).Association("Following").Append(followee); err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to follow user")
+		}
+		return EmitWebhookEvent(ctx, tx, WebhookUserFollowed, []uuid.UUID{followee.ID}, followEventData(u, followee))
+	})
+	if err != nil {
+		return err
 	}
 
 	// Update Redis cache
 	userJSON, _ := json.Marshal(u)
-	key := "user:" + u.ID.String()
-	redisClient.Set(ctx, key, userJSON, 10*time.Minute)
+	key := cache.UserKey(u.ID)
+	redisClient.Set(ctx, key, userJSON, redisClient.TTL("user"))
+	cache.Invalidate(ctx, redisClient, cache.UserTag(u.ID), cache.UserTag(followee.ID))
 
 	return nil
 }
 
 // UnfollowUser removes a user from the following list.
 func (u *User) UnfollowUser(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, username string) error {
-	followee, err := GetUserBy(ctx, redisClient, gormDB, "username = ?", []interface{}{username}, "")
+	followee, err := GetUserBy(ctx, redisClient, gormDB, "username = ?", []interface{}{username}, PreloadMinimal)
 	if err != nil {
 		return err
 	}
@@ -345,55 +551,154 @@ func (u *User) UnfollowUser(ctx context.Context, redisClient *storage.RedisClien
 	}
 
 	userJSON, _ := json.Marshal(u)
-	key := "user:" + u.ID.String()
-	redisClient.Set(ctx, key, userJSON, 10*time.Minute)
+	key := cache.UserKey(u.ID)
+	redisClient.Set(ctx, key, userJSON, redisClient.TTL("user"))
+	cache.Invalidate(ctx, redisClient, cache.UserTag(u.ID), cache.UserTag(followee.ID))
 	return nil
 }
 
+// IsFollowing reports whether the user follows the user with the given ID.
+func (u *User) IsFollowing(ctx context.Context, gormDB *gorm.DB, followeeID uuid.UUID) (bool, error) {
+	var count int64
+	if err := gormDB.WithContext(ctx).Table("user_followers").
+		Where("follower_id = ? AND following_id = ?", u.ID, followeeID).
+		Count(&count).Error; err != nil {
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Database error checking follow status")
+	}
+	return count > 0, nil
+}
+
+// GetFollowsPage returns a page of the users following the user, or with followers false of the
+// users they follow, ordered by username.
+func GetFollowsPage(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, userID uuid.UUID, followers bool, p utils.Pagination) (utils.Page[UserSummary], error) {
+	relation, self, other := "following", "follower_id", "following_id"
+	if followers {
+		relation, self, other = "followers", "following_id", "follower_id"
+	}
+	key := cache.FollowsKey(userID, relation, p.CacheKey())
+	return cache.Fetch(ctx, redisClient, key, redisClient.TTL("user_follows"), func(ctx context.Context) (utils.Page[UserSummary], []string, error) {
+		query := gormDB.WithContext(ctx).Model(&User{}).
+			Joins("JOIN user_followers ON user_followers."+other+" = users.id").
+			Where("user_followers."+self+" = ?", userID)
+		var total int64
+		if p.WithTotal {
+			if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+				return utils.Page[UserSummary]{}, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count "+relation)
+			}
+		}
+		if p.After != nil {
+			query = query.Where("users.username > ?", p.After.Key)
+		}
+
+		summaries := []UserSummary{}
+		if err := query.Select("users.id, users.username, users.name, users.avatar_url, users.bio, users.job_title, users.location, users.skills, users.interests, users.last_seen").
+			Order("users.username").Limit(p.Limit + 1).Scan(&summaries).Error; err != nil {
+			return utils.Page[UserSummary]{}, nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to get "+relation)
+		}
+		page := utils.NewPage(summaries, p, func(s UserSummary) utils.Cursor {
+			return utils.Cursor{Key: s.Username, ID: s.ID.String()}
+		})
+		if p.WithTotal {
+			page.Total = &total
+		}
+		return page, []string{cache.UserTag(userID)}, nil
+	})
+}
+
+// SetFollowState idempotently makes the user follow or unfollow the given username.
+// It reports whether the relationship changed; counters are only touched on change.
+func (u *User) SetFollowState(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB, username string, following bool) (*User, bool, error) {
+	followee, err := GetUserBy(ctx, redisClient, gormDB, "username = ?", []interface{}{username}, PreloadMinimal)
+	if err != nil {
+		return nil, false, err
+	}
+	if u.ID == followee.ID {
+		return nil, false, utils.NewError(utils.ErrBadRequest.Code, "Cannot follow yourself")
+	}
+	if following {
+		if blocked, err := HasBlocked(ctx, gormDB, followee.ID, u.ID); err != nil {
+			return nil, false, err
+		} else if blocked {
+			return nil, false, errBlocked("follow")
+		}
+	}
+
+	changed := false
+	err = gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var res *gorm.DB
+		if following {
+			res = tx.Exec("INSERT INTO user_followers (follower_id, following_id) VALUES (?, ?) ON CONFLICT DO NOTHING", u.ID, followee.ID)
+		} else {
+			res = tx.Exec("DELETE FROM user_followers WHERE follower_id = ? AND following_id = ?", u.ID, followee.ID)
+		}
+		if res.Error != nil {
+			return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to update follow state")
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		changed = true
+
+		delta := gorm.Expr("following_count + 1")
+		followerDelta := gorm.Expr("followers_count + 1")
+		if !following {
+			delta = gorm.Expr("GREATEST(following_count - 1, 0)")
+			followerDelta = gorm.Expr("GREATEST(followers_count - 1, 0)")
+		}
+		if err := tx.Model(&User{}).Where("id = ?", u.ID).UpdateColumn("following_count", delta).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update following count")
+		}
+		if err := tx.Model(&User{}).Where("id = ?", followee.ID).UpdateColumn("followers_count", followerDelta).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update followers count")
+		}
+		if !following {
+			return nil
+		}
+		return EmitWebhookEvent(ctx, tx, WebhookUserFollowed, []uuid.UUID{followee.ID}, followEventData(u, followee))
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if changed {
+		cache.InvalidateUser(ctx, redisClient, u.ID)
+		cache.InvalidateUser(ctx, redisClient, followee.ID)
+	}
+	return followee, changed, nil
+}
+
+// followEventData is the payload of a user.followed webhook event.
+func followEventData(follower, followee *User) map[string]interface{} {
+	return map[string]interface{}{
+		"follower": map[string]interface{}{
+			"id":         follower.ID,
+			"username":   follower.Username,
+			"name":       follower.Profile.Name,
+			"avatar_url": follower.Profile.AvatarURL,
+		},
+		"followee": map[string]interface{}{
+			"id":       followee.ID,
+			"username": followee.Username,
+		},
+		"followed_at": time.Now(),
+	}
+}
+
 // UpdateLastSeen refreshes the user’s last seen timestamp.
 func (u *User) UpdateLastSeen(ctx context.Context, redisClient *storage.RedisClient, gormDB *gorm.DB) error {
 	u.Stats.LastSeen = time.Now()
-	if err := gormDB.WithContext(ctx).Save(u).Error; err != nil {
+	// Only touch last_seen so a stale in-memory user cannot overwrite a concurrent profile update.
+	if err := gormDB.WithContext(ctx).Model(&User{}).Where("id = ?", u.ID).UpdateColumn("last_seen", u.Stats.LastSeen).Error; err != nil {
 		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update last seen")
 	}
-
-	userJSON, _ := json.Marshal(u)
-	key := "user:" + u.ID.String()
-	redisClient.Set(ctx, key, userJSON, 10*time.Minute)
 	return nil
 }
 
 // HasPermission checks if the user has a permission.
 func (u *User) HasPermission(ctx context.Context, rclient *storage.RedisClient, db *gorm.DB, permission string) bool {
-	cacheKey := "perms:role:" + u.RoleID.String()
-	if cachedPerms, err := rclient.Get(ctx, cacheKey).Result(); err == nil {
-		var perms []string
-		if json.Unmarshal([]byte(cachedPerms), &perms) == nil {
-			for _, p := range perms {
-				if p == permission {
-					return true
-				}
-			}
-			return false
-		}
-	}
-
-	var role Role
-	if err := db.WithContext(ctx).Preload("Permissions").Where("id = ?", u.RoleID).First(&role).Error; err != nil {
+	snapshot, err := GetPermissionSnapshot(ctx, rclient, db, u.RoleID)
+	if err != nil {
 		return false
 	}
-
-	for _, p := range role.Permissions {
-		if p.Name == permission {
-			perms := make([]string, len(role.Permissions))
-			for i, perm := range role.Permissions {
-				perms[i] = perm.Name
-			}
-			if permsJSON, err := json.Marshal(perms); err == nil {
-				rclient.Set(ctx, cacheKey, permsJSON, 10*time.Minute)
-			}
-			return true
-		}
-	}
-	return false
+	return snapshot.Has(permission)
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestSecretsAreNotMarshalled guards the json:"-" tags of the fields that must never leave the
+// server: every model that is returned or cached as JSON is marshalled with a marker in each of
+// its secret fields, and no marker may come out.
+func TestSecretsAreNotMarshalled(t *testing.T) {
+	var user User
+	user.Password = "secret-password-hash"
+	user.OTP = "secret-otp"
+	user.PreviousPasswords = "secret-previous-passwords"
+	user.TwoFactorSecret = "secret-totp"
+	user.TwoFactorBackupCodes = "secret-backup-codes"
+
+	cases := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "user", value: user},
+		{name: "user list", value: []User{user}},
+		{name: "api key", value: APIKey{Name: "ci", TokenHash: "secret-token-hash"}},
+		{name: "webhook", value: Webhook{URL: "https://example.com/hook", Secret: "secret-webhook"}},
+		{name: "push subscription", value: PushSubscription{Endpoint: "https://push.example.com", P256dh: "secret-p256dh", Auth: "secret-auth"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := json.Marshal(tc.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(raw), "secret-") {
+				t.Fatalf("secret marshalled: %s", raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,470 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Webhook events integrators can subscribe to.
+const (
+	WebhookPostPublished  = "post.published"
+	WebhookCommentCreated = "comment.created"
+	WebhookUserFollowed   = "user.followed"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to.
+var WebhookEvents = []string{WebhookPostPublished, WebhookCommentCreated, WebhookUserFollowed}
+
+// Webhook delivery states.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+const (
+	// MaxWebhooksPerUser caps how many endpoints one user can register.
+	MaxWebhooksPerUser = 10
+	// WebhookMaxAttempts is how often a delivery is tried before it is given up.
+	WebhookMaxAttempts = 10
+	// webhookBaseBackoff is the wait after the first failed attempt; it doubles with every attempt
+	// up to webhookMaxBackoff.
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+	// webhookLease is how long a claimed delivery is hidden from other workers while it is sent.
+	webhookLease = 2 * time.Minute
+	// webhookDisableAfter is how many deliveries in a row may be given up before the webhook is
+	// deactivated.
+	webhookDisableAfter = 5
+	// WebhookDeliveryRetention is how long finished deliveries stay in the delivery log.
+	WebhookDeliveryRetention = 30 * 24 * time.Hour
+)
+
+// Webhook is an endpoint that receives signed event deliveries. A user's webhook receives the
+// events concerning them; a global webhook, which only admins can register, receives every event.
+type Webhook struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID         uuid.UUID       `gorm:"type:uuid;not null;index" json:"user_id"`
+	URL            string          `gorm:"size:500;not null" json:"url"`
+	Secret         string          `gorm:"size:255;not null" json:"-"` // encrypted
+	Events         json.RawMessage `gorm:"type:jsonb;not null;default:'[]'" json:"events"`
+	Global         bool            `gorm:"default:false;index" json:"global"`
+	Active         bool            `gorm:"default:true" json:"active"`
+	FailureCount   int             `gorm:"default:0" json:"failure_count"`
+	LastDeliveryAt *time.Time      `json:"last_delivery_at"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// WebhookDelivery is one event sent, or still to be sent, to a webhook, with the outcome of its
+// latest attempt.
+type WebhookDelivery struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	WebhookID      uuid.UUID       `gorm:"type:uuid;not null;index:idx_webhook_delivery_log" json:"webhook_id"`
+	Event          string          `gorm:"size:50;not null" json:"event"`
+	Payload        json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string          `gorm:"size:20;not null;default:'pending';index:idx_webhook_delivery_due" json:"status"`
+	Attempts       int             `gorm:"default:0" json:"attempts"`
+	NextAttemptAt  time.Time       `gorm:"not null;index:idx_webhook_delivery_due" json:"next_attempt_at"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	ResponseBody   string          `gorm:"size:1024" json:"response_body,omitempty"`
+	Error          string          `gorm:"size:255" json:"error,omitempty"`
+	DurationMs     int64           `json:"duration_ms"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `gorm:"autoCreateTime;index:idx_webhook_delivery_log" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// WebhookOption configures a Webhook.
+type WebhookOption func(*Webhook)
+
+// WebhookSender sends a delivery to its webhook; it is supplied by the API layer, which owns the
+// key the webhook secrets are encrypted with.
+type WebhookSender func(ctx context.Context, hook *Webhook, delivery *WebhookDelivery) (*utils.WebhookResponse, error)
+
+// webhookEnvelope is the body every delivery of an event carries.
+type webhookEnvelope struct {
+	ID        uuid.UUID   `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// EventList returns the events the webhook subscribes to.
+func (w *Webhook) EventList() []string {
+	events := []string{}
+	json.Unmarshal(w.Events, &events)
+	return events
+}
+
+// ValidWebhookURL reports whether raw is an absolute https URL without credentials.
+func ValidWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "https" && u.Host != "" && u.User == nil
+}
+
+// WebhookEventsJSON validates a subscription and returns it deduplicated, as stored.
+func WebhookEventsJSON(events []string) (json.RawMessage, error) {
+	seen := map[string]bool{}
+	valid := []string{}
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if !utils.Contains(WebhookEvents, event) {
+			return nil, utils.NewError(utils.ErrBadRequest.Code, "Unknown webhook event: "+event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			valid = append(valid, event)
+		}
+	}
+	if len(valid) == 0 {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Subscribe to at least one event")
+	}
+	eventsJSON, _ := json.Marshal(valid)
+	return eventsJSON, nil
+}
+
+// CreateWebhook registers a webhook. Its secret must already be encrypted.
+func CreateWebhook(ctx context.Context, gormDB *gorm.DB, hook *Webhook) error {
+	if hook.UserID == uuid.Nil || hook.Secret == "" || len(hook.Events) == 0 {
+		return utils.NewError(utils.ErrBadRequest.Code, "Required fields missing: user_id, secret, events")
+	}
+	if !ValidWebhookURL(hook.URL) {
+		return utils.NewError(utils.ErrBadRequest.Code, "Webhook URL must be an https URL")
+	}
+
+	return gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the owner serializes concurrent registrations so the cap holds
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", hook.UserID).First(&User{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NewError(utils.ErrNotFound.Code, "User not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch user")
+		}
+		var count int64
+		if err := tx.Model(&Webhook{}).Where("user_id = ?", hook.UserID).Count(&count).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count webhooks")
+		}
+		if count >= MaxWebhooksPerUser {
+			return utils.NewError(utils.ErrConflict.Code, "Webhook limit reached")
+		}
+
+		hook.Active = true
+		hook.FailureCount = 0
+		if err := tx.Create(hook).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to create webhook")
+		}
+		return nil
+	})
+}
+
+// GetWebhook retrieves a webhook by ID.
+func GetWebhook(ctx context.Context, gormDB *gorm.DB, id uuid.UUID) (*Webhook, error) {
+	var hook Webhook
+	if err := gormDB.WithContext(ctx).Where("id = ?", id).First(&hook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewError(utils.ErrNotFound.Code, "Webhook not found")
+		}
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch webhook")
+	}
+	return &hook, nil
+}
+
+// GetUserWebhooks lists the webhooks a user registered, oldest first.
+func GetUserWebhooks(ctx context.Context, gormDB *gorm.DB, userID uuid.UUID) ([]Webhook, error) {
+	hooks := []Webhook{}
+	if err := gormDB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&hooks).Error; err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch webhooks")
+	}
+	return hooks, nil
+}
+
+// UpdateWebhook changes a webhook. Reactivating it clears its failure count.
+func UpdateWebhook(ctx context.Context, gormDB *gorm.DB, id uuid.UUID, opts ...WebhookOption) (*Webhook, error) {
+	var hook Webhook
+	err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&hook).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NewError(utils.ErrNotFound.Code, "Webhook not found")
+			}
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch webhook")
+		}
+		wasActive := hook.Active
+		for _, opt := range opts {
+			opt(&hook)
+		}
+		if !ValidWebhookURL(hook.URL) {
+			return utils.NewError(utils.ErrBadRequest.Code, "Webhook URL must be an https URL")
+		}
+		if hook.Active && !wasActive {
+			hook.FailureCount = 0
+		}
+		if err := tx.Save(&hook).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update webhook")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// DeleteWebhook removes a webhook and gives up its pending deliveries.
+func DeleteWebhook(ctx context.Context, gormDB *gorm.DB, id uuid.UUID) error {
+	return gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ?", id).Delete(&Webhook{})
+		if res.Error != nil {
+			return utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to delete webhook")
+		}
+		if res.RowsAffected == 0 {
+			return utils.NewError(utils.ErrNotFound.Code, "Webhook not found")
+		}
+		if err := tx.Model(&WebhookDelivery{}).
+			Where("webhook_id = ? AND status = ?", id, WebhookDeliveryPending).
+			Updates(map[string]interface{}{"status": WebhookDeliveryFailed, "error": "Webhook deleted"}).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to cancel webhook deliveries")
+		}
+		return nil
+	})
+}
+
+// GetWebhookDeliveriesPage retrieves a page of a webhook's delivery log, newest first; status
+// limits it to deliveries in that state.
+func GetWebhookDeliveriesPage(ctx context.Context, gormDB *gorm.DB, webhookID uuid.UUID, status string, p utils.Pagination) (utils.Page[WebhookDelivery], error) {
+	query := gormDB.WithContext(ctx).Model(&WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if p.WithTotal {
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return utils.Page[WebhookDelivery]{}, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to count webhook deliveries")
+		}
+	}
+	if p.After != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, p.After.Key)
+		if err != nil {
+			return utils.Page[WebhookDelivery]{}, utils.NewError(utils.ErrBadRequest.Code, "Invalid cursor")
+		}
+		id, err := uuid.Parse(p.After.ID)
+		if err != nil {
+			return utils.Page[WebhookDelivery]{}, utils.NewError(utils.ErrBadRequest.Code, "Invalid cursor")
+		}
+		query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	deliveries := []WebhookDelivery{}
+	if err := query.Order("created_at DESC, id DESC").Limit(p.Limit + 1).Find(&deliveries).Error; err != nil {
+		return utils.Page[WebhookDelivery]{}, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch webhook deliveries")
+	}
+	page := utils.NewPage(deliveries, p, func(d WebhookDelivery) utils.Cursor {
+		return utils.Cursor{Key: d.CreatedAt.Format(time.RFC3339Nano), ID: d.ID.String()}
+	})
+	if p.WithTotal {
+		page.Total = &total
+	}
+	return page, nil
+}
+
+// EmitWebhookEvent queues a delivery of an event to every active webhook subscribed to it: the
+// webhooks of the users it concerns and the global ones. Run it inside the transaction making the
+// change, so an event is only delivered once the change is committed.
+func EmitWebhookEvent(ctx context.Context, tx *gorm.DB, event string, ownerIDs []uuid.UUID, data interface{}) error {
+	subscribed, _ := json.Marshal([]string{event})
+	query := tx.WithContext(ctx).Model(&Webhook{}).Where("active = ? AND events @> ?::jsonb", true, string(subscribed))
+	if len(ownerIDs) > 0 {
+		query = query.Where("global = ? OR user_id IN ?", true, ownerIDs)
+	} else {
+		query = query.Where("global = ?", true)
+	}
+	var hookIDs []uuid.UUID
+	if err := query.Pluck("id", &hookIDs).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch webhooks")
+	}
+	if len(hookIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(webhookEnvelope{ID: uuid.New(), Event: event, CreatedAt: now, Data: data})
+	if err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to encode webhook payload")
+	}
+	deliveries := make([]WebhookDelivery, 0, len(hookIDs))
+	for _, id := range hookIDs {
+		deliveries = append(deliveries, WebhookDelivery{
+			WebhookID:     id,
+			Event:         event,
+			Payload:       payload,
+			Status:        WebhookDeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	if err := tx.WithContext(ctx).Create(&deliveries).Error; err != nil {
+		return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to queue webhook deliveries")
+	}
+	return nil
+}
+
+// DeliverDueWebhooks sends up to limit deliveries whose attempt is due and returns how many
+// succeeded. Deliveries are claimed with a lease first, so several workers never send the same
+// one; a delivery whose worker died is retried once the lease runs out.
+func DeliverDueWebhooks(ctx context.Context, gormDB *gorm.DB, limit int, send WebhookSender) (int, error) {
+	var due []WebhookDelivery
+	err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", WebhookDeliveryPending, now).
+			Order("next_attempt_at").Limit(limit).Find(&due).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to fetch due webhook deliveries")
+		}
+		if len(due) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(due))
+		for i, d := range due {
+			ids[i] = d.ID
+		}
+		if err := tx.Model(&WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(webhookLease)).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to claim webhook deliveries")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	hooks := map[uuid.UUID]*Webhook{}
+	succeeded := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		d := &due[i]
+		hook, ok := hooks[d.WebhookID]
+		if !ok {
+			hook, err = GetWebhook(ctx, gormDB, d.WebhookID)
+			if err != nil && !isNotFound(err) {
+				return succeeded, err
+			}
+			hooks[d.WebhookID] = hook
+		}
+		if hook == nil || !hook.Active {
+			if err := gormDB.WithContext(ctx).Model(d).Updates(map[string]interface{}{
+				"status": WebhookDeliveryFailed,
+				"error":  "Webhook is disabled",
+			}).Error; err != nil {
+				return succeeded, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update webhook delivery")
+			}
+			continue
+		}
+
+		res, sendErr := send(ctx, hook, d)
+		ok, err = recordWebhookAttempt(ctx, gormDB, hook, d, res, sendErr)
+		if err != nil {
+			return succeeded, err
+		}
+		if ok {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// recordWebhookAttempt stores the outcome of an attempt and schedules the next one with an
+// exponential backoff. A delivery given up counts against its webhook, which is deactivated after
+// webhookDisableAfter of them in a row.
+func recordWebhookAttempt(ctx context.Context, gormDB *gorm.DB, hook *Webhook, d *WebhookDelivery, res *utils.WebhookResponse, sendErr error) (bool, error) {
+	now := time.Now()
+	d.Attempts++
+	d.Error = ""
+	d.ResponseStatus, d.ResponseBody, d.DurationMs = 0, "", 0
+	if res != nil {
+		d.ResponseStatus = res.Status
+		d.ResponseBody = strings.ToValidUTF8(res.Body, "")
+		d.DurationMs = res.Duration.Milliseconds()
+	}
+	succeeded := sendErr == nil && res != nil && res.Status >= 200 && res.Status < 300
+	switch {
+	case succeeded:
+		d.Status = WebhookDeliverySucceeded
+		d.DeliveredAt = &now
+	case sendErr != nil:
+		d.Error = truncate(sendErr.Error(), 255)
+	default:
+		d.Error = "Endpoint answered with a non-2xx status"
+	}
+	if !succeeded {
+		if d.Attempts >= WebhookMaxAttempts {
+			d.Status = WebhookDeliveryFailed
+		} else {
+			backoff := webhookBaseBackoff << (d.Attempts - 1)
+			if backoff > webhookMaxBackoff || backoff <= 0 {
+				backoff = webhookMaxBackoff
+			}
+			d.NextAttemptAt = now.Add(backoff)
+		}
+	}
+
+	err := gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(d).Select("status", "attempts", "next_attempt_at", "response_status", "response_body", "error", "duration_ms", "delivered_at").
+			Updates(d).Error; err != nil {
+			return utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to update webhook delivery")
+		}
+		switch {
+		case succeeded:
+			hook.FailureCount = 0
+			hook.LastDeliveryAt = &now
+			return tx.Model(&Webhook{}).Where("id = ?", hook.ID).
+				Updates(map[string]interface{}{"failure_count": 0, "last_delivery_at": now}).Error
+		case d.Status == WebhookDeliveryFailed:
+			hook.FailureCount++
+			if hook.FailureCount >= webhookDisableAfter {
+				hook.Active = false
+			}
+			return tx.Model(&Webhook{}).Where("id = ?", hook.ID).
+				Updates(map[string]interface{}{"failure_count": hook.FailureCount, "active": hook.Active}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return false, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to record webhook attempt")
+	}
+	return succeeded, nil
+}
+
+// PurgeWebhookDeliveries drops finished deliveries older than WebhookDeliveryRetention and
+// returns how many were removed.
+func PurgeWebhookDeliveries(ctx context.Context, gormDB *gorm.DB) (int64, error) {
+	res := gormDB.WithContext(ctx).
+		Where("status <> ? AND created_at < ?", WebhookDeliveryPending, time.Now().Add(-WebhookDeliveryRetention)).
+		Delete(&WebhookDelivery{})
+	if res.Error != nil {
+		return 0, utils.WrapError(res.Error, utils.ErrInternalServerError.Code, "Failed to purge webhook deliveries")
+	}
+	return res.RowsAffected, nil
+}
+
+func isNotFound(err error) bool {
+	var appErr *utils.CustomError
+	return utils.As(err, &appErr) && appErr.Code == utils.ErrNotFound.Code
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"gorm.io/gorm"
+)
+
+type flagRepo struct {
+	db      *gorm.DB
+	rclient *storage.RedisClient
+}
+
+// NewFlagRepo returns the feature flag repository on db, caching in rclient.
+func NewFlagRepo(db *gorm.DB, rclient *storage.RedisClient) FlagRepo {
+	return &flagRepo{db: db, rclient: rclient}
+}
+
+func (r *flagRepo) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	return models.ListFeatureFlags(ctx, r.db)
+}
+
+func (r *flagRepo) Get(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	return models.GetFeatureFlag(ctx, r.db, key)
+}
+
+func (r *flagRepo) All(ctx context.Context) (map[string]*models.FeatureFlag, error) {
+	return models.GetFeatureFlags(ctx, r.rclient, r.db)
+}
+
+func (r *flagRepo) Create(ctx context.Context, actorID uuid.UUID, key string, u models.FeatureFlagUpdate) (*models.FeatureFlag, error) {
+	return models.CreateFeatureFlag(ctx, r.rclient, r.db, actorID, key, u)
+}
+
+func (r *flagRepo) Update(ctx context.Context, actorID uuid.UUID, key string, u models.FeatureFlagUpdate) (*models.FeatureFlag, *models.FeatureFlag, error) {
+	return models.UpdateFeatureFlag(ctx, r.rclient, r.db, actorID, key, u)
+}
+
+func (r *flagRepo) Delete(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	return models.DeleteFeatureFlag(ctx, r.rclient, r.db, key)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type notificationRepo struct {
+	db      *gorm.DB
+	rclient *storage.RedisClient
+}
+
+// NewNotificationRepo returns the notification repository on db, caching in rclient.
+func NewNotificationRepo(db *gorm.DB, rclient *storage.RedisClient) NotificationRepo {
+	return &notificationRepo{db: db, rclient: rclient}
+}
+
+func (r *notificationRepo) Create(ctx context.Context, userID uuid.UUID, notifType, message string) (*models.Notification, error) {
+	return models.NewNotification(ctx, r.rclient, r.db, userID, notifType, message)
+}
+
+func (r *notificationRepo) CreateMany(ctx context.Context, inputs []models.NotificationInput) ([]models.Notification, error) {
+	return models.NewNotifications(ctx, r.rclient, r.db, inputs)
+}
+
+func (r *notificationRepo) Get(ctx context.Context, userID, id uuid.UUID) (*models.Notification, error) {
+	return models.GetUserNotification(ctx, r.rclient, r.db, userID, id)
+}
+
+func (r *notificationRepo) List(ctx context.Context, userID uuid.UUID, p utils.Pagination, unreadOnly bool) (utils.Page[models.Notification], error) {
+	return models.GetNotificationsPage(ctx, r.rclient, r.db, userID, p, unreadOnly)
+}
+
+func (r *notificationRepo) MarkRead(ctx context.Context, userID, id uuid.UUID, isRead bool) (*models.Notification, error) {
+	return models.UpdateNotification(ctx, r.rclient, r.db, userID, id, isRead)
+}
+
+func (r *notificationRepo) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return models.MarkAllNotificationsRead(ctx, r.rclient, r.db, userID)
+}
+
+func (r *notificationRepo) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	return models.DeleteNotification(ctx, r.rclient, r.db, userID, id)
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type permissionRepo struct {
+	db      *gorm.DB
+	rclient *storage.RedisClient
+}
+
+// NewPermissionRepo returns the permission repository on db, caching in rclient.
+func NewPermissionRepo(db *gorm.DB, rclient *storage.RedisClient) PermissionRepo {
+	return &permissionRepo{db: db, rclient: rclient}
+}
+
+func (r *permissionRepo) List(ctx context.Context, p utils.Pagination) (utils.Page[models.Permission], error) {
+	return models.ListPermissions(ctx, r.rclient, r.db, p)
+}
+
+func (r *permissionRepo) Get(ctx context.Context, id uuid.UUID) (*models.Permission, error) {
+	return models.GetPermission(ctx, r.rclient, r.db, id)
+}
+
+func (r *permissionRepo) Create(ctx context.Context, name string) (*models.Permission, error) {
+	return models.NewPermission(ctx, r.rclient, r.db, name)
+}
+
+func (r *permissionRepo) Update(ctx context.Context, id uuid.UUID, name string) (*models.Permission, error) {
+	return models.UpdatePermission(ctx, r.rclient, r.db, id, name)
+}
+
+func (r *permissionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return models.DeletePermission(ctx, r.rclient, r.db, id)
+}
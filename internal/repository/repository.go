@@ -0,0 +1,101 @@
+// Package repository is the data access layer the services are built on. Each repository wraps the
+// model functions of one kind of record, with their Redis caching, behind an interface, so services
+// can be exercised against the mocks in package mocks without Postgres or Redis.
+package repository
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository.go -package=mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// UserRepo reads and writes user accounts.
+type UserRepo interface {
+	// Get returns users by ID, leaving out those that do not exist.
+	Get(ctx context.Context, ids ...uuid.UUID) (map[uuid.UUID]*models.User, error)
+	// GetBy returns the first user matching condition with the relations of preload, or a not
+	// found error.
+	GetBy(ctx context.Context, preload models.UserPreload, condition string, args ...interface{}) (*models.User, error)
+	// GetByUsername returns a user by username, with the relations the public profile shows, from
+	// the entry it is cached under.
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	// Register creates an inactive account and queues its activation email in one transaction.
+	Register(ctx context.Context, username, email, password, otp, token, invitation string, opts ...models.UserOption) (*models.User, error)
+	Update(ctx context.Context, id uuid.UUID, opts ...models.UserOption) (*models.User, error)
+	// RecordSecurityEvent adds an entry to the user's security log.
+	RecordSecurityEvent(ctx context.Context, id uuid.UUID, event, ip, userAgent string) error
+}
+
+// RoleRepo reads and writes roles. Changes take the version of the role they were made against.
+type RoleRepo interface {
+	List(ctx context.Context, p utils.Pagination) (utils.Page[models.Role], error)
+	Get(ctx context.Context, id uuid.UUID) (*models.Role, error)
+	Users(ctx context.Context, id uuid.UUID, page, limit int) ([]models.UserSummary, int64, error)
+	Create(ctx context.Context, name string, permissions ...string) (*models.Role, error)
+	Clone(ctx context.Context, id uuid.UUID, name string) (*models.Role, error)
+	Update(ctx context.Context, id uuid.UUID, version int, name string, permissions []string) (*models.Role, error)
+	AddPermissions(ctx context.Context, id uuid.UUID, version int, permissions []string) (*models.Role, error)
+	RemovePermissions(ctx context.Context, id uuid.UUID, version int, permissions []string) (*models.Role, error)
+	PreviewPermissions(ctx context.Context, id uuid.UUID, permissions []string) (*models.PermissionPreview, error)
+	// Snapshot returns the cached permission set of the role's current version.
+	Snapshot(ctx context.Context, id uuid.UUID) (*models.PermissionSnapshot, error)
+}
+
+// PermissionRepo reads and writes permissions.
+type PermissionRepo interface {
+	List(ctx context.Context, p utils.Pagination) (utils.Page[models.Permission], error)
+	Get(ctx context.Context, id uuid.UUID) (*models.Permission, error)
+	Create(ctx context.Context, name string) (*models.Permission, error)
+	Update(ctx context.Context, id uuid.UUID, name string) (*models.Permission, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// NotificationRepo reads and writes a user's notifications.
+type NotificationRepo interface {
+	Create(ctx context.Context, userID uuid.UUID, notifType, message string) (*models.Notification, error)
+	// CreateMany creates a batch of notifications with one insert and returns the in-app ones.
+	CreateMany(ctx context.Context, inputs []models.NotificationInput) ([]models.Notification, error)
+	Get(ctx context.Context, userID, id uuid.UUID) (*models.Notification, error)
+	List(ctx context.Context, userID uuid.UUID, p utils.Pagination, unreadOnly bool) (utils.Page[models.Notification], error)
+	MarkRead(ctx context.Context, userID, id uuid.UUID, isRead bool) (*models.Notification, error)
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error)
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+}
+
+// FlagRepo reads and writes feature flags.
+type FlagRepo interface {
+	List(ctx context.Context) ([]models.FeatureFlag, error)
+	Get(ctx context.Context, key string) (*models.FeatureFlag, error)
+	// All returns every flag by key, from the entry they are cached under.
+	All(ctx context.Context) (map[string]*models.FeatureFlag, error)
+	Create(ctx context.Context, actorID uuid.UUID, key string, u models.FeatureFlagUpdate) (*models.FeatureFlag, error)
+	// Update returns the flag as it was and as it is now.
+	Update(ctx context.Context, actorID uuid.UUID, key string, u models.FeatureFlagUpdate) (*models.FeatureFlag, *models.FeatureFlag, error)
+	Delete(ctx context.Context, key string) (*models.FeatureFlag, error)
+}
+
+// Repos bundles the repositories on one database and cache.
+type Repos struct {
+	Users         UserRepo
+	Roles         RoleRepo
+	Permissions   PermissionRepo
+	Notifications NotificationRepo
+	Flags         FlagRepo
+}
+
+// New returns the repositories on db, caching in rclient.
+func New(db *gorm.DB, rclient *storage.RedisClient) *Repos {
+	return &Repos{
+		Users:         NewUserRepo(db, rclient),
+		Roles:         NewRoleRepo(db, rclient),
+		Permissions:   NewPermissionRepo(db, rclient),
+		Notifications: NewNotificationRepo(db, rclient),
+		Flags:         NewFlagRepo(db, rclient),
+	}
+}
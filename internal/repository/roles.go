@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type roleRepo struct {
+	db      *gorm.DB
+	rclient *storage.RedisClient
+}
+
+// NewRoleRepo returns the role repository on db, caching in rclient.
+func NewRoleRepo(db *gorm.DB, rclient *storage.RedisClient) RoleRepo {
+	return &roleRepo{db: db, rclient: rclient}
+}
+
+func (r *roleRepo) List(ctx context.Context, p utils.Pagination) (utils.Page[models.Role], error) {
+	return models.ListRoles(ctx, r.rclient, r.db, p)
+}
+
+func (r *roleRepo) Get(ctx context.Context, id uuid.UUID) (*models.Role, error) {
+	return models.GetRoleBy(ctx, r.rclient, r.db, "id = ?", []interface{}{id})
+}
+
+func (r *roleRepo) Users(ctx context.Context, id uuid.UUID, page, limit int) ([]models.UserSummary, int64, error) {
+	return models.GetRoleUsers(ctx, r.db, id, page, limit)
+}
+
+func (r *roleRepo) Create(ctx context.Context, name string, permissions ...string) (*models.Role, error) {
+	return models.NewRole(ctx, r.rclient, r.db, name, permissions...)
+}
+
+func (r *roleRepo) Clone(ctx context.Context, id uuid.UUID, name string) (*models.Role, error) {
+	return models.CloneRole(ctx, r.rclient, r.db, id, name)
+}
+
+func (r *roleRepo) Update(ctx context.Context, id uuid.UUID, version int, name string, permissions []string) (*models.Role, error) {
+	return models.UpdateRole(ctx, r.rclient, r.db, id, version, name, permissions)
+}
+
+func (r *roleRepo) AddPermissions(ctx context.Context, id uuid.UUID, version int, permissions []string) (*models.Role, error) {
+	return models.AddRolePermissions(ctx, r.rclient, r.db, id, version, permissions)
+}
+
+func (r *roleRepo) RemovePermissions(ctx context.Context, id uuid.UUID, version int, permissions []string) (*models.Role, error) {
+	return models.RemoveRolePermissions(ctx, r.rclient, r.db, id, version, permissions)
+}
+
+func (r *roleRepo) PreviewPermissions(ctx context.Context, id uuid.UUID, permissions []string) (*models.PermissionPreview, error) {
+	return models.PreviewRolePermissions(ctx, r.db, id, permissions)
+}
+
+func (r *roleRepo) Snapshot(ctx context.Context, id uuid.UUID) (*models.PermissionSnapshot, error) {
+	return models.GetPermissionSnapshot(ctx, r.rclient, r.db, id)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"gorm.io/gorm"
+)
+
+type userRepo struct {
+	db      *gorm.DB
+	rclient *storage.RedisClient
+}
+
+// NewUserRepo returns the user repository on db, caching in rclient.
+func NewUserRepo(db *gorm.DB, rclient *storage.RedisClient) UserRepo {
+	return &userRepo{db: db, rclient: rclient}
+}
+
+func (r *userRepo) Get(ctx context.Context, ids ...uuid.UUID) (map[uuid.UUID]*models.User, error) {
+	return models.GetUsersByID(ctx, r.rclient, r.db, ids...)
+}
+
+func (r *userRepo) GetBy(ctx context.Context, preload models.UserPreload, condition string, args ...interface{}) (*models.User, error) {
+	return models.GetUserBy(ctx, r.rclient, r.db, condition, args, preload)
+}
+
+func (r *userRepo) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return cache.Fetch(ctx, r.rclient, cache.PublicUserKey(username), r.rclient.TTL("public_user"), func(ctx context.Context) (*models.User, []string, error) {
+		user, err := models.GetUserBy(ctx, r.rclient, r.db, "username = ?", []interface{}{username}, models.PreloadPublic)
+		if err != nil {
+			return nil, nil, err
+		}
+		return user, []string{cache.UserTag(user.ID)}, nil
+	})
+}
+
+func (r *userRepo) Register(ctx context.Context, username, email, password, otp, token, invitation string, opts ...models.UserOption) (*models.User, error) {
+	return models.RegisterUser(ctx, r.rclient, r.db, username, email, password, otp, token, invitation, opts...)
+}
+
+func (r *userRepo) Update(ctx context.Context, id uuid.UUID, opts ...models.UserOption) (*models.User, error) {
+	return models.UpdateUser(ctx, r.rclient, r.db, id, opts...)
+}
+
+func (r *userRepo) RecordSecurityEvent(ctx context.Context, id uuid.UUID, event, ip, userAgent string) error {
+	return models.RecordSecurityEvent(ctx, r.db, id, event, ip, userAgent)
+}
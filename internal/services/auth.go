@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/auth"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/i18n"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// Reasons a login is refused, as reported by LoginError.
+const (
+	LoginUnknownUser     = "unknown_user"
+	LoginInactive        = "inactive"
+	LoginInvalidPassword = "invalid_password"
+	LoginRestricted      = "restricted"
+)
+
+// LoginError is returned by Authenticate when it refuses a login. Err is the answer to give the
+// client; Reason tells the failures apart for metrics and logs, which the answer may not in
+// privacy mode. UserID is the account the attempt was on, when there is one.
+type LoginError struct {
+	Reason string
+	Err    *utils.CustomError
+	UserID uuid.UUID
+}
+
+func (e *LoginError) Error() string {
+	return e.Reason + ": " + e.Err.Error()
+}
+
+// Registration is the sign-up form of a new account.
+type Registration struct {
+	Username       string
+	Email          string
+	Password       string
+	Name           string
+	AvatarURL      string
+	InvitationCode string
+	// Locale is the language the account's notifications and emails are written in.
+	Locale string
+}
+
+// AuthService registers accounts, checks credentials and validates access tokens.
+type AuthService interface {
+	// Register creates an inactive account and queues its activation email. Duplicate usernames
+	// and emails fail with a conflict.
+	Register(ctx context.Context, reg Registration) (*models.User, error)
+	// Authenticate checks an email and password. Refused logins fail with a *LoginError; for
+	// restricted accounts the user is returned along with it so the answer can explain why.
+	Authenticate(ctx context.Context, email, password string, client Client) (*models.User, error)
+	// ValidateToken checks an access token the way RefreshTokenMiddleware does: signature and
+	// expiry, blacklist, token version, account status and role. Expired tokens are not refreshed.
+	ValidateToken(ctx context.Context, token string) (*auth.Claims, *models.User, error)
+}
+
+type authService struct {
+	deps  Deps
+	users UserService
+
+	dummyHashOnce sync.Once
+	dummyHash     string
+}
+
+func (s *authService) Register(ctx context.Context, reg Registration) (*models.User, error) {
+	if s.deps.RegistrationMode == models.RegistrationClosed {
+		return nil, utils.NewError(utils.ErrForbidden.Code, "Registration is closed")
+	}
+	if utils.ContainsInvalidChars(reg.Password) {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "password contains invalid characters")
+	}
+	if !utils.IsStrongPassword(reg.Password) {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "new password does not meet strength requirements")
+	}
+	reg.Email = strings.ToLower(strings.TrimSpace(reg.Email))
+	if !i18n.Supports(reg.Locale) {
+		reg.Locale = i18n.Default
+	}
+
+	// Invitations are only redeemed while they are required, so open registration spends none
+	invitation := ""
+	if s.deps.RegistrationMode == models.RegistrationInviteOnly {
+		invitation = strings.TrimSpace(reg.InvitationCode)
+		if invitation == "" {
+			return nil, utils.NewError(utils.ErrForbidden.Code, "An invitation code is required to register")
+		}
+	}
+
+	hashedPass, err := utils.HashPassword(reg.Password)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to process password")
+	}
+	otp, err := utils.GenerateOTP(10)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to generate activation code")
+	}
+	token, err := utils.GenerateRandomToken(64, 124)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to generate activation code")
+	}
+
+	// The activation email is sent by the outbox worker once the account is committed
+	user, err := s.deps.Repos.Users.Register(ctx, reg.Username, reg.Email, hashedPass, otp, token, invitation, models.WithName(reg.Name), models.WithAvatarURL(reg.AvatarURL), models.WithLocale(reg.Locale))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return nil, utils.NewError(utils.ErrConflict.Code, "Username or email already exists")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *authService) Authenticate(ctx context.Context, email, password string, client Client) (*models.User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	inactive := &LoginError{Reason: LoginInactive, Err: utils.NewError(utils.ErrForbidden.Code, "Account not activated. Check your email.")}
+	invalid := utils.NewError(utils.ErrUnauthorized.Code, "Invalid email or password")
+
+	user, err := s.deps.Repos.Users.GetBy(ctx, models.PreloadAuth, "email = ?", email)
+	if err != nil {
+		var appErr *utils.CustomError
+		if !utils.As(err, &appErr) || appErr.Code != utils.ErrNotFound.Code {
+			return nil, err
+		}
+		if s.deps.PrivacyMode {
+			s.comparePasswordOrDummy("", password)
+			return nil, &LoginError{Reason: LoginUnknownUser, Err: invalid}
+		}
+		return nil, &LoginError{Reason: LoginUnknownUser, Err: utils.NewError(utils.ErrNotFound.Code, "User not found")}
+	}
+
+	inactive.UserID = user.ID
+	if !s.deps.PrivacyMode && (!user.IsActive || !user.IsEmailVerified) {
+		return nil, inactive
+	}
+	if err := s.comparePasswordOrDummy(user.Password, password); err != nil {
+		if err := s.deps.Repos.Users.RecordSecurityEvent(ctx, user.ID, models.EventLoginFailed, client.IP, client.UserAgent); err != nil {
+			s.deps.Logger.Warn(ctx).WithFields("error", err, "user_id", user.ID).Logs("Failed to record failed login")
+		}
+		return nil, &LoginError{Reason: LoginInvalidPassword, Err: invalid, UserID: user.ID}
+	}
+	// In privacy mode the activation state is only revealed to someone who knows the password.
+	if s.deps.PrivacyMode && (!user.IsActive || !user.IsEmailVerified) {
+		return nil, inactive
+	}
+	if user.IsRestricted() {
+		return user, &LoginError{Reason: LoginRestricted, Err: utils.NewError(utils.ErrForbidden.Code, "Account is "+user.Status), UserID: user.ID}
+	}
+	return user, nil
+}
+
+// comparePasswordOrDummy checks password against hash, or against a throwaway hash when the
+// account does not exist or has no usable password, so all cases take the same time.
+func (s *authService) comparePasswordOrDummy(hash, password string) error {
+	if hash == "" || hash == utils.UnusablePassword {
+		s.dummyHashOnce.Do(func() {
+			s.dummyHash, _ = utils.HashPassword("devpulse-dummy-password")
+		})
+		utils.ComparePasswords(s.dummyHash, password)
+		return fmt.Errorf("no usable password")
+	}
+	return utils.ComparePasswords(hash, password)
+}
+
+func (s *authService) ValidateToken(ctx context.Context, token string) (*auth.Claims, *models.User, error) {
+	if token == "" {
+		return nil, nil, utils.NewError(utils.ErrBadRequest.Code, "Access token is required")
+	}
+	if s.deps.Rclient.Revoked(ctx, storage.AccessToken, token) {
+		return nil, nil, utils.NewError(utils.ErrUnauthorized.Code, "Access token has been invalidated")
+	}
+
+	claims, err := auth.VerifyToken(token)
+	if errors.Is(err, auth.ErrExpiredToken) {
+		return nil, nil, utils.NewError(utils.ErrUnauthorized.Code, "Access token has expired")
+	}
+	if err != nil {
+		return nil, nil, utils.NewError(utils.ErrUnauthorized.Code, "Invalid access token")
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, nil, utils.NewError(utils.ErrUnauthorized.Code, "Invalid access token")
+	}
+
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		var appErr *utils.CustomError
+		if utils.As(err, &appErr) && appErr.Code == utils.ErrNotFound.Code {
+			return nil, nil, utils.NewError(utils.ErrUnauthorized.Code, "User not found")
+		}
+		return nil, nil, err
+	}
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, nil, utils.NewError(utils.ErrUnauthorized.Code, "Session has been revoked")
+	}
+	if user.IsRestricted() {
+		return nil, nil, utils.NewError(utils.ErrForbidden.Code, "Account is "+user.Status)
+	}
+	if claims.RoleID != user.RoleID.String() {
+		return nil, nil, utils.NewError(utils.ErrForbidden.Code, "Role mismatch")
+	}
+	return claims, user, nil
+}
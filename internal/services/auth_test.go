@@ -0,0 +1,144 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/services"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"go.uber.org/mock/gomock"
+)
+
+const password = "Test-passw0rd!"
+
+func registration() services.Registration {
+	return services.Registration{Username: "newcomer", Email: " Newcomer@Example.com ", Password: password, Name: "New Comer"}
+}
+
+func TestRegister(t *testing.T) {
+	t.Run("stores the account", func(t *testing.T) {
+		s, r := newServices(t, services.Deps{})
+		r.users.EXPECT().
+			Register(gomock.Any(), "newcomer", "newcomer@example.com", gomock.Not(password), gomock.Any(), gomock.Any(), "", gomock.Any()).
+			Return(&models.User{Username: "newcomer"}, nil)
+		user, err := s.Auth.Register(context.Background(), registration())
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if user.Username != "newcomer" {
+			t.Fatalf("Register returned %q", user.Username)
+		}
+	})
+
+	t.Run("duplicate account conflicts", func(t *testing.T) {
+		s, r := newServices(t, services.Deps{})
+		r.users.EXPECT().
+			Register(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, errors.New(`duplicate key value violates unique constraint "users_email_key"`))
+		_, err := s.Auth.Register(context.Background(), registration())
+		wantCode(t, err, utils.ErrConflict.Code)
+	})
+
+	// The cases below are refused before the repository is reached, which the mock enforces
+	refused := []struct {
+		name string
+		mode string
+		edit func(*services.Registration)
+		code int
+	}{
+		{name: "registration closed", mode: models.RegistrationClosed, code: utils.ErrForbidden.Code},
+		{name: "invitation missing", mode: models.RegistrationInviteOnly, code: utils.ErrForbidden.Code},
+		{name: "weak password", edit: func(reg *services.Registration) { reg.Password = "password" }, code: utils.ErrBadRequest.Code},
+	}
+	for _, tc := range refused {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := newServices(t, services.Deps{RegistrationMode: tc.mode})
+			reg := registration()
+			if tc.edit != nil {
+				tc.edit(&reg)
+			}
+			_, err := s.Auth.Register(context.Background(), reg)
+			wantCode(t, err, tc.code)
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := func(edit func(*models.User)) *models.User {
+		user := &models.User{ID: uuid.New(), Email: "member@example.com", Password: hashed, IsActive: true, IsEmailVerified: true, Status: models.UserStatusActive}
+		if edit != nil {
+			edit(user)
+		}
+		return user
+	}
+	inactive := func(u *models.User) { u.IsActive = false }
+	future := time.Now().Add(time.Hour)
+
+	cases := []struct {
+		name     string
+		privacy  bool
+		user     *models.User
+		password string
+		// failed is whether a failed login is recorded on the account
+		failed bool
+		reason string
+		code   int
+	}{
+		{name: "valid credentials", user: account(nil), password: password},
+		{name: "unknown user", password: password, reason: services.LoginUnknownUser, code: utils.ErrNotFound.Code},
+		{name: "unknown user in privacy mode", privacy: true, password: password, reason: services.LoginUnknownUser, code: utils.ErrUnauthorized.Code},
+		{name: "wrong password", user: account(nil), password: "Wrong-passw0rd!", failed: true, reason: services.LoginInvalidPassword, code: utils.ErrUnauthorized.Code},
+		{name: "inactive account", user: account(inactive), password: password, reason: services.LoginInactive, code: utils.ErrForbidden.Code},
+		{name: "inactive account in privacy mode hides behind the password", privacy: true, user: account(inactive), password: "Wrong-passw0rd!", failed: true, reason: services.LoginInvalidPassword, code: utils.ErrUnauthorized.Code},
+		{name: "inactive account in privacy mode", privacy: true, user: account(inactive), password: password, reason: services.LoginInactive, code: utils.ErrForbidden.Code},
+		{name: "suspended account", user: account(func(u *models.User) { u.Status, u.SuspendedUntil = models.UserStatusSuspended, &future }), password: password, reason: services.LoginRestricted, code: utils.ErrForbidden.Code},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, r := newServices(t, services.Deps{PrivacyMode: tc.privacy})
+			lookup := r.users.EXPECT().GetBy(gomock.Any(), models.PreloadAuth, "email = ?", "member@example.com")
+			if tc.user != nil {
+				lookup.Return(tc.user, nil)
+			} else {
+				lookup.Return(nil, utils.NewError(utils.ErrNotFound.Code, "User not found"))
+			}
+			if tc.failed {
+				r.users.EXPECT().RecordSecurityEvent(gomock.Any(), tc.user.ID, models.EventLoginFailed, "203.0.113.7", "test").Return(nil)
+			}
+
+			user, err := s.Auth.Authenticate(context.Background(), " Member@Example.com", tc.password, services.Client{IP: "203.0.113.7", UserAgent: "test"})
+			if tc.reason == "" {
+				if err != nil {
+					t.Fatalf("Authenticate: %v", err)
+				}
+				if user.ID != tc.user.ID {
+					t.Fatalf("Authenticate returned user %s, want %s", user.ID, tc.user.ID)
+				}
+				return
+			}
+			var loginErr *services.LoginError
+			if !errors.As(err, &loginErr) {
+				t.Fatalf("Authenticate: got %v, want a LoginError", err)
+			}
+			if loginErr.Reason != tc.reason || loginErr.Err.Code != tc.code {
+				t.Fatalf("Authenticate refused with %s (%d), want %s (%d)", loginErr.Reason, loginErr.Err.Code, tc.reason, tc.code)
+			}
+		})
+	}
+}
+
+func TestAuthenticateLookupFailure(t *testing.T) {
+	s, r := newServices(t, services.Deps{PrivacyMode: true})
+	r.users.EXPECT().GetBy(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, utils.NewError(utils.ErrInternalServerError.Code, "Failed to fetch user"))
+	_, err := s.Auth.Authenticate(context.Background(), "member@example.com", password, services.Client{})
+	wantCode(t, err, utils.ErrInternalServerError.Code)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+)
+
+// FlagService manages feature flags and decides which of them are on for a request. Features
+// check their flag with Enabled, such as Flags.Enabled(ctx, "new_feed"), and stay off while the
+// flag does not exist or cannot be read.
+type FlagService interface {
+	List(ctx context.Context) ([]models.FeatureFlag, error)
+	Get(ctx context.Context, key string) (*models.FeatureFlag, error)
+	Create(ctx context.Context, actorID uuid.UUID, key string, u models.FeatureFlagUpdate) (*models.FeatureFlag, error)
+	// Update returns the flag as it was and as it is now.
+	Update(ctx context.Context, actorID uuid.UUID, key string, u models.FeatureFlagUpdate) (*models.FeatureFlag, *models.FeatureFlag, error)
+	Delete(ctx context.Context, key string) (*models.FeatureFlag, error)
+	// Enabled reports whether a flag is on for the subject of ctx.
+	Enabled(ctx context.Context, key string) bool
+	// Evaluate returns whether each flag is on for the subject of ctx.
+	Evaluate(ctx context.Context) (map[string]bool, error)
+}
+
+// FlagSubject is who flags are evaluated for; the zero value is a guest.
+type FlagSubject struct {
+	UserID uuid.UUID
+	RoleID uuid.UUID
+}
+
+type flagSubjectKey struct{}
+
+// WithFlagSubject returns ctx with the subject its flags are evaluated for. Without one, the
+// subject is read from the user_id and role_id a request is authenticated with, which Fiber
+// exposes through the context of the request.
+func WithFlagSubject(ctx context.Context, subject FlagSubject) context.Context {
+	return context.WithValue(ctx, flagSubjectKey{}, subject)
+}
+
+// flagSubject returns who flags are evaluated for in ctx.
+func flagSubject(ctx context.Context) FlagSubject {
+	if subject, ok := ctx.Value(flagSubjectKey{}).(FlagSubject); ok {
+		return subject
+	}
+	var subject FlagSubject
+	if userID, ok := ctx.Value("user_id").(string); ok {
+		subject.UserID, _ = uuid.Parse(userID)
+	}
+	if roleID, ok := ctx.Value("role_id").(uuid.UUID); ok {
+		subject.RoleID = roleID
+	}
+	return subject
+}
+
+type flagService struct {
+	deps Deps
+}
+
+func (s *flagService) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	return s.deps.Repos.Flags.List(ctx)
+}
+
+func (s *flagService) Get(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	return s.deps.Repos.Flags.Get(ctx, key)
+}
+
+func (s *flagService) Create(ctx context.Context, actorID uuid.UUID, key string, u models.FeatureFlagUpdate) (*models.FeatureFlag, error) {
+	return s.deps.Repos.Flags.Create(ctx, actorID, key, u)
+}
+
+func (s *flagService) Update(ctx context.Context, actorID uuid.UUID, key string, u models.FeatureFlagUpdate) (*models.FeatureFlag, *models.FeatureFlag, error) {
+	return s.deps.Repos.Flags.Update(ctx, actorID, key, u)
+}
+
+func (s *flagService) Delete(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	return s.deps.Repos.Flags.Delete(ctx, key)
+}
+
+func (s *flagService) Enabled(ctx context.Context, key string) bool {
+	flags, err := s.deps.Repos.Flags.All(ctx)
+	if err != nil {
+		s.deps.Logger.Warn(ctx).WithFields("error", err, "flag", key).Logs("Failed to get feature flags")
+		return false
+	}
+	flag, ok := flags[key]
+	if !ok {
+		return false
+	}
+	subject := flagSubject(ctx)
+	return flag.EnabledFor(subject.UserID, subject.RoleID)
+}
+
+func (s *flagService) Evaluate(ctx context.Context) (map[string]bool, error) {
+	flags, err := s.deps.Repos.Flags.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subject := flagSubject(ctx)
+	enabled := make(map[string]bool, len(flags))
+	for key, flag := range flags {
+		enabled[key] = flag.EnabledFor(subject.UserID, subject.RoleID)
+	}
+	return enabled, nil
+}
@@ -0,0 +1,52 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/services"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFlagEnabled(t *testing.T) {
+	member, beta := uuid.New(), uuid.New()
+	flags := map[string]*models.FeatureFlag{
+		"everyone": {Key: "everyone", Enabled: true, Rollout: 100},
+		"off":      {Key: "off", Rollout: 100},
+		"beta":     {Key: "beta", Enabled: true, Roles: []string{beta.String()}},
+	}
+	cases := []struct {
+		name    string
+		key     string
+		subject services.FlagSubject
+		want    bool
+	}{
+		{name: "rolled out to everyone", key: "everyone", want: true},
+		{name: "disabled", key: "off", subject: services.FlagSubject{UserID: member}},
+		{name: "unknown flag", key: "missing", subject: services.FlagSubject{UserID: member}},
+		{name: "targeted role", key: "beta", subject: services.FlagSubject{UserID: member, RoleID: beta}, want: true},
+		{name: "other role", key: "beta", subject: services.FlagSubject{UserID: member, RoleID: uuid.New()}},
+		{name: "guest", key: "beta"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, r := newServices(t, services.Deps{})
+			r.flags.EXPECT().All(gomock.Any()).Return(flags, nil)
+			ctx := services.WithFlagSubject(context.Background(), tc.subject)
+			if got := s.Flags.Enabled(ctx, tc.key); got != tc.want {
+				t.Fatalf("Enabled(%q) = %v, want %v", tc.key, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFlagEnabledReadFailure(t *testing.T) {
+	s, r := newServices(t, services.Deps{})
+	r.flags.EXPECT().All(gomock.Any()).Return(nil, errors.New("redis unavailable"))
+	if s.Flags.Enabled(context.Background(), "everyone") {
+		t.Fatal("Enabled is on while the flags cannot be read")
+	}
+}
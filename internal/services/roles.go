@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// RoleService manages roles and answers permission checks. Changes take the version of the role
+// they were made against and fail with a conflict if it changed in the meantime.
+type RoleService interface {
+	List(ctx context.Context, p utils.Pagination) (utils.Page[models.Role], error)
+	ListPermissions(ctx context.Context, p utils.Pagination) (utils.Page[models.Permission], error)
+	Templates() ([]models.ResolvedRoleTemplate, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.Role, error)
+	// Users returns a page of the users assigned a role and how many there are.
+	Users(ctx context.Context, id uuid.UUID, page, limit int) ([]models.UserSummary, int64, error)
+	// Create creates a role with the permissions of template, if any, and permissions.
+	Create(ctx context.Context, name, template string, permissions []string) (*models.Role, error)
+	Clone(ctx context.Context, id uuid.UUID, name string) (*models.Role, error)
+	Update(ctx context.Context, id uuid.UUID, version int, name string, permissions []string) (*models.Role, error)
+	Grant(ctx context.Context, id uuid.UUID, version int, permissions []string) (*models.Role, error)
+	Revoke(ctx context.Context, id uuid.UUID, version int, permissions []string) (*models.Role, error)
+	// Preview returns what setting permissions on a role would add and remove, without saving.
+	Preview(ctx context.Context, id uuid.UUID, permissions []string) (*models.PermissionPreview, error)
+	// Check reports whether a role grants any of perms, from its cached permission snapshot.
+	Check(ctx context.Context, id uuid.UUID, perms ...string) (bool, *models.PermissionSnapshot, error)
+}
+
+type roleService struct {
+	deps Deps
+}
+
+func (s *roleService) List(ctx context.Context, p utils.Pagination) (utils.Page[models.Role], error) {
+	return s.deps.Repos.Roles.List(ctx, p)
+}
+
+func (s *roleService) ListPermissions(ctx context.Context, p utils.Pagination) (utils.Page[models.Permission], error) {
+	return s.deps.Repos.Permissions.List(ctx, p)
+}
+
+func (s *roleService) Templates() ([]models.ResolvedRoleTemplate, error) {
+	return models.ResolveRoleTemplates()
+}
+
+func (s *roleService) Get(ctx context.Context, id uuid.UUID) (*models.Role, error) {
+	return s.deps.Repos.Roles.Get(ctx, id)
+}
+
+func (s *roleService) Users(ctx context.Context, id uuid.UUID, page, limit int) ([]models.UserSummary, int64, error) {
+	return s.deps.Repos.Roles.Users(ctx, id, page, limit)
+}
+
+func (s *roleService) Create(ctx context.Context, name, template string, permissions []string) (*models.Role, error) {
+	if template != "" {
+		t, err := models.GetRoleTemplate(template)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(t.Permissions, permissions...)
+	}
+	return s.deps.Repos.Roles.Create(ctx, name, permissions...)
+}
+
+func (s *roleService) Clone(ctx context.Context, id uuid.UUID, name string) (*models.Role, error) {
+	return s.deps.Repos.Roles.Clone(ctx, id, name)
+}
+
+func (s *roleService) Update(ctx context.Context, id uuid.UUID, version int, name string, permissions []string) (*models.Role, error) {
+	return s.deps.Repos.Roles.Update(ctx, id, version, name, permissions)
+}
+
+func (s *roleService) Grant(ctx context.Context, id uuid.UUID, version int, permissions []string) (*models.Role, error) {
+	return s.deps.Repos.Roles.AddPermissions(ctx, id, version, permissions)
+}
+
+func (s *roleService) Revoke(ctx context.Context, id uuid.UUID, version int, permissions []string) (*models.Role, error) {
+	return s.deps.Repos.Roles.RemovePermissions(ctx, id, version, permissions)
+}
+
+func (s *roleService) Preview(ctx context.Context, id uuid.UUID, permissions []string) (*models.PermissionPreview, error) {
+	return s.deps.Repos.Roles.PreviewPermissions(ctx, id, permissions)
+}
+
+func (s *roleService) Check(ctx context.Context, id uuid.UUID, perms ...string) (bool, *models.PermissionSnapshot, error) {
+	snapshot, err := s.deps.Repos.Roles.Snapshot(ctx, id)
+	if err != nil {
+		return false, nil, err
+	}
+	return snapshot.Has(perms...), snapshot, nil
+}
@@ -0,0 +1,66 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/services"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateRoleFromTemplate(t *testing.T) {
+	template, err := models.GetRoleTemplate("auditor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, r := newServices(t, services.Deps{})
+	want := append(append([]string{}, template.Permissions...), "extra_permission")
+	r.roles.EXPECT().Create(gomock.Any(), "auditors", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, name string, permissions ...string) (*models.Role, error) {
+			if len(permissions) != len(want) {
+				t.Fatalf("Create got permissions %v, want %v", permissions, want)
+			}
+			for i := range want {
+				if permissions[i] != want[i] {
+					t.Fatalf("Create got permissions %v, want %v", permissions, want)
+				}
+			}
+			return &models.Role{Name: name}, nil
+		})
+
+	if _, err := s.Roles.Create(context.Background(), "auditors", "auditor", []string{"extra_permission"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+}
+
+func TestCreateRoleUnknownTemplate(t *testing.T) {
+	s, _ := newServices(t, services.Deps{})
+	_, err := s.Roles.Create(context.Background(), "auditors", "no_such_template", nil)
+	wantCode(t, err, utils.ErrNotFound.Code)
+}
+
+func TestCheckRole(t *testing.T) {
+	s, r := newServices(t, services.Deps{})
+	id := uuid.New()
+	r.roles.EXPECT().Snapshot(gomock.Any(), id).Return(&models.PermissionSnapshot{RoleID: id, Version: 3, Permissions: []string{"read_post", "create_post"}}, nil).Times(2)
+
+	ok, snapshot, err := s.Roles.Check(context.Background(), id, "delete_post", "create_post")
+	if err != nil || !ok || snapshot.Version != 3 {
+		t.Fatalf("Check(granted) = %v, %v, %v", ok, snapshot, err)
+	}
+	if ok, _, _ := s.Roles.Check(context.Background(), id, "delete_post"); ok {
+		t.Fatal("Check granted a permission the role does not have")
+	}
+}
+
+func TestUpdateRoleConflict(t *testing.T) {
+	s, r := newServices(t, services.Deps{})
+	id := uuid.New()
+	r.roles.EXPECT().Update(gomock.Any(), id, 2, "editors", []string{"read_post"}).
+		Return(nil, utils.NewError(utils.ErrConflict.Code, "Role was changed by someone else"))
+	_, err := s.Roles.Update(context.Background(), id, 2, "editors", []string{"read_post"})
+	wantCode(t, err, utils.ErrConflict.Code)
+}
@@ -0,0 +1,54 @@
+// Package services holds the account, authentication and role logic behind the HTTP handlers and
+// the internal gRPC API. Services know nothing of Fiber or gRPC: they take plain arguments, read
+// and write through the repositories, and fail with *utils.CustomError so every transport maps the
+// same failure to the same answer.
+package services
+
+import (
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/repository"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+)
+
+// Deps are what the services are built on: their stores and the account settings they enforce.
+type Deps struct {
+	Repos *repository.Repos
+	// Rclient holds the short-lived state of sign-up and sessions: pending registrations,
+	// activation codes and revoked access tokens.
+	Rclient *storage.RedisClient
+	Logger  *logger.Logger
+	// RegistrationMode decides who may create an account; empty means open registration.
+	RegistrationMode string
+	// PrivacyMode answers login attempts on unknown and inactive accounts like wrong passwords,
+	// so they cannot be used to find out which emails are registered.
+	PrivacyMode bool
+}
+
+// Services bundles the services built on one set of stores.
+type Services struct {
+	Users UserService
+	Auth  AuthService
+	Roles RoleService
+	Flags FlagService
+}
+
+// New builds the services on deps.
+func New(deps Deps) *Services {
+	if deps.RegistrationMode == "" {
+		deps.RegistrationMode = models.RegistrationOpen
+	}
+	users := &userService{deps: deps}
+	return &Services{
+		Users: users,
+		Auth:  &authService{deps: deps, users: users},
+		Roles: &roleService{deps: deps},
+		Flags: &flagService{deps: deps},
+	}
+}
+
+// Client describes who is behind a request, for the security events it records.
+type Client struct {
+	IP        string
+	UserAgent string
+}
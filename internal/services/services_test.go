@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mnuddindev/devpulse/internal/repository"
+	"github.com/mnuddindev/devpulse/internal/repository/mocks"
+	"github.com/mnuddindev/devpulse/internal/services"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"go.uber.org/mock/gomock"
+)
+
+// repos are the mocked repositories a test's services are built on.
+type repos struct {
+	users         *mocks.MockUserRepo
+	roles         *mocks.MockRoleRepo
+	permissions   *mocks.MockPermissionRepo
+	notifications *mocks.MockNotificationRepo
+	flags         *mocks.MockFlagRepo
+}
+
+// newServices builds the services on mocked repositories, with deps as the rest of their
+// dependencies. They have no Redis, so only code paths that never reach it can be tested here.
+func newServices(t *testing.T, deps services.Deps) (*services.Services, *repos) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	r := &repos{
+		users:         mocks.NewMockUserRepo(ctrl),
+		roles:         mocks.NewMockRoleRepo(ctrl),
+		permissions:   mocks.NewMockPermissionRepo(ctrl),
+		notifications: mocks.NewMockNotificationRepo(ctrl),
+		flags:         mocks.NewMockFlagRepo(ctrl),
+	}
+	deps.Repos = &repository.Repos{
+		Users:         r.users,
+		Roles:         r.roles,
+		Permissions:   r.permissions,
+		Notifications: r.notifications,
+		Flags:         r.flags,
+	}
+	log, err := logger.NewLogger(context.Background(), logger.WithOutputDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(log.Close)
+	deps.Logger = log
+	return services.New(deps), r
+}
+
+// wantCode fails the test unless err is a *utils.CustomError with code.
+func wantCode(t *testing.T, err error, code int) {
+	t.Helper()
+	var appErr *utils.CustomError
+	if !errors.As(err, &appErr) || appErr.Code != code {
+		t.Fatalf("got error %v, want status %d", err, code)
+	}
+}
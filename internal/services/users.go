@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/cache"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// UserService looks up accounts and activates new ones.
+type UserService interface {
+	// Get returns a user by ID from the shared user cache, loading it on a miss.
+	Get(ctx context.Context, id uuid.UUID) (*models.User, error)
+	// GetByUsername returns a user by username, sharing its cache entry with the public profile.
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	// Activate checks the activation code mailed for a pending registration and activates it.
+	Activate(ctx context.Context, token, otp string) (*models.User, error)
+}
+
+// welcomeNotifications greet every newly activated account, translated into its locale when
+// they are created.
+var welcomeNotifications = []string{
+	"Welcome to DEVPULSE! 👋 I'm Heart, the community mascot and I'm here to help get you started. Let's begin by setting up your profile!",
+	"Heart here again! 👋 DEVPULSE is a friendly community. Why not introduce yourself by leaving a comment in the welcome thread!",
+	"You're on a roll! 🎉 Do you have a Facebook account? Consider connecting it.",
+	"Hi, it's me again! 👋 Now that you're a part of the DEVPULSE community, let's focus on personalizing your content. You can start by following some tags to help customize your feed! 🎉",
+	"Heart here! 👋 Did you know that that you can customize your DEVPULSE experience? Try changing your font and theme and find the best style for you!",
+	"Heart here! 👋 I noticed that you haven't asked a question or started a discussion yet. It's easy to do both of these; just click on 'Write a Post' in the sidebar of the tag page to get started!",
+}
+
+type userService struct {
+	deps Deps
+}
+
+func (s *userService) Get(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	users, err := s.deps.Repos.Users.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user, ok := users[id]
+	if !ok {
+		return nil, utils.NewError(utils.ErrNotFound.Code, "User not found")
+	}
+	return user, nil
+}
+
+func (s *userService) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return s.deps.Repos.Users.GetByUsername(ctx, username)
+}
+
+// Activate activates the account registered under token once otp matches the mailed code. The
+// new user is greeted with the welcome notifications; failing to create them does not fail the
+// activation.
+func (s *userService) Activate(ctx context.Context, token, otp string) (*models.User, error) {
+	r := s.deps.Rclient
+	// Pending registrations live only in Redis, so activation waits out an outage
+	unavailable := utils.NewError(utils.ErrServiceUnavailable.Code, "Account activation is temporarily unavailable, try again shortly")
+	cachedUser, err := r.Get(ctx, cache.PendingUserKey(token)).Result()
+	if storage.Unavailable(err) {
+		return nil, unavailable
+	}
+	if err != nil {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid or expired User data")
+	}
+	var pending models.User
+	if err := json.Unmarshal([]byte(cachedUser), &pending); err != nil {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Failed to deserialize user data")
+	}
+
+	user, err := s.deps.Repos.Users.GetBy(ctx, models.PreloadMinimal, "email = ?", pending.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	otpKey := "otp:" + token
+	otpHash, err := r.Get(ctx, otpKey).Result()
+	if storage.Unavailable(err) {
+		return nil, unavailable
+	}
+	if err != nil || otpHash == "" {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid or expired activation code")
+	}
+	if err := utils.ComparePasswords(otpHash, otp); err != nil {
+		return nil, utils.NewError(utils.ErrBadRequest.Code, "Invalid activation code")
+	}
+
+	activated, err := s.deps.Repos.Users.Update(ctx, user.ID, models.WithIsActive(true))
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "Failed to activate account")
+	}
+	welcome := make([]models.NotificationInput, len(welcomeNotifications))
+	for i, message := range welcomeNotifications {
+		welcome[i] = models.NotificationInput{UserID: activated.ID, Type: "all", Message: message}
+	}
+	if _, err := s.deps.Repos.Notifications.CreateMany(ctx, welcome); err != nil {
+		s.deps.Logger.Warn(ctx).WithFields("error", err, "user_id", activated.ID).Logs("Failed to create welcome notifications")
+	}
+	r.Del(ctx, otpKey, cache.PendingUserKey(token))
+
+	userJSON, err := json.Marshal(activated)
+	if err == nil {
+		err = r.Set(ctx, cache.UserKey(activated.ID), userJSON, r.TTL("user")).Err()
+	}
+	if err != nil {
+		s.deps.Logger.Warn(ctx).WithFields("error", err, "user_id", activated.ID).Logs("Failed to cache activated user")
+	}
+	return activated, nil
+}
@@ -0,0 +1,26 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/internal/services"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetUser(t *testing.T) {
+	s, r := newServices(t, services.Deps{})
+	known, unknown := uuid.New(), uuid.New()
+	r.users.EXPECT().Get(gomock.Any(), known).Return(map[uuid.UUID]*models.User{known: {ID: known}}, nil)
+	r.users.EXPECT().Get(gomock.Any(), unknown).Return(map[uuid.UUID]*models.User{}, nil)
+
+	user, err := s.Users.Get(context.Background(), known)
+	if err != nil || user.ID != known {
+		t.Fatalf("Get(known) = %v, %v", user, err)
+	}
+	_, err = s.Users.Get(context.Background(), unknown)
+	wantCode(t, err, utils.ErrNotFound.Code)
+}
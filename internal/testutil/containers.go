@@ -0,0 +1,59 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// container is a throwaway Docker container, removed along with its volumes when stopped.
+type container struct {
+	id string
+}
+
+// startContainer runs image detached with port published on a random host port, and returns the
+// container and the host address the port is reachable at.
+func startContainer(image, port string, env ...string) (*container, string, error) {
+	args := []string{"run", "--detach", "--rm", "--publish", "127.0.0.1::" + port}
+	for _, e := range env {
+		args = append(args, "--env", e)
+	}
+	out, err := docker(append(args, image)...)
+	if err != nil {
+		return nil, "", err
+	}
+	c := &container{id: out}
+
+	addr, err := docker("port", c.id, port+"/tcp")
+	if err != nil {
+		c.stop()
+		return nil, "", err
+	}
+	// Docker lists one mapping per address family; the first is the IPv4 one asked for.
+	addr, _, _ = strings.Cut(addr, "\n")
+	return c, addr, nil
+}
+
+// stop removes the container, discarding its data.
+func (c *container) stop() error {
+	_, err := docker("rm", "--force", "--volumes", c.id)
+	return err
+}
+
+// docker runs the Docker CLI and returns its trimmed output.
+func docker(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// dockerAvailable reports whether a Docker daemon can be reached.
+func dockerAvailable() bool {
+	_, err := docker("info", "--format", "{{.ServerVersion}}")
+	return err == nil
+}
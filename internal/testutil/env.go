@@ -0,0 +1,240 @@
+// Package testutil runs the API against throwaway Postgres and Redis for endpoint tests. A test
+// package starts the stores once from its TestMain:
+//
+//	func TestMain(m *testing.M) { os.Exit(testutil.Main(m)) }
+//
+// and every test takes a clean, seeded environment with Setup. The stores are Docker containers
+// removed when the tests end; set TEST_DATABASE_DSN and TEST_REDIS_ADDR to use running servers
+// instead, as CI does with service containers. Without Docker or those, and in short mode, the
+// tests are skipped.
+package testutil
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	routes "github.com/mnuddindev/devpulse/internal/api"
+	"github.com/mnuddindev/devpulse/internal/config"
+	"github.com/mnuddindev/devpulse/internal/db"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"gorm.io/gorm"
+)
+
+const (
+	postgresImage = "postgres:16-alpine"
+	redisImage    = "redis:7-alpine"
+	// startTimeout bounds how long the stores may take to accept connections.
+	startTimeout = time.Minute
+)
+
+var errNoDocker = errors.New("integration tests need Docker, or TEST_DATABASE_DSN and TEST_REDIS_ADDR")
+
+// Env is the API running on its own stores. The handlers keep their dependencies in package
+// variables, so there is one Env per test binary and tests using it must not run in parallel.
+type Env struct {
+	App    *fiber.App
+	DB     *gorm.DB
+	Redis  *storage.RedisClient
+	Logger *logger.Logger
+
+	cancel     context.CancelFunc
+	containers []*container
+	dir        string
+}
+
+var (
+	shared *Env
+	// skipped tells why Main did not start the environment.
+	skipped string
+)
+
+// Main starts the environment, runs the tests and tears it down, returning the exit code.
+func Main(m *testing.M) int {
+	flag.Parse()
+	if testing.Short() {
+		skipped = "integration tests are skipped in short mode"
+		return m.Run()
+	}
+
+	env, err := Start(context.Background())
+	if errors.Is(err, errNoDocker) {
+		skipped = err.Error()
+		return m.Run()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "testutil:", err)
+		return 1
+	}
+	shared = env
+	defer env.Close()
+	return m.Run()
+}
+
+// Setup returns the environment, emptied of everything but the seeded roles and permissions.
+func Setup(tb testing.TB) *Env {
+	tb.Helper()
+	if shared == nil {
+		if skipped == "" {
+			tb.Fatal("testutil.Setup needs testutil.Main to be called from TestMain")
+		}
+		tb.Skip(skipped)
+	}
+	if err := shared.Reset(context.Background()); err != nil {
+		tb.Fatal(err)
+	}
+	return shared
+}
+
+// Start starts the stores, migrates and seeds the database as the server does at startup and
+// mounts the routes.
+func Start(ctx context.Context) (_ *Env, err error) {
+	dsn, redisAddr := os.Getenv("TEST_DATABASE_DSN"), os.Getenv("TEST_REDIS_ADDR")
+	if (dsn == "" || redisAddr == "") && !dockerAvailable() {
+		return nil, errNoDocker
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	e := &Env{cancel: cancel}
+	defer func() {
+		if err != nil {
+			e.Close()
+		}
+	}()
+
+	if dsn == "" {
+		c, addr, err := startContainer(postgresImage, "5432", "POSTGRES_USER=devpulse", "POSTGRES_PASSWORD=devpulse", "POSTGRES_DB=devpulse_test")
+		if err != nil {
+			return nil, err
+		}
+		e.containers = append(e.containers, c)
+		host, port, _ := net.SplitHostPort(addr)
+		dsn = fmt.Sprintf("host=%s user=devpulse password=devpulse dbname=devpulse_test port=%s sslmode=disable TimeZone=UTC", host, port)
+	}
+	if redisAddr == "" {
+		c, addr, err := startContainer(redisImage, "6379")
+		if err != nil {
+			return nil, err
+		}
+		e.containers = append(e.containers, c)
+		redisAddr = addr
+	}
+
+	e.dir, err = os.MkdirTemp("", "devpulse-test-")
+	if err != nil {
+		return nil, err
+	}
+	e.Logger, err = logger.NewLogger(ctx, logger.WithOutputDir(e.dir+"/logs"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Containers accept connections a moment after they start
+	err = retry(ctx, func() error {
+		rclient, err := storage.NewRedis(ctx, storage.Config{Addrs: []string{redisAddr}, Logger: e.Logger})
+		if err != nil {
+			return err
+		}
+		e.Redis = rclient
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = retry(ctx, func() error {
+		gdb, err := db.Open(dsn)
+		if err != nil {
+			return err
+		}
+		sqlDB, err := gdb.DB()
+		if err != nil {
+			return err
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			sqlDB.Close()
+			return err
+		}
+		return sqlDB.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.DB, err = db.NewDB(ctx, dsn, e.Redis, e.Logger, true)
+	if err != nil {
+		return nil, err
+	}
+
+	e.App = fiber.New(fiber.Config{
+		ErrorHandler: apierror.Handler(e.Logger),
+		BodyLimit:    10 << 20,
+	})
+	routes.NewRoutes(ctx, e.App, &config.Config{Status: "test", UploadDir: e.dir + "/uploads"}, e.DB, e.Logger, e.Redis)
+	return e, nil
+}
+
+// retry calls fn until it succeeds or startTimeout passes, returning its last error.
+func retry(ctx context.Context, fn func() error) error {
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// Reset empties every table but the applied migrations, reseeds the roles and permissions and
+// flushes Redis, so each test starts from a freshly migrated server.
+func (e *Env) Reset(ctx context.Context) error {
+	var tables []string
+	err := e.DB.WithContext(ctx).Raw("SELECT tablename FROM pg_tables WHERE schemaname = current_schema() AND tablename <> ?", "schema_migrations").Scan(&tables).Error
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if err := e.DB.WithContext(ctx).Exec(fmt.Sprintf("TRUNCATE TABLE %q CASCADE", table)).Error; err != nil {
+			return err
+		}
+	}
+	if err := e.Redis.FlushDB(ctx).Err(); err != nil {
+		return err
+	}
+	return models.ApplyRolePolicy(ctx, e.DB, e.Redis, e.Logger)
+}
+
+// Close stops the background workers and removes the containers and temporary files.
+func (e *Env) Close() {
+	e.cancel()
+	if e.Redis != nil {
+		e.Redis.UniversalClient.Close()
+	}
+	if e.DB != nil {
+		db.CloseDB()
+	}
+	if e.Logger != nil {
+		e.Logger.Close()
+	}
+	for _, c := range e.containers {
+		if err := c.stop(); err != nil {
+			fmt.Fprintln(os.Stderr, "testutil:", err)
+		}
+	}
+	if e.dir != "" {
+		os.RemoveAll(e.dir)
+	}
+}
@@ -0,0 +1,52 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mnuddindev/devpulse/internal/auth"
+	"github.com/mnuddindev/devpulse/internal/models"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// Password is the password of the users created by CreateUser.
+const Password = "Test-passw0rd!"
+
+var userSeq atomic.Int64
+
+// CreateUser creates an active, verified user with the given role and Password. Names and emails
+// are numbered so a test can create as many as it needs.
+func (e *Env) CreateUser(tb testing.TB, role string, opts ...models.UserOption) *models.User {
+	tb.Helper()
+	ctx := context.Background()
+
+	var r models.Role
+	if err := e.DB.WithContext(ctx).Where("name = ?", role).First(&r).Error; err != nil {
+		tb.Fatalf("finding role %s: %v", role, err)
+	}
+	hashed, err := utils.HashPassword(Password)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	n := userSeq.Add(1)
+	opts = append([]models.UserOption{models.WithIsActive(true), models.WithRoleID(r.ID)}, opts...)
+	user, err := models.NewUser(ctx, e.Redis, e.DB, fmt.Sprintf("user%d", n), fmt.Sprintf("user%d@example.com", n), hashed, "", opts...)
+	if err != nil {
+		tb.Fatalf("creating user: %v", err)
+	}
+	user.Role = r
+	return user
+}
+
+// Token returns an access token for user.
+func (e *Env) Token(tb testing.TB, user *models.User) string {
+	tb.Helper()
+	token, err := auth.GenerateAccessToken(user.ID.String(), user.RoleID.String(), user.TokenVersion)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return token
+}
@@ -0,0 +1,112 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/internal/auth"
+)
+
+// Request is a call to the API. Requests ask for body tokens, so they need no CSRF token.
+type Request struct {
+	Method string
+	Path   string
+	// Body is sent as JSON unless it is nil.
+	Body interface{}
+	// Raw is sent as it is when Body is nil, such as a multipart form; its Content-Type goes in
+	// Header.
+	Raw []byte
+	// Token is sent as a bearer access token unless it is empty.
+	Token  string
+	Header map[string]string
+}
+
+// Response is the API's answer to a Request.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// JSON decodes the body into v, failing the test if it is not JSON.
+func (r *Response) JSON(tb testing.TB, v interface{}) {
+	tb.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		tb.Fatalf("decoding response %s: %v", r.Body, err)
+	}
+}
+
+// Do sends req to the API and returns the answer, failing the test if it cannot be sent.
+func (e *Env) Do(tb testing.TB, req Request) *Response {
+	tb.Helper()
+	var body io.Reader
+	if req.Body != nil {
+		raw, err := json.Marshal(req.Body)
+		if err != nil {
+			tb.Fatalf("encoding request body: %v", err)
+		}
+		body = bytes.NewReader(raw)
+	} else if req.Raw != nil {
+		body = bytes.NewReader(req.Raw)
+	}
+
+	r := httptest.NewRequest(req.Method, req.Path, body)
+	r.Header.Set(auth.TokenTransportHeader, "body")
+	if req.Body != nil {
+		r.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	if req.Token != "" {
+		r.Header.Set(fiber.HeaderAuthorization, "Bearer "+req.Token)
+	}
+	for k, v := range req.Header {
+		r.Header.Set(k, v)
+	}
+
+	res, err := e.App.Test(r, -1)
+	if err != nil {
+		tb.Fatalf("%s %s: %v", req.Method, req.Path, err)
+	}
+	defer res.Body.Close()
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		tb.Fatalf("%s %s: reading response: %v", req.Method, req.Path, err)
+	}
+	return &Response{Status: res.StatusCode, Header: res.Header, Body: raw}
+}
+
+// Case is one row of a table-driven endpoint test.
+type Case struct {
+	Name string
+	// Setup prepares the stores for the case and may fill in the request, such as its token.
+	Setup   func(t *testing.T, e *Env, req *Request)
+	Request Request
+	Status  int
+	// Check inspects the response once its status matched.
+	Check func(t *testing.T, e *Env, res *Response)
+}
+
+// Run runs each case as a subtest on a freshly reset environment.
+func Run(t *testing.T, cases []Case) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			e := Setup(t)
+			req := tc.Request
+			if tc.Setup != nil {
+				tc.Setup(t, e, &req)
+			}
+			res := e.Do(t, req)
+			if res.Status != tc.Status {
+				t.Fatalf("%s %s: status %d, want %d: %s", req.Method, req.Path, res.Status, tc.Status, res.Body)
+			}
+			if tc.Check != nil {
+				tc.Check(t, e, res)
+			}
+		})
+	}
+}
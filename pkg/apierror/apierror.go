@@ -0,0 +1,137 @@
+// Package apierror defines the single error envelope every API error is answered with:
+//
+//	{"error": "User not found", "status": 404, "code": "not_found", "details": ...}
+//
+// error is a message safe to show to users, status repeats the HTTP status, code is a stable
+// machine-readable Code and details, when present, carries per-field validation errors or other
+// structured context. Handlers return an *Error and the Fiber ErrorHandler from Handler writes it.
+package apierror
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mnuddindev/devpulse/pkg/i18n"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/utils"
+)
+
+// Code identifies the kind of error independently of its message.
+type Code string
+
+// Error codes.
+const (
+	CodeBadRequest       Code = "bad_request"
+	CodeValidation       Code = "validation_failed"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeNotFound         Code = "not_found"
+	CodeConflict         Code = "conflict"
+	CodeTooLarge         Code = "payload_too_large"
+	CodeRateLimited      Code = "rate_limited"
+	CodeInternal         Code = "internal_error"
+	CodeUnavailable      Code = "service_unavailable"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+)
+
+// codes maps HTTP statuses to their default code.
+var codes = map[int]Code{
+	fiber.StatusBadRequest:            CodeBadRequest,
+	fiber.StatusUnprocessableEntity:   CodeValidation,
+	fiber.StatusUnauthorized:          CodeUnauthorized,
+	fiber.StatusForbidden:             CodeForbidden,
+	fiber.StatusNotFound:              CodeNotFound,
+	fiber.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	fiber.StatusConflict:              CodeConflict,
+	fiber.StatusRequestEntityTooLarge: CodeTooLarge,
+	fiber.StatusTooManyRequests:       CodeRateLimited,
+	fiber.StatusInternalServerError:   CodeInternal,
+	fiber.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// Error is an API error and the envelope it is written as.
+type Error struct {
+	Message string      `json:"error"`
+	Status  int         `json:"status"`
+	Code    Code        `json:"code"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New creates an error with the default code of its status.
+func New(status int, message string) *Error {
+	code, ok := codes[status]
+	if !ok {
+		code = CodeInternal
+		if status < fiber.StatusInternalServerError {
+			code = CodeBadRequest
+		}
+	}
+	return &Error{Message: message, Status: status, Code: code}
+}
+
+// WithDetails attaches structured context to the error.
+func (e *Error) WithDetails(details interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+func BadRequest(message string) *Error   { return New(fiber.StatusBadRequest, message) }
+func Unauthorized(message string) *Error { return New(fiber.StatusUnauthorized, message) }
+func Forbidden(message string) *Error    { return New(fiber.StatusForbidden, message) }
+func NotFound(message string) *Error     { return New(fiber.StatusNotFound, message) }
+func Conflict(message string) *Error     { return New(fiber.StatusConflict, message) }
+func TooLarge(message string) *Error     { return New(fiber.StatusRequestEntityTooLarge, message) }
+func RateLimited(message string) *Error  { return New(fiber.StatusTooManyRequests, message) }
+func Internal(message string) *Error     { return New(fiber.StatusInternalServerError, message) }
+func Unavailable(message string) *Error  { return New(fiber.StatusServiceUnavailable, message) }
+
+// Validation reports the fields of a request that failed validation.
+func Validation(res *utils.ErrorResponse) *Error {
+	e := New(fiber.StatusUnprocessableEntity, "Validation failed")
+	if res != nil {
+		e.Details = res.Errors
+	}
+	return e
+}
+
+// From converts any error into an API error. Client errors raised by the models keep their message;
+// anything else becomes an internal error with the fallback message, so internals never leak.
+func From(err error, fallback string) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	var appErr *utils.CustomError
+	// Unavailable answers name a dependency that is down, which the client may retry later
+	if utils.As(err, &appErr) && (appErr.Code < fiber.StatusInternalServerError || appErr.Code == fiber.StatusServiceUnavailable) {
+		return New(appErr.Code, appErr.Message)
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) && fiberErr.Code < fiber.StatusInternalServerError {
+		return New(fiberErr.Code, fiberErr.Message)
+	}
+	return Internal(fallback)
+}
+
+// Handler is the Fiber ErrorHandler writing every error returned by a handler as the envelope.
+// Server errors are logged with their cause, which the client never sees. The message is
+// translated into the language the client asks for with Accept-Language; the code is not.
+func Handler(log *logger.Logger) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		apiErr := From(err, "Something went wrong")
+		if apiErr.Status >= fiber.StatusInternalServerError {
+			log.Error(c.Context()).WithFields("error", err, "path", c.Path(), "method", c.Method()).Logs("Request failed")
+		}
+		locale := i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+		localized := *apiErr
+		localized.Message = i18n.T(locale, apiErr.Message)
+		c.Set(fiber.HeaderContentLanguage, locale)
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Status(apiErr.Status).JSON(&localized)
+	}
+}
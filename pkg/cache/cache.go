@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// tagTTL keeps a tag's key set alive at least as long as the longest cached value.
+const tagTTL = 7 * 24 * time.Hour
+
+// loads collapses concurrent misses of the same key into a single database load.
+var loads singleflight.Group
+
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
+
+// Get reads a cached value. A miss returns ok false and no error; so does a value that no
+// longer decodes into T, which is dropped so the next read reloads it.
+func Get[T any](ctx context.Context, client *storage.RedisClient, key string) (T, bool, error) {
+	var value T
+	raw, err := client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return value, false, nil
+	}
+	if err != nil {
+		return value, false, fmt.Errorf("failed to read cache key %s: %w", key, err)
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		client.Del(ctx, key)
+		return value, false, nil
+	}
+	return value, true, nil
+}
+
+// Set caches a value and registers the key under tags so Invalidate can drop it later.
+func Set[T any](ctx context.Context, client *storage.RedisClient, key string, value T, ttl time.Duration, tags ...string) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache key %s: %w", key, err)
+	}
+	pipe := client.TxPipeline()
+	pipe.Set(ctx, key, raw, ttl)
+	tagPipe(ctx, pipe, key, tags)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to write cache key %s: %w", key, err)
+	}
+	return nil
+}
+
+// Fetch returns the cached value of key, calling load on a miss. Concurrent misses share one
+// load, and the loaded value is only cached if no invalidation happened while it was loading.
+// load returns the tags to register the key under, which usually depend on the loaded value.
+func Fetch[T any](ctx context.Context, client *storage.RedisClient, key string, ttl time.Duration, load func(ctx context.Context) (T, []string, error)) (T, error) {
+	if value, ok, err := Get[T](ctx, client, key); err == nil && ok {
+		return value, nil
+	}
+
+	v, err, _ := loads.Do(key, func() (interface{}, error) {
+		gen := client.Generation(ctx, key)
+		value, tags, err := load(ctx)
+		if err != nil {
+			return value, err
+		}
+		if raw, err := json.Marshal(value); err == nil {
+			if set, err := client.SetIfGeneration(ctx, key, raw, ttl, gen); err == nil && set && len(tags) > 0 {
+				pipe := client.TxPipeline()
+				tagPipe(ctx, pipe, key, tags)
+				pipe.Exec(ctx)
+			}
+		}
+		return value, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+// FetchMany is Fetch for a batch of keys. The cached values and their generations are read in
+// one MGET and the misses are handed to a single call of load, which returns the values it found
+// by key; keys it leaves out do not exist and are left out of the result. Loaded values are
+// cached in one pipeline unless an invalidation happened while they were loading.
+func FetchMany[T any](ctx context.Context, client *storage.RedisClient, keys []string, ttl time.Duration, load func(ctx context.Context, missing []string) (map[string]T, error)) (map[string]T, error) {
+	found := make(map[string]T, len(keys))
+	if len(keys) == 0 {
+		return found, nil
+	}
+
+	// On a Redis error every key is loaded and nothing is cached
+	values, gens, readErr := client.GetWithGenerations(ctx, keys...)
+	missing := make([]string, 0, len(keys))
+	missingGens := make(map[string]string, len(keys))
+	for i, key := range keys {
+		if readErr == nil {
+			if raw, ok := values[i].(string); ok {
+				var value T
+				if err := json.Unmarshal([]byte(raw), &value); err == nil {
+					found[key] = value
+					continue
+				}
+			}
+			missingGens[key] = gens[i]
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return found, nil
+	}
+
+	loaded, err := load(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	fill := make(map[string][]byte, len(loaded))
+	for key, value := range loaded {
+		found[key] = value
+		if _, ok := missingGens[key]; !ok {
+			continue
+		}
+		if raw, err := json.Marshal(value); err == nil {
+			fill[key] = raw
+		}
+	}
+	client.SetManyIfGeneration(ctx, fill, missingGens, ttl)
+	return found, nil
+}
+
+func tagPipe(ctx context.Context, pipe redis.Pipeliner, key string, tags []string) {
+	for _, tag := range tags {
+		pipe.SAdd(ctx, tagKey(tag), key)
+		pipe.Expire(ctx, tagKey(tag), tagTTL)
+	}
+}
+
+// Invalidate drops every key registered under the given tags.
+func Invalidate(ctx context.Context, client *storage.RedisClient, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := client.SMembers(ctx, tagKey(tag)).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read cache tag %s: %w", tag, err)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		if err := client.Invalidate(ctx, keys...); err != nil {
+			return fmt.Errorf("failed to invalidate cache tag %s: %w", tag, err)
+		}
+		// Only the keys read above are removed so keys tagged meanwhile stay registered.
+		members := make([]interface{}, len(keys))
+		for i, key := range keys {
+			members[i] = key
+		}
+		client.SRem(ctx, tagKey(tag), members...)
+	}
+	return nil
+}
+
+// Delete drops individual keys.
+func Delete(ctx context.Context, client *storage.RedisClient, keys ...string) error {
+	return client.Invalidate(ctx, keys...)
+}
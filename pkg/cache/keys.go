@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/google/uuid"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+)
+
+// Key and tag builders. Every cache entry about a user is keyed by these so readers and
+// writers agree on the format.
+
+// UserKey is the key of the full user record.
+func UserKey(id uuid.UUID) string {
+	return "user:" + id.String()
+}
+
+// PublicUserKey is the key of the user record served by the public profile endpoints.
+func PublicUserKey(username string) string {
+	return "public_user:" + username
+}
+
+// UserTag tags every entry derived from a user so a change to the user drops them all.
+func UserTag(id uuid.UUID) string {
+	return "user:" + id.String()
+}
+
+// FollowsKey is the key of a page of a user's followers or followed users. It is tagged with the
+// user, which every follow change of theirs invalidates.
+func FollowsKey(userID uuid.UUID, relation, page string) string {
+	return "follows:" + userID.String() + ":" + relation + ":" + page
+}
+
+// SuggestionsKey is the key of the users suggested for a user to follow. It is tagged with the
+// user, so a follow change of theirs drops it.
+func SuggestionsKey(userID uuid.UUID) string {
+	return "suggestions:" + userID.String()
+}
+
+// PendingUserKey is the key of a registration waiting for activation, looked up by its token.
+func PendingUserKey(token string) string {
+	return "pending_user:" + token
+}
+
+// PostKey is the key of a post record.
+func PostKey(id uuid.UUID) string {
+	return "post:" + id.String()
+}
+
+// PublicPostKey is the key of a published post served by its slug.
+func PublicPostKey(slug string) string {
+	return "public_post:" + slug
+}
+
+// PostHTMLKey is the key of a post's rendered content, dropped whenever the post is edited.
+func PostHTMLKey(id uuid.UUID) string {
+	return "post_html:" + id.String()
+}
+
+// AutosaveKey is the key of the in-flight content of a post's editor, dropped once the post is
+// saved.
+func AutosaveKey(postID uuid.UUID) string {
+	return "autosave:" + postID.String()
+}
+
+// CommentHTMLKey is the key of a comment's rendered content, dropped whenever the comment is
+// edited.
+func CommentHTMLKey(id uuid.UUID) string {
+	return "comment_html:" + id.String()
+}
+
+// FeedKey is the key of a user's precomputed home feed.
+func FeedKey(userID uuid.UUID) string {
+	return "feed:" + userID.String()
+}
+
+// FeedAuthorTag tags the feeds that include an author's posts so they are rebuilt when the
+// author publishes.
+func FeedAuthorTag(authorID uuid.UUID) string {
+	return "feed_author:" + authorID.String()
+}
+
+// Keys of the @-mention prefix index. MentionIndexKey is a sorted set of "username:id" members of
+// the users who can be mentioned, all at score 0 so a prefix of usernames is a lexicographic range;
+// MentionNamesKey maps each user ID to its member so a rename drops the old one. The braces keep
+// the keys in one cluster slot, so the index can be rebuilt aside and renamed into place.
+const (
+	MentionIndexKey = "{mention_index}:usernames"
+	MentionNamesKey = "{mention_index}:names"
+)
+
+// PublishedPostsTag tags the site-wide listings of published posts, such as the site and tag
+// feeds, so they are rebuilt whenever a post is published or taken down.
+const PublishedPostsTag = "published_posts"
+
+// TagsTag tags every cached listing of tags so any tag change drops them all.
+const TagsTag = "tags"
+
+// PopularTagsKey is the key of the list of the most followed tags.
+func PopularTagsKey(limit int) string {
+	return "popular_tags:" + strconv.Itoa(limit)
+}
+
+// RolesTag tags every cached page of the role listing so any role change drops them all.
+const RolesTag = "roles"
+
+// RolesPageKey is the key of a page of the role listing.
+func RolesPageKey(page string) string {
+	return "roles:page:" + page
+}
+
+// PermissionsTag tags every cached page of the permission listing.
+const PermissionsTag = "permissions"
+
+// PermissionsPageKey is the key of a page of the permission listing.
+func PermissionsPageKey(page string) string {
+	return "permissions:page:" + page
+}
+
+// BlocklistKey is the key of the content filter blocklists, dropped whenever an entry changes.
+const BlocklistKey = "content_blocklist"
+
+// RateLimitOverridesKey is the key of the rate limit overrides, dropped whenever one changes.
+const RateLimitOverridesKey = "rate_limit_overrides"
+
+// FeatureFlagsKey is the key of the feature flags, dropped whenever one changes.
+const FeatureFlagsKey = "feature_flags"
+
+// NotificationsKey is the key of a page of a user's notifications.
+func NotificationsKey(userID uuid.UUID, unreadOnly bool, page string) string {
+	return "notifications:user:" + userID.String() + ":unread:" + strconv.FormatBool(unreadOnly) + ":" + page
+}
+
+// NotificationsTag tags every cached page of a user's notifications.
+func NotificationsTag(userID uuid.UUID) string {
+	return "notifications:" + userID.String()
+}
+
+// OrganizationKey is the key of an organization served by its slug.
+func OrganizationKey(slug string) string {
+	return "organization:" + slug
+}
+
+// OrganizationMemberKey is the key of a user's membership of an organization.
+func OrganizationMemberKey(orgID, userID uuid.UUID) string {
+	return "organization_member:" + orgID.String() + ":" + userID.String()
+}
+
+// OrganizationMembersKey is the key of a page of an organization's members.
+func OrganizationMembersKey(orgID uuid.UUID, page string) string {
+	return "organization_members:" + orgID.String() + ":" + page
+}
+
+// OrganizationTag tags every entry derived from an organization, its memberships included, so a
+// change to the organization or its members drops them all.
+func OrganizationTag(id uuid.UUID) string {
+	return "org:" + id.String()
+}
+
+// SeriesKey is the key of a series with its published parts, served by its slug.
+func SeriesKey(slug string) string {
+	return "series_slug:" + slug
+}
+
+// SeriesPartsKey is the key of a series with its published parts, read to place a post within
+// its series.
+func SeriesPartsKey(id uuid.UUID) string {
+	return "series_parts:" + id.String()
+}
+
+// SeriesTag tags every entry derived from a series so a change to it or to one of its parts drops
+// them all.
+func SeriesTag(id uuid.UUID) string {
+	return "series:" + id.String()
+}
+
+// SyndicationFeedKey is the key of a rendered RSS or Atom feed, scoped to the whole site, an author
+// or a tag, as in "site", "user:<username>" or "tag:<slug>".
+func SyndicationFeedKey(scope, format string) string {
+	return "syndication:" + scope + ":" + format
+}
+
+// GuestResponseKey is the key of a response served to anonymous readers, by path and query.
+func GuestResponseKey(url string) string {
+	return "guest_response:" + url
+}
+
+// ScheduledPublishKey is held while a worker publishes a scheduled post so no other worker
+// publishes it at the same time.
+func ScheduledPublishKey(postID uuid.UUID) string {
+	return "scheduled_publish:" + postID.String()
+}
+
+// SitemapKey is the key of the sitemap, rebuilt by a background job.
+const SitemapKey = "sitemap"
+
+// InvalidateUser drops the cached user record and every entry tagged with the user.
+func InvalidateUser(ctx context.Context, client *storage.RedisClient, id uuid.UUID) error {
+	if err := Delete(ctx, client, UserKey(id)); err != nil {
+		return err
+	}
+	return Invalidate(ctx, client, UserTag(id))
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+)
+
+// Kinds of entities whose lookups are negatively cached. Creating an entity of a kind forgets
+// every negative entry of that kind.
+const (
+	MissingUsers       = "users"
+	MissingRoles       = "roles"
+	MissingPermissions = "permissions"
+)
+
+// LookupKey identifies a lookup by its condition and arguments, such as "email = ?" and an
+// address, so negative entries of the same entity found by different fields stay apart.
+func LookupKey(condition string, args ...interface{}) string {
+	return strings.ReplaceAll(condition, " ", "") + ":" + fmt.Sprint(args...)
+}
+
+func missingKey(kind, lookup string) string {
+	return "missing:" + kind + ":" + lookup
+}
+
+func missingEpochKey(kind string) string {
+	return "missing_epoch:" + kind
+}
+
+// Missing reports whether a lookup was recorded as finding nothing. It also returns the current
+// epoch of the kind, which MarkMissing needs if the lookup is run and finds nothing: reading it
+// before the lookup keeps an entity created meanwhile from being recorded as missing.
+func Missing(ctx context.Context, client *storage.RedisClient, kind, lookup string) (bool, string) {
+	values, err := client.GetMany(ctx, missingKey(kind, lookup), missingEpochKey(kind))
+	if err != nil || len(values) != 2 {
+		return false, ""
+	}
+	epoch, _ := values[1].(string)
+	if epoch == "" {
+		epoch = "0"
+	}
+	marked, _ := values[0].(string)
+	return marked == epoch, epoch
+}
+
+// MarkMissing records for ttl that a lookup found nothing at the given epoch. An empty epoch,
+// returned when Redis failed, records nothing.
+func MarkMissing(ctx context.Context, client *storage.RedisClient, kind, lookup, epoch string, ttl time.Duration) error {
+	if epoch == "" {
+		return nil
+	}
+	if err := client.Set(ctx, missingKey(kind, lookup), epoch, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write cache key %s: %w", missingKey(kind, lookup), err)
+	}
+	return nil
+}
+
+// ForgetMissing drops every negative entry of a kind at once by moving it to a new epoch.
+func ForgetMissing(ctx context.Context, client *storage.RedisClient, kind string) error {
+	if err := client.Incr(ctx, missingEpochKey(kind)).Err(); err != nil {
+		return fmt.Errorf("failed to forget missing %s: %w", kind, err)
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+// Package contentfilter checks user-supplied text such as usernames, display names, bios and post
+// titles against configurable rules: blocked terms, links and blocked domains, and characters used
+// to impersonate other text. The blocklists come from the caller, so they can be managed at
+// runtime.
+package contentfilter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Rules a field can be checked against.
+const (
+	// RuleProfanity rejects blocked terms, also when spelled with look-alike letters, accents or
+	// digits standing in for letters.
+	RuleProfanity = "profanity"
+	// RuleURLs rejects links and domain names altogether.
+	RuleURLs = "urls"
+	// RuleDomains allows links, except to blocked domains and their subdomains.
+	RuleDomains = "domains"
+	// RuleSpoofing rejects invisible and direction-changing characters and words mixing scripts,
+	// such as a Latin name with a Cyrillic "а".
+	RuleSpoofing = "spoofing"
+)
+
+// Rules lists every rule.
+var Rules = []string{RuleProfanity, RuleURLs, RuleDomains, RuleSpoofing}
+
+// Filtered fields.
+const (
+	FieldUsername  = "username"
+	FieldName      = "name"
+	FieldBio       = "bio"
+	FieldPostTitle = "post_title"
+)
+
+// DefaultRules are the rules of each field when none are configured. Titles may name
+// technologies such as "Node.js", so links are only checked in names and bios.
+func DefaultRules() map[string][]string {
+	return map[string][]string{
+		FieldUsername:  {RuleProfanity, RuleSpoofing},
+		FieldName:      {RuleProfanity, RuleURLs, RuleSpoofing},
+		FieldBio:       {RuleProfanity, RuleDomains, RuleSpoofing},
+		FieldPostTitle: {RuleProfanity, RuleSpoofing},
+	}
+}
+
+// Term is a blocked term. A whole-word term only matches words of its own, so blocking "ass" leaves
+// "class" alone; a substring term matches anywhere, which catches it inside usernames.
+type Term struct {
+	Value     string `json:"value"`
+	Substring bool   `json:"substring"`
+}
+
+// Blocklist holds the blocked terms and domains text is checked against.
+type Blocklist struct {
+	Terms   []Term   `json:"terms"`
+	Domains []string `json:"domains"`
+}
+
+// Violation reports why a field was rejected. Its message never repeats the matched term.
+type Violation struct {
+	Field string
+	Rule  string
+}
+
+// Error implements the error interface.
+func (v *Violation) Error() string {
+	switch v.Rule {
+	case RuleProfanity:
+		return "contains language that is not allowed"
+	case RuleURLs:
+		return "cannot contain links"
+	case RuleDomains:
+		return "links to a blocked domain"
+	default:
+		return "contains characters that are not allowed"
+	}
+}
+
+// Filter checks fields against their configured rules. It is safe for concurrent use.
+type Filter struct {
+	rules map[string][]string
+}
+
+// New returns a filter applying rules by field. Fields without rules are not checked.
+func New(rules map[string][]string) (*Filter, error) {
+	for field, names := range rules {
+		for _, name := range names {
+			if !contains(Rules, name) {
+				return nil, fmt.Errorf("unknown content filter rule %q for field %q", name, field)
+			}
+		}
+	}
+	return &Filter{rules: rules}, nil
+}
+
+// ParseRules parses comma separated "field=rule|rule" entries (e.g.
+// "username=profanity|spoofing,bio=profanity|domains") on top of DefaultRules. A field given
+// without rules, as in "post_title=", is not checked.
+func ParseRules(raw string) (map[string][]string, error) {
+	rules := DefaultRules()
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		field, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q, expected field=rule|rule", entry)
+		}
+		names := []string{}
+		for _, name := range strings.Split(value, "|") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		rules[strings.TrimSpace(field)] = names
+	}
+	return rules, nil
+}
+
+// Check checks the text of a field against the field's rules and returns a *Violation for the
+// first rule it breaks.
+func (f *Filter) Check(field, text string, list *Blocklist) error {
+	if f == nil || text == "" {
+		return nil
+	}
+	if list == nil {
+		list = &Blocklist{}
+	}
+	for _, rule := range f.rules[field] {
+		var broken bool
+		switch rule {
+		case RuleProfanity:
+			broken = hasBlockedTerm(text, list.Terms)
+		case RuleURLs:
+			broken = len(hosts(text)) > 0
+		case RuleDomains:
+			broken = hasBlockedDomain(text, list.Domains)
+		case RuleSpoofing:
+			broken = isSpoofed(text)
+		}
+		if broken {
+			return &Violation{Field: field, Rule: rule}
+		}
+	}
+	return nil
+}
+
+// NormalizeTerm folds a term the way checked text is folded, so it is stored as it will match.
+func NormalizeTerm(term string) string {
+	return strings.Join(strings.Fields(fold(term)), " ")
+}
+
+// NormalizeDomain lowercases a domain and strips any scheme, path and leading "www.".
+func NormalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if _, rest, ok := strings.Cut(domain, "://"); ok {
+		domain = rest
+	}
+	if i := strings.IndexAny(domain, "/?#:"); i >= 0 {
+		domain = domain[:i]
+	}
+	return strings.TrimPrefix(strings.Trim(domain, "."), "www.")
+}
+
+// lookAlikes maps characters commonly substituted for Latin letters to those letters.
+var lookAlikes = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l',
+	// Cyrillic and Greek letters drawn like Latin ones
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c',
+	'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x',
+}
+
+// fold lowercases text, drops accents and maps look-alike characters to the Latin letters they
+// imitate. Anything else that is not a letter becomes a space.
+func fold(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(strings.ToLower(text)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if l, ok := lookAlikes[r]; ok {
+			r = l
+		}
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// hasBlockedTerm reports whether folded text contains a blocked term. Runs of single letters, as
+// in "b a d" or "b.a.d", are also read as one word.
+func hasBlockedTerm(text string, terms []Term) bool {
+	if len(terms) == 0 {
+		return false
+	}
+	words := strings.Fields(fold(text))
+	joined := " " + strings.Join(words, " ") + " "
+	squashed := " " + strings.Join(squashSingles(words), " ") + " "
+	compact := strings.Join(words, "")
+	for _, t := range terms {
+		term := NormalizeTerm(t.Value)
+		if term == "" {
+			continue
+		}
+		if t.Substring {
+			if strings.Contains(compact, strings.ReplaceAll(term, " ", "")) {
+				return true
+			}
+			continue
+		}
+		if strings.Contains(joined, " "+term+" ") || strings.Contains(squashed, " "+term+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// squashSingles joins runs of single-letter words into one word.
+func squashSingles(words []string) []string {
+	out := []string{}
+	run := ""
+	for _, w := range words {
+		if len([]rune(w)) == 1 {
+			run += w
+			continue
+		}
+		if run != "" {
+			out = append(out, run)
+			run = ""
+		}
+		out = append(out, w)
+	}
+	if run != "" {
+		out = append(out, run)
+	}
+	return out
+}
+
+var (
+	// linkPattern matches links with a scheme or starting with "www.".
+	linkPattern = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)([^\s/?#<>"']+)`)
+	// domainPattern matches bare domain names under the top-level domains links are usually
+	// shared under, leaving names such as "Node.js" alone.
+	domainPattern = regexp.MustCompile(`(?i)\b((?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+(?:com|net|org|info|biz|io|co|me|ly|gg|tv|to|xyz|site|online|top|club|app|dev|link|click|ru|cn|tk|ml|ga|cf|gq|uk|de|fr|in|us))\b`)
+)
+
+// hosts returns the hosts of the links and domain names in text.
+func hosts(text string) []string {
+	found := []string{}
+	for _, m := range linkPattern.FindAllStringSubmatch(text, -1) {
+		found = append(found, NormalizeDomain(m[1]))
+	}
+	for _, m := range domainPattern.FindAllStringSubmatch(text, -1) {
+		found = append(found, NormalizeDomain(m[1]))
+	}
+	return found
+}
+
+// hasBlockedDomain reports whether text links to a blocked domain or one of its subdomains.
+func hasBlockedDomain(text string, domains []string) bool {
+	if len(domains) == 0 {
+		return false
+	}
+	for _, host := range hosts(text) {
+		for _, d := range domains {
+			d = NormalizeDomain(d)
+			if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// confusableScripts are the scripts whose letters pass for one another; a word mixing them is
+// almost always an impersonation attempt.
+var confusableScripts = map[string]*unicode.RangeTable{
+	"Latin":    unicode.Latin,
+	"Cyrillic": unicode.Cyrillic,
+	"Greek":    unicode.Greek,
+	"Armenian": unicode.Armenian,
+	"Cherokee": unicode.Cherokee,
+}
+
+// isSpoofed reports whether text holds invisible or direction-changing characters, control
+// characters other than line breaks and tabs, or a word mixing confusable scripts.
+func isSpoofed(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Cf, r) || (unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t') {
+			return true
+		}
+	}
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		scripts := map[string]bool{}
+		for _, r := range word {
+			for name, table := range confusableScripts {
+				if unicode.Is(table, r) {
+					scripts[name] = true
+				}
+			}
+		}
+		if len(scripts) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Config returns the rules of each field.
+func (f *Filter) Config() map[string][]string {
+	if f == nil {
+		return map[string][]string{}
+	}
+	return f.rules
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+// Package diff compares texts line by line and writes the differences as unified diffs, the
+// format of diff -u and git diff.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxEdits bounds the work of a comparison. Texts further apart than this many inserted and
+// deleted lines are shown as entirely replaced rather than compared line by line.
+const maxEdits = 2000
+
+// Kind is what an edit does to a line.
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Edit is one line of the script turning a into b.
+type Edit struct {
+	Kind Kind
+	Line string
+}
+
+// Lines splits a text into its lines, without their line breaks.
+func Lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Edits returns the shortest script of deleted and inserted lines turning a into b, with the
+// lines they share in between, using the algorithm of Myers.
+func Edits(a, b []string) []Edit {
+	// Lines shared at either end are left out of the search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	edits = append(edits, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	return edits
+}
+
+// middle finds the edits between a and b, which differ at both ends.
+func middle(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+
+	// v[off+k] is the furthest x reached on diagonal k; trace[d] is v as it was before round d,
+	// kept for diagonals -d to d only.
+	off := limit + 1
+	v := make([]int, 2*off+1)
+	var trace [][]int
+	found := -1
+	for d := 0; d <= limit && found < 0; d++ {
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				found = d
+				break
+			}
+		}
+	}
+	if found < 0 {
+		edits := make([]Edit, 0, n+m)
+		for _, line := range a {
+			edits = append(edits, Edit{Delete, line})
+		}
+		for _, line := range b {
+			edits = append(edits, Edit{Insert, line})
+		}
+		return edits
+	}
+
+	// Walk back from the end, collecting the edits in reverse
+	var reversed []Edit
+	x, y := n, m
+	for d := found; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[d+k] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Edit{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, Edit{Insert, b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, Edit{Delete, a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, Edit{Equal, a[x-1]})
+		x--
+		y--
+	}
+
+	edits := make([]Edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+// Unified returns the unified diff turning from into to, with context unchanged lines around each
+// change, or an empty string when they are the same. fromName and toName label the two texts.
+func Unified(fromName, toName, from, to string, context int) string {
+	edits := Edits(Lines(from), Lines(to))
+
+	// Each edit's line number in from and in to, counted from 1
+	type position struct{ a, b int }
+	positions := make([]position, len(edits)+1)
+	ai, bi := 1, 1
+	for i, e := range edits {
+		positions[i] = position{ai, bi}
+		if e.Kind != Insert {
+			ai++
+		}
+		if e.Kind != Delete {
+			bi++
+		}
+	}
+	positions[len(edits)] = position{ai, bi}
+
+	var sb strings.Builder
+	for i := 0; i < len(edits); {
+		if edits[i].Kind == Equal {
+			i++
+			continue
+		}
+		// A hunk runs from context lines before a change to context lines after the last change
+		// closer than twice the context to the one before it
+		start := max(i-context, 0)
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].Kind != Equal {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		stop := min(end+context, len(edits))
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fromStart, toStart := positions[start].a, positions[start].b
+		fromLen, toLen := positions[stop].a-fromStart, positions[stop].b-toStart
+		// An empty range is numbered by the line before it, as diff does
+		if fromLen == 0 {
+			fromStart--
+		}
+		if toLen == 0 {
+			toStart--
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(fromStart, fromLen), hunkRange(toStart, toLen))
+		for _, e := range edits[start:stop] {
+			switch e.Kind {
+			case Equal:
+				sb.WriteByte(' ')
+			case Delete:
+				sb.WriteByte('-')
+			case Insert:
+				sb.WriteByte('+')
+			}
+			sb.WriteString(e.Line)
+			sb.WriteByte('\n')
+		}
+		i = stop
+	}
+	return sb.String()
+}
+
+// hunkRange formats the lines a hunk covers in one text.
+func hunkRange(start, length int) string {
+	if length == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
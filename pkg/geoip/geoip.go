@@ -0,0 +1,118 @@
+// Package geoip locates IP addresses with a database of networks read from a CSV file.
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Location is where an IP address is, as precisely as the database knows. Every field may be empty.
+type Location struct {
+	Country string `json:"country"`
+	Region  string `json:"region"`
+	City    string `json:"city"`
+}
+
+// String joins the known parts of the location, most precise first.
+func (l Location) String() string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{l.City, l.Region, l.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+type network struct {
+	prefix   netip.Prefix
+	location Location
+}
+
+// DB locates addresses in the networks it was loaded with. The networks must not overlap.
+type DB struct {
+	networks []network
+}
+
+// Open loads a database from a CSV file with one network per line: network in CIDR notation,
+// country, region and city. Lines starting with # and a header line are skipped.
+func Open(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Load reads a database in the format of Open.
+func Load(r io.Reader) (*DB, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+
+	db := &DB{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("geoip: line %d: want a network and a country", line)
+		}
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("geoip: line %d: %w", line, err)
+		}
+		loc := Location{Country: strings.TrimSpace(record[1])}
+		if len(record) > 2 {
+			loc.Region = strings.TrimSpace(record[2])
+		}
+		if len(record) > 3 {
+			loc.City = strings.TrimSpace(record[3])
+		}
+		db.networks = append(db.networks, network{prefix: prefix.Masked(), location: loc})
+	}
+
+	sort.Slice(db.networks, func(i, j int) bool {
+		return db.networks[i].prefix.Addr().Less(db.networks[j].prefix.Addr())
+	})
+	return db, nil
+}
+
+// Lookup returns the location of ip. Unknown, private and malformed addresses, and lookups on a
+// nil DB, have no location.
+func (db *DB) Lookup(ip string) Location {
+	if db == nil {
+		return Location{}
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return Location{}
+	}
+	addr = addr.Unmap()
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsUnspecified() {
+		return Location{}
+	}
+
+	// The last network starting at or before addr is the only one that can contain it
+	i := sort.Search(len(db.networks), func(i int) bool {
+		return addr.Less(db.networks[i].prefix.Addr())
+	})
+	if i == 0 || !db.networks[i-1].prefix.Contains(addr) {
+		return Location{}
+	}
+	return db.networks[i-1].location
+}
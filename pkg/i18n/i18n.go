@@ -0,0 +1,99 @@
+// Package i18n translates the messages the platform shows to people: API errors, notifications
+// and emails.
+//
+// Messages are written in English in the code and double as their own catalog keys, so an
+// untranslated message, or a locale without a catalog, reads in English. A catalog is a JSON
+// object in locales/<locale>.json mapping each English message to its translation. Messages that
+// take arguments are fmt format strings and keep their verbs, in order, in every translation.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Default is the locale messages are written in.
+const Default = "en"
+
+//go:embed locales/*.json
+var files embed.FS
+
+var (
+	// catalogs maps each supported locale but Default to its translations.
+	catalogs = map[string]map[string]string{}
+	// supported lists Default followed by the locales with a catalog.
+	supported = []string{Default}
+	matcher   language.Matcher
+)
+
+func init() {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		locale := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		data, err := files.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		catalog := map[string]string{}
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", entry.Name(), err))
+		}
+		catalogs[locale] = catalog
+		supported = append(supported, locale)
+	}
+	sort.Strings(supported[1:])
+
+	tags := make([]language.Tag, len(supported))
+	for i, locale := range supported {
+		tags[i] = language.MustParse(locale)
+	}
+	matcher = language.NewMatcher(tags)
+}
+
+// Supported returns the locales messages can be shown in, Default first.
+func Supported() []string {
+	return append([]string(nil), supported...)
+}
+
+// Supports reports whether locale is one of the supported locales.
+func Supports(locale string) bool {
+	return locale == Default || catalogs[locale] != nil
+}
+
+// Negotiate picks the supported locale that best matches an Accept-Language header, falling back
+// to Default.
+func Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return Default
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return supported[index]
+}
+
+// T translates message into locale and, given args, formats it with them. Messages missing from
+// the locale's catalog, and unsupported locales, are left in English.
+func T(locale, message string, args ...interface{}) string {
+	if translated, ok := catalogs[locale][message]; ok && translated != "" {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
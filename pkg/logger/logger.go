@@ -7,11 +7,13 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	fiblog "github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/google/uuid"
 )
 
 type LogLevel string
@@ -20,7 +22,7 @@ const (
 	LevelDebug LogLevel = "DEBUG"
 	LevelInfo  LogLevel = "INFO"
 	LevelWarn  LogLevel = "WARN"
-	LevelError LogLevel = "ERRORs"
+	LevelError LogLevel = "ERROR"
 )
 
 // LogEntry represents a structured log entry in JSON.
@@ -30,6 +32,7 @@ type LogEntry struct {
 	RequestID string            `json:"request_id,omitempty"`
 	UserID    string            `json:"user_id,omitempty"`
 	Message   string            `json:"message"`
+	Route     string            `json:"route,omitempty"`
 	Path      string            `json:"path,omitempty"`
 	Method    string            `json:"method,omitempty"`
 	Status    int               `json:"status,omitempty"`
@@ -41,7 +44,6 @@ type LogEntry struct {
 // Logger manages structured logging with rotation and color
 type Logger struct {
 	Mu         sync.Mutex
-	Format     string
 	TimeFormat string
 	OutputDir  string
 	MaxSizeMB  int
@@ -49,7 +51,6 @@ type Logger struct {
 	File       *os.File
 	FileSize   int64
 	Log        *log.Logger
-	FiberLog   fiber.Handler
 	Queue      chan LogEntry
 	Quit       chan struct{}
 }
@@ -59,7 +60,6 @@ type LoggerOption func(*Logger)
 
 func NewLogger(ctx context.Context, opts ...LoggerOption) (*Logger, error) {
 	l := &Logger{
-		Format:     "[${time}] ${status} - ${method} ${path} ${latency}\n",
 		TimeFormat: time.RFC3339,
 		OutputDir:  "./logs",
 		MaxSizeMB:  10,
@@ -93,11 +93,6 @@ func NewLogger(ctx context.Context, opts ...LoggerOption) (*Logger, error) {
 		}
 		l.File = file
 		l.Log = log.New(file, "", 0)
-		l.FiberLog = fiblog.New(fiblog.Config{
-			Format:     l.Format,
-			TimeFormat: l.TimeFormat,
-			Output:     file,
-		})
 	}
 
 	go l.Worker()
@@ -136,11 +131,6 @@ func (l *Logger) Rotate() error {
 		l.File = newFile
 		l.FileSize = 0
 		l.Log.SetOutput(newFile)
-		l.FiberLog = fiblog.New(fiblog.Config{
-			Format:     l.Format,
-			TimeFormat: l.TimeFormat,
-			Output:     newFile,
-		})
 	}
 	l.FileSize = info.Size()
 	return nil
@@ -180,44 +170,84 @@ func (l *Logger) WriteEntry(entry LogEntry) error {
 	return nil
 }
 
-// Middleware returns the Fiber logger middleware.
-func (l *Logger) Middleware() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		ctx := context.WithValue(c.Context(), "fiber_ctx", c)
-		c.SetUserContext(ctx)
-		return l.FiberLog(c)
-	}
+// Keys of the request state SetupLogger stores in Fiber locals. Locals are also values of
+// c.Context(), which is how entries logged with it find them.
+const (
+	requestIDKey = "request_id"
+	userIDKey    = "user_id"
+	loggerKey    = "logger"
+	fiberCtxKey  = "fiber_ctx"
+)
+
+// requestIDPattern accepts the X-Request-ID of a client or proxy only if it is safe to log.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestLogger logs on behalf of one request. Its entries carry the request ID, user, route and
+// latency of the request without handlers passing them along.
+type RequestLogger struct {
+	logger *Logger
+	c      *fiber.Ctx
 }
 
-// SetupRoutesContext adds request ID and user ID to the context.
-func SetupRoutesContext(c *fiber.Ctx) context.Context {
-	ctx := c.UserContext()
-	if ctx == nil {
-		ctx = context.Background()
-	}
+// Debug starts a debug-level log entry of the request.
+func (r *RequestLogger) Debug() *LogBuilder { return r.logger.Debug(r.c.Context()) }
 
-	// feth request ID from request header.
-	reqID := c.Get(fiber.HeaderXRequestID)
-	if reqID == "" {
-		reqID = fmt.Sprintf("req-%d", time.Now().UnixNano())
-	}
-	ctx = context.WithValue(ctx, "request_id", reqID)
+// Info starts an info-level log entry of the request.
+func (r *RequestLogger) Info() *LogBuilder { return r.logger.Info(r.c.Context()) }
 
-	// fetch user ID from (set by JWT or locals)
-	if userID, ok := c.Locals("user_id").(string); ok && userID != "" {
-		ctx = context.WithValue(ctx, "user_id", userID)
-	}
+// Warn starts a warn-level log entry of the request.
+func (r *RequestLogger) Warn() *LogBuilder { return r.logger.Warn(r.c.Context()) }
+
+// Error starts an error-level log entry of the request.
+func (r *RequestLogger) Error() *LogBuilder { return r.logger.Error(r.c.Context()) }
 
-	return ctx
+// FromCtx returns the logger SetupLogger stored for a request, or nil outside of it.
+func FromCtx(c *fiber.Ctx) *RequestLogger {
+	rl, _ := c.Locals(loggerKey).(*RequestLogger)
+	return rl
 }
 
-// SetupLogger initializes the logger and adds it to Fiber locals.
+// RequestID returns the ID SetupLogger assigned to a request.
+func RequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(requestIDKey).(string)
+	return id
+}
+
+// SetupLogger assigns every request an ID, honouring a well-formed X-Request-ID, stores a
+// request-scoped logger in Fiber locals and writes one access entry per request once it is
+// answered. Errors are handed to the app's error handler first so the entry has the final status.
 func SetupLogger(l *Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		c.Locals("logger", l)
-		ctx := SetupRoutesContext(c)
+		// Fiber reuses the buffers behind header strings, and entries are written after the request
+		reqID := strings.Clone(c.Get(fiber.HeaderXRequestID))
+		if !requestIDPattern.MatchString(reqID) {
+			reqID = uuid.NewString()
+		}
+		c.Set(fiber.HeaderXRequestID, reqID)
+		c.Locals(requestIDKey, reqID)
+		c.Locals(fiberCtxKey, c)
+		c.Locals(loggerKey, &RequestLogger{logger: l, c: c})
+		ctx := c.UserContext()
+		ctx = context.WithValue(ctx, requestIDKey, reqID)
+		ctx = context.WithValue(ctx, fiberCtxKey, c)
 		c.SetUserContext(ctx)
-		return c.Next()
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		entry := l.entry(c.Context(), LevelInfo, "Request completed", nil)
+		entry.Status = c.Response().StatusCode()
+		switch {
+		case entry.Status >= fiber.StatusInternalServerError:
+			entry.Level = string(LevelError)
+		case entry.Status >= fiber.StatusBadRequest:
+			entry.Level = string(LevelWarn)
+		}
+		l.Queue <- entry
+		return nil
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -20,11 +21,6 @@ type LogBuilder struct {
 	Fields []interface{}
 }
 
-// WithFormat sets the Fiber logger format.
-func WithFormat(format string) LoggerOption {
-	return func(l *Logger) { l.Format = format }
-}
-
 // WithTimeFormat sets the timestamp format.
 func WithTimeFormat(timeformat string) LoggerOption {
 	return func(l *Logger) { l.TimeFormat = timeformat }
@@ -71,37 +67,64 @@ func (b *LogBuilder) WithMeta(meta map[string]string) *LogBuilder {
 	return b
 }
 
-// WithFields adds formatted fields to the message.
+// WithFields adds key-value pairs to the entry, e.g. WithFields("user_id", id, "error", err).
 func (b *LogBuilder) WithFields(fields ...interface{}) *LogBuilder {
 	b.Fields = fields
 	return b
 }
 
-// LogWithLevel logs a message with the specified level and context.
+// Logs queues the entry. Entries logged with the context of a request, c.Context() or
+// c.UserContext(), carry its ID, user, route and latency.
 func (b *LogBuilder) Logs(msg string) {
-	entry := LogEntry{
-		TimeStamp: time.Now().Format(b.Logger.TimeFormat),
-		Level:     string(b.Level),
-		Message:   fmt.Sprintf(msg, b.Fields...),
-	}
+	b.Logger.Queue <- b.Logger.entry(b.Ctx, b.Level, msg, b.meta())
+}
 
-	// Extract request context
-	if reqID, ok := b.Ctx.Value("request_id").(string); ok {
-		entry.RequestID = reqID
+// meta merges the metadata and fields of the entry.
+func (b *LogBuilder) meta() map[string]string {
+	if len(b.Meta) == 0 && len(b.Fields) == 0 {
+		return nil
 	}
-	if userID, ok := b.Ctx.Value("user_id").(string); ok {
-		entry.UserID = userID
+	meta := make(map[string]string, len(b.Meta)+len(b.Fields)/2)
+	for k, v := range b.Meta {
+		meta[strings.Clone(k)] = strings.Clone(v)
 	}
+	for i := 0; i < len(b.Fields); i += 2 {
+		if i+1 == len(b.Fields) {
+			meta["field"] = fmt.Sprint(b.Fields[i])
+			break
+		}
+		meta[fmt.Sprint(b.Fields[i])] = fmt.Sprint(b.Fields[i+1])
+	}
+	return meta
+}
 
-	// Add Fiber-specific fields if available
-	if c, ok := b.Ctx.Value("fiber_ctx").(*fiber.Ctx); ok {
-		entry.Path = c.Path()
-		entry.Method = c.Method()
-		entry.Status = c.Response().StatusCode()
-		entry.Latency = time.Since(c.Context().Time()).String()
+// entry builds a log entry, filling in the request the context belongs to, if any.
+func (l *Logger) entry(ctx context.Context, level LogLevel, msg string, meta map[string]string) LogEntry {
+	entry := LogEntry{
+		TimeStamp: time.Now().Format(l.TimeFormat),
+		Level:     string(level),
+		Message:   strings.Clone(msg),
+		Meta:      meta,
+	}
+	if ctx == nil {
+		return entry
 	}
 
-	b.Logger.Queue <- entry
+	c, ok := ctx.Value(fiberCtxKey).(*fiber.Ctx)
+	if !ok {
+		// Contexts derived from a request but detached from it keep the plain values
+		entry.RequestID, _ = ctx.Value(requestIDKey).(string)
+		entry.UserID, _ = ctx.Value(userIDKey).(string)
+		return entry
+	}
+	entry.RequestID, _ = c.Locals(requestIDKey).(string)
+	entry.UserID, _ = c.Locals(userIDKey).(string)
+	// Entries are written asynchronously, after Fiber may have reused the request's buffers
+	entry.Route = c.Route().Path
+	entry.Path = strings.Clone(c.Path())
+	entry.Method = strings.Clone(c.Method())
+	entry.Latency = time.Since(c.Context().Time()).String()
+	return entry
 }
 
 // Worker processes the async logging queue.
@@ -0,0 +1,61 @@
+// Package mailer delivers rendered emails through a configurable provider: an SMTP server,
+// Amazon SES or SendGrid.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds a call to the HTTP APIs of SES and SendGrid.
+const requestTimeout = 30 * time.Second
+
+// Message is a rendered email with a plain-text and an HTML body, sent as multipart/alternative.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Provider sends messages.
+type Provider interface {
+	// Name identifies the provider in logs.
+	Name() string
+	// Send delivers a message; an error means it was not accepted for delivery.
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config selects and configures a provider.
+type Config struct {
+	// Provider is "smtp" (the default), "ses" or "sendgrid".
+	Provider string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	SESRegion    string
+	SESAccessKey string
+	SESSecretKey string
+
+	SendGridAPIKey string
+}
+
+// NewProvider returns the provider selected by cfg.
+func NewProvider(cfg Config) (Provider, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", "smtp":
+		return NewSMTPProvider(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
+	case "ses":
+		return NewSESProvider(cfg.SESRegion, cfg.SESAccessKey, cfg.SESSecretKey)
+	case "sendgrid":
+		return NewSendGridProvider(cfg.SendGridAPIKey)
+	default:
+		return nil, fmt.Errorf("unknown mail provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,164 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testMessage = Message{
+	From:    "DevPulse <no-reply@devpulse.example>",
+	To:      "jane@example.com",
+	Subject: "Hello",
+	Text:    "Hello Jane",
+	HTML:    "<p>Hello Jane</p>",
+}
+
+func TestNewProvider(t *testing.T) {
+	cases := []struct {
+		cfg  Config
+		name string
+	}{
+		{cfg: Config{SMTPHost: "localhost", SMTPPort: 1025}, name: "smtp"},
+		{cfg: Config{Provider: "SES", SESAccessKey: "key", SESSecretKey: "secret"}, name: "ses"},
+		{cfg: Config{Provider: "sendgrid", SendGridAPIKey: "key"}, name: "sendgrid"},
+		{cfg: Config{Provider: "smtp"}},
+		{cfg: Config{Provider: "ses", SESAccessKey: "key"}},
+		{cfg: Config{Provider: "sendgrid"}},
+		{cfg: Config{Provider: "pigeon"}},
+	}
+	for _, tc := range cases {
+		p, err := NewProvider(tc.cfg)
+		if tc.name == "" {
+			if err == nil {
+				t.Errorf("NewProvider(%+v) = %s, want an error", tc.cfg, p.Name())
+			}
+			continue
+		}
+		if err != nil || p.Name() != tc.name {
+			t.Errorf("NewProvider(%+v) = %v, %v; want %s", tc.cfg, p, err, tc.name)
+		}
+	}
+}
+
+// recordServer answers every request with status and keeps the last one with its body.
+func recordServer(t *testing.T, status int) (*httptest.Server, *http.Request, *[]byte) {
+	t.Helper()
+	var last http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = *r.Clone(context.Background())
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		io.WriteString(w, "answer body")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &last, &body
+}
+
+func TestSendGridSend(t *testing.T) {
+	srv, req, body := recordServer(t, http.StatusAccepted)
+	p, _ := NewSendGridProvider("sg-key")
+	p.endpoint = srv.URL
+
+	if err := p.Send(context.Background(), testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer sg-key" {
+		t.Fatalf("Authorization %q", got)
+	}
+	var payload sendGridRequest
+	if err := json.Unmarshal(*body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.From != (sendGridAddress{Email: "no-reply@devpulse.example", Name: "DevPulse"}) {
+		t.Fatalf("from %+v", payload.From)
+	}
+	if len(payload.Personalizations) != 1 || len(payload.Personalizations[0].To) != 1 || payload.Personalizations[0].To[0].Email != testMessage.To {
+		t.Fatalf("personalizations %+v", payload.Personalizations)
+	}
+	want := []sendGridContent{{Type: "text/plain", Value: testMessage.Text}, {Type: "text/html", Value: testMessage.HTML}}
+	if len(payload.Content) != 2 || payload.Content[0] != want[0] || payload.Content[1] != want[1] {
+		t.Fatalf("content %+v, want the text part first", payload.Content)
+	}
+}
+
+func TestSendGridSendRefused(t *testing.T) {
+	srv, _, _ := recordServer(t, http.StatusBadRequest)
+	p, _ := NewSendGridProvider("sg-key")
+	p.endpoint = srv.URL
+
+	if err := p.Send(context.Background(), testMessage); err == nil || !strings.Contains(err.Error(), "answer body") {
+		t.Fatalf("Send: %v, want the answer in the error", err)
+	}
+	bad := testMessage
+	bad.From = "not an address"
+	if err := p.Send(context.Background(), bad); err == nil {
+		t.Fatal("Send with an invalid sender succeeded")
+	}
+}
+
+var sesAuthorization = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=ses-key/\d{8}/eu-west-1/ses/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=[0-9a-f]{64}$`)
+
+func TestSESSend(t *testing.T) {
+	srv, req, body := recordServer(t, http.StatusOK)
+	p, _ := NewSESProvider("eu-west-1", "ses-key", "ses-secret")
+	p.endpoint = srv.URL
+
+	if err := p.Send(context.Background(), testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if req.URL.Path != sesSendPath {
+		t.Fatalf("path %q", req.URL.Path)
+	}
+	if got := req.Header.Get("Authorization"); !sesAuthorization.MatchString(got) {
+		t.Fatalf("Authorization %q", got)
+	}
+	var payload sesRequest
+	if err := json.Unmarshal(*body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	simple := payload.Content.Simple
+	if payload.FromEmailAddress != testMessage.From || len(payload.Destination.ToAddresses) != 1 || payload.Destination.ToAddresses[0] != testMessage.To ||
+		simple.Subject.Data != testMessage.Subject || simple.Body.Text.Data != testMessage.Text || simple.Body.HTML.Data != testMessage.HTML {
+		t.Fatalf("payload %+v", payload)
+	}
+
+	srv, _, _ = recordServer(t, http.StatusForbidden)
+	p.endpoint = srv.URL
+	if err := p.Send(context.Background(), testMessage); err == nil || !strings.Contains(err.Error(), "answer body") {
+		t.Fatalf("Send: %v, want the answer in the error", err)
+	}
+}
+
+// TestSESSignature checks that the signature covers the time and the body.
+func TestSESSignature(t *testing.T) {
+	p, _ := NewSESProvider("", "ses-key", "ses-secret")
+	at := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	sign := func(body string, t time.Time) string {
+		req, _ := http.NewRequest(http.MethodPost, p.endpoint+sesSendPath, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		p.sign(req, []byte(body), t)
+		return req.Header.Get("Authorization")
+	}
+
+	signature := sign(`{"a":1}`, at)
+	if !strings.Contains(signature, "Credential=ses-key/20261017/us-east-1/ses/aws4_request") {
+		t.Fatalf("Authorization %q", signature)
+	}
+	if sign(`{"a":1}`, at) != signature {
+		t.Fatal("signing the same request twice differs")
+	}
+	if sign(`{"a":2}`, at) == signature {
+		t.Fatal("the signature does not cover the body")
+	}
+	if sign(`{"a":1}`, at.Add(time.Second)) == signature {
+		t.Fatal("the signature does not cover the time")
+	}
+}
@@ -0,0 +1,94 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+)
+
+// sendGridEndpoint is the v3 Mail Send API.
+const sendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGridProvider sends messages through the SendGrid v3 API.
+type SendGridProvider struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewSendGridProvider returns a provider authenticating with apiKey.
+func NewSendGridProvider(apiKey string) (*SendGridProvider, error) {
+	if apiKey == "" {
+		return nil, errors.New("sendgrid mail provider needs an api key")
+	}
+	return &SendGridProvider{apiKey: apiKey, endpoint: sendGridEndpoint, client: &http.Client{Timeout: requestTimeout}}, nil
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+// Name implements Provider.
+func (p *SendGridProvider) Name() string {
+	return "sendgrid"
+}
+
+// Send implements Provider.
+func (p *SendGridProvider) Send(ctx context.Context, msg Message) error {
+	// SendGrid takes the address and display name apart
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %v", msg.From, err)
+	}
+	payload := sendGridRequest{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: msg.To}}}},
+		From:             sendGridAddress{Email: from.Address, Name: from.Name},
+		Subject:          msg.Subject,
+		// The plain-text part must come first
+		Content: []sendGridContent{{Type: "text/plain", Value: msg.Text}, {Type: "text/html", Value: msg.HTML}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode sendgrid request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build sendgrid request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sendgrid request failed: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("sendgrid answered %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sesAlgorithm  = "AWS4-HMAC-SHA256"
+	sesTimeFormat = "20060102T150405Z"
+	sesDateFormat = "20060102"
+	sesService    = "ses"
+	sesSendPath   = "/v2/email/outbound-emails"
+)
+
+// SESProvider sends messages through the Amazon SES v2 API, signing requests with AWS Signature
+// Version 4.
+type SESProvider struct {
+	endpoint  string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// NewSESProvider returns a provider for SES in region, "us-east-1" when empty.
+func NewSESProvider(region, accessKey, secretKey string) (*SESProvider, error) {
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("ses mail provider needs an access key and secret key")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &SESProvider{
+		endpoint:  "https://email." + region + ".amazonaws.com",
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+type sesRequest struct {
+	FromEmailAddress string `json:"FromEmailAddress"`
+	Destination      struct {
+		ToAddresses []string `json:"ToAddresses"`
+	} `json:"Destination"`
+	Content struct {
+		Simple struct {
+			Subject sesContent `json:"Subject"`
+			Body    struct {
+				Text sesContent `json:"Text"`
+				HTML sesContent `json:"Html"`
+			} `json:"Body"`
+		} `json:"Simple"`
+	} `json:"Content"`
+}
+
+// Name implements Provider.
+func (p *SESProvider) Name() string {
+	return "ses"
+}
+
+// Send implements Provider.
+func (p *SESProvider) Send(ctx context.Context, msg Message) error {
+	var payload sesRequest
+	payload.FromEmailAddress = msg.From
+	payload.Destination.ToAddresses = []string{msg.To}
+	payload.Content.Simple.Subject = sesContent{Data: msg.Subject, Charset: "UTF-8"}
+	payload.Content.Simple.Body.Text = sesContent{Data: msg.Text, Charset: "UTF-8"}
+	payload.Content.Simple.Body.HTML = sesContent{Data: msg.HTML, Charset: "UTF-8"}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode ses request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+sesSendPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build ses request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.sign(req, body, time.Now())
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ses request failed: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("ses answered %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// sign adds the Authorization header of a request carrying body. SES requests have no query, so
+// the canonical query string is empty.
+func (p *SESProvider) sign(req *http.Request, body []byte, t time.Time) {
+	t = t.UTC()
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", t.Format(sesTimeFormat))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := t.Format(sesDateFormat) + "/" + p.region + "/" + sesService + "/aws4_request"
+	toSign := strings.Join([]string{sesAlgorithm, t.Format(sesTimeFormat), scope, sha256Hex([]byte(canonical))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+p.secretKey), t.Format(sesDateFormat))
+	key = hmacSHA256(key, p.region)
+	key = hmacSHA256(key, sesService)
+	key = hmacSHA256(key, "aws4_request")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sesAlgorithm, p.accessKey, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
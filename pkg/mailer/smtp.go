@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gopkg.in/gomail.v2"
+)
+
+// SMTPProvider sends messages through an SMTP server, authenticating when a username is set.
+type SMTPProvider struct {
+	dialer *gomail.Dialer
+}
+
+// NewSMTPProvider returns a provider for the SMTP server at host:port.
+func NewSMTPProvider(host string, port int, username, password string) (*SMTPProvider, error) {
+	if host == "" || port <= 0 {
+		return nil, errors.New("smtp mail provider needs a host and port")
+	}
+	return &SMTPProvider{dialer: gomail.NewDialer(host, port, username, password)}, nil
+}
+
+// Name implements Provider.
+func (p *SMTPProvider) Name() string {
+	return "smtp"
+}
+
+// Send implements Provider. SMTP sessions cannot be canceled midway, so ctx is only checked
+// before dialing.
+func (p *SMTPProvider) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m := gomail.NewMessage()
+	m.SetHeader("From", msg.From)
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetBody("text/plain", msg.Text)
+	m.AddAlternative("text/html", msg.HTML)
+	if err := p.dialer.DialAndSend(m); err != nil {
+		return fmt.Errorf("smtp delivery failed: %v", err)
+	}
+	return nil
+}
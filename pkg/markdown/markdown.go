@@ -0,0 +1,165 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// Content formats a post can be written in.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// embedToken marks where an embed goes while the rest of the document is rendered and sanitized.
+const embedToken = "devpulse-embed-"
+
+var (
+	// liquidTag matches a liquid-style tag alone on its line, e.g. {% youtube dQw4w9WgXcQ %}.
+	liquidTag = regexp.MustCompile(`^\s*\{%\s*([a-z]+)\s+(\S+)\s*%\}\s*$`)
+	// embedParagraph matches the paragraph an embed token was rendered into.
+	embedParagraph = regexp.MustCompile(`<p>` + embedToken + `(\d+)</p>`)
+	youtubeID      = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	gistPath       = regexp.MustCompile(`^[A-Za-z0-9-]{1,39}/[0-9a-f]{1,64}$`)
+	// classNames limits class attributes to the plain names the highlighter emits.
+	classNames = regexp.MustCompile(`^[a-zA-Z0-9_ -]+$`)
+)
+
+// Renderer turns markdown into HTML that is safe to insert into a page. It is safe for concurrent
+// use.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+}
+
+// New returns a renderer supporting GitHub flavored markdown, highlighted code blocks, heading
+// anchors and YouTube and gist embeds. Code is highlighted with CSS classes, so pages supply the
+// chroma stylesheet of their choice.
+func New() *Renderer {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			highlighting.NewHighlighting(
+				highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+			),
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		// Raw HTML is passed through here and cleaned by the policy below
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("id").Matching(bluemonday.SpaceSeparatedTokens).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	policy.AllowAttrs("class").Matching(classNames).OnElements("pre", "code", "span")
+	policy.AllowAttrs("tabindex").Matching(regexp.MustCompile(`^0$`)).OnElements("pre")
+	policy.RequireNoFollowOnLinks(true)
+	policy.RequireNoReferrerOnLinks(true)
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return &Renderer{md: md, policy: policy}
+}
+
+// Render converts markdown to sanitized HTML.
+func (r *Renderer) Render(source string) (string, error) {
+	source, embeds := extractEmbeds(source)
+
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(source), &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %v", err)
+	}
+	out := r.policy.Sanitize(buf.String())
+
+	// Embeds are built from validated IDs only, so they are added after sanitizing
+	return embedParagraph.ReplaceAllStringFunc(out, func(p string) string {
+		i, err := strconv.Atoi(embedParagraph.FindStringSubmatch(p)[1])
+		if err != nil || i >= len(embeds) {
+			return p
+		}
+		return embeds[i]
+	}), nil
+}
+
+// Sanitize cleans HTML written by a user.
+func (r *Renderer) Sanitize(source string) string {
+	return r.policy.Sanitize(source)
+}
+
+// RenderFormat renders content written in format, markdown or HTML.
+func (r *Renderer) RenderFormat(source, format string) (string, error) {
+	if format == FormatHTML {
+		return r.Sanitize(source), nil
+	}
+	return r.Render(source)
+}
+
+// extractEmbeds replaces the liquid tags standing on their own line outside code blocks with
+// tokens and returns the HTML of each embed. Unknown or malformed tags are left as text.
+func extractEmbeds(source string) (string, []string) {
+	lines := strings.Split(source, "\n")
+	var embeds []string
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		m := liquidTag.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		embed, ok := renderEmbed(m[1], m[2])
+		if !ok {
+			continue
+		}
+		// Blank lines keep the token in a paragraph of its own
+		lines[i] = "\n" + embedToken + strconv.Itoa(len(embeds)) + "\n"
+		embeds = append(embeds, embed)
+	}
+	return strings.Join(lines, "\n"), embeds
+}
+
+// renderEmbed returns the HTML of a liquid tag, if it names a supported embed with a valid target.
+func renderEmbed(name, arg string) (string, bool) {
+	switch name {
+	case "youtube":
+		id := arg
+		if _, v, ok := strings.Cut(arg, "v="); ok {
+			id, _, _ = strings.Cut(v, "&")
+		} else if _, v, ok := strings.Cut(arg, "youtu.be/"); ok {
+			id = v
+		}
+		if !youtubeID.MatchString(id) {
+			return "", false
+		}
+		return `<div class="embed embed-youtube"><iframe src="https://www.youtube-nocookie.com/embed/` + id +
+			`" title="YouTube video" loading="lazy" allowfullscreen ` +
+			`allow="accelerometer; encrypted-media; gyroscope; picture-in-picture"></iframe></div>`, true
+	case "gist":
+		path := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(arg, "https://"), "gist.github.com/"), "/")
+		if !gistPath.MatchString(path) {
+			return "", false
+		}
+		return `<div class="embed embed-gist"><iframe src="https://gist.github.com/` + path +
+			`.pibb" title="GitHub gist" loading="lazy" sandbox="allow-popups"></iframe></div>`, true
+	}
+	return "", false
+}
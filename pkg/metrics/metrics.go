@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+// Registry holds the metrics exposed on /metrics. It is separate from the default registry so
+// libraries cannot add metrics behind our back.
+var Registry = prometheus.NewRegistry()
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "devpulse",
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "devpulse",
+		Name:      "cache_lookups_total",
+		Help:      "Redis cache lookups by key prefix and result, hit or miss.",
+	}, []string{"cache", "result"})
+
+	rateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "devpulse",
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected by a rate limit policy.",
+	}, []string{"policy"})
+
+	loginFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "devpulse",
+		Name:      "login_failures_total",
+		Help:      "Failed login attempts by reason.",
+	}, []string{"reason"})
+
+	deprecatedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "devpulse",
+		Name:      "deprecated_requests_total",
+		Help:      "Requests to deprecated routes by method and route, to tell when they can be removed.",
+	}, []string{"method", "route"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestDuration,
+		cacheLookups,
+		rateLimitRejections,
+		loginFailures,
+		deprecatedRequests,
+	)
+}
+
+// Middleware records the latency of every request. Requests are labelled with their route
+// pattern rather than their path so IDs in URLs do not multiply the series.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// The error handler has not written the response yet
+			status = apierror.From(err, "").Status
+		}
+		route := c.Route().Path
+		if status == fiber.StatusNotFound && route == "/" && c.Path() != "/" {
+			route = "unmatched"
+		}
+		requestDuration.WithLabelValues(c.Method(), route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format. A non-empty token must be sent as
+// "Authorization: Bearer <token>" by the scraper.
+func Handler(token string) fiber.Handler {
+	serve := adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	return func(c *fiber.Ctx) error {
+		if token != "" {
+			sent, _ := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				return apierror.Unauthorized("Invalid metrics token")
+			}
+		}
+		return serve(c)
+	}
+}
+
+// CacheLookup counts a lookup of a cache key. Keys are grouped by their prefix, "user" for
+// "user:<id>", to keep the series bounded.
+func CacheLookup(key string, hit bool) {
+	prefix, _, found := strings.Cut(key, ":")
+	if !found {
+		prefix = "other"
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(prefix, result).Inc()
+}
+
+// RedisHook counts every GET sent to Redis as a cache lookup, so lookups made directly through
+// the client are measured along with those of the cache package.
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		observeGet(cmd)
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			observeGet(cmd)
+		}
+		return err
+	}
+}
+
+// observeGet records the outcome of a GET. Failed commands are neither hits nor misses.
+func observeGet(cmd redis.Cmder) {
+	args := cmd.Args()
+	if cmd.Name() != "get" || len(args) < 2 {
+		return
+	}
+	err := cmd.Err()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return
+	}
+	key, _ := args[1].(string)
+	CacheLookup(key, err == nil)
+}
+
+// RateLimitRejected counts a request rejected by a rate limit policy.
+func RateLimitRejected(policy string) {
+	rateLimitRejections.WithLabelValues(policy).Inc()
+}
+
+// LoginFailed counts a failed login attempt.
+func LoginFailed(reason string) {
+	loginFailures.WithLabelValues(reason).Inc()
+}
+
+// DeprecatedRequest counts a request to a deprecated route.
+func DeprecatedRequest(method, route string) {
+	deprecatedRequests.WithLabelValues(method, route).Inc()
+}
@@ -0,0 +1,332 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/mnuddindev/devpulse/pkg/apierror"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/metrics"
+	storage "github.com/mnuddindev/devpulse/pkg/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// Algorithm selects how requests are counted against a limit.
+type Algorithm string
+
+const (
+	// SlidingWindow allows Limit requests in any Window long period.
+	SlidingWindow Algorithm = "sliding_window"
+	// TokenBucket allows bursts of Limit requests and refills Limit tokens per Window.
+	TokenBucket Algorithm = "token_bucket"
+)
+
+// KeyFunc returns who a request is counted against. An empty key skips the limit.
+type KeyFunc func(c *fiber.Ctx) string
+
+// Policy describes the rate limit of a route.
+type Policy struct {
+	// Name namespaces the counters of the policy; routes sharing a name share counters.
+	Name      string
+	Limit     int
+	Window    time.Duration
+	Algorithm Algorithm
+	Key       KeyFunc
+	// SkipSuccessful gives back the request when the handler answers below 400, so only failures count.
+	SkipSuccessful bool
+	Message        string
+}
+
+// Override replaces the limit and window of a policy for the requests of one role or, without a
+// role, for every request. A zero Window keeps the policy's; a zero Limit lifts the policy.
+type Override struct {
+	Policy string
+	RoleID string
+	Limit  int
+	Window time.Duration
+}
+
+// OverrideSource loads the overrides in effect.
+type OverrideSource func(ctx context.Context) ([]Override, error)
+
+// Result is the outcome of counting one request.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+	member    string
+}
+
+// Limiter applies policies with counters stored in Redis so limits hold across instances.
+type Limiter struct {
+	client *storage.RedisClient
+	logger *logger.Logger
+
+	source   OverrideSource
+	role     KeyFunc
+	refresh  time.Duration
+	mu       sync.RWMutex
+	loading  sync.Mutex
+	loaded   time.Time
+	override map[string]Override
+}
+
+// New creates a limiter.
+func New(client *storage.RedisClient, log *logger.Logger) *Limiter {
+	return &Limiter{client: client, logger: log}
+}
+
+var slidingWindow = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call("PEXPIRE", KEYS[1], window)
+local reset = window
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+var tokenBucket = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+local rate = capacity / window
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + (now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], window)
+local reset = 0
+if tokens < 1 then
+	reset = math.ceil((1 - tokens) / rate)
+end
+return {allowed, math.floor(tokens), reset}
+`)
+
+// UseOverrides makes the limiter apply the overrides of source to its policies, reading them again
+// at most every refresh so a change reaches every instance. role tells the role of a request;
+// requests before authentication have none and only get the overrides for everyone.
+func (l *Limiter) UseOverrides(source OverrideSource, role KeyFunc, refresh time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.source, l.role, l.refresh = source, role, refresh
+	l.loaded = time.Time{}
+}
+
+// Reload makes the next request read the overrides again.
+func (l *Limiter) Reload() {
+	l.mu.Lock()
+	l.loaded = time.Time{}
+	l.mu.Unlock()
+}
+
+func overrideKey(policy, roleID string) string {
+	return policy + "/" + roleID
+}
+
+// loadOverrides reads the overrides when they are due. One request reads them while the others
+// keep using the ones already loaded; when reading fails those stay in effect until the next try.
+func (l *Limiter) loadOverrides(ctx context.Context) {
+	l.mu.RLock()
+	due := l.source != nil && time.Since(l.loaded) >= l.refresh
+	l.mu.RUnlock()
+	if !due || !l.loading.TryLock() {
+		return
+	}
+	defer l.loading.Unlock()
+
+	overrides, err := l.source(ctx)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loaded = time.Now()
+	if err != nil {
+		l.logger.Warn(ctx).WithFields("error", err).Logs("Failed to load rate limit overrides")
+		return
+	}
+	l.override = make(map[string]Override, len(overrides))
+	for _, o := range overrides {
+		l.override[overrideKey(o.Policy, o.RoleID)] = o
+	}
+}
+
+// Effective returns p as it applies to the request: the override for the request's role wins over
+// the one for every request, and without either p is returned as is.
+func (l *Limiter) Effective(c *fiber.Ctx, p Policy) Policy {
+	l.loadOverrides(c.Context())
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if len(l.override) == 0 {
+		return p
+	}
+	o, ok := Override{}, false
+	if l.role != nil {
+		if role := l.role(c); role != "" {
+			o, ok = l.override[overrideKey(p.Name, role)]
+		}
+	}
+	if !ok {
+		o, ok = l.override[overrideKey(p.Name, "")]
+	}
+	if !ok {
+		return p
+	}
+	p.Limit = o.Limit
+	if o.Window > 0 {
+		p.Window = o.Window
+	}
+	return p
+}
+
+func counterKey(p Policy, key string) string {
+	return "ratelimit:" + p.Name + ":" + key
+}
+
+// Allow counts one request of key against a policy. Handlers use it directly when the key is only
+// known after parsing the request.
+func (l *Limiter) Allow(ctx context.Context, p Policy, key string) (Result, error) {
+	now := time.Now().UnixMilli()
+	window := p.Window.Milliseconds()
+	res := Result{Limit: p.Limit}
+
+	var values []interface{}
+	var err error
+	switch p.Algorithm {
+	case TokenBucket:
+		values, err = tokenBucket.Run(ctx, l.client.UniversalClient, []string{counterKey(p, key)}, now, window, p.Limit).Slice()
+	default:
+		res.member = strconv.FormatInt(now, 10) + ":" + uuid.NewString()
+		values, err = slidingWindow.Run(ctx, l.client.UniversalClient, []string{counterKey(p, key)}, now, window, p.Limit, res.member).Slice()
+	}
+	if err != nil {
+		return res, fmt.Errorf("failed to apply rate limit %q: %w", p.Name, err)
+	}
+	if len(values) != 3 {
+		return res, fmt.Errorf("unexpected rate limit reply for %q", p.Name)
+	}
+
+	allowed, _ := values[0].(int64)
+	remaining, _ := values[1].(int64)
+	reset, _ := values[2].(int64)
+	res.Allowed = allowed == 1
+	if !res.Allowed {
+		metrics.RateLimitRejected(p.Name)
+	}
+	res.Remaining = int(math.Max(0, float64(remaining)))
+	res.Reset = time.Duration(reset) * time.Millisecond
+	return res, nil
+}
+
+// Refund gives back a request counted by Allow.
+func (l *Limiter) Refund(ctx context.Context, p Policy, key string, res Result) error {
+	switch p.Algorithm {
+	case TokenBucket:
+		return l.client.HIncrByFloat(ctx, counterKey(p, key), "tokens", 1).Err()
+	default:
+		return l.client.ZRem(ctx, counterKey(p, key), res.member).Err()
+	}
+}
+
+// SetHeaders writes the standard X-RateLimit-* headers of a result.
+func SetHeaders(c *fiber.Ctx, res Result) {
+	c.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(res.Reset.Seconds()))))
+}
+
+// TooManyRequests answers a request rejected by a policy.
+func TooManyRequests(c *fiber.Ctx, p Policy, res Result) error {
+	message := p.Message
+	if message == "" {
+		message = "Too many requests, try again later"
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(res.Reset.Seconds()))))
+	return apierror.RateLimited(message)
+}
+
+// Handle returns middleware enforcing a policy, with its overrides applied. Redis failures let the request through so an
+// outage of the limiter does not take the API down with it.
+func (l *Limiter) Handle(policy Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := policy.Key(c)
+		if key == "" {
+			return c.Next()
+		}
+		p := l.Effective(c, policy)
+		if p.Limit <= 0 {
+			return c.Next()
+		}
+
+		res, err := l.Allow(c.Context(), p, key)
+		if err != nil {
+			l.logger.Warn(c.Context()).WithFields("error", err, "policy", p.Name).Logs("Rate limit check failed")
+			return c.Next()
+		}
+		SetHeaders(c, res)
+		if !res.Allowed {
+			l.logger.Warn(c.Context()).WithFields("policy", p.Name, "key", key).Logs("Rate limit exceeded")
+			return TooManyRequests(c, p, res)
+		}
+
+		if err := c.Next(); err != nil || !p.SkipSuccessful {
+			return err
+		}
+		if c.Response().StatusCode() < fiber.StatusBadRequest {
+			if err := l.Refund(c.Context(), p, key, res); err != nil {
+				l.logger.Warn(c.Context()).WithFields("error", err, "policy", p.Name).Logs("Failed to refund rate limit")
+			}
+		}
+		return nil
+	}
+}
+
+// ByIP counts requests per client IP.
+func ByIP(c *fiber.Ctx) string {
+	return c.IP()
+}
+
+// ByUser counts requests per authenticated user; it must run after the auth middleware.
+func ByUser(c *fiber.Ctx) string {
+	userID, _ := c.Locals("user_id").(string)
+	return userID
+}
+
+// ByBodyField counts requests per value of a JSON body field, e.g. the email of a login form.
+func ByBodyField(field string) KeyFunc {
+	return func(c *fiber.Ctx) string {
+		var body map[string]interface{}
+		if err := json.Unmarshal(c.Body(), &body); err != nil {
+			return c.IP()
+		}
+		value, _ := body[field].(string)
+		if value == "" {
+			return c.IP()
+		}
+		return value
+	}
+}
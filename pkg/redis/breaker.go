@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCircuitOpen is returned, without contacting Redis, while the circuit breaker is open.
+// Callers treat it like any other Redis failure: caches fall back to the database.
+var ErrCircuitOpen = errors.New("redis: circuit breaker is open")
+
+const (
+	// breakerThreshold is how many commands in a row must fail for the circuit to open.
+	breakerThreshold = 5
+	// breakerCooldown is how long the circuit stays open before a single command is let
+	// through to probe whether Redis is back.
+	breakerCooldown = 10 * time.Second
+)
+
+// breaker is a go-redis hook that stops sending commands to an unreachable Redis, so requests
+// fail fast instead of each waiting out the dial and read timeouts. Only failures to reach Redis
+// count: misses and error replies show the server is up.
+type breaker struct {
+	log *logger.Logger
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newBreaker(log *logger.Logger) *breaker {
+	return &breaker{log: log}
+}
+
+// closed reports whether commands are let through.
+func (b *breaker) closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures < breakerThreshold
+}
+
+// allow reports whether a command may be sent. Once the cooldown has passed, one command at a
+// time is let through as a probe.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < breakerThreshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record counts the outcome of a command sent to Redis.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !unreachable(err) {
+		if b.failures >= breakerThreshold && b.log != nil {
+			b.log.Info(context.Background()).Logs("Redis is reachable again, circuit breaker closed")
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= breakerThreshold {
+		if b.failures == breakerThreshold && b.log != nil {
+			b.log.Warn(context.Background()).WithFields("error", err).Logs("Redis is unreachable, circuit breaker opened")
+		}
+		b.openUntil = time.Now().Add(breakerCooldown)
+	}
+}
+
+// unreachable reports whether err means Redis could not be reached or did not answer in time.
+func unreachable(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var reply redis.Error
+	if errors.As(err, &reply) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, redis.ErrClosed)
+}
+
+func (b *breaker) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (b *breaker) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !b.allow() {
+			cmd.SetErr(ErrCircuitOpen)
+			return ErrCircuitOpen
+		}
+		err := next(ctx, cmd)
+		b.record(err)
+		return err
+	}
+}
+
+func (b *breaker) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !b.allow() {
+			for _, cmd := range cmds {
+				cmd.SetErr(ErrCircuitOpen)
+			}
+			return ErrCircuitOpen
+		}
+		err := next(ctx, cmds)
+		b.record(err)
+		return err
+	}
+}
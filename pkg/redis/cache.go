@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache write modes used after a database commit.
+const (
+	// WriteThrough stores the committed value in the cache.
+	WriteThrough = "write_through"
+	// Invalidate deletes the cached value and lets the next read repopulate it.
+	Invalidate = "invalidate"
+)
+
+// generationTTL outlives every cached value so a racing reader always sees the bump.
+const generationTTL = 7 * 24 * time.Hour
+
+// setIfGeneration only fills the cache when no writer bumped the generation since the
+// reader started loading from the database.
+var setIfGeneration = redis.NewScript(`
+local current = redis.call("GET", KEYS[2])
+if not current then current = "0" end
+if current ~= ARGV[2] then return 0 end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+return 1
+`)
+
+// ParseWriteMode validates a cache write mode, defaulting to write-through.
+func ParseWriteMode(mode string) (string, error) {
+	switch mode {
+	case "", WriteThrough:
+		return WriteThrough, nil
+	case Invalidate:
+		return Invalidate, nil
+	}
+	return "", fmt.Errorf("invalid cache write mode %q, expected %s or %s", mode, WriteThrough, Invalidate)
+}
+
+// SetWriteMode sets how WriteBack updates the cache after a commit.
+func (r *RedisClient) SetWriteMode(mode string) {
+	r.writeMode = mode
+}
+
+// generationKey is where the write generation of key is kept. The hash tag puts both in the same
+// cluster slot, as setIfGeneration must touch them together.
+func generationKey(key string) string {
+	return "{" + key + "}:gen"
+}
+
+// Generation returns the current write generation of a cache key. Readers take it before
+// loading from the database and pass it to SetIfGeneration.
+func (r *RedisClient) Generation(ctx context.Context, key string) string {
+	gen, err := r.Get(ctx, generationKey(key)).Result()
+	if err != nil {
+		return "0"
+	}
+	return gen
+}
+
+// SetIfGeneration caches a value loaded from the database unless it was written since gen was read.
+func (r *RedisClient) SetIfGeneration(ctx context.Context, key string, value []byte, ttl time.Duration, gen string) (bool, error) {
+	set, err := setIfGeneration.Run(ctx, r.UniversalClient, []string{key, generationKey(key)}, value, gen, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return set == 1, nil
+}
+
+// GetWithGenerations reads cache keys along with their write generations in one round trip, so a
+// batch of readers costs no more than one. A missing value is nil; a missing generation is "0".
+func (r *RedisClient) GetWithGenerations(ctx context.Context, keys ...string) ([]interface{}, []string, error) {
+	all := make([]string, 0, 2*len(keys))
+	all = append(all, keys...)
+	for _, key := range keys {
+		all = append(all, generationKey(key))
+	}
+	values, err := r.GetMany(ctx, all...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gens := make([]string, len(keys))
+	for i := range keys {
+		gens[i] = "0"
+		if gen, ok := values[len(keys)+i].(string); ok {
+			gens[i] = gen
+		}
+	}
+	return values[:len(keys)], gens, nil
+}
+
+// SetManyIfGeneration is SetIfGeneration for several keys sent in one pipeline. gens holds the
+// generation read for each key; it returns the keys that were set.
+func (r *RedisClient) SetManyIfGeneration(ctx context.Context, values map[string][]byte, gens map[string]string, ttl time.Duration) ([]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	pipe := r.Pipeline()
+	cmds := make(map[string]*redis.Cmd, len(values))
+	for key, value := range values {
+		gen, ok := gens[key]
+		if !ok {
+			gen = "0"
+		}
+		cmds[key] = setIfGeneration.Eval(ctx, pipe, []string{key, generationKey(key)}, value, gen, ttl.Milliseconds())
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	set := make([]string, 0, len(cmds))
+	for key, cmd := range cmds {
+		if n, err := cmd.Int(); err == nil && n == 1 {
+			set = append(set, key)
+		}
+	}
+	return set, nil
+}
+
+// WriteBack updates a cache key after its database row was committed, bumping the generation
+// so in-flight readers holding the previous value cannot overwrite it.
+func (r *RedisClient) WriteBack(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	pipe := r.TxPipeline()
+	pipe.Incr(ctx, generationKey(key))
+	pipe.Expire(ctx, generationKey(key), generationTTL)
+	if r.writeMode == Invalidate {
+		pipe.Del(ctx, key)
+	} else {
+		pipe.Set(ctx, key, value, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Invalidate deletes cache keys and bumps their generations so in-flight readers cannot
+// repopulate them with values loaded before the change.
+func (r *RedisClient) Invalidate(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	pipe := r.TxPipeline()
+	// One DEL per key, as a cluster refuses a DEL of keys in different slots
+	for _, key := range keys {
+		pipe.Incr(ctx, generationKey(key))
+		pipe.Expire(ctx, generationKey(key), generationTTL)
+		pipe.Del(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetMany reads several keys in one round trip, returning nil for missing ones like MGET. A
+// cluster refuses an MGET of keys in different slots, so there the keys are read with pipelined
+// GETs, which the client sends to each node at once.
+func (r *RedisClient) GetMany(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if !r.cluster {
+		return r.MGet(ctx, keys...).Result()
+	}
+	pipe := r.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	values := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		if value, err := cmd.Result(); err == nil {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
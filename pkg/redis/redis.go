@@ -1,39 +1,165 @@
+// Package storage wraps the Redis client shared by the caches, sessions and rate limits.
+//
+// When Redis is down the API degrades instead of failing. Caches are bypassed and reads go to
+// the database. Rate limits let requests through and log the failure. Revoked tokens are checked
+// against those this instance revoked recently, kept in memory. Only the features whose state
+// lives in Redis alone, such as account activation, answer 503. Readyz keeps reporting the
+// instance ready but flags it as degraded.
 package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mnuddindev/devpulse/pkg/logger"
 	"github.com/mnuddindev/devpulse/pkg/utils"
 	"github.com/redis/go-redis/v9"
 )
 
+// Redis topologies selected by Config.Mode.
+const (
+	// Standalone talks to a single server.
+	Standalone = "standalone"
+	// Sentinel asks the sentinels at Addrs for the current master of MasterName and follows
+	// failovers.
+	Sentinel = "sentinel"
+	// Cluster spreads keys over the slots of a Redis Cluster discovered from any of Addrs.
+	Cluster = "cluster"
+)
+
+// healthCheckInterval is how often an idle client pings Redis, so the circuit breaker notices
+// an outage, and a recovery, without waiting for requests.
+const healthCheckInterval = 5 * time.Second
+
+// Config describes how to reach Redis.
+type Config struct {
+	// Mode is Standalone, Sentinel or Cluster; empty means Standalone.
+	Mode string
+	// Addrs is the server in standalone mode, the sentinels in sentinel mode and the seed nodes
+	// in cluster mode.
+	Addrs      []string
+	MasterName string
+	Password   string
+	// SentinelPassword authenticates to the sentinels, which may differ from the servers.
+	SentinelPassword string
+	// Logger, if set, is told when the circuit breaker opens and closes.
+	Logger *logger.Logger
+	// TTLPolicy sets the cache TTLs returned by TTL; nil means DefaultTTLs without jitter.
+	TTLPolicy *TTLPolicy
+}
+
 type RedisClient struct {
-	*redis.Client
+	redis.UniversalClient
+	cluster   bool
+	breaker   *breaker
+	revoked   *revocationLRU
+	policy    *TTLPolicy
+	writeMode string
 }
 
-// NewRedis initializes a Redis client with context.
-func NewRedis(ctx context.Context, addr, password string) (*RedisClient, error) {
+// ParseAddrs splits a comma separated list of Redis addresses.
+func ParseAddrs(raw string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(raw, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// NewRedis initializes a Redis client for the configured topology. Commands pass through a
+// circuit breaker, and the client pings Redis in the background until ctx is done.
+func NewRedis(ctx context.Context, cfg Config) (*RedisClient, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, utils.WrapError(err, utils.ErrInternalServerError.Code, "redis initialization canceled")
 	}
+	if len(cfg.Addrs) == 0 {
+		return nil, utils.NewError(utils.ErrInternalServerError.Code, "No Redis address configured")
+	}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-	})
+	// The policy is fixed here, before the client is shared, so TTL only ever reads it
+	policy := cfg.TTLPolicy
+	if policy == nil {
+		policy = &TTLPolicy{TTLs: DefaultTTLs}
+	}
+	r := &RedisClient{breaker: newBreaker(cfg.Logger), revoked: newRevocationLRU(), policy: policy}
+	switch cfg.Mode {
+	case "", Standalone:
+		r.UniversalClient = redis.NewClient(&redis.Options{
+			Addr:     cfg.Addrs[0],
+			Password: cfg.Password,
+		})
+	case Sentinel:
+		if cfg.MasterName == "" {
+			return nil, utils.NewError(utils.ErrInternalServerError.Code, "Redis sentinel mode needs a master name")
+		}
+		r.UniversalClient = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    cfg.Addrs,
+			SentinelPassword: cfg.SentinelPassword,
+			Password:         cfg.Password,
+		})
+	case Cluster:
+		r.UniversalClient = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    cfg.Addrs,
+			Password: cfg.Password,
+		})
+		r.cluster = true
+	default:
+		return nil, utils.NewError(utils.ErrInternalServerError.Code, fmt.Sprintf("Invalid Redis mode %q, expected %s, %s or %s", cfg.Mode, Standalone, Sentinel, Cluster))
+	}
 
 	// Ping Redis with context
-	if err := client.Ping(ctx).Err(); err != nil {
+	if err := r.UniversalClient.Ping(ctx).Err(); err != nil {
+		r.UniversalClient.Close()
 		return nil, utils.NewError(utils.ErrInternalServerError.Code, "Failed to connect to Redis", err.Error())
 	}
+	r.AddHook(r.breaker)
+	go r.checkHealth(ctx)
+
+	return r, nil
+}
+
+// checkHealth pings Redis every healthCheckInterval until ctx is done. The pings go through the
+// circuit breaker, so they open it when Redis goes away and close it once Redis is back.
+func (r *RedisClient) checkHealth(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, healthCheckInterval)
+			r.Ping(pingCtx)
+			cancel()
+		}
+	}
+}
+
+// Healthy reports whether the circuit breaker lets commands through to Redis.
+func (r *RedisClient) Healthy() bool {
+	return r.breaker.closed()
+}
+
+// Unavailable reports whether err means Redis could not be reached, as opposed to a miss or an
+// error reply.
+func Unavailable(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || unreachable(err)
+}
 
-	return &RedisClient{client}, nil
+// TTL returns the cache TTL configured for a category.
+func (r *RedisClient) TTL(category string) time.Duration {
+	return r.policy.TTL(category)
 }
 
 // CloseRedis shuts down the Redis connection.
 func (r *RedisClient) Close(log *logger.Logger) error {
-	if err := r.Client.Close(); err != nil {
+	if err := r.UniversalClient.Close(); err != nil {
 		log.Error(context.Background()).WithMeta(map[string]string{"error": err.Error()}).Logs("Redis close failed")
 		return utils.NewError(utils.ErrInternalServerError.Code, "Failed to close Redis", err.Error())
 	}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Kinds of revoked tokens.
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+const (
+	// localRevocations bounds how many revoked tokens an instance remembers in memory.
+	localRevocations = 10_000
+	// localRevocationTTL bounds how long they are remembered, which outlives access tokens.
+	localRevocationTTL = time.Hour
+)
+
+// RevokedKey is the key a revoked token is kept under.
+func RevokedKey(kind, token string) string {
+	return "blacklist:" + kind + ":" + token
+}
+
+// Revoke blacklists a token for ttl. The token is also remembered in memory, so this instance
+// keeps refusing it if Redis fails.
+func (r *RedisClient) Revoke(ctx context.Context, kind, token string, ttl time.Duration) error {
+	r.revoked.add(RevokedKey(kind, token), ttl)
+	return r.Set(ctx, RevokedKey(kind, token), "invalid", ttl).Err()
+}
+
+// RevokePipe is Revoke queued on a pipeline.
+func (r *RedisClient) RevokePipe(ctx context.Context, pipe redis.Pipeliner, kind, token string, ttl time.Duration) {
+	r.revoked.add(RevokedKey(kind, token), ttl)
+	pipe.Set(ctx, RevokedKey(kind, token), "invalid", ttl)
+}
+
+// Revoked reports whether a token was blacklisted. While Redis cannot be reached only the tokens
+// this instance revoked recently are refused: failing closed would sign everyone out.
+func (r *RedisClient) Revoked(ctx context.Context, kind, token string) bool {
+	key := RevokedKey(kind, token)
+	if r.revoked.has(key) {
+		return true
+	}
+	n, err := r.Exists(ctx, key).Result()
+	return err == nil && n > 0
+}
+
+// revocationLRU is a bounded, expiring set of the keys of revoked tokens.
+type revocationLRU struct {
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type revocation struct {
+	key     string
+	expires time.Time
+}
+
+func newRevocationLRU() *revocationLRU {
+	return &revocationLRU{order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (l *revocationLRU) add(key string, ttl time.Duration) {
+	if ttl > localRevocationTTL {
+		ttl = localRevocationTTL
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.entries[key]; ok {
+		e.Value.(*revocation).expires = time.Now().Add(ttl)
+		l.order.MoveToFront(e)
+		return
+	}
+	l.entries[key] = l.order.PushFront(&revocation{key: key, expires: time.Now().Add(ttl)})
+	for l.order.Len() > localRevocations {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*revocation).key)
+	}
+}
+
+func (l *revocationLRU) has(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.entries[key]
+	if !ok {
+		return false
+	}
+	if time.Now().After(e.Value.(*revocation).expires) {
+		l.order.Remove(e)
+		delete(l.entries, key)
+		return false
+	}
+	l.order.MoveToFront(e)
+	return true
+}
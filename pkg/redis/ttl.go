@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTTLs holds the base TTL of every cache category.
+var DefaultTTLs = map[string]time.Duration{
+	"user":             30 * time.Minute,
+	"users":            10 * time.Minute,
+	"public_user":      5 * time.Minute,
+	"active_users":     1 * time.Minute,
+	"missing":          30 * time.Second,
+	"user_follows":     5 * time.Minute,
+	"suggestions":      1 * time.Hour,
+	"notification":     10 * time.Minute,
+	"notifications":    5 * time.Minute,
+	"notif_summary":    30 * time.Second,
+	"notif_prefs":      10 * time.Minute,
+	"badge":            10 * time.Minute,
+	"permission":       10 * time.Minute,
+	"role":             10 * time.Minute,
+	"role_perms":       24 * time.Hour,
+	"post":             10 * time.Minute,
+	"post_analytics":   10 * time.Minute,
+	"rendered":         24 * time.Hour,
+	"autosave":         72 * time.Hour,
+	"feed":             10 * time.Minute,
+	"syndication":      15 * time.Minute,
+	"sitemap":          2 * time.Hour,
+	"tag":              24 * time.Hour,
+	"tag_followers":    1 * time.Hour,
+	"tag_moderators":   1 * time.Hour,
+	"tag_analytics":    1 * time.Hour,
+	"organization":     10 * time.Minute,
+	"series":           24 * time.Hour,
+	"series_posts":     1 * time.Hour,
+	"series_analytics": 1 * time.Hour,
+	"blocklist":        1 * time.Hour,
+	"rate_limits":      1 * time.Hour,
+	"feature_flags":    1 * time.Hour,
+	"guest_response":   1 * time.Minute,
+}
+
+const (
+	minTTL    = 1 * time.Second
+	maxTTL    = 7 * 24 * time.Hour
+	maxJitter = 0.5
+)
+
+// TTLPolicy resolves the TTL of a cache category, spreading expiries with jitter
+// so keys written together do not expire together.
+type TTLPolicy struct {
+	TTLs   map[string]time.Duration
+	Jitter float64
+}
+
+// NewTTLPolicy builds a policy from the defaults and validated overrides.
+func NewTTLPolicy(overrides map[string]time.Duration, jitter float64) (*TTLPolicy, error) {
+	if math.IsNaN(jitter) || jitter < 0 || jitter > maxJitter {
+		return nil, fmt.Errorf("cache TTL jitter must be between 0 and %.2f, got %.2f", maxJitter, jitter)
+	}
+
+	ttls := make(map[string]time.Duration, len(DefaultTTLs))
+	for category, ttl := range DefaultTTLs {
+		ttls[category] = ttl
+	}
+	for category, ttl := range overrides {
+		if _, ok := DefaultTTLs[category]; !ok {
+			return nil, fmt.Errorf("unknown cache category %q", category)
+		}
+		if ttl < minTTL || ttl > maxTTL {
+			return nil, fmt.Errorf("cache TTL for %q must be between %s and %s, got %s", category, minTTL, maxTTL, ttl)
+		}
+		ttls[category] = ttl
+	}
+
+	return &TTLPolicy{TTLs: ttls, Jitter: jitter}, nil
+}
+
+// ParseTTLPolicy parses "category=duration" pairs separated by commas
+// (e.g. "user=30m,public_user=5m") and a jitter fraction (e.g. "0.1").
+func ParseTTLPolicy(raw, rawJitter string) (*TTLPolicy, error) {
+	overrides := map[string]time.Duration{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		category, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid cache TTL entry %q, expected category=duration", pair)
+		}
+		ttl, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache TTL for %q: %v", category, err)
+		}
+		overrides[strings.TrimSpace(category)] = ttl
+	}
+
+	jitter := 0.0
+	if rawJitter = strings.TrimSpace(rawJitter); rawJitter != "" {
+		parsed, err := strconv.ParseFloat(rawJitter, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache TTL jitter %q: %v", rawJitter, err)
+		}
+		jitter = parsed
+	}
+
+	return NewTTLPolicy(overrides, jitter)
+}
+
+// TTL returns the jittered TTL for a category. It panics on a category missing from DefaultTTLs,
+// which is a typo in the caller rather than something to cache under another category's TTL.
+func (p *TTLPolicy) TTL(category string) time.Duration {
+	base, ok := p.TTLs[category]
+	if !ok {
+		panic(fmt.Sprintf("unknown cache category %q", category))
+	}
+	if p.Jitter == 0 {
+		return base
+	}
+
+	spread := int64(float64(base) * p.Jitter)
+	if spread <= 0 {
+		return base
+	}
+	return base - time.Duration(spread) + time.Duration(rand.Int63n(2*spread+1))
+}
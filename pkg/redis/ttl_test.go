@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLPolicy(t *testing.T) {
+	policy, err := ParseTTLPolicy("user=10m, notifications=2m", "0.1")
+	if err != nil {
+		t.Fatalf("ParseTTLPolicy: %v", err)
+	}
+	r := &RedisClient{policy: policy}
+
+	cases := []struct {
+		category string
+		base     time.Duration
+	}{
+		{category: "user", base: 10 * time.Minute},
+		{category: "notifications", base: 2 * time.Minute},
+		{category: "public_user", base: DefaultTTLs["public_user"]},
+	}
+	for _, tc := range cases {
+		t.Run(tc.category, func(t *testing.T) {
+			low, high := tc.base-tc.base/10, tc.base+tc.base/10
+			spread := map[time.Duration]bool{}
+			for i := 0; i < 200; i++ {
+				ttl := r.TTL(tc.category)
+				if ttl < low || ttl > high {
+					t.Fatalf("TTL(%q) = %s, want within %s of %s", tc.category, ttl, tc.base/10, tc.base)
+				}
+				spread[ttl] = true
+			}
+			if len(spread) < 2 {
+				t.Fatalf("TTL(%q) is not jittered", tc.category)
+			}
+		})
+	}
+}
+
+func TestTTLPolicyWithoutJitter(t *testing.T) {
+	r := &RedisClient{policy: &TTLPolicy{TTLs: DefaultTTLs}}
+	for category, ttl := range DefaultTTLs {
+		if got := r.TTL(category); got != ttl {
+			t.Errorf("TTL(%q) = %s, want %s", category, got, ttl)
+		}
+	}
+}
+
+func TestTTLPolicyUnknownCategory(t *testing.T) {
+	r := &RedisClient{policy: &TTLPolicy{TTLs: DefaultTTLs}}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("TTL of an unknown category did not panic")
+		}
+	}()
+	r.TTL("no_such_category")
+}
+
+func TestParseTTLPolicyRejects(t *testing.T) {
+	cases := []struct {
+		name   string
+		raw    string
+		jitter string
+	}{
+		{name: "unknown category", raw: "no_such_category=1m"},
+		{name: "too short", raw: "user=500ms"},
+		{name: "too long", raw: "user=200h"},
+		{name: "not a duration", raw: "user=soon"},
+		{name: "missing duration", raw: "user"},
+		{name: "jitter too large", jitter: "0.6"},
+		{name: "negative jitter", jitter: "-0.1"},
+		{name: "jitter not a number", jitter: "some"},
+		{name: "NaN jitter", jitter: "NaN"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseTTLPolicy(tc.raw, tc.jitter); err == nil {
+				t.Fatalf("ParseTTLPolicy(%q, %q) succeeded", tc.raw, tc.jitter)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+// Package syndication renders RSS 2.0 and Atom feeds and XML sitemaps from plain descriptions of
+// their entries.
+package syndication
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Content types feeds and sitemaps are served with.
+const (
+	ContentTypeRSS     = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom    = "application/atom+xml; charset=utf-8"
+	ContentTypeSitemap = "application/xml; charset=utf-8"
+)
+
+// MaxSitemapURLs is the most URLs a single sitemap may list.
+const MaxSitemapURLs = 50000
+
+// Feed describes a feed independently of its format.
+type Feed struct {
+	Title       string
+	Link        string // page the feed is about
+	Self        string // URL the feed is served at
+	Description string
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item is an entry of a feed. Link doubles as its permanent ID.
+type Item struct {
+	Title       string
+	Link        string
+	Description string // plain text summary
+	Content     string // full HTML content
+	Author      string
+	Categories  []string
+	Published   time.Time
+	Updated     time.Time
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Self          atomLink  `xml:"atom:link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	Description string   `xml:"description,omitempty"`
+	Content     *cdata   `xml:"content:encoded,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// RSS renders the feed as RSS 2.0, with the full content of items in content:encoded.
+func (f *Feed) RSS() ([]byte, error) {
+	channel := rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Self:        atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+		Description: f.Description,
+		Items:       make([]rssItem, len(f.Items)),
+	}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for i, item := range f.Items {
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{IsPermaLink: true, Value: item.Link},
+			Description: item.Description,
+			Creator:     item.Author,
+			Categories:  item.Categories,
+		}
+		if item.Content != "" {
+			entry.Content = &cdata{Value: item.Content}
+		}
+		if !item.Published.IsZero() {
+			entry.PubDate = item.Published.UTC().Format(time.RFC1123Z)
+		}
+		channel.Items[i] = entry
+	}
+	return encode(rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: channel,
+	})
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    *atomContent   `xml:"content,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Atom renders the feed as an Atom 1.0 feed.
+func (f *Feed) Atom() ([]byte, error) {
+	feed := atomFeed{
+		ID:       f.Self,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  atomTime(f.Updated),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]atomEntry, len(f.Items)),
+	}
+	for i, item := range f.Items {
+		updated := item.Updated
+		if updated.IsZero() {
+			updated = item.Published
+		}
+		entry := atomEntry{
+			ID:      item.Link,
+			Title:   item.Title,
+			Link:    atomLink{Href: item.Link, Rel: "alternate", Type: "text/html"},
+			Updated: atomTime(updated),
+			Summary: item.Description,
+		}
+		if !item.Published.IsZero() {
+			entry.Published = atomTime(item.Published)
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		if item.Content != "" {
+			entry.Content = &atomContent{Type: "html", Value: item.Content}
+		}
+		feed.Entries[i] = entry
+	}
+	return encode(feed)
+}
+
+// atomTime formats t as an RFC 3339 timestamp in UTC.
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// URL is an entry of a sitemap.
+type URL struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   float64
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string  `xml:"loc"`
+	LastMod    string  `xml:"lastmod,omitempty"`
+	ChangeFreq string  `xml:"changefreq,omitempty"`
+	Priority   float64 `xml:"priority,omitempty"`
+}
+
+// Sitemap renders urls as an XML sitemap, keeping the first MaxSitemapURLs.
+func Sitemap(urls []URL) ([]byte, error) {
+	if len(urls) > MaxSitemapURLs {
+		urls = urls[:MaxSitemapURLs]
+	}
+	set := urlSet{URLs: make([]sitemapURL, len(urls))}
+	for i, u := range urls {
+		entry := sitemapURL{Loc: u.Loc, ChangeFreq: u.ChangeFreq, Priority: u.Priority}
+		if !u.LastMod.IsZero() {
+			entry.LastMod = u.LastMod.UTC().Format("2006-01-02")
+		}
+		set.URLs[i] = entry
+	}
+	return encode(set)
+}
+
+// encode marshals v as an indented XML document.
+func encode(v interface{}) ([]byte, error) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
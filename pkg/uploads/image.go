@@ -0,0 +1,149 @@
+package uploads
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	_ "image/png" // register the PNG decoder
+	"net/http"
+)
+
+// Image kinds, each with the size it is shown at.
+const (
+	KindAvatar = "avatar"
+	KindCover  = "cover"
+)
+
+const (
+	avatarSize     = 400
+	coverMaxWidth  = 1600
+	coverMaxHeight = 900
+	jpegQuality    = 85
+	// maxPixels refuses images whose decoded size would exhaust memory, whatever their file size.
+	maxPixels = 40_000_000
+)
+
+// ImageTypes are the content types accepted for images.
+var ImageTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type")
+	ErrImageTooLarge    = errors.New("image dimensions too large")
+	ErrUnknownKind      = errors.New("unknown image kind")
+)
+
+// Image is a processed image ready to be stored.
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// SniffImage returns the content type of data detected from its bytes, ignoring whatever type the
+// client claimed, and fails unless it is one of ImageTypes.
+func SniffImage(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	for _, t := range ImageTypes {
+		if contentType == t {
+			return contentType, nil
+		}
+	}
+	return contentType, ErrUnsupportedImage
+}
+
+// ProcessImage converts an uploaded image to a JPEG sized for kind: avatars are cropped to a
+// centered square, covers are scaled to fit. Images are never scaled up, transparency is
+// flattened onto white and animated GIFs keep their first frame. Re-encoding also drops any
+// metadata the original carried, such as EXIF location data.
+func ProcessImage(data []byte, kind string) (*Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	bounds := src.Bounds()
+	var crop image.Rectangle
+	var width, height int
+	switch kind {
+	case KindAvatar:
+		side := min(bounds.Dx(), bounds.Dy())
+		x := bounds.Min.X + (bounds.Dx()-side)/2
+		y := bounds.Min.Y + (bounds.Dy()-side)/2
+		crop = image.Rect(x, y, x+side, y+side)
+		width = min(side, avatarSize)
+		height = width
+	case KindCover:
+		crop = bounds
+		width, height = fit(bounds.Dx(), bounds.Dy(), coverMaxWidth, coverMaxHeight)
+	default:
+		return nil, ErrUnknownKind
+	}
+
+	// Flatten onto white first, as JPEG has no transparency
+	flat := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, crop.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resize(flat, width, height), &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return &Image{Data: buf.Bytes(), ContentType: "image/jpeg", Width: width, Height: height}, nil
+}
+
+// fit scales w×h down to fit within maxW×maxH, keeping the aspect ratio.
+func fit(w, h, maxW, maxH int) (int, int) {
+	if w <= maxW && h <= maxH {
+		return w, h
+	}
+	if w*maxH > h*maxW {
+		return maxW, max(1, h*maxW/w)
+	}
+	return max(1, w*maxH/h), maxH
+}
+
+// resize scales src down to w×h by averaging the source pixels each target pixel covers, which
+// avoids the aliasing of nearest-neighbour sampling.
+func resize(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw == w && sh == h {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					b += uint32(p[2])
+					a += uint32(p[3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,148 @@
+package uploads
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalDriver keeps objects on the local filesystem; the API serves them itself.
+type LocalDriver struct {
+	dir        string
+	publicURL  string
+	signingKey []byte
+}
+
+// NewLocalDriver returns a driver storing objects below dir, served from publicURL. Without a
+// signing key, URLs are signed with a per-process key and stop working on restart.
+func NewLocalDriver(dir, publicURL string, signingKey []byte) (*LocalDriver, error) {
+	if dir == "" {
+		dir = "uploads"
+	}
+	if len(signingKey) == 0 {
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, fmt.Errorf("failed to generate upload signing key: %v", err)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %v", err)
+	}
+	return &LocalDriver{dir: dir, publicURL: strings.TrimSuffix(publicURL, "/"), signingKey: signingKey}, nil
+}
+
+// Put implements Driver. The object is written to a temporary file first so readers never see
+// a partial file.
+func (d *LocalDriver) Put(ctx context.Context, key, contentType string, body []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(d.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create upload directory: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return fmt.Errorf("failed to write upload: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to store upload: %v", err)
+	}
+	return nil
+}
+
+// PutStream implements Driver, writing through a temporary file like Put.
+func (d *LocalDriver) PutStream(ctx context.Context, key, contentType string, body io.ReadSeeker) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(d.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create upload directory: %v", err)
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write upload: %v", err)
+	}
+	_, err = io.Copy(f, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write upload: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to store upload: %v", err)
+	}
+	return nil
+}
+
+// Open implements Driver.
+func (d *LocalDriver) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(d.dir, filepath.FromSlash(key)))
+}
+
+// Delete implements Driver.
+func (d *LocalDriver) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(d.dir, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete upload: %v", err)
+	}
+	return nil
+}
+
+// URL implements Driver.
+func (d *LocalDriver) URL(key string) string {
+	return d.publicURL + "/" + key
+}
+
+// SignedURL implements Driver; Verify checks the returned signature.
+func (d *LocalDriver) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	if ttl <= 0 || ttl > MaxSignedURLTTL {
+		return "", fmt.Errorf("signed URL lifetime must be between 0 and %s", MaxSignedURLTTL)
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", d.sign(key, expires))
+	return d.URL(key) + "?" + query.Encode(), nil
+}
+
+// Verify reports whether a signature produced by SignedURL is genuine and unexpired.
+func (d *LocalDriver) Verify(key, expires, signature string) bool {
+	at, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > at {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(d.sign(key, expires)))
+}
+
+func (d *LocalDriver) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, d.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
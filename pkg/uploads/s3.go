@@ -0,0 +1,288 @@
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3TimeFormat     = "20060102T150405Z"
+	s3DateFormat     = "20060102"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3RequestTimeout = 30 * time.Second
+)
+
+// S3Driver stores objects in a bucket of an S3-compatible service, signing requests with AWS
+// Signature Version 4. Buckets are addressed path-style, which every compatible service supports.
+type S3Driver struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+	// streams carries bodies that may take longer than s3RequestTimeout, such as data export
+	// archives; the caller's context bounds them instead.
+	streams *http.Client
+}
+
+// NewS3Driver returns a driver for bucket at endpoint, such as "https://s3.eu-west-1.amazonaws.com".
+// Without publicURL, objects are addressed through the endpoint.
+func NewS3Driver(endpoint, region, bucket, accessKey, secretKey, publicURL string) (*S3Driver, error) {
+	if endpoint == "" || bucket == "" || accessKey == "" || secretKey == "" {
+		return nil, errors.New("s3 upload driver needs an endpoint, bucket, access key and secret key")
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	if publicURL == "" {
+		publicURL = u.String() + "/" + bucket
+	}
+	return &S3Driver{
+		endpoint:  u,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		client:    &http.Client{Timeout: s3RequestTimeout},
+		streams:   &http.Client{},
+	}, nil
+}
+
+// Put implements Driver.
+func (d *S3Driver) Put(ctx context.Context, key, contentType string, body []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, d.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build s3 request: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	d.sign(req, sha256Hex(body), time.Now())
+	return d.do(d.client, req)
+}
+
+// PutStream implements Driver. The body is read twice, once to sign its hash and once to send it.
+func (d *S3Driver) PutStream(ctx context.Context, key, contentType string, body io.ReadSeeker) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return fmt.Errorf("failed to read upload: %v", err)
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read upload: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, d.objectURL(key), io.NopCloser(body))
+	if err != nil {
+		return fmt.Errorf("failed to build s3 request: %v", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", "private, no-store")
+	d.sign(req, hex.EncodeToString(hash.Sum(nil)), time.Now())
+	return d.do(d.streams, req)
+}
+
+// Open implements Driver.
+func (d *S3Driver) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.objectURL(key), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build s3 request: %v", err)
+	}
+	d.sign(req, sha256Hex(nil), time.Now())
+	res, err := d.streams.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("s3 answered %s", res.Status)
+	}
+	return res.Body, nil
+}
+
+// Delete implements Driver.
+func (d *S3Driver) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, d.objectURL(key), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build s3 request: %v", err)
+	}
+	d.sign(req, sha256Hex(nil), time.Now())
+	return d.do(d.client, req)
+}
+
+// URL implements Driver.
+func (d *S3Driver) URL(key string) string {
+	return d.publicURL + "/" + key
+}
+
+// SignedURL implements Driver with a presigned GET request.
+func (d *S3Driver) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	if ttl <= 0 || ttl > MaxSignedURLTTL {
+		return "", fmt.Errorf("signed URL lifetime must be between 0 and %s", MaxSignedURLTTL)
+	}
+	u, _ := url.Parse(d.objectURL(key))
+	now := time.Now().UTC()
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", d.accessKey+"/"+d.scope(now))
+	query.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	u.RawQuery = canonicalQuery(query)
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedBody,
+	}, "\n")
+	u.RawQuery += "&X-Amz-Signature=" + d.signature(canonical, now)
+	return u.String(), nil
+}
+
+func (d *S3Driver) objectURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return d.endpoint.String() + "/" + uriEncode(d.bucket) + "/" + strings.Join(segments, "/")
+}
+
+func (d *S3Driver) do(client *http.Client, req *http.Request) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 request failed: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("s3 answered %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// sign adds the Authorization header of a request whose body hashes to payloadHash.
+func (d *S3Driver) sign(req *http.Request, payloadHash string, t time.Time) {
+	t = t.UTC()
+	req.Header.Set("X-Amz-Date", t.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, d.accessKey, d.scope(t), signedHeaders, d.signature(canonical, t)))
+}
+
+func (d *S3Driver) scope(t time.Time) string {
+	return t.Format(s3DateFormat) + "/" + d.region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request as described by AWS Signature Version 4.
+func (d *S3Driver) signature(canonical string, t time.Time) string {
+	toSign := strings.Join([]string{s3Algorithm, t.Format(s3TimeFormat), d.scope(t), sha256Hex([]byte(canonical))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+d.secretKey), t.Format(s3DateFormat))
+	key = hmacSHA256(key, d.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, toSign))
+}
+
+// canonicalQuery encodes a query sorted by key with RFC 3986 escaping, as SigV4 requires.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode escapes everything but the unreserved characters of RFC 3986.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,69 @@
+// Package uploads stores user uploaded files on a configurable storage driver and prepares
+// images for the places they are shown.
+package uploads
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// MaxSignedURLTTL caps how long a signed URL stays valid; S3 refuses presigned URLs valid longer.
+const MaxSignedURLTTL = 7 * 24 * time.Hour
+
+// Driver stores objects under keys such as "avatars/<id>.jpg".
+type Driver interface {
+	// Put stores an object, replacing any object under the same key.
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	// PutStream stores an object read from body, for objects too large to hold in memory such as
+	// data export archives. Unlike Put the object is not marked cacheable.
+	PutStream(ctx context.Context, key, contentType string, body io.ReadSeeker) error
+	// Open reads an object; drivers that serve objects themselves return an error.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes an object; deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the permanent public URL of an object.
+	URL(key string) string
+	// SignedURL returns a URL granting read access to an object until ttl has passed.
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// Config selects and configures a storage driver.
+type Config struct {
+	// Driver is "local" (the default) or "s3".
+	Driver string
+	// PublicURL is the base URL objects are served from, such as a CDN in front of the bucket.
+	PublicURL string
+	// Dir is where the local driver keeps objects.
+	Dir string
+	// SigningKey signs the URLs of the local driver.
+	SigningKey []byte
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// NewDriver returns the driver selected by cfg.
+func NewDriver(cfg Config) (Driver, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", "local":
+		return NewLocalDriver(cfg.Dir, cfg.PublicURL, cfg.SigningKey)
+	case "s3":
+		return NewS3Driver(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey, cfg.PublicURL)
+	default:
+		return nil, fmt.Errorf("unknown upload driver %q", cfg.Driver)
+	}
+}
+
+// checkKey refuses keys that could escape the storage root.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "..") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+	return nil
+}
@@ -12,7 +12,9 @@ var (
 	ErrUnauthorized        = NewError(fiber.StatusUnauthorized, "Unauthorized")
 	ErrForbidden           = NewError(fiber.StatusForbidden, "Forbidden")
 	ErrNotFound            = NewError(fiber.StatusNotFound, "Resource not found")
+	ErrConflict            = NewError(fiber.StatusConflict, "Resource conflict")
 	ErrInternalServerError = NewError(fiber.StatusInternalServerError, "Internal server error")
+	ErrServiceUnavailable  = NewError(fiber.StatusServiceUnavailable, "Service temporarily unavailable")
 )
 
 // Error represents a structured error for the web app.
@@ -6,159 +6,332 @@ import (
 	"time"
 
 	"github.com/mnuddindev/devpulse/pkg/logger"
-	"gopkg.in/gomail.v2"
+	"github.com/mnuddindev/devpulse/pkg/mailer"
 )
 
-// EmailConfig holds SMTP and app settings—passed in from app config
+// EmailConfig holds the mail provider and app settings—passed in from app config
 type EmailConfig struct {
-	SMTPHost     string
-	SMTPPort     int
-	SMTPUsername string
-	SMTPPassword string
-	AppURL       string
-	FromEmail    string
+	Provider  mailer.Provider
+	AppURL    string
+	FromEmail string
+}
+
+// sampleUsername is who previews and test sends are addressed to
+const sampleUsername = "janedoe"
+
+var activationEmail = NewMailTemplate("activation", "Activate Your BlogBlaze Account", "Welcome to BlogBlaze!", `
+            <p>{{t "Hello %s," .Data.Username}}</p>
+            <p>{{t "Thanks for joining BlogBlaze—the ultimate platform for creators and readers. To get started, please activate your account using the OTP code below or click the activation link."}}</p>
+            <div class="otp">{{.Data.OTP}}</div>
+            <p style="text-align: center;">
+                <a href="{{.Data.Link}}" class="button">{{t "Activate Your Account"}}</a>
+            </p>
+            <p><strong>{{t "Instructions:"}}</strong></p>
+            <ul>
+                <li>{{t "Use the OTP code above if the link doesn’t work."}}</li>
+                <li>{{t "Enter it on the activation page:"}} <a href="{{.Data.Link}}">{{.Data.Link}}</a></li>
+                <li>{{t "This code expires in 24 hours for your security."}}</li>
+            </ul>
+            <p>{{t "If you didn’t sign up, please ignore this email or contact our support team."}}</p>
+            <p>{{t "Happy blogging!"}}</p>`, `
+{{t "Hello %s," .Data.Username}}
+
+{{t "Welcome to BlogBlaze! Your activation OTP is: %s" .Data.OTP}}
+
+{{t "Activate your account here: %s" .Data.Link}}
+
+{{t "Instructions:"}}
+- {{t "Use the OTP code above if the link doesn’t work."}}
+- {{t "Enter it on the activation page:"}} {{.AppURL}}/activate
+- {{t "This code expires in 24 hours for your security."}}
+
+{{t "If you didn’t sign up, please ignore this email or contact our support team."}}
+
+{{t "Happy blogging!"}}
+{{t "The BlogBlaze Team"}}
+© {{.Year}} BlogBlaze
+`, func(config EmailConfig) interface{} {
+	return activationData(config, sampleUsername, "sample-token", "123456")
+})
+
+func activationData(config EmailConfig, username, token, otp string) map[string]string {
+	return map[string]string{
+		"Username": username,
+		"OTP":      otp,
+		"Link":     fmt.Sprintf("%s/activate?token=%s", config.AppURL, token),
+	}
 }
 
 // SendActivationEmail sends a professional activation email with OTP and link
-func SendActivationEmail(ctx context.Context, config EmailConfig, email, username, token, otp string, logger *logger.Logger) error {
-	activationLink := fmt.Sprintf("%s/activate?token=%s", config.AppURL, token)
-
-	htmlBody := fmt.Sprintf(`
-<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Welcome to BlogBlaze</title>
-    <style>
-        body {
-            font-family: 'Arial', sans-serif;
-            background-color: #f4f4f4;
-            margin: 0;
-            padding: 0;
-            color: #333;
-        }
-        .container {
-            max-width: 600px;
-            margin: 40px auto;
-            background-color: #ffffff;
-            border-radius: 8px;
-            box-shadow: 0 2px 10px rgba(0, 0, 0, 0.1);
-            overflow: hidden;
-        }
-        .header {
-            background-color: #1a73e8;
-            padding: 20px;
-            text-align: center;
-            color: #ffffff;
-        }
-        .header h1 {
-            margin: 0;
-            font-size: 24px;
-        }
-        .content {
-            padding: 30px;
-            line-height: 1.6;
-        }
-        .otp {
-            font-size: 28px;
-            font-weight: bold;
-            color: #1a73e8;
-            text-align: center;
-            margin: 20px 0;
-        }
-        .button {
-            display: inline-block;
-            padding: 12px 24px;
-            background-color: #1a73e8;
-            color: #ffffff;
-            text-decoration: none;
-            border-radius: 5px;
-            font-weight: bold;
-            text-align: center;
-        }
-        .button:hover {
-            background-color: #1557b0;
-        }
-        .footer {
-            background-color: #f4f4f4;
-            padding: 20px;
-            text-align: center;
-            font-size: 12px;
-            color: #777;
-        }
-        .footer a {
-            color: #1a73e8;
-            text-decoration: none;
-        }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>Welcome to BlogBlaze!</h1>
-        </div>
-        <div class="content">
-            <p>Hello %s,</p>
-            <p>Thanks for joining BlogBlaze—the ultimate platform for creators and readers. To get started, please activate your account using the OTP code below or click the activation link.</p>
-            <div class="otp">%s</div>
+func SendActivationEmail(ctx context.Context, config EmailConfig, email, locale, username, token, otp string, logger *logger.Logger) error {
+	return SendTemplatedEmail(ctx, config, email, locale, activationEmail, activationData(config, username, token, otp), logger)
+}
+
+var passwordResetEmail = NewMailTemplate("password_reset", "Reset Your BlogBlaze Password", "Reset your password", `
+            <p>{{t "Hello %s," .Data.Username}}</p>
+            <p>{{t "We received a request to reset the password of your BlogBlaze account. Click the button below to choose a new one."}}</p>
+            <p style="text-align: center;">
+                <a href="{{.Data.Link}}" class="button">{{t "Reset Password"}}</a>
+            </p>
+            <p>{{t "This link can be used once and expires in %d minutes." .Data.Minutes}}</p>
+            <p>{{t "If you didn’t request a reset, you can ignore this email; your password stays unchanged."}}</p>`, `
+{{t "Hello %s," .Data.Username}}
+
+{{t "We received a request to reset the password of your BlogBlaze account."}}
+
+{{t "Reset it here: %s" .Data.Link}}
+
+{{t "This link can be used once and expires in %d minutes." .Data.Minutes}}
+{{t "If you didn’t request a reset, you can ignore this email; your password stays unchanged."}}
+
+{{t "The BlogBlaze Team"}}
+© {{.Year}} BlogBlaze
+`, func(config EmailConfig) interface{} {
+	return passwordResetData(config, sampleUsername, "sample-token", time.Hour)
+})
+
+func passwordResetData(config EmailConfig, username, token string, expiresIn time.Duration) map[string]interface{} {
+	return map[string]interface{}{
+		"Username": username,
+		"Link":     fmt.Sprintf("%s/reset-password?token=%s", config.AppURL, token),
+		"Minutes":  int(expiresIn.Minutes()),
+	}
+}
+
+// SendPasswordResetEmail sends a password reset link carrying a one-time token
+func SendPasswordResetEmail(ctx context.Context, config EmailConfig, email, locale, username, token string, expiresIn time.Duration, logger *logger.Logger) error {
+	return SendTemplatedEmail(ctx, config, email, locale, passwordResetEmail, passwordResetData(config, username, token, expiresIn), logger)
+}
+
+var mentionEmail = NewMailTemplate("mention", "You were mentioned on BlogBlaze", "You were mentioned", `
+            <p>{{t "Hello %s," .Data.Username}}</p>
+            <p>{{.Data.Message}}</p>
             <p style="text-align: center;">
-                <a href="%s" class="button">Activate Your Account</a>
+                <a href="{{.Data.Link}}" class="button">{{t "View Mention"}}</a>
             </p>
-            <p><strong>Instructions:</strong></p>
+            <p>{{t "You can turn these emails off in your notification settings."}}</p>`, `
+{{t "Hello %s," .Data.Username}}
+
+{{.Data.Message}}
+
+{{t "View it here: %s" .Data.Link}}
+
+{{t "You can turn these emails off in your notification settings."}}
+
+{{t "The BlogBlaze Team"}}
+© {{.Year}} BlogBlaze
+`, func(config EmailConfig) interface{} {
+	return map[string]string{
+		"Username": sampleUsername,
+		"Message":  "johndoe mentioned you in a comment",
+		"Link":     config.AppURL + "/posts/sample-post",
+	}
+})
+
+// SendMentionEmail tells a user they were mentioned, linking to where it happened
+func SendMentionEmail(ctx context.Context, config EmailConfig, email, locale, username, message, link string, logger *logger.Logger) error {
+	return SendTemplatedEmail(ctx, config, email, locale, mentionEmail, map[string]string{
+		"Username": username,
+		"Message":  message,
+		"Link":     link,
+	}, logger)
+}
+
+// DigestItem is one line of a digest email
+type DigestItem struct {
+	Title string
+	Meta  string
+	Link  string
+}
+
+// DigestContent is what a digest email lists
+type DigestContent struct {
+	Username      string
+	Period        string
+	Unread        int64
+	Notifications []DigestItem
+	Posts         []DigestItem
+	Link          string
+}
+
+var digestEmail = NewMailTemplate("digest", "Your BlogBlaze digest", "Your BlogBlaze digest", `
+            <p>{{t "Hello %s," .Data.Username}}</p>
+            <p>{{t "Here is what happened on BlogBlaze since your last %s digest." (t .Data.Period)}}</p>
+            {{if .Data.Notifications}}
+            <h3>{{if eq .Data.Unread 1}}{{t "1 unread notification"}}{{else}}{{t "%d unread notifications" .Data.Unread}}{{end}}</h3>
+            <ul>
+                {{range .Data.Notifications}}<li>{{.Title}} <span class="muted">{{.Meta}}</span></li>
+                {{end}}
+            </ul>
+            {{end}}
+            {{if .Data.Posts}}
+            <h3>{{t "New posts for you"}}</h3>
             <ul>
-                <li>Use the OTP code above if the link doesn’t work.</li>
-                <li>Enter it on the activation page at <a href="%s">%s</a>.</li>
-                <li>This code expires in 24 hours for your security.</li>
+                {{range .Data.Posts}}<li><a href="{{.Link}}">{{.Title}}</a> <span class="muted">{{.Meta}}</span></li>
+                {{end}}
             </ul>
-            <p>If you didn’t sign up, please ignore this email or contact our support team.</p>
-            <p>Happy blogging!</p>
-            <p>The BlogBlaze Team</p>
-        </div>
-        <div class="footer">
-            <p>&copy; %d BlogBlaze. All rights reserved.</p>
-            <p><a href="%s/support">Contact Support</a> | <a href="%s/privacy">Privacy Policy</a></p>
-        </div>
-    </div>
-</body>
-</html>
-`, username, otp, activationLink, activationLink, activationLink, time.Now().Year(), config.AppURL, config.AppURL)
-
-	// Plain text fallback
-	textBody := fmt.Sprintf(`
-Hello %s,
-
-Welcome to BlogBlaze! Your activation OTP is: %06d
-
-Activate your account here: %s
-
-Instructions:
-- Use the OTP if the link doesn’t work.
-- Enter it at %s/activate.
-- This code expires in 24 hours.
-
-If you didn’t sign up, ignore this email or contact support@blogblaze.com.
-
-Happy blogging!
-The BlogBlaze Team
-© %d BlogBlaze
-`, username, otp, activationLink, config.AppURL, time.Now().Year())
-
-	// Setup email
-	msg := gomail.NewMessage()
-	msg.SetHeader("From", config.FromEmail)
-	msg.SetHeader("To", email)
-	msg.SetHeader("Subject", "Activate Your BlogBlaze Account")
-	msg.SetBody("text/plain", textBody)
-	msg.AddAlternative("text/html", htmlBody)
-
-	// Dial and send
-	dialer := gomail.NewDialer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword)
-	if err := dialer.DialAndSend(msg); err != nil {
-		logger.Warn(ctx).WithFields("email", email).Logs(fmt.Sprintf("Failed to send activation email: %v, email: %s, user: %s", err, email, username))
-		return WrapError(err, ErrInternalServerError.Code, "Failed to send activation email")
+            {{end}}
+            <p style="text-align: center;">
+                <a href="{{.Data.Link}}" class="button">{{t "Open BlogBlaze"}}</a>
+            </p>
+            <p class="muted">{{t "You can change how often you get this email, or turn it off, in your notification settings."}}</p>`, `
+{{t "Hello %s," .Data.Username}}
+
+{{t "Here is what happened on BlogBlaze since your last %s digest." (t .Data.Period)}}
+{{if .Data.Notifications}}
+{{if eq .Data.Unread 1}}{{t "1 unread notification"}}{{else}}{{t "%d unread notifications" .Data.Unread}}{{end}}:
+{{range .Data.Notifications}}- {{.Title}} ({{.Meta}})
+{{end}}{{end}}{{if .Data.Posts}}
+{{t "New posts for you"}}:
+{{range .Data.Posts}}- {{.Title}} ({{.Meta}}): {{.Link}}
+{{end}}{{end}}
+{{t "Open BlogBlaze"}}: {{.Data.Link}}
+
+{{t "You can change how often you get this email, or turn it off, in your notification settings."}}
+
+{{t "The BlogBlaze Team"}}
+© {{.Year}} BlogBlaze
+`, func(config EmailConfig) interface{} {
+	return DigestContent{
+		Username:      sampleUsername,
+		Period:        "weekly",
+		Unread:        2,
+		Notifications: []DigestItem{{Title: "johndoe started following you", Meta: "2 days ago"}, {Title: "johndoe liked your post", Meta: "3 days ago"}},
+		Posts:         []DigestItem{{Title: "Getting started with Go", Meta: "by johndoe", Link: config.AppURL + "/posts/getting-started-with-go"}},
+		Link:          config.AppURL,
+	}
+})
+
+// SendDigestEmail sends a periodic digest of unread notifications and new posts
+func SendDigestEmail(ctx context.Context, config EmailConfig, email, locale string, digest DigestContent, logger *logger.Logger) error {
+	return SendTemplatedEmail(ctx, config, email, locale, digestEmail, digest, logger)
+}
+
+var dataExportEmail = NewMailTemplate("data_export", "Your BlogBlaze data export is ready", "Your data export is ready", `
+            <p>{{t "Hello %s," .Data.Username}}</p>
+            <p>{{t "The copy of your BlogBlaze data you asked for is ready to download."}}</p>
+            <p style="text-align: center;">
+                <a href="{{.Data.Link}}" class="button">{{t "Download Your Data"}}</a>
+            </p>
+            <p class="muted">{{t "You need to be signed in to download it. The link expires on %s." .Data.Expires}}</p>
+            <p>{{t "If you didn't request this export, please change your password."}}</p>`, `
+{{t "Hello %s," .Data.Username}}
+
+{{t "The copy of your BlogBlaze data you asked for is ready to download."}}
+{{.Data.Link}}
+
+{{t "You need to be signed in to download it. The link expires on %s." .Data.Expires}}
+
+{{t "If you didn't request this export, please change your password."}}
+
+{{t "The BlogBlaze Team"}}
+© {{.Year}} BlogBlaze
+`, func(config EmailConfig) interface{} {
+	return map[string]string{
+		"Username": sampleUsername,
+		"Link":     config.AppURL + "/settings/export?id=sample",
+		"Expires":  time.Now().AddDate(0, 0, 7).Format("2006-01-02"),
+	}
+})
+
+// SendDataExportEmail tells a user their personal data export can be downloaded
+func SendDataExportEmail(ctx context.Context, config EmailConfig, email, locale, username, link string, expires time.Time, logger *logger.Logger) error {
+	return SendTemplatedEmail(ctx, config, email, locale, dataExportEmail, map[string]string{
+		"Username": username,
+		"Link":     link,
+		"Expires":  expires.Format("2006-01-02"),
+	}, logger)
+}
+
+var badgeEarnedEmail = NewMailTemplate("badge_earned", "You earned a badge on BlogBlaze", "You earned a badge!", `
+            <p>{{t "Hello %s," .Data.Username}}</p>
+            <p>{{t "Congratulations! You were awarded the %s badge." .Data.Badge}}</p>
+            {{if .Data.Image}}<p style="text-align: center;"><img src="{{.Data.Image}}" alt="{{.Data.Badge}}" width="96" height="96"></p>{{end}}
+            <p style="text-align: center;">
+                <a href="{{.Data.Link}}" class="button">{{t "View Your Badges"}}</a>
+            </p>
+            <p class="muted">{{t "You can turn these emails off in your notification settings."}}</p>`, `
+{{t "Hello %s," .Data.Username}}
+
+{{t "Congratulations! You were awarded the %s badge." .Data.Badge}}
+
+{{t "View your badges here: %s" .Data.Link}}
+
+{{t "You can turn these emails off in your notification settings."}}
+
+{{t "The BlogBlaze Team"}}
+© {{.Year}} BlogBlaze
+`, func(config EmailConfig) interface{} {
+	return badgeEarnedData(config, sampleUsername, "First Post", "")
+})
+
+func badgeEarnedData(config EmailConfig, username, badge, image string) map[string]string {
+	return map[string]string{
+		"Username": username,
+		"Badge":    badge,
+		"Image":    image,
+		"Link":     config.AppURL + "/" + username,
+	}
+}
+
+// SendBadgeEarnedEmail congratulates a user on a badge they were awarded
+func SendBadgeEarnedEmail(ctx context.Context, config EmailConfig, email, locale, username, badge, image string, logger *logger.Logger) error {
+	return SendTemplatedEmail(ctx, config, email, locale, badgeEarnedEmail, badgeEarnedData(config, username, badge, image), logger)
+}
+
+// LoginAlert describes the sign-in a user is warned about.
+type LoginAlert struct {
+	Username string
+	// Failed marks a warning about repeated failed sign-ins rather than a successful one
+	Failed   bool
+	Time     string
+	IP       string
+	Location string
+	Device   string
+	Link     string
+}
+
+var loginAlertEmail = NewMailTemplate("login_alert", "New sign-in activity on your BlogBlaze account", "New sign-in activity", `
+            <p>{{t "Hello %s," .Data.Username}}</p>
+            {{if .Data.Failed}}<p>{{t "There were several failed attempts to sign in to your account."}}</p>{{else}}<p>{{t "Your account was signed in to from a new location or device."}}</p>{{end}}
+            <p class="muted">
+                {{t "Time: %s" .Data.Time}}<br>
+                {{t "IP address: %s" .Data.IP}}<br>
+                {{if .Data.Location}}{{t "Location: %s" .Data.Location}}<br>{{end}}
+                {{t "Device: %s" .Data.Device}}
+            </p>
+            <p style="text-align: center;">
+                <a href="{{.Data.Link}}" class="button">{{t "Review Account Activity"}}</a>
+            </p>
+            <p>{{t "If this wasn't you, change your password and sign out of your other sessions."}}</p>`, `
+{{t "Hello %s," .Data.Username}}
+
+{{if .Data.Failed}}{{t "There were several failed attempts to sign in to your account."}}{{else}}{{t "Your account was signed in to from a new location or device."}}{{end}}
+
+{{t "Time: %s" .Data.Time}}
+{{t "IP address: %s" .Data.IP}}
+{{if .Data.Location}}{{t "Location: %s" .Data.Location}}
+{{end}}{{t "Device: %s" .Data.Device}}
+
+{{t "Review your account activity here: %s" .Data.Link}}
+
+{{t "If this wasn't you, change your password and sign out of your other sessions."}}
+
+{{t "The BlogBlaze Team"}}
+© {{.Year}} BlogBlaze
+`, func(config EmailConfig) interface{} {
+	return LoginAlert{
+		Username: sampleUsername,
+		Time:     time.Now().UTC().Format("2006-01-02 15:04 MST"),
+		IP:       "203.0.113.7",
+		Location: "Dhaka, Bangladesh",
+		Device:   "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0",
+		Link:     config.AppURL + "/settings/security",
 	}
+})
 
-	logger.Info(ctx).WithFields("email", email).Logs(fmt.Sprintf("Activation email sent to: %s", email))
-	return nil
+// SendLoginAlertEmail warns a user about a sign-in from a new location or device, or about
+// repeated failed sign-ins
+func SendLoginAlertEmail(ctx context.Context, config EmailConfig, email, locale string, alert LoginAlert, logger *logger.Logger) error {
+	return SendTemplatedEmail(ctx, config, email, locale, loginAlertEmail, alert, logger)
 }
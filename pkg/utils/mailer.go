@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/mnuddindev/devpulse/pkg/i18n"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/mailer"
+)
+
+// mailLayout wraps the content block of every templated email in the shared BlogBlaze frame
+const mailLayout = `<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Subject}}</title>
+    <style>
+        body {
+            font-family: 'Arial', sans-serif;
+            background-color: #f4f4f4;
+            margin: 0;
+            padding: 0;
+            color: #333;
+        }
+        .container {
+            max-width: 600px;
+            margin: 40px auto;
+            background-color: #ffffff;
+            border-radius: 8px;
+            box-shadow: 0 2px 10px rgba(0, 0, 0, 0.1);
+            overflow: hidden;
+        }
+        .header {
+            background-color: #1a73e8;
+            padding: 20px;
+            text-align: center;
+            color: #ffffff;
+        }
+        .content {
+            padding: 30px;
+            line-height: 1.6;
+        }
+        .button {
+            display: inline-block;
+            padding: 12px 24px;
+            background-color: #1a73e8;
+            color: #ffffff;
+            text-decoration: none;
+            border-radius: 5px;
+            font-weight: bold;
+        }
+        .otp {
+            font-size: 28px;
+            font-weight: bold;
+            color: #1a73e8;
+            text-align: center;
+            margin: 20px 0;
+        }
+        .muted {
+            color: #777;
+            font-size: 13px;
+        }
+        .footer {
+            background-color: #f4f4f4;
+            padding: 20px;
+            text-align: center;
+            font-size: 12px;
+            color: #777;
+        }
+        .footer a {
+            color: #1a73e8;
+            text-decoration: none;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>{{.Heading}}</h1>
+        </div>
+        <div class="content">
+            {{template "content" .}}
+            <p>{{t "The BlogBlaze Team"}}</p>
+        </div>
+        <div class="footer">
+            <p>&copy; {{.Year}} BlogBlaze. {{t "All rights reserved."}}</p>
+            <p><a href="{{.AppURL}}/support">{{t "Contact Support"}}</a> | <a href="{{.AppURL}}/privacy">{{t "Privacy Policy"}}</a></p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+// MailTemplate is a named email whose HTML and plain-text bodies are rendered from the same data.
+// Templates read the caller's data from .Data and may use .AppURL and .Year. Text is written in
+// English and passed through t, which translates it into the recipient's locale as i18n.T does:
+// {{t "Hello %s," .Data.Username}}. The subject and heading are translated too.
+type MailTemplate struct {
+	Name    string
+	Subject string
+	Heading string
+	// Sample returns made-up data the template is previewed and test-sent with
+	Sample func(config EmailConfig) interface{}
+	html   *htmltemplate.Template
+	text   *texttemplate.Template
+}
+
+// RenderedEmail is a mail template rendered for a recipient
+type RenderedEmail struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// mailTemplates holds every template by name
+var mailTemplates = map[string]*MailTemplate{}
+
+// mailData is what mail templates are executed with
+type mailData struct {
+	Subject string
+	Heading string
+	AppURL  string
+	Year    int
+	Locale  string
+	Data    interface{}
+}
+
+// untranslated stands in for t until a template is executed for a locale.
+var untranslated = map[string]interface{}{"t": i18n.T}
+
+// NewMailTemplate parses an email template and registers it under name; htmlContent fills the
+// shared layout and textContent is the plain-text fallback. It panics on malformed templates and
+// duplicate names, so templates are built at init.
+func NewMailTemplate(name, subject, heading, htmlContent, textContent string, sample func(config EmailConfig) interface{}) *MailTemplate {
+	if _, dup := mailTemplates[name]; dup {
+		panic("mail template " + name + " is declared twice")
+	}
+	tmpl := &MailTemplate{
+		Name:    name,
+		Subject: subject,
+		Heading: heading,
+		Sample:  sample,
+		html:    htmltemplate.Must(htmltemplate.Must(htmltemplate.New("layout").Funcs(untranslated).Parse(mailLayout)).New("content").Parse(htmlContent)),
+		text:    texttemplate.Must(texttemplate.New("text").Funcs(untranslated).Parse(textContent)),
+	}
+	mailTemplates[name] = tmpl
+	return tmpl
+}
+
+// MailTemplateNames lists the names of every mail template, sorted
+func MailTemplateNames() []string {
+	names := make([]string, 0, len(mailTemplates))
+	for name := range mailTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupMailTemplate returns the mail template registered under name
+func LookupMailTemplate(name string) (*MailTemplate, bool) {
+	tmpl, ok := mailTemplates[name]
+	return tmpl, ok
+}
+
+// SendTemplatedEmail renders tmpl in locale with data and sends it to email
+func SendTemplatedEmail(ctx context.Context, config EmailConfig, email, locale string, tmpl *MailTemplate, data interface{}, logger *logger.Logger) error {
+	rendered, err := tmpl.Render(config, locale, data)
+	if err != nil {
+		return err
+	}
+	return deliverEmail(ctx, config, email, rendered, logger)
+}
+
+// Preview renders tmpl in locale with its sample data
+func (tmpl *MailTemplate) Preview(config EmailConfig, locale string) (*RenderedEmail, error) {
+	return tmpl.Render(config, locale, tmpl.Sample(config))
+}
+
+// Render returns the subject and the plain-text and HTML bodies of tmpl in locale; unsupported
+// locales fall back to i18n.Default
+func (tmpl *MailTemplate) Render(config EmailConfig, locale string, data interface{}) (*RenderedEmail, error) {
+	if !i18n.Supports(locale) {
+		locale = i18n.Default
+	}
+	t := map[string]interface{}{"t": func(message string, args ...interface{}) string {
+		return i18n.T(locale, message, args...)
+	}}
+	subject := i18n.T(locale, tmpl.Subject)
+	md := mailData{Subject: subject, Heading: i18n.T(locale, tmpl.Heading), AppURL: config.AppURL, Year: time.Now().Year(), Locale: locale, Data: data}
+
+	// The parsed templates are shared, so t is bound to the locale on a copy
+	html, err := tmpl.html.Clone()
+	if err != nil {
+		return nil, WrapError(err, ErrInternalServerError.Code, "Failed to render email")
+	}
+	text, err := tmpl.text.Clone()
+	if err != nil {
+		return nil, WrapError(err, ErrInternalServerError.Code, "Failed to render email")
+	}
+
+	var htmlBody, textBody bytes.Buffer
+	if err := html.Funcs(t).ExecuteTemplate(&htmlBody, "layout", md); err != nil {
+		return nil, WrapError(err, ErrInternalServerError.Code, "Failed to render email")
+	}
+	if err := text.Funcs(t).Execute(&textBody, md); err != nil {
+		return nil, WrapError(err, ErrInternalServerError.Code, "Failed to render email")
+	}
+	return &RenderedEmail{Subject: subject, Text: textBody.String(), HTML: htmlBody.String()}, nil
+}
+
+// deliverEmail hands a rendered email to the configured provider
+func deliverEmail(ctx context.Context, config EmailConfig, email string, rendered *RenderedEmail, logger *logger.Logger) error {
+	if config.Provider == nil {
+		return NewError(ErrInternalServerError.Code, "No mail provider configured")
+	}
+	err := config.Provider.Send(ctx, mailer.Message{
+		From:    config.FromEmail,
+		To:      email,
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+	})
+	if err != nil {
+		logger.Warn(ctx).WithFields("email", email, "provider", config.Provider.Name()).Logs(fmt.Sprintf("Failed to send email %q: %v, email: %s", rendered.Subject, err, email))
+		return WrapError(err, ErrInternalServerError.Code, "Failed to send email")
+	}
+
+	logger.Info(ctx).WithFields("email", email, "provider", config.Provider.Name()).Logs(fmt.Sprintf("Email %q sent to: %s", rendered.Subject, email))
+	return nil
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mnuddindev/devpulse/pkg/i18n"
+	"github.com/mnuddindev/devpulse/pkg/logger"
+	"github.com/mnuddindev/devpulse/pkg/mailer"
+)
+
+// recordingProvider keeps the messages it is given instead of sending them.
+type recordingProvider struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (p *recordingProvider) Name() string { return "recording" }
+
+func (p *recordingProvider) Send(ctx context.Context, msg mailer.Message) error {
+	p.sent = append(p.sent, msg)
+	return p.err
+}
+
+func testLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.NewLogger(context.Background(), logger.WithOutputDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(log.Close)
+	return log
+}
+
+func TestMailTemplatePreviews(t *testing.T) {
+	config := EmailConfig{AppURL: "https://devpulse.example"}
+	names := MailTemplateNames()
+	for _, want := range []string{"activation", "password_reset", "digest", "badge_earned"} {
+		if _, ok := LookupMailTemplate(want); !ok {
+			t.Errorf("no %s template among %v", want, names)
+		}
+	}
+
+	for _, name := range names {
+		tmpl, _ := LookupMailTemplate(name)
+		for _, locale := range i18n.Supported() {
+			t.Run(name+"/"+locale, func(t *testing.T) {
+				rendered, err := tmpl.Preview(config, locale)
+				if err != nil {
+					t.Fatalf("Preview: %v", err)
+				}
+				if rendered.Subject == "" || rendered.Text == "" || rendered.HTML == "" {
+					t.Fatalf("empty part in %+v", rendered)
+				}
+				if !strings.Contains(rendered.HTML, `<html lang="`+locale+`">`) {
+					t.Fatalf("HTML body is not in the %s layout", locale)
+				}
+				for part, body := range map[string]string{"text": rendered.Text, "HTML": rendered.HTML} {
+					if strings.Contains(body, "<no value>") || strings.Contains(body, "{{") {
+						t.Fatalf("%s body has unfilled placeholders:\n%s", part, body)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestMailTemplateUnsupportedLocale(t *testing.T) {
+	tmpl, _ := LookupMailTemplate("activation")
+	rendered, err := tmpl.Preview(EmailConfig{}, "xx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Subject != tmpl.Subject || !strings.Contains(rendered.HTML, `<html lang="`+i18n.Default+`">`) {
+		t.Fatalf("got %q in %.40q, want the %s rendering", rendered.Subject, rendered.HTML, i18n.Default)
+	}
+}
+
+func TestSendTemplatedEmail(t *testing.T) {
+	log := testLogger(t)
+	provider := &recordingProvider{}
+	config := EmailConfig{Provider: provider, AppURL: "https://devpulse.example", FromEmail: "DevPulse <no-reply@devpulse.example>"}
+
+	err := SendMentionEmail(context.Background(), config, "jane@example.com", i18n.Default, "jane", `<b>bob</b> mentioned you`, config.AppURL+"/posts/hello", log)
+	if err != nil {
+		t.Fatalf("SendMentionEmail: %v", err)
+	}
+	if len(provider.sent) != 1 {
+		t.Fatalf("%d messages sent, want 1", len(provider.sent))
+	}
+	msg := provider.sent[0]
+	if msg.From != config.FromEmail || msg.To != "jane@example.com" || msg.Subject != mentionEmail.Subject {
+		t.Fatalf("sent from %q to %q about %q", msg.From, msg.To, msg.Subject)
+	}
+	if !strings.Contains(msg.HTML, "&lt;b&gt;bob&lt;/b&gt; mentioned you") || strings.Contains(msg.HTML, "<b>bob</b>") {
+		t.Fatal("data is not escaped in the HTML body")
+	}
+	if !strings.Contains(msg.Text, "<b>bob</b> mentioned you") || !strings.Contains(msg.Text, config.AppURL+"/posts/hello") {
+		t.Fatalf("text body lacks the data:\n%s", msg.Text)
+	}
+}
+
+func TestSendTemplatedEmailFailures(t *testing.T) {
+	log := testLogger(t)
+	cases := []struct {
+		name     string
+		provider mailer.Provider
+	}{
+		{name: "no provider"},
+		{name: "provider refuses", provider: &recordingProvider{err: errors.New("mailbox unavailable")}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := SendActivationEmail(context.Background(), EmailConfig{Provider: tc.provider}, "jane@example.com", i18n.Default, "jane", "token", "123456", log)
+			var cerr *CustomError
+			if !errors.As(err, &cerr) || cerr.Code != ErrInternalServerError.Code {
+				t.Fatalf("got %v, want an internal error", err)
+			}
+		})
+	}
+}
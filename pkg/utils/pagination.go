@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Cursor marks where a page ended: the sort key and ID of its last row. Clients only ever see it
+// sealed, so they can neither forge positions nor learn how a listing is ordered.
+type Cursor struct {
+	Key string `json:"k"`
+	ID  string `json:"i"`
+}
+
+// Pagination is the paging part of a list request.
+type Pagination struct {
+	// After is the position to continue from, nil for the first page.
+	After *Cursor
+	Limit int
+	// WithTotal asks for the number of rows across all pages as well.
+	WithTotal bool
+}
+
+// CacheKey identifies the page within a cached listing. It is derived from the opened cursor
+// because sealing the same position twice gives different strings.
+func (p Pagination) CacheKey() string {
+	after := ""
+	if p.After != nil {
+		sum := sha256.Sum256([]byte(p.After.Key + "|" + p.After.ID))
+		after = hex.EncodeToString(sum[:8])
+	}
+	return "after:" + after + ":limit:" + strconv.Itoa(p.Limit) + ":total:" + strconv.FormatBool(p.WithTotal)
+}
+
+// Page is one page of a listing. Next is nil on the last page and Total is only set when it was
+// asked for.
+type Page[T any] struct {
+	Items []T     `json:"items"`
+	Next  *Cursor `json:"next,omitempty"`
+	Total *int64  `json:"total,omitempty"`
+}
+
+// NewPage builds a page from rows fetched with a limit of p.Limit+1; the extra row only tells
+// whether another page follows.
+func NewPage[T any](rows []T, p Pagination, cursor func(T) Cursor) Page[T] {
+	page := Page[T]{Items: rows}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if len(rows) > p.Limit {
+		page.Items = rows[:p.Limit]
+		next := cursor(page.Items[p.Limit-1])
+		page.Next = &next
+	}
+	return page
+}
+
+// Paginator seals and opens page cursors with AES-GCM.
+type Paginator struct {
+	key []byte
+}
+
+// NewPaginator returns a paginator sealing cursors with a 32-byte key. A nil key is replaced by a
+// random one, which keeps cursors valid only for the life of the process.
+func NewPaginator(key []byte) (*Paginator, error) {
+	if key == nil {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate cursor key: %v", err)
+		}
+	}
+	if _, err := newGCM(key); err != nil {
+		return nil, err
+	}
+	return &Paginator{key: key}, nil
+}
+
+// Parse reads ?cursor, ?limit and ?total from a request. A limit outside 1..max falls back to def.
+func (p *Paginator) Parse(c *fiber.Ctx, def, max int) (Pagination, error) {
+	page := Pagination{
+		Limit:     c.QueryInt("limit", def),
+		WithTotal: c.QueryBool("total"),
+	}
+	if page.Limit < 1 || page.Limit > max {
+		page.Limit = def
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		after, err := p.Open(raw)
+		if err != nil {
+			return Pagination{}, err
+		}
+		page.After = after
+	}
+	return page, nil
+}
+
+// Seal encodes a cursor for a client. A nil cursor seals to the empty string.
+func (p *Paginator) Seal(cursor *Cursor) (string, error) {
+	if cursor == nil {
+		return "", nil
+	}
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return "", WrapError(err, ErrInternalServerError.Code, "Failed to encode cursor")
+	}
+	sealed, err := EncryptSecret(p.key, string(raw))
+	if err != nil {
+		return "", WrapError(err, ErrInternalServerError.Code, "Failed to encode cursor")
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(sealed)
+	return base64.RawURLEncoding.EncodeToString(decoded), nil
+}
+
+// Open decodes a cursor produced by Seal.
+func (p *Paginator) Open(sealed string) (*Cursor, error) {
+	invalid := NewError(ErrBadRequest.Code, "Invalid cursor")
+	decoded, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, invalid
+	}
+	raw, err := DecryptSecret(p.key, base64.StdEncoding.EncodeToString(decoded))
+	if err != nil {
+		return nil, invalid
+	}
+	var cursor Cursor
+	if err := json.Unmarshal([]byte(raw), &cursor); err != nil || cursor.ID == "" {
+		return nil, invalid
+	}
+	return &cursor, nil
+}
@@ -6,6 +6,10 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// UnusablePassword is stored in place of a password hash for accounts that cannot sign in with a
+// password. It is not a bcrypt hash, so no password ever matches it.
+const UnusablePassword = "!unusable"
+
 // HashPassword is a function that hashes the password using bcrypt
 func HashPassword(password string) (string, error) {
 	// GenerateFromPassword returns the bcrypt hash of the password at the given cost of 10
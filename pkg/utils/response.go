@@ -3,7 +3,6 @@ package utils
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/mnuddindev/devpulse/pkg/logger"
@@ -72,18 +71,15 @@ func (b *ResponseBuilder) Send() error {
 		status = b.Err.Code
 	}
 
-	if logger, ok := b.C.Locals("logger").(*logger.Logger); ok {
+	if log := logger.FromCtx(b.C); log != nil {
 		meta := map[string]string{
 			"status":  fmt.Sprintf("%d", status),
 			"success": fmt.Sprintf("%t", b.Success),
-			"path":    b.C.Path(),
-			"method":  b.C.Method(),
-			"latency": time.Since(b.C.Context().Time()).String(),
 		}
 		if b.Success {
-			logger.Info(b.Ctx).WithMeta(meta).Logs("Response sent")
+			log.Info().WithMeta(meta).Logs("Response sent")
 		} else {
-			logger.Error(b.Ctx).WithMeta(meta).Logs(fmt.Sprintf("Error response sent: %s", b.Err.Error()))
+			log.Error().WithMeta(meta).Logs(fmt.Sprintf("Error response sent: %s", b.Err.Error()))
 		}
 	}
 
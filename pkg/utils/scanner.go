@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ScanResult reports whether a scanner flagged a file and why.
+type ScanResult struct {
+	Flagged bool
+	Reason  string
+}
+
+// ContentScanner inspects uploaded content after size and type checks pass.
+// Deployments plug in an antivirus integration; the default accepts everything.
+type ContentScanner interface {
+	Scan(ctx context.Context, filename, contentType string, content io.Reader) (ScanResult, error)
+}
+
+// NoopScanner accepts every file.
+type NoopScanner struct{}
+
+// Scan implements ContentScanner.
+func (NoopScanner) Scan(ctx context.Context, filename, contentType string, content io.Reader) (ScanResult, error) {
+	return ScanResult{}, nil
+}
+
+// StubScanner flags every file containing Signature, for tests.
+type StubScanner struct {
+	Signature []byte
+	Reason    string
+}
+
+// Scan implements ContentScanner.
+func (s StubScanner) Scan(ctx context.Context, filename, contentType string, content io.Reader) (ScanResult, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	if len(s.Signature) > 0 && bytes.Contains(data, s.Signature) {
+		return ScanResult{Flagged: true, Reason: s.Reason}, nil
+	}
+	return ScanResult{}, nil
+}
+
+// Scanner is the ContentScanner used for uploads.
+var Scanner ContentScanner = NoopScanner{}
+
+// UploadPolicy limits the size of an upload per content type.
+type UploadPolicy struct {
+	MaxSize      map[string]int64
+	DefaultLimit int64
+	// AsyncScanSize is the size above which files are scanned in the background instead of while
+	// the upload waits; zero scans every file while it waits.
+	AsyncScanSize int64
+}
+
+// ScanLater reports whether a file of size is scanned in the background.
+func (p UploadPolicy) ScanLater(size int64) bool {
+	return p.AsyncScanSize > 0 && size > p.AsyncScanSize
+}
+
+// CheckUpload validates an upload against the policy and runs the configured scanner,
+// returning a 413, 415 or 422 error when the file is rejected.
+func CheckUpload(ctx context.Context, policy UploadPolicy, filename, contentType string, size int64, content io.Reader) error {
+	if err := CheckUploadSize(policy, filename, contentType, size); err != nil {
+		return err
+	}
+
+	result, err := Scanner.Scan(ctx, filename, contentType, content)
+	if err != nil {
+		return WrapError(err, ErrInternalServerError.Code, "Failed to scan file")
+	}
+	if result.Flagged {
+		return NewError(fiber.StatusUnprocessableEntity, "File rejected by content scanner", result.Reason)
+	}
+	return nil
+}
+
+// CheckUploadSize validates an upload against the policy without scanning it, for files scanned
+// in the background. It returns a 413 or 415 error when the file is rejected.
+func CheckUploadSize(policy UploadPolicy, filename, contentType string, size int64) error {
+	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	limit, ok := policy.MaxSize[contentType]
+	if !ok {
+		if policy.DefaultLimit <= 0 {
+			return NewError(fiber.StatusUnsupportedMediaType, "Unsupported file type", contentType)
+		}
+		limit = policy.DefaultLimit
+	}
+	if size > limit {
+		return NewError(fiber.StatusRequestEntityTooLarge, "File too large", filename)
+	}
+	return nil
+}
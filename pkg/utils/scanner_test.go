@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// countingScanner wraps a scanner and counts the files it was given.
+type countingScanner struct {
+	ContentScanner
+	calls int
+}
+
+func (s *countingScanner) Scan(ctx context.Context, filename, contentType string, content io.Reader) (ScanResult, error) {
+	s.calls++
+	return s.ContentScanner.Scan(ctx, filename, contentType, content)
+}
+
+func TestCheckUpload(t *testing.T) {
+	policy := UploadPolicy{MaxSize: map[string]int64{"image/png": 16}}
+	stub := StubScanner{Signature: []byte("EICAR"), Reason: "test signature"}
+
+	cases := []struct {
+		name        string
+		contentType string
+		content     string
+		status      int
+		scans       int
+	}{
+		{name: "clean", contentType: "image/png", content: "clean image", scans: 1},
+		{name: "content type parameters are ignored", contentType: "Image/PNG; charset=binary", content: "clean image", scans: 1},
+		{name: "flagged", contentType: "image/png", content: "has EICAR in it", status: fiber.StatusUnprocessableEntity, scans: 1},
+		{name: "unsupported type is not scanned", contentType: "text/html", content: "EICAR", status: fiber.StatusUnsupportedMediaType},
+		{name: "oversized file is not scanned", contentType: "image/png", content: strings.Repeat("x", 17), status: fiber.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			scanner := &countingScanner{ContentScanner: stub}
+			previous := Scanner
+			Scanner = scanner
+			t.Cleanup(func() { Scanner = previous })
+
+			err := CheckUpload(context.Background(), policy, "file.png", tc.contentType, int64(len(tc.content)), strings.NewReader(tc.content))
+			if tc.status == 0 {
+				if err != nil {
+					t.Fatalf("CheckUpload: %v", err)
+				}
+			} else {
+				var cerr *CustomError
+				if !errors.As(err, &cerr) || cerr.Code != tc.status {
+					t.Fatalf("CheckUpload: got %v, want status %d", err, tc.status)
+				}
+			}
+			if scanner.calls != tc.scans {
+				t.Fatalf("scanner called %d times, want %d", scanner.calls, tc.scans)
+			}
+		})
+	}
+}
+
+func TestCheckUploadFlaggedReason(t *testing.T) {
+	previous := Scanner
+	Scanner = StubScanner{Signature: []byte("EICAR"), Reason: "test signature"}
+	t.Cleanup(func() { Scanner = previous })
+
+	err := CheckUpload(context.Background(), UploadPolicy{DefaultLimit: 64}, "file.bin", "application/octet-stream", 5, strings.NewReader("EICAR"))
+	var cerr *CustomError
+	if !errors.As(err, &cerr) || cerr.Details != "test signature" {
+		t.Fatalf("CheckUpload: got %v, want the scanner's reason", err)
+	}
+}
+
+func TestCheckUploadScannerError(t *testing.T) {
+	previous := Scanner
+	Scanner = failingScanner{}
+	t.Cleanup(func() { Scanner = previous })
+
+	err := CheckUpload(context.Background(), UploadPolicy{DefaultLimit: 64}, "file.bin", "application/octet-stream", 4, strings.NewReader("data"))
+	var cerr *CustomError
+	if !errors.As(err, &cerr) || cerr.Code != ErrInternalServerError.Code {
+		t.Fatalf("CheckUpload: got %v, want an internal error", err)
+	}
+}
+
+func TestScanLater(t *testing.T) {
+	cases := []struct {
+		asyncSize int64
+		size      int64
+		want      bool
+	}{
+		{asyncSize: 0, size: 1 << 30, want: false},
+		{asyncSize: 100, size: 100, want: false},
+		{asyncSize: 100, size: 101, want: true},
+	}
+	for _, tc := range cases {
+		if got := (UploadPolicy{AsyncScanSize: tc.asyncSize}).ScanLater(tc.size); got != tc.want {
+			t.Errorf("ScanLater(%d) with AsyncScanSize %d = %v, want %v", tc.size, tc.asyncSize, got, tc.want)
+		}
+	}
+}
+
+type failingScanner struct{}
+
+func (failingScanner) Scan(ctx context.Context, filename, contentType string, content io.Reader) (ScanResult, error) {
+	return ScanResult{}, errors.New("scanner unavailable")
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew accepts codes from one period before and after the current one to absorb clock drift.
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random base32 encoded 160-bit TOTP secret.
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate TOTP secret: %v", err)
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPURL returns the otpauth:// URL authenticator apps scan to enroll a secret.
+func TOTPURL(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("period", fmt.Sprint(totpPeriod))
+	params.Set("digits", fmt.Sprint(totpDigits))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// totpCode computes the code of a secret for a time step (RFC 6238 with HMAC-SHA1).
+func totpCode(secret string, step uint64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %v", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], step)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// ValidateTOTP checks a code against a secret at time t and returns the matching time step,
+// which callers can remember to reject a replay of the same code.
+func ValidateTOTP(secret, code string, t time.Time) (uint64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := uint64(t.Unix()) / totpPeriod
+	for delta := -totpSkew; delta <= totpSkew; delta++ {
+		step := current + uint64(delta)
+		expected, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateBackupCodes returns n random single-use recovery codes formatted as xxxxx-xxxxx.
+func GenerateBackupCodes(n int) ([]string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+	codes := make([]string, n)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate backup codes: %v", err)
+		}
+		for j, b := range buf {
+			buf[j] = alphabet[b&31]
+		}
+		codes[i] = string(buf[:5]) + "-" + string(buf[5:])
+	}
+	return codes, nil
+}
+
+// ParseEncryptionKey decodes a base64 encoded 32-byte AES-256 key.
+func ParseEncryptionKey(raw string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("encryption key must be base64 encoded: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// EncryptSecret seals plaintext with AES-GCM and returns the base64 encoded nonce and ciphertext.
+func EncryptSecret(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret opens a value produced by EncryptSecret.
+func DecryptSecret(key []byte, encoded string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted secret: %v", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted secret: too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
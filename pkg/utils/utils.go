@@ -3,10 +3,14 @@ package utils
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -47,6 +51,12 @@ func GenerateRandomToken(minLength, maxLength int) (string, error) {
 	return token, nil
 }
 
+// HashToken returns the hex SHA-256 digest of a token so it can be stored without the token itself.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func GenerateOTP(length int) (string, error) {
 	if length < 6 {
 		return "", fmt.Errorf("length must be at least 6")
@@ -133,3 +143,45 @@ func IsLoggedIn(c *fiber.Ctx) bool {
 	}
 	return true
 }
+
+// ParseDurations parses comma separated "name=duration" pairs (e.g. "username=720h,email=168h").
+func ParseDurations(raw string) (map[string]time.Duration, error) {
+	durations := map[string]time.Duration{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q, expected name=duration", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid duration for %q: %s", name, value)
+		}
+		durations[strings.TrimSpace(name)] = d
+	}
+	return durations, nil
+}
+
+// Slugify turns a title into a lowercase, hyphen separated slug of at most maxLen characters.
+func Slugify(title string, maxLen int) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			hyphen = false
+		case b.Len() > 0 && !hyphen:
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > maxLen {
+		slug = strings.TrimRight(slug[:maxLen], "-")
+	}
+	return slug
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader carries the signature of a delivery, "t=<unix seconds>,v1=<hex>".
+	WebhookSignatureHeader = "X-DevPulse-Signature"
+	// webhookTimeout bounds a whole delivery attempt, response included.
+	webhookTimeout = 10 * time.Second
+	// webhookResponseLimit caps how much of a response body is kept for the delivery log.
+	webhookResponseLimit = 1024
+)
+
+var errWebhookAddress = errors.New("webhook endpoint resolves to a private address")
+
+// webhookClient refuses to connect to loopback, private and link-local addresses, so endpoints
+// cannot be pointed at the internal network, and does not follow redirects.
+var webhookClient = &http.Client{
+	Timeout: webhookTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				ip := net.ParseIP(host)
+				if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+					ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+					return errWebhookAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// WebhookResponse is what an endpoint answered to a delivery.
+type WebhookResponse struct {
+	Status   int
+	Body     string
+	Duration time.Duration
+}
+
+// GenerateWebhookSecret returns a random hex encoded 256-bit signing secret prefixed with "whsec_".
+func GenerateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %v", err)
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// SignWebhook returns the signature header value of a payload sent at t. The HMAC-SHA256 covers
+// the timestamp and the body joined by a dot, so receivers can reject replayed deliveries.
+func SignWebhook(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// PostWebhook sends a signed payload to an endpoint. A response is returned whenever the endpoint
+// answered, even with an error status; err is only set when no answer was received.
+func PostWebhook(ctx context.Context, url, secret, event, deliveryID string, body []byte) (*WebhookResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "DevPulse-Webhooks/1.0")
+	req.Header.Set("X-DevPulse-Event", event)
+	req.Header.Set("X-DevPulse-Delivery", deliveryID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, time.Now(), body))
+
+	start := time.Now()
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(res.Body, webhookResponseLimit))
+	return &WebhookResponse{Status: res.StatusCode, Body: string(respBody), Duration: time.Since(start)}, nil
+}
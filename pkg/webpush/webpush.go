@@ -0,0 +1,276 @@
+// Package webpush sends Web Push messages (RFC 8030) to browser push services, encrypting payloads
+// as RFC 8291 requires and identifying the application server with VAPID (RFC 8292).
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// recordSize is the record size announced in the encryption header; a message is one record.
+	recordSize = 4096
+	// MaxPayload is the largest payload that fits in one record after the header, the padding
+	// delimiter and the authentication tag.
+	MaxPayload = recordSize - 86 - 1 - 16
+	// vapidExpiry is how long the signed VAPID token of a request is valid; push services refuse
+	// tokens valid for more than a day.
+	vapidExpiry = 12 * time.Hour
+	sendTimeout = 10 * time.Second
+)
+
+var (
+	// ErrGone is returned when the push service no longer knows the subscription, which the
+	// browser dropped or the user revoked; it should be deleted.
+	ErrGone = errors.New("push subscription is gone")
+	// ErrInvalidSubscription is returned for subscriptions whose endpoint or keys are malformed.
+	ErrInvalidSubscription = errors.New("invalid push subscription")
+
+	errPrivateAddress = errors.New("push endpoint resolves to a private address")
+	b64               = base64.RawURLEncoding
+)
+
+// Subscription is what a browser's PushManager.subscribe returns: where to send messages and the
+// keys to encrypt them with, both base64url encoded.
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// Message is a push message. TTL is how long the push service keeps it for an offline device;
+// Urgency, one of very-low, low, normal or high, lets devices save battery on unimportant ones.
+type Message struct {
+	Payload []byte
+	TTL     time.Duration
+	Urgency string
+}
+
+// Sender sends push messages on behalf of one application server.
+type Sender struct {
+	key       *ecdsa.PrivateKey
+	publicKey string
+	subject   string
+	client    *http.Client
+}
+
+// NewSender creates a sender signing with the base64url encoded P-256 private key privateKey, as
+// generated by tools such as "web-push generate-vapid-keys". subject, a mailto: or https: URL,
+// tells push services whom to contact about the traffic.
+func NewSender(privateKey, subject string) (*Sender, error) {
+	raw, err := b64.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %v", err)
+	}
+	priv, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %v", err)
+	}
+	if u, err := url.Parse(subject); err != nil || (u.Scheme != "mailto" && u.Scheme != "https") {
+		return nil, fmt.Errorf("VAPID subject must be a mailto: or https: URL")
+	}
+
+	pub := priv.PublicKey().Bytes()
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+	return &Sender{key: key, publicKey: b64.EncodeToString(pub), subject: subject, client: newClient()}, nil
+}
+
+// PublicKey is the base64url encoded key browsers pass to PushManager.subscribe as
+// applicationServerKey.
+func (s *Sender) PublicKey() string {
+	return s.publicKey
+}
+
+// Validate checks that a subscription has an HTTPS endpoint and well formed keys.
+func Validate(sub Subscription) error {
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return ErrInvalidSubscription
+	}
+	if _, err := ecdh.P256().NewPublicKey(decode(sub.P256dh)); err != nil {
+		return ErrInvalidSubscription
+	}
+	if len(decode(sub.Auth)) != 16 {
+		return ErrInvalidSubscription
+	}
+	return nil
+}
+
+// Send encrypts msg for sub and hands it to the subscription's push service.
+func (s *Sender) Send(ctx context.Context, sub Subscription, msg Message) error {
+	if err := Validate(sub); err != nil {
+		return err
+	}
+	if len(msg.Payload) > MaxPayload {
+		return fmt.Errorf("push payload of %d bytes exceeds %d", len(msg.Payload), MaxPayload)
+	}
+	body, err := encrypt(msg.Payload, decode(sub.P256dh), decode(sub.Auth))
+	if err != nil {
+		return err
+	}
+	endpoint, _ := url.Parse(sub.Endpoint)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(vapidExpiry).Unix(),
+		"sub": s.subject,
+	}).SignedString(s.key)
+	if err != nil {
+		return fmt.Errorf("failed to sign VAPID token: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build push request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(msg.TTL/time.Second)))
+	if msg.Urgency != "" {
+		req.Header.Set("Urgency", msg.Urgency)
+	}
+	req.Header.Set("Authorization", "vapid t="+token+", k="+s.publicKey)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1024))
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return ErrGone
+	case res.StatusCode >= 300:
+		return fmt.Errorf("push service answered %d", res.StatusCode)
+	}
+	return nil
+}
+
+// encrypt seals plaintext as a single aes128gcm record for the browser holding uaPublic, using a
+// fresh key pair and salt per message.
+func encrypt(plaintext, uaPublic, authSecret []byte) ([]byte, error) {
+	ua, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, ErrInvalidSubscription
+	}
+	as, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := as.ECDH(ua)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := as.PublicKey().Bytes()
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	// The input keying material mixes the shared secret with the browser's auth secret and
+	// both public keys (RFC 8291, section 3.4).
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm, err := hkdf.Key(sha256.New, shared, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 marks the last (and only) record, with no padding after it.
+	padded := append(append(make([]byte, 0, len(plaintext)+1), plaintext...), 0x02)
+	record := gcm.Seal(nil, nonce, padded, nil)
+
+	header := make([]byte, 0, 86)
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+	return append(header, record...), nil
+}
+
+// decode reads a base64url key, with or without padding as browsers differ.
+func decode(s string) []byte {
+	if raw, err := b64.DecodeString(s); err == nil {
+		return raw
+	}
+	raw, _ := base64.URLEncoding.DecodeString(s)
+	return raw
+}
+
+// newClient returns a client that refuses to connect to loopback, private and link-local
+// addresses, as endpoints come from browsers and could point at the internal network.
+func newClient() *http.Client {
+	return &http.Client{
+		Timeout: sendTimeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: 5 * time.Second,
+				Control: func(network, address string, _ syscall.RawConn) error {
+					host, _, err := net.SplitHostPort(address)
+					if err != nil {
+						return err
+					}
+					ip := net.ParseIP(host)
+					if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+						ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+						return errPrivateAddress
+					}
+					return nil
+				},
+			}).DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}